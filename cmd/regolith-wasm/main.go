@@ -0,0 +1,95 @@
+//go:build js && wasm
+
+// Command regolith-wasm is the WebAssembly build behind the npm
+// package in npm/: it renders diagrams in the browser through
+// pkg/regolith, so documentation sites can keep the pattern text as
+// the source of truth. Build it with `make wasm`.
+//
+// It sets a global regolith object with three functions and then
+// calls globalThis.regolithReady, if defined:
+//
+//	regolith.render(flavor, pattern, options) // {svg} or {error}
+//	regolith.flavors()                        // flavor names
+//	regolith.themes()                         // theme names
+//
+// options is an optional object with the fields of regolith.Options
+// in lower camel case: theme, compact, padding, fontSize, lineWidth,
+// backgroundFill, showSource, summary and language.
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/0x4d5352/regolith/pkg/regolith"
+)
+
+func main() {
+	js.Global().Set("regolith", js.ValueOf(map[string]any{
+		"render":  js.FuncOf(render),
+		"flavors": js.FuncOf(func(js.Value, []js.Value) any { return stringArray(regolith.Flavors()) }),
+		"themes":  js.FuncOf(func(js.Value, []js.Value) any { return stringArray(regolith.Themes()) }),
+	}))
+	if ready := js.Global().Get("regolithReady"); ready.Type() == js.TypeFunction {
+		ready.Invoke()
+	}
+	// Keep the Go runtime alive to serve calls from JavaScript.
+	select {}
+}
+
+// render is regolith.render. Errors are returned rather than thrown,
+// so the web component can show them in place of the diagram.
+func render(_ js.Value, args []js.Value) any {
+	if len(args) < 2 {
+		return map[string]any{"error": "render(flavor, pattern, options) needs a flavor and a pattern"}
+	}
+	p, err := regolith.Parse(args[0].String(), args[1].String())
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	var opts regolith.Options
+	if len(args) > 2 && args[2].Type() == js.TypeObject {
+		o := args[2]
+		opts = regolith.Options{
+			Theme:          stringField(o, "theme"),
+			Compact:        boolField(o, "compact"),
+			Padding:        numberField(o, "padding"),
+			FontSize:       numberField(o, "fontSize"),
+			LineWidth:      numberField(o, "lineWidth"),
+			BackgroundFill: stringField(o, "backgroundFill"),
+			ShowSource:     boolField(o, "showSource"),
+			Summary:        boolField(o, "summary"),
+			Language:       stringField(o, "language"),
+		}
+	}
+	svg, err := regolith.RenderSVG(p, &opts)
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"svg": svg}
+}
+
+func stringField(o js.Value, name string) string {
+	if v := o.Get(name); v.Type() == js.TypeString {
+		return v.String()
+	}
+	return ""
+}
+
+func boolField(o js.Value, name string) bool {
+	return o.Get(name).Truthy()
+}
+
+func numberField(o js.Value, name string) float64 {
+	if v := o.Get(name); v.Type() == js.TypeNumber {
+		return v.Float()
+	}
+	return 0
+}
+
+func stringArray(items []string) any {
+	out := make([]any, len(items))
+	for i, s := range items {
+		out[i] = s
+	}
+	return out
+}
@@ -5,19 +5,23 @@ package main
 // ================================================================================
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"strings"
 	"time"
 
 	"github.com/muesli/termenv"
 	flag "github.com/spf13/pflag"
 
 	"github.com/0x4d5352/regolith/internal/analyzer"
-	"github.com/0x4d5352/regolith/internal/flavor"
 	"github.com/0x4d5352/regolith/internal/output"
 	"github.com/0x4d5352/regolith/internal/renderer"
 )
 
+// analyzeFormats lists the --format values runAnalyze accepts.
+var analyzeFormats = []string{"json", "svg", "text"}
+
 // runAnalyze implements the `regolith analyze` subcommand. It parses the
 // pattern with the selected flavor, runs static analysis, optionally
 // benchmarks runtime performance, and outputs results in text, JSON, or
@@ -27,7 +31,7 @@ func runAnalyze(args []string, stdin io.Reader, stdout, stderr io.Writer) error
 	fs.SetOutput(stderr)
 
 	var common commonFlags
-	common.Register(fs, commonDefaults{Format: "text", Output: ""})
+	common.Register(fs, commonDefaults{Format: "text", Output: "", Formats: analyzeFormats})
 
 	var style svgStyleFlags
 	style.Register(fs)
@@ -41,6 +45,12 @@ func runAnalyze(args []string, stdin io.Reader, stdout, stderr io.Writer) error
 		"Input sizes for benchmarking")
 	severity := fs.String("severity", "info",
 		"Minimum severity: info, warning, error, critical")
+	lintConfigPath := fs.String("lint-config", "",
+		"Lint config listing the flavors in play, for portability warnings (default: .regolith-lint.yaml, .yml or .json if present)")
+	stats := fs.Bool("stats", false,
+		"Print complexity metrics (nodes, depth, branches, quantifiers, groups) instead of findings")
+	limits := fs.StringToInt("limit", nil,
+		"Fail when a metric exceeds its limit, e.g. --limit depth=3,nodes=80")
 
 	fs.Usage = func() {
 		_, _ = fmt.Fprintf(stderr, "regolith analyze - Analyze regex performance\n\n")
@@ -50,7 +60,15 @@ func runAnalyze(args []string, stdin io.Reader, stdout, stderr io.Writer) error
 		fs.PrintDefaults()
 	}
 
-	if err := fs.Parse(args[2:]); err != nil {
+	if err := parseFlags(fs, args[2:], stdout); err != nil {
+		if errors.Is(err, errConfigShown) {
+			return nil
+		}
+		return err
+	}
+	common.Output = resolveOutputPath(common.Output, common.Format)
+	if err := validateErrorFormat(common.ErrorFormat); err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
 		return err
 	}
 
@@ -58,11 +76,20 @@ func runAnalyze(args []string, stdin io.Reader, stdout, stderr io.Writer) error
 	co := termenv.NewOutput(stderr, termenv.WithProfile(profile))
 	stdoutCo := termenv.NewOutput(stdout, termenv.WithProfile(profile))
 
-	f, ok := flavor.Get(common.Flavor)
+	f, ok := lookupFlavor(common.Flavor, stderr)
 	if !ok {
 		_, _ = fmt.Fprintf(stderr, "Error: unknown flavor '%s'\n", common.Flavor)
 		return fmt.Errorf("unknown flavor: %s", common.Flavor)
 	}
+	if err := validateMetricLimits(*limits); err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+	lintOpts, err := loadLintConfig(*lintConfigPath)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
 
 	pattern, err := getInput(fs.Args(), stdin)
 	if err != nil {
@@ -73,11 +100,11 @@ func runAnalyze(args []string, stdin io.Reader, stdout, stderr io.Writer) error
 
 	parsedAST, err := f.Parse(pattern)
 	if err != nil {
-		displayParseError(stderr, pattern, err, co)
+		reportParseError(stderr, pattern, f.Name(), common.ErrorFormat, err, co)
 		return fmt.Errorf("parse error: %w", err)
 	}
 
-	report := analyzer.Analyze(parsedAST, pattern, f.Name(), f.SupportedFeatures())
+	report := analyzer.AnalyzeWith(parsedAST, pattern, f.Name(), f.SupportedFeatures(), lintOpts)
 
 	if *benchmark {
 		corpusTypes := resolveCorpusTypes(*corpus)
@@ -96,6 +123,18 @@ func runAnalyze(args []string, stdin io.Reader, stdout, stderr io.Writer) error
 	minSev := parseSeverity(*severity)
 	report.Findings = filterBySeverity(report.Findings, minSev)
 
+	// Metrics go out before the limits are checked, so a failing CI
+	// job still shows every count.
+	if *stats {
+		if err := writeMetrics(report, &common, stdout, stderr, co); err != nil {
+			return err
+		}
+		return checkMetricLimits(report.Metrics, *limits, stderr)
+	}
+	if err := checkMetricLimits(report.Metrics, *limits, stderr); err != nil {
+		return err
+	}
+
 	switch common.Format {
 	case "text":
 		// Text mode: ANSI to stdout, Markdown to file. The termenv
@@ -119,17 +158,67 @@ func runAnalyze(args []string, stdin io.Reader, stdout, stderr io.Writer) error
 		_, _ = fmt.Fprintln(stdout, jsonStr)
 
 	case "svg":
-		return renderAndWriteSVG(fs, &common, &style, stdout, stderr, co,
-			func(r *renderer.Renderer) string { return r.RenderAnnotated(parsedAST, report) })
+		return renderAndWriteSVG(fs, &common, &style, pattern, f.Name(), stdout, stderr, co,
+			func(r *renderer.Renderer) string {
+				r.Flavor, r.Language = f, common.Lang
+				return r.RenderAnnotated(parsedAST, report)
+			})
 
 	default:
-		_, _ = fmt.Fprintf(stderr, "Error: unknown format %q\nAvailable: json, svg, text\n", common.Format)
+		_, _ = fmt.Fprintf(stderr, "Error: unknown format %q\nAvailable: %s\n", common.Format, strings.Join(analyzeFormats, ", "))
 		return fmt.Errorf("unknown format: %s", common.Format)
 	}
 
 	return nil
 }
 
+// writeMetrics writes the complexity metrics of report for --stats.
+func writeMetrics(report *analyzer.AnalysisReport, common *commonFlags, stdout, stderr io.Writer, co *termenv.Output) error {
+	switch common.Format {
+	case "text":
+		return writeTextOrStdout(output.RenderMetricsText(report, common.Output != ""), common.Output, stdout, co)
+	case "json":
+		jsonStr, err := output.RenderMetricsJSON(report)
+		if err != nil {
+			return fmt.Errorf("json render: %w", err)
+		}
+		_, _ = fmt.Fprintln(stdout, jsonStr)
+		return nil
+	}
+	_, _ = fmt.Fprintf(stderr, "Error: unknown format %q for --stats\nAvailable: json, text\n", common.Format)
+	return fmt.Errorf("unknown format: %s", common.Format)
+}
+
+// validateMetricLimits rejects --limit names that are not metrics.
+func validateMetricLimits(limits map[string]int) error {
+	for name := range limits {
+		if _, ok := (analyzer.Metrics{}).Metric(name); !ok {
+			return fmt.Errorf("unknown metric %q in --limit (available: %s)", name, strings.Join(analyzer.MetricNames, ", "))
+		}
+	}
+	return nil
+}
+
+// checkMetricLimits reports each metric above its --limit on stderr,
+// and fails if there is one.
+func checkMetricLimits(m *analyzer.Metrics, limits map[string]int, stderr io.Writer) error {
+	over := 0
+	for _, name := range analyzer.MetricNames {
+		limit, ok := limits[name]
+		if !ok {
+			continue
+		}
+		if v, _ := m.Metric(name); v > limit {
+			_, _ = fmt.Fprintf(stderr, "Error: %s is %d, over the limit of %d\n", name, v, limit)
+			over++
+		}
+	}
+	if over > 0 {
+		return fmt.Errorf("%d metric(s) over their limit", over)
+	}
+	return nil
+}
+
 // resolveCorpusTypes expands "all" to the full list of built-in corpus types.
 func resolveCorpusTypes(requested []string) []string {
 	for _, r := range requested {
@@ -0,0 +1,158 @@
+package main
+
+// ================================================================================
+// audit subcommand
+// ================================================================================
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/muesli/termenv"
+	flag "github.com/spf13/pflag"
+
+	"github.com/0x4d5352/regolith/internal/audit"
+	"github.com/0x4d5352/regolith/internal/output"
+	"github.com/0x4d5352/regolith/internal/scan"
+)
+
+// errAuditFailed is returned when --fail-on is set and a pattern's
+// worst finding reaches it, so CI can gate on the exit status.
+var errAuditFailed = errors.New("audit found patterns at or above --fail-on")
+
+// runAudit implements `regolith audit`: find the regexes in a source
+// tree (see internal/scan for what is recognized), analyze each with
+// the flavor its language implies, and write one JSON, HTML, or SARIF
+// report.
+func runAudit(args []string, _ io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("regolith audit", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	// Only the styling subset of commonFlags applies; each site's
+	// flavor comes from its source language.
+	var common commonFlags
+	fs.StringVar(&common.Format, "format", "json", "Report format: json, html, sarif")
+	fs.StringVarP(&common.Output, "output", "o", "", "Report file (default: stdout)")
+	fs.StringVar(&common.Theme, "theme", "", "Color theme for diagrams (e.g. light, dark, catppuccin-mocha, gruvbox-dark)")
+	fs.Float64VarP(&common.Padding, "padding", "p", 10, "Padding around diagram")
+	fs.Float64Var(&common.FontSize, "font-size", 13, "Font size in pixels")
+	fs.Float64Var(&common.LineWidth, "line-width", 1.5, "Stroke width for connectors and loops")
+	fs.StringVar(&common.Color, "color", "auto", "Color output: auto, always, never")
+
+	var style svgStyleFlags
+	style.Register(fs)
+
+	diagrams := fs.Bool("diagrams", true, "Embed an annotated diagram for each pattern (disable to speed up large audits)")
+	severity := fs.String("severity", "info", "Minimum finding severity to report: info, warning, error, critical")
+	jobs := fs.IntP("jobs", "j", 0, "How many patterns to analyze at once (default: one per CPU)")
+	lintConfigPath := fs.String("lint-config", "",
+		"Lint config listing the flavors in play, for portability warnings (default: .regolith-lint.yaml, .yml or .json if present)")
+	failOn := fs.String("fail-on", "", "Exit non-zero if any pattern has a finding at this severity or above (invalid patterns count as error)")
+
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "regolith audit - Analyze every regex in a source tree\n\n")
+		_, _ = fmt.Fprintf(stderr, "Usage:\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith audit [flags] [path...]\n\n")
+		_, _ = fmt.Fprintf(stderr, "Paths default to the current directory and are walked recursively;\n")
+		_, _ = fmt.Fprintf(stderr, "\"./...\" is accepted too. Patterns are extracted from JavaScript and\n")
+		_, _ = fmt.Fprintf(stderr, "TypeScript, Python, Java and Kotlin, C#, and PHP sources.\n\n")
+		_, _ = fmt.Fprintf(stderr, "Examples:\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith audit ./... > audit.json\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith audit --format html -o audit src\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith audit --diagrams=false --fail-on error ./...\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith audit --format sarif -o regolith.sarif ./...\n\n")
+		_, _ = fmt.Fprintf(stderr, "Flags:\n")
+		fs.PrintDefaults()
+	}
+
+	err := parseFlags(fs, args[2:], stdout)
+	if errors.Is(err, flag.ErrHelp) || errors.Is(err, errConfigShown) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if common.Format != "json" && common.Format != "html" && common.Format != "sarif" {
+		err := fmt.Errorf("unknown format %q (available: html, json, sarif)", common.Format)
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+	for _, sev := range []string{*severity, *failOn} {
+		if sev != "" && !validSeverity(sev) {
+			err := fmt.Errorf("unknown severity %q (available: info, warning, error, critical)", sev)
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return err
+		}
+	}
+	common.Output = resolveOutputPath(common.Output, common.Format)
+	co := termenv.NewOutput(stderr, termenv.WithProfile(output.ResolveColorProfile(common.Color)))
+
+	// SARIF has nowhere to put a diagram, so skip rendering them.
+	opts := audit.Options{MinSeverity: parseSeverity(*severity), Diagrams: *diagrams && common.Format != "sarif", Workers: *jobs}
+	if opts.Analyzer, err = loadLintConfig(*lintConfigPath); err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+	if opts.Diagrams {
+		if opts.Config, err = buildSVGConfig(fs, &common, &style); err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return err
+		}
+	}
+
+	roots := fs.Args()
+	if len(roots) == 0 {
+		roots = []string{"."}
+	}
+	var sites []scan.Site
+	for _, root := range roots {
+		found, err := scan.Dir(root)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return err
+		}
+		sites = append(sites, found...)
+	}
+
+	report := audit.Run(sites, opts)
+	report.SortBySeverity()
+
+	var text string
+	switch common.Format {
+	case "html":
+		text, err = output.RenderAuditHTML(report)
+	case "sarif":
+		text, err = output.RenderAuditSARIF(report, version)
+		text += "\n"
+	default:
+		text, err = output.RenderAuditJSON(report)
+		text += "\n"
+	}
+	if err != nil {
+		return err
+	}
+	if err := writeTextOrStdout(text, common.Output, stdout, co); err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+
+	if *failOn != "" {
+		threshold := parseSeverity(*failOn)
+		for _, e := range report.Entries {
+			if sev, ok := e.Worst(); ok && sev >= threshold {
+				return errAuditFailed
+			}
+		}
+	}
+	return nil
+}
+
+// validSeverity reports whether s names a severity level.
+func validSeverity(s string) bool {
+	switch s {
+	case "info", "warning", "error", "critical":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package main
+
+// ================================================================================
+// classes subcommand
+// ================================================================================
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/0x4d5352/regolith/internal/renderer"
+)
+
+// runClasses implements `regolith classes`: print every CSS class the
+// SVG renderer can emit (renderer.Classes), for external stylesheets
+// and tests that select on them.
+func runClasses(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("regolith classes", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	format := fs.String("format", "text", "Output format: text, json")
+
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "regolith classes - List the CSS classes in rendered SVG\n\n")
+		_, _ = fmt.Fprintf(stderr, "Usage:\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith classes [flags]\n\n")
+		_, _ = fmt.Fprintf(stderr, "These names are stable: new ones may be added, but existing ones are\n")
+		_, _ = fmt.Fprintf(stderr, "not renamed or removed, so stylesheets that select on them keep working.\n")
+		_, _ = fmt.Fprintf(stderr, "Node classes are set on a <g> holding a <rect> and its <text>, so\n")
+		_, _ = fmt.Fprintf(stderr, "style them as .literal rect and .literal text.\n\n")
+		_, _ = fmt.Fprintf(stderr, "Flags:\n")
+		fs.PrintDefaults()
+	}
+
+	err := parseFlags(fs, args[2:], stdout)
+	if errors.Is(err, flag.ErrHelp) || errors.Is(err, errConfigShown) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "text":
+		tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "CLASS\tKIND\tELEMENT\tDESCRIPTION")
+		for _, c := range renderer.Classes {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", c.Name, c.Kind, c.Element, c.Description)
+		}
+		return tw.Flush()
+	case "json":
+		data, err := json.MarshalIndent(renderer.Classes, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(stdout, "%s\n", data)
+		return err
+	}
+	err = fmt.Errorf("unknown format %q (available: text, json)", *format)
+	_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+	return err
+}
@@ -0,0 +1,85 @@
+package main
+
+// ================================================================================
+// color subcommand
+// ================================================================================
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/muesli/termenv"
+	flag "github.com/spf13/pflag"
+
+	"github.com/0x4d5352/regolith/internal/flavor"
+	"github.com/0x4d5352/regolith/internal/output"
+	"github.com/0x4d5352/regolith/internal/unescape"
+)
+
+// runColor implements `regolith color`: print the pattern itself with
+// ANSI syntax coloring (see output.Highlight), a quick aid where an SVG
+// viewer is not to hand. The pattern is printed even when it does not
+// parse, since an unbalanced bracket is shown in red, and the parse
+// error follows on stderr.
+func runColor(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("regolith color", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	flavorName := fs.StringP("flavor", "f", "javascript", "Regex flavor")
+	unescapeFlag := fs.BoolP("unescape", "u", false,
+		`Apply string literal unescaping before parsing (e.g., \\ becomes \)`)
+	color := fs.String("color", "auto", "Color output: auto, always, never")
+	errorFormat := fs.String("error-format", "text", "Parse error format: text, json, gnu")
+
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "regolith color - Print a pattern with syntax coloring\n\n")
+		_, _ = fmt.Fprintf(stderr, "Usage:\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith color [flags] <pattern>\n\n")
+		_, _ = fmt.Fprintf(stderr, "Escapes are green, classes cyan, quantifiers yellow and anchors\n")
+		_, _ = fmt.Fprintf(stderr, "bright cyan. Each pair of group brackets, and the | inside it,\n")
+		_, _ = fmt.Fprintf(stderr, "shares a color picked by nesting depth; a bracket with no partner\n")
+		_, _ = fmt.Fprintf(stderr, "is shown reversed in red.\n\n")
+		_, _ = fmt.Fprintf(stderr, "Flags:\n")
+		fs.PrintDefaults()
+	}
+
+	err := parseFlags(fs, args[2:], stdout)
+	if errors.Is(err, flag.ErrHelp) || errors.Is(err, errConfigShown) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := validateErrorFormat(*errorFormat); err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+
+	profile := output.ResolveColorProfile(*color)
+	co := termenv.NewOutput(stderr, termenv.WithProfile(profile))
+
+	f, ok := lookupFlavor(*flavorName, stderr)
+	if !ok {
+		_, _ = fmt.Fprintf(stderr, "Error: unknown flavor '%s'\n", *flavorName)
+		return fmt.Errorf("unknown flavor: %s", *flavorName)
+	}
+
+	pattern, err := getInput(fs.Args(), stdin)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		fs.Usage()
+		return err
+	}
+	if *unescapeFlag {
+		pattern = unescape.JavaStringLiteral(pattern)
+	}
+
+	out := termenv.NewOutput(stdout, termenv.WithProfile(profile))
+	_, _ = fmt.Fprintln(stdout, output.Highlight(flavor.Tokens(f, pattern), out))
+	if _, err := f.Parse(pattern); err != nil {
+		reportParseError(stderr, pattern, f.Name(), *errorFormat, err, co)
+		return fmt.Errorf("parse error: %w", err)
+	}
+	return nil
+}
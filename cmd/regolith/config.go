@@ -0,0 +1,238 @@
+package main
+
+// Environment variable configuration, `regolith config show`, and
+// `regolith config render`. Config files are in configfile.go.
+//
+// Container deployments configure through the environment rather than
+// argv, so every flag on every command can also be set as REGOLITH_
+// followed by the flag name in upper case with dashes as underscores:
+// --font-size is REGOLITH_FONT_SIZE, --max-concurrent is
+// REGOLITH_MAX_CONCURRENT. Precedence, highest first:
+//
+//	command-line flag > REGOLITH_* variable > config file > built-in default
+//
+// Env and config file values go through the same pflag Set path as
+// argv, so they are validated identically and count as "changed" for
+// the style overrides in svgStyleFlags.Apply.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	flag "github.com/spf13/pflag"
+)
+
+// envPrefix is prepended to every flag-derived environment variable.
+const envPrefix = "REGOLITH_"
+
+// envExempt lists flags that are never read from the environment.
+// REGOLITH_VERSION in particular is commonly set by build scripts and
+// must not turn every invocation into a version print.
+var envExempt = map[string]bool{
+	"help":        true,
+	"version":     true,
+	"show-config": true,
+}
+
+// errConfigShown stops a command after --show-config has printed the
+// resolved settings. Callers treat it like flag.ErrHelp.
+var errConfigShown = errors.New("config shown")
+
+// envName returns the environment variable that sets flag name.
+func envName(name string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// parseFlags parses args into fs, then fills every flag the command
+// line left unset from its REGOLITH_* variable, and every flag still
+// unset from the config file (see applyConfigFile). It also registers
+// the hidden --show-config flag that `regolith config show` drives;
+// when that is set the resolved settings are written to stdout and
+// errConfigShown is returned. The command's usage text gains a note
+// on the variables, so every -h mentions them.
+func parseFlags(fs *flag.FlagSet, args []string, stdout io.Writer) error {
+	showConfig := fs.Bool("show-config", false, "Print the resolved settings and exit")
+	_ = fs.MarkHidden("show-config")
+	if usage := fs.Usage; usage != nil {
+		fs.Usage = func() {
+			usage()
+			writeEnvNote(fs.Output())
+		}
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	fromEnv, err := applyEnv(fs, os.LookupEnv)
+	if err != nil {
+		_, _ = fmt.Fprintf(fs.Output(), "Error: %v\n", err)
+		return err
+	}
+	paths, fromFile, err := applyConfigFile(fs)
+	if err != nil {
+		_, _ = fmt.Fprintf(fs.Output(), "Error: %v\n", err)
+		return err
+	}
+	if *showConfig {
+		writeConfig(stdout, fs, fromEnv, fromFile, paths)
+		return errConfigShown
+	}
+	return nil
+}
+
+// writeEnvNote tells the reader of a usage text that its flags can
+// also come from the environment.
+func writeEnvNote(w io.Writer) {
+	_, _ = fmt.Fprintf(w, "\nEvery flag can also be set with a REGOLITH_* environment variable,\n")
+	_, _ = fmt.Fprintf(w, "e.g. --font-size as REGOLITH_FONT_SIZE; see regolith config show.\n")
+}
+
+// applyEnv sets each flag not given on the command line from its
+// environment variable, as reported by lookup. It returns the names of
+// the flags it set.
+func applyEnv(fs *flag.FlagSet, lookup func(string) (string, bool)) (map[string]bool, error) {
+	fromEnv := make(map[string]bool)
+	var errs []error
+	fs.VisitAll(func(f *flag.Flag) {
+		if f.Changed || envExempt[f.Name] {
+			return
+		}
+		v, ok := lookup(envName(f.Name))
+		if !ok {
+			return
+		}
+		if err := fs.Set(f.Name, v); err != nil {
+			errs = append(errs, fmt.Errorf("%s=%q: %w", envName(f.Name), v, err))
+			return
+		}
+		fromEnv[f.Name] = true
+	})
+	return fromEnv, errors.Join(errs...)
+}
+
+// writeConfig prints every setting of fs with its effective value and
+// where that value came from, after the paths of the config files read.
+func writeConfig(w io.Writer, fs *flag.FlagSet, fromEnv, fromFile map[string]bool, paths []string) {
+	for _, path := range paths {
+		_, _ = fmt.Fprintf(w, "# config file: %s\n", path)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "SETTING\tVALUE\tSOURCE\tVARIABLE")
+	fs.VisitAll(func(f *flag.Flag) {
+		if envExempt[f.Name] {
+			return
+		}
+		source := "default"
+		switch {
+		case fromEnv[f.Name]:
+			source = "env"
+		case fromFile[f.Name]:
+			source = "file"
+		case f.Changed:
+			source = "flag"
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", f.Name, displayValue(f), source, envName(f.Name))
+	})
+	_ = tw.Flush()
+}
+
+// displayValue renders a flag value for writeConfig, quoting empty
+// strings so they read as set-but-empty rather than missing.
+func displayValue(f *flag.Flag) string {
+	v := f.Value.String()
+	if v == "" || v == "[]" {
+		return `""`
+	}
+	return v
+}
+
+// configCommands are the subcommands `regolith config show` can
+// describe besides the default render command: every one that parses
+// flags with parseFlags.
+var configCommands = []string{
+	"analyze", "audit", "classes", "color", "convert", "ebnf", "explain", "flavors",
+	"hash", "match", "query", "self-update", "serve", "svgdiff", "teach", "version",
+}
+
+// runConfig implements `regolith config show [command] [flags]`: print
+// the settings the command would run with after flags and REGOLITH_*
+// variables are applied. It works by re-entering run with the hidden
+// --show-config flag, so the output always reflects the command's real
+// FlagSet and defaults.
+func runConfig(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	usage := func() {
+		_, _ = fmt.Fprintf(stderr, "regolith config - Inspect resolved configuration\n\n")
+		_, _ = fmt.Fprintf(stderr, "Usage:\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith config show [command] [flags]\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith config render [flags]\n\n")
+		_, _ = fmt.Fprintf(stderr, "Commands: %s\n\n", strings.Join(configCommands, ", "))
+		_, _ = fmt.Fprintf(stderr, "show prints each setting with where it came from. render prints the\n")
+		_, _ = fmt.Fprintf(stderr, "complete renderer configuration the flags produce as JSON, for\n")
+		_, _ = fmt.Fprintf(stderr, "--render-config.\n\n")
+		_, _ = fmt.Fprintf(stderr, "Every flag can also be set with a REGOLITH_* environment variable,\n")
+		_, _ = fmt.Fprintf(stderr, "e.g. --font-size as REGOLITH_FONT_SIZE. Flags take precedence over\n")
+		_, _ = fmt.Fprintf(stderr, "the environment, which takes precedence over a config file\n")
+		_, _ = fmt.Fprintf(stderr, "(.regolith.toml in the working directory, regolith.toml in the user\n")
+		_, _ = fmt.Fprintf(stderr, "config directory, or the file %s names), which takes\n", configFileEnv)
+		_, _ = fmt.Fprintf(stderr, "precedence over built-in defaults.\n")
+	}
+	if len(args) >= 3 && args[2] == "render" {
+		return runConfigRender(args, stdout, stderr)
+	}
+	if len(args) < 3 || args[2] != "show" {
+		usage()
+		if len(args) >= 3 && (args[2] == "-h" || args[2] == "--help") {
+			return nil
+		}
+		return fmt.Errorf("usage: regolith config show|render [command] [flags]")
+	}
+
+	inner := []string{args[0]}
+	rest := args[3:]
+	if len(rest) > 0 {
+		for _, name := range configCommands {
+			if rest[0] == name {
+				inner = append(inner, name)
+				rest = rest[1:]
+				break
+			}
+		}
+	}
+	inner = append(inner, "--show-config")
+	inner = append(inner, rest...)
+	return run(inner, stdin, stdout, stderr)
+}
+
+// runConfigRender implements `regolith config render [flags]`: print
+// the renderer.Config that the theme, --render-config, and style flags
+// resolve to as indented JSON. Saved to a file, it is a starting point
+// for --render-config that spells out every option.
+func runConfigRender(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("regolith config render", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var common commonFlags
+	common.Register(fs, commonDefaults{Format: "svg", Formats: renderFormats})
+	var style svgStyleFlags
+	style.Register(fs)
+	if err := parseFlags(fs, args[3:], stdout); err != nil {
+		if errors.Is(err, flag.ErrHelp) || errors.Is(err, errConfigShown) {
+			return nil
+		}
+		return err
+	}
+	cfg, err := buildSVGConfig(fs, &common, &style)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(stdout, "%s\n", data)
+	return err
+}
@@ -0,0 +1,458 @@
+package main
+
+// ================================================================================
+// Config files
+// ================================================================================
+//
+// A config file sets flag defaults for a user or a project, so a team's
+// theme, colors, and dimensions live in one place instead of on every
+// command line. Keys are flag names; top-level keys apply to every
+// command that has the flag, and a table named for a command applies to
+// that command alone and wins over the top level:
+//
+//	theme = "catppuccin-mocha"
+//	font-size = 16
+//
+//	[render]
+//	format = "svg"
+//
+// The same settings can be written as YAML or JSON. A user file and a
+// project file are both read, the project's settings over the user's,
+// and both sit below the environment in the precedence order in
+// config.go:
+//
+//	command-line flag > REGOLITH_* variable > project file > user file > built-in default
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/0x4d5352/regolith/internal/manifest"
+)
+
+// configFileEnv names the config file explicitly; set to "" it turns
+// config files off.
+const configFileEnv = "REGOLITH_CONFIG"
+
+// configFileNames are the names looked for in the user config
+// directory (~/.config on Linux), and with a leading dot in the working
+// directory for a project's settings. The first name found in each
+// place is read.
+var configFileNames = []string{"regolith.toml", "regolith.yaml", "regolith.yml", "regolith.json"}
+
+// configSections are the tables a config file may hold: the render
+// command and each subcommand with flags, the ones configCommands
+// lists.
+var configSections = append([]string{"render"}, configCommands...)
+
+// projectExempt lists flags a project file cannot set: the ones naming
+// files to write or read. A project file comes with whatever checkout
+// regolith runs in, so it must not be able to point output, profiles or
+// templates at arbitrary paths. The user file and REGOLITH_CONFIG can
+// set them.
+var projectExempt = map[string]bool{
+	"output":        true,
+	"metrics-out":   true,
+	"trace-parse":   true,
+	"profile":       true,
+	"profile-out":   true,
+	"embed-font":    true,
+	"render-config": true,
+	"template":      true,
+	"input":         true,
+	"from-json":     true,
+	"manifest":      true,
+	"lint-config":   true,
+	"test":          true,
+}
+
+// configFile is a config file to read, and whether it is a project's,
+// found in the working directory, rather than the user's own.
+type configFile struct {
+	path    string
+	project bool
+}
+
+// findConfigFiles returns the config files to read, lowest precedence
+// first: the file REGOLITH_CONFIG names alone, else the user file and
+// then the project file, either of which may be missing.
+func findConfigFiles() []configFile {
+	if path, ok := os.LookupEnv(configFileEnv); ok {
+		if path == "" {
+			return nil
+		}
+		return []configFile{{path: path}}
+	}
+	firstFile := func(paths []string) string {
+		for _, path := range paths {
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path
+			}
+		}
+		return ""
+	}
+	var files []configFile
+	if dir, err := os.UserConfigDir(); err == nil {
+		var paths []string
+		for _, name := range configFileNames {
+			paths = append(paths, filepath.Join(dir, name))
+		}
+		if path := firstFile(paths); path != "" {
+			files = append(files, configFile{path: path})
+		}
+	}
+	var paths []string
+	for _, name := range configFileNames {
+		paths = append(paths, "."+name)
+	}
+	if path := firstFile(paths); path != "" {
+		files = append(files, configFile{path: path, project: true})
+	}
+	return files
+}
+
+// commandSection returns the config file table for the command fs
+// parses: "render" for the default command and for config render,
+// which resolves the render command's settings, else the subcommand.
+func commandSection(fs *flag.FlagSet) string {
+	name := strings.TrimSpace(strings.TrimPrefix(fs.Name(), "regolith"))
+	if name == "" || name == "config render" {
+		return "render"
+	}
+	return name
+}
+
+// loadConfigFile reads the settings at path for section: the top-level
+// ones overlaid with section's table, whose keys are reported in own.
+// Values are flag strings, with lists joined by commas.
+func loadConfigFile(path, section string) (settings map[string]string, own map[string]bool, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var doc map[string]any
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		doc, err = parseTOML(data)
+		if err != nil {
+			err = fmt.Errorf("%s: %w", path, err)
+		}
+	} else {
+		err = manifest.Decode(path, data, &doc)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	settings, own = make(map[string]string), make(map[string]bool)
+	var table map[string]any
+	for key, v := range doc {
+		if m, ok := v.(map[string]any); ok {
+			if !slices.Contains(configSections, key) {
+				return nil, nil, fmt.Errorf("%s: unknown command %q (available: %s)", path, key, strings.Join(configSections, ", "))
+			}
+			if key == section {
+				table = m
+			}
+			continue
+		}
+		if settings[key], err = configValue(v); err != nil {
+			return nil, nil, fmt.Errorf("%s: %s: %w", path, key, err)
+		}
+	}
+	for key, v := range table {
+		if settings[key], err = configValue(v); err != nil {
+			return nil, nil, fmt.Errorf("%s: [%s] %s: %w", path, section, key, err)
+		}
+		own[key] = true
+	}
+	return settings, own, nil
+}
+
+// configValue returns v, a decoded config value, as a flag would be
+// given it on the command line.
+func configValue(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			s, err := configValue(item)
+			if err != nil {
+				return "", err
+			}
+			parts[i] = s
+		}
+		return strings.Join(parts, ","), nil
+	}
+	return "", fmt.Errorf("unsupported value %v", v)
+}
+
+// applyConfigFile sets each flag that neither the command line nor the
+// environment set from the config files, the project file's value over
+// the user file's. It returns the paths of the files read and the
+// names of the flags they set. A key in the command's own table that
+// names no flag is an error; a top-level one is not, as it may be meant
+// for another command. A project file's projectExempt keys are ignored.
+func applyConfigFile(fs *flag.FlagSet) ([]string, map[string]bool, error) {
+	files := findConfigFiles()
+	if len(files) == 0 {
+		return nil, nil, nil
+	}
+	section := commandSection(fs)
+	type setting struct{ value, path string }
+	merged := make(map[string]setting)
+	var paths []string
+	var errs []error
+	for _, file := range files {
+		paths = append(paths, file.path)
+		settings, own, err := loadConfigFile(file.path, section)
+		if err != nil {
+			return paths, nil, err
+		}
+		for key, v := range settings {
+			if fs.Lookup(key) == nil || envExempt[key] {
+				if own[key] {
+					errs = append(errs, fmt.Errorf("%s: [%s] %s: no such setting", file.path, section, key))
+				}
+				continue
+			}
+			if file.project && projectExempt[key] {
+				continue
+			}
+			merged[key] = setting{v, file.path}
+		}
+	}
+	fromFile := make(map[string]bool)
+	for key, s := range merged {
+		if fs.Lookup(key).Changed {
+			continue
+		}
+		if err := fs.Set(key, s.value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s=%q: %w", s.path, key, s.value, err))
+			continue
+		}
+		fromFile[key] = true
+	}
+	return paths, fromFile, errors.Join(errs...)
+}
+
+// parseTOML decodes the TOML subset config files need: key = value
+// pairs, optionally under [table] headers, whose values are strings,
+// numbers, booleans, or one-line arrays of those. Dotted keys, inline
+// tables, arrays of tables, dates, and multi-line strings are rejected
+// with their line number. Numbers decode as float64, as JSON's do.
+func parseTOML(data []byte) (map[string]any, error) {
+	doc := make(map[string]any)
+	table := doc
+	for i, line := range strings.Split(string(data), "\n") {
+		num := i + 1
+		line = strings.TrimSpace(strings.TrimSuffix(line, "\r"))
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		if line[0] == '[' {
+			name, rest, ok := strings.Cut(line[1:], "]")
+			name = strings.TrimSpace(name)
+			if !ok || strings.HasPrefix(name, "[") || !isBareKey(name) || !isTOMLComment(rest) {
+				return nil, fmt.Errorf("%d: unsupported table header %s", num, line)
+			}
+			if _, dup := doc[name]; dup {
+				return nil, fmt.Errorf("%d: duplicate table %q", num, name)
+			}
+			table = make(map[string]any)
+			doc[name] = table
+			continue
+		}
+		key, rest, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok {
+			return nil, fmt.Errorf("%d: expected key = value", num)
+		}
+		if len(key) >= 2 && key[0] == '"' && key[len(key)-1] == '"' {
+			key = key[1 : len(key)-1]
+		} else if !isBareKey(key) {
+			return nil, fmt.Errorf("%d: unsupported key %s", num, key)
+		}
+		if _, dup := table[key]; dup {
+			return nil, fmt.Errorf("%d: duplicate key %q", num, key)
+		}
+		v, rest, err := tomlValue(strings.TrimSpace(rest))
+		if err == nil && !isTOMLComment(rest) {
+			err = fmt.Errorf("unexpected %s after the value", strings.TrimSpace(rest))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%d: %s: %w", num, key, err)
+		}
+		table[key] = v
+	}
+	return doc, nil
+}
+
+// isBareKey reports whether s is a TOML bare key: letters, digits,
+// dashes, and underscores.
+func isBareKey(s string) bool {
+	return s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_") == ""
+}
+
+// isTOMLComment reports whether rest, what follows a value or header,
+// is only space and an optional comment.
+func isTOMLComment(rest string) bool {
+	rest = strings.TrimSpace(rest)
+	return rest == "" || rest[0] == '#'
+}
+
+// tomlValue decodes the value at the start of s and returns it with the
+// text after it.
+func tomlValue(s string) (any, string, error) {
+	switch {
+	case s == "":
+		return nil, "", errors.New("missing value")
+	case strings.HasPrefix(s, `"""`) || strings.HasPrefix(s, "'''"):
+		return nil, "", errors.New("multi-line strings are not supported")
+	case s[0] == '"':
+		return tomlBasicString(s)
+	case s[0] == '\'':
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return nil, "", errors.New("unterminated string")
+		}
+		return s[1 : end+1], s[end+2:], nil
+	case s[0] == '[':
+		var items []any
+		rest := strings.TrimSpace(s[1:])
+		for !strings.HasPrefix(rest, "]") {
+			item, after, err := tomlValue(rest)
+			if err != nil {
+				return nil, "", err
+			}
+			if _, ok := item.([]any); ok {
+				return nil, "", errors.New("nested arrays are not supported")
+			}
+			items = append(items, item)
+			rest = strings.TrimSpace(after)
+			if strings.HasPrefix(rest, ",") {
+				rest = strings.TrimSpace(rest[1:])
+			} else if !strings.HasPrefix(rest, "]") {
+				return nil, "", errors.New("unterminated array (arrays must be on one line)")
+			}
+		}
+		return items, rest[1:], nil
+	}
+	end := strings.IndexAny(s, " \t,]#")
+	if end < 0 {
+		end = len(s)
+	}
+	word, rest := s[:end], s[end:]
+	switch word {
+	case "true":
+		return true, rest, nil
+	case "false":
+		return false, rest, nil
+	}
+	f, ok := tomlNumber(word)
+	if !ok {
+		return nil, "", fmt.Errorf("unsupported value %s (quote strings)", word)
+	}
+	return f, rest, nil
+}
+
+// tomlEscapes are the single-character escapes of a TOML basic string.
+// \e is TOML 1.1's; Go's \a, \v, \x and octal escapes are not TOML's.
+var tomlEscapes = map[byte]rune{
+	'b': '\b', 't': '\t', 'n': '\n', 'f': '\f', 'r': '\r', 'e': '\x1b', '"': '"', '\\': '\\',
+}
+
+// tomlBasicString decodes the "double-quoted" string at the start of s
+// by TOML's rules and returns it with the text after it: the escapes
+// in tomlEscapes, \uXXXX and \UXXXXXXXX naming a Unicode scalar value,
+// and no control characters but tab written as they are.
+func tomlBasicString(s string) (any, string, error) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"':
+			return b.String(), s[i+1:], nil
+		case c == '\\' && i+1 < len(s):
+			i++
+			if r, ok := tomlEscapes[s[i]]; ok {
+				b.WriteRune(r)
+				continue
+			}
+			n := 0
+			switch s[i] {
+			case 'u':
+				n = 4
+			case 'U':
+				n = 8
+			}
+			if n == 0 || i+n >= len(s) {
+				return nil, "", fmt.Errorf("invalid escape \\%c", s[i])
+			}
+			code, err := strconv.ParseUint(s[i+1:i+1+n], 16, 32)
+			if err != nil || !utf8.ValidRune(rune(code)) {
+				return nil, "", fmt.Errorf("invalid escape \\%s", s[i:i+1+n])
+			}
+			b.WriteRune(rune(code))
+			i += n
+		case c < 0x20 && c != '\t' || c == 0x7f:
+			return nil, "", fmt.Errorf("control character %q in a string", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return nil, "", errors.New("unterminated string")
+}
+
+var (
+	// tomlInteger and tomlFloat match TOML's decimal numbers: no
+	// leading zeros, and each underscore between two digits.
+	tomlInteger = regexp.MustCompile(`^[+-]?(0|[1-9](_?[0-9])*)$`)
+	tomlFloat   = regexp.MustCompile(`^[+-]?(0|[1-9](_?[0-9])*)(\.[0-9](_?[0-9])*)?([eE][+-]?[0-9](_?[0-9])*)?$`)
+	// tomlPrefixed matches TOML's unsigned hex, octal and binary
+	// integers.
+	tomlPrefixed = regexp.MustCompile(`^0(x[0-9A-Fa-f](_?[0-9A-Fa-f])*|o[0-7](_?[0-7])*|b[01](_?[01])*)$`)
+)
+
+// tomlNumber decodes word if it is a TOML integer or float, including
+// inf and nan, as a float64.
+func tomlNumber(word string) (float64, bool) {
+	digits := strings.ReplaceAll(word, "_", "")
+	switch {
+	case tomlPrefixed.MatchString(word):
+		n, err := strconv.ParseUint(digits, 0, 64)
+		return float64(n), err == nil
+	case tomlInteger.MatchString(word), tomlFloat.MatchString(word):
+		f, err := strconv.ParseFloat(digits, 64)
+		return f, err == nil
+	}
+	body := strings.TrimPrefix(strings.TrimPrefix(word, "+"), "-")
+	if len(word)-len(body) > 1 {
+		return 0, false
+	}
+	switch body {
+	case "inf":
+		if word[0] == '-' {
+			return math.Inf(-1), true
+		}
+		return math.Inf(1), true
+	case "nan":
+		return math.NaN(), true
+	}
+	return 0, false
+}
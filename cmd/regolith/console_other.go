@@ -0,0 +1,9 @@
+//go:build !windows
+
+package main
+
+// setupConsole is a no-op outside Windows: POSIX terminals already
+// speak UTF-8 and ANSI escapes.
+func setupConsole() (restore func()) {
+	return func() {}
+}
@@ -0,0 +1,41 @@
+//go:build windows
+
+package main
+
+import "golang.org/x/sys/windows"
+
+// cpUTF8 is the Windows code page identifier for UTF-8.
+const cpUTF8 = 65001
+
+// setupConsole prepares a Windows console for regolith's output. It
+// switches the output code page to UTF-8, so non-ASCII patterns echoed
+// in parse errors print intact instead of as mojibake. It also enables
+// virtual terminal processing on stdout and stderr, so legacy conhost
+// renders the ANSI colors rather than printing raw escape bytes.
+// Handles that are not consoles (pipes, files) are left alone. The
+// returned func restores the previous console state.
+func setupConsole() (restore func()) {
+	var undo []func()
+	if cp, err := windows.GetConsoleOutputCP(); err == nil && cp != cpUTF8 {
+		if windows.SetConsoleOutputCP(cpUTF8) == nil {
+			undo = append(undo, func() { _ = windows.SetConsoleOutputCP(cp) })
+		}
+	}
+	for _, h := range []windows.Handle{windows.Stdout, windows.Stderr} {
+		var mode uint32
+		if windows.GetConsoleMode(h, &mode) != nil {
+			continue
+		}
+		if mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING != 0 {
+			continue
+		}
+		if windows.SetConsoleMode(h, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING) == nil {
+			undo = append(undo, func() { _ = windows.SetConsoleMode(h, mode) })
+		}
+	}
+	return func() {
+		for i := len(undo) - 1; i >= 0; i-- {
+			undo[i]()
+		}
+	}
+}
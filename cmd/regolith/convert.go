@@ -0,0 +1,158 @@
+package main
+
+// ================================================================================
+// convert subcommand
+// ================================================================================
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/muesli/termenv"
+	flag "github.com/spf13/pflag"
+
+	"github.com/0x4d5352/regolith/internal/convert"
+	"github.com/0x4d5352/regolith/internal/output"
+	"github.com/0x4d5352/regolith/internal/unescape"
+)
+
+// runConvert implements `regolith convert`: parse a pattern under one
+// flavor and print it in another's syntax (see convert.Convert). Notes
+// about emulated constructs go to stderr; constructs the target cannot
+// express fail the command unless --lossy asks for the nearest pattern.
+func runConvert(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("regolith convert", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	from := fs.StringP("from", "f", "javascript", "Flavor the pattern is written in")
+	to := fs.StringP("to", "t", "", "Flavor to write the pattern in (required)")
+	lossy := fs.Bool("lossy", false,
+		"Print the nearest pattern even when the target cannot express every construct")
+	unescapeFlag := fs.BoolP("unescape", "u", false,
+		`Apply string literal unescaping before parsing (e.g., \\ becomes \)`)
+	outputPath := fs.StringP("output", "o", "", "Output file (default: stdout)")
+	color := fs.String("color", "auto", "Color output: auto, always, never")
+	errorFormat := fs.String("error-format", "text", "Parse error format: text, json, gnu")
+
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "regolith convert - Rewrite a pattern in another flavor's syntax\n\n")
+		_, _ = fmt.Fprintf(stderr, "Usage:\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith convert --from <flavor> --to <flavor> [flags] <pattern>\n\n")
+		_, _ = fmt.Fprintf(stderr, "Constructs the target lacks are emulated where an exact equivalent\n")
+		_, _ = fmt.Fprintf(stderr, "exists, such as a possessive a++ as (?>a+) or (?=(a+))\\1, with a note\n")
+		_, _ = fmt.Fprintf(stderr, "on stderr. Anything else is an error unless --lossy is given.\n\n")
+		_, _ = fmt.Fprintf(stderr, "Flags:\n")
+		fs.PrintDefaults()
+	}
+
+	err := parseFlags(fs, singleDashFlags(args[2:], "from", "to", "lossy"), stdout)
+	if errors.Is(err, flag.ErrHelp) || errors.Is(err, errConfigShown) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := validateErrorFormat(*errorFormat); err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+
+	co := termenv.NewOutput(stderr, termenv.WithProfile(output.ResolveColorProfile(*color)))
+
+	if *to == "" {
+		_, _ = fmt.Fprintf(stderr, "Error: --to is required\n")
+		fs.Usage()
+		return errors.New("--to is required")
+	}
+	src, ok := lookupFlavor(*from, stderr)
+	if !ok {
+		_, _ = fmt.Fprintf(stderr, "Error: unknown flavor '%s'\n", *from)
+		return fmt.Errorf("unknown flavor: %s", *from)
+	}
+	dst, ok := lookupFlavor(*to, stderr)
+	if !ok {
+		_, _ = fmt.Fprintf(stderr, "Error: unknown flavor '%s'\n", *to)
+		return fmt.Errorf("unknown flavor: %s", *to)
+	}
+
+	pattern, err := getInput(fs.Args(), stdin)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		fs.Usage()
+		return err
+	}
+	if *unescapeFlag {
+		pattern = unescape.JavaStringLiteral(pattern)
+	}
+	root, err := src.Parse(pattern)
+	if err != nil {
+		reportParseError(stderr, pattern, src.Name(), *errorFormat, err, co)
+		return fmt.Errorf("parse error: %w", err)
+	}
+	text, issues, err := convert.Convert(root, src, dst)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+
+	lost := reportConvertIssues(stderr, pattern, issues, *lossy, co)
+	if lost > 0 && !*lossy {
+		err := fmt.Errorf("%d construct(s) cannot be written in %s", lost, dst.Name())
+		_, _ = fmt.Fprintf(stderr, "Error: %v; pass --lossy to print the nearest pattern anyway\n", err)
+		return err
+	}
+	if err := writeTextOrStdout(text+"\n", *outputPath, stdout, co); err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+	return nil
+}
+
+// reportConvertIssues prints one line per issue, labelled Note for
+// exact emulations and Warning, or Error without --lossy, for the
+// rest, and returns how many were lossy.
+func reportConvertIssues(w io.Writer, pattern string, issues []convert.Issue, lossy bool, co *termenv.Output) int {
+	note := co.String("Note:").Bold().Foreground(termenv.ANSIColor(6)).String()
+	warning := co.String("Warning:").Bold().Foreground(termenv.ANSIColor(3)).String()
+	if !lossy {
+		warning = co.String("Error:").Bold().Foreground(termenv.ANSIColor(1)).String()
+	}
+	lost := 0
+	for _, issue := range issues {
+		label := note
+		if issue.Lossy {
+			label = warning
+			lost++
+		}
+		where := ""
+		if col := convert.Column(pattern, issue.Pos); col > 0 {
+			where = fmt.Sprintf("column %d: ", col)
+		}
+		_, _ = fmt.Fprintf(w, "%s %s%s\n", label, where, issue.Message)
+	}
+	return lost
+}
+
+// singleDashFlags rewrites -from style spellings of the named long
+// flags to --from, so `regolith convert -from pcre -to golang` works
+// as it would with Go's flag package; pflag would read -from as the
+// shorthands -f -r -o -m.
+func singleDashFlags(args []string, names ...string) []string {
+	out := make([]string, len(args))
+	for i, arg := range args {
+		out[i] = arg
+		if arg == "--" {
+			copy(out[i:], args[i:])
+			break
+		}
+		name, _, _ := strings.Cut(strings.TrimPrefix(arg, "-"), "=")
+		for _, n := range names {
+			if strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && name == n {
+				out[i] = "-" + arg
+			}
+		}
+	}
+	return out
+}
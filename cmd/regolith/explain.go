@@ -0,0 +1,84 @@
+package main
+
+// ================================================================================
+// explain subcommand
+// ================================================================================
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/muesli/termenv"
+	flag "github.com/spf13/pflag"
+
+	"github.com/0x4d5352/regolith/internal/output"
+	"github.com/0x4d5352/regolith/internal/unescape"
+)
+
+// runExplain implements `regolith explain`: print an indented English
+// breakdown of a pattern (see output.RenderExplain). Like ebnf, it only
+// takes the flags that affect parsing and error reporting.
+func runExplain(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("regolith explain", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	flavorName := fs.StringP("flavor", "f", "javascript", "Regex flavor")
+	unescapeFlag := fs.BoolP("unescape", "u", false,
+		`Apply string literal unescaping before parsing (e.g., \\ becomes \)`)
+	outputPath := fs.StringP("output", "o", "", "Output file (default: stdout)")
+	color := fs.String("color", "auto", "Color output: auto, always, never")
+	errorFormat := fs.String("error-format", "text", "Parse error format: text, json, gnu")
+
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "regolith explain - Describe a pattern in plain English\n\n")
+		_, _ = fmt.Fprintf(stderr, "Usage:\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith explain [flags] <pattern>\n\n")
+		_, _ = fmt.Fprintf(stderr, "Each construct gets one line, indented by nesting, with quantifiers\n")
+		_, _ = fmt.Fprintf(stderr, "folded in (\"group #1 'year': exactly 4 digits\"). The output is plain\n")
+		_, _ = fmt.Fprintf(stderr, "text, for review comments and screen readers.\n\n")
+		_, _ = fmt.Fprintf(stderr, "Flags:\n")
+		fs.PrintDefaults()
+	}
+
+	err := parseFlags(fs, args[2:], stdout)
+	if errors.Is(err, flag.ErrHelp) || errors.Is(err, errConfigShown) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := validateErrorFormat(*errorFormat); err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+
+	co := termenv.NewOutput(stderr, termenv.WithProfile(output.ResolveColorProfile(*color)))
+
+	f, ok := lookupFlavor(*flavorName, stderr)
+	if !ok {
+		_, _ = fmt.Fprintf(stderr, "Error: unknown flavor '%s'\n", *flavorName)
+		return fmt.Errorf("unknown flavor: %s", *flavorName)
+	}
+
+	pattern, err := getInput(fs.Args(), stdin)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		fs.Usage()
+		return err
+	}
+	if *unescapeFlag {
+		pattern = unescape.JavaStringLiteral(pattern)
+	}
+	root, err := f.Parse(pattern)
+	if err != nil {
+		reportParseError(stderr, pattern, f.Name(), *errorFormat, err, co)
+		return fmt.Errorf("parse error: %w", err)
+	}
+	text := output.RenderExplain(root, pattern, f.Name())
+	if err := writeTextOrStdout(text, *outputPath, stdout, co); err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+	return nil
+}
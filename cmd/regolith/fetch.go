@@ -0,0 +1,109 @@
+package main
+
+// ================================================================================
+// Remote inputs
+// ================================================================================
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// maxFetchBytes caps what is read from a URL: a pattern is a line or
+// two and a manifest a few hundred, so anything bigger is a wrong link
+// (a web page rather than the raw file) or a hostile one.
+const maxFetchBytes = 1 << 20
+
+// fetchTimeout bounds a whole request, response body included.
+const fetchTimeout = 30 * time.Second
+
+// fetchClient is the client remote inputs are fetched with; tests point
+// it at a local server.
+var fetchClient = http.DefaultClient
+
+// isURL reports whether source names an http or https URL rather than
+// a file.
+func isURL(source string) bool {
+	return strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://")
+}
+
+// readSource reads the file, http(s) URL, or ("-") stdin that source
+// names, for --input, --from-json and --manifest.
+func readSource(source string, stdin io.Reader) ([]byte, error) {
+	switch {
+	case source == "-":
+		if stdin == nil {
+			return nil, fmt.Errorf("no input on stdin")
+		}
+		return io.ReadAll(stdin)
+	case isURL(source):
+		return fetchURL(source)
+	default:
+		return os.ReadFile(source)
+	}
+}
+
+// fetchURL GETs source and returns its body, failing on any status but
+// 200 OK and on a body over maxFetchBytes. A gist page URL is fetched
+// as its raw content (see gistRawURL).
+func fetchURL(source string) ([]byte, error) {
+	u, err := url.Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gistRawURL(u).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "regolith/"+version)
+	resp, err := fetchClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", source, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFetchBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxFetchBytes {
+		return nil, fmt.Errorf("%s: larger than the %d MiB limit", source, maxFetchBytes>>20)
+	}
+	return data, nil
+}
+
+// gistRawURL returns the raw content URL for a GitHub gist page,
+// gist.github.com/user/id, which serves HTML; any other URL is returned
+// as is. The raw URL serves the gist's first file.
+func gistRawURL(u *url.URL) *url.URL {
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if u.Host != "gist.github.com" || len(parts) != 2 {
+		return u
+	}
+	raw := *u
+	raw.Host = "gist.githubusercontent.com"
+	raw.Path = "/" + parts[0] + "/" + parts[1] + "/raw"
+	raw.RawQuery, raw.Fragment = "", ""
+	return &raw
+}
+
+// sourceName is source as a file name for choosing a syntax by
+// extension: a URL's query and fragment are dropped.
+func sourceName(source string) string {
+	if !isURL(source) {
+		return source
+	}
+	name, _, _ := strings.Cut(source, "#")
+	name, _, _ = strings.Cut(name, "?")
+	return name
+}
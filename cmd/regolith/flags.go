@@ -14,14 +14,21 @@ package main
 // bring. If a third subcommand lands, revisit.
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/muesli/termenv"
 	flag "github.com/spf13/pflag"
 
+	"github.com/0x4d5352/regolith/internal/flavor"
+	"github.com/0x4d5352/regolith/internal/output"
 	"github.com/0x4d5352/regolith/internal/renderer"
 	"github.com/0x4d5352/regolith/internal/renderer/theme"
 )
@@ -30,14 +37,18 @@ import (
 // bound to the FlagSet passed to Register, so the caller can read the
 // resolved values directly off the struct after fs.Parse.
 type commonFlags struct {
-	Flavor    string
-	Format    string
-	Output    string
-	Color     string
-	Theme     string
-	Padding   float64
-	FontSize  float64
-	LineWidth float64
+	Flavor       string
+	Format       string
+	Output       string
+	Color        string
+	ErrorFormat  string
+	Theme        string
+	Padding      float64
+	FontSize     float64
+	LineWidth    float64
+	Reproducible bool
+	Lang         string
+	DPI          float64 // --format png resolution; registered by commands that offer png
 }
 
 // commonDefaults lets each command choose slightly different defaults at
@@ -46,21 +57,46 @@ type commonFlags struct {
 type commonDefaults struct {
 	Format string
 	Output string
+	// Formats lists the --format values the command accepts, for the
+	// flag's help text; it is the list the command validates against.
+	Formats []string
 }
 
 // Register binds every shared flag onto fs using the supplied defaults.
 // All other fields (flavor, color, theme, padding, etc.) use the same
 // values across both commands.
 func (c *commonFlags) Register(fs *flag.FlagSet, d commonDefaults) {
+	// The registry lists every flavor, those added through the public
+	// package included; `regolith flavors` describes them.
 	fs.StringVarP(&c.Flavor, "flavor", "f", "javascript",
-		"Regex flavor (javascript, java, dotnet, pcre, posix-bre, posix-ere, gnugrep, gnugrep-bre, gnugrep-ere)")
-	fs.StringVar(&c.Format, "format", d.Format, "Output format: text, json, svg")
-	fs.StringVarP(&c.Output, "output", "o", d.Output, "Output file path")
+		"Regex flavor ("+strings.Join(flavor.List(), ", ")+"; see regolith flavors)")
+	fs.StringVar(&c.Format, "format", d.Format, "Output format: "+strings.Join(d.Formats, ", "))
+	fs.StringVarP(&c.Output, "output", "o", d.Output, "Output file path, or - for stdout (placeholders: %n sequence, %p pattern hash, %f flavor, %t timestamp)")
 	fs.StringVar(&c.Color, "color", "auto", "Color output: auto, always, never")
+	fs.StringVar(&c.ErrorFormat, "error-format", "text", "Parse error format: text, json, gnu")
 	fs.StringVar(&c.Theme, "theme", "", "Color theme (e.g. light, dark, catppuccin-mocha, gruvbox-dark)")
 	fs.Float64VarP(&c.Padding, "padding", "p", 10, "Padding around diagram")
 	fs.Float64Var(&c.FontSize, "font-size", 13, "Font size in pixels")
 	fs.Float64Var(&c.LineWidth, "line-width", 1.5, "Stroke width for connectors and loops")
+	fs.BoolVar(&c.Reproducible, "reproducible", false,
+		"Omit the version and timestamp from the SVG provenance comment (SOURCE_DATE_EPOCH pins the timestamp instead)")
+	fs.StringVar(&c.Lang, "lang", defaultLang(),
+		"Language for flag names and descriptions in the flags panel and this help (e.g. de; default from $LC_ALL, $LC_MESSAGES, $LANG)")
+}
+
+// defaultLang is the user's language from the usual POSIX locale
+// variables, or "" for the C locale, which means the flavors' own
+// English text.
+func defaultLang() string {
+	for _, v := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if lang := os.Getenv(v); lang != "" {
+			if lang == "C" || lang == "POSIX" || strings.HasPrefix(lang, "C.") {
+				return ""
+			}
+			return lang
+		}
+	}
+	return ""
 }
 
 // svgStyleFlags captures every SVG-specific color/fill override. These
@@ -77,6 +113,23 @@ type svgStyleFlags struct {
 	AnchorFill     string
 	SubexpFill     string
 	BackgroundFill string
+	LazyLayout     string
+	LookLayout     string
+	Layout         string
+	WrapWidth      float64
+	MaxWidth       float64
+	MaxHeight      float64
+	TextLength     bool
+	EmbedFont      string
+	NumberAlts     bool
+	CheckContrast  bool
+	CornerRadius   float64
+	CurveRadius    float64
+	ConnectorWidth float64
+	HorizontalGap  float64
+	VerticalGap    float64
+	Compact        bool
+	RenderConfig   string
 }
 
 // Register binds every SVG style flag onto fs. Defaults mirror the
@@ -100,6 +153,37 @@ func (s *svgStyleFlags) Register(fs *flag.FlagSet) {
 		"Outermost subexpression box fill color (nested groups use cycling colors)")
 	fs.StringVar(&s.BackgroundFill, "background-fill", "",
 		"Solid background fill color (hex or CSS name; 'theme' uses the active theme's background; default: off)")
+	fs.StringVar(&s.LazyLayout, "lazy-layout", "arrow",
+		"How lazy quantifiers are drawn: arrow (flip the loop arrow), skip-first (exit path primary, loop dashed)")
+	fs.StringVar(&s.LookLayout, "lookaround-layout", "inline",
+		"Where lookarounds are drawn: inline (on the track), above or below (off the track on a dashed spur, so the consumed text reads continuously)")
+	fs.StringVar(&s.Layout, "layout", "row",
+		"How a long pattern is laid out: row (one track), wrap (the top-level sequence folded onto rows --wrap-width wide)")
+	fs.Float64Var(&s.WrapWidth, "wrap-width", 800,
+		"With --layout wrap, the width in pixels of the rows (setting it implies --layout wrap)")
+	fs.Float64Var(&s.MaxWidth, "max-width", 0,
+		"Scale the SVG down, text and all, to at most this many pixels wide (0: no limit)")
+	fs.Float64Var(&s.MaxHeight, "max-height", 0,
+		"Scale the SVG down, text and all, to at most this many pixels high (0: no limit)")
+	fs.BoolVar(&s.TextLength, "text-length", false,
+		"Pin each literal, escape and class item to its measured width (SVG textLength), so wider or narrower monospace fonts still fit their boxes")
+	fs.StringVar(&s.EmbedFont, "embed-font", "",
+		"Embed this WOFF2, WOFF, TTF or OTF file in the SVG as the content font, so every viewer draws the same glyphs")
+	fs.BoolVar(&s.NumberAlts, "number-alternatives", false,
+		"Number each alternation branch (1., 2., ...), matching \"Branch N\" in the text walk and analyze findings")
+	fs.BoolVar(&s.CheckContrast, "check-contrast", false,
+		"Warn when configured text/fill colors fall below the WCAG AA contrast ratio (4.5:1)")
+	fs.Float64Var(&s.CornerRadius, "corner-radius", 8, "Corner radius of node boxes")
+	fs.Float64Var(&s.CurveRadius, "curve-radius", 10,
+		"Radius of the bends in alternation branches and repeat loops")
+	fs.Float64Var(&s.ConnectorWidth, "connector-width", 20,
+		"Horizontal run where alternation branches fan out and rejoin (at least --curve-radius)")
+	fs.Float64Var(&s.HorizontalGap, "horizontal-gap", 10, "Gap between items in a sequence")
+	fs.Float64Var(&s.VerticalGap, "vertical-gap", 5, "Gap between stacked elements; alternation branches use twice this")
+	fs.BoolVar(&s.Compact, "compact", false,
+		"Minimal footprint for inline docs: smallest gaps and radii, merged literal runs, no labels on *, +, ? (explicit dimension flags still win)")
+	fs.StringVar(&s.RenderConfig, "render-config", "",
+		"JSON renderer configuration applied over the theme (write one with: regolith config render; explicit style flags still win)")
 }
 
 // Apply layers the SVG style overrides onto cfg. Only flags the user
@@ -127,6 +211,45 @@ func (s *svgStyleFlags) Apply(fs *flag.FlagSet, cfg *renderer.Config) {
 	if fs.Changed("subexp-fill") {
 		cfg.SubexpFill = s.SubexpFill
 	}
+	if fs.Changed("lazy-layout") {
+		cfg.Connector.LazyLayout = s.LazyLayout
+	}
+	if fs.Changed("lookaround-layout") {
+		cfg.LookaroundLayout = s.LookLayout
+	}
+	if fs.Changed("layout") || fs.Changed("wrap-width") {
+		cfg.WrapWidth = 0
+		if s.Layout == "wrap" || !fs.Changed("layout") {
+			cfg.WrapWidth = s.WrapWidth
+		}
+	}
+	if fs.Changed("max-width") {
+		cfg.MaxWidth = s.MaxWidth
+	}
+	if fs.Changed("max-height") {
+		cfg.MaxHeight = s.MaxHeight
+	}
+	if fs.Changed("text-length") {
+		cfg.TextLength = s.TextLength
+	}
+	if fs.Changed("number-alternatives") {
+		cfg.NumberAlternatives = s.NumberAlts
+	}
+	if fs.Changed("corner-radius") {
+		cfg.CornerRadius = s.CornerRadius
+	}
+	if fs.Changed("curve-radius") {
+		cfg.CurveRadius = s.CurveRadius
+	}
+	if fs.Changed("connector-width") {
+		cfg.ConnectorWidth = s.ConnectorWidth
+	}
+	if fs.Changed("horizontal-gap") {
+		cfg.HorizontalGap = s.HorizontalGap
+	}
+	if fs.Changed("vertical-gap") {
+		cfg.VerticalGap = s.VerticalGap
+	}
 	if fs.Changed("background-fill") {
 		// The 'theme' sentinel opts into whatever background the
 		// currently selected theme already wrote to cfg.BackgroundColor.
@@ -142,22 +265,103 @@ func (s *svgStyleFlags) Apply(fs *flag.FlagSet, cfg *renderer.Config) {
 
 // buildSVGConfig produces a fully-configured renderer.Config from the
 // shared common and style flags. The layering order matters: defaults →
-// theme → explicit overrides. A theme replaces color fields wholesale;
-// the --literal-fill / --line-color / etc. flags then tint specific
-// categories without rebuilding the whole palette.
+// theme → --render-config → --compact → explicit overrides. A theme
+// replaces color fields wholesale; a render config file then sets
+// whatever it names; --compact shrinks the dimensions, and the
+// --literal-fill / --padding / etc. flags tint specific categories or
+// set specific dimensions without rebuilding the whole palette. With a
+// render config file, --padding, --font-size and --line-width only
+// apply when given, so their defaults do not undo the file.
 func buildSVGConfig(fs *flag.FlagSet, common *commonFlags, style *svgStyleFlags) (*renderer.Config, error) {
 	cfg := renderer.DefaultConfig()
 	if err := applyTheme(cfg, common.Theme); err != nil {
 		return nil, err
 	}
-	cfg.Padding = common.Padding
-	cfg.FontSize = common.FontSize
-	cfg.CharWidth = common.FontSize * 0.6
-	cfg.Connector.StrokeWidth = common.LineWidth
+	fromFile := style.RenderConfig != ""
+	if fromFile {
+		if err := loadRenderConfig(cfg, style.RenderConfig); err != nil {
+			return nil, err
+		}
+	}
+	if style.Compact {
+		cfg.Compact()
+	}
+	if (!style.Compact && !fromFile) || fs.Changed("padding") {
+		cfg.Padding = common.Padding
+	}
+	if !fromFile || fs.Changed("font-size") {
+		cfg.FontSize = common.FontSize
+		cfg.CharWidth = common.FontSize * 0.6
+	}
+	if !fromFile || fs.Changed("line-width") {
+		cfg.Connector.StrokeWidth = common.LineWidth
+	}
 	style.Apply(fs, cfg)
+	if l := cfg.Connector.LazyLayout; l != "arrow" && l != "skip-first" {
+		return nil, fmt.Errorf("unknown lazy layout %q (available: arrow, skip-first)", l)
+	}
+	if l := cfg.LookaroundLayout; l != "inline" && l != "above" && l != "below" {
+		return nil, fmt.Errorf("unknown lookaround layout %q (available: above, below, inline)", l)
+	}
+	if style.EmbedFont != "" {
+		font, err := os.ReadFile(style.EmbedFont)
+		if err != nil {
+			return nil, err
+		}
+		if err := cfg.EmbedFont(font); err != nil {
+			return nil, fmt.Errorf("%s: %w", style.EmbedFont, err)
+		}
+	}
+	if l := style.Layout; l != "row" && l != "wrap" {
+		return nil, fmt.Errorf("unknown layout %q (available: row, wrap)", l)
+	}
+	if err := validateDimensions(cfg); err != nil {
+		return nil, err
+	}
 	return cfg, nil
 }
 
+// loadRenderConfig applies the JSON renderer configuration at path
+// over cfg.
+func loadRenderConfig(cfg *renderer.Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// validateDimensions rejects layout dimensions that would draw a broken
+// diagram: negative sizes, and alternation connectors too short for
+// their curves, which would make the branch paths double back.
+func validateDimensions(cfg *renderer.Config) error {
+	for _, d := range []struct {
+		name  string
+		value float64
+	}{
+		{"padding", cfg.Padding},
+		{"corner radius", cfg.CornerRadius},
+		{"curve radius", cfg.CurveRadius},
+		{"connector width", cfg.ConnectorWidth},
+		{"horizontal gap", cfg.HorizontalGap},
+		{"vertical gap", cfg.VerticalGap},
+		{"wrap width", cfg.WrapWidth},
+		{"max width", cfg.MaxWidth},
+		{"max height", cfg.MaxHeight},
+	} {
+		if d.value < 0 {
+			return fmt.Errorf("%s must not be negative, got %g", d.name, d.value)
+		}
+	}
+	if cfg.ConnectorWidth < cfg.CurveRadius {
+		return fmt.Errorf("connector width %g is less than the curve radius %g", cfg.ConnectorWidth, cfg.CurveRadius)
+	}
+	return nil
+}
+
 // applyTheme resolves a theme name and applies it to cfg. An empty
 // string is a no-op: DefaultConfig()'s built-in palette (which matches
 // the registered "light" theme byte-for-byte) is used as-is. Any
@@ -188,20 +392,81 @@ func patchNodeFill(cfg *renderer.Config, class, fill string) {
 	cfg.NodeStyles[class] = s
 }
 
-// requireOutputForSVG fails when the caller picked --format svg but
-// didn't supply --output. SVG blobs are multi-kilobyte; dumping them
-// to a terminal would be worse than a clear error.
+// requireOutputForSVG fails when the caller picked --format svg, png,
+// or pdf but didn't supply --output. SVG blobs are multi-kilobyte and
+// PNGs and PDFs binary; dumping them to a terminal would be worse than a
+// clear error. -o - asks for stdout explicitly, for piping.
 func requireOutputForSVG(format, output string) error {
-	if format == "svg" && output == "" {
-		return fmt.Errorf("svg format requires --output/-o (e.g., -o diagram.svg)")
+	if (format == "svg" || format == "png" || format == "pdf") && output == "" {
+		return fmt.Errorf("%s format requires --output/-o (e.g., -o diagram.%s, or -o - for stdout)", format, format)
 	}
 	return nil
 }
 
+// stdoutPath is the -o value that writes the output to stdout instead
+// of a file, so it can be piped into rsvg-convert, a clipboard helper
+// and the like. It holds one document, so it cannot take a batch.
+const stdoutPath = "-"
+
+// validateErrorFormat rejects --error-format values other than the
+// ones reportParseError understands.
+func validateErrorFormat(format string) error {
+	switch format {
+	case "text", "json", "gnu":
+		return nil
+	default:
+		return fmt.Errorf("unknown error format %q (available: gnu, json, text)", format)
+	}
+}
+
+// defaultExtensions maps each --format to the extension appended to an
+// -o path that has none. Text written to a file is Markdown.
+var defaultExtensions = map[string]string{
+	"svg":   ".svg",
+	"png":   ".png",
+	"pdf":   ".pdf",
+	"json":  ".json",
+	"text":  ".md",
+	"html":  ".html",
+	"sarif": ".sarif",
+}
+
+// resolveOutputPath normalizes an -o value before anything is written.
+// Forward slashes become the platform separator, and a path that does
+// not exist yet and whose last element has no extension gets the
+// format's default one, so "--format svg -o diagram" writes
+// diagram.svg. A path that already exists — a directory, /dev/stdout,
+// /dev/null, a named pipe, an extensionless file — is used exactly as
+// given. The extension check
+// splits on both / and \ on every platform: a Windows-style
+// "out\v1.2\diagram" pasted into a Unix shell must not be read as
+// having the extension ".2\diagram".
+func resolveOutputPath(path, format string) string {
+	if path == "" || path == stdoutPath {
+		return path
+	}
+	path = filepath.FromSlash(path)
+	base := path[strings.LastIndexAny(path, `/\`)+1:]
+	if base == "" || base == "." || base == ".." || strings.LastIndexByte(base, '.') > 0 {
+		return path
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		return path
+	}
+	return path + defaultExtensions[format]
+}
+
 // writeOutputFile writes data to path and prints a colorized confirmation
 // to stdout. Used by every command path that produces a file (SVG render,
-// markdown from --format text -o, etc).
+// markdown from --format text -o, etc). For stdoutPath the data itself
+// goes to stdout, with no confirmation to corrupt the stream.
 func writeOutputFile(path string, data []byte, stdout io.Writer, co *termenv.Output) error {
+	if path == stdoutPath {
+		if _, err := stdout.Write(data); err != nil {
+			return fmt.Errorf("writing output: %w", err)
+		}
+		return nil
+	}
 	if err := os.WriteFile(path, data, 0644); err != nil {
 		return fmt.Errorf("writing output: %w", err)
 	}
@@ -227,15 +492,37 @@ func writeTextOrStdout(text, outPath string, stdout io.Writer, co *termenv.Outpu
 // renderer config from the parsed flags, invokes the caller-supplied
 // render function (plain Render vs annotated RenderAnnotated), and
 // writes the result to disk. Keeping the dispatch here means the
-// subcommands don't drift on validation, config layering, or confirmation
-// output.
+// subcommands don't drift on validation, config layering, provenance,
+// or confirmation output.
 func renderAndWriteSVG(
 	fs *flag.FlagSet,
 	common *commonFlags,
 	style *svgStyleFlags,
+	pattern, flavorName string,
 	stdout, stderr io.Writer,
 	co *termenv.Output,
 	render func(*renderer.Renderer) string,
+) error {
+	return renderAndWriteSVGPages(fs, common, style, pattern, flavorName, stdout, stderr, co,
+		func(r *renderer.Renderer) []string { return []string{render(r)} })
+}
+
+// renderAndWriteSVGPages is renderAndWriteSVG for a render function
+// that may return several pages (see renderer.RenderPages). A single
+// page is written to the -o path as usual; otherwise page n goes to
+// that path with -n before the extension: out-1.svg, out-2.svg, ...
+// With --format png each page is rasterized at --dpi first, and with
+// --format pdf each is drawn as a vector PDF. --format html instead
+// writes all pages into one interactive page (see output.RenderHTML),
+// to stdout when there is no -o.
+func renderAndWriteSVGPages(
+	fs *flag.FlagSet,
+	common *commonFlags,
+	style *svgStyleFlags,
+	pattern, flavorName string,
+	stdout, stderr io.Writer,
+	co *termenv.Output,
+	render func(*renderer.Renderer) []string,
 ) error {
 	if err := requireOutputForSVG(common.Format, common.Output); err != nil {
 		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
@@ -246,6 +533,105 @@ func renderAndWriteSVG(
 		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
 		return err
 	}
+	if style.CheckContrast {
+		reportContrastIssues(stderr, renderer.CheckContrast(cfg), co)
+	}
+	prov, err := svgProvenance(pattern, flavorName, common.Reproducible, time.Now())
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
 	r := renderer.New(cfg)
-	return writeOutputFile(common.Output, []byte(render(r)), stdout, co)
+	r.Provenance = prov
+	// A PNG or PDF is drawn from the same element tree as the SVG text.
+	var docs []*renderer.SVG
+	if common.Format == "png" || common.Format == "pdf" {
+		r.OnDocument = func(doc *renderer.SVG) { docs = append(docs, doc) }
+	}
+	pages := render(r)
+	if common.Format == "html" {
+		page, err := output.RenderHTML(pages, pattern, flavorName)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return err
+		}
+		return writeTextOrStdout(page, common.Output, stdout, co)
+	}
+	data := make([][]byte, len(pages))
+	for i, page := range pages {
+		data[i] = []byte(page)
+		switch common.Format {
+		case "png":
+			data[i], err = r.RasterizePNG(docs[i], common.DPI)
+		case "pdf":
+			data[i], err = r.DrawPDF(docs[i])
+		}
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return fmt.Errorf("%s: %w", common.Format, err)
+		}
+	}
+	if len(data) == 1 {
+		return writeOutputFile(common.Output, data[0], stdout, co)
+	}
+	if common.Output == stdoutPath {
+		err := fmt.Errorf("the diagram has %d pages and -o - can hold only one; give -o a file name", len(data))
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+	for i, page := range data {
+		if err := writeOutputFile(pageOutputPath(common.Output, i+1), page, stdout, co); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pageOutputPath inserts -n before the extension of path.
+func pageOutputPath(path string, n int) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + strconv.Itoa(n) + ext
+}
+
+// svgProvenance fills in the provenance comment for an SVG the CLI
+// writes. SOURCE_DATE_EPOCH, the reproducible-builds.org convention,
+// pins the timestamp whenever it is set. --reproducible also drops the
+// version, so upgrading regolith does not churn every checked-in
+// diagram, and omits the timestamp when SOURCE_DATE_EPOCH is unset.
+func svgProvenance(pattern, flavorName string, reproducible bool, now time.Time) (*renderer.Provenance, error) {
+	prov := &renderer.Provenance{Pattern: pattern, Flavor: flavorName}
+	if epoch := os.Getenv("SOURCE_DATE_EPOCH"); epoch != "" {
+		secs, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q: want seconds since the Unix epoch", epoch)
+		}
+		prov.Time = time.Unix(secs, 0)
+	} else if !reproducible {
+		prov.Time = now
+	}
+	if !reproducible {
+		prov.Version = version
+	}
+	return prov, nil
+}
+
+// reportContrastIssues prints one warning line per failing color pair.
+// Contrast problems never abort the render — the SVG is still valid,
+// just hard to read — so they go to stderr alongside other notes.
+func reportContrastIssues(w io.Writer, issues []renderer.ContrastIssue, co *termenv.Output) {
+	label := co.String("Warning:").Bold().Foreground(termenv.ANSIColor(3)).String()
+	for _, issue := range issues {
+		_, _ = fmt.Fprintf(w, "%s %s\n", label, issue)
+	}
+}
+
+// lookupFlavor is flavor.Get for a name the user gave, by --flavor or
+// otherwise: a deprecated alias still resolves, with a warning on
+// stderr naming what to use instead.
+func lookupFlavor(name string, stderr io.Writer) (flavor.Flavor, bool) {
+	f, ok := flavor.Get(name)
+	if note, deprecated := flavor.Deprecated(name); ok && deprecated {
+		_, _ = fmt.Fprintf(stderr, "Warning: flavor name %q is deprecated: %s\n", name, note)
+	}
+	return f, ok
 }
@@ -0,0 +1,182 @@
+package main
+
+// ================================================================================
+// flavors subcommand
+// ================================================================================
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/0x4d5352/regolith/internal/flavor"
+)
+
+// runFlavors implements `regolith flavors [name]`: list the flavors by
+// family, or document one from its FeatureSet, flags and Notes (see
+// flavor.Document).
+func runFlavors(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("regolith flavors", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	format := fs.String("format", "text", "Output format: text, json")
+	lang := fs.String("lang", defaultLang(), "Language for flag descriptions")
+
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "regolith flavors - Describe the supported regex flavors\n\n")
+		_, _ = fmt.Fprintf(stderr, "Usage:\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith flavors [flags] [name]\n\n")
+		_, _ = fmt.Fprintf(stderr, "Without a name, lists the flavors grouped by family. With one, prints\n")
+		_, _ = fmt.Fprintf(stderr, "the constructs the flavor supports with an example of each, the ones\n")
+		_, _ = fmt.Fprintf(stderr, "it lacks, its flags, and where regolith falls short of the real engine.\n\n")
+		_, _ = fmt.Fprintf(stderr, "Flags:\n")
+		fs.PrintDefaults()
+	}
+
+	err := parseFlags(fs, args[2:], stdout)
+	if errors.Is(err, flag.ErrHelp) || errors.Is(err, errConfigShown) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		err := fmt.Errorf("unknown format %q (available: text, json)", *format)
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return errors.New("flavors takes at most one flavor name")
+	}
+
+	if fs.NArg() == 0 {
+		if *format == "json" {
+			docs := make([]flavor.Doc, 0, flavor.Count())
+			for _, name := range flavor.List() {
+				f, _ := flavor.Get(name)
+				docs = append(docs, flavor.Document(f, *lang))
+			}
+			return writeFlavorsJSON(stdout, docs)
+		}
+		for i, group := range flavor.ByFamily() {
+			if i > 0 {
+				_, _ = fmt.Fprintln(stdout)
+			}
+			_, _ = fmt.Fprintf(stdout, "%s:\n", group.Family)
+			for _, name := range group.Flavors {
+				f, _ := flavor.Get(name)
+				_, _ = fmt.Fprintf(stdout, "  %-12s %s\n", name, f.Description())
+			}
+		}
+		_, _ = fmt.Fprintf(stdout, "\nRun regolith flavors <name> for details.\n")
+		return nil
+	}
+
+	name := fs.Arg(0)
+	f, ok := lookupFlavor(name, stderr)
+	if !ok {
+		_, _ = fmt.Fprintf(stderr, "Error: unknown flavor '%s'\n", name)
+		_, _ = fmt.Fprintf(stderr, "Available flavors: %s\n", strings.Join(flavor.List(), ", "))
+		return fmt.Errorf("unknown flavor: %s", name)
+	}
+	doc := flavor.Document(f, *lang)
+	if *format == "json" {
+		return writeFlavorsJSON(stdout, doc)
+	}
+	return writeFlavorDoc(stdout, doc)
+}
+
+// writeFlavorsJSON writes v as indented JSON.
+func writeFlavorsJSON(w io.Writer, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+// writeFlavorDoc writes doc as text, one section per part, leaving out
+// the sections the flavor has nothing for.
+func writeFlavorDoc(w io.Writer, doc flavor.Doc) error {
+	_, _ = fmt.Fprintf(w, "%s - %s\n", doc.Name, doc.Description)
+	_, _ = fmt.Fprintf(w, "Family: %s\n", doc.Family)
+	if len(doc.Aliases) > 0 {
+		_, _ = fmt.Fprintf(w, "Aliases: %s\n", strings.Join(doc.Aliases, ", "))
+	}
+	if len(doc.Versions) > 0 {
+		_, _ = fmt.Fprintf(w, "Versions checked: %s\n", strings.Join(doc.Versions, ", "))
+	}
+
+	_, _ = fmt.Fprintf(w, "\nSupported:\n")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, c := range doc.Supported {
+		_, _ = fmt.Fprintf(tw, "  %s\t%s\n", c.Name, c.Example)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if len(doc.Unsupported) > 0 {
+		names := make([]string, len(doc.Unsupported))
+		for i, c := range doc.Unsupported {
+			names[i] = c.Name
+		}
+		_, _ = fmt.Fprintf(w, "\nNot supported:\n")
+		writeWrapped(w, strings.Join(names, ", "))
+	}
+	if len(doc.Unread) > 0 {
+		names := make([]string, len(doc.Unread))
+		for i, c := range doc.Unread {
+			names[i] = c.Name
+		}
+		_, _ = fmt.Fprintf(w, "\nIn the engine, not yet read by regolith (see Limitations):\n")
+		writeWrapped(w, strings.Join(names, ", "))
+	}
+
+	if len(doc.Flags) > 0 {
+		_, _ = fmt.Fprintf(w, "\nFlags:\n")
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, fl := range doc.Flags {
+			_, _ = fmt.Fprintf(tw, "  %s\t%s\t%s\n", fl.Char, fl.Name, fl.Description)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	for _, section := range []struct {
+		title string
+		items []string
+	}{
+		{"Limitations compared to the real engine", doc.Limitations},
+		{"Notes", doc.Notes},
+	} {
+		if len(section.items) == 0 {
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "\n%s:\n", section.title)
+		for _, item := range section.items {
+			_, _ = fmt.Fprintf(w, "  - %s\n", item)
+		}
+	}
+	return nil
+}
+
+// writeWrapped writes text indented by two spaces, wrapped at word
+// boundaries to fit 72 columns.
+func writeWrapped(w io.Writer, text string) {
+	line := " "
+	for _, word := range strings.Fields(text) {
+		if len(line)+1+len(word) > 72 {
+			_, _ = fmt.Fprintln(w, line)
+			line = " "
+		}
+		line += " " + word
+	}
+	_, _ = fmt.Fprintln(w, line)
+}
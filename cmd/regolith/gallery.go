@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/muesli/termenv"
+
+	"github.com/0x4d5352/regolith/internal/flavor"
+	"github.com/0x4d5352/regolith/internal/output"
+)
+
+// galleryRecorder collects the outcome of each pattern in an SVG --null
+// batch for the index.html gallery written once the batch is done.
+// Patterns finish in any order under -j, so it is safe for concurrent
+// use.
+type galleryRecorder struct {
+	mu      sync.Mutex
+	results map[int]galleryResult
+}
+
+type galleryResult struct {
+	pattern, flavor, path string
+	title                 string
+	notes                 []string
+	err                   error
+}
+
+// wrap returns render with each call's outcome recorded. path gives
+// the file the -o template expands to for a pattern.
+func (g *galleryRecorder) wrap(render func(pattern string, seq int, stdout, stderr io.Writer) error,
+	path func(pattern string, seq int) string, flavorName string) func(string, int, io.Writer, io.Writer) error {
+	return func(pattern string, seq int, stdout, stderr io.Writer) error {
+		err := render(pattern, seq, stdout, stderr)
+		g.record(seq, galleryResult{pattern: pattern, flavor: flavorName, path: path(pattern, seq), err: err})
+		return err
+	}
+}
+
+// record stores the outcome of the pattern numbered seq.
+func (g *galleryRecorder) record(seq int, r galleryResult) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.results == nil {
+		g.results = make(map[int]galleryResult)
+	}
+	g.results[seq] = r
+}
+
+// write builds the gallery from the recorded results and writes it to
+// index.html in the directory the diagrams went to. It refuses to
+// overwrite an index.html that an earlier gallery did not produce, and
+// skips batches whose -o template spreads diagrams across directories.
+func (g *galleryRecorder) write(stdout, stderr io.Writer, co *termenv.Output) error {
+	seqs := make([]int, 0, len(g.results))
+	for seq := range g.results {
+		seqs = append(seqs, seq)
+	}
+	if len(seqs) == 0 {
+		return nil
+	}
+	sort.Ints(seqs)
+
+	dir := filepath.Dir(g.results[seqs[0]].path)
+	entries := make([]output.GalleryEntry, 0, len(seqs))
+	for _, seq := range seqs {
+		r := g.results[seq]
+		if filepath.Dir(r.path) != dir {
+			_, _ = fmt.Fprintf(stderr, "Warning: skipping gallery: diagrams were written to more than one directory\n")
+			return nil
+		}
+		e := output.GalleryEntry{Pattern: r.pattern, Flavor: r.flavor, Title: r.title, Notes: r.notes}
+		if r.err != nil {
+			e.Error = galleryError(r.err)
+			entries = append(entries, e)
+			continue
+		}
+		// A paginated diagram is shown by its first page.
+		for _, p := range []string{r.path, pageOutputPath(r.path, 1)} {
+			if data, err := os.ReadFile(p); err == nil {
+				e.File, e.SVG = filepath.Base(p), data
+				break
+			}
+		}
+		if e.File == "" {
+			e.Error = "diagram file not found"
+		}
+		entries = append(entries, e)
+	}
+
+	index := filepath.Join(dir, "index.html")
+	if existing, err := os.ReadFile(index); err == nil && !strings.Contains(string(existing), output.GalleryGenerator) {
+		_, _ = fmt.Fprintf(stderr, "Warning: skipping gallery: %s exists and was not written by regolith\n", index)
+		return nil
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("gallery: %w", err)
+	}
+	page, err := output.RenderGalleryHTML(entries)
+	if err != nil {
+		return err
+	}
+	return writeOutputFile(index, []byte(page), stdout, co)
+}
+
+// galleryError is the one-line reason shown on a failed pattern's card.
+func galleryError(err error) string {
+	var pe *flavor.ParseError
+	if errors.As(err, &pe) {
+		return "Parse error: " + pe.Message
+	}
+	return err.Error()
+}
@@ -0,0 +1,95 @@
+package main
+
+// ================================================================================
+// hash subcommand
+// ================================================================================
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/muesli/termenv"
+	flag "github.com/spf13/pflag"
+
+	"github.com/0x4d5352/regolith/internal/output"
+	"github.com/0x4d5352/regolith/internal/unescape"
+)
+
+// runHash implements `regolith hash`: print the canonical AST hash of a
+// pattern (see output.Hash), one line per pattern. It renders nothing,
+// so it only takes the flags that affect parsing and error reporting
+// rather than the full commonFlags set.
+func runHash(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("regolith hash", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	flavorName := fs.StringP("flavor", "f", "javascript", "Regex flavor")
+	unescapeFlag := fs.BoolP("unescape", "u", false,
+		`Apply string literal unescaping before parsing (e.g., \\ becomes \)`)
+	nullSeparated := fs.BoolP("null", "0", false,
+		"Read NUL-separated patterns from stdin and print one hash per line")
+	color := fs.String("color", "auto", "Color output: auto, always, never")
+	errorFormat := fs.String("error-format", "text", "Parse error format: text, json, gnu")
+
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "regolith hash - Print a canonical AST hash of a pattern\n\n")
+		_, _ = fmt.Fprintf(stderr, "Usage:\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith hash [flags] <pattern>\n\n")
+		_, _ = fmt.Fprintf(stderr, "The hash depends only on the parsed structure, so cosmetic\n")
+		_, _ = fmt.Fprintf(stderr, "rewrites such as a{1,} -> a+ or /x/gi -> /x/ig keep it stable.\n\n")
+		_, _ = fmt.Fprintf(stderr, "Flags:\n")
+		fs.PrintDefaults()
+	}
+
+	err := parseFlags(fs, args[2:], stdout)
+	if errors.Is(err, flag.ErrHelp) || errors.Is(err, errConfigShown) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := validateErrorFormat(*errorFormat); err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+
+	profile := output.ResolveColorProfile(*color)
+
+	f, ok := lookupFlavor(*flavorName, stderr)
+	if !ok {
+		_, _ = fmt.Fprintf(stderr, "Error: unknown flavor '%s'\n", *flavorName)
+		return fmt.Errorf("unknown flavor: %s", *flavorName)
+	}
+
+	hashPattern := func(pattern string, _ int, stdout, stderr io.Writer) error {
+		co := termenv.NewOutput(stderr, termenv.WithProfile(profile))
+		if *unescapeFlag {
+			pattern = unescape.JavaStringLiteral(pattern)
+		}
+		root, err := f.Parse(pattern)
+		if err != nil {
+			reportParseError(stderr, pattern, f.Name(), *errorFormat, err, co)
+			return fmt.Errorf("parse error: %w", err)
+		}
+		h, err := output.Hash(root)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return err
+		}
+		_, _ = fmt.Fprintln(stdout, h)
+		return nil
+	}
+
+	if *nullSeparated {
+		return runPatternList(fs.Args(), stdin, "", 0, stdout, stderr, hashPattern)
+	}
+
+	pattern, err := getInput(fs.Args(), stdin)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		fs.Usage()
+		return err
+	}
+	return hashPattern(pattern, 1, stdout, stderr)
+}
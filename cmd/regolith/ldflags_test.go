@@ -45,16 +45,18 @@ func TestLdflagsDoNotAffectSVGOutput(t *testing.T) {
 	}
 
 	// Run both binaries with the same pattern and compare SVG output.
+	// --reproducible keeps the version out of the provenance comment,
+	// which is the one place it is meant to appear.
 	pattern := `(?i)\p{Alpha}+\d{2,}`
 	plainSVG := filepath.Join(dir, "plain.svg")
 	ldflagSVG := filepath.Join(dir, "ldflag.svg")
 
-	run := exec.Command(plainBin, "--format", "svg", "-f", "java", "-o", plainSVG, pattern)
+	run := exec.Command(plainBin, "--reproducible", "--format", "svg", "-f", "java", "-o", plainSVG, pattern)
 	if out, err := run.CombinedOutput(); err != nil {
 		t.Fatalf("plain binary run failed: %v\n%s", err, out)
 	}
 
-	run = exec.Command(ldflagBin, "--format", "svg", "-f", "java", "-o", ldflagSVG, pattern)
+	run = exec.Command(ldflagBin, "--reproducible", "--format", "svg", "-f", "java", "-o", ldflagSVG, pattern)
 	if out, err := run.CombinedOutput(); err != nil {
 		t.Fatalf("ldflag binary run failed: %v\n%s", err, out)
 	}
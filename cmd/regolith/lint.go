@@ -0,0 +1,68 @@
+package main
+
+// ================================================================================
+// Lint config for analyze and audit
+// ================================================================================
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/0x4d5352/regolith/internal/analyzer"
+	"github.com/0x4d5352/regolith/internal/flavor"
+	"github.com/0x4d5352/regolith/internal/manifest"
+)
+
+// lintConfigNames are the files looked for in the current directory
+// when --lint-config is not given.
+var lintConfigNames = []string{".regolith-lint.yaml", ".regolith-lint.yml", ".regolith-lint.json"}
+
+// lintConfig is the decoded lint config file:
+//
+//	flavors: [javascript, java, golang]
+//
+// Flavors lists every flavor the team's patterns are shared between,
+// which turns on the analyzer's portability rules.
+type lintConfig struct {
+	Flavors []string `json:"flavors"`
+}
+
+// loadLintConfig reads the lint config at path, or the first of
+// lintConfigNames that exists when path is empty, into analyzer
+// options. No file at all means the zero Options.
+func loadLintConfig(path string) (analyzer.Options, error) {
+	var data []byte
+	var err error
+	if path != "" {
+		data, err = os.ReadFile(path)
+	} else {
+		for _, name := range lintConfigNames {
+			data, err = os.ReadFile(name)
+			if !errors.Is(err, fs.ErrNotExist) {
+				path = name
+				break
+			}
+		}
+		if path == "" {
+			return analyzer.Options{}, nil
+		}
+	}
+	if err != nil {
+		return analyzer.Options{}, err
+	}
+
+	var cfg lintConfig
+	if err := manifest.Decode(path, data, &cfg); err != nil {
+		return analyzer.Options{}, err
+	}
+	for i, name := range cfg.Flavors {
+		f, ok := flavor.Get(name)
+		if !ok {
+			return analyzer.Options{}, fmt.Errorf("%s: unknown flavor %q", path, name)
+		}
+		cfg.Flavors[i] = f.Name()
+	}
+	return analyzer.Options{Flavors: cfg.Flavors}, nil
+}
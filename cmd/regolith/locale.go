@@ -0,0 +1,52 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/0x4d5352/regolith/internal/analyzer"
+	"github.com/0x4d5352/regolith/internal/ast"
+	"github.com/0x4d5352/regolith/internal/flavor"
+)
+
+// localeNoteHeader opens the --locale-note footnote: the two ways grep
+// and the other POSIX tools can read the same pattern.
+const localeNoteHeader = "Locale: LC_ALL=C matches bytes and byte order; UTF-8 locales match characters in collation order."
+
+// localeConstructs returns the parts of pattern whose meaning depends
+// on the locale under f, as the analyzer's locale findings locate
+// them: letter ranges, non-ASCII bracket members, counted dots. It is
+// empty for flavors outside the POSIX families.
+func localeConstructs(f flavor.Flavor, root *ast.Regexp, pattern string) []string {
+	if fam := flavor.FamilyOf(f); fam != flavor.FamilyPOSIXExtended && fam != flavor.FamilyPOSIXBasic {
+		return nil
+	}
+	var parts []string
+	for _, finding := range analyzer.Analyze(root, pattern, f.Name(), f.SupportedFeatures()).Findings {
+		if !strings.HasPrefix(finding.ID, "locale-") {
+			continue
+		}
+		pos := finding.Node.Pos()
+		if pos.End <= pos.Start || pos.End > len(pattern) {
+			continue
+		}
+		if part := pattern[pos.Start:pos.End]; !slices.Contains(parts, part) {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// withLocaleNote appends the --locale-note footnote to caption, naming
+// parts, the locale-dependent constructs. A pattern without any gets
+// the caption back unchanged.
+func withLocaleNote(caption string, parts []string) string {
+	if len(parts) == 0 {
+		return caption
+	}
+	note := localeNoteHeader + "\nLocale-dependent here: " + strings.Join(parts, "  ")
+	if caption == "" {
+		return note
+	}
+	return caption + "\n" + note
+}
@@ -8,11 +8,14 @@ import (
 	_ "github.com/0x4d5352/regolith/internal/flavor/dotnet"
 	_ "github.com/0x4d5352/regolith/internal/flavor/gnugrep_bre"
 	_ "github.com/0x4d5352/regolith/internal/flavor/gnugrep_ere"
+	_ "github.com/0x4d5352/regolith/internal/flavor/gnused"
+	_ "github.com/0x4d5352/regolith/internal/flavor/golang"
 	_ "github.com/0x4d5352/regolith/internal/flavor/java"
 	_ "github.com/0x4d5352/regolith/internal/flavor/javascript"
 	_ "github.com/0x4d5352/regolith/internal/flavor/pcre"
 	_ "github.com/0x4d5352/regolith/internal/flavor/posix_bre"
 	_ "github.com/0x4d5352/regolith/internal/flavor/posix_ere"
+	_ "github.com/0x4d5352/regolith/internal/flavor/vim"
 )
 
 var version = "0.2.0"
@@ -23,17 +26,57 @@ func main() {
 	if (stat.Mode() & os.ModeCharDevice) == 0 {
 		stdin = os.Stdin
 	}
-	if err := run(os.Args, stdin, os.Stdout, os.Stderr); err != nil {
+	restoreConsole := setupConsole()
+	err := run(os.Args, stdin, os.Stdout, os.Stderr)
+	restoreConsole()
+	if err != nil {
 		os.Exit(1)
 	}
 }
 
 // run is the top-level dispatcher. The subcommand routing happens
-// before pflag parsing because `regolith analyze` has its own FlagSet
-// with a different default --format.
+// before pflag parsing because each subcommand (`analyze`, `audit`,
+// `classes`, `color`, `convert`, `ebnf`, `explain`, `flavors`, `hash`,
+// `match`, `query`, `self-update`, `serve`, `svgdiff`, `teach`, `version`,
+// `config`) has its own FlagSet.
 func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
-	if len(args) > 1 && args[1] == "analyze" {
-		return runAnalyze(args, stdin, stdout, stderr)
+	if len(args) > 1 {
+		switch args[1] {
+		case "analyze":
+			return runAnalyze(args, stdin, stdout, stderr)
+		case "audit":
+			return runAudit(args, stdin, stdout, stderr)
+		case "classes":
+			return runClasses(args, stdout, stderr)
+		case "color":
+			return runColor(args, stdin, stdout, stderr)
+		case "convert":
+			return runConvert(args, stdin, stdout, stderr)
+		case "ebnf":
+			return runEBNF(args, stdin, stdout, stderr)
+		case "explain":
+			return runExplain(args, stdin, stdout, stderr)
+		case "flavors":
+			return runFlavors(args, stdout, stderr)
+		case "match":
+			return runMatch(args, stdin, stdout, stderr)
+		case "hash":
+			return runHash(args, stdin, stdout, stderr)
+		case "self-update":
+			return runSelfUpdate(args, stdout, stderr)
+		case "serve":
+			return runServe(args, stdin, stdout, stderr)
+		case "svgdiff":
+			return runSVGDiff(args, stdin, stdout, stderr)
+		case "query":
+			return runQuery(args, stdin, stdout, stderr)
+		case "teach":
+			return runTeach(args, stdout, stderr)
+		case "version":
+			return runVersion(args, stdout, stderr)
+		case "config":
+			return runConfig(args, stdin, stdout, stderr)
+		}
 	}
 	return runRender(args, stdin, stdout, stderr)
 }
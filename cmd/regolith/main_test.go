@@ -1,16 +1,32 @@
 package main
 
 import (
+	"archive/tar"
 	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/0x4d5352/regolith/internal/flavor"
+	"github.com/0x4d5352/regolith/internal/selfupdate"
 )
 
 // ---------------------------------------------------------------------------
@@ -81,6 +97,45 @@ func TestRunAllFlavors(t *testing.T) {
 	}
 }
 
+func TestRunFlavorAlias(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"regolith", "--format", "json", "-f", "js", "a+"}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), `"flavor": "javascript"`) {
+		t.Errorf("expected the alias to report its flavor, got:\n%s", stdout.String())
+	}
+
+	names, err := teachFlavors([]string{"grep"})
+	if err != nil || len(names) != 1 || names[0] != "gnugrep" {
+		t.Errorf("teachFlavors(grep) = %v, %v; want [gnugrep]", names, err)
+	}
+
+	if err := flavor.RegisterDeprecatedAlias("posix", "posix-ere", "use posix-ere or posix-bre"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = flavor.Deregister("posix") }()
+	stdout.Reset()
+	stderr.Reset()
+	if err := run([]string{"regolith", "hash", "-f", "posix", "a+"}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("deprecated alias: %v\nstderr: %s", err, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), `Warning: flavor name "posix" is deprecated: use posix-ere or posix-bre`) {
+		t.Errorf("expected a deprecation warning, got: %s", stderr.String())
+	}
+
+	stderr.Reset()
+	_ = run([]string{"regolith", "-h"}, nil, &stdout, &stderr)
+	help := stderr.String()
+	perl, basic := strings.Index(help, "  Perl-style:\n"), strings.Index(help, "  POSIX basic:\n")
+	if perl < 0 || basic < perl || !strings.Contains(help, "(also: ecmascript, js)") {
+		t.Errorf("expected flavors grouped by family with aliases, got:\n%s", help)
+	}
+	if strings.Contains(help, "also: posix") {
+		t.Error("help should not advertise a deprecated alias")
+	}
+}
+
 func TestRunUnknownFlavor(t *testing.T) {
 	dir := t.TempDir()
 	out := filepath.Join(dir, "out.svg")
@@ -195,6 +250,25 @@ func TestRunHelp(t *testing.T) {
 	if !strings.Contains(stderrStr, "Available flavors:") {
 		t.Errorf("expected flavor list in stderr, got: %s", stderrStr)
 	}
+	if !strings.Contains(stderrStr, "UNICODE_CHARACTER_CLASS") {
+		t.Errorf("expected pattern flags in stderr, got: %s", stderrStr)
+	}
+	if !strings.Contains(stderrStr, "Output format: "+strings.Join(renderFormats, ", ")) {
+		t.Errorf("expected every --format in the help, got: %s", stderrStr)
+	}
+	if !strings.Contains(stderrStr, "Regex flavor ("+strings.Join(flavor.List(), ", ")+";") {
+		t.Errorf("expected every registered flavor in the --flavor help, got: %s", stderrStr)
+	}
+}
+
+func TestRunHelpLang(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"regolith", "--lang", "de", "-h"}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("expected no error for -h, got: %v", err)
+	}
+	if !strings.Contains(stderr.String(), "Findet alle Treffer statt nur den ersten") {
+		t.Errorf("expected German flag descriptions, got: %s", stderr.String())
+	}
 }
 
 func TestRunSVGContent(t *testing.T) {
@@ -254,6 +328,10 @@ func TestRunCustomColors(t *testing.T) {
 var binaryPath string
 
 func TestMain(m *testing.M) {
+	// Keep a config file in the developer's home directory out of the
+	// tests; the ones that exercise config files set their own.
+	_ = os.Setenv(configFileEnv, "")
+
 	tmp, err := os.MkdirTemp("", "regolith-test-*")
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to create temp dir: %v\n", err)
@@ -661,11 +739,29 @@ func TestRunFormatUnknown(t *testing.T) {
 	if !strings.Contains(stderrStr, "unknown format") {
 		t.Errorf("expected stderr to mention 'unknown format', got: %s", stderrStr)
 	}
-	if !strings.Contains(stderrStr, "Available: json, svg, text") {
+	if !strings.Contains(stderrStr, "Available: diagram, gotemplate, html, json, pdf, png, svg, text") {
 		t.Errorf("expected stderr to list available formats, got: %s", stderrStr)
 	}
 }
 
+func TestRunFormatDiagram(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "--format", "diagram", "--ascii", "a|b"}, nil, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v\nstderr: %s", err, stderr.String())
+	}
+	want := `     +---+
+o--+-+"a"+-+--o
+   | +---+ |
+   | +---+ |
+   +-+"b"+-+
+     +---+
+`
+	if got := stdout.String(); got != want {
+		t.Errorf("unexpected diagram:\n%s\nwant:\n%s", got, want)
+	}
+}
+
 // TestRunDefaultFormatIsText covers the standardized default behavior:
 // bare `regolith <pattern>` prints a text walk to stdout and does not
 // touch the filesystem. Previously the default was svg and the binary
@@ -690,7 +786,7 @@ func TestRunDefaultFormatIsText(t *testing.T) {
 	}
 }
 
-func TestRunFormatJSONNoFileCreated(t *testing.T) {
+func TestRunFormatJSONOutputFile(t *testing.T) {
 	dir := t.TempDir()
 	out := filepath.Join(dir, "out.svg")
 
@@ -700,9 +796,61 @@ func TestRunFormatJSONNoFileCreated(t *testing.T) {
 		t.Fatalf("expected no error, got: %v\nstderr: %s", err, stderr.String())
 	}
 
-	// JSON format should write to stdout, not create a file
-	if _, err := os.Stat(out); err == nil {
-		t.Error("expected no SVG file to be created when using --format json")
+	// --format wins over the extension: the file holds JSON, not SVG.
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("--format json -o should write the file: %v", err)
+	}
+	if !json.Valid(data) {
+		t.Errorf("expected JSON in %s, got %q", out, data[:min(len(data), 40)])
+	}
+	if strings.Contains(stdout.String(), `"pattern"`) {
+		t.Errorf("JSON went to stdout as well as -o: %s", stdout.String())
+	}
+}
+
+func TestRunFormatGoTemplate(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := filepath.Join(dir, "out.tmpl")
+	if err := os.WriteFile(tmpl, []byte("{{.Flavor}} {{.Root.type}}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "--format", "gotemplate", "--template", tmpl, "a|b"}, nil, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v\nstderr: %s", err, stderr.String())
+	}
+	if got := stdout.String(); got != "javascript alternation\n" {
+		t.Errorf("unexpected template output: %q", got)
+	}
+}
+
+func TestRunFormatGoTemplateLayout(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := filepath.Join(dir, "out.tmpl")
+	if err := os.WriteFile(tmpl, []byte(`{{range .Layout.Texts}}{{.Content}} {{.Category}}{{"\n"}}{{end}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "--format", "gotemplate", "--template", tmpl, "a|b"}, nil, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v\nstderr: %s", err, stderr.String())
+	}
+	if got := stdout.String(); got != "\"a\" literal\n\"b\" literal\n" {
+		t.Errorf("unexpected template output: %q", got)
+	}
+}
+
+func TestRunFormatGoTemplateRequiresTemplate(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "--format", "gotemplate", "abc"}, nil, &stdout, &stderr)
+	if err == nil {
+		t.Fatal("expected error when --template is missing, got nil")
+	}
+	if !strings.Contains(stderr.String(), "--template") {
+		t.Errorf("expected stderr to mention --template, got: %s", stderr.String())
 	}
 }
 
@@ -753,6 +901,68 @@ func TestAnalyzeSubcommand(t *testing.T) {
 	}
 }
 
+func TestAnalyzeLintConfig(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "lint.yaml")
+	if err := os.WriteFile(config, []byte("flavors: [pcre, posix-ere]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr strings.Builder
+	err := run([]string{"regolith", "analyze", "--color", "never", "--lint-config", config, "^a.c$"}, nil, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	for _, want := range []string{"portability-dollar", "portability-dot"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("output missing %q\ngot: %s", want, stdout.String())
+		}
+	}
+
+	if err := os.WriteFile(config, []byte("flavors: [pcre2]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stderr.Reset()
+	err = run([]string{"regolith", "analyze", "--lint-config", config, "a"}, nil, &stdout, &stderr)
+	if err == nil || !strings.Contains(stderr.String(), `unknown flavor "pcre2"`) {
+		t.Errorf("expected an unknown flavor error, got %v\nstderr: %s", err, stderr.String())
+	}
+}
+
+func TestAnalyzeStats(t *testing.T) {
+	pattern := `^(?:(a|b)+c)?$`
+	var stdout, stderr strings.Builder
+	err := run([]string{"regolith", "analyze", "--stats", "--format", "json", pattern}, nil, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	var doc struct {
+		Findings []any          `json:"findings"`
+		Metrics  map[string]int `json:"metrics"`
+	}
+	if err := json.Unmarshal([]byte(stdout.String()), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if doc.Findings != nil || doc.Metrics["depth"] != 2 || doc.Metrics["branches"] != 2 {
+		t.Errorf("got %+v", doc)
+	}
+
+	stdout.Reset()
+	err = run([]string{"regolith", "analyze", "--stats", "--limit", "depth=1,nodes=50", pattern}, nil, &stdout, &stderr)
+	if err == nil || !strings.Contains(stderr.String(), "depth is 2, over the limit of 1") {
+		t.Errorf("expected a depth limit failure, got %v\nstderr: %s", err, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "quantifiers") {
+		t.Errorf("metrics should still be printed when over a limit:\n%s", stdout.String())
+	}
+
+	stderr.Reset()
+	if err := run([]string{"regolith", "analyze", "--limit", "depth=2", pattern}, nil, &stdout, &stderr); err != nil {
+		t.Errorf("depth at its limit should pass: %v\nstderr: %s", err, stderr.String())
+	}
+	if err := run([]string{"regolith", "analyze", "--limit", "size=2", pattern}, nil, &stdout, &stderr); err == nil {
+		t.Error("expected an unknown metric error")
+	}
+}
+
 func TestAnalyzeColorNever(t *testing.T) {
 	var stdout, stderr strings.Builder
 	err := run([]string{"regolith", "analyze", "--color", "never", ".*.*=.*"}, nil, &stdout, &stderr)
@@ -923,6 +1133,97 @@ func TestRunSVGRequiresOutput(t *testing.T) {
 	}
 }
 
+func TestRunSVGToStdout(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"regolith", "--format", "svg", "-o", "-", "hello"}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	if !strings.HasPrefix(stdout.String(), "<svg") || !strings.HasSuffix(stdout.String(), "</svg>") {
+		t.Errorf("expected only the SVG on stdout, got:\n%s", stdout.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	err := run([]string{"regolith", "-0", "--format", "svg", "-o", "-"}, strings.NewReader("a\x00b"), &stdout, &stderr)
+	if err == nil || !strings.Contains(stderr.String(), "-o - can hold only one of the 2 patterns") {
+		t.Errorf("expected -o - to refuse a batch, got %v: %s", err, stderr.String())
+	}
+}
+
+// TestRunStackedPatterns checks that several patterns, as arguments or
+// --pattern, render into one SVG under their titles.
+func TestRunStackedPatterns(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	args := []string{"regolith", "--format", "svg", "-o", "-", "--pattern-title", "digits", `\d+`, "--pattern", "[a-z]+"}
+	if err := run(args, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	svg := stdout.String()
+	if strings.Count(svg, "<svg") != 1 || strings.Count(svg, `class="stack-entry"`) != 2 {
+		t.Errorf("expected one SVG with two stacked diagrams, got:\n%s", svg)
+	}
+	for _, title := range []string{">digits<", ">[a-z]+<"} {
+		if !strings.Contains(svg, `class="pattern-title"`+title) {
+			t.Errorf("missing title %s", title)
+		}
+	}
+
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--format", "json", "a", "b"}, "stack only in svg, png or pdf output, not json"},
+		{[]string{"--format", "svg", "-o", "-", "--pattern-title", "x", "--pattern-title", "y", "a"}, "2 --pattern-title values for 1 patterns"},
+		{[]string{"-0", "--pattern", "a"}, "--pattern cannot be combined"},
+		{[]string{"--format", "svg", "-o", "-", "a", "("}, "parse error"},
+	} {
+		stdout.Reset()
+		stderr.Reset()
+		err := run(append([]string{"regolith"}, tc.args...), nil, &stdout, &stderr)
+		if err == nil || !strings.Contains(err.Error()+stderr.String(), tc.want) {
+			t.Errorf("%v: expected %q, got %v: %s", tc.args, tc.want, err, stderr.String())
+		}
+	}
+}
+
+func TestRunTitleAndCaption(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	args := []string{"regolith", "--format", "svg", "-o", "-", "--title", "Digits", "--caption", "One or more\nASCII digits", `\d+`}
+	if err := run(args, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	svg := stdout.String()
+	for _, want := range []string{`class="diagram-title">Digits<`, `class="diagram-caption">One or more<`, `class="diagram-caption">ASCII digits<`} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("missing %s in:\n%s", want, svg)
+		}
+	}
+}
+
+func TestRunLocaleNote(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	args := []string{"regolith", "--format", "svg", "-o", "-", "-f", "posix-ere", "--locale-note", "--caption", "Words", `^[a-z]+ .{2}$`}
+	if err := run(args, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	svg := stdout.String()
+	for _, want := range []string{`class="diagram-caption">Words<`, `class="diagram-caption">Locale: `, `class="diagram-caption">Locale-dependent here: [a-z]  .{2}<`} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("missing %s in:\n%s", want, svg)
+		}
+	}
+
+	// Perl-style flavors do not follow the locale.
+	stdout.Reset()
+	args = []string{"regolith", "--format", "svg", "-o", "-", "-f", "pcre", "--locale-note", `^[a-z]+$`}
+	if err := run(args, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(stdout.String(), "Locale:") {
+		t.Error("unexpected locale footnote for pcre")
+	}
+}
+
 // TestAnalyzeSVGRequiresOutput confirms the shared requireOutputForSVG
 // helper is wired into the analyze subcommand's svg path.
 func TestAnalyzeSVGRequiresOutput(t *testing.T) {
@@ -963,3 +1264,2028 @@ func TestAnalyzeSVGStyleFlags(t *testing.T) {
 		t.Error("expected --literal-fill color in analyze SVG output")
 	}
 }
+
+func TestRunOutputExtensionImpliesFormat(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string) []byte {
+		t.Helper()
+		out := filepath.Join(dir, name)
+		var stdout, stderr bytes.Buffer
+		if err := run([]string{"regolith", "-o", out, "a+"}, nil, &stdout, &stderr); err != nil {
+			t.Fatalf("-o %s: %v\nstderr: %s", name, err, stderr.String())
+		}
+		data, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	if svg := write("diagram.svg"); !bytes.Contains(svg, []byte("<svg")) {
+		t.Errorf("-o diagram.svg should write SVG, got %q", svg[:min(len(svg), 40)])
+	}
+	if data := write("diagram.JSON"); !json.Valid(data) {
+		t.Errorf("-o diagram.JSON should write JSON, got %q", data[:min(len(data), 40)])
+	}
+	if md := write("outline.txt"); !bytes.HasPrefix(md, []byte("#")) {
+		t.Errorf("-o outline.txt should keep the Markdown walk, got %q", md[:min(len(md), 40)])
+	}
+}
+
+func TestRunFormatPNG(t *testing.T) {
+	// No converter is needed: PATH holds nothing.
+	t.Setenv("PATH", t.TempDir())
+	dir := t.TempDir()
+	size := func(dpi string) image.Point {
+		t.Helper()
+		out := filepath.Join(dir, "diagram-"+dpi+".png")
+		var stdout, stderr bytes.Buffer
+		if err := run([]string{"regolith", "--dpi", dpi, "-o", out, "a+b"}, nil, &stdout, &stderr); err != nil {
+			t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+		}
+		f, err := os.Open(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = f.Close() }()
+		cfg, err := png.DecodeConfig(f)
+		if err != nil {
+			t.Fatalf("-o %s should write a PNG: %v", out, err)
+		}
+		return image.Pt(cfg.Width, cfg.Height)
+	}
+	one, twice := size("96"), size("192")
+	if twice.X < 2*one.X-1 || twice.X > 2*one.X || twice.Y < 2*one.Y-1 || twice.Y > 2*one.Y {
+		t.Errorf("192 DPI gave %v pixels, want twice the %v of 96 DPI", twice, one)
+	}
+}
+
+func TestRunFormatPDF(t *testing.T) {
+	// No converter is needed: an empty PATH still gets a PDF.
+	t.Setenv("PATH", t.TempDir())
+
+	out := filepath.Join(t.TempDir(), "diagram.pdf")
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"regolith", "-o", out, "a+b"}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	data, err := os.ReadFile(out)
+	if err != nil || !bytes.HasPrefix(data, []byte("%PDF-")) {
+		t.Fatalf("-o diagram.pdf should write a PDF, got %q, %v", data[:min(len(data), 20)], err)
+	}
+}
+
+func TestRunDimensionFlags(t *testing.T) {
+	render := func(args ...string) string {
+		t.Helper()
+		out := filepath.Join(t.TempDir(), "out.svg")
+		var stdout, stderr bytes.Buffer
+		args = append([]string{"regolith", "--format", "svg", "-o", out}, append(args, "^(cat|dog)+$")...)
+		if err := run(args, nil, &stdout, &stderr); err != nil {
+			t.Fatalf("%v: %v\nstderr: %s", args, err, stderr.String())
+		}
+		data, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	width := func(svg string) string {
+		_, rest, _ := strings.Cut(svg, ` width="`)
+		w, _, _ := strings.Cut(rest, `"`)
+		return w
+	}
+
+	plain := render()
+	compact := render("--compact")
+	if a, b := width(plain), width(compact); a == b {
+		t.Errorf("--compact did not shrink the diagram: width %s both ways", a)
+	}
+	if !strings.Contains(compact, `rx="3"`) {
+		t.Error("--compact should use the compact corner radius")
+	}
+	if got := render("--compact", "--corner-radius", "2"); !strings.Contains(got, `rx="2"`) || strings.Contains(got, `rx="3"`) {
+		t.Error("an explicit --corner-radius should override --compact")
+	}
+	if got := render("--compact", "--padding", "10"); width(got) == width(compact) {
+		t.Error("an explicit --padding should override --compact")
+	}
+
+	if got := render("--max-width", "100"); width(got) != "100" || !strings.Contains(got, `viewBox="0 0 `+width(plain)+" ") {
+		t.Errorf("--max-width 100 should scale the %s-wide diagram to 100, got %s", width(plain), width(got))
+	}
+	if got := render("--max-height", "10"); !strings.Contains(got, `height="10"`) {
+		t.Error("--max-height 10 should scale the diagram to 10 high")
+	}
+	if got := render("--text-length"); !strings.Contains(got, `lengthAdjust="spacingAndGlyphs"`) {
+		t.Error("--text-length should pin content text to its measured width")
+	}
+	font := filepath.Join(t.TempDir(), "mono.woff2")
+	if err := os.WriteFile(font, []byte("wOF2 font data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := render("--embed-font", font); !strings.Contains(got, "@font-face") || !strings.Contains(got, "data:font/woff2;base64,") {
+		t.Error("--embed-font should declare the font as a data URL")
+	}
+
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "--format", "svg", "-o", filepath.Join(t.TempDir(), "x.svg"),
+		"--curve-radius", "12", "--connector-width", "8", "a|b"}, nil, &stdout, &stderr)
+	if err == nil || !strings.Contains(stderr.String(), "less than the curve radius") {
+		t.Errorf("expected a connector/curve error, got %v: %s", err, stderr.String())
+	}
+}
+
+func TestRunCheckContrastWarns(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.svg")
+
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "--format", "svg", "--color", "never", "--check-contrast",
+		"--anchor-fill", "#ffffff", "-o", out, "^a"}, nil, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("contrast warnings must not fail the render: %v\nstderr: %s", err, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "Warning: anchor text") {
+		t.Errorf("expected anchor contrast warning, got: %s", stderr.String())
+	}
+	if _, err := os.Stat(out); err != nil {
+		t.Errorf("expected SVG to be written despite warnings: %v", err)
+	}
+}
+
+func TestRunShowSource(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.svg")
+
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "--format", "svg", "--show-source", "-o", out, `(a)\d+`}, nil, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("failed to read SVG: %v", err)
+	}
+	for _, class := range []string{"src-group", "src-escape", "src-quantifier"} {
+		if !strings.Contains(string(data), class) {
+			t.Errorf("expected %s token in --show-source output", class)
+		}
+	}
+}
+
+func TestRunFlatten(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "--flatten", "a|(?:b|c)"}, nil, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "one of 3 branches") {
+		t.Errorf("expected a single 3-branch alternation, got:\n%s", stdout.String())
+	}
+}
+
+func TestRunExpandSubroutines(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "-f", "pcre", "--color", "never", "--expand-subroutines", "(x)(?1)"}, nil, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Subroutine call to group #1") {
+		t.Errorf("expected the call to be expanded, got:\n%s", stdout.String())
+	}
+}
+
+func TestRunExpandShorthands(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out.svg")
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "-f", "pcre", "--format", "svg", "--expand-shorthands", "-o", out, "(*UCP)[\\d_]"}, nil, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("failed to read SVG: %v", err)
+	}
+	if want := `digit = \p{Nd} (Unicode)`; !strings.Contains(string(data), want) {
+		t.Errorf("expected %q in the rendered SVG", want)
+	}
+}
+
+func TestRunPaginate(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "date.svg")
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "--format", "svg", "--paginate", "400", "-o", out, `(\d{4})-(\d{2})-(\d{2})T(\d{2}):(\d{2})`}, nil, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Errorf("expected no unnumbered %s when paginating", out)
+	}
+	for _, page := range []string{"date-1.svg", "date-2.svg"} {
+		if _, err := os.Stat(filepath.Join(dir, page)); err != nil {
+			t.Errorf("expected page %s: %v", page, err)
+		}
+		if !strings.Contains(stdout.String(), page) {
+			t.Errorf("expected %q in confirmation output, got:\n%s", page, stdout.String())
+		}
+	}
+}
+
+func TestRunLayoutWrap(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "date.svg")
+	pattern := `(\d{4})-(\d{2})-(\d{2})T(\d{2}):(\d{2})`
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"regolith", "--format", "svg", "--layout", "wrap", "--wrap-width", "300", "-o", out, pattern}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("failed to read SVG: %v", err)
+	}
+	if !strings.Contains(string(data), `class="wrap-path"`) {
+		t.Error("expected --layout wrap to fold the sequence onto rows")
+	}
+
+	err = run([]string{"regolith", "--format", "svg", "--layout", "stacked", "-o", out, pattern}, nil, &stdout, &stderr)
+	if err == nil || !strings.Contains(stderr.String(), `unknown layout "stacked"`) {
+		t.Errorf("expected an unknown layout error, got %v: %s", err, stderr.String())
+	}
+}
+
+func TestRunFromJSON(t *testing.T) {
+	dir := t.TempDir()
+	railroad := filepath.Join(dir, "railroad.json")
+	if err := os.WriteFile(railroad, []byte(`{"type":"Diagram","items":["SELECT",{"type":"OneOrMore","item":{"type":"NonTerminal","text":"column"},"repeat":","}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out.svg")
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"regolith", "--from-json", railroad, "--format", "svg", "-o", out}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("failed to read SVG: %v", err)
+	}
+	for _, want := range []string{"SELECT", "column"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected %q in the rendered SVG", want)
+		}
+	}
+
+	// A regolith document brings its own flavor along.
+	stdout.Reset()
+	if err := run([]string{"regolith", "--flavor", "pcre", "--format", "json", `(?>a)`}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	doc := stdout.String()
+	stdout.Reset()
+	if err := run([]string{"regolith", "--from-json", "-"}, strings.NewReader(doc), &stdout, &stderr); err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Flavor: PCRE") || !strings.Contains(stdout.String(), "Atomic group") {
+		t.Errorf("expected the PCRE document to render as PCRE, got:\n%s", stdout.String())
+	}
+
+	stderr.Reset()
+	if err := run([]string{"regolith", "--from-json", railroad, "a+"}, nil, &stdout, &stderr); err == nil {
+		t.Error("expected an error when a pattern is given alongside --from-json")
+	}
+	if err := run([]string{"regolith", "--from-json", railroad, "--show-source", "--format", "svg", "-o", out}, nil, &stdout, &stderr); err == nil {
+		t.Error("expected --show-source to be rejected for a railroad tree")
+	}
+}
+
+// TestAllFlavorsTokenize guards against a new flavor shipping without a
+// highlighter: --show-source would silently degrade to one plain run.
+func TestAllFlavorsTokenize(t *testing.T) {
+	for _, name := range flavor.List() {
+		f, _ := flavor.Get(name)
+		if _, ok := f.(flavor.Tokenizer); !ok {
+			t.Errorf("flavor %q does not implement flavor.Tokenizer", name)
+		}
+	}
+}
+
+func TestRunRulerImpliesSource(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.svg")
+
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "--format", "svg", "--ruler", "-o", out, "abc"}, nil, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("failed to read SVG: %v", err)
+	}
+	if !strings.Contains(string(data), `class="source"`) || !strings.Contains(string(data), `class="ruler"`) {
+		t.Error("expected --ruler to draw both the source line and the ruler")
+	}
+}
+
+func TestCaretPaddingWideCharacters(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		col     int
+		want    string
+		ok      bool
+	}{
+		{"ascii", "abc(", 4, "   ", true},
+		{"cjk is two cells", "日本(", 3, "    ", true},
+		{"combining mark takes no cell", "éx(", 4, "  ", true},
+		{"tab copied through", "a\tb(", 4, " \t ", true},
+		{"end of input", "ab", 3, "  ", true},
+		{"past end", "ab", 4, "", false},
+		{"no column", "ab", 0, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := caretPadding(tt.pattern, tt.col)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("caretPadding(%q, %d) = %q, %v; want %q, %v", tt.pattern, tt.col, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestResolveOutputPath(t *testing.T) {
+	dir := t.TempDir()
+	// An existing file keeps its name even without an extension.
+	existing := filepath.Join(dir, "diagram")
+	if err := os.WriteFile(existing, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path, format, want string
+	}{
+		{"", "svg", ""},
+		{"-", "svg", "-"},
+		{"diagram", "svg", "diagram.svg"},
+		{"diagram", "json", "diagram.json"},
+		{"notes", "text", "notes.md"},
+		{"diagram.svg", "svg", "diagram.svg"},
+		{"out.v2", "svg", "out.v2"},
+		{`out\v1.2\diagram`, "svg", `out\v1.2\diagram.svg`},
+		{".hidden", "svg", ".hidden.svg"},
+		{"report", "gotemplate", "report"},
+		{dir, "svg", dir},
+		{existing, "svg", existing},
+		{os.DevNull, "svg", os.DevNull},
+	}
+	for _, tt := range tests {
+		if got := resolveOutputPath(tt.path, tt.format); got != filepath.FromSlash(tt.want) {
+			t.Errorf("resolveOutputPath(%q, %q) = %q, want %q", tt.path, tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestRunOutputGetsFormatExtension(t *testing.T) {
+	dir := t.TempDir()
+
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "--format", "svg", "-o", filepath.Join(dir, "diagram"), "abc"}, nil, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "diagram.svg")); err != nil {
+		t.Errorf("expected diagram.svg to be written: %v", err)
+	}
+}
+
+func TestRunCheckValid(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.svg")
+
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "--check", "--color", "never", "--format", "svg", "-o", out, "a+b"}, nil, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Valid javascript pattern") {
+		t.Errorf("expected confirmation, got: %s", stdout.String())
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Error("--check must not render output")
+	}
+}
+
+func TestRunCheckInvalidJSON(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "--check", "--error-format", "json", "a("}, nil, &stdout, &stderr)
+	if err == nil {
+		t.Fatal("expected error for invalid pattern")
+	}
+	var doc struct {
+		Valid bool `json:"valid"`
+		Error struct {
+			Column int `json:"column"`
+			Offset int `json:"offset"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &doc); err != nil {
+		t.Fatalf("expected JSON on stdout: %v\n%s", err, stdout.String())
+	}
+	// The unclosed ( is pinpointed rather than the end of the pattern.
+	if doc.Valid || doc.Error.Column != 2 || doc.Error.Offset != 1 {
+		t.Errorf("unexpected validation document: %s", stdout.String())
+	}
+}
+
+func TestRunCheckHints(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "--check", "--color", "never", "-f", "posix-ere", "(?<=a)b"}, nil, &stdout, &stderr)
+	if err == nil {
+		t.Fatal("expected error for lookbehind in posix-ere")
+	}
+	if !strings.Contains(stdout.String(), "hint: lookbehind (?<=...) is not supported in posix-ere; try --flavor pcre") {
+		t.Errorf("expected lookbehind hint, got: %s", stdout.String())
+	}
+
+	stdout.Reset()
+	err = run([]string{"regolith", "--check", "--color", "never", "-f", "pcre", "(*SKIPP)a"}, nil, &stdout, &stderr)
+	if err == nil {
+		t.Fatal("expected error for misspelled verb")
+	}
+	if !strings.Contains(stdout.String(), "hint: did you mean (*SKIP)?") {
+		t.Errorf("expected spelling suggestion, got: %s", stdout.String())
+	}
+
+	stdout.Reset()
+	err = run([]string{"regolith", "--check", "--error-format", "json", "a(b"}, nil, &stdout, &stderr)
+	if err == nil {
+		t.Fatal("expected error for unclosed group")
+	}
+	var doc struct {
+		Error struct {
+			Hints []string `json:"hints"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &doc); err != nil {
+		t.Fatalf("expected JSON on stdout: %v\n%s", err, stdout.String())
+	}
+	if len(doc.Error.Hints) != 1 || !strings.Contains(doc.Error.Hints[0], "never closed") {
+		t.Errorf("expected unclosed-group hint, got: %s", stdout.String())
+	}
+}
+
+func TestRunErrorFormatJSONOnStderr(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "--error-format", "json", "a("}, nil, &stdout, &stderr)
+	if err == nil {
+		t.Fatal("expected error for invalid pattern")
+	}
+	if !json.Valid(stderr.Bytes()) {
+		t.Errorf("expected JSON parse error on stderr, got: %s", stderr.String())
+	}
+}
+
+func TestRunErrorFormatGNU(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "route.regex")
+	if err := os.WriteFile(path, []byte("ab(c\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "--check", "--error-format", "gnu", "--input", path}, nil, &stdout, &stderr)
+	if err == nil {
+		t.Fatal("expected error for invalid pattern")
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if lines[0] != path+":1:3: unclosed group: ( has no matching )" {
+		t.Errorf("unexpected first line: %q", lines[0])
+	}
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], path+":1:3: hint: ") {
+		t.Errorf("expected one hint line, got: %s", stdout.String())
+	}
+
+	stdout.Reset()
+	if err := run([]string{"regolith", "--check", "--error-format", "gnu", "abc"}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("expected no output for a valid pattern, got: %s", stdout.String())
+	}
+
+	stderr.Reset()
+	if err := run([]string{"regolith", "--error-format", "gnu", "a["}, nil, &stdout, &stderr); err == nil {
+		t.Fatal("expected error for invalid pattern")
+	}
+	if !strings.HasPrefix(stderr.String(), "<pattern>:1:2: ") {
+		t.Errorf("expected the error on stderr, got: %s", stderr.String())
+	}
+}
+
+func TestRunErrorFormatUnknown(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"regolith", "--error-format", "xml", "a"}, nil, &stdout, &stderr); err == nil {
+		t.Fatal("expected error for unknown --error-format")
+	}
+}
+
+func TestRunNullSeparatedPatterns(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := filepath.Join(dir, "out-%d.svg")
+
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader("a+\x00b|c\x00(d)\x00")
+	err := run([]string{"regolith", "-0", "--format", "svg", "-o", tmpl}, stdin, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	for i := 1; i <= 3; i++ {
+		if _, err := os.Stat(filepath.Join(dir, fmt.Sprintf("out-%d.svg", i))); err != nil {
+			t.Errorf("expected out-%d.svg: %v", i, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out-4.svg")); !os.IsNotExist(err) {
+		t.Error("trailing NUL must not produce an extra pattern")
+	}
+}
+
+func TestRunNullSeparatedGallery(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := filepath.Join(dir, "out-%n.svg")
+
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader("a+\x00b(\x00c|d")
+	err := run([]string{"regolith", "-0", "--format", "svg", "-o", tmpl}, stdin, &stdout, &stderr)
+	if err == nil {
+		t.Fatal("expected the failing pattern to set the exit status")
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "index.html"))
+	if err != nil {
+		t.Fatalf("expected index.html: %v\nstderr: %s", err, stderr.String())
+	}
+	page := string(data)
+	for _, want := range []string{`href="out-1.svg"`, `href="out-3.svg"`, `data-pattern="b("`, "Parse error:"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("gallery missing %q", want)
+		}
+	}
+
+	// A hand-written index.html is left alone.
+	if err := os.WriteFile(filepath.Join(dir, "index.html"), []byte("mine"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stderr.Reset()
+	_ = run([]string{"regolith", "-0", "--format", "svg", "-o", tmpl}, strings.NewReader("a\x00b"), &stdout, &stderr)
+	if data, _ := os.ReadFile(filepath.Join(dir, "index.html")); string(data) != "mine" {
+		t.Error("gallery overwrote an index.html it did not write")
+	}
+	if !strings.Contains(stderr.String(), "skipping gallery") {
+		t.Errorf("expected a skip warning, got: %s", stderr.String())
+	}
+
+	if err := os.Remove(filepath.Join(dir, "index.html")); err != nil {
+		t.Fatal(err)
+	}
+	_ = run([]string{"regolith", "-0", "--gallery=false", "--format", "svg", "-o", tmpl}, strings.NewReader("a\x00b"), &stdout, &stderr)
+	if _, err := os.Stat(filepath.Join(dir, "index.html")); !os.IsNotExist(err) {
+		t.Error("--gallery=false must not write index.html")
+	}
+}
+
+func TestRunNullSeparatedContinuesPastErrors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader("a(\x00b")
+	err := run([]string{"regolith", "--null", "--format", "json"}, stdin, &stdout, &stderr)
+	if err == nil {
+		t.Fatal("expected the failing pattern to set the exit status")
+	}
+	if !strings.Contains(stdout.String(), `"pattern": "b"`) {
+		t.Errorf("expected the second pattern to render, got: %s", stdout.String())
+	}
+}
+
+func TestRunNullSeparatedRequiresIndexVerb(t *testing.T) {
+	dir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader("a\x00b")
+	err := run([]string{"regolith", "-0", "--format", "svg", "-o", filepath.Join(dir, "out-%f.svg")}, stdin, &stdout, &stderr)
+	if err == nil || !strings.Contains(stderr.String(), "%n") {
+		t.Errorf("expected an error asking for %%n in -o, got %v: %s", err, stderr.String())
+	}
+}
+
+func TestRunManifest(t *testing.T) {
+	dir := t.TempDir()
+	manifestPath := filepath.Join(dir, "regex.yaml")
+	src := `defaults:
+  flavor: pcre
+patterns:
+  - title: Year
+    pattern: '\d{4}'
+    output: year.svg
+    annotations: [Four digits]
+  - pattern: '/^[a-z]+$/'
+    flavor: javascript
+    flags: i
+    output: word.svg
+  - pattern: 'a('
+    output: broken.svg
+`
+	if err := os.WriteFile(manifestPath, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "--manifest", manifestPath, "--format", "svg", "--reproducible"}, nil, &stdout, &stderr)
+	if err == nil {
+		t.Fatal("expected the failing entry to set the exit status")
+	}
+	year, err := os.ReadFile(filepath.Join(dir, "year.svg"))
+	if err != nil {
+		t.Fatalf("expected year.svg: %v\nstderr: %s", err, stderr.String())
+	}
+	if !strings.Contains(string(year), "flavor: pcre") {
+		t.Errorf("year.svg should be rendered as pcre:\n%s", year)
+	}
+	word, err := os.ReadFile(filepath.Join(dir, "word.svg"))
+	if err != nil {
+		t.Fatalf("expected word.svg: %v", err)
+	}
+	if !strings.Contains(string(word), "flavor: javascript") || !strings.Contains(string(word), "ignore case") {
+		t.Errorf("word.svg should be javascript with the i flag in its panel:\n%s", word)
+	}
+	if !strings.Contains(stderr.String(), "Processed 3 patterns") {
+		t.Errorf("expected a batch summary, got: %s", stderr.String())
+	}
+	page, err := os.ReadFile(filepath.Join(dir, "index.html"))
+	if err != nil {
+		t.Fatalf("expected index.html: %v", err)
+	}
+	for _, want := range []string{"<h2>Year</h2>", "<li>Four digits</li>", `href="word.svg"`, "Parse error:"} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("gallery missing %q", want)
+		}
+	}
+}
+
+func TestRunManifestSharedOutput(t *testing.T) {
+	dir := t.TempDir()
+	manifestPath := filepath.Join(dir, "m.json")
+	if err := os.WriteFile(manifestPath, []byte(`[{"pattern": "a"}, {"pattern": "b", "flavor": "posix-ere"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "--manifest", manifestPath, "--format", "svg", "-o", filepath.Join(dir, "same.svg")}, nil, &stdout, &stderr)
+	if err == nil || !strings.Contains(stderr.String(), "%n") {
+		t.Errorf("expected an error asking for %%n in -o, got %v: %s", err, stderr.String())
+	}
+
+	stderr.Reset()
+	err = run([]string{"regolith", "--manifest", manifestPath, "--format", "svg", "-o", filepath.Join(dir, "out-%n-%f.svg")}, nil, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	for _, name := range []string{"out-1-javascript.svg", "out-2-posix-ere.svg"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected %s: %v", name, err)
+		}
+	}
+}
+
+func TestRunManifestRejectsPatternArgument(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "--manifest", "m.yaml", "a+"}, nil, &stdout, &stderr)
+	if err == nil || !strings.Contains(stderr.String(), "no pattern argument") {
+		t.Errorf("expected a pattern argument to be rejected, got %v: %s", err, stderr.String())
+	}
+}
+
+func TestRunInputURL(t *testing.T) {
+	dir := t.TempDir()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/pattern.txt", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprintln(w, `^\d{4}$`)
+	})
+	mux.HandleFunc("/big.txt", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(bytes.Repeat([]byte("a"), maxFetchBytes+1))
+	})
+	mux.HandleFunc("/regex.yaml", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprintf(w, "- pattern: 'a+'\n  output: %s\n", filepath.Join(dir, "remote.svg"))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"regolith", "--format", "json", "-i", srv.URL + "/pattern.txt"}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), `"pattern": "^\\d{4}$"`) {
+		t.Errorf("expected the fetched pattern, without its newline, got:\n%s", stdout.String())
+	}
+
+	for _, tc := range []struct{ path, want string }{
+		{"/big.txt", "larger than the 1 MiB limit"},
+		{"/missing.txt", "404 Not Found"},
+	} {
+		stderr.Reset()
+		err := run([]string{"regolith", "-i", srv.URL + tc.path}, nil, &stdout, &stderr)
+		if err == nil || !strings.Contains(stderr.String(), tc.want) {
+			t.Errorf("%s: expected %q, got %v: %s", tc.path, tc.want, err, stderr.String())
+		}
+	}
+
+	stderr.Reset()
+	if err := run([]string{"regolith", "--manifest", srv.URL + "/regex.yaml", "--format", "svg", "--gallery=false"}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("remote manifest: %v\nstderr: %s", err, stderr.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "remote.svg")); err != nil {
+		t.Errorf("expected the remote manifest's entry to be rendered: %v", err)
+	}
+
+	stderr.Reset()
+	err := run([]string{"regolith", "-i", srv.URL + "/pattern.txt", "a+"}, nil, &stdout, &stderr)
+	if err == nil || !strings.Contains(stderr.String(), "no pattern argument") {
+		t.Errorf("expected a pattern argument to be rejected, got %v: %s", err, stderr.String())
+	}
+}
+
+func TestGistRawURL(t *testing.T) {
+	for in, want := range map[string]string{
+		"https://gist.github.com/octo/0123abcd":          "https://gist.githubusercontent.com/octo/0123abcd/raw",
+		"https://gist.github.com/octo/0123abcd#file-re":  "https://gist.githubusercontent.com/octo/0123abcd/raw",
+		"https://gist.github.com/octo/0123abcd/raw/x.re": "https://gist.github.com/octo/0123abcd/raw/x.re",
+		"https://example.com/octo/0123abcd":              "https://example.com/octo/0123abcd",
+	} {
+		u, err := url.Parse(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := gistRawURL(u).String(); got != want {
+			t.Errorf("gistRawURL(%s) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestRunPatternFlags(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"regolith", "-f", "pcre", "--pattern-flags", "ix", "--format", "json", "a"}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), `"flags": "ix"`) {
+		t.Errorf("expected the flags in the JSON, got: %s", stdout.String())
+	}
+
+	stderr.Reset()
+	err := run([]string{"regolith", "-f", "pcre", "--pattern-flags", "q", "a"}, nil, &stdout, &stderr)
+	if err == nil || !strings.Contains(stderr.String(), `unknown pcre pattern flag 'q'`) {
+		t.Errorf("expected an unknown flag error, got %v: %s", err, stderr.String())
+	}
+}
+
+func TestExpandOutputTemplate(t *testing.T) {
+	vars := outputVars{
+		Seq:     7,
+		Pattern: "a+",
+		Flavor:  "pcre",
+		Time:    time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC),
+	}
+	tests := []struct {
+		tmpl string
+		want string
+	}{
+		{"out-%d.svg", "out-7.svg"},
+		{"out-%n.svg", "out-7.svg"},
+		{"out-%03n.svg", "out-007.svg"},
+		{"%f/%n.svg", "pcre/7.svg"},
+		{"run-%t.svg", "run-20260102T150405Z.svg"},
+		{"%p.svg", "85e200cddd8f.svg"},
+		{"100%%-%n.svg", "100%-7.svg"},
+		{"50%.svg", "50%.svg"},
+		{"plain.svg", "plain.svg"},
+	}
+	for _, tt := range tests {
+		if got := expandOutputTemplate(tt.tmpl, vars); got != tt.want {
+			t.Errorf("expandOutputTemplate(%q) = %q, want %q", tt.tmpl, got, tt.want)
+		}
+	}
+	if templateVariesPerPattern("100%%-%f-%t.svg") {
+		t.Error("flavor and timestamp alone must not count as per-pattern placeholders")
+	}
+	if !templateVariesPerPattern("%p.svg") {
+		t.Error("expected a pattern hash placeholder to vary per pattern")
+	}
+}
+
+func TestHashSubcommand(t *testing.T) {
+	hash := func(args ...string) string {
+		t.Helper()
+		var stdout, stderr bytes.Buffer
+		if err := run(append([]string{"regolith", "hash"}, args...), nil, &stdout, &stderr); err != nil {
+			t.Fatalf("hash %v: %v\nstderr: %s", args, err, stderr.String())
+		}
+		return strings.TrimSpace(stdout.String())
+	}
+
+	if got := hash("a{1,}"); got != hash("a+") || len(got) != 64 {
+		t.Errorf("expected equal 64-digit hashes for a{1,} and a+, got %q and %q", got, hash("a+"))
+	}
+	if hash("-f", "gnugrep", "a*") != hash("--flavor", "gnugrep-bre", "a*") {
+		t.Error("expected flavor aliases to hash equal")
+	}
+	if hash("a+") == hash("a*") {
+		t.Error("expected different structures to hash differently")
+	}
+}
+
+func TestEBNFSubcommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "ebnf", "--flavor", "pcre", `(?<n>\d+)(?:,(?&n))*`}, nil, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	for _, want := range []string{
+		`pattern = n , { ( "," , n ) } ;`,
+		"n = ",
+	} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("expected %q in output, got:\n%s", want, stdout.String())
+		}
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if err := run([]string{"regolith", "ebnf", "a("}, nil, &stdout, &stderr); err == nil {
+		t.Error("expected a parse error for an unbalanced group")
+	}
+}
+
+func TestExplainSubcommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "explain", "--flavor", "pcre", `(?<year>\d{4})-\d{2}`}, nil, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	for _, want := range []string{
+		"Flavor: PCRE\n",
+		"group #1 'year': exactly 4 digits\n",
+		"exactly 2 digits\n",
+	} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("expected %q in output, got:\n%s", want, stdout.String())
+		}
+	}
+}
+
+func TestConvertSubcommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "convert", "-from", "pcre", "-to", "javascript", "--color", "never", `(?i)a++\d`},
+		nil, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	if got, want := stdout.String(), "/(?=(a+))\\1\\d/i\n"; got != want {
+		t.Errorf("stdout = %q, want %q", got, want)
+	}
+	if !strings.Contains(stderr.String(), "Note: column 6: possessive quantifier emulated") {
+		t.Errorf("expected a note about the emulation, got:\n%s", stderr.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	err = run([]string{"regolith", "convert", "-f", "pcre", "-t", "golang", "--color", "never", `(a)\1`},
+		nil, &stdout, &stderr)
+	if err == nil {
+		t.Fatal("expected an error for a back-reference in golang")
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("expected no pattern without --lossy, got %q", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "Error: column 4: back-reference cannot be written in golang") {
+		t.Errorf("expected the lossy construct in stderr, got:\n%s", stderr.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	err = run([]string{"regolith", "convert", "-f", "pcre", "-t", "golang", "--lossy", "--color", "never", `(a)\1`},
+		nil, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error with --lossy: %v", err)
+	}
+	if stdout.String() != "(a)\n" || !strings.Contains(stderr.String(), "Warning: column 4:") {
+		t.Errorf("stdout = %q, stderr = %q", stdout.String(), stderr.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if err := run([]string{"regolith", "convert", "a"}, nil, &stdout, &stderr); err == nil {
+		t.Error("expected an error without --to")
+	}
+	if err := run([]string{"regolith", "convert", "-t", "vim", "a"}, nil, &stdout, &stderr); err == nil {
+		t.Error("expected an error converting to vim")
+	}
+}
+
+func TestColorSubcommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "color", "--color", "always", "-f", "pcre", `(a|\d)+`}, nil, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	for _, want := range []string{"\x1b[1;35m(\x1b[0m", "\x1b[32m\\d\x1b[0m", "\x1b[33m+\x1b[0m"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("expected %q in output, got %q", want, stdout.String())
+		}
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if err := run([]string{"regolith", "color", "--color", "never", "a("}, nil, &stdout, &stderr); err == nil {
+		t.Error("expected a parse error for an unbalanced group")
+	}
+	if stdout.String() != "a(\n" {
+		t.Errorf("expected the pattern to be printed anyway, got %q", stdout.String())
+	}
+}
+
+func TestHashSubcommandNullList(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "hash", "-0"}, strings.NewReader("a\x00b\x00"), &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	if lines := strings.Split(strings.TrimSpace(stdout.String()), "\n"); len(lines) != 2 {
+		t.Errorf("expected two hash lines, got %d: %s", len(lines), stdout.String())
+	}
+}
+
+func TestHashSubcommandParseError(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"regolith", "hash", "--color", "never", "a("}, nil, &stdout, &stderr); err == nil {
+		t.Fatal("expected parse error")
+	}
+	if stdout.Len() != 0 || !strings.Contains(stderr.String(), "Error parsing pattern") {
+		t.Errorf("expected error on stderr only, got stdout=%q stderr=%q", stdout.String(), stderr.String())
+	}
+}
+
+func TestRunMetrics(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "--metrics", "--format", "json", "(a|b)+c"}, nil, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	line := stderr.String()
+	for _, key := range []string{"parse_ms=", "render_ms=", "nodes=4 ", "bytes=", `pattern="(a|b)+c"`} {
+		if !strings.Contains(line, key) {
+			t.Errorf("expected %q in metrics line, got: %s", key, line)
+		}
+	}
+}
+
+func TestRunMetricsOutAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.log")
+	for _, pattern := range []string{"a", "b"} {
+		var stdout, stderr bytes.Buffer
+		if err := run([]string{"regolith", "--metrics", "--metrics-out", path, pattern}, nil, &stdout, &stderr); err != nil {
+			t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+		}
+		if strings.Contains(stderr.String(), "metrics ") {
+			t.Error("metrics must not also go to stderr when --metrics-out is set")
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read metrics file: %v", err)
+	}
+	if n := strings.Count(string(data), "metrics "); n != 2 {
+		t.Errorf("expected 2 appended metrics lines, got %d:\n%s", n, data)
+	}
+}
+
+func TestRunProfileMem(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "heap.pprof")
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"regolith", "--profile", "mem", "--profile-out", path, "a+"}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+		t.Errorf("expected a non-empty heap profile: %v", err)
+	}
+}
+
+func TestRunProfileUnknown(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"regolith", "--profile", "gpu", "a"}, nil, &stdout, &stderr); err == nil {
+		t.Fatal("expected error for unknown profile kind")
+	}
+}
+
+func TestRunServeRejectsDefaultOutsideAllowlist(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "serve", "--flavors", "pcre"}, nil, &stdout, &stderr)
+	if err == nil || !strings.Contains(stderr.String(), "not in --flavors") {
+		t.Fatalf("expected allowlist error, got %v: %s", err, stderr.String())
+	}
+}
+
+func TestRunServeRejectsBadShareRange(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "serve", "--share", "--share-allow", "10.0.0.0/8,intranet"}, nil, &stdout, &stderr)
+	if err == nil || !strings.Contains(stderr.String(), `--share-allow: "intranet"`) {
+		t.Fatalf("expected --share-allow error, got %v: %s", err, stderr.String())
+	}
+}
+
+func TestRunEnvConfig(t *testing.T) {
+	t.Run("env sets unset flags", func(t *testing.T) {
+		t.Setenv("REGOLITH_FORMAT", "json")
+		t.Setenv("REGOLITH_FLAVOR", "pcre")
+		var stdout, stderr bytes.Buffer
+		if err := run([]string{"regolith", "a+"}, nil, &stdout, &stderr); err != nil {
+			t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+		}
+		if !strings.Contains(stdout.String(), `"flavor": "pcre"`) {
+			t.Errorf("expected JSON for pcre, got:\n%s", stdout.String())
+		}
+	})
+
+	t.Run("flag beats env", func(t *testing.T) {
+		t.Setenv("REGOLITH_FORMAT", "json")
+		var stdout, stderr bytes.Buffer
+		if err := run([]string{"regolith", "--format", "text", "--color", "never", "a+"}, nil, &stdout, &stderr); err != nil {
+			t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+		}
+		if strings.HasPrefix(stdout.String(), "{") {
+			t.Errorf("--format text should override REGOLITH_FORMAT, got:\n%s", stdout.String())
+		}
+	})
+
+	t.Run("invalid env value", func(t *testing.T) {
+		t.Setenv("REGOLITH_PADDING", "wide")
+		var stdout, stderr bytes.Buffer
+		if err := run([]string{"regolith", "a"}, nil, &stdout, &stderr); err == nil {
+			t.Fatal("expected error for invalid REGOLITH_PADDING")
+		}
+		if !strings.Contains(stderr.String(), "REGOLITH_PADDING") {
+			t.Errorf("error should name the variable, got: %s", stderr.String())
+		}
+	})
+
+	t.Run("version is not read from env", func(t *testing.T) {
+		t.Setenv("REGOLITH_VERSION", "true")
+		var stdout, stderr bytes.Buffer
+		if err := run([]string{"regolith", "--color", "never", "a"}, nil, &stdout, &stderr); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if strings.Contains(stdout.String(), "regolith version") {
+			t.Error("REGOLITH_VERSION must not trigger --version")
+		}
+	})
+}
+
+func TestRunConfigShow(t *testing.T) {
+	t.Setenv("REGOLITH_THEME", "dark")
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"regolith", "config", "show", "serve", "--addr", ":9000"}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	rows := map[string][]string{}
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		if fields := strings.Fields(line); len(fields) == 4 {
+			rows[fields[0]] = fields[1:]
+		}
+	}
+	for name, want := range map[string][]string{
+		"theme":      {"dark", "env", "REGOLITH_THEME"},
+		"addr":       {":9000", "flag", "REGOLITH_ADDR"},
+		"cache-size": {"512", "default", "REGOLITH_CACHE_SIZE"},
+	} {
+		if got := rows[name]; strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("%s: got %v, want %v", name, got, want)
+		}
+	}
+	if _, ok := rows["show-config"]; ok {
+		t.Error("show-config must not be listed")
+	}
+}
+
+func TestRunConfigShowEveryCommand(t *testing.T) {
+	t.Setenv("REGOLITH_FLAVOR", "java")
+	for _, name := range configCommands {
+		var stdout, stderr bytes.Buffer
+		if err := run([]string{"regolith", "config", "show", name}, nil, &stdout, &stderr); err != nil {
+			t.Errorf("config show %s: %v\nstderr: %s", name, err, stderr.String())
+			continue
+		}
+		if !strings.Contains(stdout.String(), "SETTING") {
+			t.Errorf("config show %s printed no settings:\n%s", name, stdout.String())
+		}
+		if name == "explain" && !strings.Contains(stdout.String(), "REGOLITH_FLAVOR") {
+			t.Errorf("config show explain should list flavor, got:\n%s", stdout.String())
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	_ = run([]string{"regolith", "explain", "-h"}, nil, &stdout, &stderr)
+	if !strings.Contains(stderr.String(), "REGOLITH_* environment variable") {
+		t.Errorf("help should mention the environment variables, got:\n%s", stderr.String())
+	}
+}
+
+func TestRunConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "regolith.toml")
+	write := func(name, text string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	write("regolith.toml", `# team defaults
+theme = "dark"
+padding = 30
+flavors = ["pcre", "java"]
+
+[render]
+font-size = 18 # wins over nothing
+theme = "catppuccin-mocha"
+`)
+	t.Setenv(configFileEnv, config)
+
+	show := func(args ...string) map[string][]string {
+		t.Helper()
+		var stdout, stderr bytes.Buffer
+		if err := run(append([]string{"regolith", "config", "show"}, args...), nil, &stdout, &stderr); err != nil {
+			t.Fatalf("config show %v: %v\nstderr: %s", args, err, stderr.String())
+		}
+		if !strings.HasPrefix(stdout.String(), "# config file: "+config+"\n") {
+			t.Errorf("expected the config file to be named first, got:\n%s", stdout.String())
+		}
+		rows := map[string][]string{}
+		for _, line := range strings.Split(stdout.String(), "\n") {
+			if fields := strings.Fields(line); len(fields) == 4 {
+				rows[fields[0]] = fields[1:3]
+			}
+		}
+		return rows
+	}
+	for name, want := range map[string]string{
+		"theme":     "catppuccin-mocha file",
+		"font-size": "18 file",
+		"padding":   "30 file",
+	} {
+		if got := strings.Join(show()[name], " "); got != want {
+			t.Errorf("render %s: got %q, want %q", name, got, want)
+		}
+	}
+	serve := show("serve")
+	if got := strings.Join(serve["theme"], " "); got != "dark file" {
+		t.Errorf("serve theme: got %q, want the top-level value", got)
+	}
+	if got := strings.Join(serve["flavors"], " "); got != "[pcre,java] file" {
+		t.Errorf("serve flavors: got %q", got)
+	}
+
+	// The environment and the command line both win over the file.
+	t.Setenv("REGOLITH_PADDING", "12")
+	rows := show("--theme", "light")
+	if got := strings.Join(rows["padding"], " "); got != "12 env" {
+		t.Errorf("padding: got %q, want the env value", got)
+	}
+	if got := strings.Join(rows["theme"], " "); got != "light flag" {
+		t.Errorf("theme: got %q, want the flag value", got)
+	}
+
+	// YAML works the same way.
+	t.Setenv(configFileEnv, write("regolith.yaml", "theme: dark\nrender:\n  line-width: 3\n"))
+	config = filepath.Join(dir, "regolith.yaml")
+	if got := strings.Join(show()["line-width"], " "); got != "3 file" {
+		t.Errorf("YAML line-width: got %q", got)
+	}
+
+	for _, tc := range []struct{ name, text, want string }{
+		{"typo.toml", "[render]\nfont-szie = 12\n", "[render] font-szie: no such setting"},
+		{"section.toml", "[rendr]\ntheme = \"dark\"\n", `unknown command "rendr"`},
+		{"value.toml", "font-size = \"big\"\n", `font-size="big"`},
+		{"syntax.toml", "theme = dark\n", "1: theme: unsupported value dark"},
+	} {
+		t.Setenv(configFileEnv, write(tc.name, tc.text))
+		var stdout, stderr bytes.Buffer
+		err := run([]string{"regolith", "--format", "json", "a"}, nil, &stdout, &stderr)
+		if err == nil || !strings.Contains(stderr.String(), tc.want) {
+			t.Errorf("%s: expected %q, got %v: %s", tc.name, tc.want, err, stderr.String())
+		}
+	}
+}
+
+// TestRunConfigFileEverySection runs each command config show knows
+// with a config file holding a table for every one of them.
+func TestRunConfigFileEverySection(t *testing.T) {
+	var text strings.Builder
+	for _, name := range configSections {
+		fmt.Fprintf(&text, "[%s]\n", name)
+		if name == "flavors" {
+			text.WriteString("format = \"json\"\n")
+		}
+	}
+	path := filepath.Join(t.TempDir(), "regolith.toml")
+	if err := os.WriteFile(path, []byte(text.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(configFileEnv, path)
+
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"regolith", "flavors", "pcre"}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("flavors with a [flavors] table: %v\nstderr: %s", err, stderr.String())
+	}
+	if !strings.HasPrefix(stdout.String(), "{") {
+		t.Errorf("expected the [flavors] table's JSON format, got:\n%s", stdout.String())
+	}
+}
+
+func TestRunConfigFileUserAndProject(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("AppData", filepath.Join(home, "AppData"))
+	userDir, err := os.UserConfigDir()
+	if err != nil {
+		t.Skip(err)
+	}
+	if err := os.MkdirAll(userDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	user := "theme = \"dark\"\npadding = 30\noutput = \"mine.svg\"\n"
+	if err := os.WriteFile(filepath.Join(userDir, "regolith.toml"), []byte(user), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	project := t.TempDir()
+	t.Chdir(project)
+	// A checkout cannot point output or templates at files of its choosing.
+	text := "theme = \"light\"\n[render]\noutput = \"/tmp/evil.svg\"\ntemplate = \"/etc/passwd\"\n"
+	if err := os.WriteFile(".regolith.toml", []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(configFileEnv, "") // restored after the test
+	_ = os.Unsetenv(configFileEnv)
+
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"regolith", "config", "show"}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("config show: %v\nstderr: %s", err, stderr.String())
+	}
+	out := stdout.String()
+	wantHeader := "# config file: " + filepath.Join(userDir, "regolith.toml") + "\n# config file: .regolith.toml\n"
+	if !strings.HasPrefix(out, wantHeader) {
+		t.Errorf("expected both files named, user first, got:\n%s", out)
+	}
+	rows := map[string]string{}
+	for _, line := range strings.Split(out, "\n") {
+		if fields := strings.Fields(line); len(fields) == 4 {
+			rows[fields[0]] = fields[1] + " " + fields[2]
+		}
+	}
+	for name, want := range map[string]string{
+		"theme":    "light file", // the project file wins
+		"padding":  "30 file",    // the user file still applies
+		"output":   "mine.svg file",
+		"template": `"" default`,
+	} {
+		if rows[name] != want {
+			t.Errorf("%s: got %q, want %q", name, rows[name], want)
+		}
+	}
+}
+
+func TestParseTOML(t *testing.T) {
+	doc, err := parseTOML([]byte(`
+a = "x \"q\""   # comment
+"c-d" = 'lit\eral'
+e = [1, "two", true]
+f = 1_000.5
+h = "\e[1m\u00e9\U0001F600\t"
+i = [0x1_F, 0o17, 0b11, -2e1_0, +inf]
+
+[serve]
+g = false
+`))
+	if err != nil {
+		t.Fatalf("parseTOML: %v", err)
+	}
+	want := map[string]any{
+		"a":     `x "q"`,
+		"c-d":   `lit\eral`,
+		"e":     []any{1.0, "two", true},
+		"f":     1000.5,
+		"h":     "\x1b[1mé😀\t",
+		"i":     []any{31.0, 15.0, 3.0, -2e10, math.Inf(1)},
+		"serve": map[string]any{"g": false},
+	}
+	if fmt.Sprint(doc) != fmt.Sprint(want) {
+		t.Errorf("parseTOML = %v, want %v", doc, want)
+	}
+
+	for _, bad := range []string{
+		"a = 1\na = 2",
+		"[t]\n[t]",
+		"[[t]]",
+		"a.b = 1",
+		"a = [1,\n2]",
+		`a = """x"""`,
+		"a = 1 2",
+		"a",
+		"'a' = 1",
+		// Go's escapes and number forms that TOML does not have.
+		`a = "\x41"`,
+		`a = "\a"`,
+		`a = "\v"`,
+		`a = "\101"`,
+		`a = "\u12"`,
+		`a = "\uD800"`,
+		"a = \"tab\x01\"",
+		"a = 0x1p3",
+		"a = Inf",
+		"a = NaN",
+		"a = 01",
+		"a = 1__0",
+		"a = _1",
+		"a = 1.",
+		"a = .5",
+		"a = +0x1",
+		"a = +-inf",
+	} {
+		if _, err := parseTOML([]byte(bad)); err == nil {
+			t.Errorf("parseTOML(%q) accepted unsupported input", bad)
+		}
+	}
+}
+
+func TestRenderConfigRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"regolith", "config", "render", "--theme", "dark", "--padding", "30"}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	config := filepath.Join(dir, "render.json")
+	if err := os.WriteFile(config, stdout.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	render := func(args ...string) string {
+		t.Helper()
+		out := filepath.Join(dir, "out.svg")
+		args = append([]string{"regolith", "--format", "svg", "--reproducible", "-o", out}, args...)
+		if err := run(append(args, "a+(b|c)"), nil, &stdout, &stderr); err != nil {
+			t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+		}
+		data, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	if fromFile, fromFlags := render("--render-config", config), render("--theme", "dark", "--padding", "30"); fromFile != fromFlags {
+		t.Error("--render-config from config render differs from the flags it was written with")
+	}
+
+	if err := os.WriteFile(config, []byte(`{"nodeStyles": {"literal": {"fill": "#00ff00"}}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if svg := render("--render-config", config); !strings.Contains(svg, "#00ff00") {
+		t.Error("partial render config: literal fill not applied")
+	}
+	if svg := render("--render-config", config, "--literal-fill", "#0000ff"); !strings.Contains(svg, "#0000ff") || strings.Contains(svg, "#00ff00") {
+		t.Error("--literal-fill should win over the render config")
+	}
+
+	if err := os.WriteFile(config, []byte(`{"paddng": 1}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stderr.Reset()
+	err := run([]string{"regolith", "--format", "svg", "-o", filepath.Join(dir, "bad.svg"), "--render-config", config, "a"}, nil, &stdout, &stderr)
+	if err == nil || !strings.Contains(stderr.String(), `unknown field "paddng"`) {
+		t.Errorf("expected an unknown field error, got %v\nstderr: %s", err, stderr.String())
+	}
+}
+
+func TestClassesSubcommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"regolith", "classes"}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	for _, want := range []string{"any-character", "comment", "lazy-preferred", "src-quantifier", "analysis-badge"} {
+		if !strings.Contains(stdout.String(), "\n"+want+" ") {
+			t.Errorf("output missing class %q", want)
+		}
+	}
+
+	stdout.Reset()
+	if err := run([]string{"regolith", "classes", "--format", "json"}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var classes []map[string]string
+	if err := json.Unmarshal(stdout.Bytes(), &classes); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(classes) == 0 || classes[0]["name"] != "literal" || classes[0]["kind"] != "node" {
+		t.Errorf("first class = %v", classes)
+	}
+}
+
+func TestFlavorsSubcommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"regolith", "flavors"}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	if !strings.HasPrefix(stdout.String(), "Perl-style:\n") || !strings.Contains(stdout.String(), "\nEditor:\n  vim ") {
+		t.Errorf("expected flavors grouped by family, got:\n%s", stdout.String())
+	}
+
+	stdout.Reset()
+	if err := run([]string{"regolith", "flavors", "go"}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	for _, want := range []string{
+		"golang - Go regexp", "Family: Perl-style", "Aliases: go\n",
+		"  Named groups        (?P<year>\\d{4})\n", "Not supported:\n  Lookahead, Lookbehind,",
+		"  U  ungreedy ", "Limitations compared to the real engine:\n  - ",
+	} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, stdout.String())
+		}
+	}
+
+	stdout.Reset()
+	if err := run([]string{"regolith", "flavors", "--format", "json", "posix-bre"}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var doc flavor.Doc
+	if err := json.Unmarshal(stdout.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if doc.Family != flavor.FamilyPOSIXBasic || len(doc.Supported) != 1 || doc.Supported[0].Key != "posix_classes" {
+		t.Errorf("posix-bre doc = %+v", doc)
+	}
+
+	if err := run([]string{"regolith", "flavors", "perl"}, nil, &stdout, &stderr); err == nil {
+		t.Error("expected an error for an unknown flavor")
+	}
+}
+
+func TestTeachSubcommand(t *testing.T) {
+	dir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "teach", "--color", "never", "-f", "javascript,posix-bre", dir}, nil, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Wrote 10 examples in 2 flavors") {
+		t.Errorf("stdout = %q", stdout.String())
+	}
+	for _, name := range []string{"email-javascript.svg", "ipv4-posix-bre.md", "index.html"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Error(err)
+		}
+	}
+	md, _ := os.ReadFile(filepath.Join(dir, "ipv4-posix-bre.md"))
+	for _, want := range []string{"# IPv4 address (POSIX BRE)", "**Note:**", "![IPv4 address diagram](ipv4-posix-bre.svg)", "## How it reads"} {
+		if !strings.Contains(string(md), want) {
+			t.Errorf("handout missing %q:\n%s", want, md)
+		}
+	}
+	index, _ := os.ReadFile(filepath.Join(dir, "index.html"))
+	if !strings.Contains(string(index), "Log line") {
+		t.Error("index.html is missing the example titles")
+	}
+
+	if err := run([]string{"regolith", "teach", "-f", "nope", dir}, nil, &stdout, &stderr); err == nil {
+		t.Error("unknown flavor: expected an error")
+	}
+}
+
+func TestRunQuery(t *testing.T) {
+	t.Run("match", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		err := run([]string{"regolith", "query", "--color", "never", "--find", "negative lookbehind", "a(?<!b)c"}, nil, &stdout, &stderr)
+		if err != nil {
+			t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+		}
+		if got := stdout.String(); got != "/root/elements/1  col 2-7  negative lookbehind\n" {
+			t.Errorf("unexpected output: %q", got)
+		}
+	})
+
+	t.Run("no match exits non-zero", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		err := run([]string{"regolith", "query", "--find", "lookbehind", "abc"}, nil, &stdout, &stderr)
+		if !errors.Is(err, errNoMatches) {
+			t.Errorf("expected errNoMatches, got %v", err)
+		}
+		if stdout.Len() != 0 || stderr.Len() != 0 {
+			t.Errorf("expected no output, got stdout %q stderr %q", stdout.String(), stderr.String())
+		}
+	})
+
+	t.Run("null list with json", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		stdin := strings.NewReader("a\x00[x-]\x00")
+		err := run([]string{"regolith", "query", "-0", "--format", "json", "--find", "charset containing -"}, stdin, &stdout, &stderr)
+		if err != nil {
+			t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+		}
+		var hits []queryHit
+		if err := json.Unmarshal(stdout.Bytes(), &hits); err != nil {
+			t.Fatalf("invalid JSON: %v\n%s", err, stdout.String())
+		}
+		if len(hits) != 1 || hits[0].Seq != 2 || hits[0].Pointer != "/root/elements/0" {
+			t.Errorf("unexpected hits: %+v", hits)
+		}
+	})
+
+	t.Run("json offsets", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		err := run([]string{"regolith", "query", "--format", "json", "--find", "literal", "[<]a<b"}, nil, &stdout, &stderr)
+		if err != nil {
+			t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+		}
+		if strings.Contains(stdout.String(), `\u003c`) || !strings.Contains(stdout.String(), `"pattern": "[<]a<b"`) {
+			t.Errorf("pattern should be written as is:\n%s", stdout.String())
+		}
+		var hits []queryHit
+		if err := json.Unmarshal(stdout.Bytes(), &hits); err != nil {
+			t.Fatalf("invalid JSON: %v\n%s", err, stdout.String())
+		}
+		// A class member has no recorded position; the literal after
+		// the class spans bytes 3 to 6.
+		if len(hits) != 2 || hits[0].Start != nil || hits[1].Start == nil || *hits[1].Start != 3 || *hits[1].End != 6 {
+			t.Errorf("unexpected hits: %s", stdout.String())
+		}
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		err := run([]string{"regolith", "query", "--find", "lazy charset", "a"}, nil, &stdout, &stderr)
+		if err == nil || !strings.Contains(stderr.String(), "does not apply") {
+			t.Errorf("expected query error, got %v: %s", err, stderr.String())
+		}
+	})
+}
+
+func TestRunMatch(t *testing.T) {
+	t.Run("table", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		err := run([]string{"regolith", "match", "-f", "pcre", `(?<y>\d{4})(x)?`, "in 2024"}, nil, &stdout, &stderr)
+		if err != nil {
+			t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+		}
+		want := "Match 1  3-7  \"2024\"\n" +
+			"  GROUP  NAME  SPAN  TEXT\n" +
+			"  1      y     3-7   \"2024\"\n" +
+			"  2            -     (no match)\n"
+		if got := stdout.String(); got != want {
+			t.Errorf("got\n%s\nwant\n%s", got, want)
+		}
+	})
+
+	t.Run("json from stdin", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		err := run([]string{"regolith", "match", "--format", "json", `(?<w>\w)\w*`}, strings.NewReader("ab cd"), &stdout, &stderr)
+		if err != nil {
+			t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+		}
+		var res matchResult
+		if err := json.Unmarshal(stdout.Bytes(), &res); err != nil {
+			t.Fatalf("invalid JSON: %v\n%s", err, stdout.String())
+		}
+		if len(res.Matches) != 2 || res.Matches[1].Text != "cd" || res.Matches[1].Named["w"] != "c" || res.Engine != "regexp2" {
+			t.Errorf("unexpected result: %+v", res)
+		}
+	})
+
+	t.Run("svg captions", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "match.svg")
+		var stdout, stderr bytes.Buffer
+		err := run([]string{"regolith", "match", "--format", "svg", "-o", out, "(a+)(b*)", "aab"}, nil, &stdout, &stderr)
+		if err != nil {
+			t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+		}
+		data, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{`data-capture="aa"`, `data-capture="b"`, `class="capture">= &#34;aa&#34;<`} {
+			if !strings.Contains(string(data), want) {
+				t.Errorf("SVG missing %s", want)
+			}
+		}
+	})
+
+	t.Run("test matrix", func(t *testing.T) {
+		cases := filepath.Join(t.TempDir(), "cases.txt")
+		if err := os.WriteFile(cases, []byte("# years\n+ 2024\n- 20245\n\n- x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		var stdout, stderr bytes.Buffer
+		err := run([]string{"regolith", "match", "--color", "never", "--test", cases, `^\d{4}$`, `\d{4}`}, nil, &stdout, &stderr)
+		if !errors.Is(err, errTestsFailed) {
+			t.Fatalf("expected errTestsFailed, got %v\nstderr: %s", err, stderr.String())
+		}
+		for _, want := range []string{"P2  \\d{4}\n", "3     \"20245\"  no match  PASS  FAIL\n", "1 of 6 failed\n"} {
+			if !strings.Contains(stdout.String(), want) {
+				t.Errorf("output missing %q:\n%s", want, stdout.String())
+			}
+		}
+
+		stdout.Reset()
+		err = run([]string{"regolith", "match", "--format", "json", "--test", "-", `^\d{4}$`}, strings.NewReader("+ 2024\n"), &stdout, &stderr)
+		if err != nil {
+			t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+		}
+		var report testReport
+		if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+			t.Fatalf("invalid JSON: %v\n%s", err, stdout.String())
+		}
+		if report.Failures != 0 || len(report.Cases) != 1 || !report.Cases[0].Results[0].Pass {
+			t.Errorf("unexpected report: %+v", report)
+		}
+
+		err = run([]string{"regolith", "match", "--test", "-", "a"}, strings.NewReader("2024\n"), &stdout, &stderr)
+		if err == nil || !strings.Contains(stderr.String(), "line 1: want '+ input' or '- input'") {
+			t.Errorf("expected a label error, got %v: %s", err, stderr.String())
+		}
+	})
+
+	t.Run("no match exits non-zero", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		err := run([]string{"regolith", "match", "-f", "posix-ere", "z", "abc"}, nil, &stdout, &stderr)
+		if !errors.Is(err, errNoMatches) || stdout.String() != "no match\n" {
+			t.Errorf("expected errNoMatches and \"no match\", got %v, %q", err, stdout.String())
+		}
+	})
+}
+
+func TestRunSVGDiff(t *testing.T) {
+	dir := t.TempDir()
+	render := func(name, pattern string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		var stdout, stderr bytes.Buffer
+		if err := run([]string{"regolith", "--format", "svg", "-o", path, pattern}, nil, &stdout, &stderr); err != nil {
+			t.Fatalf("render %q: %v\nstderr: %s", pattern, err, stderr.String())
+		}
+		return path
+	}
+	a, b := render("a.svg", "ab"), render("b.svg", "a(b)")
+
+	var stdout, stderr bytes.Buffer
+	overlay := filepath.Join(dir, "overlay.svg")
+	err := run([]string{"regolith", "svgdiff", "--color", "never", "-o", overlay, a, b}, nil, &stdout, &stderr)
+	if !errors.Is(err, errSVGsDiffer) {
+		t.Fatalf("expected errSVGsDiffer, got %v\nstderr: %s", err, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "changed svg: ") || !strings.Contains(stdout.String(), "Wrote "+overlay) {
+		t.Errorf("unexpected output:\n%s", stdout.String())
+	}
+	data, err := os.ReadFile(overlay)
+	if err != nil || !strings.Contains(string(data), "svgdiff-added") {
+		t.Errorf("overlay missing added highlight: %v", err)
+	}
+
+	stdout.Reset()
+	if err := run([]string{"regolith", "svgdiff", a, a}, nil, &stdout, &stderr); err != nil || stdout.Len() != 0 {
+		t.Errorf("identical files: %v, %q", err, stdout.String())
+	}
+}
+
+func TestRunAudit(t *testing.T) {
+	dir := t.TempDir()
+	src := "const ok = /^\\d+$/;\nconst bad = /^(a+)+$/;\n"
+	if err := os.WriteFile(filepath.Join(dir, "app.js"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "audit", "--diagrams=false", dir + "/..."}, strings.NewReader(""), &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	var doc struct {
+		Patterns []struct {
+			Pattern string `json:"pattern"`
+			Line    int    `json:"line"`
+		} `json:"patterns"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout.String())
+	}
+	// Sorted worst first: the nested quantifier leads.
+	if len(doc.Patterns) != 2 || doc.Patterns[0].Line != 2 {
+		t.Errorf("patterns = %+v", doc.Patterns)
+	}
+
+	stdout.Reset()
+	err = run([]string{"regolith", "audit", "--diagrams=false", "--fail-on", "error", dir}, strings.NewReader(""), &stdout, &stderr)
+	if !errors.Is(err, errAuditFailed) {
+		t.Errorf("--fail-on error: got %v, want errAuditFailed", err)
+	}
+
+	out := filepath.Join(dir, "report")
+	if err := run([]string{"regolith", "audit", "--format", "html", "-o", out, dir}, strings.NewReader(""), &stdout, &stderr); err != nil {
+		t.Fatal(err)
+	}
+	html, err := os.ReadFile(out + ".html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(html, []byte("<svg")) {
+		t.Error("HTML report has no diagrams")
+	}
+}
+
+func TestRunReproducibleSVG(t *testing.T) {
+	dir := t.TempDir()
+	render := func(name string, extra ...string) string {
+		t.Helper()
+		out := filepath.Join(dir, name)
+		var stdout, stderr bytes.Buffer
+		args := append([]string{"regolith", "--format", "svg", "-o", out}, extra...)
+		if err := run(append(args, "a|b"), nil, &stdout, &stderr); err != nil {
+			t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+		}
+		b, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	t.Setenv("SOURCE_DATE_EPOCH", "")
+	plain := render("plain.svg")
+	if !strings.Contains(plain, "version: "+version) || !strings.Contains(plain, "generated: ") {
+		t.Errorf("default SVG should carry version and timestamp:\n%.300s", plain)
+	}
+
+	first, second := render("r1.svg", "--reproducible"), render("r2.svg", "--reproducible")
+	if first != second {
+		t.Error("--reproducible output differs between runs")
+	}
+	if strings.Contains(first, "version:") || strings.Contains(first, "generated:") {
+		t.Errorf("--reproducible should omit version and timestamp:\n%.300s", first)
+	}
+	if !strings.Contains(first, `pattern: "a|b"`) || !strings.Contains(first, "flavor: javascript") {
+		t.Errorf("--reproducible should keep pattern and flavor:\n%.300s", first)
+	}
+
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	if pinned := render("pinned.svg", "--reproducible"); !strings.Contains(pinned, "generated: 2023-11-14T22:13:20Z") {
+		t.Errorf("SOURCE_DATE_EPOCH should pin the timestamp:\n%.300s", pinned)
+	}
+
+	t.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "--format", "svg", "-o", filepath.Join(dir, "bad.svg"), "a"}, nil, &stdout, &stderr)
+	if err == nil || !strings.Contains(stderr.String(), "SOURCE_DATE_EPOCH") {
+		t.Errorf("expected SOURCE_DATE_EPOCH error, got %v: %s", err, stderr.String())
+	}
+}
+
+func TestRunTraceParse(t *testing.T) {
+	trace := filepath.Join(t.TempDir(), "trace.txt")
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "--check", "--color", "never", "--trace-parse", trace, "a(b"}, nil, &stdout, &stderr)
+	if err == nil {
+		t.Fatal("expected a parse error for an unclosed group")
+	}
+	data, err := os.ReadFile(trace)
+	if err != nil {
+		t.Fatalf("failed to read trace: %v", err)
+	}
+	for _, want := range []string{
+		`# regolith ` + version + `, flavor javascript, pattern "a(b"`,
+		"enter Root at 1:1:0",
+		"exit Root at 1:1:0 failed",
+		"# result: parse error:",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected %q in trace, got:\n%s", want, data)
+		}
+	}
+}
+
+func TestRunPCREVersion(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "-f", "pcre", "--check", "--color", "never", "--pcre-version", "10.33", "(*napla:a)b"}, nil, &stdout, &stderr)
+	if err == nil {
+		t.Fatal("expected a non-atomic lookahead to fail against PCRE2 10.33")
+	}
+	if !strings.Contains(stdout.String(), "requires PCRE2 10.34 or later") {
+		t.Errorf("expected the minimum version in the error, got: %s", stdout.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if err := run([]string{"regolith", "--check", "--pcre-version", "10.44", "a"}, nil, &stdout, &stderr); err == nil {
+		t.Error("expected --pcre-version to require the pcre flavor")
+	}
+}
+
+func TestRunJSTarget(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "--check", "--color", "never", "--js-target", "es5", `/(?<=\$)\d+/`}, nil, &stdout, &stderr)
+	if err == nil {
+		t.Fatal("expected lookbehind to fail against ES5")
+	}
+	if !strings.Contains(stdout.String(), "lookbehind requires ES2018 or later") {
+		t.Errorf("expected the minimum edition in the error, got: %s", stdout.String())
+	}
+}
+
+func TestRunJavaFlags(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out.svg")
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"regolith", "-f", "java", "--format", "svg", "--java-flags", "LITERAL,CANON_EQ", "-o", out, "a.b*"}, nil, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("failed to read SVG: %v", err)
+	}
+	for _, want := range []string{"LITERAL: whole pattern is literal text", "CANON_EQ: canonically equivalent text matches"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected %q in the flags panel", want)
+		}
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if err := run([]string{"regolith", "--java-flags", "LITERAL", "a"}, nil, &stdout, &stderr); err == nil {
+		t.Error("expected --java-flags to require the java flavor")
+	}
+}
+
+func TestRunNullSeparatedParallelKeepsOrder(t *testing.T) {
+	var patterns []string
+	for i := 0; i < 40; i++ {
+		patterns = append(patterns, fmt.Sprintf("a{%d}", i))
+	}
+	patterns[7] = "a("
+
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader(strings.Join(patterns, "\x00"))
+	err := run([]string{"regolith", "-0", "--jobs", "8", "--format", "json"}, stdin, &stdout, &stderr)
+	if err == nil {
+		t.Fatal("expected the failing pattern to set the exit status")
+	}
+	last := -1
+	for i, p := range patterns {
+		if i == 7 {
+			continue
+		}
+		at := strings.Index(stdout.String(), fmt.Sprintf("%q", p))
+		if at < 0 || at < last {
+			t.Fatalf("pattern %d missing or out of order in output", i)
+		}
+		last = at
+	}
+	if !strings.Contains(stderr.String(), "Processed 40 patterns") || !strings.Contains(stderr.String(), "39 ok, 1 failed") {
+		t.Errorf("expected a batch summary on stderr, got: %s", stderr.String())
+	}
+}
+
+func TestRunFormatHTML(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"regolith", "--format", "html", "-f", "pcre", `(?<y>\d+)$`}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	page := stdout.String()
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		`data-fragment="(?&lt;y&gt;\d+)"`,
+		`data-explain="Matches any digit \d (0-9)"`,
+		`data-notes="Matches ASCII 0-9 only unless PCRE2_UCP or (*UCP) is set."`,
+		`data-docs="https://www.pcre.org/current/doc/html/pcre2pattern.html"`,
+		`data-src-end="9" data-src-start="0"`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("html output missing %q", want)
+		}
+	}
+
+	out := filepath.Join(t.TempDir(), "explain.html")
+	stdout.Reset()
+	if err := run([]string{"regolith", "-o", out, "a+b"}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	data, err := os.ReadFile(out)
+	if err != nil || !strings.Contains(string(data), `data-fragment="a"`) {
+		t.Fatalf("-o explain.html should write an interactive page, got %v", err)
+	}
+}
+
+func TestRunVersionCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"regolith", "version"}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+	if !strings.HasPrefix(stdout.String(), "regolith version "+version+"\n") || !strings.Contains(stdout.String(), runtime.GOOS+"/"+runtime.GOARCH) {
+		t.Errorf("unexpected text output:\n%s", stdout.String())
+	}
+
+	stdout.Reset()
+	if err := run([]string{"regolith", "version", "--json"}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("--json: %v", err)
+	}
+	var info buildInfo
+	if err := json.Unmarshal(stdout.Bytes(), &info); err != nil {
+		t.Fatalf("--json is not JSON: %v\n%s", err, stdout.String())
+	}
+	if info.Version != version || info.GoVersion != runtime.Version() || info.Signed {
+		t.Errorf("unexpected build info: %+v", info)
+	}
+}
+
+func TestRunSelfUpdate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test release only has a tarball")
+	}
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newBinary := []byte("new binary")
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	_ = tw.WriteHeader(&tar.Header{Name: "regolith", Mode: 0755, Size: int64(len(newBinary)), Typeflag: tar.TypeReg})
+	_, _ = tw.Write(newBinary)
+	_ = tw.Close()
+	_ = gz.Close()
+	name := selfupdate.ArchiveName("9.0.0", runtime.GOOS, runtime.GOARCH)
+	sum := sha256.Sum256(archive.Bytes())
+	sums := []byte(hex.EncodeToString(sum[:]) + "  " + name + "\n")
+	assets := map[string][]byte{
+		name:                      archive.Bytes(),
+		selfupdate.ChecksumsAsset: sums,
+		selfupdate.SignatureAsset: []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, sums))),
+	}
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/latest" {
+			_, _ = fmt.Fprintf(w, `{"tag_name": "v9.0.0", "assets": [`)
+			sep := ""
+			for n := range assets {
+				_, _ = fmt.Fprintf(w, `%s{"name": %q, "browser_download_url": %q}`, sep, n, srv.URL+"/"+n)
+				sep = ","
+			}
+			_, _ = fmt.Fprint(w, "]}")
+			return
+		}
+		data, ok := assets[strings.TrimPrefix(r.URL.Path, "/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	defer srv.Close()
+
+	exe := filepath.Join(t.TempDir(), "regolith")
+	if err := os.WriteFile(exe, []byte("old binary"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	oldAPI, oldKey, oldExe := updateAPI, releaseKey, executablePath
+	defer func() { updateAPI, releaseKey, executablePath = oldAPI, oldKey, oldExe }()
+	updateAPI = srv.URL + "/latest"
+	executablePath = func() (string, error) { return exe, nil }
+
+	var stdout, stderr bytes.Buffer
+	err = run([]string{"regolith", "self-update"}, nil, &stdout, &stderr)
+	if err == nil || !strings.Contains(stderr.String(), "no release signing key") {
+		t.Errorf("expected an unsigned build to refuse, got %v: %s", err, stderr.String())
+	}
+
+	releaseKey = base64.StdEncoding.EncodeToString(pub)
+	stdout.Reset()
+	if err := run([]string{"regolith", "self-update", "--check"}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("--check: %v\nstderr: %s", err, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "regolith 9.0.0 is available") {
+		t.Errorf("--check should report the new release, got %q", stdout.String())
+	}
+	if data, _ := os.ReadFile(exe); string(data) != "old binary" {
+		t.Error("--check replaced the binary")
+	}
+
+	stdout.Reset()
+	if err := run([]string{"regolith", "self-update"}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("self-update: %v\nstderr: %s", err, stderr.String())
+	}
+	if data, _ := os.ReadFile(exe); !bytes.Equal(data, newBinary) {
+		t.Errorf("binary not replaced: %q", data)
+	}
+
+	assets[name] = []byte("tampered")
+	stderr.Reset()
+	err = run([]string{"regolith", "self-update"}, nil, &stdout, &stderr)
+	if err == nil || !strings.Contains(stderr.String(), "checksum mismatch") {
+		t.Errorf("expected a tampered archive to be rejected, got %v: %s", err, stderr.String())
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/muesli/termenv"
+	flag "github.com/spf13/pflag"
+
+	"github.com/0x4d5352/regolith/internal/manifest"
+	"github.com/0x4d5352/regolith/internal/output"
+)
+
+// --manifest renders every pattern a manifest file lists, each with its
+// own flavor, flags, theme and output (see package manifest). Options
+// the manifest leaves out come from the command line, so one
+// invocation can still set --format or --compact for the whole build.
+
+// manifestExempt lists render flags that are not passed on to each
+// entry's run: they drive the batch itself or are replaced per entry.
+var manifestExempt = map[string]bool{
+	"manifest":    true,
+	"output":      true,
+	"gallery":     true,
+	"jobs":        true,
+	"show-config": true,
+}
+
+// runManifest renders each entry of the manifest at path (a file, an
+// http(s) URL, or "-" for stdin) by re-entering runRender with the command line's own flags
+// followed by the entry's, so an entry gets exactly the pipeline a
+// single pattern would. Like --null it keeps going past a failing
+// entry and returns the first error after a summary, and with --format
+// svg it writes a gallery, unless gallery is false, whose cards carry
+// each entry's title and annotations.
+func runManifest(path string, fs *flag.FlagSet, common *commonFlags, gallery bool, stdin io.Reader, stdout, stderr io.Writer) error {
+	fail := func(err error) error {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+	switch {
+	case len(fs.Args()) > 0:
+		return fail(fmt.Errorf("--manifest takes no pattern argument (got %q)", fs.Args()[0]))
+	case fs.Changed("null") || fs.Changed("from-json") || fs.Changed("trace-parse") || fs.Changed("input"):
+		return fail(fmt.Errorf("--manifest cannot be combined with --null, --from-json, --input or --trace-parse"))
+	}
+
+	data, err := readSource(path, stdin)
+	if err != nil {
+		return fail(fmt.Errorf("reading %s: %w", path, err))
+	}
+	m, err := manifest.Parse(sourceName(path), data)
+	if err != nil {
+		return fail(err)
+	}
+
+	// Entries without an output of their own share -o, which must then
+	// name a different file for each of them.
+	shared := 0
+	for _, e := range m.Patterns {
+		if e.Output == "" {
+			shared++
+		}
+	}
+	if shared > 1 && common.Output == stdoutPath {
+		return fail(fmt.Errorf("-o - can hold only one of the %d patterns; use an -o template with %%n or %%p or give each an output", shared))
+	}
+	if shared > 1 && common.Output != "" && !templateVariesPerPattern(common.Output) {
+		return fail(fmt.Errorf("-o %q would be overwritten by each of %d patterns; include %%n or %%p (e.g. out-%%n.svg) or give each an output", common.Output, shared))
+	}
+
+	var base []string
+	fs.Visit(func(fl *flag.Flag) {
+		if manifestExempt[fl.Name] {
+			return
+		}
+		if sv, ok := fl.Value.(flag.SliceValue); ok {
+			for _, v := range sv.GetSlice() {
+				base = append(base, "--"+fl.Name+"="+v)
+			}
+			return
+		}
+		base = append(base, "--"+fl.Name+"="+fl.Value.String())
+	})
+
+	start := time.Now()
+	var g galleryRecorder
+	var firstErr error
+	failed := 0
+	for i, e := range m.Patterns {
+		seq := i + 1
+		flavorName := common.Flavor
+		if e.Flavor != "" {
+			flavorName = e.Flavor
+		}
+		if f, ok := lookupFlavor(flavorName, stderr); ok {
+			flavorName = f.Name()
+		}
+		out := common.Output
+		if e.Output != "" {
+			// A remote manifest's outputs are relative to the working
+			// directory.
+			if isURL(path) {
+				out = e.OutputPath("")
+			} else {
+				out = e.OutputPath(path)
+			}
+		}
+		// The entry's run sees a plain path: placeholders are filled in
+		// here, where the sequence number is known.
+		out = expandOutputTemplate(out, outputVars{Seq: seq, Pattern: e.Pattern, Flavor: flavorName, Time: start})
+
+		// An explicitly empty --manifest keeps a REGOLITH_MANIFEST
+		// variable from turning the entry's run into another batch, and
+		// an empty --input a REGOLITH_INPUT one from replacing its
+		// pattern.
+		args := append([]string{"regolith", "--manifest=", "--input="}, base...)
+		for _, opt := range []struct{ name, value string }{
+			{"flavor", e.Flavor},
+			{"theme", e.Theme},
+			{"pattern-flags", e.Flags},
+			{"output", strings.ReplaceAll(out, "%", "%%")},
+		} {
+			if opt.value != "" {
+				args = append(args, "--"+opt.name+"="+opt.value)
+			}
+		}
+		args = append(args, "--", e.Pattern)
+
+		err := runRender(args, nil, stdout, stderr)
+		if err != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		if p := resolveOutputPath(out, common.Format); strings.EqualFold(filepath.Ext(p), ".svg") {
+			g.record(seq, galleryResult{
+				pattern: e.Pattern, flavor: flavorName, path: p,
+				title: e.Title, notes: e.Annotations, err: err,
+			})
+		}
+	}
+	if len(m.Patterns) > 1 {
+		_, _ = fmt.Fprintf(stderr, "Processed %d patterns in %s: %d ok, %d failed\n",
+			len(m.Patterns), time.Since(start).Round(time.Millisecond), len(m.Patterns)-failed, failed)
+	}
+
+	if gallery && common.Format == "svg" {
+		co := termenv.NewOutput(stdout, termenv.WithProfile(output.ResolveColorProfile(common.Color)))
+		if err := g.write(stdout, stderr, co); err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,307 @@
+package main
+
+// ================================================================================
+// match subcommand
+// ================================================================================
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/muesli/termenv"
+	flag "github.com/spf13/pflag"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+	"github.com/0x4d5352/regolith/internal/match"
+	"github.com/0x4d5352/regolith/internal/output"
+	"github.com/0x4d5352/regolith/internal/renderer"
+	"github.com/0x4d5352/regolith/internal/unescape"
+)
+
+// matchResult is the --format json document of `regolith match`.
+type matchResult struct {
+	Pattern string      `json:"pattern"`
+	Flavor  string      `json:"flavor"`
+	Engine  string      `json:"engine"`
+	Input   string      `json:"input"`
+	Matches []matchJSON `json:"matches"`
+}
+
+type matchJSON struct {
+	Start  int               `json:"start"`
+	End    int               `json:"end"`
+	Text   string            `json:"text"`
+	Groups []groupJSON       `json:"groups"`
+	Named  map[string]string `json:"named"`
+}
+
+// groupJSON is one capture group; Start, End, and Text are null when
+// the group took no part in the match.
+type groupJSON struct {
+	Number int     `json:"number"`
+	Name   string  `json:"name,omitempty"`
+	Start  *int    `json:"start"`
+	End    *int    `json:"end"`
+	Text   *string `json:"text"`
+}
+
+// matchFormats lists the --format values runMatch accepts.
+var matchFormats = []string{"json", "svg", "text"}
+
+// runMatch implements `regolith match`: run a pattern against an input
+// string with the flavor's match engine (see internal/match) and report
+// every match with its capture groups, like an online regex tester.
+// --format svg draws the diagram instead, with each capture group
+// captioned by what it captured in the first match.
+func runMatch(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("regolith match", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	var common commonFlags
+	common.Register(fs, commonDefaults{Format: "text", Output: "", Formats: matchFormats})
+
+	var style svgStyleFlags
+	style.Register(fs)
+
+	first := fs.Bool("first", false, "Report only the first match")
+	testFile := fs.String("test", "",
+		"File of labeled inputs ('+ input' must match, '- input' must not; - for stdin) to check every pattern argument against")
+	unescapeFlag := fs.BoolP("unescape", "u", false,
+		`Apply string literal unescaping to the pattern before parsing (e.g., \\ becomes \)`)
+
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "regolith match - Show what a pattern matches in an input\n\n")
+		_, _ = fmt.Fprintf(stderr, "Usage:\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith match [flags] <pattern> [input]\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith match --test <file> [flags] <pattern>...\n\n")
+		_, _ = fmt.Fprintf(stderr, "The input is read from stdin when not given. Matching runs in-process:\n")
+		_, _ = fmt.Fprintf(stderr, "Go's regexp (POSIX leftmost-longest) for the POSIX and GNU flavors,\n")
+		_, _ = fmt.Fprintf(stderr, "regexp2 for the others, which approximates Java and PCRE.\n\n")
+		_, _ = fmt.Fprintf(stderr, "Formats: text (a table per match), json, svg (the diagram with each\n")
+		_, _ = fmt.Fprintf(stderr, "group captioned by its capture in the first match; requires -o).\n\n")
+		_, _ = fmt.Fprintf(stderr, "Exits 0 when the pattern matched and 1 when it did not, like grep.\n\n")
+		_, _ = fmt.Fprintf(stderr, "With --test, each pattern is run against every input in the file and\n")
+		_, _ = fmt.Fprintf(stderr, "a pass/fail matrix is printed (text or json); the exit status is 1\n")
+		_, _ = fmt.Fprintf(stderr, "when any input matched against its label.\n\n")
+		_, _ = fmt.Fprintf(stderr, "Examples:\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith match -f pcre '(?<year>\\d{4})-(\\d{2})' 'due 2024-06'\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith match --format json '\\w+' 'two words' | jq '.matches[].text'\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith match --format svg -o match.svg '(a+)(b*)' aab\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith match --test dates.txt '^\\d{4}-\\d{2}$' '^\\d+-\\d+$'\n\n")
+		_, _ = fmt.Fprintf(stderr, "Flags:\n")
+		fs.PrintDefaults()
+	}
+
+	err := parseFlags(fs, args[2:], stdout)
+	if errors.Is(err, flag.ErrHelp) || errors.Is(err, errConfigShown) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	common.Output = resolveOutputPath(common.Output, common.Format)
+	if err := validateErrorFormat(common.ErrorFormat); err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+	switch {
+	case common.Format == "text", common.Format == "json":
+	case common.Format == "svg" && *testFile == "":
+	default:
+		err := fmt.Errorf("unknown format %q (available: %s)", common.Format, strings.Join(matchFormats, ", "))
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+
+	profile := output.ResolveColorProfile(common.Color)
+	co := termenv.NewOutput(stderr, termenv.WithProfile(profile))
+
+	f, ok := lookupFlavor(common.Flavor, stderr)
+	if !ok {
+		_, _ = fmt.Fprintf(stderr, "Error: unknown flavor '%s'\n", common.Flavor)
+		return fmt.Errorf("unknown flavor: %s", common.Flavor)
+	}
+
+	if *testFile != "" {
+		return runMatchTests(*testFile, fs.Args(), f, &common, *unescapeFlag, stdin, stdout, stderr, co)
+	}
+
+	pattern, input, err := matchArgs(fs.Args(), stdin)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		fs.Usage()
+		return err
+	}
+	if *unescapeFlag {
+		pattern = unescape.JavaStringLiteral(pattern)
+	}
+
+	root, err := f.Parse(pattern)
+	if err != nil {
+		reportParseError(stderr, pattern, f.Name(), common.ErrorFormat, err, co)
+		return fmt.Errorf("parse error: %w", err)
+	}
+	engine, err := match.For(f.Name())
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+	m, err := engine.Compile(pattern)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+	limit := -1
+	if *first {
+		limit = 1
+	}
+	found, err := m.FindAll(input, limit)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+
+	switch common.Format {
+	case "text":
+		writeMatchTable(stdout, input, found)
+	case "json":
+		b, err := json.MarshalIndent(matchResultJSON(pattern, f.Name(), engine.Name(), input, found), "", "  ")
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintln(stdout, string(b))
+	case "svg":
+		err := renderAndWriteSVG(fs, &common, &style, pattern, f.Name(), stdout, stderr, co,
+			func(r *renderer.Renderer) string {
+				r.Flavor, r.Language = f, common.Lang
+				if len(found) > 0 {
+					r.PostRender = captionCaptures(r.Config, found[0].Groups)
+				}
+				return r.Render(root)
+			})
+		if err != nil {
+			return err
+		}
+	}
+	if len(found) == 0 {
+		return errNoMatches
+	}
+	return nil
+}
+
+// matchArgs returns the pattern and input from the positional
+// arguments, reading the input verbatim from stdin when only the
+// pattern was given.
+func matchArgs(args []string, stdin io.Reader) (pattern, input string, err error) {
+	switch {
+	case len(args) >= 2:
+		return args[0], args[1], nil
+	case len(args) == 1 && stdin != nil:
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to read input from stdin: %w", err)
+		}
+		return args[0], string(data), nil
+	case len(args) == 1:
+		return "", "", fmt.Errorf("no input provided")
+	default:
+		return "", "", fmt.Errorf("no pattern provided")
+	}
+}
+
+// writeMatchTable prints each match as a heading and a table of its
+// capture groups, or "no match".
+func writeMatchTable(w io.Writer, input string, found []match.Match) {
+	if len(found) == 0 {
+		_, _ = fmt.Fprintln(w, "no match")
+		return
+	}
+	for i, m := range found {
+		if i > 0 {
+			_, _ = fmt.Fprintln(w)
+		}
+		_, _ = fmt.Fprintf(w, "Match %d  %d-%d  %s\n", i+1, m.Start, m.End, strconv.Quote(input[m.Start:m.End]))
+		if len(m.Groups) < 2 {
+			continue
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "  GROUP\tNAME\tSPAN\tTEXT")
+		for _, g := range m.Groups[1:] {
+			span, text := "-", "(no match)"
+			if g.Matched() {
+				span, text = fmt.Sprintf("%d-%d", g.Start, g.End), strconv.Quote(g.Text)
+			}
+			_, _ = fmt.Fprintf(tw, "  %d\t%s\t%s\t%s\n", g.Number, g.Name, span, text)
+		}
+		_ = tw.Flush()
+	}
+}
+
+func matchResultJSON(pattern, flavorName, engine, input string, found []match.Match) matchResult {
+	res := matchResult{Pattern: pattern, Flavor: flavorName, Engine: engine, Input: input, Matches: []matchJSON{}}
+	for _, m := range found {
+		mj := matchJSON{Start: m.Start, End: m.End, Text: input[m.Start:m.End], Named: map[string]string{}}
+		for _, g := range m.Groups[1:] {
+			gj := groupJSON{Number: g.Number, Name: g.Name}
+			if g.Matched() {
+				start, end, text := g.Start, g.End, g.Text
+				gj.Start, gj.End, gj.Text = &start, &end, &text
+				if g.Name != "" {
+					mj.Named[g.Name] = g.Text
+				}
+			}
+			mj.Groups = append(mj.Groups, gj)
+		}
+		if mj.Groups == nil {
+			mj.Groups = []groupJSON{}
+		}
+		res.Matches = append(res.Matches, mj)
+	}
+	return res
+}
+
+// captionCaptures returns a PostRender hook that writes what each
+// capture group captured beneath its box, and records it in a
+// data-capture attribute. A caption wider than its box widens the node,
+// and a connector stub carries the track to the new right edge.
+func captionCaptures(cfg *renderer.Config, groups []match.Group) renderer.RenderHook {
+	return func(node ast.Node, rn *renderer.RenderedNode) {
+		sub, ok := node.(*ast.Subexp)
+		if !ok || sub.Number <= 0 || sub.Number >= len(groups) {
+			return
+		}
+		if sub.GroupType != "capture" && sub.GroupType != "named_capture" {
+			return
+		}
+		g := groups[sub.Number]
+		caption, data := "(no match)", map[string]string{}
+		if g.Matched() {
+			caption, data["capture"] = "= "+strconv.Quote(g.Text), g.Text
+		}
+		children := []renderer.SVGElement{rn.Element, &renderer.Text{
+			X:          cfg.Padding,
+			Y:          rn.BBox.Height + cfg.FontSize + cfg.Padding/2,
+			Content:    caption,
+			FontFamily: cfg.FontFamily,
+			FontSize:   cfg.FontSize,
+			Fill:       cfg.TextColor,
+			Class:      "capture",
+		}}
+		if w := 2*cfg.Padding + renderer.MeasureText(caption, cfg); w > rn.BBox.Width {
+			children = append(children, &renderer.Line{
+				X1: rn.BBox.AnchorRight, Y1: rn.BBox.AnchorY,
+				X2: w, Y2: rn.BBox.AnchorY,
+				Stroke:      cfg.Connector.Color,
+				StrokeWidth: cfg.Connector.StrokeWidth,
+			})
+			rn.BBox.Width, rn.BBox.AnchorRight = w, w
+		}
+		rn.Element = &renderer.Group{Data: data, Children: children}
+		rn.BBox.Height += cfg.FontSize + cfg.Padding
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/muesli/termenv"
+
+	"github.com/0x4d5352/regolith/internal/flavor"
+	"github.com/0x4d5352/regolith/internal/match"
+	"github.com/0x4d5352/regolith/internal/unescape"
+)
+
+// errTestsFailed makes `regolith match --test` exit non-zero when any
+// input disagreed with its label, so CI can gate on pattern changes.
+var errTestsFailed = errors.New("match tests failed")
+
+// testCase is one labeled input from a --test file.
+type testCase struct {
+	Line   int    `json:"line"`
+	Input  string `json:"input"`
+	Expect bool   `json:"expect_match"`
+}
+
+// testResult is one pattern's outcome for one case.
+type testResult struct {
+	Pattern string `json:"pattern"`
+	Matched bool   `json:"matched"`
+	Pass    bool   `json:"pass"`
+}
+
+// testRow is one case with the outcome for every pattern, in the order
+// the patterns were given.
+type testRow struct {
+	testCase
+	Results []testResult `json:"results"`
+}
+
+// testReport is the --format json document of `regolith match --test`.
+type testReport struct {
+	Flavor   string    `json:"flavor"`
+	Patterns []string  `json:"patterns"`
+	Cases    []testRow `json:"cases"`
+	Failures int       `json:"failures"`
+}
+
+// parseTestCases reads a --test file: one input per line, prefixed by
+// "+ " when the pattern must match it and "- " when it must not. Blank
+// lines and lines starting with # are skipped. Inputs are taken
+// verbatim, so they may contain spaces but not newlines.
+func parseTestCases(r io.Reader) ([]testCase, error) {
+	var cases []testCase
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSuffix(sc.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		label, input, ok := strings.Cut(line, " ")
+		if !ok && (line == "+" || line == "-") {
+			label, input, ok = line, "", true
+		}
+		if !ok || (label != "+" && label != "-") {
+			return nil, fmt.Errorf("line %d: want '+ input' or '- input', got %q", n, line)
+		}
+		cases = append(cases, testCase{Line: n, Input: input, Expect: label == "+"})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(cases) == 0 {
+		return nil, fmt.Errorf("no test cases")
+	}
+	return cases, nil
+}
+
+// runMatchTests implements `regolith match --test`: run every pattern
+// against every case in path and report a pass/fail matrix with one
+// row per case and one column per pattern, so a rewritten pattern can
+// be checked side by side with the one it replaces.
+func runMatchTests(path string, patterns []string, f flavor.Flavor, common *commonFlags, unescapePatterns bool,
+	stdin io.Reader, stdout, stderr io.Writer, co *termenv.Output) error {
+	if len(patterns) == 0 {
+		err := fmt.Errorf("no pattern provided")
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+	var r io.Reader
+	if path == "-" {
+		if stdin == nil {
+			err := fmt.Errorf("--test -: no input on stdin")
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return err
+		}
+		r = stdin
+	} else {
+		file, err := os.Open(path)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return err
+		}
+		defer func() { _ = file.Close() }()
+		r = file
+	}
+	cases, err := parseTestCases(r)
+	if err != nil {
+		err = fmt.Errorf("%s: %w", path, err)
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+
+	engine, err := match.For(f.Name())
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+	matchers := make([]match.Matcher, len(patterns))
+	for i, pattern := range patterns {
+		if unescapePatterns {
+			pattern = unescape.JavaStringLiteral(pattern)
+			patterns[i] = pattern
+		}
+		if _, err := f.Parse(pattern); err != nil {
+			reportParseError(stderr, pattern, f.Name(), common.ErrorFormat, err, co)
+			return fmt.Errorf("parse error: %w", err)
+		}
+		if matchers[i], err = engine.Compile(pattern); err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return err
+		}
+	}
+
+	report := testReport{Flavor: f.Name(), Patterns: patterns}
+	for _, c := range cases {
+		row := testRow{testCase: c}
+		for i, m := range matchers {
+			matched, err := match.MatchString(m, c.Input)
+			if err != nil {
+				err = fmt.Errorf("line %d: %w", c.Line, err)
+				_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+				return err
+			}
+			pass := matched == c.Expect
+			if !pass {
+				report.Failures++
+			}
+			row.Results = append(row.Results, testResult{Pattern: patterns[i], Matched: matched, Pass: pass})
+		}
+		report.Cases = append(report.Cases, row)
+	}
+
+	if common.Format == "json" {
+		b, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintln(stdout, string(b))
+	} else {
+		stdoutCo := termenv.NewOutput(stdout, termenv.WithProfile(co.Profile))
+		writeTestMatrix(stdout, stdoutCo, report)
+	}
+	if report.Failures > 0 {
+		return errTestsFailed
+	}
+	return nil
+}
+
+// writeTestMatrix prints the report as a table: the expected outcome
+// and then PASS or FAIL under each pattern, numbered in a legend when
+// there are several.
+func writeTestMatrix(w io.Writer, co *termenv.Output, report testReport) {
+	if len(report.Patterns) > 1 {
+		for i, p := range report.Patterns {
+			_, _ = fmt.Fprintf(w, "P%d  %s\n", i+1, p)
+		}
+		_, _ = fmt.Fprintln(w)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := "LINE\tINPUT\tEXPECT"
+	for i := range report.Patterns {
+		header += fmt.Sprintf("\tP%d", i+1)
+	}
+	if len(report.Patterns) == 1 {
+		header = "LINE\tINPUT\tEXPECT\tRESULT"
+	}
+	_, _ = fmt.Fprintln(tw, header)
+	for _, row := range report.Cases {
+		expect := "no match"
+		if row.Expect {
+			expect = "match"
+		}
+		line := fmt.Sprintf("%d\t%s\t%s", row.Line, strconv.Quote(row.Input), expect)
+		for _, res := range row.Results {
+			// Colors are applied to fixed-width words so tabwriter,
+			// which counts escape bytes, still lines the columns up.
+			if res.Pass {
+				line += "\t" + co.String("PASS").Foreground(termenv.ANSIColor(2)).String()
+			} else {
+				line += "\t" + co.String("FAIL").Foreground(termenv.ANSIColor(1)).Bold().String()
+			}
+		}
+		_, _ = fmt.Fprintln(tw, line)
+	}
+	_ = tw.Flush()
+
+	total := len(report.Cases) * len(report.Patterns)
+	if report.Failures == 0 {
+		_, _ = fmt.Fprintf(w, "\n%d of %d passed\n", total, total)
+	} else {
+		_, _ = fmt.Fprintf(w, "\n%d of %d failed\n", report.Failures, total)
+	}
+}
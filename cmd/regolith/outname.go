@@ -0,0 +1,77 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// -o templates.
+//
+// Batch runs (--null) write one file per pattern, so -o accepts
+// printf-style placeholders that are filled in per pattern:
+//
+//	%n  1-based sequence number (%d is accepted as an alias)
+//	%p  short hash of the pattern text
+//	%f  flavor name
+//	%t  run timestamp, UTC, e.g. 20260102T150405Z
+//	%%  a literal percent sign
+//
+// %n and %d take an optional zero-padded width (%03n). Any other %
+// sequence is left alone, so a plain filename that happens to contain
+// a percent sign keeps working.
+
+// outputVars are the values substituted into an -o template.
+type outputVars struct {
+	Seq     int       // %n / %d
+	Pattern string    // hashed for %p
+	Flavor  string    // %f
+	Time    time.Time // %t: taken once per run so every file shares it
+}
+
+// outputVerb matches one placeholder. Widths apply to the numeric
+// verbs only.
+var outputVerb = regexp.MustCompile(`%(?:%|0?\d*[dn]|[pft])`)
+
+// patternHashLen is the number of hex digits %p keeps: short enough for
+// a filename, long enough that collisions in one batch are implausible.
+const patternHashLen = 12
+
+// expandOutputTemplate fills every placeholder in tmpl from v.
+func expandOutputTemplate(tmpl string, v outputVars) string {
+	if !strings.Contains(tmpl, "%") {
+		return tmpl
+	}
+	return outputVerb.ReplaceAllStringFunc(tmpl, func(verb string) string {
+		switch verb[len(verb)-1] {
+		case '%':
+			return "%"
+		case 'd', 'n':
+			return fmt.Sprintf(verb[:len(verb)-1]+"d", v.Seq)
+		case 'p':
+			sum := sha256.Sum256([]byte(v.Pattern))
+			return hex.EncodeToString(sum[:])[:patternHashLen]
+		case 'f':
+			return v.Flavor
+		case 't':
+			return v.Time.UTC().Format("20060102T150405Z")
+		}
+		return verb
+	})
+}
+
+// templateVariesPerPattern reports whether tmpl yields a distinct path
+// for each pattern in a batch (it has a %n, %d, or %p placeholder).
+// Flavor and timestamp are the same for the whole run.
+func templateVariesPerPattern(tmpl string) bool {
+	for _, verb := range outputVerb.FindAllString(tmpl, -1) {
+		switch verb[len(verb)-1] {
+		case 'd', 'n', 'p':
+			return true
+		}
+	}
+	return false
+}
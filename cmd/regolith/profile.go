@@ -0,0 +1,159 @@
+package main
+
+// Profiling and metrics flags.
+//
+// When a user reports "this pattern is slow", the first questions are
+// which stage is slow and how big the input to it was. --metrics
+// answers both with one logfmt line per pattern; --profile captures a
+// pprof or execution trace that can be attached to the issue as is.
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"strconv"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+)
+
+// diagFlags holds the --profile and --metrics settings.
+type diagFlags struct {
+	Profile    string
+	ProfileOut string
+	Metrics    bool
+	MetricsOut string
+}
+
+// Register binds the diagnostic flags onto fs.
+func (d *diagFlags) Register(fs *flag.FlagSet) {
+	fs.StringVar(&d.Profile, "profile", "",
+		"Record a profile of the run: cpu, mem, or trace")
+	fs.StringVar(&d.ProfileOut, "profile-out", "",
+		"Profile output path (default: regolith.<kind>.pprof, or regolith.trace)")
+	fs.BoolVar(&d.Metrics, "metrics", false,
+		"Print per-pattern timing and size metrics (parse, render, nodes, bytes)")
+	fs.StringVar(&d.MetricsOut, "metrics-out", "",
+		"Append metrics to this file instead of stderr")
+}
+
+// startProfile begins the profile selected by --profile and returns a
+// func that finishes it and writes the file. With no --profile it is a
+// no-op. The mem profile is a heap snapshot taken when stop is called,
+// so it reflects everything the run allocated and kept.
+func (d *diagFlags) startProfile(stderr io.Writer) (stop func() error, err error) {
+	if d.Profile == "" {
+		return func() error { return nil }, nil
+	}
+
+	path := d.ProfileOut
+	if path == "" {
+		path = "regolith." + d.Profile + ".pprof"
+		if d.Profile == "trace" {
+			path = "regolith.trace"
+		}
+	}
+
+	var start func(io.Writer) error
+	var finish func(io.Writer) error
+	switch d.Profile {
+	case "cpu":
+		start = pprof.StartCPUProfile
+		finish = func(io.Writer) error { pprof.StopCPUProfile(); return nil }
+	case "trace":
+		start = trace.Start
+		finish = func(io.Writer) error { trace.Stop(); return nil }
+	case "mem":
+		start = func(io.Writer) error { return nil }
+		finish = func(w io.Writer) error {
+			runtime.GC()
+			return pprof.WriteHeapProfile(w)
+		}
+	default:
+		return nil, fmt.Errorf("unknown profile %q (available: cpu, mem, trace)", d.Profile)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("creating profile: %w", err)
+	}
+	if err := start(f); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("starting %s profile: %w", d.Profile, err)
+	}
+	return func() error {
+		err := finish(f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return fmt.Errorf("writing %s profile: %w", d.Profile, err)
+		}
+		_, _ = fmt.Fprintf(stderr, "Wrote %s profile to %s\n", d.Profile, path)
+		return nil
+	}, nil
+}
+
+// openMetrics returns the writer --metrics lines go to, and a func to
+// release it. Metrics files are appended to so repeated runs build up
+// a history a user can diff.
+func (d *diagFlags) openMetrics(stderr io.Writer) (w io.Writer, closeFn func() error, err error) {
+	if d.MetricsOut == "" {
+		return stderr, func() error { return nil }, nil
+	}
+	f, err := os.OpenFile(d.MetricsOut, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening metrics file: %w", err)
+	}
+	return f, f.Close, nil
+}
+
+// patternMetrics are the measurements --metrics reports for one
+// pattern.
+type patternMetrics struct {
+	Pattern string
+	Parse   time.Duration
+	Render  time.Duration // Layout plus serialization for the selected format
+	Nodes   int           // AST nodes, excluding branch/fragment containers
+	Bytes   int           // Size of the rendered output
+}
+
+// timeRender runs fn, recording its duration and output size.
+func (m *patternMetrics) timeRender(fn func() string) string {
+	start := time.Now()
+	out := fn()
+	m.Render = time.Since(start)
+	m.Bytes = len(out)
+	return out
+}
+
+// write prints m as a single logfmt line so metrics from many runs can
+// be grepped, sorted, or loaded into a spreadsheet.
+func (m *patternMetrics) write(w io.Writer) {
+	_, _ = fmt.Fprintf(w, "metrics parse_ms=%s render_ms=%s nodes=%d bytes=%d pattern=%s\n",
+		fmtMillis(m.Parse), fmtMillis(m.Render), m.Nodes, m.Bytes, strconv.Quote(m.Pattern))
+}
+
+// fmtMillis formats d in milliseconds with microsecond precision.
+func fmtMillis(d time.Duration) string {
+	return strconv.FormatFloat(float64(d.Microseconds())/1000, 'f', 3, 64)
+}
+
+// countNodes counts the content nodes of root: the things a diagram
+// draws, not the Regexp/Match/MatchFragment containers holding them.
+func countNodes(root *ast.Regexp) int {
+	n := 0
+	ast.Walk(root, func(node ast.Node) {
+		switch node.(type) {
+		case *ast.Regexp, *ast.Match, *ast.MatchFragment:
+		default:
+			n++
+		}
+	})
+	return n
+}
@@ -0,0 +1,216 @@
+package main
+
+// ================================================================================
+// query subcommand
+// ================================================================================
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/muesli/termenv"
+	flag "github.com/spf13/pflag"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+	"github.com/0x4d5352/regolith/internal/output"
+	"github.com/0x4d5352/regolith/internal/query"
+	"github.com/0x4d5352/regolith/internal/unescape"
+)
+
+// errNoMatches makes `regolith query` exit non-zero when nothing was
+// found, like grep, so scripts can branch on the exit status alone.
+var errNoMatches = errors.New("no matches")
+
+// queryHit is one match in --format json output.
+type queryHit struct {
+	Seq     int    `json:"seq"`
+	Pattern string `json:"pattern"`
+	Query   string `json:"query"`
+	Pointer string `json:"pointer"`
+	// Start and End are the bytes [start, end) of Pattern the node was
+	// parsed from, or null when the parser recorded none, as for the
+	// members of a character class.
+	Start       *int   `json:"start"`
+	End         *int   `json:"end"`
+	Description string `json:"node"`
+}
+
+// runQuery implements `regolith query`: report where the constructs
+// named by each --find query occur in a pattern (see internal/query
+// for the language). Locations are JSON Pointers into the document
+// `regolith --format json` prints for the same pattern, with the
+// node's place in the pattern itself: byte offsets in JSON, columns in
+// text.
+func runQuery(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("regolith query", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	flavorName := fs.StringP("flavor", "f", "javascript", "Regex flavor")
+	finds := fs.StringArray("find", nil, "Structural query to run (repeatable), e.g. 'unbounded repeat of group'")
+	format := fs.String("format", "text", "Output format: text, json")
+	unescapeFlag := fs.BoolP("unescape", "u", false,
+		`Apply string literal unescaping before parsing (e.g., \\ becomes \)`)
+	nullSeparated := fs.BoolP("null", "0", false,
+		"Read NUL-separated patterns from stdin; text output prefixes each match with the pattern's sequence number")
+	color := fs.String("color", "auto", "Color output: auto, always, never")
+	errorFormat := fs.String("error-format", "text", "Parse error format: text, json, gnu")
+
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "regolith query - Find constructs in a pattern by structure\n\n")
+		_, _ = fmt.Fprintf(stderr, "Usage:\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith query --find <query> [flags] <pattern>\n\n")
+		_, _ = fmt.Fprintf(stderr, "Query language:\n")
+		_, _ = fmt.Fprintf(stderr, "  [adjective...] kind [containing|inside|of operand]\n\n")
+		_, _ = fmt.Fprintf(stderr, "  Adjectives: positive, negative, negated, named, unbounded, bounded,\n")
+		_, _ = fmt.Fprintf(stderr, "              lazy, greedy, possessive\n")
+		_, _ = fmt.Fprintf(stderr, "  Kinds:\n")
+		for _, n := range query.Nouns() {
+			_, _ = fmt.Fprintf(stderr, "    %-12s %s\n", n, query.NounHelp(n))
+		}
+		_, _ = fmt.Fprintf(stderr, "\nExamples:\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith query --find lookbehind '(?<=\\$)\\d+'\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith query --find 'charset containing -' '[a-z-_]+'\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith query --find 'unbounded repeat inside unbounded repeat' '(a+)+'\n\n")
+		_, _ = fmt.Fprintf(stderr, "Exits 0 when anything matched and 1 when nothing did, like grep.\n\n")
+		_, _ = fmt.Fprintf(stderr, "Flags:\n")
+		fs.PrintDefaults()
+	}
+
+	err := parseFlags(fs, args[2:], stdout)
+	if errors.Is(err, flag.ErrHelp) || errors.Is(err, errConfigShown) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := validateErrorFormat(*errorFormat); err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		err := fmt.Errorf("unknown format %q (available: json, text)", *format)
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+	if len(*finds) == 0 {
+		err := fmt.Errorf("at least one --find query is required")
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+	queries := make([]*query.Query, len(*finds))
+	for i, src := range *finds {
+		if queries[i], err = query.Parse(src); err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return err
+		}
+	}
+
+	profile := output.ResolveColorProfile(*color)
+
+	f, ok := lookupFlavor(*flavorName, stderr)
+	if !ok {
+		_, _ = fmt.Fprintf(stderr, "Error: unknown flavor '%s'\n", *flavorName)
+		return fmt.Errorf("unknown flavor: %s", *flavorName)
+	}
+
+	// Under --null patterns are queried concurrently; hits is shared and
+	// put back into input order afterwards.
+	var hits []queryHit
+	var hitsMu sync.Mutex
+	queryPattern := func(pattern string, seq int, stdout, stderr io.Writer) error {
+		co := termenv.NewOutput(stderr, termenv.WithProfile(profile))
+		stdoutCo := termenv.NewOutput(stdout, termenv.WithProfile(profile))
+		if *unescapeFlag {
+			pattern = unescape.JavaStringLiteral(pattern)
+		}
+		root, err := f.Parse(pattern)
+		if err != nil {
+			reportParseError(stderr, pattern, f.Name(), *errorFormat, err, co)
+			return fmt.Errorf("parse error: %w", err)
+		}
+		for _, q := range queries {
+			for _, m := range q.Find(root) {
+				hit := queryHit{Seq: seq, Pattern: pattern, Query: q.String(), Pointer: m.Pointer, Description: m.Description}
+				if pos := m.Node.Pos(); pos != (ast.Position{}) {
+					hit.Start, hit.End = &pos.Start, &pos.End
+				}
+				hitsMu.Lock()
+				hits = append(hits, hit)
+				hitsMu.Unlock()
+				if *format == "text" {
+					writeQueryHit(stdout, stdoutCo, hit, *nullSeparated, len(queries) > 1)
+				}
+			}
+		}
+		return nil
+	}
+
+	if *nullSeparated {
+		err = runPatternList(fs.Args(), stdin, "", 0, stdout, stderr, queryPattern)
+		sort.SliceStable(hits, func(i, j int) bool { return hits[i].Seq < hits[j].Seq })
+	} else {
+		var pattern string
+		if pattern, err = getInput(fs.Args(), stdin); err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			fs.Usage()
+			return err
+		}
+		err = queryPattern(pattern, 1, stdout, stderr)
+	}
+
+	if *format == "json" {
+		if hits == nil {
+			hits = []queryHit{}
+		}
+		// Patterns are full of < > and &; keep them readable.
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		if jerr := enc.Encode(hits); jerr != nil {
+			return jerr
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if len(hits) == 0 {
+		return errNoMatches
+	}
+	return nil
+}
+
+// writeQueryHit prints one text-format match: the pointer, the columns
+// of the pattern the node spans, when known, then the node
+// description, prefixed by the sequence number in --null mode and
+// followed by the query when several were given.
+func writeQueryHit(w io.Writer, co *termenv.Output, hit queryHit, withSeq, withQuery bool) {
+	line := co.String(hit.Pointer).Foreground(termenv.ANSIColor(6)).String() + "  "
+	if hit.Start != nil {
+		line += queryColumns(hit.Pattern, *hit.Start, *hit.End) + "  "
+	}
+	line += hit.Description
+	if withSeq {
+		line = fmt.Sprintf("%d:%s", hit.Seq, line)
+	}
+	if withQuery {
+		line += co.String("  [" + hit.Query + "]").Faint().String()
+	}
+	_, _ = fmt.Fprintln(w, line)
+}
+
+// queryColumns describes the bytes [start, end) of pattern as the
+// 1-based rune columns they cover: "col 3" for one, "col 3-5" for
+// several.
+func queryColumns(pattern string, start, end int) string {
+	first := utf8.RuneCountInString(pattern[:start]) + 1
+	last := max(utf8.RuneCountInString(pattern[:end]), first)
+	if last == first {
+		return fmt.Sprintf("col %d", first)
+	}
+	return fmt.Sprintf("col %d-%d", first, last)
+}
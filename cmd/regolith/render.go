@@ -1,21 +1,37 @@
 package main
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 
 	"github.com/muesli/termenv"
+	"github.com/rivo/uniseg"
 	flag "github.com/spf13/pflag"
 
+	"github.com/0x4d5352/regolith/internal/ast"
 	"github.com/0x4d5352/regolith/internal/flavor"
+	"github.com/0x4d5352/regolith/internal/importer"
 	"github.com/0x4d5352/regolith/internal/output"
 	"github.com/0x4d5352/regolith/internal/renderer"
 	"github.com/0x4d5352/regolith/internal/renderer/theme"
 	"github.com/0x4d5352/regolith/internal/unescape"
+	"github.com/0x4d5352/regolith/internal/workpool"
 )
 
+// renderFormats lists the --format values runRender accepts.
+var renderFormats = []string{"diagram", "gotemplate", "html", "json", "pdf", "png", "svg", "text"}
+
 // runRender implements the main `regolith` command — parse a regex and
 // emit it as SVG, JSON, or Markdown. Phase 1 of the refactor preserves
 // the historical default (svg to regex.svg); phase 3 flips the default
@@ -25,14 +41,75 @@ func runRender(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
 	fs.SetOutput(stderr)
 
 	var common commonFlags
-	common.Register(fs, commonDefaults{Format: "text", Output: ""})
+	common.Register(fs, commonDefaults{Format: "text", Output: "", Formats: renderFormats})
 
 	var style svgStyleFlags
 	style.Register(fs)
 
+	var diag diagFlags
+	diag.Register(fs)
+
 	showVersion := fs.BoolP("version", "v", false, "Show version")
 	unescapeFlag := fs.BoolP("unescape", "u", false,
 		`Apply string literal unescaping before parsing (e.g., \\ becomes \)`)
+	templatePath := fs.String("template", "",
+		"Go text/template file used by --format gotemplate")
+	showSource := fs.Bool("show-source", false,
+		"Draw the raw pattern with flavor-aware syntax coloring beneath the SVG diagram")
+	showRuler := fs.Bool("ruler", false,
+		"Draw a character-position ruler under the raw pattern (implies --show-source)")
+	expandShorthands := fs.Bool("expand-shorthands", false,
+		`Annotate \d, \w, and \s inside character classes with the exact set they match in this flavor and mode`)
+	flatten := fs.Bool("flatten", false,
+		"Merge nested non-capturing alternations, e.g. a|(?:b|c) into a|b|c, before output")
+	expandSubroutines := fs.Bool("expand-subroutines", false,
+		"Draw each subroutine call, (?1), (?&name) or \\g<name>, as a copy of the group it calls; recursive calls stay references")
+	subroutineDepth := fs.Int("subroutine-depth", ast.DefaultSubroutineDepth,
+		"With --expand-subroutines, how many calls deep to expand calls inside expanded groups")
+	summary := fs.Bool("summary", false,
+		"Draw a compact one-line SVG overview with groups as labeled chips, for thumbnails and index pages")
+	groupLegend := fs.Bool("group-legend", false,
+		"List the capturing groups beside the SVG diagram, each entry linking to its group's box")
+	title := fs.String("title", "",
+		"Heading drawn above the SVG, PNG or PDF diagram; a newline in it starts a new line")
+	caption := fs.String("caption", "",
+		"Explanatory caption drawn below the SVG, PNG or PDF diagram; a newline in it starts a new line")
+	localeNote := fs.Bool("locale-note", false,
+		"For grep, sed and POSIX flavors, add a footnote to the SVG, PNG or PDF diagram naming the constructs that match differently under LC_ALL=C and UTF-8 locales")
+	asciiDiagram := fs.Bool("ascii", false,
+		"Draw --format diagram with plain ASCII (- | +) instead of box-drawing characters")
+	paginate := fs.Int("paginate", 0,
+		"Split a long top-level sequence across SVG pages at most N pixels wide (out.svg becomes out-1.svg, out-2.svg, ...)")
+	checkOnly := fs.Bool("check", false,
+		"Validate the pattern under the selected flavor and exit without rendering")
+	inputSource := fs.StringP("input", "i", "",
+		`Read the pattern from this file, http(s) URL or gist ("-" for stdin) instead of an argument`)
+	fromJSON := fs.String("from-json", "",
+		`Render a regolith JSON document or railroad-diagrams tree read from this file or http(s) URL ("-" for stdin) instead of a pattern`)
+	traceParse := fs.String("trace-parse", "",
+		"Write the grammar rule trace (rule enter/exit with positions) of each parse to this file, for bug reports")
+	pcreVersion := fs.String("pcre-version", "",
+		"Reject constructs newer than this PCRE2 release (e.g. 10.34, 10.42), naming the minimum version each needs")
+	javaFlags := fs.StringSlice("java-flags", nil,
+		"Parse as Pattern.compile(pattern, flags) with these Java flags (e.g. LITERAL,COMMENTS,CANON_EQ) and list them in the flags panel")
+	jsTarget := fs.String("js-target", "",
+		"Reject JavaScript syntax newer than this ECMAScript edition (es5, es2015, es2018, es2022, es2024, es2025)")
+	jobs := fs.IntP("jobs", "j", 0,
+		"With --null, how many patterns to parse and render at once (default: one per CPU)")
+	nullSeparated := fs.BoolP("null", "0", false,
+		"Read NUL-separated patterns from stdin; use %n in -o for one file per pattern (e.g. out-%n.svg)")
+	fs.Float64Var(&common.DPI, "dpi", 96,
+		"Resolution of --format png output (96 is one pixel per SVG unit, 192 for 2x screens)")
+	gallery := fs.Bool("gallery", true,
+		"With --null or --manifest and --format svg, also write an index.html gallery of the diagrams next to them")
+	manifestPath := fs.String("manifest", "",
+		`Render every pattern listed in this JSON or YAML manifest, a file or http(s) URL ("-" for stdin), each with its own flavor, flags, theme and output`)
+	patternFlags := fs.String("pattern-flags", "",
+		"Flag letters the engine applies outside the pattern (e.g. im), checked against the flavor and shown in the flags panel")
+	patternArgs := fs.StringArray("pattern", nil,
+		"A pattern to render; give several (repeated, or as arguments) to stack their diagrams in one SVG, PNG or PDF")
+	patternTitles := fs.StringArray("pattern-title", nil,
+		"Title over each stacked diagram, in pattern order (default: the pattern itself)")
 
 	fs.Usage = func() {
 		_, _ = fmt.Fprintf(stderr, "regolith - Visualize regular expressions as SVG diagrams\n\n")
@@ -40,14 +117,12 @@ func runRender(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
 		_, _ = fmt.Fprintf(stderr, "  regolith [flags] <pattern>\n")
 		_, _ = fmt.Fprintf(stderr, "  echo 'pattern' | regolith [flags]\n\n")
 		_, _ = fmt.Fprintf(stderr, "Arguments:\n")
-		_, _ = fmt.Fprintf(stderr, "  pattern    Regular expression to visualize (reads from stdin if omitted)\n\n")
+		_, _ = fmt.Fprintf(stderr, "  pattern    Regular expression to visualize (reads from stdin if omitted);\n")
+		_, _ = fmt.Fprintf(stderr, "             several are stacked in one SVG, PNG or PDF\n\n")
 		_, _ = fmt.Fprintf(stderr, "Flags:\n")
 		fs.PrintDefaults()
-		_, _ = fmt.Fprintf(stderr, "\nAvailable flavors:\n")
-		for _, name := range flavor.List() {
-			f, _ := flavor.Get(name)
-			_, _ = fmt.Fprintf(stderr, "  %-12s %s\n", name, f.Description())
-		}
+		writeFlavorList(stderr)
+		writeFlavorFlags(stderr, common.Lang)
 		_, _ = fmt.Fprintf(stderr, "\nAvailable themes:\n")
 		for _, name := range theme.List() {
 			t, _ := theme.Get(name)
@@ -56,21 +131,39 @@ func runRender(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
 		_, _ = fmt.Fprintf(stderr, "\nOutput:\n")
 		_, _ = fmt.Fprintf(stderr, "  Default format is 'text': an ANSI-colored AST walk on stdout.\n")
 		_, _ = fmt.Fprintf(stderr, "  Redirecting text to a file via -o writes Markdown instead.\n")
-		_, _ = fmt.Fprintf(stderr, "  The 'svg' format requires -o with a destination filename.\n")
+		_, _ = fmt.Fprintf(stderr, "  The 'diagram' format draws the railroad diagram in box-drawing\n")
+		_, _ = fmt.Fprintf(stderr, "  characters (or ASCII with --ascii) on stdout, or to -o as plain text.\n")
+		_, _ = fmt.Fprintf(stderr, "  The 'svg' format (implied by -o *.svg) requires -o with a destination filename.\n")
+		_, _ = fmt.Fprintf(stderr, "  The 'json' format (implied by -o *.json) dumps the AST on stdout or to -o.\n")
+		_, _ = fmt.Fprintf(stderr, "  The 'png' format (implied by -o *.png) draws the diagram as an\n")
+		_, _ = fmt.Fprintf(stderr, "  image at --dpi, with no converter needed.\n")
+		_, _ = fmt.Fprintf(stderr, "  The 'pdf' format (implied by -o *.pdf) draws it as a vector PDF,\n")
+		_, _ = fmt.Fprintf(stderr, "  also with no converter needed.\n")
+		_, _ = fmt.Fprintf(stderr, "  The 'html' format (implied by -o *.html) wraps the SVG in a page\n")
+		_, _ = fmt.Fprintf(stderr, "  with a hovercard explaining each node; without -o it goes to stdout.\n")
+		_, _ = fmt.Fprintf(stderr, "  The 'gotemplate' format executes the --template file against the AST\n")
+		_, _ = fmt.Fprintf(stderr, "  and the diagram's layout.\n")
 		_, _ = fmt.Fprintf(stderr, "\nExamples:\n")
 		_, _ = fmt.Fprintf(stderr, "  regolith 'a|b|c'                              # ANSI walk on stdout\n")
 		_, _ = fmt.Fprintf(stderr, "  regolith 'a|b|c' -o outline.md                # Markdown to file\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith --format diagram '(ab|c)+d'          # railroad diagram on stdout\n")
 		_, _ = fmt.Fprintf(stderr, "  regolith --format svg -o diagram.svg '[a-z]+' # SVG diagram to file\n")
 		_, _ = fmt.Fprintf(stderr, "  regolith --flavor javascript '/pattern/gi'\n")
 		_, _ = fmt.Fprintf(stderr, "  regolith --format svg --literal-fill '#ff0000' -o out.svg 'hello'\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith -f pcre -o explain.html '(?<year>\\d{4})-\\d{2}'\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith -o dates.svg '\\d{4}-\\d{2}' '\\d{2}/\\d{2}/\\d{4}'  # two diagrams, one file\n")
 		_, _ = fmt.Fprintf(stderr, "  echo '^hello$' | regolith\n")
+		_, _ = fmt.Fprintf(stderr, "  printf 'a+\\0b|c\\0' | regolith -0 --format svg -o out-%%n-%%f.svg\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith --manifest docs/regex.yaml --format svg\n")
 		_, _ = fmt.Fprintf(stderr, "  regolith -f java -u '\\\\d+\\\\.\\\\d+'\n")
 		_, _ = fmt.Fprintf(stderr, "  regolith --format json 'foo([a-z]+)' | jq .\n")
 		_, _ = fmt.Fprintf(stderr, "  echo '[a-z]+' | regolith --format json\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith --format gotemplate --template tikz.tmpl 'a+b'\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith --from-json diagram.json --format svg -o diagram.svg\n")
 	}
 
-	err := fs.Parse(args[1:])
-	if errors.Is(err, flag.ErrHelp) {
+	err := parseFlags(fs, args[1:], stdout)
+	if errors.Is(err, flag.ErrHelp) || errors.Is(err, errConfigShown) {
 		return nil
 	}
 	if err != nil {
@@ -81,69 +174,713 @@ func runRender(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
 		_, _ = fmt.Fprintf(stdout, "regolith version %s\n", version)
 		return nil
 	}
+	// -o diagram.png asks for a PNG without spelling out --format, and
+	// likewise .svg, .pdf, .html and .json for theirs. Any other name
+	// keeps the text format, which writes Markdown to a file.
+	if !fs.Changed("format") {
+		switch ext := strings.ToLower(filepath.Ext(common.Output)); ext {
+		case ".svg", ".png", ".pdf", ".html", ".json":
+			common.Format = ext[1:]
+		}
+	}
+	common.Output = resolveOutputPath(common.Output, common.Format)
+	if err := validateErrorFormat(common.ErrorFormat); err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+	if len(*patternArgs) > 0 && (*manifestPath != "" || *nullSeparated || *inputSource != "" || *fromJSON != "") {
+		err := fmt.Errorf("--pattern cannot be combined with --manifest, --null, --input or --from-json")
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+	if *manifestPath != "" {
+		return runManifest(*manifestPath, fs, &common, *gallery && !*checkOnly, stdin, stdout, stderr)
+	}
 
 	profile := output.ResolveColorProfile(common.Color)
-	// Two termenv outputs so stdout-bound content and stderr-bound
-	// status messages each get the auto-detected profile for their
-	// own writer. Piping stdout to a file correctly yields plain
-	// text on stdout while leaving stderr colored if that's still
-	// a TTY.
-	co := termenv.NewOutput(stderr, termenv.WithProfile(profile))
-	stdoutCo := termenv.NewOutput(stdout, termenv.WithProfile(profile))
-
-	f, ok := flavor.Get(common.Flavor)
+
+	f, ok := lookupFlavor(common.Flavor, stderr)
 	if !ok {
 		_, _ = fmt.Fprintf(stderr, "Error: unknown flavor '%s'\n", common.Flavor)
 		_, _ = fmt.Fprintf(stderr, "Available flavors: %s\n", strings.Join(flavor.List(), ", "))
 		return fmt.Errorf("unknown flavor: %s", common.Flavor)
 	}
 
-	pattern, err := getInput(fs.Args(), stdin)
+	// With --from-json the AST comes from the document rather than the
+	// flavor's parser. A regolith document names its own flavor, which
+	// is used unless --flavor was given explicitly.
+	parse := f.Parse
+	var imported *importer.Document
+	if *inputSource != "" && (*nullSeparated || *fromJSON != "") {
+		err := fmt.Errorf("--input cannot be combined with --null or --from-json")
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+	if *fromJSON != "" {
+		if *checkOnly || *nullSeparated {
+			err := fmt.Errorf("--from-json cannot be combined with --check or --null")
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return err
+		}
+		if imported, err = readImport(*fromJSON, fs.Args(), stdin); err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return err
+		}
+		if docFlavor, ok := flavor.Get(imported.Flavor); ok && !fs.Changed("flavor") {
+			f = docFlavor
+		}
+		if imported.Pattern == "" && (*showSource || *showRuler) {
+			err := fmt.Errorf("--show-source needs pattern text, which a %s tree does not have", imported.Format)
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return err
+		}
+		parse = func(string) (*ast.Regexp, error) { return imported.Root, nil }
+	}
+
+	// --java-flags are Pattern.compile's flags argument. LITERAL and
+	// COMMENTS change how the pattern text is read, so they go to the
+	// parser, and the flags panel names each flag Java's way.
+	var flagLabels []string
+	if len(*javaFlags) > 0 {
+		if f.Name() != "java" || imported != nil {
+			err := fmt.Errorf("--java-flags needs --flavor java and a pattern to parse")
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return err
+		}
+		if flagLabels, err = javaFlagLabels(f, *javaFlags); err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return err
+		}
+		parse = func(pattern string) (*ast.Regexp, error) {
+			return flavor.ParseWithFlags(f, pattern, *javaFlags)
+		}
+	}
+
+	// --pattern-flags are flags set outside the pattern text, as an
+	// engine's options argument or a manifest entry does. They only
+	// label the diagram, so any flavor that lists the letters takes them.
+	if *patternFlags != "" {
+		if imported != nil {
+			err := fmt.Errorf("--pattern-flags needs a pattern to parse, not --from-json")
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return err
+		}
+		if err := checkPatternFlags(f, *patternFlags); err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return err
+		}
+		parsePattern := parse
+		parse = func(pattern string) (*ast.Regexp, error) {
+			root, err := parsePattern(pattern)
+			if err != nil {
+				return nil, err
+			}
+			for _, c := range *patternFlags {
+				if !strings.ContainsRune(root.Flags, c) {
+					root.Flags += string(c)
+				}
+			}
+			return root, nil
+		}
+	}
+
+	// --trace-parse records the parser's rule trace for every pattern,
+	// failing or not, each under a header naming what was parsed.
+	if *traceParse != "" {
+		if imported != nil || len(*javaFlags) > 0 {
+			err := fmt.Errorf("--trace-parse traces the plain pattern parser, which --from-json and --java-flags do not use")
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return err
+		}
+		traceFile, err := os.Create(*traceParse)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return err
+		}
+		defer func() { _ = traceFile.Close() }()
+		parse = func(pattern string) (*ast.Regexp, error) {
+			_, _ = fmt.Fprintf(traceFile, "# regolith %s, flavor %s, pattern %q\n", version, f.Name(), pattern)
+			root, err := flavor.ParseTrace(f, pattern, traceFile)
+			if err != nil {
+				_, _ = fmt.Fprintf(traceFile, "# result: %v\n", err)
+			} else {
+				_, _ = fmt.Fprintf(traceFile, "# result: ok\n")
+			}
+			return root, err
+		}
+	}
+
+	// --pcre-version and --js-target fail patterns the deployed engine
+	// would reject even though this (newer) grammar accepts them.
+	for _, gate := range []struct{ flag, flavor, version string }{
+		{"pcre-version", "pcre", *pcreVersion},
+		{"js-target", "javascript", *jsTarget},
+	} {
+		if gate.version == "" {
+			continue
+		}
+		if f.Name() != gate.flavor {
+			err := fmt.Errorf("--%s needs --flavor %s", gate.flag, gate.flavor)
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return err
+		}
+		// An empty pattern needs no particular release, so this only
+		// rejects a malformed version before any pattern is read.
+		if err := flavor.CheckVersion(f, &ast.Regexp{}, gate.version); err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return err
+		}
+		parsePattern, version := parse, gate.version
+		parse = func(pattern string) (*ast.Regexp, error) {
+			root, err := parsePattern(pattern)
+			if err != nil {
+				return nil, err
+			}
+			if err := flavor.CheckVersion(f, root, version); err != nil {
+				return nil, err
+			}
+			return root, nil
+		}
+	}
+
+	stopProfile, err := diag.startProfile(stderr)
 	if err != nil {
 		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
-		fs.Usage()
 		return err
 	}
+	defer func() {
+		if err := stopProfile(); err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		}
+	}()
+	metricsW, closeMetrics, err := diag.openMetrics(stderr)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+	defer func() { _ = closeMetrics() }()
+	// Metrics lines go out with the rest of a pattern's messages, unless
+	// --metrics-out gives them a file, which --null's workers share.
+	if diag.MetricsOut != "" {
+		metricsW = &lockedWriter{w: metricsW}
+	}
+	metricsTo := func(stderr io.Writer) io.Writer {
+		if diag.MetricsOut != "" {
+			return metricsW
+		}
+		return stderr
+	}
 
-	if *unescapeFlag {
-		pattern = unescape.JavaStringLiteral(pattern)
-	} else if (common.Flavor == "java" || common.Flavor == "dotnet") && unescape.ContainsDoubleEscapes(pattern) {
-		_, _ = fmt.Fprintf(stderr, "Note: Pattern contains '\\\\' sequences. If copied from source code, use --unescape to apply string literal unescaping.\n")
+	// renderPattern runs the parse/render pipeline for one pattern.
+	// seq is its 1-based position in the input (always 1 outside
+	// --null) and, with the pattern and flavor, fills in the -o
+	// template placeholders. Under --null several patterns run at once,
+	// each writing to its own stdout and stderr, which shadow the
+	// command's.
+	runStart := time.Now()
+	renderPattern := func(pattern string, seq int, stdout, stderr io.Writer) error {
+		// Two termenv outputs so stdout-bound content and stderr-bound
+		// status messages each get the auto-detected profile for their
+		// own writer. Piping stdout to a file correctly yields plain
+		// text on stdout while leaving stderr colored if that's still
+		// a TTY.
+		co := termenv.NewOutput(stderr, termenv.WithProfile(profile))
+		stdoutCo := termenv.NewOutput(stdout, termenv.WithProfile(profile))
+		job := common
+		job.Output = expandOutputTemplate(common.Output, outputVars{
+			Seq:     seq,
+			Pattern: pattern,
+			Flavor:  f.Name(),
+			Time:    runStart,
+		})
+
+		if *unescapeFlag {
+			pattern = unescape.JavaStringLiteral(pattern)
+		} else if (job.Flavor == "java" || job.Flavor == "dotnet") && unescape.ContainsDoubleEscapes(pattern) {
+			_, _ = fmt.Fprintf(stderr, "Note: Pattern contains '\\\\' sequences. If copied from source code, use --unescape to apply string literal unescaping.\n")
+		}
+
+		if *checkOnly {
+			return runCheck(f.Name(), parse, *inputSource, pattern, job.ErrorFormat, stdout, stdoutCo)
+		}
+
+		met := patternMetrics{Pattern: pattern}
+		parseStart := time.Now()
+		parsedAST, err := parse(pattern)
+		met.Parse = time.Since(parseStart)
+		if err != nil {
+			reportSourceParseError(stderr, *inputSource, pattern, f.Name(), job.ErrorFormat, err, co)
+			return fmt.Errorf("parse error: %w", err)
+		}
+		if *flatten {
+			ast.FlattenAlternations(parsedAST)
+		}
+		if *expandSubroutines {
+			ast.ExpandSubroutines(parsedAST, *subroutineDepth)
+		}
+		if diag.Metrics {
+			met.Nodes = countNodes(parsedAST)
+			defer met.write(metricsTo(stderr))
+		}
+
+		switch job.Format {
+		case "text":
+			// Text format has two personalities: ANSI on stdout (default)
+			// and Markdown when redirected to a file via -o. This mirrors
+			// the convention established by `regolith analyze`, keeping
+			// both commands predictable.
+			toFile := job.Output != ""
+			text := met.timeRender(func() string {
+				return output.RenderText(parsedAST, pattern, f.Name(), toFile, stdoutCo)
+			})
+			return writeTextOrStdout(text, job.Output, stdout, co)
+
+		case "diagram":
+			cfg, err := buildSVGConfig(fs, &job, &style)
+			if err != nil {
+				_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+				return err
+			}
+			r := renderer.New(cfg)
+			if *expandShorthands {
+				r.Shorthands = flavor.Shorthands(f, parsedAST)
+			}
+			text := met.timeRender(func() string {
+				return r.RenderTextDiagram(parsedAST, *asciiDiagram)
+			})
+			return writeTextOrStdout(text, job.Output, stdout, co)
+
+		case "svg", "png", "pdf", "html":
+			return renderAndWriteSVGPages(fs, &job, &style, pattern, f.Name(), stdout, stderr, co,
+				func(r *renderer.Renderer) []string {
+					if job.Format == "html" {
+						r.PostRender = attachHovercards(f.Name())
+					}
+					if *showSource || *showRuler {
+						r.Source = flavor.Tokens(f, pattern)
+						r.Ruler = *showRuler
+					}
+					r.Summary = *summary
+					r.GroupLegend, r.Pattern = *groupLegend, pattern
+					r.Title, r.Caption = *title, *caption
+					if *localeNote {
+						r.Caption = withLocaleNote(r.Caption, localeConstructs(f, parsedAST, pattern))
+					}
+					r.FlagLabels = flagLabels
+					r.Flavor, r.Language = f, job.Lang
+					if *expandShorthands {
+						r.Shorthands = flavor.Shorthands(f, parsedAST)
+					}
+					var pages []string
+					met.timeRender(func() string {
+						pages = r.RenderPages(parsedAST, float64(*paginate))
+						return strings.Join(pages, "")
+					})
+					return pages
+				})
+
+		case "json":
+			var err error
+			out := met.timeRender(func() string {
+				var s string
+				s, err = output.RenderJSON(parsedAST, pattern, f.Name())
+				return s
+			})
+			if err != nil {
+				_, _ = fmt.Fprintf(stderr, "Error rendering JSON: %v\n", err)
+				return fmt.Errorf("json render: %w", err)
+			}
+			return writeTextOrStdout(out+"\n", job.Output, stdout, co)
+
+		case "gotemplate":
+			if *templatePath == "" {
+				err := fmt.Errorf("gotemplate format requires --template (e.g., --template out.tmpl)")
+				_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+				return err
+			}
+			tmplText, err := os.ReadFile(*templatePath)
+			if err != nil {
+				_, _ = fmt.Fprintf(stderr, "Error: reading template: %v\n", err)
+				return fmt.Errorf("reading template: %w", err)
+			}
+			cfg, err := buildSVGConfig(fs, &job, &style)
+			if err != nil {
+				_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+				return err
+			}
+			r := renderer.New(cfg)
+			r.Flavor, r.Language = f, job.Lang
+			out := met.timeRender(func() string {
+				layout, lerr := r.Layout(parsedAST)
+				if lerr != nil {
+					err = lerr
+					return ""
+				}
+				var s string
+				s, err = output.RenderTemplate(parsedAST, layout, pattern, f.Name(), filepath.Base(*templatePath), string(tmplText))
+				return s
+			})
+			if err != nil {
+				_, _ = fmt.Fprintf(stderr, "Error rendering template: %v\n", err)
+				return fmt.Errorf("template render: %w", err)
+			}
+			return writeTextOrStdout(out, job.Output, stdout, co)
+
+		default:
+			_, _ = fmt.Fprintf(stderr, "Error: unknown format %q\nAvailable: %s\n", job.Format, strings.Join(renderFormats, ", "))
+			return fmt.Errorf("unknown format: %s", job.Format)
+		}
+	}
+
+	// renderStack draws several patterns, one under another, in a single
+	// document, each under its --pattern-title or else its own text.
+	// The panels and options are those renderPattern gives one pattern.
+	renderStack := func(patterns []string) error {
+		var err error
+		switch {
+		case common.Format != "svg" && common.Format != "png" && common.Format != "pdf":
+			err = fmt.Errorf("several patterns stack only in svg, png or pdf output, not %s; use --null for one output per pattern", common.Format)
+		case *checkOnly || *paginate > 0:
+			err = fmt.Errorf("--check and --paginate take one pattern; use --null for several")
+		case len(*patternTitles) > len(patterns):
+			err = fmt.Errorf("%d --pattern-title values for %d patterns", len(*patternTitles), len(patterns))
+		}
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return err
+		}
+		co := termenv.NewOutput(stderr, termenv.WithProfile(profile))
+		entries := make([]renderer.StackEntry, len(patterns))
+		var shorthands map[string]flavor.ShorthandSet
+		var localeParts []string
+		for i, pattern := range patterns {
+			if *unescapeFlag {
+				pattern = unescape.JavaStringLiteral(pattern)
+			}
+			root, err := parse(pattern)
+			if err != nil {
+				reportParseError(stderr, pattern, f.Name(), common.ErrorFormat, err, co)
+				return fmt.Errorf("parse error: %w", err)
+			}
+			if *flatten {
+				ast.FlattenAlternations(root)
+			}
+			if *expandSubroutines {
+				ast.ExpandSubroutines(root, *subroutineDepth)
+			}
+			if *localeNote {
+				for _, part := range localeConstructs(f, root, pattern) {
+					if !slices.Contains(localeParts, part) {
+						localeParts = append(localeParts, part)
+					}
+				}
+			}
+			entries[i] = renderer.StackEntry{Title: pattern, Root: root, Pattern: pattern}
+			if i < len(*patternTitles) {
+				entries[i].Title = (*patternTitles)[i]
+			}
+			if *showSource || *showRuler {
+				entries[i].Source = flavor.Tokens(f, pattern)
+			}
+			if *expandShorthands {
+				// A flavor gives an escape the same set in every pattern.
+				if shorthands == nil {
+					shorthands = map[string]flavor.ShorthandSet{}
+				}
+				maps.Copy(shorthands, flavor.Shorthands(f, root))
+			}
+		}
+		job := common
+		job.Output = expandOutputTemplate(common.Output, outputVars{
+			Seq:     1,
+			Pattern: patterns[0],
+			Flavor:  f.Name(),
+			Time:    runStart,
+		})
+		return renderAndWriteSVGPages(fs, &job, &style, strings.Join(patterns, "\n"), f.Name(), stdout, stderr, co,
+			func(r *renderer.Renderer) []string {
+				r.Ruler = *showRuler
+				r.Summary = *summary
+				r.GroupLegend = *groupLegend
+				r.Title, r.Caption = *title, withLocaleNote(*caption, localeParts)
+				r.FlagLabels = flagLabels
+				r.Flavor, r.Language = f, job.Lang
+				r.Shorthands = shorthands
+				return []string{r.RenderStack(entries)}
+			})
 	}
 
-	parsedAST, err := f.Parse(pattern)
+	if imported != nil {
+		pattern := imported.Pattern
+		if pattern == "" {
+			pattern = *fromJSON
+		}
+		return renderPattern(pattern, 1, stdout, stderr)
+	}
+	if *nullSeparated {
+		// The parser trace redirects the process's stdout while it
+		// runs, so traced patterns take turns.
+		if *traceParse != "" {
+			*jobs = 1
+		}
+		if !*gallery || *checkOnly || common.Format != "svg" {
+			return runPatternList(fs.Args(), stdin, common.Output, *jobs, stdout, stderr, renderPattern)
+		}
+		var g galleryRecorder
+		outputPath := func(pattern string, seq int) string {
+			return expandOutputTemplate(common.Output, outputVars{Seq: seq, Pattern: pattern, Flavor: f.Name(), Time: runStart})
+		}
+		err := runPatternList(fs.Args(), stdin, common.Output, *jobs, stdout, stderr, g.wrap(renderPattern, outputPath, f.Name()))
+		co := termenv.NewOutput(stdout, termenv.WithProfile(profile))
+		if gerr := g.write(stdout, stderr, co); gerr != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", gerr)
+			if err == nil {
+				err = gerr
+			}
+		}
+		return err
+	}
+
+	if *inputSource != "" {
+		pattern, err := readPatternSource(*inputSource, fs.Args(), stdin)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return err
+		}
+		return renderPattern(pattern, 1, stdout, stderr)
+	}
+	patterns := append(slices.Clone(fs.Args()), *patternArgs...)
+	if len(patterns) > 1 || len(*patternTitles) > 0 {
+		return renderStack(patterns)
+	}
+	pattern, err := getInput(patterns, stdin)
 	if err != nil {
-		displayParseError(stderr, pattern, err, co)
-		return fmt.Errorf("parse error: %w", err)
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		fs.Usage()
+		return err
 	}
+	return renderPattern(pattern, 1, stdout, stderr)
+}
 
-	switch common.Format {
-	case "text":
-		// Text format has two personalities: ANSI on stdout (default)
-		// and Markdown when redirected to a file via -o. This mirrors
-		// the convention established by `regolith analyze`, keeping
-		// both commands predictable.
-		toFile := common.Output != ""
-		text := output.RenderText(parsedAST, pattern, f.Name(), toFile, stdoutCo)
-		return writeTextOrStdout(text, common.Output, stdout, co)
+// writeFlavorList lists the flavors for the usage text, grouped by
+// family, each with its description and any aliases.
+func writeFlavorList(w io.Writer) {
+	_, _ = fmt.Fprintf(w, "\nAvailable flavors:\n")
+	for _, group := range flavor.ByFamily() {
+		_, _ = fmt.Fprintf(w, "  %s:\n", group.Family)
+		for _, name := range group.Flavors {
+			f, _ := flavor.Get(name)
+			desc := f.Description()
+			if aliases := flavor.AliasesOf(name); len(aliases) > 0 {
+				desc += " (also: " + strings.Join(aliases, ", ") + ")"
+			}
+			_, _ = fmt.Fprintf(w, "    %-12s %s\n", name, desc)
+		}
+	}
+	_, _ = fmt.Fprintf(w, "  Run regolith flavors <name> for what each supports.\n")
+}
+
+// writeFlavorFlags lists each flavor's pattern flags for the usage
+// text, in lang. Each flavor's flags are documented on one site, so
+// its heading carries the link they share rather than one per flag.
+func writeFlavorFlags(w io.Writer, lang string) {
+	_, _ = fmt.Fprintf(w, "\nPattern flags:\n")
+	for _, name := range flavor.List() {
+		f, _ := flavor.Get(name)
+		flags := f.SupportedFlags()
+		if len(flags) == 0 {
+			continue
+		}
+		urls := make([]string, 0, len(flags))
+		for _, info := range flags {
+			if info.DocURL != "" {
+				urls = append(urls, info.DocURL)
+			}
+		}
+		if docs := sharedDocURL(urls); docs != "" {
+			_, _ = fmt.Fprintf(w, "  %s (%s)\n", name, docs)
+		} else {
+			_, _ = fmt.Fprintf(w, "  %s\n", name)
+		}
+		for _, info := range flags {
+			text := info.Text(lang)
+			_, _ = fmt.Fprintf(w, "    %c  %-24s %s\n", info.Char, text.Label, text.Description)
+		}
+	}
+}
 
-	case "svg":
-		return renderAndWriteSVG(fs, &common, &style, stdout, stderr, co,
-			func(r *renderer.Renderer) string { return r.Render(parsedAST) })
+// sharedDocURL is the page, or else the directory, that every URL in
+// urls points into, or "" when they have nothing in common.
+func sharedDocURL(urls []string) string {
+	if len(urls) == 0 {
+		return ""
+	}
+	page, _, _ := strings.Cut(urls[0], "#")
+	for _, u := range urls[1:] {
+		for !strings.HasPrefix(u, page) {
+			i := strings.LastIndexByte(strings.TrimSuffix(page, "/"), '/')
+			if i < len("https://") {
+				return ""
+			}
+			page = page[:i+1]
+		}
+	}
+	return page
+}
 
-	case "json":
-		out, err := output.RenderJSON(parsedAST, pattern, f.Name())
+// runPatternList implements --null: read NUL-separated patterns from
+// stdin and run render on each with its 1-based sequence number, on up
+// to workers goroutines (see workpool.Workers). Each pattern's output is
+// buffered and written out in input order as soon as the patterns
+// before it are done, so results stream while later patterns render.
+// A failing or crashing pattern does not stop the rest; the first error
+// is returned once the whole list has been processed so the exit status
+// still reflects it, after a one-line summary on stderr.
+func runPatternList(args []string, stdin io.Reader, outTmpl string, workers int, stdout, stderr io.Writer,
+	render func(pattern string, seq int, stdout, stderr io.Writer) error) error {
+	if len(args) > 0 {
+		err := fmt.Errorf("--null reads patterns from stdin; do not also pass a pattern argument")
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+	if stdin == nil {
+		err := fmt.Errorf("--null requires patterns on stdin")
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+	patterns, err := readNullSeparated(stdin)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+	if len(patterns) > 1 && outTmpl == stdoutPath {
+		err := fmt.Errorf("-o - can hold only one of the %d patterns; use an -o template with %%n or %%p (e.g. out-%%n.svg)", len(patterns))
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+	if len(patterns) > 1 && outTmpl != "" && !templateVariesPerPattern(outTmpl) {
+		err := fmt.Errorf("-o %q would be overwritten by each of %d patterns; include %%n or %%p (e.g. out-%%n.svg)", outTmpl, len(patterns))
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+
+	start := time.Now()
+	outs := make([]bytes.Buffer, len(patterns))
+	errOuts := make([]bytes.Buffer, len(patterns))
+	var firstErr error
+	failed := 0
+	workpool.Run(len(patterns), workers, func(i int) error {
+		return render(patterns[i], i+1, &outs[i], &errOuts[i])
+	}, func(i int, err error) {
+		_, _ = stdout.Write(outs[i].Bytes())
+		_, _ = stderr.Write(errOuts[i].Bytes())
+		outs[i], errOuts[i] = bytes.Buffer{}, bytes.Buffer{}
 		if err != nil {
-			_, _ = fmt.Fprintf(stderr, "Error rendering JSON: %v\n", err)
-			return fmt.Errorf("json render: %w", err)
+			failed++
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	})
+	if len(patterns) > 1 {
+		_, _ = fmt.Fprintf(stderr, "Processed %d patterns in %s: %d ok, %d failed\n",
+			len(patterns), time.Since(start).Round(time.Millisecond), len(patterns)-failed, failed)
+	}
+	return firstErr
+}
+
+// lockedWriter serializes writes from concurrent goroutines.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+// readNullSeparated splits stdin on NUL bytes, the framing produced by
+// find -print0 and friends. A trailing NUL does not start an empty
+// final pattern, and patterns are not trimmed: with NUL framing,
+// surrounding whitespace and newlines are part of the pattern.
+func readNullSeparated(r io.Reader) ([]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from stdin: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("no pattern provided")
+	}
+	patterns := strings.Split(strings.TrimSuffix(string(data), "\x00"), "\x00")
+	return patterns, nil
+}
+
+// javaFlagLabels checks the names given to --java-flags and returns
+// the flags panel entry for each, in the order given.
+func javaFlagLabels(f flavor.Flavor, names []string) ([]string, error) {
+	fp, ok := f.(flavor.FlagParser)
+	if !ok {
+		return nil, fmt.Errorf("flavor %s does not take compile flags", f.Name())
+	}
+	labels := make([]string, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, info := range fp.CompileFlags() {
+			if strings.EqualFold(info.Name, strings.TrimPrefix(strings.TrimSpace(name), "Pattern.")) {
+				labels = append(labels, info.Name+": "+info.Description)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown Java compile flag %q", name)
 		}
-		_, _ = fmt.Fprintln(stdout, out)
+	}
+	return labels, nil
+}
 
-	default:
-		_, _ = fmt.Fprintf(stderr, "Error: unknown format %q\nAvailable: json, svg, text\n", common.Format)
-		return fmt.Errorf("unknown format: %s", common.Format)
+// checkPatternFlags rejects --pattern-flags letters f does not list.
+func checkPatternFlags(f flavor.Flavor, letters string) error {
+	var known strings.Builder
+	for _, info := range f.SupportedFlags() {
+		known.WriteRune(info.Char)
+	}
+	for _, c := range letters {
+		if !strings.ContainsRune(known.String(), c) {
+			if known.Len() == 0 {
+				return fmt.Errorf("flavor %s has no pattern flags (got %q)", f.Name(), letters)
+			}
+			return fmt.Errorf("unknown %s pattern flag %q (available: %s)", f.Name(), c, known.String())
+		}
 	}
+	return nil
+}
 
+// runCheck implements --check: parse and validate the pattern, report
+// the outcome on stdout, and render nothing. The outcome is the
+// command's product here, so even a failure goes to stdout in the
+// selected --error-format; the exit status carries pass/fail for CI.
+func runCheck(flavorName string, parse func(string) (*ast.Regexp, error), source, pattern, errorFormat string, stdout io.Writer, stdoutCo *termenv.Output) error {
+	_, err := parse(pattern)
+	if err != nil {
+		reportSourceParseError(stdout, source, pattern, flavorName, errorFormat, err, stdoutCo)
+		return fmt.Errorf("parse error: %w", err)
+	}
+	switch errorFormat {
+	case "gnu":
+		// Like a compiler, a clean pattern produces no messages.
+		return nil
+	case "json":
+		doc, err := output.RenderValidationJSON(pattern, flavorName, nil)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintln(stdout, doc)
+		return nil
+	}
+	_, _ = fmt.Fprintln(stdout, stdoutCo.String("Valid "+flavorName+" pattern").Foreground(termenv.ANSIColor(2)).String())
 	return nil
 }
 
@@ -163,47 +900,154 @@ func getInput(args []string, stdin io.Reader) (string, error) {
 	return "", fmt.Errorf("no pattern provided")
 }
 
-// displayParseError shows a parse error with a caret pointing at the
-// offending column when the pigeon error text has usable position
-// information.
-func displayParseError(w io.Writer, pattern string, err error, co *termenv.Output) {
-	errStr := err.Error()
-
-	var line, col int
-	var msg string
-
-	if strings.Contains(errStr, "parse error:") {
-		_, parseErr := fmt.Sscanf(errStr, "parse error: %d:%d", &line, &col)
-		if parseErr == nil {
-			idx := strings.Index(errStr, ":")
-			if idx != -1 {
-				idx = strings.Index(errStr[idx+1:], ":")
-				if idx != -1 {
-					remaining := errStr[strings.Index(errStr, "parse error:")+len("parse error:"):]
-					parts := strings.SplitN(remaining, ":", 2)
-					if len(parts) > 1 {
-						msg = strings.TrimSpace(parts[1])
-						if strings.Contains(msg, "):") {
-							msg = strings.TrimSpace(strings.SplitN(msg, "):", 2)[1])
-						}
-					}
-				}
-			}
+// readPatternSource reads the --input pattern from source (see
+// readSource), trimmed like a pattern piped to stdin. It rejects a
+// pattern argument, which would otherwise be silently ignored.
+func readPatternSource(source string, args []string, stdin io.Reader) (string, error) {
+	if len(args) > 0 {
+		return "", fmt.Errorf("--input takes no pattern argument (got %q)", args[0])
+	}
+	data, err := readSource(source, stdin)
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %w", source, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// readImport reads and decodes the --from-json document at path, a
+// file, URL, or stdin when path is "-". It rejects a pattern argument,
+// which would otherwise be silently ignored.
+func readImport(path string, args []string, stdin io.Reader) (*importer.Document, error) {
+	if len(args) > 0 {
+		return nil, fmt.Errorf("--from-json takes no pattern argument (got %q)", args[0])
+	}
+	data, err := readSource(path, stdin)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	doc, err := importer.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return doc, nil
+}
+
+// attachHovercards returns a PostRender hook that tags each node the
+// diagram draws with its hovercard for --format html, and with the
+// part of the pattern it came from when the parser recorded it.
+func attachHovercards(flavorName string) renderer.RenderHook {
+	return func(node ast.Node, rn *renderer.RenderedNode) {
+		data := output.DescribeNode(node, flavorName).HTMLData()
+		if pos := node.Pos(); pos != (ast.Position{}) {
+			data["src-start"] = strconv.Itoa(pos.Start)
+			data["src-end"] = strconv.Itoa(pos.End)
+		}
+		rn.Element = &renderer.Group{
+			Data:     data,
+			Children: []renderer.SVGElement{rn.Element},
+		}
+	}
+}
+
+// reportParseError writes a parse error in the --error-format the user
+// selected: the caret display for "text", the validation JSON document
+// for "json", or "<pattern>:line:col: message" lines for "gnu". All
+// three carry the flavor's hints for the pattern.
+func reportParseError(w io.Writer, pattern, flavorName, errorFormat string, err error, co *termenv.Output) {
+	reportSourceParseError(w, "", pattern, flavorName, errorFormat, err, co)
+}
+
+// reportSourceParseError is reportParseError for a pattern read from
+// source, an --input path, which "gnu" errors then name so an editor
+// can open it at the offending character. An empty source names the
+// pattern argument itself, and "-" standard input.
+func reportSourceParseError(w io.Writer, source, pattern, flavorName, errorFormat string, err error, co *termenv.Output) {
+	info := output.ParseError(err)
+	if f, ok := flavor.Get(flavorName); ok {
+		info.Hints = flavor.Hints(f, pattern, errorOffset(info))
+	}
+	switch errorFormat {
+	case "gnu":
+		switch source {
+		case "":
+			source = "<pattern>"
+		case stdoutPath:
+			source = "<stdin>"
+		}
+		_, _ = fmt.Fprintln(w, output.RenderValidationGNU(source, &info))
+		return
+	case "json":
+		if doc, jerr := output.RenderValidationJSON(pattern, flavorName, &info); jerr == nil {
+			_, _ = fmt.Fprintln(w, doc)
+			return
 		}
 	}
+	displayParseError(w, pattern, err, info.Hints, co)
+}
+
+// errorOffset is the byte offset a parse error points at, or -1 when
+// the error carried no position.
+func errorOffset(info output.ParseErrorInfo) int {
+	if info.Line == 0 {
+		return -1
+	}
+	return info.Offset
+}
+
+// displayParseError shows a parse error with a caret pointing at the
+// offending column when the pigeon error text has usable position
+// information, followed by any hints.
+func displayParseError(w io.Writer, pattern string, err error, hints []string, co *termenv.Output) {
+	info := output.ParseError(err)
 
 	header := co.String("Error parsing pattern:").Bold().Foreground(termenv.ANSIColor(1)).String()
 	_, _ = fmt.Fprintf(w, "%s\n\n", header)
 	_, _ = fmt.Fprintf(w, "  %s\n", pattern)
 
-	if col > 0 && col <= len(pattern) {
+	if pad, ok := caretPadding(pattern, info.Column); ok {
 		caret := co.String("^").Bold().Foreground(termenv.ANSIColor(1)).String()
-		_, _ = fmt.Fprintf(w, "  %s%s\n", strings.Repeat(" ", col-1), caret)
+		_, _ = fmt.Fprintf(w, "  %s%s\n", pad, caret)
 	}
 
-	if msg != "" {
-		_, _ = fmt.Fprintf(w, "\n%s\n", msg)
-	} else {
-		_, _ = fmt.Fprintf(w, "\n%s\n", errStr)
+	_, _ = fmt.Fprintf(w, "\n%s\n", info.Message)
+	for _, h := range hints {
+		label := co.String("hint:").Bold().Foreground(termenv.ANSIColor(3)).String()
+		_, _ = fmt.Fprintf(w, "%s %s\n", label, h)
+	}
+}
+
+// caretPadding returns the whitespace that puts a caret under the
+// col'th rune (1-based) of pattern; col may be one past the end for
+// errors at end of input. The parsers count columns in runes, but
+// terminals advance by display cells — CJK and most emoji take two,
+// combining marks none — so the padding is measured per grapheme
+// cluster. Tabs are copied through so the terminal expands them the
+// same way on both lines.
+func caretPadding(pattern string, col int) (string, bool) {
+	if col < 1 || col > utf8.RuneCountInString(pattern)+1 {
+		return "", false
+	}
+	prefix := pattern
+	n := 0
+	for i := range pattern {
+		if n == col-1 {
+			prefix = pattern[:i]
+			break
+		}
+		n++
+	}
+
+	var pad strings.Builder
+	state := -1
+	for prefix != "" {
+		var cluster string
+		var width int
+		cluster, prefix, width, state = uniseg.FirstGraphemeClusterInString(prefix, state)
+		if cluster == "\t" {
+			pad.WriteByte('\t')
+			continue
+		}
+		pad.WriteString(strings.Repeat(" ", width))
 	}
+	return pad.String(), true
 }
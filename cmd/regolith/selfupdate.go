@@ -0,0 +1,113 @@
+package main
+
+// ================================================================================
+// self-update subcommand
+// ================================================================================
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/0x4d5352/regolith/internal/selfupdate"
+)
+
+// releaseKey is the base64 Ed25519 public key that release checksums
+// are signed with. Release builds set it with -X main.releaseKey; a
+// build without it refuses to self-update, as it has nothing to verify
+// a download against.
+var releaseKey = ""
+
+// updateAPI is the latest-release endpoint self-update asks; tests point
+// it at a local server.
+var updateAPI = selfupdate.DefaultAPI
+
+// executablePath locates the binary self-update replaces; tests swap in
+// a scratch file.
+var executablePath = os.Executable
+
+// updateTimeout bounds a whole update, the archive download included.
+const updateTimeout = 5 * time.Minute
+
+// runSelfUpdate implements `regolith self-update`: replace the running
+// binary with the latest release once its signature and checksum
+// verify (see package selfupdate).
+func runSelfUpdate(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("regolith self-update", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	check := fs.Bool("check", false, "Report whether an update is available without installing it")
+
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "regolith self-update - Update regolith to the latest release\n\n")
+		_, _ = fmt.Fprintf(stderr, "Usage:\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith self-update [flags]\n\n")
+		_, _ = fmt.Fprintf(stderr, "Downloads the release archive for this platform, checks the\n")
+		_, _ = fmt.Fprintf(stderr, "signature on the release checksums against the key built into this\n")
+		_, _ = fmt.Fprintf(stderr, "binary and the archive against its checksum, and only then replaces\n")
+		_, _ = fmt.Fprintf(stderr, "the binary. Builds without a release key cannot update themselves.\n\n")
+		_, _ = fmt.Fprintf(stderr, "Flags:\n")
+		fs.PrintDefaults()
+	}
+
+	err := parseFlags(fs, args[2:], stdout)
+	if errors.Is(err, flag.ErrHelp) || errors.Is(err, errConfigShown) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fail := func(err error) error {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+
+	key, err := selfupdate.ParsePublicKey(releaseKey)
+	if err != nil && !*check {
+		return fail(err)
+	}
+	u := &selfupdate.Updater{
+		Client:    fetchClient,
+		API:       updateAPI,
+		UserAgent: "regolith/" + version,
+		PublicKey: key,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), updateTimeout)
+	defer cancel()
+	rel, err := u.Latest(ctx)
+	if err != nil {
+		return fail(fmt.Errorf("checking for updates: %w", err))
+	}
+	if !selfupdate.Newer(rel.Version, version) {
+		_, _ = fmt.Fprintf(stdout, "regolith %s is up to date\n", version)
+		return nil
+	}
+	if *check {
+		_, _ = fmt.Fprintf(stdout, "regolith %s is available (installed: %s)\n", rel.Version, version)
+		return nil
+	}
+
+	exe, err := executablePath()
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err != nil {
+		return fail(fmt.Errorf("locating the installed binary: %w", err))
+	}
+	binary, err := u.Download(ctx, rel, runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		return fail(err)
+	}
+	if err := selfupdate.Replace(exe, binary); err != nil {
+		return fail(fmt.Errorf("replacing %s: %w", exe, err))
+	}
+	_, _ = fmt.Fprintf(stdout, "Updated regolith %s -> %s (%s)\n", version, rel.Version, exe)
+	return nil
+}
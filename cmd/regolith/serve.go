@@ -0,0 +1,180 @@
+package main
+
+// ================================================================================
+// serve subcommand
+// ================================================================================
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"os"
+	"os/signal"
+	"runtime"
+	"slices"
+	"syscall"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/0x4d5352/regolith/internal/server"
+)
+
+// runServe implements `regolith serve`: render patterns over HTTP until
+// interrupted. SIGINT and SIGTERM drain in-flight requests before the
+// process exits, which is what container orchestrators expect.
+//
+// The abuse limits default on, sized for a small public instance: the
+// library leaves them off so embedders opt in, but someone running the
+// binary on the open internet should not have to know to.
+func runServe(args []string, _ io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("regolith serve", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	// Only the styling subset of commonFlags applies; flavor is a
+	// per-request default and format/output come from each request.
+	var common commonFlags
+	fs.StringVarP(&common.Flavor, "flavor", "f", "javascript", "Flavor used when a request does not name one")
+	fs.StringVar(&common.Theme, "theme", "", "Color theme (e.g. light, dark, catppuccin-mocha, gruvbox-dark)")
+	fs.Float64VarP(&common.Padding, "padding", "p", 10, "Padding around diagram")
+	fs.Float64Var(&common.FontSize, "font-size", 13, "Font size in pixels")
+	fs.Float64Var(&common.LineWidth, "line-width", 1.5, "Stroke width for connectors and loops")
+
+	var style svgStyleFlags
+	style.Register(fs)
+
+	addr := fs.String("addr", ":8080", "Listen address")
+	cacheSize := fs.Int("cache-size", 512, "Maximum number of rendered responses to cache (0 disables)")
+	flavors := fs.StringSlice("flavors", nil, "Comma-separated flavors clients may request (default: all)")
+	maxPattern := fs.Int("max-pattern-length", 4096, "Reject patterns longer than this many bytes (0 disables)")
+	maxConcurrent := fs.Int("max-concurrent", 2*runtime.NumCPU(), "Maximum renders in flight at once (0 disables)")
+	timeout := fs.Duration("timeout", 5*time.Second, "Per-render deadline (0 disables)")
+	rateLimit := fs.Float64("rate-limit", 10, "Sustained requests per second per client (0 disables)")
+	rateBurst := fs.Int("rate-burst", 20, "Requests a client may send at once before --rate-limit applies")
+	trustProxy := fs.Bool("trust-proxy", false, "Identify clients by X-Forwarded-For (only behind a proxy that sets it)")
+
+	shares := fs.Bool("share", false, "Enable POST /share and short /s/{id} links to stored patterns")
+	shareTTL := fs.Duration("share-ttl", 7*24*time.Hour, "Expiry of shares that do not ask for one (0 keeps them until exit)")
+	maxShareTTL := fs.Duration("max-share-ttl", 30*24*time.Hour, "Longest expiry a share may ask for (0 disables)")
+	maxShares := fs.Int("max-shares", 10000, "Maximum shares stored at once (0 disables)")
+	shareAllow := fs.StringSlice("share-allow", nil, "Comma-separated IPs or CIDR ranges that may create shares (default: all)")
+	shareDeny := fs.StringSlice("share-deny", nil, "Comma-separated IPs or CIDR ranges that may not create shares")
+	baseURL := fs.String("base-url", "", "Scheme and host for share URLs, e.g. https://regex.example.com (default: from the request)")
+
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "regolith serve - Render patterns over HTTP\n\n")
+		_, _ = fmt.Fprintf(stderr, "Usage:\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith serve [flags]\n\n")
+		_, _ = fmt.Fprintf(stderr, "Endpoints:\n")
+		_, _ = fmt.Fprintf(stderr, "  /render?pattern=...&flavor=...&format=svg|json|text\n")
+		_, _ = fmt.Fprintf(stderr, "  /healthz    liveness probe\n")
+		_, _ = fmt.Fprintf(stderr, "  /metrics    Prometheus metrics\n")
+		_, _ = fmt.Fprintf(stderr, "  /share      POST pattern, flavor, format, expires; returns a /s/{id} link (with --share)\n\n")
+		_, _ = fmt.Fprintf(stderr, "Flags:\n")
+		fs.PrintDefaults()
+	}
+
+	err := parseFlags(fs, args[2:], stdout)
+	if errors.Is(err, flag.ErrHelp) || errors.Is(err, errConfigShown) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// Aliases are resolved up front so --flavors js admits -f javascript.
+	names := append([]string{common.Flavor}, *flavors...)
+	for i, name := range names {
+		f, ok := lookupFlavor(name, stderr)
+		if !ok {
+			_, _ = fmt.Fprintf(stderr, "Error: unknown flavor '%s'\n", name)
+			return fmt.Errorf("unknown flavor: %s", name)
+		}
+		names[i] = f.Name()
+	}
+	common.Flavor, *flavors = names[0], names[1:]
+	if len(*flavors) > 0 && !slices.Contains(*flavors, common.Flavor) {
+		err := fmt.Errorf("default flavor %q is not in --flavors", common.Flavor)
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+	cfg, err := buildSVGConfig(fs, &common, &style)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+	allow, err := parsePrefixes("--share-allow", *shareAllow)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+	deny, err := parsePrefixes("--share-deny", *shareDeny)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+
+	srv := &http.Server{
+		Addr: *addr,
+		Handler: server.New(server.Options{
+			DefaultFlavor: common.Flavor,
+			Config:        cfg,
+			CacheSize:     *cacheSize,
+
+			Flavors:          *flavors,
+			MaxPatternLength: *maxPattern,
+			MaxConcurrent:    *maxConcurrent,
+			Timeout:          *timeout,
+			RateLimit:        *rateLimit,
+			RateBurst:        *rateBurst,
+			TrustProxy:       *trustProxy,
+
+			Shares:      *shares,
+			ShareTTL:    *shareTTL,
+			MaxShareTTL: *maxShareTTL,
+			MaxShares:   *maxShares,
+			ShareAllow:  allow,
+			ShareDeny:   deny,
+			BaseURL:     *baseURL,
+		}),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	_, _ = fmt.Fprintf(stderr, "Listening on %s\n", *addr)
+
+	select {
+	case err := <-errc:
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
+
+// parsePrefixes reads --share-allow and --share-deny values: CIDR
+// ranges, or single addresses standing for a range of one.
+func parsePrefixes(flagName string, values []string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, v := range values {
+		if p, err := netip.ParsePrefix(v); err == nil {
+			prefixes = append(prefixes, p.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not an IP address or CIDR range", flagName, v)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
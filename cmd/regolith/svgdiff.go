@@ -0,0 +1,102 @@
+package main
+
+// ================================================================================
+// svgdiff subcommand
+// ================================================================================
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/muesli/termenv"
+	flag "github.com/spf13/pflag"
+
+	"github.com/0x4d5352/regolith/internal/output"
+	"github.com/0x4d5352/regolith/internal/svgtest"
+)
+
+// errSVGsDiffer makes `regolith svgdiff` exit non-zero when the two
+// documents differ, like diff(1).
+var errSVGsDiffer = errors.New("SVGs differ")
+
+// runSVGDiff implements `regolith svgdiff`: compare two SVG files by
+// structure, as the golden tests do, list every difference, and
+// optionally write an overlay SVG that highlights them, for reviewing
+// golden updates where a raw XML diff of moved coordinates is
+// unreadable.
+func runSVGDiff(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("regolith svgdiff", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	out := fs.StringP("output", "o", "", "Write an overlay SVG highlighting the differences to this path")
+	tolerance := fs.Float64("tolerance", svgtest.DefaultTolerance, "Largest difference between two numbers that still counts as equal")
+	color := fs.String("color", "auto", "Color output: auto, always, never")
+
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "regolith svgdiff - Compare two SVG diagrams by structure\n\n")
+		_, _ = fmt.Fprintf(stderr, "Usage:\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith svgdiff [flags] <old.svg> <new.svg>\n\n")
+		_, _ = fmt.Fprintf(stderr, "Lists each changed, added, and removed element, ignoring attribute\n")
+		_, _ = fmt.Fprintf(stderr, "order, whitespace, comments, and float noise below --tolerance.\n")
+		_, _ = fmt.Fprintf(stderr, "With -o it also writes an overlay: the new diagram with changed\n")
+		_, _ = fmt.Fprintf(stderr, "elements outlined in amber and added ones in green, over a dashed\n")
+		_, _ = fmt.Fprintf(stderr, "red ghost of what was changed or removed.\n\n")
+		_, _ = fmt.Fprintf(stderr, "Exits 0 when the files match and 1 when they differ, like diff.\n\n")
+		_, _ = fmt.Fprintf(stderr, "Examples:\n")
+		_, _ = fmt.Fprintf(stderr, "  git show HEAD:testdata/golden/x.svg > /tmp/old.svg\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith svgdiff -o review.svg /tmp/old.svg testdata/golden/x.svg\n\n")
+		_, _ = fmt.Fprintf(stderr, "Flags:\n")
+		fs.PrintDefaults()
+	}
+
+	err := parseFlags(fs, args[2:], stdout)
+	if errors.Is(err, flag.ErrHelp) || errors.Is(err, errConfigShown) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		err := fmt.Errorf("svgdiff takes two SVG files, got %d arguments", fs.NArg())
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		fs.Usage()
+		return err
+	}
+
+	profile := output.ResolveColorProfile(*color)
+	co := termenv.NewOutput(stdout, termenv.WithProfile(profile))
+
+	var docs [2][]byte
+	for i, path := range fs.Args() {
+		if docs[i], err = os.ReadFile(path); err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return err
+		}
+	}
+	diff, err := svgtest.Compute(docs[0], docs[1], svgtest.Options{Tolerance: *tolerance})
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+
+	kindColor := map[string]termenv.ANSIColor{"changed": 3, "added": 2, "removed": 1}
+	for _, c := range diff.Changes {
+		line := co.String(fmt.Sprintf("%-7s", c.Kind)).Foreground(kindColor[c.Kind]).String() + " " + c.Path
+		if c.Detail != "" {
+			line += co.String(": " + c.Detail).Faint().String()
+		}
+		_, _ = fmt.Fprintln(stdout, line)
+	}
+	if *out != "" {
+		if err := writeOutputFile(*out, diff.Overlay(), stdout, co); err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return err
+		}
+	}
+	if len(diff.Changes) > 0 {
+		return errSVGsDiffer
+	}
+	return nil
+}
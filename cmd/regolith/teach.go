@@ -0,0 +1,160 @@
+package main
+
+// ================================================================================
+// teach subcommand
+// ================================================================================
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/muesli/termenv"
+	flag "github.com/spf13/pflag"
+
+	"github.com/0x4d5352/regolith/internal/analyzer"
+	"github.com/0x4d5352/regolith/internal/flavor"
+	"github.com/0x4d5352/regolith/internal/output"
+	"github.com/0x4d5352/regolith/internal/renderer"
+	"github.com/0x4d5352/regolith/internal/teach"
+)
+
+// runTeach implements `regolith teach`: render the canonical teaching
+// examples (see package teach) for each chosen flavor into a directory,
+// as an annotated diagram and a Markdown handout apiece, with an
+// index.html gallery over all of them.
+func runTeach(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("regolith teach", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	flavors := fs.StringSliceP("flavor", "f", []string{"all"}, "Flavors to write the examples for (comma-separated), or all")
+	themeName := fs.String("theme", "", "Color theme (e.g. light, dark, catppuccin-mocha, gruvbox-dark)")
+	lang := fs.String("lang", defaultLang(), "Language for the flags panel")
+	severity := fs.String("severity", "warning",
+		"Minimum severity of the findings annotated: info, warning, error, critical")
+	color := fs.String("color", "auto", "Color output: auto, always, never")
+	reproducible := fs.Bool("reproducible", false,
+		"Omit the version and timestamp from the SVG provenance comment (SOURCE_DATE_EPOCH pins the timestamp instead)")
+
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "regolith teach - Write a workshop kit of annotated examples\n\n")
+		_, _ = fmt.Fprintf(stderr, "Usage:\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith teach [flags] <dir>\n\n")
+		_, _ = fmt.Fprintf(stderr, "Renders an email address, URL, IPv4 address, log line, and CSV field\n")
+		_, _ = fmt.Fprintf(stderr, "pattern written for each flavor. Every example gets an annotated\n")
+		_, _ = fmt.Fprintf(stderr, "diagram (<example>-<flavor>.svg) and a Markdown handout with the\n")
+		_, _ = fmt.Fprintf(stderr, "lesson, sample input, and a breakdown (<example>-<flavor>.md), and\n")
+		_, _ = fmt.Fprintf(stderr, "index.html shows them all. The directory is created if needed.\n\n")
+		_, _ = fmt.Fprintf(stderr, "Flags:\n")
+		fs.PrintDefaults()
+	}
+
+	err := parseFlags(fs, args[2:], stdout)
+	if errors.Is(err, flag.ErrHelp) || errors.Is(err, errConfigShown) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	co := termenv.NewOutput(stdout, termenv.WithProfile(output.ResolveColorProfile(*color)))
+
+	if fs.NArg() != 1 {
+		err := errors.New("expected one output directory")
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		fs.Usage()
+		return err
+	}
+	dir := fs.Arg(0)
+
+	names, err := teachFlavors(*flavors)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+	cfg := renderer.DefaultConfig()
+	if err := applyTheme(cfg, *themeName); err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return err
+	}
+
+	var gallery galleryRecorder
+	count := 0
+	for _, name := range names {
+		f, _ := flavor.Get(name)
+		examples, err := teach.For(name)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return err
+		}
+		for _, ex := range examples {
+			stem := filepath.Join(dir, ex.Name+"-"+name)
+			if err := writeLesson(ex, f, stem, cfg, parseSeverity(*severity), *lang, *reproducible); err != nil {
+				_, _ = fmt.Fprintf(stderr, "Error: %s: %v\n", stem, err)
+				return err
+			}
+			notes := []string{ex.Lesson}
+			if ex.Limit != "" {
+				notes = append(notes, "Note: "+ex.Limit)
+			}
+			notes = append(notes, "Matches: "+strings.Join(ex.Matches, "  "))
+			count++
+			gallery.record(count, galleryResult{
+				pattern: ex.Pattern, flavor: name, path: stem + ".svg",
+				title: ex.Title, notes: notes,
+			})
+		}
+	}
+	_, _ = fmt.Fprintln(stdout, co.String(fmt.Sprintf("Wrote %d examples in %d flavors to %s", count, len(names), dir)).
+		Foreground(termenv.ANSIColor(2)).String())
+	return gallery.write(stdout, stderr, co)
+}
+
+// teachFlavors expands the --flavor list of runTeach, where "all" means
+// every registered flavor.
+func teachFlavors(list []string) ([]string, error) {
+	var names []string
+	for _, name := range list {
+		if name == "all" {
+			names = append(names, flavor.List()...)
+			continue
+		}
+		f, ok := flavor.Get(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown flavor %q (available: all, %s)", name, strings.Join(flavor.List(), ", "))
+		}
+		names = append(names, f.Name())
+	}
+	return names, nil
+}
+
+// writeLesson writes ex's diagram, annotated with the findings of at
+// least minSev, to stem.svg and its handout to stem.md.
+func writeLesson(ex teach.Example, f flavor.Flavor, stem string, cfg *renderer.Config,
+	minSev analyzer.Severity, lang string, reproducible bool) error {
+	root, err := f.Parse(ex.Pattern)
+	if err != nil {
+		return fmt.Errorf("parse error: %w", err)
+	}
+	report := analyzer.Analyze(root, ex.Pattern, f.Name(), f.SupportedFeatures())
+	report.Findings = filterBySeverity(report.Findings, minSev)
+	prov, err := svgProvenance(ex.Pattern, f.Name(), reproducible, time.Now())
+	if err != nil {
+		return err
+	}
+	r := renderer.New(cfg)
+	r.Provenance, r.Flavor, r.Language = prov, f, lang
+	if err := os.WriteFile(stem+".svg", []byte(r.RenderAnnotated(root, report)), 0644); err != nil {
+		return err
+	}
+	handout := output.RenderLessonMarkdown(ex, root, report, f.Name(), filepath.Base(stem)+".svg")
+	return os.WriteFile(stem+".md", []byte(handout), 0644)
+}
@@ -0,0 +1,120 @@
+package main
+
+// ================================================================================
+// version subcommand
+// ================================================================================
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+	"text/tabwriter"
+
+	flag "github.com/spf13/pflag"
+)
+
+// buildInfo describes the running binary for `regolith version`.
+type buildInfo struct {
+	Version    string `json:"version"`
+	GoVersion  string `json:"goVersion"`
+	OS         string `json:"os"`
+	Arch       string `json:"arch"`
+	Commit     string `json:"commit,omitempty"`
+	CommitTime string `json:"commitTime,omitempty"`
+	Modified   bool   `json:"modified,omitempty"`
+	Signed     bool   `json:"signed"` // Whether self-update can verify releases
+}
+
+// currentBuild returns the buildInfo of the running binary. The commit
+// fields come from the VCS stamp Go records when building from a
+// checkout, and are empty otherwise.
+func currentBuild() buildInfo {
+	info := buildInfo{
+		Version:   version,
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		Signed:    releaseKey != "",
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				info.Commit = s.Value
+			case "vcs.time":
+				info.CommitTime = s.Value
+			case "vcs.modified":
+				info.Modified = s.Value == "true"
+			}
+		}
+	}
+	return info
+}
+
+// runVersion implements `regolith version`: print the version and the
+// build it came from, for bug reports and for scripts that check what
+// is installed.
+func runVersion(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("regolith version", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	format := fs.String("format", "text", "Output format: text, json")
+	asJSON := fs.Bool("json", false, "Same as --format json")
+
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "regolith version - Show version and build information\n\n")
+		_, _ = fmt.Fprintf(stderr, "Usage:\n")
+		_, _ = fmt.Fprintf(stderr, "  regolith version [flags]\n\n")
+		_, _ = fmt.Fprintf(stderr, "Flags:\n")
+		fs.PrintDefaults()
+	}
+
+	err := parseFlags(fs, args[2:], stdout)
+	if errors.Is(err, flag.ErrHelp) || errors.Is(err, errConfigShown) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		*format = "json"
+	}
+
+	info := currentBuild()
+	switch *format {
+	case "text":
+		tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintf(tw, "regolith version %s\n", info.Version)
+		_, _ = fmt.Fprintf(tw, "go\t%s\n", info.GoVersion)
+		_, _ = fmt.Fprintf(tw, "platform\t%s/%s\n", info.OS, info.Arch)
+		if info.Commit != "" {
+			commit := info.Commit
+			if info.Modified {
+				commit += " (modified)"
+			}
+			_, _ = fmt.Fprintf(tw, "commit\t%s\n", commit)
+		}
+		if info.CommitTime != "" {
+			_, _ = fmt.Fprintf(tw, "commit time\t%s\n", info.CommitTime)
+		}
+		signed := "no (self-update unavailable)"
+		if info.Signed {
+			signed = "yes"
+		}
+		_, _ = fmt.Fprintf(tw, "signed updates\t%s\n", signed)
+		return tw.Flush()
+	case "json":
+		data, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(stdout, "%s\n", data)
+		return err
+	}
+	err = fmt.Errorf("unknown format %q (available: text, json)", *format)
+	_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+	return err
+}
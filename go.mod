@@ -5,13 +5,15 @@ go 1.25.6
 require (
 	github.com/dlclark/regexp2 v1.11.5
 	github.com/muesli/termenv v0.16.0
+	github.com/rivo/uniseg v0.4.7
 	github.com/spf13/pflag v1.0.10
+	golang.org/x/image v0.36.0
+	golang.org/x/sys v0.30.0
 )
 
 require (
 	github.com/aymanbagabas/go-osc52/v2 v2.0.1 // indirect
 	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
-	github.com/rivo/uniseg v0.4.7 // indirect
-	golang.org/x/sys v0.30.0 // indirect
+	golang.org/x/text v0.34.0 // indirect
 )
@@ -1,6 +1,8 @@
 package analyzer
 
 import (
+	"strings"
+
 	"github.com/0x4d5352/regolith/internal/ast"
 	"github.com/0x4d5352/regolith/internal/flavor"
 )
@@ -10,6 +12,10 @@ import (
 // here instead of re-walking the AST for each check.
 type analysis struct {
 	features     flavor.FeatureSet
+	flavorName   string
+	pattern      string // The source the AST's positions index
+	foldCase     bool   // The pattern's flags make it case-insensitive
+	opts         Options
 	findings     []*Finding
 	definedNums  map[int]bool    // All capture group numbers present in the pattern
 	definedNames map[string]bool // All named capture groups present in the pattern
@@ -31,13 +37,30 @@ type backrefSite struct {
 	frag *ast.MatchFragment
 }
 
+// Options turns on rules that need more than the pattern itself.
+type Options struct {
+	// Flavors lists the other flavors the pattern is used with, for
+	// the portability rules (see checkPortability). They stay silent
+	// when it is empty.
+	Flavors []string
+}
+
 // Analyze performs static analysis on a parsed regex AST, walking all
 // nodes and applying rules at the appropriate scope. The flavorName and
 // features parameters allow rules to gate on flavor-specific capabilities
 // (e.g., possessive quantifiers, atomic groups).
 func Analyze(root *ast.Regexp, pattern, flavorName string, features flavor.FeatureSet) *AnalysisReport {
+	return AnalyzeWith(root, pattern, flavorName, features, Options{})
+}
+
+// AnalyzeWith is Analyze with the rules opts enables.
+func AnalyzeWith(root *ast.Regexp, pattern, flavorName string, features flavor.FeatureSet, opts Options) *AnalysisReport {
 	a := &analysis{
 		features:     features,
+		flavorName:   flavorName,
+		pattern:      pattern,
+		foldCase:     strings.Contains(root.Flags, "i"),
+		opts:         opts,
 		definedNums:  map[int]bool{},
 		definedNames: map[string]bool{},
 		usedNums:     map[int]bool{},
@@ -61,14 +84,17 @@ func Analyze(root *ast.Regexp, pattern, flavorName string, features flavor.Featu
 	for _, site := range a.pendingBackrefs {
 		a.flagInvalidBackRef(site.br, site.frag)
 	}
+	a.checkPortability(root, pattern)
 
 	// Per-scope rules: recurse through the AST.
 	a.walkRegexp(root)
 
+	metrics := ComputeMetrics(root)
 	return &AnalysisReport{
 		Pattern:  pattern,
 		Flavor:   flavorName,
 		Findings: a.findings,
+		Metrics:  &metrics,
 	}
 }
 
@@ -118,11 +144,13 @@ func (a *analysis) walkFragment(frag *ast.MatchFragment) {
 	// unquantified fragment (the common case in most patterns) without
 	// changing the order or set of findings produced.
 	if frag.Repeat != nil {
-		checkNestedQuantifier(frag, &a.findings)
+		a.checkNestedQuantifier(frag)
 		checkQuantifiedAssertion(frag, &a.findings)
 		checkRedundantBoundedQuantifier(frag, &a.findings)
 	}
 	checkSingleCharClass(frag.Content, &a.findings)
+	a.checkCharsetRanges(frag.Content)
+	a.checkMultibyte(frag)
 	checkRedundantGroup(frag, &a.findings)
 	a.checkUselessCapture(frag)
 	if frag.Repeat != nil {
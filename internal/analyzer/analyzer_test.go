@@ -1,6 +1,7 @@
 package analyzer
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/0x4d5352/regolith/internal/flavor"
@@ -31,6 +32,12 @@ func TestAnalyze(t *testing.T) {
 			wantIDs:      []string{"nested-quantifier"},
 			wantMinCount: 1,
 		},
+		{
+			name:         "(\\d+\\.?)+ has nested-quantifier",
+			pattern:      "^(\\d+\\.?)+$",
+			wantIDs:      []string{"nested-quantifier"},
+			wantMinCount: 1,
+		},
 		{
 			// Use a fully-anchored literal so that missing-anchor is not raised,
 			// allowing us to assert zero findings for a structurally clean pattern.
@@ -92,6 +99,11 @@ func TestAnalyze(t *testing.T) {
 			if len(report.Findings) < tc.wantMinCount {
 				t.Errorf("got %d findings, want at least %d", len(report.Findings), tc.wantMinCount)
 			}
+			for _, finding := range report.Findings {
+				if RuleDescription(finding.ID) == "" {
+					t.Errorf("rule %q has no RuleDescription", finding.ID)
+				}
+			}
 			for _, wantID := range tc.wantIDs {
 				found := false
 				for _, finding := range report.Findings {
@@ -194,3 +206,32 @@ func TestAnalyzeUUIDWordBoundariesSuppressMissingAnchor(t *testing.T) {
 		t.Errorf("missing-anchor fired %d times, want 0 (\\b should count as an anchor)", got)
 	}
 }
+
+func TestAnalyzeAlternationFindingsNameBranches(t *testing.T) {
+	f, ok := flavor.Get("javascript")
+	if !ok {
+		t.Fatal("javascript flavor not registered")
+	}
+	pattern := `a|b||.*|c|a`
+	parsed, err := f.Parse(pattern)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	report := Analyze(parsed, pattern, "javascript", f.SupportedFeatures())
+	want := map[string][]int{
+		"empty-alternative":        {3},
+		"overlapping-alternatives": {1, 6},
+		"unreachable-alternative":  {5, 6},
+	}
+	for _, finding := range report.Findings {
+		if branches, ok := want[finding.ID]; ok {
+			if !reflect.DeepEqual(finding.Branches, branches) {
+				t.Errorf("%s: got branches %v, want %v", finding.ID, finding.Branches, branches)
+			}
+			delete(want, finding.ID)
+		}
+	}
+	for id := range want {
+		t.Errorf("missing %s finding", id)
+	}
+}
@@ -0,0 +1,268 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+	"github.com/0x4d5352/regolith/internal/flavor"
+)
+
+// ================================================================================
+// Character Class Rules
+// ================================================================================
+
+// checkCharsetRanges lints the members of a bracket expression: ranges
+// written backwards ([z-a]), ranges running from one kind of character
+// to another across the punctuation between them ([A-z]), members that
+// repeat what earlier ones already match, and, in POSIX flavors, letter
+// ranges whose meaning follows the locale's collation order. Each
+// problem is reported once per class, for its first occurrence.
+func (a *analysis) checkCharsetRanges(node ast.Node) {
+	cs, ok := node.(*ast.Charset)
+	if !ok || cs.SetExpression != nil {
+		return
+	}
+	var reversed, crossing, localeRange *ast.CharsetRange
+	var dupItem, dupOf ast.CharsetItem
+	var seen []ast.CharsetItem
+	var covered runeSet
+	for _, item := range cs.Items {
+		if r, ok := item.(*ast.CharsetRange); ok {
+			lo, hi := rangeEnds(r)
+			switch {
+			case lo > hi:
+				if reversed == nil {
+					reversed = r
+				}
+				continue
+			case crossing == nil && rangeCrossesCategories(lo, hi):
+				crossing = r
+			}
+			if localeRange == nil && (isASCIILetter(lo) || isASCIILetter(hi)) {
+				localeRange = r
+			}
+		}
+
+		set, ok := itemSet(item)
+		if !ok {
+			continue
+		}
+		if dupItem == nil && len(covered.intersect(set)) > 0 {
+			dupItem = item
+			for _, prev := range seen {
+				if prevSet, _ := itemSet(prev); len(prevSet.intersect(set)) > 0 {
+					dupOf = prev
+					break
+				}
+			}
+		}
+		seen = append(seen, item)
+		covered = newRuneSet(append(covered, set...)...)
+	}
+
+	if reversed != nil {
+		lo, hi := rangeEnds(reversed)
+		a.findings = append(a.findings, &Finding{
+			ID:       "reversed-range",
+			Category: CategoryCorrectness,
+			Severity: SeverityError,
+			Title:    "Reversed range",
+			Description: fmt.Sprintf("The range %s runs backwards: %q comes after %q. Most engines reject the pattern; the rest match nothing for it.",
+				rangeText(reversed), reversed.First, reversed.Last),
+			Suggestion: fmt.Sprintf("Write it as %s.", rangeFix(hi, lo)),
+			Node:       cs,
+		})
+	}
+	if crossing != nil {
+		lo, hi := rangeEnds(crossing)
+		a.findings = append(a.findings, &Finding{
+			ID:       "cross-category-range",
+			Category: CategoryCorrectness,
+			Severity: SeverityWarning,
+			Title:    "Range spans character categories",
+			Description: fmt.Sprintf("The range %s also matches %s, the punctuation between %s and %s in code point order.",
+				rangeText(crossing), punctuationBetween(lo, hi), categoryName(lo), categoryName(hi)),
+			Suggestion: fmt.Sprintf("Write %s for the letters and digits alone.", rangeFix(lo, hi)),
+			Node:       cs,
+		})
+	}
+	if dupItem != nil {
+		description := fmt.Sprintf("%s repeats characters %s already matches.", itemText(dupItem), itemText(dupOf))
+		if itemText(dupItem) == itemText(dupOf) {
+			description = fmt.Sprintf("%s is listed more than once.", itemText(dupItem))
+		}
+		a.findings = append(a.findings, &Finding{
+			ID:          "duplicate-class-member",
+			Category:    CategoryRedundancy,
+			Severity:    SeverityInfo,
+			Title:       "Overlapping class members",
+			Description: description + " The class matches the same characters without the overlap.",
+			Suggestion:  "Remove the duplicate, or merge the overlapping members into one range.",
+			Node:        cs,
+		})
+	}
+	if localeRange != nil && a.posixFamily() {
+		a.findings = append(a.findings, &Finding{
+			ID:       "locale-range",
+			Category: CategoryPortability,
+			Severity: SeverityWarning,
+			Title:    "Locale-dependent range",
+			Description: fmt.Sprintf("POSIX leaves the range %s to the locale's collation order: outside the C locale it can match accented or other-case letters.",
+				rangeText(localeRange)),
+			Suggestion: localeRangeFix(localeRange),
+			Node:       cs,
+		})
+	}
+}
+
+// posixFamily reports whether the pattern's flavor uses POSIX bracket
+// expressions, whose ranges follow the locale.
+func (a *analysis) posixFamily() bool {
+	f, ok := flavor.Get(a.flavorName)
+	if !ok {
+		return false
+	}
+	fam := flavor.FamilyOf(f)
+	return fam == flavor.FamilyPOSIXExtended || fam == flavor.FamilyPOSIXBasic
+}
+
+// rangeEnds returns the first and last characters of r.
+func rangeEnds(r *ast.CharsetRange) (lo, hi rune) {
+	lo, _ = utf8.DecodeRuneInString(r.First)
+	hi, _ = utf8.DecodeRuneInString(r.Last)
+	return lo, hi
+}
+
+// itemSet returns the characters one class member matches, or false
+// when it has no fixed set, such as a Unicode property.
+func itemSet(item ast.CharsetItem) (runeSet, bool) {
+	return charsetSet(&ast.Charset{Items: []ast.CharsetItem{item}})
+}
+
+// itemText returns a class member as it would be written.
+func itemText(item ast.CharsetItem) string {
+	switch it := item.(type) {
+	case *ast.CharsetLiteral:
+		return it.Text
+	case *ast.CharsetRange:
+		return rangeText(it)
+	case *ast.Escape:
+		if strings.HasPrefix(it.Code, `\`) {
+			return it.Code
+		}
+		return `\` + it.Code
+	case *ast.POSIXClass:
+		if it.Negated {
+			return "[:^" + it.Name + ":]"
+		}
+		return "[:" + it.Name + ":]"
+	}
+	return item.Type()
+}
+
+// rangeText returns a range as it would be written.
+func rangeText(r *ast.CharsetRange) string {
+	return r.First + "-" + r.Last
+}
+
+// charCategory is the kind of ASCII character a range end is: a digit,
+// an uppercase or a lowercase letter, or 0 for anything else.
+func charCategory(c rune) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return 'd'
+	case 'A' <= c && c <= 'Z':
+		return 'u'
+	case 'a' <= c && c <= 'z':
+		return 'l'
+	}
+	return 0
+}
+
+// categoryName names the category of c for a description.
+func categoryName(c rune) string {
+	switch charCategory(c) {
+	case 'd':
+		return "the digits"
+	case 'u':
+		return "the uppercase letters"
+	}
+	return "the lowercase letters"
+}
+
+func isASCIILetter(c rune) bool {
+	cat := charCategory(c)
+	return cat == 'u' || cat == 'l'
+}
+
+// rangeCrossesCategories reports whether lo-hi runs from a digit or a
+// letter to one of another kind, taking in the punctuation between
+// them: 0-z, A-z, 5-F. A range from or to punctuation, such as the
+// printable ASCII range space-~, is taken as deliberate.
+func rangeCrossesCategories(lo, hi rune) bool {
+	clo, chi := charCategory(lo), charCategory(hi)
+	return clo != 0 && chi != 0 && clo != chi
+}
+
+// punctuationBetween lists the characters of lo-hi that are neither
+// digits nor letters, in brackets.
+func punctuationBetween(lo, hi rune) string {
+	var b strings.Builder
+	for c := lo; c <= hi && c < utf8.RuneSelf; c++ {
+		if charCategory(c) == 0 {
+			b.WriteRune(c)
+		}
+	}
+	return "[" + b.String() + "]"
+}
+
+// rangeFix returns what the range from lo to hi, taken in that order,
+// was most likely meant to be. Letters of two cases become the same
+// span of both cases, so a-Z and A-z both give A-Za-z or a-zA-Z;
+// otherwise each category the range touches keeps its own part, so 0-z
+// gives 0-9A-Za-z.
+func rangeFix(lo, hi rune) string {
+	span := func(lo, hi rune) string {
+		if lo == hi {
+			return string(lo)
+		}
+		return string(lo) + "-" + string(hi)
+	}
+	clo, chi := charCategory(lo), charCategory(hi)
+	if isASCIILetter(lo) && isASCIILetter(hi) && clo != chi {
+		l, h := lo|0x20, hi|0x20
+		l, h = min(l, h), max(l, h)
+		upper, lower := span(l-0x20, h-0x20), span(l, h)
+		if clo == 'u' {
+			return upper + lower
+		}
+		return lower + upper
+	}
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	if clo == 0 || chi == 0 || clo == chi {
+		return span(lo, hi)
+	}
+	var parts []string
+	for _, seg := range []runeRange{{'0', '9'}, {'A', 'Z'}, {'a', 'z'}} {
+		if l, h := max(lo, seg.lo), min(hi, seg.hi); l <= h {
+			parts = append(parts, span(l, h))
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// localeRangeFix suggests a locale-independent spelling of r: a POSIX
+// class when r covers one whole, else the C locale.
+func localeRangeFix(r *ast.CharsetRange) string {
+	switch rangeText(r) {
+	case "a-z":
+		return "Use [:lower:], or run the tool with LC_ALL=C."
+	case "A-Z":
+		return "Use [:upper:], or run the tool with LC_ALL=C."
+	}
+	return "Run the tool with LC_ALL=C so ranges follow code point order."
+}
@@ -0,0 +1,77 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/0x4d5352/regolith/internal/flavor"
+	_ "github.com/0x4d5352/regolith/internal/flavor/javascript"
+	_ "github.com/0x4d5352/regolith/internal/flavor/posix_ere"
+)
+
+func TestCharsetRangeRules(t *testing.T) {
+	tests := []struct {
+		flavor, pattern string
+		want            []string // Finding IDs, in order
+		suggestion      string   // in the first finding's suggestion
+	}{
+		{"javascript", `[a-zA-Z0-9_]`, nil, ""},
+		{"javascript", `[ -~]`, nil, ""},
+		{"javascript", `[z-a]`, []string{"reversed-range"}, "a-z"},
+		{"javascript", `[a-Z]`, []string{"reversed-range"}, "A-Za-z"},
+		{"javascript", `[A-z]`, []string{"cross-category-range"}, "A-Za-z"},
+		{"javascript", `[0-z]`, []string{"cross-category-range"}, "0-9A-Za-z"},
+		{"javascript", `[a-fa-z]`, []string{"duplicate-class-member"}, ""},
+		{"javascript", `[\w_]`, []string{"duplicate-class-member"}, ""},
+		{"javascript", `[,,]`, []string{"duplicate-class-member"}, ""},
+		{"posix-ere", `[0-9]`, nil, ""},
+		{"posix-ere", `[a-z]`, []string{"locale-range"}, "[:lower:]"},
+		{"posix-ere", `[[:lower:]]`, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.flavor+" "+tt.pattern, func(t *testing.T) {
+			f, _ := flavor.Get(tt.flavor)
+			root, err := f.Parse(tt.pattern)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			report := Analyze(root, tt.pattern, f.Name(), f.SupportedFeatures())
+			var got []*Finding
+			for _, finding := range report.Findings {
+				switch finding.ID {
+				case "reversed-range", "cross-category-range", "duplicate-class-member", "locale-range":
+					got = append(got, finding)
+				}
+			}
+			var ids []string
+			for _, finding := range got {
+				ids = append(ids, finding.ID)
+			}
+			if strings.Join(ids, " ") != strings.Join(tt.want, " ") {
+				t.Fatalf("got %v, want %v", ids, tt.want)
+			}
+			if tt.suggestion != "" && !strings.Contains(got[0].Suggestion, tt.suggestion) {
+				t.Errorf("suggestion %q does not mention %q", got[0].Suggestion, tt.suggestion)
+			}
+		})
+	}
+}
+
+func TestRangeFix(t *testing.T) {
+	tests := []struct {
+		lo, hi rune
+		want   string
+	}{
+		{'z', 'a', "a-z"},
+		{'a', 'Z', "a-zA-Z"},
+		{'A', 'z', "A-Za-z"},
+		{'0', 'z', "0-9A-Za-z"},
+		{'5', 'F', "5-9A-F"},
+		{'9', '0', "0-9"},
+	}
+	for _, tt := range tests {
+		if got := rangeFix(tt.lo, tt.hi); got != tt.want {
+			t.Errorf("rangeFix(%q, %q) = %q, want %q", tt.lo, tt.hi, got, tt.want)
+		}
+	}
+}
@@ -19,8 +19,12 @@ type Engine interface {
 
 // DetectEngine returns the best available engine for the given flavor.
 // It uses exec.LookPath for fast detection and falls back to regexp2.
-// The second return value is true when using the fallback engine.
+// The second return value is true when using the fallback engine. The
+// golang flavor's engine is Go's regexp, which is always available.
 func DetectEngine(flavorName string) (Engine, bool) {
+	if flavorName == "golang" {
+		return &GoRegexpEngine{}, false
+	}
 	primary := primaryEngineCmd(flavorName)
 	if primary != "" {
 		if _, err := exec.LookPath(primary); err == nil {
@@ -0,0 +1,31 @@
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// GoRegexpEngine runs patterns with Go's own regexp package, the real
+// engine for the golang flavor, in-process. Go's matching is
+// linear-time, so the timeout is never reached in practice; it is
+// still reported as an error for symmetry with the other engines.
+type GoRegexpEngine struct{}
+
+func (e *GoRegexpEngine) Name() string { return "regexp" }
+
+func (e *GoRegexpEngine) Run(pattern, input string, timeout time.Duration) (time.Duration, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return 0, fmt.Errorf("regexp compile: %w", err)
+	}
+
+	start := time.Now()
+	re.MatchString(input)
+	elapsed := time.Since(start)
+
+	if elapsed > timeout {
+		return elapsed, fmt.Errorf("regexp exceeded timeout of %v", timeout)
+	}
+	return elapsed, nil
+}
@@ -35,3 +35,13 @@ func TestDetectEngineFallback(t *testing.T) {
 	}
 	_ = isFallback // either value is fine
 }
+
+func TestDetectEngineGolang(t *testing.T) {
+	eng, isFallback := DetectEngine("golang")
+	if isFallback || eng.Name() != "regexp" {
+		t.Errorf("golang engine = %s (fallback %v), want Go's regexp", eng.Name(), isFallback)
+	}
+	if _, err := eng.Run(`(a+)+$`, strings.Repeat("a", 25)+"!", time.Second); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package analyzer
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+)
+
+// ================================================================================
+// Locale Rules
+// ================================================================================
+
+// checkMultibyte flags, in POSIX flavors, the constructs that match
+// characters in a UTF-8 locale but bytes in the C locale (LC_ALL=C,
+// and grep's fallback for input that is not valid UTF-8): a bracket
+// expression listing a non-ASCII character, which matches any one byte
+// of its encoding there; a quantifier after one, which repeats only its
+// last byte; and a counted dot, such as .{3}, which counts bytes.
+func (a *analysis) checkMultibyte(frag *ast.MatchFragment) {
+	if !a.posixFamily() {
+		return
+	}
+	var node ast.Node
+	var description string
+	switch c := frag.Content.(type) {
+	case *ast.Charset:
+		if ch, ok := nonASCIIMember(c); ok {
+			node = c
+			description = fmt.Sprintf("In the C locale the bracket expression matches any one of the %d bytes that encode %s in UTF-8 rather than the character.",
+				utf8.RuneLen(ch), string(ch))
+		}
+	case *ast.Literal:
+		last, _ := utf8.DecodeLastRuneInString(c.Text)
+		if frag.Repeat != nil && last >= utf8.RuneSelf {
+			node = frag
+			description = fmt.Sprintf("In the C locale the quantifier repeats only the last of the %d bytes that encode %s in UTF-8.",
+				utf8.RuneLen(last), string(last))
+		}
+	case *ast.AnyCharacter:
+		if r := frag.Repeat; r != nil && (r.Min > 1 || r.Max > 1) {
+			node = frag
+			description = "The dot counts characters in a UTF-8 locale but bytes in the C locale, where each accented letter or other non-ASCII character takes two to four."
+		}
+	}
+	if node == nil {
+		return
+	}
+	a.findings = append(a.findings, &Finding{
+		ID:          "locale-multibyte",
+		Category:    CategoryPortability,
+		Severity:    SeverityWarning,
+		Title:       "Byte semantics in the C locale",
+		Description: description,
+		Suggestion:  "Run the tool in a UTF-8 locale for character semantics, or spell out the bytes for LC_ALL=C.",
+		Node:        node,
+	})
+}
+
+// nonASCIIMember returns the first non-ASCII character a bracket
+// expression lists, alone or as a range end.
+func nonASCIIMember(c *ast.Charset) (rune, bool) {
+	for _, item := range c.Items {
+		var texts []string
+		switch it := item.(type) {
+		case *ast.CharsetLiteral:
+			texts = []string{it.Text}
+		case *ast.CharsetRange:
+			texts = []string{it.First, it.Last}
+		}
+		for _, text := range texts {
+			if ch, _ := utf8.DecodeRuneInString(text); ch >= utf8.RuneSelf {
+				return ch, true
+			}
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,41 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/0x4d5352/regolith/internal/flavor"
+	_ "github.com/0x4d5352/regolith/internal/flavor/gnused"
+	_ "github.com/0x4d5352/regolith/internal/flavor/javascript"
+	_ "github.com/0x4d5352/regolith/internal/flavor/posix_ere"
+)
+
+func TestRuleLocaleMultibyte(t *testing.T) {
+	tests := []struct {
+		flavor, pattern string
+		want            bool
+	}{
+		{"posix-ere", `caf[eé]`, true},
+		{"posix-ere", `caf[e]`, false},
+		{"posix-ere", `^.{3}$`, true},
+		{"posix-ere", `^.+$`, false},
+		{"gnused", `xé*`, true},
+		{"gnused", `éx*`, false},
+		{"javascript", `[é]{2}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.flavor+" "+tt.pattern, func(t *testing.T) {
+			f, _ := flavor.Get(tt.flavor)
+			root, err := f.Parse(tt.pattern)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			got := false
+			for _, finding := range Analyze(root, tt.pattern, f.Name(), f.SupportedFeatures()).Findings {
+				got = got || finding.ID == "locale-multibyte"
+			}
+			if got != tt.want {
+				t.Errorf("locale-multibyte reported = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package analyzer
+
+import (
+	"github.com/0x4d5352/regolith/internal/ast"
+)
+
+// ================================================================================
+// Complexity Metrics
+// ================================================================================
+
+// Metrics counts the structure of a pattern, for tracking how hard it is
+// to maintain and for failing a build when it grows past a limit.
+type Metrics struct {
+	Nodes         int // Atoms and groups: every construct but the sequences and alternations joining them
+	MaxDepth      int // Deepest group nesting; 0 when the pattern has no groups
+	Branches      int // Alternatives across every alternation with more than one
+	Quantifiers   int // Quantified items
+	CaptureGroups int // Numbered and named capturing groups
+}
+
+// MetricNames lists the metric names Metric accepts, in report order.
+var MetricNames = []string{"nodes", "depth", "branches", "quantifiers", "groups"}
+
+// Metric returns the metric called name (see MetricNames).
+func (m Metrics) Metric(name string) (int, bool) {
+	switch name {
+	case "nodes":
+		return m.Nodes, true
+	case "depth":
+		return m.MaxDepth, true
+	case "branches":
+		return m.Branches, true
+	case "quantifiers":
+		return m.Quantifiers, true
+	case "groups":
+		return m.CaptureGroups, true
+	}
+	return 0, false
+}
+
+// ComputeMetrics measures root.
+func ComputeMetrics(root *ast.Regexp) Metrics {
+	var m Metrics
+	ast.Walk(root, func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.Regexp:
+			if len(n.Matches) > 1 {
+				m.Branches += len(n.Matches)
+			}
+			return
+		case *ast.Match:
+			return
+		case *ast.MatchFragment:
+			if n.Repeat != nil {
+				m.Quantifiers++
+			}
+			return
+		case *ast.Subexp:
+			if n.GroupType == ast.GroupCapture || n.GroupType == ast.GroupNamedCapture {
+				m.CaptureGroups++
+			}
+		}
+		m.Nodes++
+	})
+	m.MaxDepth = groupDepth(root)
+	return m
+}
+
+// groupDepth returns how deeply groups nest beneath n.
+func groupDepth(n ast.Node) int {
+	deepest := 0
+	switch n := n.(type) {
+	case nil:
+	case *ast.Regexp:
+		if n == nil {
+			return 0
+		}
+		for _, m := range n.Matches {
+			deepest = max(deepest, groupDepth(m))
+		}
+	case *ast.Match:
+		for _, frag := range n.Fragments {
+			deepest = max(deepest, groupDepth(frag.Content))
+		}
+	case *ast.Subexp:
+		return 1 + groupDepth(n.Regexp)
+	case *ast.AtomicGroup:
+		return 1 + groupDepth(n.Regexp)
+	case *ast.BalancedGroup:
+		return 1 + groupDepth(n.Regexp)
+	case *ast.BranchReset:
+		return 1 + groupDepth(n.Regexp)
+	case *ast.InlineModifier:
+		if n.Regexp != nil {
+			return 1 + groupDepth(n.Regexp)
+		}
+	case *ast.Conditional:
+		return 1 + max(groupDepth(n.TrueMatch), groupDepth(n.FalseMatch))
+	}
+	return deepest
+}
@@ -0,0 +1,47 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/0x4d5352/regolith/internal/flavor"
+	_ "github.com/0x4d5352/regolith/internal/flavor/javascript"
+	_ "github.com/0x4d5352/regolith/internal/flavor/pcre"
+)
+
+func TestComputeMetrics(t *testing.T) {
+	tests := []struct {
+		flavor, pattern string
+		want            Metrics
+	}{
+		{"javascript", ``, Metrics{}},
+		{"javascript", `abc`, Metrics{Nodes: 1}},
+		{"javascript", `a|b|c`, Metrics{Nodes: 3, Branches: 3}},
+		{"javascript", `^(?:(a|b)+c(?<x>d{2}))?$`, Metrics{Nodes: 9, MaxDepth: 2, Branches: 2, Quantifiers: 3, CaptureGroups: 2}},
+		{"javascript", `[a-z]+(?=\d)`, Metrics{Nodes: 3, MaxDepth: 1, Quantifiers: 1}},
+		{"pcre", `(a)(?(1)(?>b)|c)`, Metrics{Nodes: 7, MaxDepth: 2, CaptureGroups: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			f, _ := flavor.Get(tt.flavor)
+			root, err := f.Parse(tt.pattern)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if got := ComputeMetrics(root); got != tt.want {
+				t.Errorf("ComputeMetrics = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetricNames(t *testing.T) {
+	m := Metrics{Nodes: 1, MaxDepth: 2, Branches: 3, Quantifiers: 4, CaptureGroups: 5}
+	for i, name := range MetricNames {
+		if v, ok := m.Metric(name); !ok || v != i+1 {
+			t.Errorf("Metric(%q) = %d, %v; want %d", name, v, ok, i+1)
+		}
+	}
+	if _, ok := m.Metric("size"); ok {
+		t.Error(`Metric("size") should not exist`)
+	}
+}
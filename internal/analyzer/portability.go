@@ -0,0 +1,230 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+)
+
+// ================================================================================
+// Portability Rules
+// ================================================================================
+
+// The portability rules flag constructs that every flavor accepts but
+// that do not mean the same thing in all of them, so a pattern shared
+// between, say, a JavaScript front end and a Java back end accepts
+// different input on each side without any error. They only run when
+// Options.Flavors names the flavors in play; the pattern's own flavor
+// is always one of them. Each table maps a flavor to what the construct
+// does there, and a rule fires when the flavors in play fall into more
+// than one group.
+
+// dollarBehavior is what $ matches outside multiline mode.
+var dollarBehavior = map[string]string{
+	"pcre":   "also matches before a final newline",
+	"java":   "also matches before a final line terminator",
+	"dotnet": "also matches before a final newline",
+	// JavaScript, Go, and the POSIX family match only at the very end;
+	// grep, sed, and Vim never see the newline that ends a line.
+}
+
+// dotBehavior is what . leaves out when dot-all is off.
+var dotBehavior = map[string]string{
+	"javascript": `excludes \n, \r, \u2028 and \u2029`,
+	"java":       `excludes \n, \r, \u0085, \u2028 and \u2029`,
+	"pcre":       `excludes \n`,
+	"dotnet":     `excludes \n`,
+	"golang":     `excludes \n`,
+	"vim":        `excludes \n (\_. includes it)`,
+	// The POSIX family matches every character, newline included.
+}
+
+// dotAllFlavors can turn on dot-all with an s flag, after which . also
+// matches every line terminator.
+var dotAllFlavors = map[string]bool{
+	"javascript": true, "java": true, "pcre": true, "dotnet": true, "golang": true,
+}
+
+// classBackspace is what \b means inside a bracket expression.
+var classBackspace = map[string]string{
+	"javascript": "a backspace",
+	"pcre":       "a backspace",
+	"dotnet":     "a backspace",
+	"vim":        "a backspace",
+	"java":       "a syntax error",
+	"golang":     "a syntax error",
+	// The POSIX family has no escapes in brackets: \ and b.
+}
+
+// checkPortability runs the portability rules over the whole pattern.
+// Each reports its first occurrence only, since the advice is the same
+// for every one.
+func (a *analysis) checkPortability(root *ast.Regexp, pattern string) {
+	if len(a.opts.Flavors) == 0 {
+		return
+	}
+	inPlay := []string{a.flavorName}
+	for _, name := range a.opts.Flavors {
+		if name != a.flavorName {
+			inPlay = append(inPlay, name)
+		}
+	}
+	multiline, dotAll := modeFlags(root)
+
+	var dollar, dot, classB *ast.MatchFragment
+	ast.Walk(root, func(n ast.Node) {
+		f, ok := n.(*ast.MatchFragment)
+		if !ok {
+			return
+		}
+		switch c := f.Content.(type) {
+		case *ast.Anchor:
+			if c.AnchorType == ast.AnchorEnd && dollar == nil {
+				dollar = f
+			}
+		case *ast.AnyCharacter:
+			if dot == nil {
+				dot = f
+			}
+		case *ast.Charset:
+			if classB == nil && charsetHasBackspace(c, pattern) {
+				classB = f
+			}
+		}
+	})
+
+	if dollar != nil && !multiline {
+		groups := groupFlavors(inPlay, func(name string) string {
+			if b, ok := dollarBehavior[name]; ok {
+				return b
+			}
+			return "matches only at the very end"
+		})
+		if len(groups) > 1 {
+			a.findings = append(a.findings, &Finding{
+				ID:          "portability-dollar",
+				Category:    CategoryPortability,
+				Severity:    SeverityWarning,
+				Title:       "$ differs across flavors",
+				Description: "$ " + describeGroups(groups) + ". Input ending in a newline matches in some of them only.",
+				Suggestion:  `Strip the trailing newline before matching, or use \z where every flavor in play has it.`,
+				Node:        dollar,
+			})
+		}
+	}
+
+	if dot != nil {
+		groups := groupFlavors(inPlay, func(name string) string {
+			if dotAll && dotAllFlavors[name] {
+				return "matches every character"
+			}
+			if b, ok := dotBehavior[name]; ok {
+				return b
+			}
+			return "matches every character"
+		})
+		if len(groups) > 1 {
+			a.findings = append(a.findings, &Finding{
+				ID:          "portability-dot",
+				Category:    CategoryPortability,
+				Severity:    SeverityWarning,
+				Title:       ". differs across flavors",
+				Description: ". " + describeGroups(groups) + ".",
+				Suggestion:  `Say which characters are meant, e.g. [^\n] or [\s\S], instead of relying on each flavor's dot.`,
+				Node:        dot,
+			})
+		}
+	}
+
+	if classB != nil {
+		groups := groupFlavors(inPlay, func(name string) string {
+			if b, ok := classBackspace[name]; ok {
+				return b
+			}
+			return `a \ or a b`
+		})
+		if len(groups) > 1 {
+			a.findings = append(a.findings, &Finding{
+				ID:          "portability-class-backspace",
+				Category:    CategoryPortability,
+				Severity:    SeverityWarning,
+				Title:       `[\b] differs across flavors`,
+				Description: `\b inside a character class is ` + describeGroups(groups) + ".",
+				Suggestion:  `Write a backspace as \x08, or a literal \ and b as [\\b].`,
+				Node:        classB,
+			})
+		}
+	}
+}
+
+// modeFlags reports whether multiline or dot-all mode is turned on
+// anywhere in the pattern, by its flags or an inline modifier.
+func modeFlags(root *ast.Regexp) (multiline, dotAll bool) {
+	multiline = strings.Contains(root.Flags, "m")
+	dotAll = strings.Contains(root.Flags, "s")
+	ast.Walk(root, func(n ast.Node) {
+		if im, ok := n.(*ast.InlineModifier); ok {
+			multiline = multiline || strings.Contains(im.Enable, "m")
+			dotAll = dotAll || strings.Contains(im.Enable, "s")
+		}
+	})
+	return multiline, dotAll
+}
+
+// charsetHasBackspace reports whether c contains \b. Perl-style parsers
+// keep it as an escape; POSIX-style ones read it as two characters or
+// drop the backslash, so for those the class's source is checked.
+func charsetHasBackspace(c *ast.Charset, pattern string) bool {
+	for _, item := range c.Items {
+		if e, ok := item.(*ast.Escape); ok && e.Code == "b" {
+			return true
+		}
+	}
+	if c.End <= c.Start || c.End > len(pattern) {
+		return false
+	}
+	src := pattern[c.Start:c.End]
+	for i := 0; i+1 < len(src); i++ {
+		if src[i] == '\\' {
+			if src[i+1] == 'b' {
+				return true
+			}
+			i++
+		}
+	}
+	return false
+}
+
+// flavorGroup is the flavors in play that share one behavior.
+type flavorGroup struct {
+	behavior string
+	flavors  []string
+}
+
+// groupFlavors groups names by behavior, in order of each behavior's
+// first flavor.
+func groupFlavors(names []string, behavior func(string) string) []flavorGroup {
+	var groups []flavorGroup
+	index := make(map[string]int)
+	for _, name := range names {
+		b := behavior(name)
+		i, ok := index[b]
+		if !ok {
+			i = len(groups)
+			index[b] = i
+			groups = append(groups, flavorGroup{behavior: b})
+		}
+		groups[i].flavors = append(groups[i].flavors, name)
+	}
+	return groups
+}
+
+// describeGroups writes groups as "X in a, b; Y in c".
+func describeGroups(groups []flavorGroup) string {
+	parts := make([]string, len(groups))
+	for i, g := range groups {
+		parts[i] = fmt.Sprintf("%s in %s", g.behavior, strings.Join(g.flavors, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
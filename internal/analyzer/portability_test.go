@@ -0,0 +1,70 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/0x4d5352/regolith/internal/flavor"
+	_ "github.com/0x4d5352/regolith/internal/flavor/javascript"
+	_ "github.com/0x4d5352/regolith/internal/flavor/posix_ere"
+)
+
+func TestPortabilityRules(t *testing.T) {
+	tests := []struct {
+		flavor, pattern string
+		inPlay          []string
+		want            []string // Finding IDs, in order
+	}{
+		{"javascript", `^a.c$`, nil, nil},
+		{"javascript", `^a.c$`, []string{"javascript"}, nil},
+		{"javascript", `^abc$`, []string{"golang", "posix-ere"}, nil},
+		{"javascript", `^abc$`, []string{"pcre"}, []string{"portability-dollar"}},
+		{"javascript", `/^abc$/m`, []string{"pcre"}, nil},
+		{"javascript", `a.c`, []string{"pcre"}, []string{"portability-dot"}},
+		{"javascript", `/a.c/s`, []string{"pcre", "golang"}, nil},
+		{"javascript", `/a.c/s`, []string{"posix-ere", "vim"}, []string{"portability-dot"}},
+		{"javascript", `[\b]`, []string{"dotnet", "vim"}, nil},
+		{"javascript", `[\b]`, []string{"java"}, []string{"portability-class-backspace"}},
+		{"posix-ere", `[\b]x$`, []string{"gnugrep"}, nil},
+		{"posix-ere", `[\b]x`, []string{"pcre"}, []string{"portability-class-backspace"}},
+		{"javascript", `(?:x|(a.b$))+`, []string{"dotnet"}, []string{"portability-dollar", "portability-dot"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.flavor+" "+tt.pattern+" "+strings.Join(tt.inPlay, ","), func(t *testing.T) {
+			f, _ := flavor.Get(tt.flavor)
+			root, err := f.Parse(tt.pattern)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			report := AnalyzeWith(root, tt.pattern, f.Name(), f.SupportedFeatures(), Options{Flavors: tt.inPlay})
+			var got []string
+			for _, finding := range report.Findings {
+				if finding.Category == CategoryPortability {
+					got = append(got, finding.ID)
+				}
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPortabilityDescribesEachFlavor(t *testing.T) {
+	f, _ := flavor.Get("javascript")
+	root, err := f.Parse(`a$`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := AnalyzeWith(root, `a$`, "javascript", f.SupportedFeatures(), Options{Flavors: []string{"java", "golang", "pcre"}})
+	var desc string
+	for _, finding := range report.Findings {
+		if finding.ID == "portability-dollar" {
+			desc = finding.Description
+		}
+	}
+	want := "$ matches only at the very end in javascript, golang; also matches before a final line terminator in java; also matches before a final newline in pcre."
+	if !strings.HasPrefix(desc, want) {
+		t.Errorf("description = %q, want prefix %q", desc, want)
+	}
+}
@@ -0,0 +1,619 @@
+package analyzer
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+)
+
+// ================================================================================
+// Nested Quantifier Ambiguity
+// ================================================================================
+
+// A quantified group (R)+ backtracks exponentially when some string w
+// can be read as one iteration of R or as two: on an input made of w
+// repeated n times that then fails to match, the engine tries every way
+// of grouping the copies into iterations, 2^n of them. That is the ReDoS
+// precondition nested quantifiers set up, and it is not met by every
+// nesting: in (a+b)+ each iteration ends at a b, so a string of them
+// splits into iterations only one way.
+//
+// checkNestedQuantifier decides it by building a small NFA for R and
+// one for RR and searching their product for the shortest non-empty
+// string both accept, the witness. The NFAs over-approximate where the
+// pattern leaves regular languages (lookarounds and anchors read as
+// empty, long counted repeats as unbounded), so a witness may be
+// spurious but a missing one is not; constructs with no regular
+// reading, such as back-references, fall back to flagging the nesting.
+
+// Limits on the ambiguity search. Past them the pattern is flagged as
+// the nesting alone would be.
+const (
+	maxNFAStates      = 400    // States in the NFA of one loop body
+	maxProductStates  = 200000 // Pairs visited while searching for a witness
+	maxExpandedRepeat = 16     // Copies a counted repeat is unrolled to; larger counts read as unbounded
+)
+
+// checkNestedQuantifier flags a fragment with an unbounded quantifier
+// over a group that contains another unbounded quantifier, when one
+// iteration of the group can match what two do (see above). Bounded
+// nestings such as (?:[A-Fa-f\d]{2}){5} have a finite repetition count
+// and are never flagged; neither is a possessive outer quantifier or an
+// atomic group, which the engine does not backtrack into.
+func (a *analysis) checkNestedQuantifier(frag *ast.MatchFragment) {
+	if frag.Repeat == nil || frag.Repeat.Max != -1 || frag.Repeat.Possessive {
+		return
+	}
+	subexp, ok := frag.Content.(*ast.Subexp)
+	if !ok || subexp.GroupType == ast.GroupAtomic || !containsUnboundedQuantifier(subexp.Regexp) {
+		return
+	}
+
+	finding := &Finding{
+		ID:       "nested-quantifier",
+		Category: CategoryBacktracking,
+		Severity: SeverityError,
+		Title:    "Nested quantifiers",
+		Description: "An unbounded quantifier applied to a group that itself contains an unbounded quantifier " +
+			"creates exponential backtracking paths.",
+		Suggestion: "Flatten the expression, use an atomic group (?>...), or use a possessive quantifier if the flavor supports it.",
+		Node:       frag,
+	}
+	witness, decided := ambiguousLoop(subexp.Regexp, a.foldCase)
+	if decided {
+		if witness == "" {
+			return
+		}
+		inner := innerLoop(subexp.Regexp, witness, a.foldCase)
+		finding.Description = fmt.Sprintf("The inner quantifier %s and the outer %s overlap: %q matches one iteration of the group or two. "+
+			"Input starting with it repeated, such as %q, backtracks exponentially when the rest of the match fails.",
+			a.quote(inner, "in the group"), a.quote(frag, "quantifier"), witness, strings.Repeat(witness, 8))
+	}
+	a.findings = append(a.findings, finding)
+}
+
+// quote returns the pattern text node was parsed from, in backquotes,
+// or fallback when the node has no position in the pattern.
+func (a *analysis) quote(node ast.Node, fallback string) string {
+	if node == nil {
+		return fallback
+	}
+	pos := node.Pos()
+	if pos.End <= pos.Start || pos.End > len(a.pattern) {
+		return fallback
+	}
+	return "`" + a.pattern[pos.Start:pos.End] + "`"
+}
+
+// ambiguousLoop returns the shortest non-empty string that body
+// matches both once and twice in a row, or "" when there is none.
+// decided is false when body uses a construct the search cannot model
+// or the search exceeds its limits.
+func ambiguousLoop(body *ast.Regexp, fold bool) (witness string, decided bool) {
+	once := &nfaBuilder{fold: fold}
+	once.start, once.accept = once.regexp(body)
+	twice := &nfaBuilder{fold: fold}
+	s1, e1 := twice.regexp(body)
+	s2, e2 := twice.regexp(body)
+	twice.epsilon(e1, s2)
+	twice.start, twice.accept = s1, e2
+	if once.failed || twice.failed {
+		return "", false
+	}
+	return sharedString(&once.nfa, &twice.nfa)
+}
+
+// innerLoop returns the unbounded quantifier in body that the witness
+// most likely runs through: the first one able to start with its first
+// character, else the first one.
+func innerLoop(body *ast.Regexp, witness string, fold bool) *ast.MatchFragment {
+	var loops []*ast.MatchFragment
+	walkFragmentsAny(body, func(f *ast.MatchFragment) bool {
+		if f.Repeat != nil && f.Repeat.Max == -1 {
+			loops = append(loops, f)
+		}
+		return false
+	})
+	first, _ := utf8.DecodeRuneInString(witness)
+	for _, f := range loops {
+		b := &nfaBuilder{fold: fold}
+		start, _ := b.atom(f.Content)
+		if b.failed {
+			continue
+		}
+		for _, s := range b.closure(start) {
+			if b.states[s].set.contains(first) {
+				return f
+			}
+		}
+	}
+	if len(loops) == 0 {
+		return nil
+	}
+	return loops[0]
+}
+
+// ================================================================================
+// Character Sets
+// ================================================================================
+
+// runeRange is an inclusive range of code points.
+type runeRange struct{ lo, hi rune }
+
+// runeSet is a set of code points as sorted, disjoint, non-adjacent
+// ranges.
+type runeSet []runeRange
+
+// newRuneSet returns the set covering ranges, which may overlap.
+func newRuneSet(ranges ...runeRange) runeSet {
+	s := slices.Clone(ranges)
+	slices.SortFunc(s, func(x, y runeRange) int { return int(x.lo - y.lo) })
+	var out runeSet
+	for _, r := range s {
+		if n := len(out); n > 0 && r.lo <= out[n-1].hi+1 {
+			out[n-1].hi = max(out[n-1].hi, r.hi)
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// negate returns every code point not in s.
+func (s runeSet) negate() runeSet {
+	var out runeSet
+	next := rune(0)
+	for _, r := range s {
+		if r.lo > next {
+			out = append(out, runeRange{next, r.lo - 1})
+		}
+		next = r.hi + 1
+	}
+	if next <= unicode.MaxRune {
+		out = append(out, runeRange{next, unicode.MaxRune})
+	}
+	return out
+}
+
+// intersect returns the code points in both s and t.
+func (s runeSet) intersect(t runeSet) runeSet {
+	var out runeSet
+	for i, j := 0, 0; i < len(s) && j < len(t); {
+		lo, hi := max(s[i].lo, t[j].lo), min(s[i].hi, t[j].hi)
+		if lo <= hi {
+			out = append(out, runeRange{lo, hi})
+		}
+		if s[i].hi < t[j].hi {
+			i++
+		} else {
+			j++
+		}
+	}
+	return out
+}
+
+// contains reports whether c is in s.
+func (s runeSet) contains(c rune) bool {
+	for _, r := range s {
+		if r.lo <= c && c <= r.hi {
+			return true
+		}
+	}
+	return false
+}
+
+// pick returns a member of s to spell a witness with, preferring a
+// lowercase letter, then a digit, then any printable ASCII character,
+// so the witness reads as text.
+func (s runeSet) pick() rune {
+	for _, want := range []runeRange{{'a', 'z'}, {'0', '9'}, {'A', 'Z'}, {'!', '~'}, {' ', ' '}} {
+		if in := s.intersect(runeSet{want}); len(in) > 0 {
+			return in[0].lo
+		}
+	}
+	return s[0].lo
+}
+
+// Sets for the shorthand escapes and POSIX classes, ASCII only as most
+// flavors read them by default.
+var (
+	digitSet = runeSet{{'0', '9'}}
+	wordSet  = newRuneSet(runeRange{'0', '9'}, runeRange{'A', 'Z'}, runeRange{'_', '_'}, runeRange{'a', 'z'})
+	spaceSet = newRuneSet(runeRange{'\t', '\r'}, runeRange{' ', ' '})
+	lowerSet = runeSet{{'a', 'z'}}
+	upperSet = runeSet{{'A', 'Z'}}
+	alphaSet = newRuneSet(runeRange{'A', 'Z'}, runeRange{'a', 'z'})
+)
+
+// escapeSets gives the characters each shorthand escape matches, by
+// EscapeType.
+var escapeSets = map[string]runeSet{
+	"digit":          digitSet,
+	"non_digit":      digitSet.negate(),
+	"word":           wordSet,
+	"non_word":       wordSet.negate(),
+	"whitespace":     spaceSet,
+	"non_whitespace": spaceSet.negate(),
+	"non_newline":    runeSet{{'\n', '\n'}}.negate(),
+}
+
+// posixSets gives the characters each POSIX class matches, by name.
+var posixSets = map[string]runeSet{
+	ast.POSIXAlnum:  newRuneSet(runeRange{'0', '9'}, runeRange{'A', 'Z'}, runeRange{'a', 'z'}),
+	ast.POSIXAlpha:  alphaSet,
+	ast.POSIXBlank:  newRuneSet(runeRange{'\t', '\t'}, runeRange{' ', ' '}),
+	ast.POSIXCntrl:  newRuneSet(runeRange{0, 0x1F}, runeRange{0x7F, 0x7F}),
+	ast.POSIXDigit:  digitSet,
+	ast.POSIXGraph:  runeSet{{'!', '~'}},
+	ast.POSIXLower:  lowerSet,
+	ast.POSIXPrint:  runeSet{{' ', '~'}},
+	ast.POSIXPunct:  newRuneSet(runeRange{'!', '/'}, runeRange{':', '@'}, runeRange{'[', '`'}, runeRange{'{', '~'}),
+	ast.POSIXSpace:  spaceSet,
+	ast.POSIXUpper:  upperSet,
+	ast.POSIXXdigit: newRuneSet(runeRange{'0', '9'}, runeRange{'A', 'F'}, runeRange{'a', 'f'}),
+}
+
+// controlEscapes gives the character each control escape matches.
+var controlEscapes = map[string]rune{
+	"newline":         '\n',
+	"carriage_return": '\r',
+	"tab":             '\t',
+	"form_feed":       '\f',
+	"vertical_tab":    '\v',
+	"null":            0,
+}
+
+// ================================================================================
+// NFA Construction
+// ================================================================================
+
+// nfaState is one state of an NFA: it moves on any character of set to
+// next, and without reading to each state in eps.
+type nfaState struct {
+	set  runeSet
+	next int
+	eps  []int
+}
+
+// nfa is a Thompson NFA with one start and one accepting state.
+type nfa struct {
+	states        []nfaState
+	start, accept int
+	closures      map[int][]int
+}
+
+// nfaBuilder builds an NFA from a pattern. Every construct becomes a
+// piece with one entry and one exit state, joined by empty moves.
+// failed is set when the pattern uses a construct with no regular
+// reading or the NFA grows past maxNFAStates.
+type nfaBuilder struct {
+	nfa
+	fold   bool // Case-insensitive matching
+	failed bool
+}
+
+// state adds a state with no moves.
+func (b *nfaBuilder) state() int {
+	if len(b.states) >= maxNFAStates {
+		b.failed = true
+	}
+	b.states = append(b.states, nfaState{next: -1})
+	return len(b.states) - 1
+}
+
+// epsilon adds an empty move from one state to another.
+func (b *nfaBuilder) epsilon(from, to int) {
+	b.states[from].eps = append(b.states[from].eps, to)
+}
+
+// chars returns a piece that reads one character of set.
+func (b *nfaBuilder) chars(set runeSet) (start, end int) {
+	start, end = b.state(), b.state()
+	if b.fold {
+		set = foldSet(set)
+	}
+	b.states[start].set, b.states[start].next = set, end
+	return start, end
+}
+
+// empty returns a piece that reads nothing.
+func (b *nfaBuilder) empty() (start, end int) {
+	start = b.state()
+	return start, start
+}
+
+// regexp returns a piece for an alternation.
+func (b *nfaBuilder) regexp(r *ast.Regexp) (start, end int) {
+	start, end = b.state(), b.state()
+	if r == nil {
+		b.epsilon(start, end)
+		return start, end
+	}
+	for _, m := range r.Matches {
+		ms, me := b.match(m)
+		b.epsilon(start, ms)
+		b.epsilon(me, end)
+	}
+	return start, end
+}
+
+// match returns a piece for a sequence.
+func (b *nfaBuilder) match(m *ast.Match) (start, end int) {
+	start, end = b.empty()
+	for _, f := range m.Fragments {
+		if b.failed {
+			break
+		}
+		fs, fe := b.fragment(f)
+		b.epsilon(end, fs)
+		end = fe
+	}
+	return start, end
+}
+
+// fragment returns a piece for an atom and its quantifier. A counted
+// repeat is unrolled, at most maxExpandedRepeat copies deep; a larger
+// minimum is lowered to that and a larger maximum read as unbounded,
+// which only lets the piece match more.
+func (b *nfaBuilder) fragment(f *ast.MatchFragment) (start, end int) {
+	if f.Repeat == nil {
+		return b.atom(f.Content)
+	}
+	lo, hi := min(f.Repeat.Min, maxExpandedRepeat), f.Repeat.Max
+	if hi > maxExpandedRepeat {
+		hi = -1
+	}
+	start, end = b.empty()
+	for range lo {
+		as, ae := b.atom(f.Content)
+		b.epsilon(end, as)
+		end = ae
+	}
+	if hi == -1 {
+		loop := b.state()
+		b.epsilon(end, loop)
+		as, ae := b.atom(f.Content)
+		b.epsilon(loop, as)
+		b.epsilon(ae, loop)
+		return start, loop
+	}
+	exit := b.state()
+	for i := lo; i < hi && !b.failed; i++ {
+		as, ae := b.atom(f.Content)
+		b.epsilon(end, as)
+		b.epsilon(end, exit)
+		end = ae
+	}
+	b.epsilon(end, exit)
+	return start, exit
+}
+
+// atom returns a piece for one node. Zero-width nodes read nothing.
+func (b *nfaBuilder) atom(node ast.Node) (start, end int) {
+	switch n := node.(type) {
+	case *ast.Literal:
+		return b.text(n.Text)
+	case *ast.QuotedLiteral:
+		return b.text(n.Text)
+	case *ast.AnyCharacter:
+		return b.chars(runeSet{{'\n', '\n'}}.negate())
+	case *ast.Escape:
+		if set, ok := escapeSet(n); ok {
+			return b.chars(set)
+		}
+	case *ast.Charset:
+		if set, ok := charsetSet(n); ok {
+			return b.chars(set)
+		}
+	case *ast.Subexp:
+		switch n.GroupType {
+		case ast.GroupPositiveLookahead, ast.GroupNegativeLookahead,
+			ast.GroupPositiveLookbehind, ast.GroupNegativeLookbehind:
+			return b.empty()
+		}
+		return b.regexp(n.Regexp)
+	case *ast.InlineModifier:
+		if n.Regexp == nil {
+			return b.empty()
+		}
+		return b.regexp(n.Regexp)
+	case *ast.BranchReset:
+		return b.regexp(n.Regexp)
+	case *ast.Anchor, *ast.Comment, *ast.BacktrackControl, *ast.Callout:
+		return b.empty()
+	}
+	b.failed = true
+	return b.empty()
+}
+
+// text returns a piece that reads s.
+func (b *nfaBuilder) text(s string) (start, end int) {
+	start, end = b.empty()
+	for _, c := range s {
+		cs, ce := b.chars(runeSet{{c, c}})
+		b.epsilon(end, cs)
+		end = ce
+	}
+	return start, end
+}
+
+// escapeSet returns the characters an escape matches, or false for one
+// that is not a single character class, such as a back-reference.
+func escapeSet(e *ast.Escape) (runeSet, bool) {
+	if set, ok := escapeSets[e.EscapeType]; ok {
+		return set, true
+	}
+	if c, ok := controlEscapes[e.EscapeType]; ok {
+		return runeSet{{c, c}}, true
+	}
+	switch e.EscapeType {
+	case "literal":
+		c, size := utf8.DecodeRuneInString(strings.TrimPrefix(e.Code, `\`))
+		if c == utf8.RuneError || size == 0 {
+			return nil, false
+		}
+		return runeSet{{c, c}}, true
+	case "hex", "unicode", "unicode_braced":
+		code := strings.Trim(strings.TrimLeft(strings.TrimPrefix(e.Code, `\`), "xuU"), "{}")
+		n, err := strconv.ParseUint(code, 16, 32)
+		if err != nil || n > unicode.MaxRune {
+			return nil, false
+		}
+		return runeSet{{rune(n), rune(n)}}, true
+	}
+	return nil, false
+}
+
+// charsetSet returns the characters a bracket expression matches, or
+// false when it uses a set operation or an item with no fixed set.
+func charsetSet(c *ast.Charset) (runeSet, bool) {
+	if c.SetExpression != nil {
+		return nil, false
+	}
+	var ranges []runeRange
+	for _, item := range c.Items {
+		switch it := item.(type) {
+		case *ast.CharsetLiteral:
+			for _, r := range it.Text {
+				ranges = append(ranges, runeRange{r, r})
+			}
+		case *ast.CharsetRange:
+			lo, _ := utf8.DecodeRuneInString(it.First)
+			hi, _ := utf8.DecodeRuneInString(it.Last)
+			if lo > hi {
+				return nil, false
+			}
+			ranges = append(ranges, runeRange{lo, hi})
+		case *ast.Escape:
+			set, ok := escapeSet(it)
+			if !ok {
+				return nil, false
+			}
+			ranges = append(ranges, set...)
+		case *ast.POSIXClass:
+			set, ok := posixSets[it.Name]
+			if !ok {
+				return nil, false
+			}
+			if it.Negated {
+				set = set.negate()
+			}
+			ranges = append(ranges, set...)
+		default:
+			return nil, false
+		}
+	}
+	set := newRuneSet(ranges...)
+	if c.Inverted {
+		set = set.negate()
+	}
+	return set, true
+}
+
+// foldSet adds the other cases of the letters in s, for ASCII letters,
+// which is where case-insensitive overlaps usually come from.
+func foldSet(s runeSet) runeSet {
+	out := slices.Clone(s)
+	for _, r := range []runeRange{{'a', 'z'}, {'A', 'Z'}} {
+		for _, in := range s.intersect(runeSet{r}) {
+			shift := rune('A' - 'a')
+			if r.lo == 'A' {
+				shift = -shift
+			}
+			out = append(out, runeRange{in.lo + shift, in.hi + shift})
+		}
+	}
+	return newRuneSet(out...)
+}
+
+// ================================================================================
+// Witness Search
+// ================================================================================
+
+// closure returns the states s reaches by empty moves, s included.
+func (n *nfa) closure(s int) []int {
+	if c, ok := n.closures[s]; ok {
+		return c
+	}
+	seen := map[int]bool{s: true}
+	stack := []int{s}
+	var out []int
+	for len(stack) > 0 {
+		cur := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		out = append(out, cur)
+		for _, next := range n.states[cur].eps {
+			if !seen[next] {
+				seen[next] = true
+				stack = append(stack, next)
+			}
+		}
+	}
+	if n.closures == nil {
+		n.closures = map[int][]int{}
+	}
+	n.closures[s] = out
+	return out
+}
+
+// pairState is a state of the product of two NFAs, with whether any
+// character has been read to reach it.
+type pairState struct {
+	a, b int
+	read bool
+}
+
+// sharedString searches the product of x and y breadth first for the
+// shortest non-empty string both accept. decided is false when the
+// search exceeds maxProductStates.
+func sharedString(x, y *nfa) (witness string, decided bool) {
+	type step struct {
+		from pairState
+		c    rune
+	}
+	start := pairState{x.start, y.start, false}
+	parent := map[pairState]step{start: {}}
+	queue := []pairState{start}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		xs, ys := x.closure(cur.a), y.closure(cur.b)
+		if cur.read && slices.Contains(xs, x.accept) && slices.Contains(ys, y.accept) {
+			var rs []rune
+			for p := cur; p != start; p = parent[p].from {
+				rs = append(rs, parent[p].c)
+			}
+			slices.Reverse(rs)
+			return string(rs), true
+		}
+		for _, sa := range xs {
+			if x.states[sa].next < 0 {
+				continue
+			}
+			for _, sb := range ys {
+				if y.states[sb].next < 0 {
+					continue
+				}
+				both := x.states[sa].set.intersect(y.states[sb].set)
+				if len(both) == 0 {
+					continue
+				}
+				next := pairState{x.states[sa].next, y.states[sb].next, true}
+				if _, seen := parent[next]; seen {
+					continue
+				}
+				if len(parent) >= maxProductStates {
+					return "", false
+				}
+				parent[next] = step{cur, both.pick()}
+				queue = append(queue, next)
+			}
+		}
+	}
+	return "", true
+}
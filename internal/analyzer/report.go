@@ -44,6 +44,7 @@ const (
 	CategoryRedundancy   Category = "redundancy"
 	CategoryPerformance  Category = "performance"
 	CategoryCorrectness  Category = "correctness"
+	CategoryPortability  Category = "portability"
 )
 
 // Finding represents a single issue detected by static analysis or
@@ -57,9 +58,47 @@ type Finding struct {
 	Description string         // Detailed explanation of the problem
 	Suggestion  string         // Optional recommended fix
 	Node        ast.Node       // The AST node this finding applies to
+	Branches    []int          // For alternation findings, the 1-based branches of Node it names
 	Runtime     *RuntimeResult // Non-nil when runtime benchmarking confirmed/denied
 }
 
+// ruleDescriptions explains each rule in general terms, for reports
+// that describe a rule once and its findings separately, such as
+// SARIF. A Finding's own Description may be specific to the pattern.
+var ruleDescriptions = map[string]string{
+	"adjacent-unbounded":           "Two unbounded quantifiers in a row make the engine try every way of splitting the input between them.",
+	"atomic-opportunity":           "A quantified group whose match is always final could be atomic, so the engine never backtracks into it.",
+	"cross-category-range":         "A range running from one kind of character to another, such as A-z, also matches the punctuation between them.",
+	"duplicate-class-member":       "A character class lists characters that earlier members already match.",
+	"empty-alternative":            "An alternation has an empty branch, which matches the empty string.",
+	"invalid-backreference":        "A back-reference names a group the pattern does not define.",
+	"leading-wildcard":             "A leading .* is redundant under search semantics, which already try every starting position.",
+	"locale-multibyte":             "Under LC_ALL=C, grep and sed match bytes rather than characters, so non-ASCII text matches differently.",
+	"locale-range":                 "POSIX leaves letter ranges to the locale's collation order, which can include accented or other-case letters.",
+	"missing-anchor":               "A pattern with no ^ or $ anchor makes the engine try every starting position.",
+	"nested-quantifier":            "An unbounded quantifier over a group that contains another can match the same input in exponentially many ways.",
+	"overlapping-alternatives":     "Alternation branches that start alike make the engine retry the shared prefix.",
+	"portability-class-backspace":  `[\b] means a backspace in some flavors and a letter b or an error in others.`,
+	"portability-dollar":           "$ matches before a trailing newline in some flavors and only at the very end in others.",
+	"portability-dot":              ". matches a newline in some flavors and not in others.",
+	"possessive-opportunity":       "A greedy quantifier over a fixed class could be possessive, so the engine never backtracks into it.",
+	"quantified-assertion":         "A quantifier on a zero-width assertion is redundant or an error.",
+	"redundant-bounded-quantifier": "A {0} or {1} quantifier removes its element or changes nothing.",
+	"redundant-group":              "A non-capturing group around a single unquantified element changes nothing.",
+	"repeated-token":               "Three or more identical tokens in a row read better as one quantified token.",
+	"reversed-range":               "A range whose first character comes after its last is rejected or matches nothing.",
+	"single-char-class":            "A character class with one member matches the same as that character alone.",
+	"trailing-wildcard":            "A trailing .* is redundant under search semantics unless the pattern is anchored at its end.",
+	"unreachable-alternative":      "A branch matching anything comes before others, so they never match.",
+	"useless-capture":              "A capturing group nothing refers to could be non-capturing.",
+}
+
+// RuleDescription explains the rule with the given ID in general
+// terms, or returns "" for an unknown ID.
+func RuleDescription(id string) string {
+	return ruleDescriptions[id]
+}
+
 // RuntimeResult holds the outcome of running the pattern against test
 // inputs at escalating sizes for a single finding.
 type RuntimeResult struct {
@@ -83,5 +122,6 @@ type AnalysisReport struct {
 	Pattern          string            // Original pattern string
 	Flavor           string            // Flavor name used for parsing
 	Findings         []*Finding        // All findings, ordered by position in pattern
+	Metrics          *Metrics          // Structure counts (see ComputeMetrics)
 	BenchmarkSummary *BenchmarkSummary // Nil when --benchmark was not passed
 }
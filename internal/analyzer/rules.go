@@ -37,40 +37,6 @@ func checkAdjacentUnbounded(fragments []*ast.MatchFragment, findings *[]*Finding
 	}
 }
 
-// checkNestedQuantifier detects a MatchFragment with an unbounded quantifier
-// whose content is a Subexp that itself contains an unbounded quantifier.
-// Example: (a+)+ — the outer + and inner + create exponential paths.
-//
-// The check requires BOTH the outer and the inner quantifier to be unbounded
-// (Max == -1). Bounded × bounded cases such as (?:[A-Fa-f\d]{2}){5} (MAC
-// address) have a finite upper repetition count and do not produce
-// catastrophic backtracking. Bounded × unbounded and unbounded × bounded are
-// also excluded because the total repetition count is bounded by the smaller
-// of the two factors being finite.
-func checkNestedQuantifier(frag *ast.MatchFragment, findings *[]*Finding) {
-	if frag.Repeat == nil || frag.Repeat.Max != -1 {
-		return
-	}
-
-	subexp, ok := frag.Content.(*ast.Subexp)
-	if !ok {
-		return
-	}
-
-	if containsUnboundedQuantifier(subexp.Regexp) {
-		*findings = append(*findings, &Finding{
-			ID:       "nested-quantifier",
-			Category: CategoryBacktracking,
-			Severity: SeverityError,
-			Title:    "Nested quantifiers",
-			Description: "An unbounded quantifier applied to a group that itself contains an unbounded quantifier " +
-				"creates exponential backtracking paths.",
-			Suggestion: "Flatten the expression, use an atomic group (?>...), or use a possessive quantifier if the flavor supports it.",
-			Node:       frag,
-		})
-	}
-}
-
 // walkFragmentsAny returns true if pred returns true for any fragment
 // in the subtree rooted at r, descending through Subexp content. The
 // shared helper replaces three near-identical open-coded walkers that
@@ -193,19 +159,24 @@ func checkEmptyAlternative(r *ast.Regexp, findings *[]*Finding) {
 	if len(r.Matches) < 2 {
 		return
 	}
-	for _, m := range r.Matches {
+	// Report once per Regexp node, naming every empty branch.
+	var empty []int
+	for i, m := range r.Matches {
 		if len(m.Fragments) == 0 {
-			*findings = append(*findings, &Finding{
-				ID:          "empty-alternative",
-				Category:    CategoryCorrectness,
-				Severity:    SeverityWarning,
-				Title:       "Empty alternative branch",
-				Description: "An alternation contains an empty branch, which matches the empty string. This may be intentional (making the group optional) or a typo.",
-				Node:        r,
-			})
-			return // Report once per Regexp node
+			empty = append(empty, i+1)
 		}
 	}
+	if len(empty) > 0 {
+		*findings = append(*findings, &Finding{
+			ID:          "empty-alternative",
+			Category:    CategoryCorrectness,
+			Severity:    SeverityWarning,
+			Title:       "Empty alternative branch",
+			Description: "An alternation contains an empty branch, which matches the empty string. This may be intentional (making the group optional) or a typo.",
+			Node:        r,
+			Branches:    empty,
+		})
+	}
 }
 
 // checkQuantifiedAssertion detects quantifiers applied to zero-width assertions.
@@ -405,13 +376,20 @@ func checkOverlappingAlternatives(r *ast.Regexp, findings *[]*Finding) {
 		}
 	}
 
-	// Report once when a duplicate signature is found.
+	// Report once when a duplicate signature is found, naming every
+	// branch that shares it.
 	seen := map[branchSig]bool{}
 	for _, sig := range sigs {
 		if sig.nodeType == "" {
 			continue
 		}
 		if seen[sig] {
+			var branches []int
+			for i, other := range sigs {
+				if other == sig {
+					branches = append(branches, i+1)
+				}
+			}
 			*findings = append(*findings, &Finding{
 				ID:          "overlapping-alternatives",
 				Category:    CategoryBacktracking,
@@ -420,6 +398,7 @@ func checkOverlappingAlternatives(r *ast.Regexp, findings *[]*Finding) {
 				Description: "Multiple alternation branches start with the same pattern, which may cause unnecessary backtracking.",
 				Suggestion:  "Factor out the common prefix or reorder branches.",
 				Node:        r,
+				Branches:    branches,
 			})
 			return
 		}
@@ -451,12 +430,22 @@ func checkUnreachableAlternative(r *ast.Regexp, findings *[]*Finding) {
 				Title:       "Unreachable alternative",
 				Description: "A branch matching .* appears before other branches, making them unreachable.",
 				Node:        r,
+				Branches:    unreachableBranches(i+2, len(r.Matches)),
 			})
 			return
 		}
 	}
 }
 
+// unreachableBranches lists the branches from first to last, 1-based.
+func unreachableBranches(first, last int) []int {
+	branches := make([]int, 0, last-first+1)
+	for n := first; n <= last; n++ {
+		branches = append(branches, n)
+	}
+	return branches
+}
+
 // ================================================================================
 // Redundant Bounded Quantifier
 // ================================================================================
@@ -1,6 +1,8 @@
 package analyzer
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/0x4d5352/regolith/internal/ast"
@@ -92,6 +94,26 @@ func TestRuleNestedQuantifier(t *testing.T) {
 			},
 			wantFindings: 1,
 		},
+		{
+			// The engine never backtracks into a possessive quantifier.
+			name: "(a+)++ possessive outer is fine",
+			node: &ast.MatchFragment{
+				Content: &ast.Subexp{
+					GroupType: ast.GroupCapture,
+					Number:    1,
+					Regexp: &ast.Regexp{
+						Matches: []*ast.Match{{
+							Fragments: []*ast.MatchFragment{{
+								Content: &ast.Literal{Text: "a"},
+								Repeat:  &ast.Repeat{Min: 1, Max: -1, Greedy: true},
+							}},
+						}},
+					},
+				},
+				Repeat: &ast.Repeat{Min: 1, Max: -1, Greedy: true, Possessive: true},
+			},
+			wantFindings: 0,
+		},
 		{
 			name: "(abc)+ is fine — no inner quantifier",
 			node: &ast.MatchFragment{
@@ -178,14 +200,97 @@ func TestRuleNestedQuantifier(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			var findings []*Finding
+			a := &analysis{}
 			frag := tc.node.(*ast.MatchFragment)
-			checkNestedQuantifier(frag, &findings)
-			if len(findings) != tc.wantFindings {
-				t.Errorf("got %d findings, want %d", len(findings), tc.wantFindings)
+			a.checkNestedQuantifier(frag)
+			if len(a.findings) != tc.wantFindings {
+				t.Errorf("got %d findings, want %d", len(a.findings), tc.wantFindings)
+			}
+		})
+	}
+}
+
+func TestNestedQuantifierOverlap(t *testing.T) {
+	f, ok := flavor.Get("javascript")
+	if !ok {
+		t.Fatal("javascript flavor not registered")
+	}
+	tests := []struct {
+		pattern string
+		want    string // Witness in the description; "" for no finding
+	}{
+		{`(a+)+b`, `"aa"`},
+		{`^(\d+\.?)+$`, `"00"`},
+		{`(\w+\s?)+$`, `"aa"`},
+		{`(a|b+)+c`, `"bb"`},
+		{`/(A+a)+$/i`, `"aaaa"`},
+		// Each iteration ends at a delimiter the inner loop cannot
+		// match, so a string splits into iterations one way only.
+		{`(a+b)+c`, ""},
+		{`^(?:[a-z]+\.)+com$`, ""},
+		{`(\s*,\s*\w+)+$`, ""},
+		{`(a+b+)+c`, ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.pattern, func(t *testing.T) {
+			root, err := f.Parse(tc.pattern)
+			if err != nil {
+				t.Fatalf("parse error: %v", err)
+			}
+			report := Analyze(root, tc.pattern, "javascript", f.SupportedFeatures())
+			var found *Finding
+			for _, finding := range report.Findings {
+				if finding.ID == "nested-quantifier" {
+					found = finding
+				}
+			}
+			switch {
+			case tc.want == "" && found != nil:
+				t.Errorf("unexpected finding: %s", found.Description)
+			case tc.want != "" && found == nil:
+				t.Error("expected a nested-quantifier finding")
+			case tc.want != "" && !strings.Contains(found.Description, tc.want):
+				t.Errorf("description %q should name the witness %s", found.Description, tc.want)
 			}
 		})
 	}
+
+	// A back-reference has no regular reading, so the nesting alone
+	// is flagged, without a witness.
+	root, err := f.Parse(`(a)(\1a+)+b`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := Analyze(root, `(a)(\1a+)+b`, "javascript", f.SupportedFeatures())
+	if countByID(report.Findings, "nested-quantifier") != 1 {
+		t.Errorf("expected the nesting flagged, got %v", findingIDs(report.Findings))
+	}
+}
+
+func TestSharedString(t *testing.T) {
+	build := func(pattern string, twice bool) *nfa {
+		t.Helper()
+		f, _ := flavor.Get("javascript")
+		root, err := f.Parse(pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b := &nfaBuilder{}
+		s, e := b.regexp(root)
+		if twice {
+			s2, e2 := b.regexp(root)
+			b.epsilon(e, s2)
+			e = e2
+		}
+		b.start, b.accept = s, e
+		return &b.nfa
+	}
+	if w, ok := sharedString(build(`[a-c]x+`, false), build(`[b-d]x`, true)); !ok || w != "" {
+		t.Errorf("got %q, %v; want no shared string", w, ok)
+	}
+	if w, ok := sharedString(build(`[a-c]x+[b-d]x`, false), build(`[b-d]x`, true)); !ok || w != "bxbx" {
+		t.Errorf("got %q, %v; want bxbx", w, ok)
+	}
 }
 
 func TestRuleTrailingWildcard(t *testing.T) {
@@ -376,6 +481,9 @@ func TestRuleEmptyAlternative(t *testing.T) {
 			if tc.wantFindings > 0 && findings[0].ID != tc.wantID {
 				t.Errorf("unexpected finding ID: %s", findings[0].ID)
 			}
+			if tc.wantFindings > 0 && !reflect.DeepEqual(findings[0].Branches, []int{2}) {
+				t.Errorf("expected the finding to name branch 2, got %v", findings[0].Branches)
+			}
 		})
 	}
 }
@@ -5,10 +5,35 @@ package ast
 // Node is the interface all AST nodes implement
 type Node interface {
 	Type() string
+	// Pos returns the part of the pattern the node was parsed from.
+	Pos() Position
+}
+
+// Position is the part of a pattern a node was parsed from: the bytes
+// [Start, End) of the string given to the flavor's Parse, delimiters
+// and all. Every node type embeds one. The zero Position means the
+// parser did not record one, as for a node a rewrite such as
+// FlattenAlternations made up or for the members of a character class.
+type Position struct {
+	Start, End int
+}
+
+// Pos returns p. Embedding Position gives a node its Pos method.
+func (p Position) Pos() Position { return p }
+
+// SetPos records that the node was parsed from the bytes [start, end).
+func (p *Position) SetPos(start, end int) { p.Start, p.End = start, end }
+
+// Positioner is a node whose position can be set. A pointer to any
+// node type is one.
+type Positioner interface {
+	Node
+	SetPos(start, end int)
 }
 
 // Regexp is the root node representing the entire regex
 type Regexp struct {
+	Position
 	Matches []*Match         // Alternation branches
 	Flags   string           // Optional flags (flavor-dependent)
 	Options []*PatternOption // PCRE pattern start options (nil for other flavors)
@@ -18,13 +43,19 @@ func (r *Regexp) Type() string { return "regexp" }
 
 // Match represents a sequence of fragments (one branch of alternation)
 type Match struct {
+	Position
 	Fragments []*MatchFragment
+	// Origin is set by FlattenAlternations on a branch it lifted out of
+	// a nested non-capturing group, describing where the branch came
+	// from. Parsers leave it empty.
+	Origin string
 }
 
 func (m *Match) Type() string { return "match" }
 
 // MatchFragment represents a content node with optional repeat
 type MatchFragment struct {
+	Position
 	Content Node    // Literal, Escape, Charset, Subexp, Anchor, AnyCharacter
 	Repeat  *Repeat // nil if no quantifier
 }
@@ -33,19 +64,23 @@ func (mf *MatchFragment) Type() string { return "match_fragment" }
 
 // Literal represents one or more literal characters
 type Literal struct {
+	Position
 	Text string
 }
 
 func (l *Literal) Type() string { return "literal" }
 
 // AnyCharacter represents the . metacharacter
-type AnyCharacter struct{}
+type AnyCharacter struct {
+	Position
+}
 
 func (a *AnyCharacter) Type() string { return "any_character" }
 
-// Anchor represents ^, $, \b, \B, \A, \Z, \z, \<, \>, \b{g}
+// Anchor represents ^, $, \b, \B, \A, \Z, \z, \<, \>, \b{g}, \zs, \ze
 type Anchor struct {
-	AnchorType string // "start", "end", "word_boundary", "non_word_boundary", "string_start", "string_end", "absolute_end", "word_start", "word_end", "grapheme_cluster_boundary"
+	Position
+	AnchorType string // "start", "end", "word_boundary", "non_word_boundary", "string_start", "string_end", "absolute_end", "word_start", "word_end", "grapheme_cluster_boundary", "match_start", "match_end"
 }
 
 func (a *Anchor) Type() string { return "anchor" }
@@ -62,10 +97,13 @@ const (
 	AnchorWordStart               = "word_start"                // \< (GNU)
 	AnchorWordEnd                 = "word_end"                  // \> (GNU)
 	AnchorGraphemeClusterBoundary = "grapheme_cluster_boundary" // \b{g} (Java)
+	AnchorMatchStart              = "match_start"               // \zs (Vim)
+	AnchorMatchEnd                = "match_end"                 // \ze (Vim)
 )
 
 // Subexp represents a group: (), (?:), (?=), (?!), (?<=), (?<!), (?<name>)
 type Subexp struct {
+	Position
 	GroupType string  // "capture", "non_capture", "positive_lookahead", "negative_lookahead", "positive_lookbehind", "negative_lookbehind", "named_capture", "atomic"
 	Number    int     // Capture group number (0 if non-capture/lookbehind)
 	Name      string  // Group name for named capture groups (empty otherwise)
@@ -88,6 +126,7 @@ const (
 
 // Repeat represents quantifiers: *, +, ?, {n}, {n,}, {n,m}
 type Repeat struct {
+	Position
 	Min        int  // Minimum repetitions
 	Max        int  // Maximum repetitions (-1 for unbounded)
 	Greedy     bool // true if greedy, false if non-greedy (has trailing ?)
@@ -98,6 +137,7 @@ func (r *Repeat) Type() string { return "repeat" }
 
 // Charset represents a character class: [abc], [^abc], [a-z]
 type Charset struct {
+	Position
 	Inverted      bool          // true if negated [^...]
 	Items         []CharsetItem // Contents of the charset
 	SetExpression Node          // CharsetIntersection or CharsetSubtraction; nil for classic charsets
@@ -114,6 +154,7 @@ type CharsetItem interface {
 
 // CharsetIntersection represents set intersection with && operator (v-mode)
 type CharsetIntersection struct {
+	Position
 	Operands []Node // 2+ operands (Charset, Escape, UnicodePropertyEscape, etc.)
 }
 
@@ -121,6 +162,7 @@ func (ci *CharsetIntersection) Type() string { return "charset_intersection" }
 
 // CharsetSubtraction represents set subtraction with -- operator (v-mode)
 type CharsetSubtraction struct {
+	Position
 	Operands []Node // 2+ operands; first is base, rest are subtracted
 }
 
@@ -128,6 +170,7 @@ func (cs *CharsetSubtraction) Type() string { return "charset_subtraction" }
 
 // CharsetStringDisjunction represents \q{abc|def} string disjunction (v-mode)
 type CharsetStringDisjunction struct {
+	Position
 	Strings []string // e.g., ["abc", "def"] for \q{abc|def}
 }
 
@@ -136,6 +179,7 @@ func (csd *CharsetStringDisjunction) isCharsetItem() {}
 
 // CharsetLiteral is a literal character within a charset
 type CharsetLiteral struct {
+	Position
 	Text string
 }
 
@@ -144,6 +188,7 @@ func (cl *CharsetLiteral) isCharsetItem() {}
 
 // CharsetRange represents a range like a-z within a charset
 type CharsetRange struct {
+	Position
 	First string // Starting character
 	Last  string // Ending character
 }
@@ -153,6 +198,7 @@ func (cr *CharsetRange) isCharsetItem() {}
 
 // Escape represents escape sequences: \d, \w, \s, \n, etc.
 type Escape struct {
+	Position
 	EscapeType string // "digit", "word", "whitespace", "newline", etc.
 	Code       string // The original escape code (e.g., "d", "w", "n")
 	Value      string // Display value/description
@@ -163,6 +209,7 @@ func (e *Escape) isCharsetItem() {}
 
 // BackReference represents \1 through \9 or \k<name>
 type BackReference struct {
+	Position
 	Number int    // The group number being referenced (0 for named refs)
 	Name   string // The group name for named backreferences (empty for numbered)
 }
@@ -171,6 +218,7 @@ func (br *BackReference) Type() string { return "back_reference" }
 
 // UnicodePropertyEscape represents \p{...} and \P{...}
 type UnicodePropertyEscape struct {
+	Position
 	Property string // The property name (e.g., "Letter", "L", "Script=Greek")
 	Negated  bool   // true for \P{...}, false for \p{...}
 }
@@ -186,6 +234,7 @@ func (upe *UnicodePropertyEscape) isCharsetItem() {}
 // POSIXClass represents POSIX character classes like [:alpha:], [:digit:]
 // Used in: POSIX BRE, POSIX ERE, PCRE, GNU grep
 type POSIXClass struct {
+	Position
 	Name    string // "alpha", "digit", "space", "alnum", etc.
 	Negated bool   // [:^alpha:] in some implementations
 }
@@ -212,6 +261,7 @@ const (
 // AtomicGroup represents (?>...) - non-backtracking groups
 // Used in: PCRE, Java, .NET
 type AtomicGroup struct {
+	Position
 	Regexp *Regexp
 }
 
@@ -220,6 +270,7 @@ func (ag *AtomicGroup) Type() string { return "atomic_group" }
 // Conditional represents conditional patterns (?(...)|...)
 // Used in: PCRE
 type Conditional struct {
+	Position
 	Condition  Node    // What to test (group number, name, or assertion)
 	TrueMatch  *Regexp // Pattern if condition is true
 	FalseMatch *Regexp // Pattern if condition is false (optional)
@@ -230,6 +281,7 @@ func (c *Conditional) Type() string { return "conditional" }
 // RecursiveRef represents recursive pattern references (?R), (?1), (?&name)
 // Used in: PCRE
 type RecursiveRef struct {
+	Position
 	Target string // "R" for whole pattern, number for group, name for named group
 }
 
@@ -238,6 +290,7 @@ func (rr *RecursiveRef) Type() string { return "recursive_ref" }
 // BalancedGroup represents .NET balanced groups (?<name-otherName>...)
 // Used in: .NET
 type BalancedGroup struct {
+	Position
 	Name      string
 	OtherName string
 	Regexp    *Regexp
@@ -248,6 +301,7 @@ func (bg *BalancedGroup) Type() string { return "balanced_group" }
 // Comment represents (?#...) comments in patterns
 // Used in: PCRE, Java, .NET
 type Comment struct {
+	Position
 	Text string
 }
 
@@ -256,6 +310,7 @@ func (c *Comment) Type() string { return "comment" }
 // QuotedLiteral represents \Q...\E quoted literal sequences
 // Used in: PCRE, Java
 type QuotedLiteral struct {
+	Position
 	Text string
 }
 
@@ -264,6 +319,7 @@ func (ql *QuotedLiteral) Type() string { return "quoted_literal" }
 // InlineModifier represents inline flag modifiers like (?i), (?m), (?s)
 // Used in: PCRE, Java, .NET
 type InlineModifier struct {
+	Position
 	Enable  string  // Flags to enable (e.g., "im")
 	Disable string  // Flags to disable (e.g., "s")
 	Regexp  *Regexp // Optional: scoped modifier (?i:...)
@@ -274,6 +330,7 @@ func (im *InlineModifier) Type() string { return "inline_modifier" }
 // BranchReset represents branch reset groups (?|...)
 // Used in: PCRE
 type BranchReset struct {
+	Position
 	Regexp *Regexp
 }
 
@@ -282,6 +339,7 @@ func (br *BranchReset) Type() string { return "branch_reset" }
 // BacktrackControl represents backtracking control verbs (*PRUNE), (*SKIP), (*FAIL), etc.
 // Used in: PCRE
 type BacktrackControl struct {
+	Position
 	Verb string // "PRUNE", "SKIP", "FAIL", "ACCEPT", etc.
 	Arg  string // Optional argument
 }
@@ -291,6 +349,7 @@ func (bc *BacktrackControl) Type() string { return "backtrack_control" }
 // PatternOption represents PCRE2 pattern start options like (*UTF), (*LIMIT_MATCH=d)
 // Used in: PCRE
 type PatternOption struct {
+	Position
 	Name  string // "UTF", "CR", "LIMIT_MATCH", etc.
 	Value string // For LIMIT_* options, the numeric value; empty otherwise
 }
@@ -300,6 +359,7 @@ func (po *PatternOption) Type() string { return "pattern_option" }
 // Callout represents PCRE2 callout syntax (?C), (?Cn), (?C"text")
 // Used in: PCRE
 type Callout struct {
+	Position
 	Number int    // 0-255 for numeric callouts, -1 for string callouts
 	Text   string // Content for string callouts (empty for numeric)
 }
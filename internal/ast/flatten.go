@@ -0,0 +1,70 @@
+package ast
+
+import "fmt"
+
+// FlattenAlternations rewrites root in place so that a branch made up
+// of nothing but an unquantified non-capturing group around another
+// alternation is replaced by that group's branches: a|(?:b|c) becomes
+// a|b|c, at any depth. Branch order is kept, so the rewritten pattern
+// matches exactly what the original did.
+//
+// Capturing, named, atomic, and lookaround groups are left alone, as
+// are groups with a quantifier or siblings in their branch: removing
+// any of those would change what the pattern captures or matches.
+//
+// Each lifted branch records its former position in Match.Origin so a
+// renderer can still show the original structure, e.g. as a tooltip.
+func FlattenAlternations(root *Regexp) {
+	Walk(root, func(n Node) {
+		if r, ok := n.(*Regexp); ok && r != nil {
+			flattenLevel(r)
+		}
+	})
+}
+
+// flattenLevel replaces each of r's flattenable branches with the
+// branches it expands to.
+func flattenLevel(r *Regexp) {
+	var out []*Match
+	for _, m := range r.Matches {
+		out = append(out, expand(m)...)
+	}
+	r.Matches = out
+}
+
+// expand returns the branches m stands for: m itself, or, when m is a
+// liftable group, its branches expanded in turn. Origins are phrased
+// innermost first and count branches as they were written.
+func expand(m *Match) []*Match {
+	inner := liftable(m)
+	if inner == nil {
+		return []*Match{m}
+	}
+	var out []*Match
+	for i, b := range inner.Matches {
+		where := fmt.Sprintf("branch %d of %d in a flattened (?:…) group", i+1, len(inner.Matches))
+		for _, lifted := range expand(b) {
+			if lifted.Origin == "" {
+				lifted.Origin = where
+			} else {
+				lifted.Origin += ", itself " + where
+			}
+			out = append(out, lifted)
+		}
+	}
+	return out
+}
+
+// liftable returns the alternation m consists of, or nil when m is
+// anything other than a lone unquantified (?:...) around two or more
+// branches.
+func liftable(m *Match) *Regexp {
+	if len(m.Fragments) != 1 || m.Fragments[0].Repeat != nil {
+		return nil
+	}
+	sub, ok := m.Fragments[0].Content.(*Subexp)
+	if !ok || sub.GroupType != GroupNonCapture || sub.Regexp == nil || len(sub.Regexp.Matches) < 2 {
+		return nil
+	}
+	return sub.Regexp
+}
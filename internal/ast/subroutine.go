@@ -0,0 +1,255 @@
+package ast
+
+import (
+	"strconv"
+	"strings"
+)
+
+// GroupSubroutine is the GroupType of the groups ExpandSubroutines puts
+// in place of subroutine calls. Number and Name are the called group's.
+const GroupSubroutine = "subroutine"
+
+// DefaultSubroutineDepth is how many calls deep ExpandSubroutines goes
+// unless told otherwise.
+const DefaultSubroutineDepth = 3
+
+// ExpandSubroutines rewrites root in place so that each subroutine call
+// to a group, (?1), (?-1), (?+1), (?&name), (?P>name) or \g<name>, is
+// replaced by a GroupSubroutine group holding a copy of the called
+// group's contents, as if the pattern had been written out in full.
+// Groups defined in a (?(DEFINE)...) block are expanded like any other.
+//
+// Calls inside an expansion are expanded in turn, up to depth calls
+// deep. A call that is recursion, to a group it is inside of or to the
+// whole pattern with (?R), is left as it is and still drawn as a
+// reference, as are calls past the depth limit and calls to groups
+// that do not exist.
+//
+// Relative calls are resolved where they were written, so (?-1) inside
+// a copy still calls the group it did in the original. The copies share
+// their leaf nodes with the original tree.
+func ExpandSubroutines(root *Regexp, depth int) {
+	if root == nil {
+		return
+	}
+	s := &subroutines{
+		targets: CallTargets(root),
+		active:  map[*Subexp]bool{},
+	}
+	if len(s.targets) == 0 {
+		return
+	}
+	*root = *s.regexp(root, depth)
+}
+
+// subroutines holds the state of one ExpandSubroutines call.
+type subroutines struct {
+	targets map[*RecursiveRef]*Subexp // the group each call names
+	active  map[*Subexp]bool          // the groups being copied: a call to one is recursion
+}
+
+// CallTargets returns the group each subroutine call in root names.
+// Groups are numbered in the order they open, so the number of groups
+// opened so far turns a relative call into an absolute one. Calls to
+// the whole pattern, (?R) or (?0), and calls to groups that do not
+// exist are left out.
+func CallTargets(root *Regexp) map[*RecursiveRef]*Subexp {
+	targets := map[*RecursiveRef]*Subexp{}
+	byNumber := map[int]*Subexp{}
+	byName := map[string]*Subexp{}
+	var calls []*RecursiveRef
+	opened := map[*RecursiveRef]int{}
+	count := 0
+	Walk(root, func(n Node) {
+		switch n := n.(type) {
+		case *Subexp:
+			if !capturing(n) {
+				return
+			}
+			count++
+			if byNumber[n.Number] == nil {
+				byNumber[n.Number] = n
+			}
+			if n.Name != "" && byName[n.Name] == nil {
+				byName[n.Name] = n
+			}
+		case *RecursiveRef:
+			calls = append(calls, n)
+			opened[n] = count
+		}
+	})
+	for _, call := range calls {
+		t := call.Target
+		var g *Subexp
+		switch {
+		case wholePattern(call):
+			// Not a group: see Recursion.
+		case strings.HasPrefix(t, "+"), strings.HasPrefix(t, "-"):
+			if n, err := strconv.Atoi(t[1:]); err == nil && n > 0 {
+				if t[0] == '+' {
+					g = byNumber[opened[call]+n]
+				} else {
+					g = byNumber[opened[call]-n+1]
+				}
+			}
+		default:
+			if n, err := strconv.Atoi(t); err == nil {
+				g = byNumber[n]
+			} else {
+				g = byName[t]
+			}
+		}
+		if g != nil {
+			targets[call] = g
+		}
+	}
+	return targets
+}
+
+// Recursion returns the subroutine calls in root that are recursion,
+// each with the node it re-enters: root for a call to the whole
+// pattern, and otherwise the called group. A call to a group is
+// recursion when it is made from inside that group, or from inside a
+// group that the called group reaches through calls of its own, as
+// with (?<a>x(?&b)?)(?<b>y(?&a)?). Matching such a call can go round
+// the cycle without end, so it cannot be written out in full.
+func Recursion(root *Regexp) map[*RecursiveRef]Node {
+	recursion := map[*RecursiveRef]Node{}
+	if root == nil {
+		return recursion
+	}
+	targets := CallTargets(root)
+
+	// inside lists the capturing groups each call is nested in, and
+	// calls the groups each group's calls name.
+	inside := map[*RecursiveRef][]*Subexp{}
+	calls := map[*Subexp][]*Subexp{}
+	Walk(root, func(n Node) {
+		g, ok := n.(*Subexp)
+		if !ok || !capturing(g) {
+			return
+		}
+		Walk(g.Regexp, func(n Node) {
+			if call, ok := n.(*RecursiveRef); ok {
+				inside[call] = append(inside[call], g)
+				if t := targets[call]; t != nil {
+					calls[g] = append(calls[g], t)
+				}
+			}
+		})
+	})
+
+	Walk(root, func(n Node) {
+		call, ok := n.(*RecursiveRef)
+		if !ok {
+			return
+		}
+		if wholePattern(call) {
+			recursion[call] = root
+			return
+		}
+		t := targets[call]
+		if t == nil {
+			return
+		}
+		// Follow calls from the target; reaching a group the call is
+		// inside of closes the cycle.
+		seen := map[*Subexp]bool{t: true}
+		queue := []*Subexp{t}
+		for len(queue) > 0 {
+			g := queue[0]
+			queue = queue[1:]
+			for _, next := range calls[g] {
+				if !seen[next] {
+					seen[next] = true
+					queue = append(queue, next)
+				}
+			}
+		}
+		for _, g := range inside[call] {
+			if seen[g] {
+				recursion[call] = t
+				return
+			}
+		}
+	})
+	return recursion
+}
+
+// capturing reports whether g is a group that calls can name.
+func capturing(g *Subexp) bool {
+	return g.GroupType == GroupCapture || g.GroupType == GroupNamedCapture
+}
+
+// wholePattern reports whether call is (?R) or (?0).
+func wholePattern(call *RecursiveRef) bool {
+	return call.Target == "" || call.Target == "R" || call.Target == "0"
+}
+
+// regexp returns a copy of re with the calls in it expanded. Only the
+// containers are copied; every other node is shared.
+func (s *subroutines) regexp(re *Regexp, depth int) *Regexp {
+	if re == nil {
+		return nil
+	}
+	c := *re
+	c.Matches = make([]*Match, len(re.Matches))
+	for i, m := range re.Matches {
+		mc := *m
+		mc.Fragments = make([]*MatchFragment, len(m.Fragments))
+		for j, frag := range m.Fragments {
+			fc := *frag
+			fc.Content = s.node(frag.Content, depth)
+			mc.Fragments[j] = &fc
+		}
+		c.Matches[i] = &mc
+	}
+	return &c
+}
+
+// node returns n with the calls in it expanded.
+func (s *subroutines) node(n Node, depth int) Node {
+	switch n := n.(type) {
+	case *RecursiveRef:
+		g := s.targets[n]
+		if g == nil || s.active[g] || depth <= 0 {
+			return n
+		}
+		s.active[g] = true
+		body := s.regexp(g.Regexp, depth-1)
+		delete(s.active, g)
+		return &Subexp{GroupType: GroupSubroutine, Number: g.Number, Name: g.Name, Regexp: body}
+	case *Subexp:
+		c := *n
+		if s.active[n] {
+			c.Regexp = s.regexp(n.Regexp, depth)
+		} else {
+			s.active[n] = true
+			c.Regexp = s.regexp(n.Regexp, depth)
+			delete(s.active, n)
+		}
+		return &c
+	case *AtomicGroup:
+		c := *n
+		c.Regexp = s.regexp(n.Regexp, depth)
+		return &c
+	case *BalancedGroup:
+		c := *n
+		c.Regexp = s.regexp(n.Regexp, depth)
+		return &c
+	case *BranchReset:
+		c := *n
+		c.Regexp = s.regexp(n.Regexp, depth)
+		return &c
+	case *InlineModifier:
+		c := *n
+		c.Regexp = s.regexp(n.Regexp, depth)
+		return &c
+	case *Conditional:
+		c := *n
+		c.TrueMatch = s.regexp(n.TrueMatch, depth)
+		c.FalseMatch = s.regexp(n.FalseMatch, depth)
+		return &c
+	}
+	return n
+}
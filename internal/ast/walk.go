@@ -0,0 +1,75 @@
+package ast
+
+// Walk calls fn for n and then, depth-first in source order, for every
+// node nested beneath it: alternation branches, match fragments, their
+// content, and the bodies of groups and conditionals. Quantifiers and
+// charset members belong to the node that owns them and are not
+// visited separately.
+func Walk(n Node, fn func(Node)) {
+	switch n := n.(type) {
+	case nil:
+		return
+	case *Regexp:
+		if n == nil {
+			return
+		}
+		fn(n)
+		for _, m := range n.Matches {
+			Walk(m, fn)
+		}
+		return
+	case *Match:
+		fn(n)
+		for _, frag := range n.Fragments {
+			Walk(frag, fn)
+		}
+		return
+	case *MatchFragment:
+		fn(n)
+		Walk(n.Content, fn)
+		return
+	}
+
+	fn(n)
+	switch n := n.(type) {
+	case *Subexp:
+		Walk(n.Regexp, fn)
+	case *AtomicGroup:
+		Walk(n.Regexp, fn)
+	case *BalancedGroup:
+		Walk(n.Regexp, fn)
+	case *BranchReset:
+		Walk(n.Regexp, fn)
+	case *InlineModifier:
+		Walk(n.Regexp, fn)
+	case *Conditional:
+		Walk(n.Condition, fn)
+		Walk(n.TrueMatch, fn)
+		Walk(n.FalseMatch, fn)
+	}
+}
+
+// ClearPositions resets the Position of every node Walk visits under
+// root, and of its pattern options and quantifiers, to the zero
+// Position. Two trees parsed from different spellings of the same
+// pattern are equal once their positions are cleared.
+func ClearPositions(root *Regexp) {
+	clear := func(n Node) {
+		if p, ok := n.(Positioner); ok {
+			p.SetPos(0, 0)
+		}
+	}
+	Walk(root, func(n Node) {
+		clear(n)
+		switch n := n.(type) {
+		case *Regexp:
+			for _, opt := range n.Options {
+				clear(opt)
+			}
+		case *MatchFragment:
+			if n.Repeat != nil {
+				clear(n.Repeat)
+			}
+		}
+	})
+}
@@ -0,0 +1,227 @@
+// Package audit analyzes every regular expression found in a source
+// tree and collects the results into one report. It joins three
+// existing pieces: internal/scan finds the patterns, each site's
+// inferred flavor parses them, and internal/analyzer flags ReDoS risk
+// and other hazards. A complexity score and, optionally, an annotated
+// diagram are added per pattern.
+package audit
+
+import (
+	"sort"
+
+	"github.com/0x4d5352/regolith/internal/analyzer"
+	"github.com/0x4d5352/regolith/internal/ast"
+	"github.com/0x4d5352/regolith/internal/flavor"
+	"github.com/0x4d5352/regolith/internal/renderer"
+	"github.com/0x4d5352/regolith/internal/scan"
+	"github.com/0x4d5352/regolith/internal/workpool"
+)
+
+// Options controls what Run computes for each site.
+type Options struct {
+	// MinSeverity drops findings below this level.
+	MinSeverity analyzer.Severity
+	// Diagrams renders an annotated SVG for every parsed pattern using
+	// Config. It is the slow part of an audit, so it is off by default.
+	Diagrams bool
+	Config   *renderer.Config
+	// Workers bounds how many sites are analyzed at once; zero means
+	// one per CPU (see workpool.Workers).
+	Workers int
+	// Analyzer enables optional analyzer rules, such as portability
+	// across the flavors a team uses.
+	Analyzer analyzer.Options
+}
+
+// Entry is the audit result for one site.
+type Entry struct {
+	scan.Site
+	// ParseError is set when the site's flavor rejected the pattern.
+	// Report, Complexity, and Diagram are then empty.
+	ParseError error
+	Report     *analyzer.AnalysisReport
+	Complexity int
+	Diagram    string // Annotated SVG, when Options.Diagrams is set
+}
+
+// Worst returns the highest severity among e's findings. ok is false
+// when there are none. A parse error counts as SeverityError.
+func (e *Entry) Worst() (sev analyzer.Severity, ok bool) {
+	if e.ParseError != nil {
+		return analyzer.SeverityError, true
+	}
+	if e.Report == nil {
+		return 0, false
+	}
+	for _, f := range e.Report.Findings {
+		if !ok || f.Severity > sev {
+			sev, ok = f.Severity, true
+		}
+	}
+	return sev, ok
+}
+
+// Report is the result of an audit.
+type Report struct {
+	Files   int      // Distinct files that contained at least one pattern
+	Entries []*Entry // One per site, in scan order
+}
+
+// Summary counts entries by outcome.
+type Summary struct {
+	Patterns   int
+	Invalid    int
+	BySeverity map[analyzer.Severity]int // Findings at each severity
+}
+
+// Summary tallies r's entries.
+func (r *Report) Summary() Summary {
+	s := Summary{Patterns: len(r.Entries), BySeverity: make(map[analyzer.Severity]int)}
+	for _, e := range r.Entries {
+		if e.ParseError != nil {
+			s.Invalid++
+			continue
+		}
+		for _, f := range e.Report.Findings {
+			s.BySeverity[f.Severity]++
+		}
+	}
+	return s
+}
+
+// Run analyzes each site with its flavor, several at a time (see
+// Options.Workers). Sites naming an unregistered flavor are skipped.
+// A pattern that crashes its parser or the renderer is reported as
+// that entry's ParseError rather than ending the audit.
+func Run(sites []scan.Site, opts Options) *Report {
+	report := &Report{}
+	files := make(map[string]bool)
+	type job struct {
+		f     flavor.Flavor
+		entry *Entry
+	}
+	var jobs []job
+	for _, site := range sites {
+		f, ok := flavor.Get(site.Flavor)
+		if !ok {
+			continue
+		}
+		files[site.File] = true
+		entry := &Entry{Site: site}
+		report.Entries = append(report.Entries, entry)
+		jobs = append(jobs, job{f, entry})
+	}
+
+	errs := workpool.Run(len(jobs), opts.Workers, func(i int) error {
+		analyze(jobs[i].f, jobs[i].entry, opts)
+		return nil
+	}, nil)
+	for i, err := range errs {
+		if err != nil {
+			*jobs[i].entry = Entry{Site: jobs[i].entry.Site, ParseError: err}
+		}
+	}
+	report.Files = len(files)
+	return report
+}
+
+// analyze fills in entry for one site.
+func analyze(f flavor.Flavor, entry *Entry, opts Options) {
+	root, err := f.Parse(entry.Site.Pattern)
+	if err != nil {
+		entry.ParseError = err
+		return
+	}
+	entry.Report = analyzer.AnalyzeWith(root, entry.Site.Pattern, f.Name(), f.SupportedFeatures(), opts.Analyzer)
+	entry.Report.Findings = filter(entry.Report.Findings, opts.MinSeverity)
+	entry.Complexity = Complexity(root)
+	if opts.Diagrams {
+		r := renderer.New(opts.Config)
+		r.Flavor = f
+		entry.Diagram = r.RenderAnnotated(root, entry.Report)
+	}
+}
+
+func filter(findings []*analyzer.Finding, min analyzer.Severity) []*analyzer.Finding {
+	var out []*analyzer.Finding
+	for _, f := range findings {
+		if f.Severity >= min {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// SortBySeverity orders entries worst first, keeping scan order among
+// equals, so a report leads with what needs attention.
+func (r *Report) SortBySeverity() {
+	rank := func(e *Entry) int {
+		sev, ok := e.Worst()
+		if !ok {
+			return -1
+		}
+		return int(sev)
+	}
+	sort.SliceStable(r.Entries, func(i, j int) bool {
+		return rank(r.Entries[i]) > rank(r.Entries[j])
+	})
+}
+
+// Complexity scores how hard a pattern is to read and how much room it
+// leaves for backtracking. It is a ranking aid, not a measure of
+// anything physical:
+//
+//	score = nodes + 2×(alternation branches beyond the first)
+//	        + 10×(unbounded repeats nested inside another unbounded repeat)
+//
+// so a long but flat pattern scores modestly, while (a+)+ scores high
+// for its size.
+func Complexity(root *ast.Regexp) int {
+	score := 0
+	ast.Walk(root, func(n ast.Node) {
+		score++
+		if r, ok := n.(*ast.Regexp); ok && len(r.Matches) > 1 {
+			score += 2 * (len(r.Matches) - 1)
+		}
+	})
+	return score + 10*nestedUnbounded(root, false)
+}
+
+// nestedUnbounded counts unbounded repeats beneath n that sit inside
+// another unbounded repeat.
+func nestedUnbounded(n ast.Node, inside bool) int {
+	count := 0
+	switch n := n.(type) {
+	case nil:
+	case *ast.Regexp:
+		if n == nil {
+			return 0
+		}
+		for _, m := range n.Matches {
+			count += nestedUnbounded(m, inside)
+		}
+	case *ast.Match:
+		for _, frag := range n.Fragments {
+			count += nestedUnbounded(frag, inside)
+		}
+	case *ast.MatchFragment:
+		unbounded := n.Repeat != nil && n.Repeat.Max == -1
+		if unbounded && inside {
+			count++
+		}
+		count += nestedUnbounded(n.Content, inside || unbounded)
+	case *ast.Subexp:
+		count += nestedUnbounded(n.Regexp, inside)
+	case *ast.AtomicGroup:
+		count += nestedUnbounded(n.Regexp, inside)
+	case *ast.BalancedGroup:
+		count += nestedUnbounded(n.Regexp, inside)
+	case *ast.BranchReset:
+		count += nestedUnbounded(n.Regexp, inside)
+	case *ast.InlineModifier:
+		count += nestedUnbounded(n.Regexp, inside)
+	case *ast.Conditional:
+		count += nestedUnbounded(n.TrueMatch, inside) + nestedUnbounded(n.FalseMatch, inside)
+	}
+	return count
+}
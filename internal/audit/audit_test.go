@@ -0,0 +1,77 @@
+package audit
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/0x4d5352/regolith/internal/analyzer"
+	"github.com/0x4d5352/regolith/internal/flavor"
+	_ "github.com/0x4d5352/regolith/internal/flavor/javascript"
+	"github.com/0x4d5352/regolith/internal/renderer"
+	"github.com/0x4d5352/regolith/internal/scan"
+)
+
+func TestComplexity(t *testing.T) {
+	f, _ := flavor.Get("javascript")
+	score := func(pattern string) int {
+		t.Helper()
+		root, err := f.Parse(pattern)
+		if err != nil {
+			t.Fatalf("parse %q: %v", pattern, err)
+		}
+		return Complexity(root)
+	}
+	flat, nested := score(`abc(d)+`), score(`a(b+)+c`)
+	if nested <= flat+5 {
+		t.Errorf("Complexity(a(b+)+c) = %d, want well above Complexity(abc(d)+) = %d", nested, flat)
+	}
+	if alt, seq := score(`a|b|c`), score(`[abc]`); alt <= seq {
+		t.Errorf("alternation scored %d, not above charset's %d", alt, seq)
+	}
+}
+
+func TestRun(t *testing.T) {
+	sites := []scan.Site{
+		{File: "a.js", Line: 1, Flavor: "javascript", Pattern: `^abc$`},
+		{File: "a.js", Line: 2, Flavor: "javascript", Pattern: `(`},
+		{File: "b.js", Line: 1, Flavor: "javascript", Pattern: `^(a+)+$`},
+		{File: "c.xyz", Line: 1, Flavor: "no-such-flavor", Pattern: `a`},
+	}
+	report := Run(sites, Options{MinSeverity: analyzer.SeverityWarning, Diagrams: true, Config: renderer.DefaultConfig()})
+
+	if report.Files != 2 || len(report.Entries) != 3 {
+		t.Fatalf("Files = %d, entries = %d; want 2 files, 3 entries", report.Files, len(report.Entries))
+	}
+	if report.Entries[1].ParseError == nil {
+		t.Error("expected a parse error for (")
+	}
+	for _, e := range report.Entries {
+		if e.Report == nil {
+			continue
+		}
+		for _, f := range e.Report.Findings {
+			if f.Severity < analyzer.SeverityWarning {
+				t.Errorf("%s: finding %s below MinSeverity kept", e.Pattern, f.ID)
+			}
+		}
+		if !strings.HasPrefix(e.Diagram, "<svg") {
+			t.Errorf("%s: Diagram = %.20q, want an SVG", e.Pattern, e.Diagram)
+		}
+	}
+
+	sum := report.Summary()
+	if sum.Patterns != 3 || sum.Invalid != 1 || sum.BySeverity[analyzer.SeverityError] == 0 {
+		t.Errorf("Summary = %+v", sum)
+	}
+
+	report.SortBySeverity()
+	got := []string{}
+	for _, e := range report.Entries {
+		got = append(got, e.Pattern)
+	}
+	// The parse error and the nested quantifier are both error-level,
+	// so scan order breaks the tie; the clean pattern sorts last.
+	if want := "( ^(a+)+$ ^abc$"; strings.Join(got, " ") != want {
+		t.Errorf("sorted = %q, want %q", strings.Join(got, " "), want)
+	}
+}
@@ -0,0 +1,464 @@
+package convert
+
+import (
+	"strings"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+)
+
+// bracket collects the members of one character class.
+type bracket struct {
+	w    *writer
+	body strings.Builder
+	// POSIX syntax has no escapes in a bracket expression: ] is
+	// literal only first, - only last and ^ anywhere but first.
+	close, caret, dash bool
+}
+
+func (w *writer) newBracket() *bracket { return &bracket{w: w} }
+
+// raw adds text that is already a class member, such as \d.
+func (b *bracket) raw(text string) { b.body.WriteString(text) }
+
+func (b *bracket) char(r rune) {
+	if b.w.d.family != perl {
+		switch r {
+		case ']':
+			b.close = true
+			return
+		case '^':
+			b.caret = true
+			return
+		case '-':
+			b.dash = true
+			return
+		}
+	}
+	b.body.WriteString(b.w.classChar(r))
+}
+
+func (b *bracket) span(lo, hi rune) {
+	if lo == hi {
+		b.char(lo)
+		return
+	}
+	b.body.WriteString(b.w.classChar(lo) + "-" + b.w.classChar(hi))
+}
+
+// class adds a POSIX class: as [:name:] where the target reads it,
+// Java's \p{Name} spelling, or else the ASCII characters it means.
+func (b *bracket) class(name string) {
+	switch {
+	case b.w.d.posix:
+		b.raw("[:" + name + ":]")
+	case b.w.to.Name() == "java":
+		b.raw(`\p{` + javaClasses[name] + "}")
+	default:
+		for _, item := range posixRanges[name] {
+			b.span(item.lo, item.hi)
+		}
+	}
+}
+
+func (b *bracket) items(items []setItem) {
+	for _, item := range items {
+		if item.class != "" {
+			b.class(item.class)
+		} else {
+			b.span(item.lo, item.hi)
+		}
+	}
+}
+
+func (b *bracket) empty() bool {
+	return b.body.Len() == 0 && !b.close && !b.caret && !b.dash
+}
+
+// String closes the class.
+func (b *bracket) String(negated bool) string {
+	if b.empty() {
+		// Every class must have a member; perl syntax can spell the
+		// class that matches nothing, or everything.
+		if b.w.d.family != perl {
+			return ""
+		}
+		if negated {
+			return `[\s\S]`
+		}
+		return `[^\s\S]`
+	}
+	body := b.body.String()
+	if b.caret && !b.close && !b.dash && body == "" && !negated {
+		// [^] would negate; a lone ^ is a literal outside a class.
+		return `\^`
+	}
+	var s strings.Builder
+	s.WriteString("[")
+	if negated {
+		s.WriteString("^")
+	}
+	if b.close {
+		s.WriteString("]")
+	}
+	s.WriteString(body)
+	switch {
+	case b.caret && b.dash && !b.close && body == "":
+		s.WriteString("-^")
+	case b.caret && b.dash:
+		s.WriteString("^-")
+	case b.caret:
+		s.WriteString("^")
+	case b.dash:
+		s.WriteString("-")
+	}
+	return s.String() + "]"
+}
+
+// classChar writes r so that it stands for itself inside a class.
+func (w *writer) classChar(r rune) string {
+	if w.d.family == perl {
+		metas := `\]^-[`
+		switch {
+		case w.d.slash:
+			metas += "(){}/|"
+		case w.d.sets == "java":
+			metas += "&"
+		}
+		if strings.ContainsRune(metas, r) {
+			return `\` + string(r)
+		}
+	}
+	return w.printable(r)
+}
+
+// class writes items as a class of their own.
+func (w *writer) class(negated bool, items []setItem) string {
+	b := w.newBracket()
+	b.items(items)
+	return b.String(negated)
+}
+
+// charset writes a class from the source, reporting whether the result
+// is a single atom.
+func (w *writer) charset(c *ast.Charset) (string, bool) {
+	if c.SetExpression != nil {
+		return w.setExpression(c)
+	}
+	b := w.newBracket()
+	w.members(b, c.Items)
+	if b.empty() && w.d.family != perl {
+		w.lossy(c, "character class left empty, which %s cannot write; dropped", w.to.Name())
+		return "", true
+	}
+	return b.String(c.Inverted), true
+}
+
+// members adds a class's items to b, writing each the target's way or
+// as the characters it stands for.
+func (w *writer) members(b *bracket, items []ast.CharsetItem) {
+	for _, item := range items {
+		switch v := item.(type) {
+		case *ast.CharsetLiteral:
+			for _, r := range v.Text {
+				b.char(r)
+			}
+		case *ast.CharsetRange:
+			lo, ok1 := rangeBound(v.First)
+			hi, ok2 := rangeBound(v.Last)
+			if !ok1 || !ok2 {
+				w.lossy(v, "range %s-%s cannot be written in %s; dropped", v.First, v.Last, w.to.Name())
+				continue
+			}
+			b.span(lo, hi)
+		case *ast.Escape:
+			w.classEscape(b, v)
+		case *ast.UnicodePropertyEscape:
+			if class, ok := w.javaClass(v); ok {
+				if v.Negated {
+					w.lossy(v, "%s cannot be written inside a class in %s; dropped", propertySource(v), w.to.Name())
+				} else {
+					w.javaClassNote(v, class)
+					b.class(class)
+				}
+				continue
+			}
+			switch class, ok := nearestClass(v.Property); {
+			case w.d.family == perl && w.fs.UnicodeProperties:
+				w.needU = true
+				b.raw(propertySource(v))
+			case ok && !v.Negated:
+				w.lossy(v, "%s written as [:%s:], which only matches ASCII or the locale's characters", propertySource(v), class)
+				b.class(class)
+			default:
+				w.lossy(v, "%s cannot be written inside a class in %s; dropped", propertySource(v), w.to.Name())
+			}
+		case *ast.POSIXClass:
+			switch {
+			case !v.Negated:
+				b.class(v.Name)
+			case w.to.Name() == "pcre":
+				b.raw("[:^" + v.Name + ":]")
+			default:
+				w.lossy(v, "[:^%s:] cannot be written in %s; dropped", v.Name, w.to.Name())
+			}
+		case *ast.Charset:
+			switch {
+			case w.d.sets == "v" || w.d.sets == "java":
+				if w.d.slash {
+					w.needV = true
+				}
+				text, _ := w.charset(v)
+				b.raw(text)
+			case !v.Inverted && v.SetExpression == nil:
+				w.members(b, v.Items)
+			default:
+				w.lossy(v, "nested class cannot be written in %s; dropped", w.to.Name())
+			}
+		case *ast.CharsetStringDisjunction:
+			w.stringDisjunction(b, v)
+		default:
+			w.lossy(item, "%s cannot be written in %s; dropped", item.Type(), w.to.Name())
+		}
+	}
+}
+
+// rangeBound decodes one end of a range, which the parsers keep as
+// written: a, \x41, \n.
+func rangeBound(text string) (rune, bool) {
+	runes := []rune(text)
+	switch {
+	case len(runes) == 1:
+		return runes[0], true
+	case len(runes) == 2 && runes[0] == '\\':
+		for r, letter := range controlLetters {
+			if rune(letter) == runes[1] {
+				return r, true
+			}
+		}
+		switch {
+		case runes[1] == 'b':
+			return '\b', true
+		case runes[1] < '0' || runes[1] > '9':
+			return runes[1], true
+		}
+	}
+	return decodeCodePoint(text)
+}
+
+// classEscape adds an escape found inside a class.
+func (w *writer) classEscape(b *bracket, e *ast.Escape) {
+	if s, ok := shorthands[e.EscapeType]; ok {
+		switch {
+		case s.letter != 0 && w.d.family == perl && strings.IndexByte(w.d.classes, s.letter) >= 0:
+			b.raw(`\` + string(s.letter))
+		case !s.negated:
+			w.emulated(e, s)
+			b.items(w.shorthandItems(s))
+		default:
+			w.lossy(e, "%s cannot be written inside a class in %s; dropped", escapeText(e), w.to.Name())
+		}
+		return
+	}
+	r, ok := w.escapedRune(e)
+	if !ok {
+		w.lossy(e, "escape %s cannot be written inside a class in %s; dropped", escapeText(e), w.to.Name())
+		return
+	}
+	b.char(r)
+}
+
+// stringDisjunction adds \q{...}, which only JavaScript's v mode has.
+// The single characters in it can be written as members anywhere.
+func (w *writer) stringDisjunction(b *bracket, q *ast.CharsetStringDisjunction) {
+	if w.d.sets == "v" {
+		w.needV = true
+		parts := make([]string, len(q.Strings))
+		for i, s := range q.Strings {
+			var p strings.Builder
+			for _, r := range s {
+				if strings.ContainsRune(`\|}`, r) {
+					p.WriteString(`\`)
+				}
+				p.WriteString(w.printable(r))
+			}
+			parts[i] = p.String()
+		}
+		b.raw(`\q{` + strings.Join(parts, "|") + "}")
+		return
+	}
+	for _, s := range q.Strings {
+		if runes := []rune(s); len(runes) == 1 {
+			b.char(runes[0])
+		} else {
+			w.lossy(q, "string %q in \\q{...} cannot be written in %s; dropped", s, w.to.Name())
+		}
+	}
+}
+
+// setExpression writes a class built with && or --.
+func (w *writer) setExpression(c *ast.Charset) (string, bool) {
+	var operands []ast.Node
+	subtract := false
+	switch e := c.SetExpression.(type) {
+	case *ast.CharsetIntersection:
+		operands = e.Operands
+	case *ast.CharsetSubtraction:
+		operands, subtract = e.Operands, true
+	}
+	neg := ""
+	if c.Inverted {
+		neg = "^"
+	}
+	switch {
+	case w.d.sets == "v":
+		w.needV = true
+		op := "&&"
+		if subtract {
+			op = "--"
+		}
+		parts := make([]string, len(operands))
+		for i, o := range operands {
+			parts[i] = w.operand(o, false)
+		}
+		return "[" + neg + strings.Join(parts, op) + "]", true
+	case w.d.sets == "java":
+		parts := make([]string, len(operands))
+		for i, o := range operands {
+			parts[i] = w.operand(o, subtract && i > 0)
+		}
+		return "[" + neg + strings.Join(parts, "&&") + "]", true
+	case w.d.sets == "dotnet" && dotnetSubtraction(c, operands, subtract):
+		// .NET only subtracts: A--B--C is [A-[BC]] and A&&B is [A-[^B]].
+		base, baseNeg := w.operandMembers(operands[0])
+		if baseNeg {
+			neg = "^"
+		}
+		sub := w.newBracket()
+		subNeg := false
+		for _, o := range operands[1:] {
+			members, negated := w.operandMembers(o)
+			subNeg = !subtract && !negated
+			sub.raw(members.body.String())
+		}
+		w.note(c, "class set operation written with .NET's [base-[excluded]] subtraction")
+		return "[" + neg + base.body.String() + "-" + sub.String(subNeg) + "]", true
+	}
+	return w.setLookaround(c, operands, subtract)
+}
+
+// setLookaround writes A&&B as (?=B)A and A--B as (?!B)A, which match
+// the same single character, or where lookahead is missing keeps only
+// the first operand.
+func (w *writer) setLookaround(c *ast.Charset, operands []ast.Node, subtract bool) (string, bool) {
+	if w.d.family != perl || !w.fs.Lookahead {
+		w.lossy(c, "class set operation cannot be written in %s; only its first operand is kept", w.to.Name())
+		text := w.operand(operands[0], c.Inverted)
+		return text, true
+	}
+	look := "(?="
+	if subtract {
+		look = "(?!"
+	}
+	var s strings.Builder
+	for _, o := range operands[1:] {
+		s.WriteString(look + w.operand(o, false) + ")")
+	}
+	s.WriteString(w.operand(operands[0], false))
+	w.note(c, "class set operation written with lookahead: %s has no set operations", w.to.Name())
+	if c.Inverted {
+		return `(?!` + s.String() + `)[\s\S]`, false
+	}
+	return s.String(), false
+}
+
+// operand writes one side of a set operation as a class of its own,
+// or its complement when negate is set.
+func (w *writer) operand(n ast.Node, negate bool) string {
+	switch v := n.(type) {
+	case *ast.Charset:
+		flipped := *v
+		flipped.Inverted = v.Inverted != negate
+		text, _ := w.charset(&flipped)
+		return text
+	case *ast.Escape:
+		if s, ok := shorthands[v.EscapeType]; ok && s.letter != 0 && w.d.family == perl &&
+			strings.IndexByte(w.d.classes, s.letter) >= 0 {
+			letter := s.letter
+			if negate {
+				letter ^= 'a' - 'A'
+			}
+			return `\` + string(letter)
+		}
+	case *ast.UnicodePropertyEscape:
+		if w.d.family == perl && w.fs.UnicodeProperties {
+			flipped := *v
+			flipped.Negated = v.Negated != negate
+			return propertySource(&flipped)
+		}
+	}
+	b := w.newBracket()
+	if item, ok := n.(ast.CharsetItem); ok {
+		w.members(b, []ast.CharsetItem{item})
+	}
+	return b.String(negate)
+}
+
+// dotnetSubtraction reports whether .NET's subtraction can spell the
+// set operation: every operand a plain class or item, the excluded
+// ones not negated, and only one class intersected with.
+func dotnetSubtraction(c *ast.Charset, operands []ast.Node, subtract bool) bool {
+	if !subtract && len(operands) != 2 {
+		return false
+	}
+	for i, o := range operands {
+		switch v := o.(type) {
+		case *ast.Charset:
+			if v.SetExpression != nil || (subtract && i > 0 && v.Inverted) || (i == 0 && v.Inverted && c.Inverted) {
+				return false
+			}
+		case ast.CharsetItem:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// operandMembers writes an operand as the members of a class, for
+// .NET, whose subtraction cannot nest a class in the base, and reports
+// whether the operand is negated.
+func (w *writer) operandMembers(n ast.Node) (*bracket, bool) {
+	b := w.newBracket()
+	switch v := n.(type) {
+	case *ast.Charset:
+		w.members(b, v.Items)
+		return b, v.Inverted
+	case ast.CharsetItem:
+		w.members(b, []ast.CharsetItem{v})
+	}
+	return b, false
+}
+
+// posixRanges spells each POSIX class in ASCII for targets that have
+// no [:name:].
+var posixRanges = map[string][]setItem{
+	ast.POSIXAlnum:  runes('0', '9', 'A', 'Z', 'a', 'z'),
+	ast.POSIXAlpha:  runes('A', 'Z', 'a', 'z'),
+	ast.POSIXBlank:  runes('\t', '\t', ' ', ' '),
+	ast.POSIXCntrl:  runes(0, 0x1F, 0x7F, 0x7F),
+	ast.POSIXDigit:  runes('0', '9'),
+	ast.POSIXGraph:  runes('!', '~'),
+	ast.POSIXLower:  runes('a', 'z'),
+	ast.POSIXPrint:  runes(' ', '~'),
+	ast.POSIXPunct:  runes('!', '/', ':', '@', '[', '`', '{', '~'),
+	ast.POSIXSpace:  runes('\t', '\r', ' ', ' '),
+	ast.POSIXUpper:  runes('A', 'Z'),
+	ast.POSIXXdigit: runes('0', '9', 'A', 'F', 'a', 'f'),
+}
+
+// javaClasses names the POSIX classes as Java's \p{...} spells them.
+var javaClasses = map[string]string{
+	ast.POSIXAlnum: "Alnum", ast.POSIXAlpha: "Alpha", ast.POSIXBlank: "Blank", ast.POSIXCntrl: "Cntrl",
+	ast.POSIXDigit: "Digit", ast.POSIXGraph: "Graph", ast.POSIXLower: "Lower", ast.POSIXPrint: "Print",
+	ast.POSIXPunct: "Punct", ast.POSIXSpace: "Space", ast.POSIXUpper: "Upper", ast.POSIXXdigit: "XDigit",
+}
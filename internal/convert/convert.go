@@ -0,0 +1,221 @@
+// Package convert writes a parsed pattern back out in the syntax of
+// another flavor, for `regolith convert`.
+//
+// The tree the parsers build is flavor-neutral, so converting is a
+// matter of serializing it with the target's spelling of each
+// construct: (?:...) or a plain group, \d or [[:digit:]], \+ or +. A
+// construct the target has no spelling for is emulated when an exact
+// equivalent exists — a possessive a++ becomes the atomic group
+// (?>a+), or in JavaScript the lookahead-and-back-reference idiom
+// (?=(a+))\1 — and otherwise reported as an Issue marked Lossy along
+// with the closest pattern the target does accept.
+package convert
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+	"github.com/0x4d5352/regolith/internal/flavor"
+)
+
+// Issue is a construct Convert could not copy across as written.
+type Issue struct {
+	// Pos is where the construct is in the source pattern, or the zero
+	// Position for the pattern's flags and nodes without a position.
+	Pos     ast.Position
+	Message string
+	// Lossy is set when the output may match differently from the
+	// source. Otherwise the issue notes an exact emulation or a change
+	// that does not affect matching, such as renumbered groups.
+	Lossy bool
+}
+
+// Convert writes root, parsed under from, in to's syntax. It returns
+// an error only when regolith cannot write to's syntax at all; every
+// other problem is an Issue, in source order.
+func Convert(root *ast.Regexp, from, to flavor.Flavor) (string, []Issue, error) {
+	d, ok := dialects[to.Name()]
+	if !ok {
+		return "", nil, fmt.Errorf("cannot write %s patterns", to.Name())
+	}
+	w := newWriter(root, d, from.Name(), to)
+	return w.pattern(root), w.issues, nil
+}
+
+// Supported reports whether Convert can write f's syntax.
+func Supported(f flavor.Flavor) bool {
+	_, ok := dialects[f.Name()]
+	return ok
+}
+
+// family is the overall shape of a flavor's syntax.
+type family int
+
+const (
+	perl family = iota // Backslash-free operators, (?...) groups
+	ere                // POSIX extended: ( ) | + ? { } are operators
+	bre                // POSIX basic: the operators are \( \) \{ \}
+)
+
+// dialect is what the writer needs to know about a target flavor
+// beyond its FeatureSet.
+type dialect struct {
+	family family
+	// gnu enables the \| \+ \? operators in basic syntax.
+	gnu bool
+	// slash writes the pattern as /pattern/flags (JavaScript).
+	slash bool
+	// backrefs is set when the flavor has \1 back-references.
+	backrefs bool
+	// possessive is set when the flavor has a*+ and the like. .NET's
+	// FeatureSet lists them, but .NET rejects a++ as a nested
+	// quantifier, so it gets the atomic group instead.
+	possessive bool
+	// anchors spells each anchor type beyond ^ and $.
+	anchors map[string]string
+	// classes lists the class escapes the flavor has, by letter: d for
+	// \d and so on.
+	classes string
+	// controls lists the control character escapes, by letter: n for
+	// \n and so on.
+	controls string
+	// posix is set when [:alpha:] may appear inside a class.
+	posix bool
+	// inline holds the flag letters that can be set with (?flags).
+	inline string
+	// wide writes a code point above U+00FF: "x{}" for \x{263A}, "u"
+	// for \u263A.
+	wide string
+	// sets is the set-operation syntax in classes: "v" for
+	// JavaScript's && and --, "java" for && with [^...] for
+	// subtraction, "dotnet" for -[...] subtraction, "" for none.
+	sets string
+}
+
+var (
+	perlAnchors = map[string]string{
+		ast.AnchorWordBoundary:    `\b`,
+		ast.AnchorNonWordBoundary: `\B`,
+		ast.AnchorStringStart:     `\A`,
+		ast.AnchorStringEnd:       `\Z`,
+		ast.AnchorAbsoluteEnd:     `\z`,
+		"first_match_position":    `\G`,
+	}
+	gnuAnchors = map[string]string{
+		ast.AnchorWordBoundary:    `\b`,
+		ast.AnchorNonWordBoundary: `\B`,
+		ast.AnchorWordStart:       `\<`,
+		ast.AnchorWordEnd:         `\>`,
+		ast.AnchorStringStart:     "\\`",
+		ast.AnchorAbsoluteEnd:     `\'`,
+	}
+)
+
+// with returns a copy of m with more entries.
+func with(m map[string]string, kv ...string) map[string]string {
+	out := make(map[string]string, len(m)+len(kv)/2)
+	for k, v := range m {
+		out[k] = v
+	}
+	for i := 0; i+1 < len(kv); i += 2 {
+		out[kv[i]] = kv[i+1]
+	}
+	return out
+}
+
+var dialects = map[string]dialect{
+	"javascript": {
+		family: perl, slash: true, backrefs: true,
+		anchors: map[string]string{ast.AnchorWordBoundary: `\b`, ast.AnchorNonWordBoundary: `\B`},
+		classes: "dDwWsS", controls: "nrtfv", wide: "u", sets: "v",
+	},
+	"pcre": {
+		family: perl, backrefs: true, possessive: true,
+		anchors: with(perlAnchors, "reset_match_start", `\K`, ast.AnchorMatchStart, `\K`),
+		classes: "dDwWsShHvVNRX", controls: "nrtfae", posix: true,
+		inline: "imsnUJ", wide: "x{}",
+	},
+	"java": {
+		family: perl, backrefs: true, possessive: true,
+		anchors: with(perlAnchors, ast.AnchorGraphemeClusterBoundary, `\b{g}`),
+		classes: "dDwWsShHvVRX", controls: "nrtfae",
+		inline: "imsduU", wide: "x{}", sets: "java",
+	},
+	"dotnet": {
+		family: perl, backrefs: true, anchors: perlAnchors,
+		classes: "dDwWsS", controls: "nrtfvae",
+		inline: "imns", wide: "u", sets: "dotnet",
+	},
+	"golang": {
+		family: perl,
+		anchors: map[string]string{
+			ast.AnchorWordBoundary: `\b`, ast.AnchorNonWordBoundary: `\B`,
+			ast.AnchorStringStart: `\A`, ast.AnchorAbsoluteEnd: `\z`,
+		},
+		classes: "dDwWsS", controls: "nrtfva", posix: true,
+		inline: "imsU", wide: "x{}",
+	},
+	"posix-ere":   {family: ere, posix: true},
+	"posix-bre":   {family: bre, backrefs: true, posix: true},
+	"gnugrep":     {family: bre, gnu: true, backrefs: true, anchors: gnuAnchors, classes: "wWsS", posix: true},
+	"gnugrep-bre": {family: bre, gnu: true, backrefs: true, anchors: gnuAnchors, classes: "wWsS", posix: true},
+	"gnugrep-ere": {family: ere, gnu: true, backrefs: true, anchors: gnuAnchors, classes: "wWsS", posix: true},
+	"gnused": {family: bre, gnu: true, backrefs: true, anchors: gnuAnchors, classes: "wWsS",
+		controls: "nrtfva", posix: true},
+	"gnused-ere": {family: ere, gnu: true, backrefs: true, anchors: gnuAnchors, classes: "wWsS",
+		controls: "nrtfva", posix: true},
+}
+
+// ungreedyFlag lists the flavors whose U flag makes quantifiers lazy.
+// Java's U is UNICODE_CHARACTER_CLASS instead.
+var ungreedyFlag = map[string]bool{"pcre": true, "golang": true}
+
+// lineBased lists the flavors of tools that match one line at a time,
+// so the text holds no line break for $ or . to treat specially.
+var lineBased = map[string]bool{
+	"vim": true, "gnugrep": true, "gnugrep-bre": true, "gnugrep-ere": true, "gnused": true, "gnused-ere": true,
+}
+
+// finalBreak lists the flavors whose $, without the m flag, also
+// matches before a line break that ends the text. Elsewhere it matches
+// only at the very end, as \z does.
+var finalBreak = map[string]bool{"pcre": true, "java": true, "dotnet": true}
+
+// dotBreak lists the flavors whose . matches a line break without the
+// s flag.
+var dotBreak = map[string]bool{"posix-ere": true, "posix-bre": true}
+
+// apiFlags are JavaScript flags that select how the pattern is used —
+// every match, match indices, match at lastIndex only — rather than
+// what it matches.
+const apiFlags = "dgy"
+
+// supportsFlag reports whether f lists the flag letter c.
+func supportsFlag(f flavor.Flavor, c rune) bool {
+	for _, fi := range f.SupportedFlags() {
+		if fi.Char == c {
+			return true
+		}
+	}
+	return false
+}
+
+// Column converts a node's byte offset in pattern to the 1-based rune
+// column the rest of regolith reports, or 0 when p is the zero
+// Position.
+func Column(pattern string, p ast.Position) int {
+	if p == (ast.Position{}) || p.Start > len(pattern) {
+		return 0
+	}
+	return len([]rune(pattern[:p.Start])) + 1
+}
+
+// describeFlags is a short English list of flag letters: "i", "i and m".
+func describeFlags(letters string) string {
+	parts := strings.Split(letters, "")
+	if len(parts) <= 1 {
+		return letters
+	}
+	return strings.Join(parts[:len(parts)-1], ", ") + " and " + parts[len(parts)-1]
+}
@@ -0,0 +1,188 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+	"github.com/0x4d5352/regolith/internal/flavor"
+	_ "github.com/0x4d5352/regolith/internal/flavor/dotnet"
+	_ "github.com/0x4d5352/regolith/internal/flavor/gnugrep_bre"
+	_ "github.com/0x4d5352/regolith/internal/flavor/gnugrep_ere"
+	_ "github.com/0x4d5352/regolith/internal/flavor/gnused"
+	_ "github.com/0x4d5352/regolith/internal/flavor/golang"
+	_ "github.com/0x4d5352/regolith/internal/flavor/java"
+	_ "github.com/0x4d5352/regolith/internal/flavor/javascript"
+	_ "github.com/0x4d5352/regolith/internal/flavor/pcre"
+	_ "github.com/0x4d5352/regolith/internal/flavor/posix_bre"
+	_ "github.com/0x4d5352/regolith/internal/flavor/posix_ere"
+	_ "github.com/0x4d5352/regolith/internal/flavor/vim"
+)
+
+func convertPattern(t *testing.T, from, to, pattern string) (string, []Issue) {
+	t.Helper()
+	src, ok := flavor.Get(from)
+	if !ok {
+		t.Fatalf("flavor %s not registered", from)
+	}
+	dst, ok := flavor.Get(to)
+	if !ok {
+		t.Fatalf("flavor %s not registered", to)
+	}
+	root, err := src.Parse(pattern)
+	if err != nil {
+		t.Fatalf("parse %q: %v", pattern, err)
+	}
+	got, issues, err := Convert(root, src, dst)
+	if err != nil {
+		t.Fatalf("Convert(%q): %v", pattern, err)
+	}
+	return got, issues
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		from, to, pattern string
+		want              string
+		lossy             bool   // Some issue is lossy
+		issue             string // Substring of some issue's message
+	}{
+		{"pcre", "pcre", `(?i)^(\w+)\s*=\s*(a++|\d{2,}?)\1$`, `(?i)^(\w+)\s*=\s*(a++|\d{2,}?)\1$`, false, ""},
+		{"pcre", "javascript", `(?i)a++b`, `/(?=(a+))\1b/i`, false, "adds capturing group 1"},
+		{"pcre", "javascript", `(a)x*+(b)\2`, `/(a)(?=(x*))\2(b)\3/`, false, "group 2 is now 3"},
+		{"pcre", "dotnet", `a++`, `(?>a+)`, false, "atomic group (?>a+)"},
+		{"pcre", "golang", `a++`, `a+`, true, "possessive quantifier"},
+		{"pcre", "javascript", `\Aa\z`, `/^a$/`, false, `\A written as ^`},
+		{"pcre", "javascript", `a\Z`, `/a(?=\n?$)/`, false, ""},
+		{"pcre", "javascript", `(?>a|ab)c`, `/(?=(a|ab))\1c/`, false, "atomic group emulated"},
+		{"pcre", "javascript", `a/b`, `/a\/b/`, false, ""},
+		{"pcre", "javascript", `[\h]`, `/[\t \xA0\u1680\u180E\u2000-\u200A\u202F\u205F\u3000]/`, false, `\h written as a class`},
+		{"pcre", "javascript", `\p{Lu}`, `/\p{Lu}/u`, false, "added flag u"},
+		{"pcre", "javascript", `\x{1F600}\xE9`, `/\u{1F600}\xE9/u`, false, "added flag u"},
+		{"pcre", "posix-ere", `\x{E9}`, `é`, false, ""},
+		{"pcre", "javascript", `\x01`, `/\x01/`, false, ""},
+		{"pcre", "posix-ere", `\d+\s(?:ab|c)?`, `[[:digit:]]+[[:space:]](ab|c)?`, false, "non-capturing group"},
+		{"pcre", "posix-bre", `(a+)b?\1`, `\(a\{1,\}\)b\{0,1\}\1`, false, ""},
+		{"pcre", "gnugrep", `(a+)b?\1|\bc`, `\(a\+\)b\?\1\|\bc`, false, ""},
+		{"pcre", "posix-bre", `a|b`, `a`, true, "alternation"},
+		{"pcre", "posix-ere", `a+?`, `a+`, true, "lazy quantifier"},
+		{"pcre", "posix-ere", `[a\]^-]`, `[]a^-]`, false, ""},
+		{"pcre", "posix-ere", `[\w-]`, `[[:alnum:]_-]`, false, ""},
+		{"pcre", "posix-ere", `(?=a)b`, `b`, true, "lookahead"},
+		{"pcre", "java", `[[:alpha:]]`, `[\p{Alpha}]`, false, ""},
+		{"pcre", "javascript", `[[:xdigit:]]`, `/[0-9A-Fa-f]/`, false, ""},
+		{"pcre", "javascript", `bar+`, `/bar+/`, false, ""},
+		{"pcre", "javascript", `(a)\1(?:)0`, `/(a)\1(?:)0/`, false, ""},
+		{"pcre", "golang", `(?<y>\d+)\k<y>`, `(?<y>\d+)`, true, "back-reference"},
+		{"pcre", "javascript", `(?s)a.b`, `/a.b/s`, false, ""},
+		{"pcre", "javascript", `a(?i)b`, `/ab/`, true, "inline modifier (?i)"},
+		{"javascript", "pcre", `/a.b/gis`, `(?is)a.b`, false, "flag g dropped"},
+		{"javascript", "java", `/[\p{L}--[a-z]]/v`, `[\p{L}&&[^a-z]]`, false, ""},
+		{"javascript", "dotnet", `/[\p{L}--[a-z]]/v`, `[\p{L}-[a-z]]`, false, ".NET's"},
+		{"javascript", "pcre", `/[\p{L}&&\p{Lu}]/v`, `(?=\p{Lu})\p{L}`, false, "lookahead"},
+		{"javascript", "golang", `/[\p{L}--a]/v`, `\p{L}`, true, "set operation"},
+		{"javascript", "javascript", `/[\q{abc}a]/v`, `/[\q{abc}a]/v`, false, ""},
+		{"gnugrep", "pcre", `\<foo\>\|bar\+`, `\b(?=\w)foo\b(?<=\w)|bar+`, false, `\< written as`},
+		{"gnused", "javascript", `\d065\o101\cA`, `/AA\x01/`, false, ""},
+		{"vim", "pcre", `\<\a\+\zs`, `\b(?=\w)[A-Za-z]+\K`, false, ""},
+		{"vim", "javascript", `\k`, `/[0-9A-Z_a-z\xC0-\xFF]/`, true, "'iskeyword'"},
+		{"golang", "pcre", `(?U)a+`, `(?U)a+`, false, ""},
+		{"golang", "javascript", `(?U)a+`, `/a+/`, true, "flag U"},
+		{"dotnet", "javascript", `(?<n>a)(?<n-n>b)`, `/(?<n>a)(?:b)/`, true, "balancing group"},
+		{"javascript", "pcre", `/a$/`, `a\z`, false, `$ written as \z`},
+		{"javascript", "java", `/a$/`, `a\z`, false, `$ written as \z`},
+		{"javascript", "pcre", `/a$/m`, `(?m)a$`, false, ""},
+		{"pcre", "javascript", `a$`, `/a(?=\n?$)/`, false, "final line break"},
+		{"pcre", "posix-ere", `a$`, `a$`, true, "final line break"},
+		{"pcre", "gnugrep", `a$`, `a$`, false, ""},
+		{"java", "javascript", `\p{Alpha}\P{Upper}`, `/[A-Za-z][^A-Z]/`, false, "ASCII only in Java"},
+		{"java", "pcre", `\p{Alpha}`, `[[:alpha:]]`, false, "ASCII only in Java"},
+		{"java", "posix-ere", `\p{Digit}`, `[[:digit:]]`, true, "locale"},
+		{"java", "javascript", `(?U)\p{Alpha}`, `/\p{Alpha}/u`, true, "flag U"},
+		{"javascript", "posix-ere", `/a.b/`, `a.b`, true, "also matches a line break"},
+		{"javascript", "gnugrep-ere", `/a.b/`, `a.b`, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+" to "+tt.to+" "+tt.pattern, func(t *testing.T) {
+			got, issues := convertPattern(t, tt.from, tt.to, tt.pattern)
+			if got != tt.want {
+				t.Errorf("got %q, want %q (issues %v)", got, tt.want, issues)
+			}
+			lossy, found := false, tt.issue == ""
+			for _, issue := range issues {
+				lossy = lossy || issue.Lossy
+				found = found || strings.Contains(issue.Message, tt.issue)
+			}
+			if lossy != tt.lossy {
+				t.Errorf("lossy = %v, want %v (issues %v)", lossy, tt.lossy, issues)
+			}
+			if !found {
+				t.Errorf("no issue mentions %q: %v", tt.issue, issues)
+			}
+		})
+	}
+}
+
+// TestConvertParses checks that every target accepts what Convert
+// writes for it. regolith's POSIX grammars only read printable ASCII,
+// so output holding other characters, which only POSIX syntax writes
+// unescaped, is not parsed.
+func TestConvertParses(t *testing.T) {
+	patterns := map[string][]string{
+		"pcre": {
+			`(?i)^(\w+)\s*=\s*(a++|[\d\h-]|\x{263A})\1\Z`,
+			`(?<y>\d{4})-(?<m>\d\d)?+\k<y>(?=x)(?<!y)\b.*?$`,
+			`[^\W\d_]+|\p{Greek}\R\N\X`,
+			`(?>a|b)c{2,5}+[[:^alpha:][:punct:]\t]`,
+			`a(?i)b(?-i:c)(?#note)\Q*.\E\G`,
+		},
+		"javascript": {
+			`/(?<a>x)\k<a>[\p{L}--\p{Lu}]\u{1F600}/gv`,
+			`/^[\\\/\-a-z]+(?=\d)|\cA\0\x7f$/imsu`,
+		},
+		"gnugrep": {`\<a\+\(b\|c\)\?\1\>\w\S`},
+		"vim":     {`\<\a\+\zs\k\%x41\u\h`},
+	}
+	for from, list := range patterns {
+		for _, pattern := range list {
+			for _, to := range flavor.List() {
+				dst, _ := flavor.Get(to)
+				if !Supported(dst) {
+					continue
+				}
+				got, _ := convertPattern(t, from, to, pattern)
+				if strings.IndexFunc(got, func(r rune) bool { return r < ' ' || r > '~' }) >= 0 {
+					continue
+				}
+				if _, err := dst.Parse(got); err != nil {
+					t.Errorf("%s %q to %s: %q does not parse: %v", from, pattern, to, got, err)
+				}
+			}
+		}
+	}
+}
+
+func TestConvertUnsupported(t *testing.T) {
+	pcre, _ := flavor.Get("pcre")
+	vim, _ := flavor.Get("vim")
+	root, err := pcre.Parse("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Supported(vim) {
+		t.Error("Supported(vim) = true")
+	}
+	if _, _, err := Convert(root, pcre, vim); err == nil {
+		t.Error("expected an error converting to vim")
+	}
+}
+
+func TestColumn(t *testing.T) {
+	pattern := "é(a)"
+	if got := Column(pattern, ast.Position{Start: 2, End: 5}); got != 2 {
+		t.Errorf("Column = %d, want 2", got)
+	}
+	if got := Column(pattern, ast.Position{}); got != 0 {
+		t.Errorf("Column(zero) = %d, want 0", got)
+	}
+}
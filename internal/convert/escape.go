@@ -0,0 +1,326 @@
+package convert
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+)
+
+// shorthand is a character class escape: its letter in Perl syntax,
+// and the characters it matches for targets that lack it.
+type shorthand struct {
+	letter  byte
+	items   []setItem
+	negated bool
+	// option names the Vim option the class follows; items are then
+	// the option's default.
+	option string
+}
+
+// setItem is one member of a class written out in full: a POSIX class
+// by name, or a range of code points.
+type setItem struct {
+	class  string
+	lo, hi rune
+}
+
+func runes(pairs ...rune) []setItem {
+	items := make([]setItem, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		items = append(items, setItem{lo: pairs[i], hi: pairs[i+1]})
+	}
+	return items
+}
+
+var (
+	digitItems      = []setItem{{class: ast.POSIXDigit}}
+	wordItems       = []setItem{{class: ast.POSIXAlnum}, {lo: '_', hi: '_'}}
+	spaceItems      = []setItem{{class: ast.POSIXSpace}}
+	horizontalItems = runes('\t', '\t', ' ', ' ', 0xA0, 0xA0, 0x1680, 0x1680, 0x180E, 0x180E,
+		0x2000, 0x200A, 0x202F, 0x202F, 0x205F, 0x205F, 0x3000, 0x3000)
+	verticalItems = runes('\n', '\r', 0x85, 0x85, 0x2028, 0x2029)
+	identItems    = runes('0', '9', 'A', 'Z', '_', '_', 'a', 'z', 0xC0, 0xFF)
+	headItems     = runes('A', 'Z', '_', '_', 'a', 'z', 0xC0, 0xFF)
+)
+
+// shorthands lists the class escapes by EscapeType. Java's \R and \X
+// parse as linebreak and grapheme; the rest of the flavors use the
+// PCRE names.
+var shorthands = map[string]shorthand{
+	"digit":                     {letter: 'd', items: digitItems},
+	"non_digit":                 {letter: 'D', items: digitItems, negated: true},
+	"word":                      {letter: 'w', items: wordItems},
+	"non_word":                  {letter: 'W', items: wordItems, negated: true},
+	"whitespace":                {letter: 's', items: spaceItems},
+	"non_whitespace":            {letter: 'S', items: spaceItems, negated: true},
+	"horizontal_whitespace":     {letter: 'h', items: horizontalItems},
+	"non_horizontal_whitespace": {letter: 'H', items: horizontalItems, negated: true},
+	"vertical_whitespace":       {letter: 'v', items: verticalItems},
+	"non_vertical_whitespace":   {letter: 'V', items: verticalItems, negated: true},
+	"non_newline":               {letter: 'N', items: runes('\n', '\n'), negated: true},
+
+	"alpha":           {items: runes('A', 'Z', 'a', 'z')},
+	"non_alpha":       {items: runes('A', 'Z', 'a', 'z'), negated: true},
+	"lower":           {items: runes('a', 'z')},
+	"non_lower":       {items: runes('a', 'z'), negated: true},
+	"upper":           {items: runes('A', 'Z')},
+	"non_upper":       {items: runes('A', 'Z'), negated: true},
+	"hex_digit":       {items: runes('0', '9', 'A', 'F', 'a', 'f')},
+	"non_hex_digit":   {items: runes('0', '9', 'A', 'F', 'a', 'f'), negated: true},
+	"octal_digit":     {items: runes('0', '7')},
+	"non_octal_digit": {items: runes('0', '7'), negated: true},
+	"word_head":       {items: runes('A', 'Z', '_', '_', 'a', 'z')},
+	"non_word_head":   {items: runes('A', 'Z', '_', '_', 'a', 'z'), negated: true},
+
+	"identifier":           {items: identItems, option: "isident"},
+	"identifier_non_digit": {items: headItems, option: "isident"},
+	"keyword":              {items: identItems, option: "iskeyword"},
+	"keyword_non_digit":    {items: headItems, option: "iskeyword"},
+	"filename": {items: append(runes('#', '%', '+', '.', '/', '9', '=', '=', '~', '~'), identItems...),
+		option: "isfname"},
+	"filename_non_digit": {items: append(runes('#', '%', '+', '.', '/', '/', '=', '=', '~', '~'), headItems...),
+		option: "isfname"},
+	"printable":           {items: runes(' ', '~', 0xA1, 0xFF), option: "isprint"},
+	"printable_non_digit": {items: runes(' ', '/', ':', '~', 0xA1, 0xFF), option: "isprint"},
+}
+
+// controlRunes gives the character each control escape stands for.
+// JavaScript's \b inside a class parses as word_boundary and means
+// backspace there.
+var controlRunes = map[string]rune{
+	"newline":         '\n',
+	"carriage_return": '\r',
+	"tab":             '\t',
+	"form_feed":       '\f',
+	"vertical_tab":    '\v',
+	"alert":           '\a',
+	"bell":            '\a',
+	"escape":          0x1B,
+	"escape_char":     0x1B,
+	"backspace":       '\b',
+}
+
+// controlLetters spells a control character as a one-letter escape.
+var controlLetters = map[rune]byte{
+	'\n': 'n', '\r': 'r', '\t': 't', '\f': 'f', '\v': 'v', '\a': 'a', 0x1B: 'e',
+}
+
+// escape writes an Escape node outside a class.
+func (w *writer) escape(e *ast.Escape) (string, bool) {
+	if e.EscapeType == "non_newline" && w.d.family != perl && strings.IndexByte(w.d.controls, 'n') < 0 {
+		// With no way to spell a newline, [^\n] is out of reach, but
+		// tools using POSIX syntax match one line at a time.
+		w.note(e, `\N written as ., which cannot cross a line in a tool that matches line by line`)
+		return ".", true
+	}
+	if s, ok := shorthands[e.EscapeType]; ok {
+		return w.shorthand(e, s), true
+	}
+	switch e.EscapeType {
+	case "newline_sequence", "linebreak":
+		if strings.IndexByte(w.d.classes, 'R') >= 0 {
+			return `\R`, true
+		}
+		if w.d.family == perl {
+			w.note(e, `\R written as (?:\r\n|[\n-\r\x85\u2028\u2029])`)
+			return "(?:" + w.literal("\r\n") + "|" + w.class(false, verticalItems) + ")", true
+		}
+		if strings.IndexByte(w.d.controls, 'n') < 0 {
+			w.lossy(e, `\R cannot be written in %s, which has no escape for a line break; dropped`, w.to.Name())
+			return "", true
+		}
+		w.lossy(e, `\R written as [\n-\r], which matches one character of \r\n at a time`)
+		return w.class(false, runes('\n', '\r')), true
+	case "extended_grapheme", "grapheme":
+		if strings.IndexByte(w.d.classes, 'X') >= 0 {
+			return `\X`, true
+		}
+		if w.d.family == perl && w.fs.UnicodeProperties {
+			w.lossy(e, `\X written as \P{M}\p{M}*, which does not keep \r\n or Hangul syllables together`)
+			w.needU = true
+			return `(?:\P{M}\p{M}*)`, true
+		}
+		w.lossy(e, `\X written as ., which matches one character rather than a grapheme cluster`)
+		return ".", true
+	case "word_boundary", "non_word_boundary":
+		return w.anchor(e, e.EscapeType)
+	}
+	r, ok := w.escapedRune(e)
+	if !ok {
+		w.lossy(e, "escape %s cannot be written in %s; dropped", escapeText(e), w.to.Name())
+		return "", true
+	}
+	return w.char(r), true
+}
+
+// escapeText is the escape as the source spelled it.
+func escapeText(e *ast.Escape) string {
+	if strings.HasPrefix(e.Code, `\`) {
+		return e.Code
+	}
+	return `\` + e.Code
+}
+
+// escapedRune is the one character a control, code point or quoted
+// escape matches.
+func (w *writer) escapedRune(e *ast.Escape) (rune, bool) {
+	if r, ok := controlRunes[e.EscapeType]; ok {
+		return r, true
+	}
+	if e.EscapeType == "word_boundary" {
+		return '\b', true
+	}
+	if e.EscapeType == "literal" {
+		r, _ := utf8.DecodeRuneInString(strings.TrimPrefix(e.Code, `\`))
+		return r, r != utf8.RuneError
+	}
+	return decodeCodePoint(e.Code)
+}
+
+// decodeCodePoint reads the character a code point escape names:
+// \x41, \x{263A}, \u263A, \u{1F600}, \0101, \o{101}, \cA, sed's \d065
+// and \o101, Vim's \%d65 and \%x41, PCRE's \N{U+263A}.
+func decodeCodePoint(code string) (rune, bool) {
+	code = strings.TrimPrefix(strings.TrimPrefix(code, `\`), "%")
+	if code == "" {
+		return 0, false
+	}
+	base := 0
+	digits := code[1:]
+	switch code[0] {
+	case 'x', 'X', 'u', 'U':
+		base = 16
+	case 'o':
+		base = 8
+	case 'd':
+		base = 10
+	case 'N':
+		base, digits = 16, strings.TrimPrefix(strings.Trim(digits, "{}"), "U+")
+	case 'c':
+		if digits == "" {
+			return 0, false
+		}
+		c := strings.TrimPrefix(digits, `\`)
+		return rune(strings.ToUpper(c)[0]) ^ 0x40, true
+	default:
+		base, digits = 8, code
+	}
+	n, err := strconv.ParseUint(strings.Trim(digits, "{}"), base, 32)
+	if err != nil || n > utf8.MaxRune {
+		return 0, false
+	}
+	return rune(n), true
+}
+
+// shorthand writes a class escape outside a class: as itself when the
+// target has it, or else as the class it stands for.
+func (w *writer) shorthand(e *ast.Escape, s shorthand) string {
+	if s.letter != 0 && strings.IndexByte(w.d.classes, s.letter) >= 0 {
+		return `\` + string(s.letter)
+	}
+	w.emulated(e, s)
+	return w.class(s.negated, w.shorthandItems(s))
+}
+
+// shorthandItems is what a class escape the target lacks is written
+// as. POSIX syntax has no escapes for the non-ASCII spaces of \h and
+// \v, so it gets their ASCII part.
+func (w *writer) shorthandItems(s shorthand) []setItem {
+	if w.d.family != perl {
+		switch s.letter {
+		case 'h', 'H':
+			return []setItem{{class: ast.POSIXBlank}}
+		case 'v', 'V':
+			return runes('\n', '\r')
+		}
+	}
+	return s.items
+}
+
+// emulated records that s is written out as the characters it matches.
+func (w *writer) emulated(e *ast.Escape, s shorthand) {
+	switch {
+	case s.option != "":
+		w.lossy(e, "%s follows Vim's '%s' option; written with the option's default", escapeText(e), s.option)
+	case w.d.family != perl && strings.IndexByte("hHvV", s.letter) >= 0:
+		w.lossy(e, "%s written as a bracket expression, which leaves out its non-ASCII spaces", escapeText(e))
+	case w.d.family != perl:
+		w.note(e, "%s written as a bracket expression: %s has no %s", escapeText(e), w.to.Name(), escapeText(e))
+	default:
+		w.note(e, "%s written as a class: %s has no %s", escapeText(e), w.to.Name(), escapeText(e))
+	}
+}
+
+// property writes a Unicode property escape outside a class. Targets
+// without properties get the nearest POSIX class, if there is one.
+func (w *writer) property(p *ast.UnicodePropertyEscape) (string, bool) {
+	if class, ok := w.javaClass(p); ok {
+		w.javaClassNote(p, class)
+		return w.class(p.Negated, []setItem{{class: class}}), true
+	}
+	if w.d.family == perl && w.fs.UnicodeProperties {
+		w.needU = true
+		return propertySource(p), true
+	}
+	class, ok := nearestClass(p.Property)
+	if !ok {
+		w.lossy(p, "%s cannot be written in %s; dropped", propertySource(p), w.to.Name())
+		return "", true
+	}
+	w.lossy(p, "%s written as [:%s:], which only matches ASCII or the locale's characters", propertySource(p), class)
+	return w.class(p.Negated, []setItem{{class: class}}), true
+}
+
+// javaClass returns the POSIX class a Java \p{Alpha} and the like
+// stand for. Without flag U they match ASCII only, where the same name
+// in another flavor's \p{...} is a Unicode property, so they are
+// written as the class instead.
+func (w *writer) javaClass(p *ast.UnicodePropertyEscape) (string, bool) {
+	if !w.javaASCII || w.to.Name() == "java" {
+		return "", false
+	}
+	for class, name := range javaClasses {
+		if name == p.Property {
+			return class, true
+		}
+	}
+	return "", false
+}
+
+// javaClassNote records that p is written as the POSIX class.
+func (w *writer) javaClassNote(p *ast.UnicodePropertyEscape, class string) {
+	if w.d.family != perl {
+		w.lossy(p, "%s written as [:%s:], which matches the locale's characters rather than ASCII only", propertySource(p), class)
+		return
+	}
+	w.note(p, "%s written as a class: it matches ASCII only in Java", propertySource(p))
+}
+
+func propertySource(p *ast.UnicodePropertyEscape) string {
+	if p.Negated {
+		return `\P{` + p.Property + "}"
+	}
+	return `\p{` + p.Property + "}"
+}
+
+// nearestClass maps the common Unicode properties onto the POSIX class
+// that covers their ASCII part.
+func nearestClass(property string) (string, bool) {
+	name := strings.ToLower(strings.NewReplacer("_", "", " ", "", "-", "").Replace(property))
+	name = strings.TrimPrefix(strings.TrimPrefix(name, "is"), "gc=")
+	class, ok := propertyClasses[name]
+	return class, ok
+}
+
+var propertyClasses = map[string]string{
+	"l": ast.POSIXAlpha, "letter": ast.POSIXAlpha, "alphabetic": ast.POSIXAlpha, "alpha": ast.POSIXAlpha,
+	"lu": ast.POSIXUpper, "uppercaseletter": ast.POSIXUpper, "uppercase": ast.POSIXUpper, "upper": ast.POSIXUpper,
+	"ll": ast.POSIXLower, "lowercaseletter": ast.POSIXLower, "lowercase": ast.POSIXLower, "lower": ast.POSIXLower,
+	"nd": ast.POSIXDigit, "decimalnumber": ast.POSIXDigit, "digit": ast.POSIXDigit,
+	"p": ast.POSIXPunct, "punctuation": ast.POSIXPunct, "punct": ast.POSIXPunct,
+	"z": ast.POSIXSpace, "whitespace": ast.POSIXSpace, "space": ast.POSIXSpace,
+	"alnum": ast.POSIXAlnum, "xdigit": ast.POSIXXdigit, "hexdigit": ast.POSIXXdigit,
+	"cc": ast.POSIXCntrl, "control": ast.POSIXCntrl, "cntrl": ast.POSIXCntrl,
+}
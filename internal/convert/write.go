@@ -0,0 +1,820 @@
+package convert
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+	"github.com/0x4d5352/regolith/internal/flavor"
+)
+
+// writer carries the state of one Convert call.
+type writer struct {
+	d      dialect
+	fs     flavor.FeatureSet
+	from   string
+	to     flavor.Flavor
+	issues []Issue
+
+	groups  int            // Capturing groups opened in the output so far
+	renum   map[int]int    // Source group number to output group number
+	names   map[string]int // Source group name to source group number
+	leading ast.Node       // The pattern's first node, where (?i) may become /i
+
+	flags     string // JavaScript flags after the closing /
+	multiline bool   // The output's ^ and $ match at line breaks
+	dotAll    bool   // The source's . matches a line break
+	javaASCII bool   // Java's \p{Alpha} and the like match ASCII only
+	needU     bool   // JavaScript output needs the u flag
+	needV     bool   // JavaScript output needs the v flag
+}
+
+func newWriter(root *ast.Regexp, d dialect, from string, to flavor.Flavor) *writer {
+	w := &writer{
+		d: d, fs: to.SupportedFeatures(), from: from, to: to,
+		renum: map[int]int{}, names: map[string]int{},
+	}
+	w.javaASCII = from == "java" && !strings.ContainsRune(root.Flags, 'U')
+	ast.Walk(root, func(n ast.Node) {
+		switch v := n.(type) {
+		case *ast.Subexp:
+			if v.Name != "" && v.Number > 0 {
+				w.names[v.Name] = v.Number
+			}
+		case *ast.InlineModifier:
+			if strings.ContainsRune(v.Enable, 'U') {
+				w.javaASCII = false
+			}
+		}
+	})
+	if len(root.Matches) > 0 && len(root.Matches[0].Fragments) > 0 {
+		w.leading = root.Matches[0].Fragments[0].Content
+	}
+	return w
+}
+
+func (w *writer) issue(n ast.Node, lossy bool, format string, args ...any) {
+	var pos ast.Position
+	if n != nil {
+		pos = n.Pos()
+	}
+	w.issues = append(w.issues, Issue{Pos: pos, Message: fmt.Sprintf(format, args...), Lossy: lossy})
+}
+
+// note records an exact emulation or a change that does not affect
+// what the pattern matches.
+func (w *writer) note(n ast.Node, format string, args ...any) { w.issue(n, false, format, args...) }
+
+// lossy records a construct the output cannot match the same way.
+func (w *writer) lossy(n ast.Node, format string, args ...any) { w.issue(n, true, format, args...) }
+
+// pattern writes the whole pattern: options, flags and body.
+func (w *writer) pattern(root *ast.Regexp) string {
+	var prefix strings.Builder
+	for _, opt := range root.Options {
+		if w.fs.PatternStartOptions {
+			prefix.WriteString(optionSource(opt))
+		} else {
+			w.note(opt, "%s dropped: %s has no pattern start options", optionSource(opt), w.to.Name())
+		}
+	}
+	inline := w.rootFlags(root.Flags)
+	if inline != "" {
+		prefix.WriteString("(?" + inline + ")")
+	}
+	body := w.regexp(root)
+	w.renumberNote()
+	if !w.d.slash {
+		return prefix.String() + body
+	}
+	if w.needV {
+		w.flags = strings.ReplaceAll(w.flags, "u", "")
+		if !strings.Contains(w.flags, "v") {
+			w.note(nil, "added flag v, which class set operations need in JavaScript")
+			w.flags += "v"
+		}
+	} else if w.needU && !strings.ContainsAny(w.flags, "uv") {
+		w.note(nil, `added flag u, which \p{...} and \u{...} need in JavaScript`)
+		w.flags += "u"
+	}
+	if body == "" {
+		body = "(?:)"
+	}
+	return "/" + body + "/" + sortFlags(w.flags, "dgimsuvy")
+}
+
+// rootFlags carries the source's flags over, into w.flags for
+// JavaScript and otherwise into the (?flags) it returns.
+func (w *writer) rootFlags(flags string) string {
+	var inline strings.Builder
+	for _, c := range flags {
+		switch {
+		case c == 'x', w.from == "javascript" && !w.d.slash && (c == 'u' || c == 'v'):
+			// These change how the source was read, and the tree
+			// already reflects them.
+		case c == 'U' && !(ungreedyFlag[w.from] && ungreedyFlag[w.to.Name()]):
+			w.lossy(nil, "flag U of %s has no counterpart in %s", w.from, w.to.Name())
+		case w.d.slash && supportsFlag(w.to, c) && !strings.ContainsRune(w.flags, c):
+			w.flags += string(c)
+		case !w.d.slash && strings.ContainsRune(w.d.inline, c) && w.fs.InlineModifiers:
+			inline.WriteRune(c)
+		case w.from == "javascript" && strings.ContainsRune(apiFlags, c):
+			w.note(nil, "flag %c dropped: it selects how JavaScript runs the pattern, so pass its counterpart to the matching API", c)
+		case c == 'n' || c == 'J':
+			w.note(nil, "flag %c dropped: it only changes which groups capture or may share names", c)
+		default:
+			w.lossy(nil, "flag %c cannot be written in a %s pattern; set it where the pattern is used", c, w.to.Name())
+		}
+		switch c {
+		case 'm':
+			w.multiline = true
+		case 's':
+			w.dotAll = true
+		}
+	}
+	return inline.String()
+}
+
+// renumberNote reports capturing groups whose number changed because
+// the output added groups of its own.
+func (w *writer) renumberNote() {
+	var olds []int
+	for old, n := range w.renum {
+		if old != n {
+			olds = append(olds, old)
+		}
+	}
+	if len(olds) == 0 {
+		return
+	}
+	sort.Ints(olds)
+	parts := make([]string, len(olds))
+	for i, old := range olds {
+		parts[i] = fmt.Sprintf("%d is now %d", old, w.renum[old])
+	}
+	w.note(nil, "capturing groups renumbered: group %s", strings.Join(parts, ", group "))
+}
+
+// open numbers the next capturing group in the output. old is the
+// group's number in the source, or 0 for a group the output adds.
+func (w *writer) open(old int) int {
+	w.groups++
+	if old > 0 {
+		w.renum[old] = w.groups
+	}
+	return w.groups
+}
+
+// capture writes a capturing group around what body writes, numbering
+// it before the groups inside it.
+func (w *writer) capture(old int, body func() string) string {
+	w.open(old)
+	if w.d.family == bre {
+		return `\(` + body() + `\)`
+	}
+	return "(" + body() + ")"
+}
+
+// plainGroup writes a group that only groups: (?:...) in Perl syntax,
+// and in POSIX syntax, which has nothing else, a capturing group.
+func (w *writer) plainGroup(body func() string) string {
+	if w.d.family == perl {
+		return "(?:" + body() + ")"
+	}
+	return w.capture(0, body)
+}
+
+func (w *writer) alternation() string {
+	if w.d.family == bre {
+		return `\|`
+	}
+	return "|"
+}
+
+func (w *writer) regexp(r *ast.Regexp) string {
+	if r == nil {
+		return ""
+	}
+	if len(r.Matches) > 1 && w.d.family == bre && !w.d.gnu {
+		w.lossy(r, "alternation cannot be written in %s; only the first branch is kept", w.to.Name())
+		return w.match(r.Matches[0])
+	}
+	parts := make([]string, len(r.Matches))
+	for i, m := range r.Matches {
+		parts[i] = w.match(m)
+	}
+	return strings.Join(parts, w.alternation())
+}
+
+func (w *writer) match(m *ast.Match) string {
+	var b strings.Builder
+	for _, f := range m.Fragments {
+		text := w.fragment(f)
+		if w.d.family == perl && text != "" && isDigit(text[0]) && endsInBackref(b.String()) {
+			// \1 followed by 0 would read as \10.
+			text = "(?:)" + text
+		}
+		b.WriteString(text)
+	}
+	return b.String()
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+// endsInBackref reports whether text ends in a back-reference such as
+// \1, rather than an escaped backslash followed by digits.
+func endsInBackref(text string) bool {
+	i := len(text)
+	for i > 0 && isDigit(text[i-1]) {
+		i--
+	}
+	if i == len(text) {
+		return false
+	}
+	slashes := 0
+	for i > 0 && text[i-1] == '\\' {
+		i--
+		slashes++
+	}
+	return slashes%2 == 1
+}
+
+func (w *writer) fragment(f *ast.MatchFragment) string {
+	r := f.Repeat
+	if r == nil {
+		if s, ok := f.Content.(*ast.Subexp); ok && s.GroupType == ast.GroupNonCapture && w.d.family != perl &&
+			len(s.Regexp.Matches) == 1 {
+			// A non-capturing group with one branch and no quantifier
+			// groups nothing, and POSIX syntax could only write it as
+			// a capturing group.
+			return w.match(s.Regexp.Matches[0])
+		}
+		text, _ := w.node(f.Content)
+		return text
+	}
+	// The parsers keep a run of literal characters together, and a
+	// quantifier after it applies to the last one only: bar+ is ba r+.
+	content, prefix := f.Content, ""
+	if text, ok := literalText(content); ok && utf8.RuneCountInString(text) > 1 {
+		_, size := utf8.DecodeLastRuneInString(text)
+		prefix = w.literal(text[:len(text)-size])
+		content = &ast.Literal{Text: text[len(text)-size:]}
+	}
+	possessive := r.Possessive && !w.d.possessive
+	emulated := possessive && !w.fs.AtomicGroups && w.canEmulateAtomic()
+	var group int
+	if emulated {
+		group = w.open(0)
+	}
+	text, atom := w.node(content)
+	if text == "" {
+		return prefix
+	}
+	if !atom {
+		text = w.plainGroup(func() string { return text })
+	}
+	text += w.quantifier(r)
+	switch {
+	case !possessive:
+		return prefix + text
+	case w.fs.AtomicGroups:
+		w.note(r, "possessive quantifier written as the atomic group (?>%s)", text)
+		return prefix + "(?>" + text + ")"
+	case emulated:
+		w.note(r, "possessive quantifier emulated with (?=(...))\\%d, which adds capturing group %d", group, group)
+		return prefix + "(?=(" + text + `))\` + strconv.Itoa(group)
+	default:
+		w.lossy(r, "possessive quantifier cannot be written in %s; written as a greedy one, which may backtrack", w.to.Name())
+		return prefix + text
+	}
+}
+
+// literalText is the text of a Literal or QuotedLiteral.
+func literalText(n ast.Node) (string, bool) {
+	switch v := n.(type) {
+	case *ast.Literal:
+		return v.Text, true
+	case *ast.QuotedLiteral:
+		return v.Text, true
+	}
+	return "", false
+}
+
+// canEmulateAtomic reports whether (?>X) can be written as the
+// equivalent (?=(X))\N: a lookahead does not backtrack into X, and the
+// back-reference consumes what it matched.
+func (w *writer) canEmulateAtomic() bool {
+	return w.d.family == perl && w.fs.Lookahead && w.d.backrefs
+}
+
+func (w *writer) quantifier(r *ast.Repeat) string {
+	var q string
+	interval := func(body string) string {
+		if w.d.family == bre {
+			return `\{` + body + `\}`
+		}
+		return "{" + body + "}"
+	}
+	switch {
+	case r.Min == 0 && r.Max == -1:
+		q = "*"
+	case r.Min == 1 && r.Max == -1 && w.d.family != bre:
+		q = "+"
+	case r.Min == 1 && r.Max == -1 && w.d.gnu:
+		q = `\+`
+	case r.Min == 0 && r.Max == 1 && w.d.family != bre:
+		q = "?"
+	case r.Min == 0 && r.Max == 1 && w.d.gnu:
+		q = `\?`
+	case r.Min == r.Max:
+		q = interval(strconv.Itoa(r.Min))
+	case r.Max == -1:
+		q = interval(strconv.Itoa(r.Min) + ",")
+	default:
+		q = interval(strconv.Itoa(r.Min) + "," + strconv.Itoa(r.Max))
+	}
+	if r.Possessive && w.d.possessive {
+		return q + "+"
+	}
+	if !r.Greedy && !r.Possessive {
+		if w.d.family == perl {
+			q += "?"
+		} else {
+			w.lossy(r, "lazy quantifier cannot be written in %s; written as a greedy one", w.to.Name())
+		}
+	}
+	return q
+}
+
+// node writes one fragment's content and reports whether the result is
+// a single atom a quantifier can follow without a group.
+func (w *writer) node(n ast.Node) (string, bool) {
+	switch v := n.(type) {
+	case *ast.Literal:
+		return w.literal(v.Text), utf8.RuneCountInString(v.Text) == 1
+	case *ast.QuotedLiteral:
+		return w.literal(v.Text), utf8.RuneCountInString(v.Text) == 1
+	case *ast.AnyCharacter:
+		if dotBreak[w.to.Name()] && !dotBreak[w.from] && !lineBased[w.from] && !w.dotAll {
+			w.lossy(v, ". written as ., which in %s also matches a line break", w.to.Name())
+		}
+		return ".", true
+	case *ast.Anchor:
+		return w.anchor(v, v.AnchorType)
+	case *ast.Escape:
+		return w.escape(v)
+	case *ast.UnicodePropertyEscape:
+		return w.property(v)
+	case *ast.Charset:
+		return w.charset(v)
+	case *ast.Subexp:
+		return w.subexp(v), true
+	case *ast.AtomicGroup:
+		return w.atomic(v, v.Regexp), true
+	case *ast.BackReference:
+		return w.backreference(v), true
+	case *ast.InlineModifier:
+		return w.modifier(v), v.Regexp != nil
+	case *ast.Conditional:
+		return w.conditional(v), true
+	case *ast.RecursiveRef:
+		if !w.fs.RecursivePatterns {
+			w.lossy(v, "recursion cannot be written in %s; dropped", w.to.Name())
+			return "", true
+		}
+		return w.recursion(v), true
+	case *ast.BalancedGroup:
+		if !w.fs.BalancedGroups {
+			w.lossy(v, "balancing group cannot be written in %s; written as a plain group", w.to.Name())
+			return w.plainGroup(func() string { return w.regexp(v.Regexp) }), true
+		}
+		return "(?<" + v.Name + "-" + v.OtherName + ">" + w.regexp(v.Regexp) + ")", true
+	case *ast.BranchReset:
+		if !w.fs.BranchReset {
+			w.lossy(v, "branch reset cannot be written in %s; its branches number their groups in sequence", w.to.Name())
+			return w.plainGroup(func() string { return w.regexp(v.Regexp) }), true
+		}
+		start := w.groups
+		var most int
+		branches := make([]string, len(v.Regexp.Matches))
+		for i, m := range v.Regexp.Matches {
+			w.groups = start
+			branches[i] = w.match(m)
+			most = max(most, w.groups)
+		}
+		w.groups = most
+		return "(?|" + strings.Join(branches, "|") + ")", true
+	case *ast.BacktrackControl:
+		if !w.fs.BacktrackingControl {
+			w.lossy(v, "backtracking control verb (*%s) cannot be written in %s; dropped", v.Verb, w.to.Name())
+			return "", true
+		}
+		if v.Arg != "" {
+			return "(*" + v.Verb + ":" + v.Arg + ")", true
+		}
+		return "(*" + v.Verb + ")", true
+	case *ast.Callout:
+		if !w.fs.Callouts {
+			w.note(v, "callout dropped: %s has none", w.to.Name())
+			return "", true
+		}
+		if v.Number == -1 {
+			return "(?C" + strconv.Quote(v.Text) + ")", true
+		}
+		return "(?C" + strconv.Itoa(v.Number) + ")", true
+	case *ast.Comment:
+		if !w.fs.Comments || strings.Contains(v.Text, ")") {
+			return "", true
+		}
+		return "(?#" + v.Text + ")", true
+	}
+	w.lossy(n, "%s cannot be written in %s; dropped", n.Type(), w.to.Name())
+	return "", true
+}
+
+// literal writes text with the target's metacharacters escaped.
+func (w *writer) literal(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		b.WriteString(w.char(r))
+	}
+	return b.String()
+}
+
+// char writes r so that it matches itself outside a class.
+func (w *writer) char(r rune) string {
+	var metas string
+	switch w.d.family {
+	case perl:
+		metas = `\^$.|?*+()[]{}`
+		if w.d.slash {
+			metas += "/"
+		}
+	case ere:
+		metas = `\^$.|?*+()[{}`
+	case bre:
+		metas = `\^$.[*`
+	}
+	if strings.ContainsRune(metas, r) {
+		return `\` + string(r)
+	}
+	return w.printable(r)
+}
+
+// printable writes r as is when it is visible ASCII, and otherwise as
+// the target's control escape or, in Perl syntax, a code point escape,
+// which keeps the pattern readable whatever the source file's
+// encoding. POSIX syntax has no such escapes.
+func (w *writer) printable(r rune) string {
+	if letter, ok := controlLetters[r]; ok && strings.IndexByte(w.d.controls, letter) >= 0 {
+		return `\` + string(letter)
+	}
+	if w.d.family != perl || (r < utf8.RuneSelf && unicode.IsPrint(r)) {
+		return string(r)
+	}
+	return w.codePoint(r)
+}
+
+func (w *writer) codePoint(r rune) string {
+	switch {
+	case r <= 0xFF:
+		return fmt.Sprintf(`\x%02X`, r)
+	case w.d.wide == "x{}":
+		return fmt.Sprintf(`\x{%X}`, r)
+	case r <= 0xFFFF:
+		return fmt.Sprintf(`\u%04X`, r)
+	case w.d.slash:
+		w.needU = true
+		return fmt.Sprintf(`\u{%X}`, r)
+	default:
+		return string(r)
+	}
+}
+
+// anchor writes the zero-width assertion kind, spelling out an
+// equivalent where the target has no escape for it.
+func (w *writer) anchor(n ast.Node, kind string) (string, bool) {
+	switch kind {
+	case ast.AnchorStart:
+		return "^", true
+	case ast.AnchorEnd:
+		return w.dollar(n)
+	}
+	if s, ok := w.d.anchors[kind]; ok {
+		return s, true
+	}
+	switch kind {
+	case ast.AnchorStringStart, ast.AnchorAbsoluteEnd:
+		spelling := map[string]string{ast.AnchorStringStart: "^", ast.AnchorAbsoluteEnd: "$"}[kind]
+		if w.multiline {
+			w.lossy(n, "%s written as %s, which also matches at line breaks under the m flag", anchorName(kind), spelling)
+		} else {
+			w.note(n, "%s written as %s", anchorName(kind), spelling)
+		}
+		return spelling, true
+	case ast.AnchorStringEnd:
+		end, ok := w.d.anchors[ast.AnchorAbsoluteEnd]
+		if !ok {
+			end = "$"
+		}
+		if w.fs.Lookahead && (ok || !w.multiline) {
+			w.note(n, `\Z written as (?=\n?%s)`, end)
+			return `(?=\n?` + end + ")", false
+		}
+		w.lossy(n, `\Z written as $, which does not allow for a final line break`)
+		return "$", true
+	case ast.AnchorWordStart, ast.AnchorWordEnd:
+		look, feature := `(?=\w)`, w.fs.Lookahead
+		if kind == ast.AnchorWordEnd {
+			look, feature = `(?<=\w)`, w.fs.Lookbehind
+		}
+		b, ok := w.d.anchors[ast.AnchorWordBoundary]
+		switch {
+		case ok && feature:
+			w.note(n, "%s written as %s%s", anchorName(kind), b, look)
+			return b + look, false
+		case ok:
+			w.lossy(n, "%s written as %s, which matches at either edge of a word", anchorName(kind), b)
+			return b, true
+		}
+	}
+	w.lossy(n, "%s cannot be written in %s; dropped", anchorName(kind), w.to.Name())
+	return "", true
+}
+
+// dollar writes $ so that it keeps its meaning when the source and
+// target disagree on whether it matches before a line break that ends
+// the text.
+func (w *writer) dollar(n ast.Node) (string, bool) {
+	src, dst := finalBreak[w.from], finalBreak[w.to.Name()]
+	if src == dst || w.multiline || lineBased[w.from] || lineBased[w.to.Name()] {
+		return "$", true
+	}
+	end, ok := w.d.anchors[ast.AnchorAbsoluteEnd]
+	switch {
+	case dst && ok:
+		w.note(n, "$ written as %s: $ in %s also matches before a final line break", end, w.to.Name())
+		return end, true
+	case dst:
+		w.lossy(n, "$ written as $, which in %s also matches before a final line break", w.to.Name())
+		return "$", true
+	case w.fs.Lookahead:
+		if !ok {
+			end = "$"
+		}
+		w.note(n, `$ written as (?=\n?%s): $ in %s does not allow for a final line break`, end, w.to.Name())
+		return `(?=\n?` + end + ")", false
+	}
+	w.lossy(n, "$ written as $, which in %s does not allow for a final line break", w.to.Name())
+	return "$", true
+}
+
+// anchorName is how issues refer to an anchor: its usual Perl or GNU
+// spelling.
+func anchorName(kind string) string {
+	if s, ok := anchorSpellings[kind]; ok {
+		return s
+	}
+	return kind
+}
+
+var anchorSpellings = map[string]string{
+	ast.AnchorWordBoundary:            `\b`,
+	ast.AnchorNonWordBoundary:         `\B`,
+	ast.AnchorStringStart:             `\A`,
+	ast.AnchorStringEnd:               `\Z`,
+	ast.AnchorAbsoluteEnd:             `\z`,
+	ast.AnchorWordStart:               `\<`,
+	ast.AnchorWordEnd:                 `\>`,
+	ast.AnchorGraphemeClusterBoundary: `\b{g}`,
+	ast.AnchorMatchStart:              `\zs`,
+	ast.AnchorMatchEnd:                `\ze`,
+	"first_match_position":            `\G`,
+	"reset_match_start":               `\K`,
+}
+
+func (w *writer) subexp(s *ast.Subexp) string {
+	body := func() string { return w.regexp(s.Regexp) }
+	switch s.GroupType {
+	case ast.GroupCapture:
+		return w.capture(s.Number, body)
+	case ast.GroupNamedCapture:
+		if w.d.family == perl && w.fs.NamedGroups {
+			w.open(s.Number)
+			return "(?<" + s.Name + ">" + body() + ")"
+		}
+		w.note(s, "group name %s dropped: %s has no named groups", s.Name, w.to.Name())
+		return w.capture(s.Number, body)
+	case ast.GroupPositiveLookahead, ast.GroupNegativeLookahead:
+		if w.d.family == perl && w.fs.Lookahead {
+			return subexpOpen[s.GroupType] + body() + ")"
+		}
+		w.lossy(s, "lookahead cannot be written in %s; dropped", w.to.Name())
+		return ""
+	case ast.GroupPositiveLookbehind, ast.GroupNegativeLookbehind:
+		if w.d.family == perl && w.fs.Lookbehind {
+			return subexpOpen[s.GroupType] + body() + ")"
+		}
+		w.lossy(s, "lookbehind cannot be written in %s; dropped", w.to.Name())
+		return ""
+	case ast.GroupAtomic:
+		return w.atomic(s, s.Regexp)
+	}
+	if w.d.family != perl {
+		w.note(s, "non-capturing group written as a capturing group: %s has no other kind", w.to.Name())
+	}
+	return w.plainGroup(body)
+}
+
+var subexpOpen = map[string]string{
+	ast.GroupPositiveLookahead:  "(?=",
+	ast.GroupNegativeLookahead:  "(?!",
+	ast.GroupPositiveLookbehind: "(?<=",
+	ast.GroupNegativeLookbehind: "(?<!",
+}
+
+func (w *writer) atomic(n ast.Node, r *ast.Regexp) string {
+	switch {
+	case w.fs.AtomicGroups:
+		return "(?>" + w.regexp(r) + ")"
+	case w.canEmulateAtomic():
+		group := w.open(0)
+		w.note(n, "atomic group emulated with (?=(...))\\%d, which adds capturing group %d", group, group)
+		text := "(?=(" + w.regexp(r) + `))\` + strconv.Itoa(group)
+		return text
+	}
+	w.lossy(n, "atomic group cannot be written in %s; written as a plain group, which may backtrack", w.to.Name())
+	return w.plainGroup(func() string { return w.regexp(r) })
+}
+
+// groupNumber is the output number of the group a back-reference,
+// recursion or condition names by source number or name.
+func (w *writer) groupNumber(number int, name string) int {
+	if name != "" {
+		number = w.names[name]
+	}
+	if n, ok := w.renum[number]; ok {
+		return n
+	}
+	return number
+}
+
+func (w *writer) backreference(b *ast.BackReference) string {
+	if !w.d.backrefs {
+		w.lossy(b, "back-reference cannot be written in %s; dropped", w.to.Name())
+		return ""
+	}
+	if b.Name != "" && w.d.family == perl && w.fs.NamedGroups {
+		return `\k<` + b.Name + ">"
+	}
+	n := w.groupNumber(b.Number, b.Name)
+	if n > 9 && w.d.family != perl {
+		w.lossy(b, "back-reference to group %d cannot be written in %s, which stops at \\9", n, w.to.Name())
+	}
+	return `\` + strconv.Itoa(n)
+}
+
+// modifier writes an inline modifier with the flag letters the target
+// has. Where it has none, a (?i) that starts the pattern becomes the
+// JavaScript flag.
+func (w *writer) modifier(m *ast.InlineModifier) string {
+	keep := func(letters string) string {
+		var b strings.Builder
+		for _, c := range letters {
+			switch {
+			case c == 'x':
+			case strings.ContainsRune(w.d.inline, c):
+				b.WriteRune(c)
+			default:
+				w.lossy(m, "inline flag %c cannot be written in %s; dropped", c, w.to.Name())
+			}
+		}
+		return b.String()
+	}
+	body := func() string { return w.regexp(m.Regexp) }
+	if w.fs.InlineModifiers && w.d.inline != "" {
+		enable, disable := keep(m.Enable), keep(m.Disable)
+		if strings.ContainsRune(enable, 'm') {
+			w.multiline = true
+		}
+		if strings.ContainsRune(enable, 's') {
+			w.dotAll = true
+		}
+		flags := enable
+		if disable != "" {
+			flags += "-" + disable
+		}
+		switch {
+		case flags == "" && m.Regexp == nil:
+			return ""
+		case flags == "":
+			return w.plainGroup(body)
+		case m.Regexp == nil:
+			return "(?" + flags + ")"
+		}
+		return "(?" + flags + ":" + body() + ")"
+	}
+	if m.Regexp == nil && m.Disable == "" && ast.Node(m) == w.leading && w.d.slash {
+		var moved strings.Builder
+		for _, c := range m.Enable {
+			switch {
+			case c == 'x':
+			case supportsFlag(w.to, c):
+				if !strings.ContainsRune(w.flags, c) {
+					w.flags += string(c)
+				}
+				moved.WriteRune(c)
+			default:
+				w.lossy(m, "inline flag %c cannot be written in %s; dropped", c, w.to.Name())
+			}
+		}
+		if moved.Len() > 0 {
+			w.note(m, "(?%s) at the start written as the %s flag", m.Enable, describeFlags(moved.String()))
+		}
+		return ""
+	}
+	if strings.Trim(m.Enable+m.Disable, "x") != "" {
+		w.lossy(m, "inline modifier (?%s) cannot be written in %s; dropped", modifierFlags(m), w.to.Name())
+	}
+	if m.Regexp == nil {
+		return ""
+	}
+	return w.plainGroup(body)
+}
+
+func modifierFlags(m *ast.InlineModifier) string {
+	if m.Disable != "" {
+		return m.Enable + "-" + m.Disable
+	}
+	return m.Enable
+}
+
+func (w *writer) conditional(c *ast.Conditional) string {
+	if !w.fs.ConditionalPatterns {
+		w.lossy(c, "conditional cannot be written in %s; written as a choice of its branches", w.to.Name())
+		return w.plainGroup(func() string {
+			text := w.regexp(c.TrueMatch)
+			if c.FalseMatch != nil {
+				text += w.alternation() + w.regexp(c.FalseMatch)
+			}
+			return text
+		})
+	}
+	var cond string
+	switch v := c.Condition.(type) {
+	case *ast.BackReference:
+		if v.Name != "" {
+			cond = "(" + v.Name + ")"
+		} else {
+			cond = "(" + strconv.Itoa(w.groupNumber(v.Number, "")) + ")"
+		}
+	case *ast.Subexp:
+		cond = w.subexp(v)
+	case *ast.RecursiveRef:
+		cond = "(R" + strings.TrimPrefix(v.Target, "R") + ")"
+	case *ast.Literal:
+		cond = "(" + v.Text + ")"
+	default:
+		w.lossy(c, "condition %s cannot be written in %s", c.Condition.Type(), w.to.Name())
+	}
+	text := "(?" + cond + w.regexp(c.TrueMatch)
+	if c.FalseMatch != nil {
+		text += "|" + w.regexp(c.FalseMatch)
+	}
+	return text + ")"
+}
+
+func (w *writer) recursion(r *ast.RecursiveRef) string {
+	switch {
+	case r.Target == "R" || r.Target == "0":
+		return "(?R)"
+	case strings.HasPrefix(r.Target, "+") || strings.HasPrefix(r.Target, "-"):
+		return "(?" + r.Target + ")"
+	}
+	if n, err := strconv.Atoi(r.Target); err == nil {
+		return "(?" + strconv.Itoa(w.groupNumber(n, "")) + ")"
+	}
+	return "(?&" + r.Target + ")"
+}
+
+func optionSource(o *ast.PatternOption) string {
+	if o.Value != "" {
+		return "(*" + o.Name + "=" + o.Value + ")"
+	}
+	return "(*" + o.Name + ")"
+}
+
+// sortFlags orders flag letters the way order lists them.
+func sortFlags(flags, order string) string {
+	var b strings.Builder
+	for _, c := range order {
+		if strings.ContainsRune(flags, c) {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,55 @@
+package flavor_test
+
+import (
+	"testing"
+
+	"github.com/0x4d5352/regolith/internal/flavor"
+	_ "github.com/0x4d5352/regolith/internal/flavor/dotnet"
+	_ "github.com/0x4d5352/regolith/internal/flavor/gnugrep_bre"
+	_ "github.com/0x4d5352/regolith/internal/flavor/gnugrep_ere"
+	_ "github.com/0x4d5352/regolith/internal/flavor/gnused"
+	_ "github.com/0x4d5352/regolith/internal/flavor/golang"
+	_ "github.com/0x4d5352/regolith/internal/flavor/java"
+	_ "github.com/0x4d5352/regolith/internal/flavor/javascript"
+	_ "github.com/0x4d5352/regolith/internal/flavor/pcre"
+	_ "github.com/0x4d5352/regolith/internal/flavor/posix_bre"
+	_ "github.com/0x4d5352/regolith/internal/flavor/posix_ere"
+	_ "github.com/0x4d5352/regolith/internal/flavor/vim"
+)
+
+// BenchmarkParse measures parse time and allocations per flavor, the
+// cost a --null batch or the server pays once per pattern. Run it with
+// -memprofile to see where allocations come from before trying to cut
+// them: see "Parser allocations" in CONTRIBUTING.md.
+func BenchmarkParse(b *testing.B) {
+	patterns := map[string]string{
+		"javascript":  `^(?:\d{3}-)?(?:\d{3}|\(\d{3}\))-?\d{4}$`,
+		"java":        `^(?:\d{3}-)?(?:\d{3}|\(\d{3}\))-?\d{4}$`,
+		"dotnet":      `^(?:\d{3}-)?(?:\d{3}|\(\d{3}\))-?\d{4}$`,
+		"pcre":        `^(?:\d{3}-)?(?:\d{3}|\(\d{3}\))-?\d{4}$`,
+		"posix-ere":   `^([0-9]{3}-)?([0-9]{3}|\([0-9]{3}\))-?[0-9]{4}$`,
+		"posix-bre":   `^\([0-9]\{3\}-\)*\([0-9]\{3\}\)-*[0-9]\{4\}$`,
+		"gnugrep-ere": `^([0-9]{3}-)?([0-9]{3}|\([0-9]{3}\))-?[0-9]{4}$`,
+		"gnugrep-bre": `^\([0-9]\{3\}-\)\?\([0-9]\{3\}\)-\?[0-9]\{4\}$`,
+		"golang":      `^(?:\d{3}-)?(?:\d{3}|\(\d{3}\))-?\d{4}$`,
+		"gnused":      `^\([0-9]\{3\}-\)\?\([0-9]\{3\}\|([0-9]\{3\})\)-\?[0-9]\{4\}$`,
+		"gnused-ere":  `^([0-9]{3}-)?([0-9]{3}|\([0-9]{3}\))-?[0-9]{4}$`,
+		"vim":         `\v^(\d{3}-)=(\d{3}|\(\d{3}\))-=\d{4}$`,
+	}
+	for _, name := range flavor.List() {
+		pattern, ok := patterns[name]
+		if !ok {
+			continue
+		}
+		f, _ := flavor.Get(name)
+		if _, err := f.Parse(pattern); err != nil {
+			b.Fatalf("%s: parse %q: %v", name, pattern, err)
+		}
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				_, _ = f.Parse(pattern)
+			}
+		})
+	}
+}
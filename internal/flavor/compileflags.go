@@ -0,0 +1,34 @@
+package flavor
+
+import (
+	"fmt"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+)
+
+// FlagParser is implemented by flavors whose engine also takes flags
+// outside the pattern text, as Java's Pattern.compile(regex, flags)
+// does. Some of those flags change how the pattern is read at all
+// (Java's LITERAL and COMMENTS), so they are applied while parsing
+// rather than drawn afterwards. It is optional: callers go through
+// ParseWithFlags.
+type FlagParser interface {
+	// CompileFlags lists the flags ParseWithFlags accepts. Char is the
+	// equivalent inline flag letter, or zero when there is none.
+	CompileFlags() []FlagInfo
+
+	// ParseWithFlags parses pattern as if compiled with the named
+	// flags, which are matched case-insensitively against CompileFlags.
+	// Letter flags are recorded in the root's Flags.
+	ParseWithFlags(pattern string, flags []string) (*ast.Regexp, error)
+}
+
+// ParseWithFlags parses pattern under the given compile flags. It fails
+// when f takes no compile flags.
+func ParseWithFlags(f Flavor, pattern string, flags []string) (*ast.Regexp, error) {
+	fp, ok := f.(FlagParser)
+	if !ok {
+		return nil, fmt.Errorf("flavor %s does not take compile flags", f.Name())
+	}
+	return fp.ParseWithFlags(pattern, flags)
+}
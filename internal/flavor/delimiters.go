@@ -0,0 +1,137 @@
+package flavor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// DelimiterError reports a group, character class, brace or \Q quote
+// that is never closed, or a ) with no group to close, at the offset
+// of the delimiter at fault. Its text has the same "line:column
+// (offset)" prefix as the grammar's errors, so callers that show a
+// caret under a parse error point it at the delimiter.
+type DelimiterError struct {
+	Pattern string
+	Offset  int    // Byte offset of the delimiter in Pattern
+	Message string // What is wrong, e.g. "unclosed group: ( has no matching )"
+	Err     error  // The grammar's error, when Pinpoint replaced one
+}
+
+// Error implements error.
+func (e *DelimiterError) Error() string {
+	line, col := lineColumn(e.Pattern, e.Offset)
+	return fmt.Sprintf("parse error: %d:%d (%d): %s", line, col, e.Offset, e.Message)
+}
+
+// Unwrap returns the grammar's error.
+func (e *DelimiterError) Unwrap() error { return e.Err }
+
+// Pinpoint returns err, the error f's parser gave for pattern, or in
+// its place a ParseError wrapping a DelimiterError when pattern's
+// delimiters do not balance.
+// A generated grammar stops wherever its input can no longer be
+// continued — for an unclosed group, the end of the pattern — and
+// lists every character it would have accepted there; the delimiter
+// check names the bracket instead. Only that catch-all "no match
+// found" error is replaced: messages a flavor words itself, such as
+// Vim's E54, already say what is wrong. Every flavor's Parse passes
+// its error through Pinpoint, so the check runs only on patterns the
+// parser rejected and cannot turn away one it accepts, such as a ( in
+// a free-spacing comment.
+func Pinpoint(f Flavor, pattern string, err error) error {
+	if err == nil || !strings.Contains(err.Error(), "no match found") {
+		return err
+	}
+	if d := CheckDelimiters(f, pattern); d != nil {
+		d.Err = err
+		pe := NewParseError(pattern, d.Offset, d.Message)
+		pe.Err = d
+		return pe
+	}
+	return err
+}
+
+// CheckDelimiters is a lightweight balance check of pattern's
+// delimiters under f, read off its highlighting tokens (see Tokens)
+// rather than a full parse. It returns nil when they balance.
+//
+// An unclosed [, \p{ or \Q runs to the end of the pattern and
+// swallows any closers after it, so it is reported ahead of the ) it
+// hid; then a ) with nothing to close; then the innermost group left
+// open. Flavors without a Tokenizer are never reported.
+func CheckDelimiters(f Flavor, pattern string) *DelimiterError {
+	tokens := Tokens(f, pattern)
+	report := func(offset int, format string, args ...any) *DelimiterError {
+		return &DelimiterError{Pattern: pattern, Offset: offset, Message: fmt.Sprintf(format, args...)}
+	}
+	var open []Token
+	var stray *Token
+	for i, tok := range tokens {
+		switch tok.Kind {
+		case TokenGroup:
+			switch {
+			case closesGroup(tok.Text):
+				if len(open) > 0 {
+					open = open[:len(open)-1]
+				} else if stray == nil {
+					stray = &tokens[i]
+				}
+			case opensGroup(tok.Text):
+				open = append(open, tok)
+			}
+		case TokenClass:
+			if unclosedClass(tok.Text) {
+				return report(tok.Offset, "unclosed character class: [ has no matching ]")
+			}
+		case TokenEscape:
+			rest := pattern[tok.Offset+len(tok.Text):]
+			// An unterminated quote is the escape followed by one
+			// literal to the end; flavors without \Q go on lexing.
+			quote := tok.Text == `\Q` && i == len(tokens)-2 && tokens[i+1].Kind == TokenLiteral
+			if quote && len(open) > 0 && strings.Contains(rest, ")") {
+				return report(tok.Offset, `unterminated \Q: no \E ends the quote, so the ) after it is quoted too`)
+			}
+			if len(tok.Text) == 2 && strings.IndexByte("pPxNgkou", tok.Text[1]) >= 0 &&
+				strings.HasPrefix(rest, "{") && !strings.Contains(rest, "}") {
+				return report(tok.Offset, "unclosed escape: %s{ has no matching }", tok.Text)
+			}
+		case TokenLiteral:
+			if i == len(tokens)-1 {
+				if loc := openInterval.FindStringIndex(tok.Text); loc != nil {
+					return report(tok.Offset+loc[0], "unclosed quantifier: { has no matching }")
+				}
+			}
+		}
+	}
+	if stray != nil {
+		return report(stray.Offset, "unmatched %s: there is no open group to close", stray.Text)
+	}
+	if n := len(open); n > 0 {
+		tok := open[n-1]
+		closer := ")"
+		if strings.HasPrefix(tok.Text, `\(`) {
+			closer = `\)`
+		}
+		return report(tok.Offset, "unclosed group: %s has no matching %s", tok.Text, closer)
+	}
+	return nil
+}
+
+// openInterval matches an interval quantifier cut off by the end of
+// the pattern, such as a{2, — most flavors read a { that starts no
+// complete interval as a literal, so only one at the very end is
+// taken for a missing }.
+var openInterval = regexp.MustCompile(`\{(\d+,?\d*|,\d+)$`)
+
+// lineColumn converts a byte offset in pattern to the 1-based line and
+// rune column the grammars report.
+func lineColumn(pattern string, offset int) (int, int) {
+	before := pattern[:offset]
+	line := strings.Count(before, "\n") + 1
+	if nl := strings.LastIndexByte(before, '\n'); nl >= 0 {
+		before = before[nl+1:]
+	}
+	return line, utf8.RuneCountInString(before) + 1
+}
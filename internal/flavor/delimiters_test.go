@@ -0,0 +1,87 @@
+package flavor_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/0x4d5352/regolith/internal/flavor"
+	_ "github.com/0x4d5352/regolith/internal/flavor/golang"
+	_ "github.com/0x4d5352/regolith/internal/flavor/posix_bre"
+	_ "github.com/0x4d5352/regolith/internal/flavor/vim"
+)
+
+func TestCheckDelimiters(t *testing.T) {
+	tests := []struct {
+		flavor  string
+		pattern string
+		offset  int    // -1 means the delimiters balance
+		message string // substring of the message
+	}{
+		{"pcre", `a(b`, 1, "unclosed group: ( has no matching )"},
+		{"pcre", `(a(?<n>b)(?:c`, 9, "unclosed group: (?: has no matching )"},
+		{"pcre", `a)b(`, 1, "unmatched ): there is no open group to close"},
+		{"pcre", `(a|[b)`, 3, "unclosed character class"},
+		{"pcre", `(a\Qb)`, 2, `unterminated \Q`},
+		{"pcre", `\p{L`, 0, `unclosed escape: \p{ has no matching }`},
+		{"pcre", `x\x{41`, 1, `unclosed escape: \x{`},
+		{"pcre", `ab{2,`, 2, "unclosed quantifier"},
+		{"pcre", `(?(1)a|b)`, -1, ""},
+		{"pcre", `(?i)(a(*SKIP)\Q(\E)?`, -1, ""},
+		{"pcre", `a{x`, -1, ""},
+		{"posix-bre", `a\(b`, 1, `unclosed group: \( has no matching \)`},
+		{"posix-bre", `a)b`, -1, ""},
+		{"javascript", "/a(b/i", 2, "unclosed group"},
+		{"vim", `a\)`, 1, `unmatched \)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.flavor+" "+tt.pattern, func(t *testing.T) {
+			f, ok := flavor.Get(tt.flavor)
+			if !ok {
+				t.Fatalf("flavor %s not registered", tt.flavor)
+			}
+			d := flavor.CheckDelimiters(f, tt.pattern)
+			if tt.offset < 0 {
+				if d != nil {
+					t.Fatalf("unexpected report: %v", d)
+				}
+				return
+			}
+			if d == nil {
+				t.Fatal("expected a report")
+			}
+			if d.Offset != tt.offset || !strings.Contains(d.Message, tt.message) {
+				t.Errorf("got offset %d %q, want %d %q", d.Offset, d.Message, tt.offset, tt.message)
+			}
+		})
+	}
+}
+
+func TestPinpoint(t *testing.T) {
+	pcre, _ := flavor.Get("pcre")
+	_, err := pcre.Parse("a|b\n(c")
+	var d *flavor.DelimiterError
+	if !errors.As(err, &d) {
+		t.Fatalf("expected a DelimiterError, got %v", err)
+	}
+	if got, want := err.Error(), "parse error: 2:1 (4): unclosed group: ( has no matching )"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if d.Err == nil || !strings.Contains(d.Err.Error(), "no match found") {
+		t.Errorf("grammar error not kept: %v", d.Err)
+	}
+
+	// A message the parser words itself is left alone, but Go's
+	// unbalanced brackets, which regexp/syntax reports where it gave
+	// up, are placed at the bracket.
+	golang, _ := flavor.Get("golang")
+	if _, err := golang.Parse(`a(?=b)`); errors.As(err, &d) {
+		t.Errorf("golang error replaced: %v", err)
+	}
+	if _, err := golang.Parse(`a(b`); !errors.As(err, &d) || d.Offset != 1 {
+		t.Errorf("golang unclosed group: got %v, want a DelimiterError at offset 1", err)
+	}
+	if err := flavor.Pinpoint(pcre, `a(b`, nil); err != nil {
+		t.Errorf("Pinpoint(nil) = %v", err)
+	}
+}
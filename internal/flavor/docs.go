@@ -0,0 +1,148 @@
+package flavor
+
+import "slices"
+
+// Feature documents one FeatureSet field for `regolith flavors`: what
+// the construct is called and a tiny pattern that uses it.
+type Feature struct {
+	Key       string // The field's JSON tag, e.g. "lookahead"
+	Name      string
+	Example   string // In Perl-style syntax; see Notes.Examples
+	Supported func(FeatureSet) bool
+}
+
+// Features lists every FeatureSet field, in the order the fields are
+// declared.
+var Features = []Feature{
+	{"lookahead", "Lookahead", `foo(?=bar)`, func(fs FeatureSet) bool { return fs.Lookahead }},
+	{"lookbehind", "Lookbehind", `(?<=\$)\d+`, func(fs FeatureSet) bool { return fs.Lookbehind }},
+	{"lookbehind_unlimited", "Variable-length lookbehind", `(?<=\d+\.)\d+`, func(fs FeatureSet) bool { return fs.LookbehindUnlimited }},
+	{"named_groups", "Named groups", `(?<year>\d{4})`, func(fs FeatureSet) bool { return fs.NamedGroups }},
+	{"atomic_groups", "Atomic groups", `(?>a+)b`, func(fs FeatureSet) bool { return fs.AtomicGroups }},
+	{"possessive_quantifiers", "Possessive quantifiers", `a++b`, func(fs FeatureSet) bool { return fs.PossessiveQuantifiers }},
+	{"recursive_patterns", "Recursion", `\((?:[^()]|(?R))*\)`, func(fs FeatureSet) bool { return fs.RecursivePatterns }},
+	{"conditional_patterns", "Conditionals", `(<)?\w+(?(1)>)`, func(fs FeatureSet) bool { return fs.ConditionalPatterns }},
+	{"unicode_properties", "Unicode properties", `\p{L}+`, func(fs FeatureSet) bool { return fs.UnicodeProperties }},
+	{"posix_classes", "POSIX classes", `[[:alpha:]][[:digit:]]*`, func(fs FeatureSet) bool { return fs.POSIXClasses }},
+	{"balanced_groups", "Balanced groups", `(?<o>\()[^()]*(?<-o>\))`, func(fs FeatureSet) bool { return fs.BalancedGroups }},
+	{"inline_modifiers", "Inline modifiers", `(?i)abc`, func(fs FeatureSet) bool { return fs.InlineModifiers }},
+	{"comments", "Comments", `a(?#note)b`, func(fs FeatureSet) bool { return fs.Comments }},
+	{"branch_reset", "Branch reset", `(?|(a)|(b))`, func(fs FeatureSet) bool { return fs.BranchReset }},
+	{"backtracking_control", "Backtracking control verbs", `a(*SKIP)(*FAIL)|b`, func(fs FeatureSet) bool { return fs.BacktrackingControl }},
+	{"callouts", "Callouts", `a(?C1)b`, func(fs FeatureSet) bool { return fs.Callouts }},
+	{"script_runs", "Script runs", `(*sr:\d+)`, func(fs FeatureSet) bool { return fs.ScriptRuns }},
+	{"non_atomic_lookaround", "Non-atomic lookaround", `(*napla:\w+)a`, func(fs FeatureSet) bool { return fs.NonAtomicLookaround }},
+	{"pattern_start_options", "Pattern start options", `(*UTF)abc`, func(fs FeatureSet) bool { return fs.PatternStartOptions }},
+	{"unicode_sets", "Unicode sets", `/[\p{L}--[a-z]]/v`, func(fs FeatureSet) bool { return fs.UnicodeSets }},
+	{"octal_escapes", "Octal escapes", `\101`, func(fs FeatureSet) bool { return fs.OctalEscapes }},
+	{"hex_braced_escapes", "Braced hex escapes", `\x{263A}`, func(fs FeatureSet) bool { return fs.HexBracedEscapes }},
+	{"named_unicode_escapes", "Named characters", `\N{U+263A}`, func(fs FeatureSet) bool { return fs.NamedUnicodeEscapes }},
+	{"relative_backrefs", "Relative back-references", `(a)\g{-1}`, func(fs FeatureSet) bool { return fs.RelativeBackrefs }},
+	{"set_operations", "Class set operations", `[a-z&&[^aeiou]]`, func(fs FeatureSet) bool { return fs.SetOperations }},
+}
+
+// Notes is the documentation of a flavor that its FeatureSet cannot
+// carry.
+type Notes struct {
+	// Examples replaces the Perl-style example of a Feature, by Key,
+	// with one in the flavor's own syntax.
+	Examples map[string]string
+
+	// Limitations lists where regolith's reading of a pattern falls
+	// short of the real engine's.
+	Limitations []string
+
+	// Unread lists, by Key, the Features the engine has but regolith
+	// rejects or misreads, which Limitations should explain. Document
+	// lists them apart from the supported ones.
+	Unread []string
+
+	// Notes lists anything else worth knowing when choosing the flavor.
+	Notes []string
+}
+
+// Documenter is implemented by flavors with Notes. It is optional:
+// callers go through Document, and a flavor without it is documented
+// from its FeatureSet and flags alone.
+type Documenter interface {
+	Notes() Notes
+}
+
+// Construct is a Feature as one flavor writes it.
+type Construct struct {
+	Key     string `json:"key"`
+	Name    string `json:"name"`
+	Example string `json:"example,omitempty"`
+}
+
+// Doc is the documentation `regolith flavors` prints for a flavor.
+type Doc struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Family      Family      `json:"family"`
+	Aliases     []string    `json:"aliases,omitempty"`
+	Supported   []Construct `json:"supported"`
+	Unsupported []Construct `json:"unsupported"`
+	Unread      []Construct `json:"unread,omitempty"` // See Notes.Unread
+	Flags       []FlagDoc   `json:"flags,omitempty"`
+	Versions    []string    `json:"versions,omitempty"` // See VersionChecker
+	Limitations []string    `json:"limitations,omitempty"`
+	Notes       []string    `json:"notes,omitempty"`
+}
+
+// FlagDoc is a FlagInfo in one language.
+type FlagDoc struct {
+	Char        string `json:"char"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	DocURL      string `json:"doc_url,omitempty"`
+}
+
+// Document returns the documentation of f, with flag descriptions in
+// lang (see FlagInfo.Text). Unsupported and unread constructs carry no
+// example.
+func Document(f Flavor, lang string) Doc {
+	var notes Notes
+	if d, ok := f.(Documenter); ok {
+		notes = d.Notes()
+	}
+	doc := Doc{
+		Name:        f.Name(),
+		Description: f.Description(),
+		Family:      FamilyOf(f),
+		Aliases:     AliasesOf(f.Name()),
+		Supported:   []Construct{},
+		Unsupported: []Construct{},
+		Limitations: notes.Limitations,
+		Notes:       notes.Notes,
+	}
+	fs := f.SupportedFeatures()
+	for _, feat := range Features {
+		if !feat.Supported(fs) {
+			doc.Unsupported = append(doc.Unsupported, Construct{Key: feat.Key, Name: feat.Name})
+			continue
+		}
+		if slices.Contains(notes.Unread, feat.Key) {
+			doc.Unread = append(doc.Unread, Construct{Key: feat.Key, Name: feat.Name})
+			continue
+		}
+		example := feat.Example
+		if e, ok := notes.Examples[feat.Key]; ok {
+			example = e
+		}
+		doc.Supported = append(doc.Supported, Construct{Key: feat.Key, Name: feat.Name, Example: example})
+	}
+	for _, info := range f.SupportedFlags() {
+		text := info.Text(lang)
+		doc.Flags = append(doc.Flags, FlagDoc{
+			Char:        string(info.Char),
+			Name:        info.Name,
+			Description: text.Description,
+			DocURL:      info.DocURL,
+		})
+	}
+	if vc, ok := f.(VersionChecker); ok {
+		doc.Versions = vc.Versions()
+	}
+	return doc
+}
@@ -4,6 +4,8 @@
 package dotnet
 
 import (
+	"io"
+
 	"github.com/0x4d5352/regolith/internal/ast"
 	"github.com/0x4d5352/regolith/internal/flavor"
 	"github.com/0x4d5352/regolith/internal/flavor/helpers"
@@ -25,21 +27,74 @@ func (d *DotNet) Description() string {
 	return ".NET (System.Text.RegularExpressions) regular expressions"
 }
 
+// Family returns the flavor's syntax family.
+func (d *DotNet) Family() flavor.Family {
+	return flavor.FamilyPerl
+}
+
+// Notes returns what `regolith flavors` says about the flavor beyond
+// its FeatureSet.
+func (d *DotNet) Notes() flavor.Notes {
+	return flavor.Notes{
+		Limitations: []string{
+			`Character class subtraction such as [a-z-[aeiou]] is rejected`,
+			"RegexOptions passed in code, ECMAScript among them, are not visible in the pattern; only inline (?imnsx) modifiers are",
+		},
+		Unread: []string{"set_operations"},
+	}
+}
+
 // Parse parses a .NET regex pattern and returns an AST.
 func (d *DotNet) Parse(pattern string) (*ast.Regexp, error) {
 	state := ast.NewParserState()
-	return helpers.FinalizeParse(Parse("", []byte(pattern), GlobalStore("state", state)))
+	root, err := helpers.FinalizeParse(Parse("", []byte(pattern), GlobalStore("state", state)))
+	flavor.Locate(d, pattern, root)
+	return root, flavor.Pinpoint(d, pattern, parseError(err))
+}
+
+// ParseTrace is Parse with the grammar's rule trace written to w.
+func (d *DotNet) ParseTrace(pattern string, w io.Writer) (*ast.Regexp, error) {
+	state := ast.NewParserState()
+	root, err := helpers.FinalizeParse(helpers.TraceParse(w, func() (any, error) {
+		return Parse("", []byte(pattern), GlobalStore("state", state), Debug(true))
+	}))
+	flavor.Locate(d, pattern, root)
+	return root, flavor.Pinpoint(d, pattern, parseError(err))
 }
 
 // SupportedFlags returns information about valid inline modifiers for .NET.
 func (d *DotNet) SupportedFlags() []flavor.FlagInfo {
 	return []flavor.FlagInfo{
-		{Char: 'i', Name: "IgnoreCase", Description: "Case-insensitive matching"},
-		{Char: 'm', Name: "Multiline", Description: "^ and $ match at line boundaries"},
-		{Char: 's', Name: "Singleline", Description: ". matches newline characters"},
-		{Char: 'n', Name: "ExplicitCapture", Description: "Only named groups are captured"},
-		{Char: 'x', Name: "IgnorePatternWhitespace", Description: "Ignore unescaped whitespace and allow # comments"},
+		{Char: 'i', Name: "IgnoreCase", Description: "Case-insensitive matching", DocURL: regexOptions + "#case-insensitive-matching"},
+		{Char: 'm', Name: "Multiline", Description: "^ and $ match at line boundaries", DocURL: regexOptions + "#multiline-mode"},
+		{Char: 's', Name: "Singleline", Description: ". matches newline characters", DocURL: regexOptions + "#single-line-mode"},
+		{Char: 'n', Name: "ExplicitCapture", Description: "Only named groups are captured", DocURL: regexOptions + "#explicit-captures-only"},
+		{Char: 'x', Name: "IgnorePatternWhitespace", Description: "Ignore unescaped whitespace and allow # comments", DocURL: regexOptions + "#ignore-white-space"},
+	}
+}
+
+// regexOptions is the .NET guide to RegexOptions and their inline
+// letters.
+const regexOptions = "https://learn.microsoft.com/en-us/dotnet/standard/base-types/regular-expression-options"
+
+// Tokenize splits a .NET pattern into syntax-highlighting tokens.
+func (d *DotNet) Tokenize(pattern string) []flavor.Token {
+	return flavor.TokenizeSyntax(pattern, flavor.Syntax{Perl: true, Comments: true, StringAnchors: true})
+}
+
+// ShorthandSet describes .NET's shorthand classes, which are Unicode
+// unless RegexOptions.ECMAScript is set in code; that option has no
+// inline form, so a pattern alone never turns it on.
+func (d *DotNet) ShorthandSet(code string, root *ast.Regexp) (flavor.ShorthandSet, bool) {
+	switch code {
+	case "d":
+		return flavor.ShorthandSet{Mode: flavor.ShorthandUnicode, Members: `\p{Nd}`}, true
+	case "w":
+		return flavor.ShorthandSet{Mode: flavor.ShorthandUnicode, Members: `[\p{L}\p{Mn}\p{Nd}\p{Pc}]`}, true
+	case "s":
+		return flavor.ShorthandSet{Mode: flavor.ShorthandUnicode, Members: `[\f\n\r\t\v\x85\p{Z}]`}, true
 	}
+	return flavor.ShorthandSet{}, false
 }
 
 // SupportedFeatures returns the feature capabilities of .NET regex.
@@ -60,10 +115,20 @@ func (d *DotNet) SupportedFeatures() flavor.FeatureSet {
 		Comments:              true,
 		BranchReset:           false,
 		BacktrackingControl:   false,
+		Callouts:              false,
+		ScriptRuns:            false,
+		NonAtomicLookaround:   false,
+		PatternStartOptions:   false,
+		UnicodeSets:           false,
+		OctalEscapes:          true,
+		HexBracedEscapes:      false,
+		NamedUnicodeEscapes:   false,
+		RelativeBackrefs:      false,
+		SetOperations:         true, // [a-z-[aeiou]] subtraction
 	}
 }
 
 // init registers the .NET flavor with the registry.
 func init() {
-	flavor.Register(&DotNet{})
+	flavor.MustRegister(&DotNet{})
 }
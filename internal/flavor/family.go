@@ -0,0 +1,65 @@
+package flavor
+
+import "slices"
+
+// Family is a group of flavors that share a pattern syntax, so a
+// pattern written for one member mostly reads the same in the others.
+// Help output lists flavors by family rather than in one flat list.
+type Family string
+
+// The families, in the order help lists them.
+const (
+	FamilyPerl          Family = "Perl-style"     // JavaScript, Java, .NET, PCRE, Go
+	FamilyPOSIXExtended Family = "POSIX extended" // POSIX and GNU ERE
+	FamilyPOSIXBasic    Family = "POSIX basic"    // POSIX and GNU BRE
+	FamilyEditor        Family = "Editor"         // Vim
+	FamilyOther         Family = "Other"          // Flavors that name no family
+)
+
+// families is the listing order of the known families.
+var families = []Family{FamilyPerl, FamilyPOSIXExtended, FamilyPOSIXBasic, FamilyEditor, FamilyOther}
+
+// FamilyMember is implemented by flavors that name their family. It is
+// optional: callers go through FamilyOf, and a flavor without it is
+// listed under FamilyOther.
+type FamilyMember interface {
+	Family() Family
+}
+
+// FamilyOf returns the family f belongs to.
+func FamilyOf(f Flavor) Family {
+	if m, ok := f.(FamilyMember); ok {
+		return m.Family()
+	}
+	return FamilyOther
+}
+
+// FamilyGroup is one family and the names of its registered flavors.
+type FamilyGroup struct {
+	Family  Family
+	Flavors []string // Sorted
+}
+
+// ByFamily returns the registered flavors grouped by family, in listing
+// order, leaving out families with no flavors. A family not among the
+// constants above is listed after the known ones, before FamilyOther.
+func ByFamily() []FamilyGroup {
+	members := make(map[Family][]string)
+	order := slices.Clone(families[:len(families)-1])
+	for _, name := range List() {
+		f, _ := Get(name)
+		fam := FamilyOf(f)
+		if !slices.Contains(families, fam) && !slices.Contains(order, fam) {
+			order = append(order, fam)
+		}
+		members[fam] = append(members[fam], name)
+	}
+	order = append(order, FamilyOther)
+	var groups []FamilyGroup
+	for _, fam := range order {
+		if len(members[fam]) > 0 {
+			groups = append(groups, FamilyGroup{Family: fam, Flavors: members[fam]})
+		}
+	}
+	return groups
+}
@@ -0,0 +1,139 @@
+package flavor_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/0x4d5352/regolith/internal/flavor"
+)
+
+func TestFeatureSetJSON(t *testing.T) {
+	typ := reflect.TypeOf(flavor.FeatureSet{})
+	seen := make(map[string]bool)
+	for i := 0; i < typ.NumField(); i++ {
+		tag := typ.Field(i).Tag.Get("json")
+		if tag == "" || seen[tag] {
+			t.Errorf("field %s needs a unique json tag, got %q", typ.Field(i).Name, tag)
+		}
+		seen[tag] = true
+	}
+
+	data, err := json.Marshal(flavor.All()["pcre"].SupportedFeatures())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]bool
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded["relative_backrefs"] || decoded["set_operations"] {
+		t.Errorf("pcre features = %s", data)
+	}
+}
+
+func TestSupportedFeaturesDetail(t *testing.T) {
+	type detail struct{ octal, hexBraced, named, relative, setOps bool }
+	want := map[string]detail{
+		"javascript":  {octal: true, hexBraced: true, setOps: true},
+		"java":        {octal: true, hexBraced: true, named: true, setOps: true},
+		"dotnet":      {octal: true, setOps: true},
+		"pcre":        {octal: true, hexBraced: true, named: true, relative: true},
+		"posix-bre":   {},
+		"posix-ere":   {},
+		"gnugrep":     {},
+		"gnugrep-bre": {},
+		"gnugrep-ere": {},
+		"golang":      {octal: true, hexBraced: true},
+		"gnused":      {octal: true},
+		"gnused-ere":  {octal: true},
+		"vim":         {octal: true},
+	}
+	for name, f := range flavor.All() {
+		w, ok := want[name]
+		if !ok {
+			t.Errorf("no expectations for flavor %s", name)
+			continue
+		}
+		fs := f.SupportedFeatures()
+		got := detail{fs.OctalEscapes, fs.HexBracedEscapes, fs.NamedUnicodeEscapes, fs.RelativeBackrefs, fs.SetOperations}
+		if got != w {
+			t.Errorf("%s: got %+v, want %+v", name, got, w)
+		}
+	}
+}
+
+func TestFamilies(t *testing.T) {
+	got := make(map[string]flavor.Family)
+	for _, group := range flavor.ByFamily() {
+		if group.Family == flavor.FamilyOther {
+			t.Errorf("flavors without a family: %v", group.Flavors)
+		}
+		for _, name := range group.Flavors {
+			got[name] = group.Family
+		}
+	}
+	for name, want := range map[string]flavor.Family{
+		"pcre":       flavor.FamilyPerl,
+		"golang":     flavor.FamilyPerl,
+		"gnused-ere": flavor.FamilyPOSIXExtended,
+		"gnused":     flavor.FamilyPOSIXBasic,
+		"gnugrep":    flavor.FamilyPOSIXBasic,
+		"vim":        flavor.FamilyEditor,
+	} {
+		if got[name] != want {
+			t.Errorf("%s: family %q, want %q", name, got[name], want)
+		}
+	}
+	if len(got) != flavor.Count() {
+		t.Errorf("ByFamily lists %d flavors, want %d", len(got), flavor.Count())
+	}
+}
+
+func TestDocument(t *testing.T) {
+	typ := reflect.TypeOf(flavor.FeatureSet{})
+	if len(flavor.Features) != typ.NumField() {
+		t.Fatalf("Features documents %d fields, FeatureSet has %d", len(flavor.Features), typ.NumField())
+	}
+	keys := make(map[string]bool)
+	for i, feat := range flavor.Features {
+		if tag := typ.Field(i).Tag.Get("json"); feat.Key != tag {
+			t.Errorf("Features[%d] = %s, want %s", i, feat.Key, tag)
+		}
+		keys[feat.Key] = true
+	}
+
+	for name, f := range flavor.All() {
+		doc := flavor.Document(f, "")
+		if len(doc.Supported)+len(doc.Unsupported)+len(doc.Unread) != len(flavor.Features) {
+			t.Errorf("%s: %d constructs documented", name, len(doc.Supported)+len(doc.Unsupported)+len(doc.Unread))
+		}
+		// Every example shown as supported must parse; constructs the
+		// parser lacks belong in Notes.Unread.
+		for _, c := range doc.Supported {
+			if _, err := f.Parse(c.Example); err != nil {
+				t.Errorf("%s: %s example %q does not parse: %v", name, c.Name, c.Example, err)
+			}
+		}
+		if d, ok := f.(flavor.Documenter); ok {
+			notes := d.Notes()
+			for key := range notes.Examples {
+				if !keys[key] {
+					t.Errorf("%s: example for unknown feature %q", name, key)
+				}
+				if slices.Contains(notes.Unread, key) {
+					t.Errorf("%s: example for unread feature %q", name, key)
+				}
+			}
+			for _, key := range notes.Unread {
+				if !keys[key] {
+					t.Errorf("%s: unknown unread feature %q", name, key)
+				}
+			}
+		}
+		if len(doc.Limitations) == 0 {
+			t.Errorf("%s: no limitations documented", name)
+		}
+	}
+}
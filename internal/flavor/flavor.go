@@ -3,7 +3,10 @@
 package flavor
 
 import (
+	"errors"
+	"fmt"
 	"sort"
+	"strings"
 	"sync"
 
 	"github.com/0x4d5352/regolith/internal/ast"
@@ -36,59 +39,248 @@ type FlagInfo struct {
 	Char        rune   // The flag character (e.g., 'i')
 	Name        string // Human-readable name (e.g., "case-insensitive")
 	Description string // Longer description of what the flag does
+	Label       string // Short text for the diagram's flags panel; Name when empty
+	DocURL      string // The engine's reference documentation for the flag
+
+	// Localized holds translations of Label and Description keyed by
+	// language tag ("de", "pt-BR"). See Text for the fallback order.
+	Localized map[string]FlagText
+}
+
+// FlagText is the user-facing text of a flag in one language.
+type FlagText struct {
+	Label       string
+	Description string
+}
+
+// Text returns the flag's label and description in lang, a language
+// tag or POSIX locale name ("de", "de-AT", "de_AT.UTF-8"). It falls
+// back from the full tag to its base language, then to the untranslated
+// fields, one field at a time, so a translation may leave either empty.
+func (fi FlagInfo) Text(lang string) FlagText {
+	text := FlagText{Label: fi.Label, Description: fi.Description}
+	if text.Label == "" {
+		text.Label = fi.Name
+	}
+	lang, _, _ = strings.Cut(lang, ".")
+	lang = strings.ReplaceAll(lang, "_", "-")
+	base, _, _ := strings.Cut(lang, "-")
+	for _, tag := range []string{base, lang} {
+		t, ok := fi.Localized[tag]
+		if !ok {
+			continue
+		}
+		if t.Label != "" {
+			text.Label = t.Label
+		}
+		if t.Description != "" {
+			text.Description = t.Description
+		}
+	}
+	return text
+}
+
+// DescribeFlags returns f's FlagInfo for each letter of flags, in order,
+// with Label and Description translated into lang (see FlagInfo.Text).
+// A letter f does not list comes back with only Char and Name set, so
+// the flags panel still shows it rather than guessing at a meaning.
+func DescribeFlags(f Flavor, flags, lang string) []FlagInfo {
+	known := f.SupportedFlags()
+	out := make([]FlagInfo, 0, len(flags))
+	for _, c := range flags {
+		info := FlagInfo{Char: c, Name: string(c)}
+		for _, k := range known {
+			if k.Char == c {
+				info = k
+				break
+			}
+		}
+		text := info.Text(lang)
+		info.Label, info.Description = text.Label, text.Description
+		out = append(out, info)
+	}
+	return out
 }
 
 // FeatureSet describes what features a flavor supports.
 // This can be used for documentation and for validating patterns.
+// Fields describe the engine the flavor models, not only what its
+// parser recognizes today, so compatibility checks and translation can
+// rely on them. The JSON tags are a stable machine-readable form.
 type FeatureSet struct {
-	Lookahead             bool // Supports (?=...) and (?!...)
-	Lookbehind            bool // Supports (?<=...) and (?<!...)
-	LookbehindUnlimited   bool // Lookbehind can have variable length (.NET only)
-	NamedGroups           bool // Supports (?<name>...) or (?P<name>...)
-	AtomicGroups          bool // Supports (?>...)
-	PossessiveQuantifiers bool // Supports *+, ++, ?+, {n,m}+
-	RecursivePatterns     bool // Supports (?R), (?1), (?&name)
-	ConditionalPatterns   bool // Supports (?(cond)yes|no)
-	UnicodeProperties     bool // Supports \p{...} and \P{...}
-	POSIXClasses          bool // Supports [:alpha:], [:digit:], etc.
-	BalancedGroups        bool // Supports (?<name-other>...) (.NET only)
-	InlineModifiers       bool // Supports (?i), (?m), etc.
-	Comments              bool // Supports (?#...) comments
-	BranchReset           bool // Supports (?|...)
-	BacktrackingControl   bool // Supports (*PRUNE), (*SKIP), etc.
-	Callouts              bool // Supports (?C), (?Cn), (?C"text")
-	ScriptRuns            bool // Supports (*script_run:...), (*sr:...)
-	NonAtomicLookaround   bool // Supports (?*...), (?<*...), (*napla:...), (*naplb:...)
-	PatternStartOptions   bool // Supports (*UTF), (*LIMIT_MATCH=d), etc.
-	UnicodeSets           bool // Supports v-flag set operations in character classes
-}
-
-// registry holds all registered flavors.
-// It's protected by a mutex for safe concurrent access.
+	Lookahead             bool `json:"lookahead"`              // Supports (?=...) and (?!...)
+	Lookbehind            bool `json:"lookbehind"`             // Supports (?<=...) and (?<!...)
+	LookbehindUnlimited   bool `json:"lookbehind_unlimited"`   // Lookbehind can have variable length (.NET only)
+	NamedGroups           bool `json:"named_groups"`           // Supports (?<name>...) or (?P<name>...)
+	AtomicGroups          bool `json:"atomic_groups"`          // Supports (?>...)
+	PossessiveQuantifiers bool `json:"possessive_quantifiers"` // Supports *+, ++, ?+, {n,m}+
+	RecursivePatterns     bool `json:"recursive_patterns"`     // Supports (?R), (?1), (?&name)
+	ConditionalPatterns   bool `json:"conditional_patterns"`   // Supports (?(cond)yes|no)
+	UnicodeProperties     bool `json:"unicode_properties"`     // Supports \p{...} and \P{...}
+	POSIXClasses          bool `json:"posix_classes"`          // Supports [:alpha:], [:digit:], etc.
+	BalancedGroups        bool `json:"balanced_groups"`        // Supports (?<name-other>...) (.NET only)
+	InlineModifiers       bool `json:"inline_modifiers"`       // Supports (?i), (?m), etc.
+	Comments              bool `json:"comments"`               // Supports (?#...) comments
+	BranchReset           bool `json:"branch_reset"`           // Supports (?|...)
+	BacktrackingControl   bool `json:"backtracking_control"`   // Supports (*PRUNE), (*SKIP), etc.
+	Callouts              bool `json:"callouts"`               // Supports (?C), (?Cn), (?C"text")
+	ScriptRuns            bool `json:"script_runs"`            // Supports (*script_run:...), (*sr:...)
+	NonAtomicLookaround   bool `json:"non_atomic_lookaround"`  // Supports (?*...), (?<*...), (*napla:...), (*naplb:...)
+	PatternStartOptions   bool `json:"pattern_start_options"`  // Supports (*UTF), (*LIMIT_MATCH=d), etc.
+	UnicodeSets           bool `json:"unicode_sets"`           // Supports v-flag set operations in character classes
+	OctalEscapes          bool `json:"octal_escapes"`          // Supports octal escapes: \012, \o{12}
+	HexBracedEscapes      bool `json:"hex_braced_escapes"`     // Supports \x{263A} (JavaScript: \u{263A} in u/v mode)
+	NamedUnicodeEscapes   bool `json:"named_unicode_escapes"`  // Supports \N{name} (Java) or \N{U+263A} (PCRE2)
+	RelativeBackrefs      bool `json:"relative_backrefs"`      // Supports \g{-1}, \g-1
+	SetOperations         bool `json:"set_operations"`         // Supports class intersection/subtraction: &&, --, -[...]
+}
+
+// registry holds all registered flavors by name, and aliases each
+// alternative name. Both are protected by registryLock, so flavors can
+// be registered and looked up from several goroutines at once.
 var (
 	registry     = make(map[string]Flavor)
+	aliases      = make(map[string]alias)
 	registryLock sync.RWMutex
 )
 
-// Register adds a flavor to the registry.
-// If a flavor with the same name is already registered, it is replaced.
-// This function is typically called from init() functions in flavor packages.
-func Register(f Flavor) {
+// alias is an entry of the alias table.
+type alias struct {
+	flavor     string // The name of the flavor it stands for
+	deprecated string // Why the alias should no longer be used; "" if it may
+}
+
+// ErrConflict is returned by Register and RegisterAlias for a name that
+// is already taken by a flavor or an alias, and ErrNotRegistered by
+// Deregister and RegisterAlias for a name that is neither.
+var (
+	ErrConflict      = errors.New("flavor name already registered")
+	ErrNotRegistered = errors.New("flavor not registered")
+)
+
+// checkName reports whether name can be registered: it must be free,
+// non-empty, and free of spaces and commas, which would make it
+// unusable in a --flavor list. The caller holds registryLock.
+func checkName(name string) error {
+	if name == "" || strings.ContainsAny(name, " ,\t\n") {
+		return fmt.Errorf("invalid flavor name %q", name)
+	}
+	if _, ok := registry[name]; ok {
+		return fmt.Errorf("%w: %q", ErrConflict, name)
+	}
+	if a, ok := aliases[name]; ok {
+		return fmt.Errorf("%w: %q (an alias for %s)", ErrConflict, name, a.flavor)
+	}
+	return nil
+}
+
+// Register adds a flavor to the registry under f.Name(). It fails with
+// ErrConflict when the name is already taken by a flavor or an alias;
+// Deregister the old one first to replace it.
+func Register(f Flavor) error {
 	registryLock.Lock()
 	defer registryLock.Unlock()
+	if err := checkName(f.Name()); err != nil {
+		return err
+	}
 	registry[f.Name()] = f
+	return nil
+}
+
+// MustRegister is Register for the init() functions in flavor packages,
+// where a conflict is a programming error: it panics instead of
+// returning the error.
+func MustRegister(f Flavor) {
+	if err := Register(f); err != nil {
+		panic(err)
+	}
 }
 
-// Get retrieves a flavor by name.
+// RegisterAlias makes alias another name for the flavor registered as
+// name, which may itself be an alias. Get resolves it; List does not
+// include it.
+func RegisterAlias(alias, name string) error {
+	return registerAlias(alias, name, "")
+}
+
+// RegisterDeprecatedAlias is RegisterAlias for a name kept working only
+// so existing command lines and configs do not break, such as the old
+// name of a renamed flavor. note says what to use instead; Deprecated
+// reports it so callers can warn.
+func RegisterDeprecatedAlias(alias, name, note string) error {
+	if note == "" {
+		return fmt.Errorf("deprecated alias %q needs a note", alias)
+	}
+	return registerAlias(alias, name, note)
+}
+
+func registerAlias(name, target, deprecated string) error {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	target, ok := resolve(target)
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrNotRegistered, target)
+	}
+	if err := checkName(name); err != nil {
+		return err
+	}
+	aliases[name] = alias{flavor: target, deprecated: deprecated}
+	return nil
+}
+
+// MustRegisterAlias is RegisterAlias for init() functions; it panics
+// instead of returning the error.
+func MustRegisterAlias(alias, name string) {
+	if err := RegisterAlias(alias, name); err != nil {
+		panic(err)
+	}
+}
+
+// Deregister removes name from the registry. For a flavor, its aliases
+// go with it; for an alias, only the alias is removed.
+func Deregister(name string) error {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	if _, ok := aliases[name]; ok {
+		delete(aliases, name)
+		return nil
+	}
+	if _, ok := registry[name]; !ok {
+		return fmt.Errorf("%w: %q", ErrNotRegistered, name)
+	}
+	delete(registry, name)
+	for a, entry := range aliases {
+		if entry.flavor == name {
+			delete(aliases, a)
+		}
+	}
+	return nil
+}
+
+// resolve returns the flavor name that name, a flavor name or an alias,
+// refers to. The caller holds registryLock.
+func resolve(name string) (string, bool) {
+	if a, ok := aliases[name]; ok {
+		name = a.flavor
+	}
+	_, ok := registry[name]
+	return name, ok
+}
+
+// Get retrieves a flavor by name or alias.
 // Returns nil, false if the flavor is not registered.
 func Get(name string) (Flavor, bool) {
 	registryLock.RLock()
 	defer registryLock.RUnlock()
-	f, ok := registry[name]
-	return f, ok
+	name, ok := resolve(name)
+	if !ok {
+		return nil, false
+	}
+	return registry[name], true
 }
 
-// List returns all registered flavor names in sorted order.
+// List returns all registered flavor names in sorted order. Aliases are
+// not included; see Aliases.
 func List() []string {
 	registryLock.RLock()
 	defer registryLock.RUnlock()
@@ -100,6 +292,41 @@ func List() []string {
 	return names
 }
 
+// Aliases returns a copy of the alias table, mapping each alias,
+// deprecated ones included, to the name of its flavor.
+func Aliases() map[string]string {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	result := make(map[string]string, len(aliases))
+	for a, entry := range aliases {
+		result[a] = entry.flavor
+	}
+	return result
+}
+
+// AliasesOf returns the aliases of the flavor registered as name that
+// are not deprecated, sorted, for listing next to its name.
+func AliasesOf(name string) []string {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	var result []string
+	for a, entry := range aliases {
+		if entry.flavor == name && entry.deprecated == "" {
+			result = append(result, a)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Deprecated returns the note of name when it is a deprecated alias.
+func Deprecated(name string) (string, bool) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	a, ok := aliases[name]
+	return a.deprecated, ok && a.deprecated != ""
+}
+
 // All returns all registered flavors as a map.
 // The returned map is a copy, so modifications won't affect the registry.
 func All() map[string]Flavor {
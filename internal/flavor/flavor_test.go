@@ -1,6 +1,9 @@
 package flavor
 
 import (
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/0x4d5352/regolith/internal/ast"
@@ -165,27 +168,231 @@ func TestCount(t *testing.T) {
 	}
 }
 
-func TestRegisterOverwrite(t *testing.T) {
-	// Clear registry for test
+// swapRegistry empties the registry and its aliases for the rest of
+// the test, restoring them when it ends.
+func swapRegistry(t *testing.T) {
+	t.Helper()
 	registryLock.Lock()
-	originalRegistry := registry
-	registry = make(map[string]Flavor)
+	originalRegistry, originalAliases := registry, aliases
+	registry, aliases = make(map[string]Flavor), make(map[string]alias)
 	registryLock.Unlock()
-	defer func() {
+	t.Cleanup(func() {
 		registryLock.Lock()
-		registry = originalRegistry
+		registry, aliases = originalRegistry, originalAliases
 		registryLock.Unlock()
-	}()
+	})
+}
 
-	Register(&mockFlavor{name: "test", description: "Original"})
-	Register(&mockFlavor{name: "test", description: "Updated"})
+func TestRegisterConflict(t *testing.T) {
+	swapRegistry(t)
 
-	if Count() != 1 {
-		t.Error("expected count 1 after overwriting")
+	if err := Register(&mockFlavor{name: "test", description: "Original"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := Register(&mockFlavor{name: "test", description: "Updated"}); !errors.Is(err, ErrConflict) {
+		t.Errorf("registering a taken name: got %v, want ErrConflict", err)
+	}
+	if f, _ := Get("test"); f.Description() != "Original" {
+		t.Errorf("a conflicting Register replaced the flavor: %q", f.Description())
+	}
+	if err := Register(&mockFlavor{name: "a,b"}); err == nil {
+		t.Error("Register accepted a name with a comma")
 	}
 
-	f, _ := Get("test")
-	if f.Description() != "Updated" {
+	// Deregistering frees the name for a replacement.
+	if err := Deregister("test"); err != nil {
+		t.Fatalf("Deregister: %v", err)
+	}
+	if err := Register(&mockFlavor{name: "test", description: "Updated"}); err != nil {
+		t.Fatalf("Register after Deregister: %v", err)
+	}
+	if f, _ := Get("test"); f.Description() != "Updated" {
 		t.Errorf("expected description 'Updated', got '%s'", f.Description())
 	}
+	if err := Deregister("missing"); !errors.Is(err, ErrNotRegistered) {
+		t.Errorf("Deregister(missing): got %v, want ErrNotRegistered", err)
+	}
+}
+
+func TestRegisterAlias(t *testing.T) {
+	swapRegistry(t)
+	MustRegister(&mockFlavor{name: "javascript"})
+	MustRegister(&mockFlavor{name: "pcre"})
+
+	if err := RegisterAlias("js", "javascript"); err != nil {
+		t.Fatalf("RegisterAlias: %v", err)
+	}
+	// An alias of an alias points at the flavor itself.
+	if err := RegisterAlias("ecmascript", "js"); err != nil {
+		t.Fatalf("RegisterAlias(ecmascript, js): %v", err)
+	}
+	for _, name := range []string{"js", "ecmascript"} {
+		if f, ok := Get(name); !ok || f.Name() != "javascript" {
+			t.Errorf("Get(%q) = %v, %v; want javascript", name, f, ok)
+		}
+	}
+	if got := Aliases()["ecmascript"]; got != "javascript" {
+		t.Errorf("Aliases()[ecmascript] = %q, want javascript", got)
+	}
+	if list := List(); len(list) != 2 {
+		t.Errorf("List() = %v, want only the flavors", list)
+	}
+
+	for _, tc := range []struct {
+		alias, name string
+		want        error
+	}{
+		{"js", "pcre", ErrConflict},
+		{"pcre", "javascript", ErrConflict},
+		{"py", "python", ErrNotRegistered},
+	} {
+		if err := RegisterAlias(tc.alias, tc.name); !errors.Is(err, tc.want) {
+			t.Errorf("RegisterAlias(%q, %q) = %v, want %v", tc.alias, tc.name, err, tc.want)
+		}
+	}
+	if err := Register(&mockFlavor{name: "js"}); !errors.Is(err, ErrConflict) {
+		t.Errorf("registering a flavor over an alias: got %v, want ErrConflict", err)
+	}
+
+	// Removing an alias leaves its flavor; removing a flavor takes its
+	// aliases with it.
+	if err := Deregister("js"); err != nil {
+		t.Fatalf("Deregister(js): %v", err)
+	}
+	if _, ok := Get("javascript"); !ok {
+		t.Error("deregistering an alias removed its flavor")
+	}
+	if err := Deregister("javascript"); err != nil {
+		t.Fatalf("Deregister(javascript): %v", err)
+	}
+	if _, ok := Get("ecmascript"); ok {
+		t.Error("deregistering a flavor kept its alias")
+	}
+}
+
+func TestDeprecatedAlias(t *testing.T) {
+	swapRegistry(t)
+	MustRegister(&mockFlavor{name: "python"})
+	MustRegisterAlias("py", "python")
+
+	if err := RegisterDeprecatedAlias("python2", "python", "use python"); err != nil {
+		t.Fatalf("RegisterDeprecatedAlias: %v", err)
+	}
+	if f, ok := Get("python2"); !ok || f.Name() != "python" {
+		t.Errorf("Get(python2) = %v, %v; want python", f, ok)
+	}
+	if note, ok := Deprecated("python2"); !ok || note != "use python" {
+		t.Errorf("Deprecated(python2) = %q, %v", note, ok)
+	}
+	for _, name := range []string{"py", "python", "missing"} {
+		if _, ok := Deprecated(name); ok {
+			t.Errorf("Deprecated(%q) reported a deprecation", name)
+		}
+	}
+	if got := AliasesOf("python"); len(got) != 1 || got[0] != "py" {
+		t.Errorf("AliasesOf(python) = %v, want [py] without the deprecated name", got)
+	}
+	if err := RegisterDeprecatedAlias("python1", "python", ""); err == nil {
+		t.Error("RegisterDeprecatedAlias accepted an empty note")
+	}
+}
+
+func TestByFamily(t *testing.T) {
+	swapRegistry(t)
+	MustRegister(&familyFlavor{mockFlavor{name: "vim"}, FamilyEditor})
+	MustRegister(&familyFlavor{mockFlavor{name: "pcre"}, FamilyPerl})
+	MustRegister(&familyFlavor{mockFlavor{name: "java"}, FamilyPerl})
+	MustRegister(&familyFlavor{mockFlavor{name: "lua"}, "Lua patterns"})
+	MustRegister(&mockFlavor{name: "plugin"})
+
+	var got []string
+	for _, g := range ByFamily() {
+		got = append(got, fmt.Sprintf("%s: %v", g.Family, g.Flavors))
+	}
+	want := []string{"Perl-style: [java pcre]", "Editor: [vim]", "Lua patterns: [lua]", "Other: [plugin]"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("ByFamily() = %v, want %v", got, want)
+	}
+}
+
+// familyFlavor is a mockFlavor that names its family.
+type familyFlavor struct {
+	mockFlavor
+	family Family
+}
+
+func (f *familyFlavor) Family() Family { return f.family }
+
+func TestRegistryConcurrent(t *testing.T) {
+	swapRegistry(t)
+	MustRegister(&mockFlavor{name: "base"})
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			name := fmt.Sprintf("flavor-%d", i)
+			for range 100 {
+				if err := Register(&mockFlavor{name: name}); err != nil {
+					t.Error(err)
+					return
+				}
+				if err := RegisterAlias(name+"-alias", name); err != nil {
+					t.Error(err)
+					return
+				}
+				if _, ok := Get(name + "-alias"); !ok {
+					t.Errorf("Get(%s-alias) failed", name)
+				}
+				_ = List()
+				_, _ = Get("base")
+				if err := Deregister(name); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if Count() != 1 || len(Aliases()) != 0 {
+		t.Errorf("left %v and aliases %v, want only base", List(), Aliases())
+	}
+}
+
+func TestFlagInfoText(t *testing.T) {
+	fi := FlagInfo{
+		Char: 'i', Name: "ignoreCase", Description: "Case-insensitive matching",
+		Localized: map[string]FlagText{
+			"pt":    {Label: "ignorar maiúsculas", Description: "Sem distinção de maiúsculas"},
+			"pt-BR": {Description: "Ignora maiúsculas e minúsculas"},
+		},
+	}
+	tests := []struct {
+		lang string
+		want FlagText
+	}{
+		{"", FlagText{"ignoreCase", "Case-insensitive matching"}},
+		{"fr", FlagText{"ignoreCase", "Case-insensitive matching"}},
+		{"pt", FlagText{"ignorar maiúsculas", "Sem distinção de maiúsculas"}},
+		{"pt_PT.UTF-8", FlagText{"ignorar maiúsculas", "Sem distinção de maiúsculas"}},
+		{"pt_BR.UTF-8", FlagText{"ignorar maiúsculas", "Ignora maiúsculas e minúsculas"}},
+	}
+	for _, tt := range tests {
+		if got := fi.Text(tt.lang); got != tt.want {
+			t.Errorf("Text(%q) = %+v, want %+v", tt.lang, got, tt.want)
+		}
+	}
+
+	fi.Label = "ignore case"
+	if got := fi.Text("en").Label; got != "ignore case" {
+		t.Errorf("Label should override Name, got %q", got)
+	}
+}
+
+func TestDescribeFlagsUnknownLetter(t *testing.T) {
+	got := DescribeFlags(&mockFlavor{name: "mock"}, "q", "")
+	if len(got) != 1 || got[0].Label != "q" || got[0].Description != "" {
+		t.Errorf("unknown letter should come back bare, got %+v", got)
+	}
 }
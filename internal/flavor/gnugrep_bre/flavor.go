@@ -10,6 +10,8 @@
 package gnugrep_bre
 
 import (
+	"io"
+
 	"github.com/0x4d5352/regolith/internal/ast"
 	"github.com/0x4d5352/regolith/internal/flavor"
 	"github.com/0x4d5352/regolith/internal/flavor/helpers"
@@ -36,10 +38,41 @@ func (g *GNUGrepBRE) Description() string {
 	return "GNU grep Basic Regular Expressions (BRE with GNU extensions)"
 }
 
+// Family returns the flavor's syntax family.
+func (g *GNUGrepBRE) Family() flavor.Family {
+	return flavor.FamilyPOSIXBasic
+}
+
+// Notes returns what `regolith flavors` says about the flavor beyond
+// its FeatureSet.
+func (g *GNUGrepBRE) Notes() flavor.Notes {
+	return flavor.Notes{
+		Limitations: []string{
+			"Equivalence classes [[=a=]] and collating symbols [[.a.]] are rejected",
+			"Options such as -i, -w and -x are given outside the pattern and are not visible",
+		},
+		Notes: []string{
+			"For grep -P, use the pcre flavor",
+		},
+	}
+}
+
 // Parse parses a GNU BRE pattern and returns an AST.
 func (g *GNUGrepBRE) Parse(pattern string) (*ast.Regexp, error) {
 	state := ast.NewParserState()
-	return helpers.FinalizeParse(Parse("", []byte(pattern), GlobalStore("state", state)))
+	root, err := helpers.FinalizeParse(Parse("", []byte(pattern), GlobalStore("state", state)))
+	flavor.Locate(g, pattern, root)
+	return root, flavor.Pinpoint(g, pattern, parseError(err))
+}
+
+// ParseTrace is Parse with the grammar's rule trace written to w.
+func (g *GNUGrepBRE) ParseTrace(pattern string, w io.Writer) (*ast.Regexp, error) {
+	state := ast.NewParserState()
+	root, err := helpers.FinalizeParse(helpers.TraceParse(w, func() (any, error) {
+		return Parse("", []byte(pattern), GlobalStore("state", state), Debug(true))
+	}))
+	flavor.Locate(g, pattern, root)
+	return root, flavor.Pinpoint(g, pattern, parseError(err))
 }
 
 // SupportedFlags returns information about valid flags for GNU grep BRE.
@@ -48,6 +81,21 @@ func (g *GNUGrepBRE) SupportedFlags() []flavor.FlagInfo {
 	return []flavor.FlagInfo{}
 }
 
+// Tokenize splits a GNU BRE pattern into syntax-highlighting tokens.
+func (g *GNUGrepBRE) Tokenize(pattern string) []flavor.Token {
+	return flavor.TokenizeSyntax(pattern, flavor.Syntax{BRE: true, GNU: true})
+}
+
+// ParseHints adds a reminder of GNU BRE's backslashed operators to the
+// generic parse hints when the pattern uses the bare ones, which GNU
+// BRE reads as literals.
+func (g *GNUGrepBRE) ParseHints(pattern string) []string {
+	if !flavor.LiteralsContain(g.Tokenize(pattern), "()|+?") {
+		return nil
+	}
+	return []string{"in gnugrep-bre ( ) { } + ? | are literal characters: use \\( \\) for groups, \\{ \\} for intervals, and \\+ \\? \\| for the operators, or try --flavor gnugrep-ere"}
+}
+
 // SupportedFeatures returns the feature capabilities of GNU grep BRE.
 func (g *GNUGrepBRE) SupportedFeatures() flavor.FeatureSet {
 	return flavor.FeatureSet{
@@ -66,12 +114,23 @@ func (g *GNUGrepBRE) SupportedFeatures() flavor.FeatureSet {
 		Comments:              false,
 		BranchReset:           false,
 		BacktrackingControl:   false,
+		Callouts:              false,
+		ScriptRuns:            false,
+		NonAtomicLookaround:   false,
+		PatternStartOptions:   false,
+		UnicodeSets:           false,
+		OctalEscapes:          false,
+		HexBracedEscapes:      false,
+		NamedUnicodeEscapes:   false,
+		RelativeBackrefs:      false,
+		SetOperations:         false,
 	}
 }
 
 // init registers the GNU grep BRE flavor with the registry.
 // Registers as both "gnugrep" (default) and "gnugrep-bre" (explicit).
 func init() {
-	flavor.Register(&GNUGrepBRE{name: "gnugrep"})
-	flavor.Register(&GNUGrepBRE{name: "gnugrep-bre"})
+	flavor.MustRegister(&GNUGrepBRE{name: "gnugrep"})
+	flavor.MustRegister(&GNUGrepBRE{name: "gnugrep-bre"})
+	flavor.MustRegisterAlias("grep", "gnugrep")
 }
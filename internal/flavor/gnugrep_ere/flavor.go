@@ -8,6 +8,8 @@
 package gnugrep_ere
 
 import (
+	"io"
+
 	"github.com/0x4d5352/regolith/internal/ast"
 	"github.com/0x4d5352/regolith/internal/flavor"
 	"github.com/0x4d5352/regolith/internal/flavor/helpers"
@@ -29,10 +31,41 @@ func (g *GNUGrepERE) Description() string {
 	return "GNU grep Extended Regular Expressions (ERE with GNU extensions, like grep -E)"
 }
 
+// Family returns the flavor's syntax family.
+func (g *GNUGrepERE) Family() flavor.Family {
+	return flavor.FamilyPOSIXExtended
+}
+
+// Notes returns what `regolith flavors` says about the flavor beyond
+// its FeatureSet.
+func (g *GNUGrepERE) Notes() flavor.Notes {
+	return flavor.Notes{
+		Limitations: []string{
+			"Equivalence classes [[=a=]] and collating symbols [[.a.]] are rejected",
+			"Options such as -i, -w and -x are given outside the pattern and are not visible",
+		},
+		Notes: []string{
+			"For grep -P, use the pcre flavor",
+		},
+	}
+}
+
 // Parse parses a GNU ERE pattern and returns an AST.
 func (g *GNUGrepERE) Parse(pattern string) (*ast.Regexp, error) {
 	state := ast.NewParserState()
-	return helpers.FinalizeParse(Parse("", []byte(pattern), GlobalStore("state", state)))
+	root, err := helpers.FinalizeParse(Parse("", []byte(pattern), GlobalStore("state", state)))
+	flavor.Locate(g, pattern, root)
+	return root, flavor.Pinpoint(g, pattern, parseError(err))
+}
+
+// ParseTrace is Parse with the grammar's rule trace written to w.
+func (g *GNUGrepERE) ParseTrace(pattern string, w io.Writer) (*ast.Regexp, error) {
+	state := ast.NewParserState()
+	root, err := helpers.FinalizeParse(helpers.TraceParse(w, func() (any, error) {
+		return Parse("", []byte(pattern), GlobalStore("state", state), Debug(true))
+	}))
+	flavor.Locate(g, pattern, root)
+	return root, flavor.Pinpoint(g, pattern, parseError(err))
 }
 
 // SupportedFlags returns information about valid flags for GNU grep ERE.
@@ -41,6 +74,11 @@ func (g *GNUGrepERE) SupportedFlags() []flavor.FlagInfo {
 	return []flavor.FlagInfo{}
 }
 
+// Tokenize splits a GNU ERE pattern into syntax-highlighting tokens.
+func (g *GNUGrepERE) Tokenize(pattern string) []flavor.Token {
+	return flavor.TokenizeSyntax(pattern, flavor.Syntax{GNU: true})
+}
+
 // SupportedFeatures returns the feature capabilities of GNU grep ERE.
 func (g *GNUGrepERE) SupportedFeatures() flavor.FeatureSet {
 	return flavor.FeatureSet{
@@ -59,10 +97,20 @@ func (g *GNUGrepERE) SupportedFeatures() flavor.FeatureSet {
 		Comments:              false,
 		BranchReset:           false,
 		BacktrackingControl:   false,
+		Callouts:              false,
+		ScriptRuns:            false,
+		NonAtomicLookaround:   false,
+		PatternStartOptions:   false,
+		UnicodeSets:           false,
+		OctalEscapes:          false,
+		HexBracedEscapes:      false,
+		NamedUnicodeEscapes:   false,
+		RelativeBackrefs:      false,
+		SetOperations:         false,
 	}
 }
 
 // init registers the GNU grep ERE flavor with the registry.
 func init() {
-	flavor.Register(&GNUGrepERE{})
+	flavor.MustRegister(&GNUGrepERE{})
 }
@@ -1,8 +1,11 @@
 package gnugrep_ere
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
+
+	"github.com/0x4d5352/regolith/internal/flavor"
 )
 
 // parseInt converts a PEG match result to an integer.
@@ -25,3 +28,22 @@ func parseInt(match any) int {
 	}
 	return n
 }
+
+// parseError turns the generated parser's error into a
+// *flavor.ParseError carrying the first error's position and the
+// alternatives the grammar expected there. Other errors pass through.
+func parseError(err error) error {
+	var list errList
+	var pe *parserError
+	if !errors.As(err, &list) || len(list) == 0 || !errors.As(list[0], &pe) {
+		return err
+	}
+	return &flavor.ParseError{
+		Offset:   pe.pos.offset,
+		Line:     pe.pos.line,
+		Col:      pe.pos.col,
+		Message:  pe.Inner.Error(),
+		Expected: pe.expected,
+		Err:      pe.Inner,
+	}
+}
@@ -0,0 +1,184 @@
+// Package gnused implements the regular expressions of GNU sed. They
+// are GNU grep's BRE (or, with sed -E, its ERE) as sed reads them out
+// of a script: between delimiters, where \/ is a literal slash and \n a
+// newline, with sed's own escapes such as \t, \cX and \x41 on top and
+// the I and M flags after the closing delimiter. A pattern may be given
+// as an address (/regex/I), a substitution (s|regex|replacement|g) or a
+// bare regex.
+package gnused
+
+import (
+	"strings"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+	"github.com/0x4d5352/regolith/internal/flavor"
+	"github.com/0x4d5352/regolith/internal/flavor/helpers"
+)
+
+// GNUSed is the GNU sed flavor implementation.
+type GNUSed struct {
+	name string // "gnused" or "gnused-ere"
+	ere  bool   // sed -E (or -r)
+}
+
+// Ensure GNUSed implements the Flavor interface.
+var _ flavor.Flavor = (*GNUSed)(nil)
+
+// Name returns the flavor identifier.
+func (g *GNUSed) Name() string {
+	return g.name
+}
+
+// Description returns a human-readable description.
+func (g *GNUSed) Description() string {
+	if g.ere {
+		return "GNU sed -E (ERE with GNU extensions, /regex/ and s/// syntax)"
+	}
+	return "GNU sed default mode (BRE with GNU extensions, /regex/ and s/// syntax)"
+}
+
+// Family returns the flavor's syntax family: gnused-ere is sed -E.
+func (g *GNUSed) Family() flavor.Family {
+	if g.ere {
+		return flavor.FamilyPOSIXExtended
+	}
+	return flavor.FamilyPOSIXBasic
+}
+
+// Notes returns what `regolith flavors` says about the flavor beyond
+// its FeatureSet.
+func (g *GNUSed) Notes() flavor.Notes {
+	return flavor.Notes{
+		Examples: map[string]string{
+			"octal_escapes": `\o101`,
+		},
+		Limitations: []string{
+			"An empty regex (//), which reuses the last one sed ran, and an address range (/a/,/b/) are rejected",
+			"An s command's replacement and its flags other than I and M are checked but not drawn",
+		},
+		Notes: []string{
+			"A pattern may be a bare regex, an address such as /regex/I, or a whole s command",
+		},
+	}
+}
+
+// Parse parses a sed address, s command or bare regex and returns the
+// regex's AST. The I and M flags are recorded; an s command's other
+// flags and its replacement are checked the way sed checks them, but
+// only the regex is drawn.
+func (g *GNUSed) Parse(pattern string) (*ast.Regexp, error) {
+	root, err := helpers.FinalizeParse(parse(pattern, g.ere))
+	flavor.Locate(g, pattern, root)
+	return root, flavor.Pinpoint(g, pattern, err)
+}
+
+// sedDocs is the reference for sed's regular expressions.
+const sedDocs = "https://www.gnu.org/software/sed/manual/html_node/"
+
+// SupportedFlags returns the flags an address or s command can end
+// with that change what the regex matches. s also takes i and m for
+// them.
+func (g *GNUSed) SupportedFlags() []flavor.FlagInfo {
+	return []flavor.FlagInfo{
+		{Char: 'I', Name: "case-insensitive", Description: "match without regard to case", DocURL: sedDocs + "Regexp-Addresses.html"},
+		{Char: 'M', Name: "multi-line", Description: "^ and $ match at each line in the pattern space, and . and [^...] skip newlines", DocURL: sedDocs + "Regexp-Addresses.html"},
+	}
+}
+
+// Tokenize splits a sed pattern into syntax-highlighting tokens. The
+// delimiters, replacement and flags of an address or s command are
+// delimiter and literal tokens around the regex's own.
+func (g *GNUSed) Tokenize(pattern string) []flavor.Token {
+	s, _ := split(pattern)
+	var tokens []flavor.Token
+	emit := func(kind flavor.TokenKind, start, end int) {
+		if start < end {
+			tokens = append(tokens, flavor.Token{Kind: kind, Text: pattern[start:end], Offset: start})
+		}
+	}
+	emit(flavor.TokenDelimiter, 0, s.start)
+	for _, tok := range flavor.TokenizeSyntax(pattern[s.start:s.end], flavor.Syntax{BRE: !g.ere, GNU: true}) {
+		tok.Offset += s.start
+		tokens = append(tokens, tok)
+	}
+	if s.command {
+		emit(flavor.TokenDelimiter, s.end, s.repl[0])
+		emit(flavor.TokenLiteral, s.repl[0], s.repl[1])
+		emit(flavor.TokenDelimiter, s.repl[1], len(pattern))
+	} else {
+		emit(flavor.TokenDelimiter, s.end, len(pattern))
+	}
+	return tokens
+}
+
+// ParseHints explains the mistakes sed scripts are known for: bare
+// operators in a BRE, a \1 in the replacement with no \( \) group
+// for it, and a delimiter inside a bracket expression, which still ends
+// the regex.
+func (g *GNUSed) ParseHints(pattern string) []string {
+	s, _ := split(pattern)
+	re := pattern[s.start:s.end]
+	var hints []string
+	if !g.ere && flavor.LiteralsContain(flavor.TokenizeSyntax(re, flavor.Syntax{BRE: true, GNU: true}), "()|+?") {
+		if s.command && strings.Contains(re, "(") && !strings.Contains(re, `\(`) && refersToGroup(pattern[s.repl[0]:s.repl[1]]) {
+			hints = append(hints, "the replacement's \\1 refers to a \\( \\) group, and in gnused ( ) are literal characters: write \\(...\\) or try --flavor gnused-ere (sed -E)")
+		} else {
+			hints = append(hints, "in gnused ( ) { } + ? | are literal characters: use \\( \\) for groups, \\{ \\} for intervals, and \\+ \\? \\| for the operators, or try --flavor gnused-ere (sed -E)")
+		}
+	}
+	if s.delim != 0 && strings.Count(re, "[") > strings.Count(re, "]") {
+		hints = append(hints, "sed ends the regex at the first unescaped "+string(s.delim)+", even inside [ ]: write \\"+string(s.delim)+" or pick another delimiter, as in s|...|...|")
+	}
+	return hints
+}
+
+// refersToGroup reports whether a replacement uses \1 to \9.
+func refersToGroup(repl string) bool {
+	for i := 0; i+1 < len(repl); i++ {
+		if repl[i] == '\\' {
+			if c := repl[i+1]; c >= '1' && c <= '9' {
+				return true
+			}
+			i++
+		}
+	}
+	return false
+}
+
+// SupportedFeatures returns the feature capabilities of GNU sed.
+func (g *GNUSed) SupportedFeatures() flavor.FeatureSet {
+	return flavor.FeatureSet{
+		Lookahead:             false,
+		Lookbehind:            false,
+		LookbehindUnlimited:   false,
+		NamedGroups:           false,
+		AtomicGroups:          false,
+		PossessiveQuantifiers: false,
+		RecursivePatterns:     false,
+		ConditionalPatterns:   false,
+		UnicodeProperties:     false,
+		POSIXClasses:          true,
+		BalancedGroups:        false,
+		InlineModifiers:       false,
+		Comments:              false,
+		BranchReset:           false,
+		BacktrackingControl:   false,
+		Callouts:              false,
+		ScriptRuns:            false,
+		NonAtomicLookaround:   false,
+		PatternStartOptions:   false,
+		UnicodeSets:           false,
+		OctalEscapes:          true, // \o101
+		HexBracedEscapes:      false,
+		NamedUnicodeEscapes:   false,
+		RelativeBackrefs:      false,
+		SetOperations:         false,
+	}
+}
+
+// init registers the GNU sed flavors, for sed's default BRE and for
+// sed -E.
+func init() {
+	flavor.MustRegister(&GNUSed{name: "gnused"})
+	flavor.MustRegister(&GNUSed{name: "gnused-ere", ere: true})
+}
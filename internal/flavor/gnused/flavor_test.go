@@ -0,0 +1,245 @@
+package gnused
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+	"github.com/0x4d5352/regolith/internal/flavor"
+)
+
+func TestGNUSedFlavorRegistered(t *testing.T) {
+	for _, name := range []string{"gnused", "gnused-ere"} {
+		f, ok := flavor.Get(name)
+		if !ok {
+			t.Fatalf("%s flavor not registered", name)
+		}
+		if f.Name() != name {
+			t.Errorf("expected name '%s', got '%s'", name, f.Name())
+		}
+	}
+}
+
+func TestGNUSedFlavorSupportedFlags(t *testing.T) {
+	var chars []byte
+	for _, f := range (&GNUSed{name: "gnused"}).SupportedFlags() {
+		chars = append(chars, byte(f.Char))
+	}
+	if string(chars) != "IM" {
+		t.Errorf("expected flags IM, got %s", chars)
+	}
+}
+
+// TestGNUSedScriptForms checks that a regex parses to the same tree,
+// positions aside, bare, as an address and inside an s command, and
+// that the flags after the delimiter are recorded.
+func TestGNUSedScriptForms(t *testing.T) {
+	g := &GNUSed{name: "gnused"}
+	tests := []struct {
+		forms []string
+		flags string
+	}{
+		{[]string{`^a\(b\|c\)*$`, `/^a\(b\|c\)*$/`, `s/^a\(b\|c\)*$/x/`, `s#^a\(b\|c\)*$#x#g`}, ""},
+		{[]string{`/usr/bin`, `\%/usr/bin%I`, `s|/usr/bin|/opt|i`, `/\/usr\/bin/I`}, "I"},
+		{[]string{`^x`, `/^x/IM`, `s/^x/y/3iMw out.txt`}, "IM"},
+	}
+	for _, tc := range tests {
+		want, err := g.Parse(tc.forms[0])
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", tc.forms[0], err)
+		}
+		want.Flags = tc.flags
+		ast.ClearPositions(want)
+		for _, p := range tc.forms[1:] {
+			got, err := g.Parse(p)
+			if err != nil {
+				t.Errorf("unexpected error for %q: %v", p, err)
+				continue
+			}
+			ast.ClearPositions(got)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%q parses differently from %q with flags %q", p, tc.forms[0], tc.flags)
+			}
+		}
+	}
+}
+
+func TestGNUSedParseNodes(t *testing.T) {
+	bre := &GNUSed{name: "gnused"}
+	ere := &GNUSed{name: "gnused-ere", ere: true}
+	parse := func(t *testing.T, f *GNUSed, pattern string) []*ast.MatchFragment {
+		t.Helper()
+		result, err := f.Parse(pattern)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", pattern, err)
+		}
+		return result.Matches[0].Fragments
+	}
+	literal := func(t *testing.T, n ast.Node, want string) {
+		t.Helper()
+		if lit, ok := n.(*ast.Literal); !ok || lit.Text != want {
+			t.Errorf("expected literal %q, got %#v", want, n)
+		}
+	}
+
+	t.Run("escaped delimiter is literal", func(t *testing.T) {
+		// \| would be alternation, but | delimits this command.
+		literal(t, parse(t, bre, `s|a\|b|x|`)[0].Content, "a|b")
+		literal(t, parse(t, ere, `s|a\|b|x|`)[0].Content, "a|b")
+	})
+
+	t.Run("BRE anchors depend on context", func(t *testing.T) {
+		literal(t, parse(t, bre, `a^b$c`)[0].Content, "a^b$c")
+		frags := parse(t, bre, `\(^a$\)`)
+		inner := frags[0].Content.(*ast.Subexp).Regexp.Matches[0].Fragments
+		if _, ok := inner[0].Content.(*ast.Anchor); !ok {
+			t.Errorf("expected ^ after \\( to be an anchor, got %#v", inner[0].Content)
+		}
+		if _, ok := inner[2].Content.(*ast.Anchor); !ok {
+			t.Errorf("expected $ before \\) to be an anchor, got %#v", inner[2].Content)
+		}
+		if _, ok := parse(t, ere, `a^b`)[1].Content.(*ast.Anchor); !ok {
+			t.Error("expected ^ to be an anchor anywhere in an ERE")
+		}
+	})
+
+	t.Run("BRE star with nothing before it is literal", func(t *testing.T) {
+		frags := parse(t, bre, `^*a`)
+		literal(t, frags[1].Content, "*a")
+		literal(t, parse(t, bre, `\+a`)[0].Content, "+a")
+	})
+
+	t.Run("repeat of a repeat nests", func(t *testing.T) {
+		for _, p := range []string{`a*\+`, `a+*`} {
+			f := bre
+			if !strings.Contains(p, `\`) {
+				f = ere
+			}
+			frag := parse(t, f, p)[0]
+			s, ok := frag.Content.(*ast.Subexp)
+			if !ok || s.GroupType != ast.GroupNonCapture || s.Regexp.Matches[0].Fragments[0].Repeat == nil {
+				t.Errorf("%q: expected a repeated non-capturing group, got %#v", p, frag.Content)
+			}
+		}
+	})
+
+	t.Run("code escapes are literal characters", func(t *testing.T) {
+		frags := parse(t, bre, `\x5e\d065\o101\cA`)
+		for i, want := range []string{"hex", "decimal", "octal", "control"} {
+			esc, ok := frags[i].Content.(*ast.Escape)
+			if !ok || esc.EscapeType != want {
+				t.Errorf("fragment %d: expected %s escape, got %#v", i, want, frags[i].Content)
+			}
+		}
+	})
+
+	t.Run("buffer anchors", func(t *testing.T) {
+		frags := parse(t, bre, "\\`a\\'")
+		for i, want := range map[int]string{0: ast.AnchorStringStart, 2: ast.AnchorAbsoluteEnd} {
+			a, ok := frags[i].Content.(*ast.Anchor)
+			if !ok || a.AnchorType != want {
+				t.Errorf("fragment %d: expected %s anchor, got %#v", i, want, frags[i].Content)
+			}
+		}
+	})
+
+	t.Run("bracket escapes", func(t *testing.T) {
+		cs := parse(t, bre, `[\n\t.]`)[0].Content.(*ast.Charset)
+		if len(cs.Items) != 3 {
+			t.Fatalf("expected newline, tab and dot, got %d items", len(cs.Items))
+		}
+		if esc, ok := cs.Items[0].(*ast.Escape); !ok || esc.EscapeType != "newline" {
+			t.Errorf("expected \\n in brackets to be a newline, got %#v", cs.Items[0])
+		}
+		cs = parse(t, bre, `[\.]`)[0].Content.(*ast.Charset)
+		if len(cs.Items) != 2 {
+			t.Errorf(`expected [\.] to hold a backslash and a dot, got %d items`, len(cs.Items))
+		}
+	})
+
+	t.Run("ERE parentheses after a backslash are literal", func(t *testing.T) {
+		literal(t, parse(t, ere, `\(a\)`)[0].Content, "(a)")
+	})
+}
+
+func TestGNUSedParseErrors(t *testing.T) {
+	tests := []struct {
+		ere     bool
+		pattern string
+		pos     string // "line:col (offset)"
+		msg     string
+	}{
+		{false, `a\(b`, "1:2 (1)", `Unmatched ( or \(`},
+		{false, `a\)`, "1:2 (1)", `Unmatched ) or \)`},
+		{true, `a)`, "1:2 (1)", `Unmatched ) or \)`},
+		{false, `a**`, "1:3 (2)", "Invalid preceding regular expression"},
+		{false, `\{2\}`, "1:1 (0)", "Invalid preceding regular expression"},
+		{true, `*a`, "1:1 (0)", "Invalid preceding regular expression"},
+		{true, `^*`, "1:2 (1)", "Invalid preceding regular expression"},
+		{false, `x\{3,2\}`, "1:2 (1)", `Invalid content of \{\}`},
+		{false, `x\{2`, "1:2 (1)", `Unmatched \{`},
+		{true, `x{99999}`, "1:2 (1)", "Regular expression too big"},
+		{false, `\(a\1\)`, "1:4 (3)", "Invalid back reference"},
+		{false, `[z-a]`, "1:2 (1)", "Invalid range end"},
+		{false, `[[:foo:]]`, "1:2 (1)", "Invalid character class name"},
+		{false, `[ab`, "1:1 (0)", "Unmatched [, [^, [:, [., or [="},
+		{false, `a\`, "1:2 (1)", "Trailing backslash"},
+		{false, `s/[/]/x/`, "1:3 (2)", "Unmatched [, [^"},
+		{false, `s/(a)/\1/`, "1:7 (6)", "invalid reference \\1 on `s' command's RHS"},
+		{false, `s/a/b/q`, "1:7 (6)", "unknown option to `s'"},
+		{false, `s/a/b/gpg`, "1:9 (8)", "multiple `g' options to `s' command"},
+		{false, `s/a/b/0`, "1:7 (6)", "may not be zero"},
+		{false, `//`, "1:2 (1)", "no previous regular expression"},
+		{false, `/a/,/b/`, "1:4 (3)", "address range"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.pattern, func(t *testing.T) {
+			g := &GNUSed{name: "gnused", ere: tc.ere}
+			_, err := g.Parse(tc.pattern)
+			if err == nil {
+				t.Fatalf("expected error for %q", tc.pattern)
+			}
+			msg := err.Error()
+			if !strings.HasPrefix(msg, "parse error: "+tc.pos+": ") {
+				t.Errorf("expected position %s, got: %v", tc.pos, msg)
+			}
+			if !strings.Contains(msg, tc.msg) {
+				t.Errorf("expected error to contain %q, got: %v", tc.msg, msg)
+			}
+		})
+	}
+}
+
+func TestGNUSedParseHints(t *testing.T) {
+	g := &GNUSed{name: "gnused"}
+	hints := strings.Join(g.ParseHints(`s/(a)/\1/`), "\n")
+	if !strings.Contains(hints, "replacement's \\1") {
+		t.Errorf("expected a hint about the replacement's \\1, got %q", hints)
+	}
+	hints = strings.Join(g.ParseHints(`s/[/]/x/`), "\n")
+	if !strings.Contains(hints, "even inside [ ]") {
+		t.Errorf("expected a hint about the delimiter in brackets, got %q", hints)
+	}
+}
+
+func TestGNUSedTokenize(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{`s/a\(b\)*/\1/g`, `delimiter:s/ literal:a group:\( literal:b group:\) quantifier:* delimiter:/ literal:\1 delimiter:/g`},
+		{`/^x/I`, `delimiter:/ anchor:^ literal:x delimiter:/I`},
+		{`a\+`, `literal:a quantifier:\+`},
+	}
+	g := &GNUSed{name: "gnused"}
+	for _, tc := range tests {
+		var parts []string
+		for _, tok := range g.Tokenize(tc.pattern) {
+			parts = append(parts, string(tok.Kind)+":"+tok.Text)
+		}
+		if got := strings.Join(parts, " "); got != tc.want {
+			t.Errorf("Tokenize(%q):\n got %s\nwant %s", tc.pattern, got, tc.want)
+		}
+	}
+}
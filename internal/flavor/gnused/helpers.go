@@ -0,0 +1,48 @@
+package gnused
+
+import "github.com/0x4d5352/regolith/internal/ast"
+
+// classEscapes maps GNU's character class escapes to their EscapeType
+// and display Value.
+var classEscapes = map[byte][2]string{
+	'w': {"word", "word character"},
+	'W': {"non_word", "non-word character"},
+	's': {"whitespace", "whitespace"},
+	'S': {"non_whitespace", "non-whitespace"},
+}
+
+// controlEscapes maps sed's escapes for control characters, which it
+// also reads inside bracket expressions. \n matches the newline that
+// N, G and H leave in the pattern space.
+var controlEscapes = map[byte][2]string{
+	'a': {"alert", "alert (bell)"},
+	'f': {"form_feed", "form feed"},
+	'n': {"newline", "newline"},
+	'r': {"carriage_return", "carriage return"},
+	't': {"tab", "tab"},
+	'v': {"vertical_tab", "vertical tab"},
+}
+
+// anchorEscapes maps GNU's zero-width escapes. \` and \' match at the
+// ends of the whole pattern space even in M mode, where ^ and $ match
+// at each line in it.
+var anchorEscapes = map[byte]string{
+	'b':  ast.AnchorWordBoundary,
+	'B':  ast.AnchorNonWordBoundary,
+	'<':  ast.AnchorWordStart,
+	'>':  ast.AnchorWordEnd,
+	'`':  ast.AnchorStringStart,
+	'\'': ast.AnchorAbsoluteEnd,
+}
+
+// makeEscape creates an Escape node for a single-letter class or
+// control escape, or returns nil when code is neither.
+func makeEscape(code byte) *ast.Escape {
+	t, ok := classEscapes[code]
+	if !ok {
+		if t, ok = controlEscapes[code]; !ok {
+			return nil
+		}
+	}
+	return &ast.Escape{EscapeType: t[0], Code: string(code), Value: t[1]}
+}
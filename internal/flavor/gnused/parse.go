@@ -0,0 +1,604 @@
+package gnused
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+	"github.com/0x4d5352/regolith/internal/flavor"
+)
+
+// sed hands its regexes to the GNU regex matcher, which reads them
+// differently by context: in a BRE ^ is an anchor only where a branch
+// starts and * is a literal there, in an ERE both are operators
+// anywhere. A PEG grammar cannot follow those rules without
+// duplicating every rule per context, so the parser below reads the
+// pattern by hand. Messages are the matcher's own, as sed prints them.
+const (
+	errBadRepeat  = "Invalid preceding regular expression"
+	errBadBraces  = `Invalid content of \{\}`
+	errOpenBraces = `Unmatched \{`
+	errOpenParen  = `Unmatched ( or \(`
+	errCloseParen = `Unmatched ) or \)`
+	errOpenBrack  = "Unmatched [, [^, [:, [., or [="
+	errBackRef    = "Invalid back reference"
+	errTooBig     = "Regular expression too big"
+)
+
+// dupMax is the largest count an interval may give (RE_DUP_MAX).
+const dupMax = 0x7fff
+
+// positioned reports msg at offset in pattern as a
+// *flavor.ParseError, positioned the way the PEG parsers' errors are.
+func positioned(pattern string, offset int, msg string) error {
+	return flavor.NewParseError(pattern, offset, msg)
+}
+
+// parseError carries an error out of the recursive descent; parse
+// recovers it.
+type parseError struct{ err error }
+
+type parser struct {
+	src    string
+	pos    int
+	end    int  // The regex stops here, short of a closing delimiter
+	delim  byte // Written \delim it is a literal delim; 0 for a bare regex
+	ere    bool // sed -E
+	state  *ast.ParserState
+	depth  int          // Groups open at pos
+	closed map[int]bool // Groups a back-reference may name
+}
+
+func parse(pattern string, ere bool) (re *ast.Regexp, err error) {
+	s, rangeAt := split(pattern)
+	p := &parser{src: pattern, pos: s.start, end: s.end, delim: s.delim, ere: ere, state: ast.NewParserState(), closed: map[int]bool{}}
+	defer func() {
+		if r := recover(); r != nil {
+			pe, ok := r.(parseError)
+			if !ok {
+				panic(r)
+			}
+			re, err = nil, pe.err
+		}
+	}()
+	if rangeAt >= 0 {
+		p.fail(rangeAt, "an address range holds two patterns, %s and %s; diagram each on its own",
+			pattern[:rangeAt], pattern[rangeAt+1:])
+	}
+	if s.delim != 0 && s.start == s.end {
+		p.fail(s.start, "no previous regular expression: an empty regex reuses the last one sed ran, which a diagram cannot show")
+	}
+	re = p.regexp()
+	flags := p.flags(s)
+	if s.command {
+		p.replacement(s.repl)
+	}
+	re.Flags = flags
+	return re, nil
+}
+
+func (p *parser) fail(offset int, format string, args ...any) {
+	panic(parseError{positioned(p.src, offset, fmt.Sprintf(format, args...))})
+}
+
+func (p *parser) more() bool { return p.pos < p.end }
+
+func (p *parser) peek() byte {
+	if p.pos < p.end {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func (p *parser) has(prefix string) bool {
+	return strings.HasPrefix(p.src[p.pos:p.end], prefix)
+}
+
+// next consumes and returns one UTF-8 character.
+func (p *parser) next() string {
+	_, size := utf8.DecodeRuneInString(p.src[p.pos:p.end])
+	s := p.src[p.pos : p.pos+size]
+	p.pos += size
+	return s
+}
+
+// op reports the operator at the current position and how many bytes
+// spell it, or 0 when there is none. A BRE writes ( ) { } | + ? with a
+// backslash and an ERE without; * is bare in both. The delimiter
+// written with a backslash is always a literal, even when it is |.
+func (p *parser) op() (byte, int) {
+	c := p.peek()
+	if c == '\\' {
+		if p.ere || p.pos+1 >= p.end {
+			return 0, 0
+		}
+		d := p.src[p.pos+1]
+		if d != p.delim && strings.IndexByte("(){}|+?", d) >= 0 {
+			return d, 2
+		}
+		return 0, 0
+	}
+	if c == '*' || p.ere && c != 0 && strings.IndexByte("(){}|+?", c) >= 0 {
+		return c, 1
+	}
+	return 0, 0
+}
+
+func (p *parser) regexp() *ast.Regexp {
+	re := &ast.Regexp{Matches: []*ast.Match{p.branch()}}
+	for {
+		c, n := p.op()
+		if c != '|' {
+			return re
+		}
+		p.pos += n
+		re.Matches = append(re.Matches, p.branch())
+	}
+}
+
+// branch parses one alternative. after tracks what the last item was,
+// since the matcher reads ^, * and the other repeats by what precedes
+// them.
+func (p *parser) branch() *ast.Match {
+	m := &ast.Match{Fragments: []*ast.MatchFragment{}}
+	const (
+		atStart  = iota // Nothing yet in this branch
+		atAnchor        // An anchor, which cannot be repeated
+		atAtom          // An atom, which can
+		atRepeat        // An atom and its repeat
+	)
+	after := atStart
+	for p.more() {
+		start := p.pos
+		c, n := p.op()
+		switch c {
+		case '|':
+			m.Fragments = mergeLiterals(m.Fragments)
+			return m
+		case ')':
+			if p.depth == 0 {
+				p.fail(start, errCloseParen)
+			}
+			m.Fragments = mergeLiterals(m.Fragments)
+			return m
+		case '*', '+', '?', '{':
+			if after == atStart || after == atAnchor {
+				// With nothing to repeat an ERE rejects the operator
+				// and a BRE reads it as a literal, all but \{.
+				if p.ere || c == '{' {
+					p.fail(start, errBadRepeat)
+				}
+				p.pos += n
+				m.Fragments = append(m.Fragments, &ast.MatchFragment{Content: &ast.Literal{Text: string(c)}})
+				after = atAtom
+				continue
+			}
+			if after == atRepeat && !p.ere && (c == '*' || c == '{') {
+				// A BRE allows \+ and \? after a repeat, but not * or \{.
+				p.fail(start, errBadRepeat)
+			}
+			p.pos += n
+			frag := m.Fragments[len(m.Fragments)-1]
+			r := &ast.Repeat{Min: 0, Max: -1, Greedy: true}
+			switch c {
+			case '+':
+				r.Min = 1
+			case '?':
+				r.Max = 1
+			case '{':
+				r = p.interval(start)
+			}
+			if frag.Repeat != nil {
+				frag.Content = &ast.Subexp{GroupType: ast.GroupNonCapture, Regexp: &ast.Regexp{Matches: []*ast.Match{
+					{Fragments: []*ast.MatchFragment{{Content: frag.Content, Repeat: frag.Repeat}}},
+				}}}
+			}
+			frag.Repeat = r
+			after = atRepeat
+			continue
+		}
+		node := p.atom(after == atStart || after == atAnchor)
+		m.Fragments = append(m.Fragments, &ast.MatchFragment{Content: node})
+		after = atAtom
+		if _, ok := node.(*ast.Anchor); ok {
+			after = atAnchor
+		}
+	}
+	m.Fragments = mergeLiterals(m.Fragments)
+	return m
+}
+
+// mergeLiterals joins runs of unrepeated single-character literals into
+// one Literal, the way the other flavors draw plain text.
+func mergeLiterals(frags []*ast.MatchFragment) []*ast.MatchFragment {
+	var out []*ast.MatchFragment
+	for _, f := range frags {
+		lit, ok := f.Content.(*ast.Literal)
+		if ok && f.Repeat == nil && len(out) > 0 {
+			last := out[len(out)-1]
+			if prev, ok := last.Content.(*ast.Literal); ok && last.Repeat == nil {
+				last.Content = &ast.Literal{Text: prev.Text + lit.Text}
+				continue
+			}
+		}
+		out = append(out, f)
+	}
+	if out == nil {
+		out = []*ast.MatchFragment{}
+	}
+	return out
+}
+
+// atom parses one atom. caret says whether a BRE reads ^ as an anchor
+// here: at the start of a branch or right after another anchor.
+func (p *parser) atom(caret bool) ast.Node {
+	start := p.pos
+	c, n := p.op()
+	if c == '(' {
+		p.pos += n
+		return p.group(start)
+	}
+	if c == '}' {
+		p.pos += n
+		return &ast.Literal{Text: "}"}
+	}
+	switch p.peek() {
+	case '\\':
+		return p.escape()
+	case '.':
+		p.pos++
+		return &ast.AnyCharacter{}
+	case '[':
+		p.pos++
+		return p.bracket(start)
+	case '^':
+		p.pos++
+		if p.ere || caret {
+			return &ast.Anchor{AnchorType: ast.AnchorStart}
+		}
+		return &ast.Literal{Text: "^"}
+	case '$':
+		p.pos++
+		if p.ere || !p.more() {
+			return &ast.Anchor{AnchorType: ast.AnchorEnd}
+		}
+		// A BRE reads $ as an anchor only where a branch ends.
+		if c, _ := p.op(); c == '|' || c == ')' {
+			return &ast.Anchor{AnchorType: ast.AnchorEnd}
+		}
+		return &ast.Literal{Text: "$"}
+	}
+	return &ast.Literal{Text: p.next()}
+}
+
+// group parses the rest of a group whose opener starts at start.
+func (p *parser) group(start int) ast.Node {
+	s := &ast.Subexp{GroupType: ast.GroupCapture, Number: p.state.NextGroupNumber()}
+	p.depth++
+	s.Regexp = p.regexp()
+	if c, n := p.op(); c == ')' {
+		p.pos += n
+	} else {
+		p.fail(start, errOpenParen)
+	}
+	p.depth--
+	p.closed[s.Number] = true
+	return s
+}
+
+// interval parses the rest of {m,n}, whose opener starts at start.
+// {,n} means {0,n}; {} and {n,m} with m < n are errors.
+func (p *parser) interval(start int) *ast.Repeat {
+	lo, hasLo := p.number()
+	if !hasLo && p.peek() != ',' {
+		p.badInterval(start)
+	}
+	hi := lo
+	if p.peek() == ',' {
+		p.pos++
+		hi = -1
+		if n, ok := p.number(); ok {
+			hi = n
+		}
+	}
+	if c, n := p.op(); c == '}' {
+		p.pos += n
+	} else {
+		p.badInterval(start)
+	}
+	if hi >= 0 && lo > hi {
+		p.fail(start, errBadBraces)
+	}
+	if max(lo, hi) > dupMax {
+		p.fail(start, errTooBig)
+	}
+	return &ast.Repeat{Min: lo, Max: hi, Greedy: true}
+}
+
+// badInterval fails on an interval that does not parse, which the
+// matcher reports as unclosed when it runs off the end of the regex.
+func (p *parser) badInterval(start int) {
+	if !p.more() {
+		p.fail(start, errOpenBraces)
+	}
+	p.fail(start, errBadBraces)
+}
+
+// number reads a decimal number, reporting whether there was one.
+func (p *parser) number() (int, bool) {
+	start := p.pos
+	for p.peek() >= '0' && p.peek() <= '9' {
+		p.pos++
+	}
+	if p.pos == start {
+		return 0, false
+	}
+	n, err := strconv.Atoi(p.src[start:p.pos])
+	if err != nil {
+		p.fail(start, errTooBig)
+	}
+	return n, true
+}
+
+// escape parses a backslash item that is not an operator: the escaped
+// delimiter, a back-reference, one of GNU's word and buffer items, one
+// of sed's character escapes, or an escaped character matching itself.
+func (p *parser) escape() ast.Node {
+	start := p.pos
+	p.pos++ // \
+	if !p.more() {
+		p.fail(start, "Trailing backslash")
+	}
+	c := p.peek()
+	if c == p.delim || c >= utf8.RuneSelf {
+		return &ast.Literal{Text: p.next()}
+	}
+	p.pos++
+	if esc := makeEscape(c); esc != nil {
+		return esc
+	}
+	if a, ok := anchorEscapes[c]; ok {
+		return &ast.Anchor{AnchorType: a}
+	}
+	switch {
+	case c >= '1' && c <= '9':
+		n := int(c - '0')
+		if !p.closed[n] {
+			p.fail(start, "%s: \\%d names group %d, which is not closed here", errBackRef, n, n)
+		}
+		return &ast.BackReference{Number: n}
+	case c == 'c':
+		return p.control(start)
+	case c == 'd' || c == 'o' || c == 'x':
+		if esc := p.codePoint(c, start); esc != nil {
+			return esc
+		}
+	}
+	return &ast.Literal{Text: string(c)}
+}
+
+// control parses the character of \cX, which stands for CONTROL-X. A
+// backslash must itself be escaped: \c\\ is CONTROL-\.
+func (p *parser) control(start int) *ast.Escape {
+	if !p.more() {
+		p.fail(start, `\c needs a character after it`)
+	}
+	if p.peek() == '\\' {
+		if !p.has(`\\`) {
+			p.fail(start, `recursive escaping after \c not allowed`)
+		}
+		p.pos += 2
+	} else {
+		p.next()
+	}
+	code := p.src[start:p.pos]
+	return &ast.Escape{EscapeType: "control", Code: code, Value: code}
+}
+
+// codeDigits gives, for \d, \o and \x, the base and the most digits
+// each reads.
+var codeDigits = map[byte][2]int{
+	'd': {10, 3},
+	'o': {8, 3},
+	'x': {16, 2},
+}
+
+var codeTypes = map[byte]string{
+	'd': "decimal", 'o': "octal", 'x': "hex",
+}
+
+// codePoint reads the digits of \d065, \o101 or \x41, with the letter
+// already consumed, returning nil when none follow. The character it
+// produces is always a literal: \x5e matches ^, not the start of a line.
+func (p *parser) codePoint(kind byte, start int) *ast.Escape {
+	base, most := codeDigits[kind][0], codeDigits[kind][1]
+	digits := p.pos
+	for p.pos < digits+most && p.more() && digitValue(p.peek()) < base {
+		p.pos++
+	}
+	if p.pos == digits {
+		return nil
+	}
+	code := p.src[start:p.pos]
+	return &ast.Escape{EscapeType: codeTypes[kind], Code: code, Value: code}
+}
+
+func digitValue(c byte) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'a' && c <= 'f':
+		return int(c-'a') + 10
+	case c >= 'A' && c <= 'F':
+		return int(c-'A') + 10
+	}
+	return 99
+}
+
+// bracket parses a bracket expression after its [, which is at open. A
+// backslash in one is a literal, except that sed still reads the
+// escaped delimiter and its character escapes such as \n and \t there.
+func (p *parser) bracket(open int) *ast.Charset {
+	cs := &ast.Charset{Items: []ast.CharsetItem{}}
+	if p.peek() == '^' {
+		cs.Inverted = true
+		p.pos++
+	}
+	// A ] right after [ or [^ is a literal.
+	if p.peek() == ']' {
+		cs.Items = append(cs.Items, &ast.CharsetLiteral{Text: "]"})
+		p.pos++
+	}
+	for p.peek() != ']' {
+		if !p.more() {
+			p.fail(open, errOpenBrack)
+		}
+		at := p.pos
+		lo, loText := p.member(open)
+		if p.peek() == '-' && p.pos+1 < p.end && p.src[p.pos+1] != ']' && loText != "" {
+			p.pos++
+			hi, hiText := p.member(open)
+			if hiText == "" {
+				cs.Items = append(cs.Items, lo, &ast.CharsetLiteral{Text: "-"}, hi)
+				continue
+			}
+			loRune, _ := utf8.DecodeRuneInString(loText)
+			hiRune, _ := utf8.DecodeRuneInString(hiText)
+			if hiRune < loRune {
+				p.fail(at, "Invalid range end")
+			}
+			cs.Items = append(cs.Items, &ast.CharsetRange{First: loText, Last: hiText})
+			continue
+		}
+		cs.Items = append(cs.Items, lo)
+	}
+	p.pos++ // ]
+	return cs
+}
+
+// posixClasses are the names [:name:] accepts.
+var posixClasses = map[string]bool{
+	"alnum": true, "alpha": true, "blank": true, "cntrl": true,
+	"digit": true, "graph": true, "lower": true, "print": true,
+	"punct": true, "space": true, "upper": true, "xdigit": true,
+}
+
+// member parses one bracket expression member. Single characters also
+// come back as the character they stand for, so they can bound a range.
+// Equivalence classes and collating symbols of one character, [=a=]
+// and [.a.], are that character in the C locale, which the diagram
+// assumes.
+func (p *parser) member(open int) (ast.CharsetItem, string) {
+	start := p.pos
+	if p.has("[:") || p.has("[=") || p.has("[.") {
+		kind := p.src[p.pos+1]
+		end := strings.Index(p.src[p.pos+2:p.end], string(kind)+"]")
+		if end < 0 {
+			p.fail(open, errOpenBrack)
+		}
+		name := p.src[p.pos+2 : p.pos+2+end]
+		p.pos += end + 4
+		if kind == ':' {
+			if !posixClasses[name] {
+				p.fail(start, "Invalid character class name")
+			}
+			return &ast.POSIXClass{Name: name}, ""
+		}
+		if utf8.RuneCountInString(name) != 1 {
+			p.fail(start, "Invalid collation character")
+		}
+		return &ast.CharsetLiteral{Text: name}, name
+	}
+	if p.peek() == '\\' && p.pos+1 < p.end {
+		c := p.src[p.pos+1]
+		if c == p.delim {
+			p.pos += 2
+			return &ast.CharsetLiteral{Text: string(c)}, string(c)
+		}
+		if _, ok := controlEscapes[c]; ok {
+			p.pos += 2
+			return makeEscape(c), ""
+		}
+		switch c {
+		case 'c':
+			p.pos += 2
+			return p.control(start), ""
+		case 'd', 'o', 'x':
+			p.pos += 2
+			if esc := p.codePoint(c, start); esc != nil {
+				return esc, ""
+			}
+			p.pos = start
+		}
+	}
+	ch := p.next()
+	return &ast.CharsetLiteral{Text: ch}, ch
+}
+
+// flags reads the flags after the last delimiter and returns the ones
+// that change matching, I and M, for the flags panel. The other s
+// flags act on the substitution and are only checked.
+func (p *parser) flags(s script) string {
+	var out string
+	add := func(c byte) {
+		if !strings.Contains(out, string(c)) {
+			out += string(c)
+		}
+	}
+	seen := map[byte]bool{}
+	for i := s.flags[0]; i < s.flags[1]; i++ {
+		c := p.src[i]
+		switch {
+		case c == 'I' || c == 'i' && s.command:
+			add('I')
+		case c == 'M' || c == 'm' && s.command:
+			add('M')
+		case c == 'g' || c == 'p':
+			if seen[c] {
+				p.fail(i, "multiple `%c' options to `s' command", c)
+			}
+			seen[c] = true
+		case c >= '0' && c <= '9':
+			if seen['0'] {
+				p.fail(i, "multiple number options to `s' command")
+			}
+			seen['0'] = true
+			j := i
+			for j < s.flags[1] && p.src[j] >= '0' && p.src[j] <= '9' {
+				j++
+			}
+			if n, _ := strconv.Atoi(p.src[i:j]); n == 0 {
+				p.fail(i, "number option to `s' command may not be zero")
+			}
+			i = j - 1
+		case c == 'e':
+			// e runs the result as a command; matching is unchanged.
+		case c == 'w':
+			if strings.TrimSpace(p.src[i+1:s.flags[1]]) == "" {
+				p.fail(i, "missing filename in r/R/w/W commands")
+			}
+			return out
+		default:
+			p.fail(i, "unknown option to `s'")
+		}
+	}
+	return out
+}
+
+// replacement checks the back-references in an s command's
+// replacement, which sed rejects when the regex has too few groups.
+// \n and the other escapes there are not part of the match, so nothing
+// else in it is drawn.
+func (p *parser) replacement(span [2]int) {
+	for i := span[0]; i < span[1]; i++ {
+		if p.src[i] != '\\' || i+1 >= span[1] {
+			continue
+		}
+		i++
+		if c := p.src[i]; c >= '1' && c <= '9' && int(c-'0') > p.state.GroupCounter {
+			p.fail(i-1, "invalid reference \\%c on `s' command's RHS", c)
+		}
+	}
+}
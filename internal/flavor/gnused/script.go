@@ -0,0 +1,99 @@
+package gnused
+
+import "strings"
+
+// script locates the parts of a pattern written the way a sed script
+// holds it. An address is /regex/ or \%regex% with the I and M flags
+// after it; a substitution is s/regex/replacement/flags with any
+// punctuation for the slash. Anything else is a bare regex, so
+// patterns copied out of a script and typed on their own both work.
+type script struct {
+	start, end int    // The regex is src[start:end]
+	delim      byte   // Written \delim it is a literal delim; 0 for a bare regex
+	command    bool   // An s command, with a replacement
+	repl       [2]int // The replacement's span in src
+	flags      [2]int // The span of the flags after the last delimiter
+}
+
+// addressDelimiters are the characters a \cregexc address may use. The
+// ones left out already mean something after a backslash, so a pattern
+// such as \.a. stays a bare regex.
+const addressDelimiters = "!\"#%&,-/:;=@_~"
+
+// split finds the regex in src. It reports the second half of an
+// address range, which is a second pattern, through rangeAt; it is -1
+// otherwise.
+func split(src string) (s script, rangeAt int) {
+	bare := script{start: 0, end: len(src)}
+	switch {
+	case len(src) > 1 && src[0] == 's' && isDelimiter(src[1]):
+		d := src[1]
+		re := closing(src, 2, d)
+		if re < 0 {
+			return bare, -1
+		}
+		rp := closing(src, re+1, d)
+		if rp < 0 {
+			return bare, -1
+		}
+		return script{start: 2, end: re, delim: d, command: true, repl: [2]int{re + 1, rp}, flags: [2]int{rp + 1, len(src)}}, -1
+	case len(src) > 0 && src[0] == '/':
+		s, rest := address(src, 1, '/')
+		return addressOrBare(src, s, rest, bare)
+	case len(src) > 1 && src[0] == '\\' && strings.IndexByte(addressDelimiters, src[1]) >= 0:
+		s, rest := address(src, 2, src[1])
+		return addressOrBare(src, s, rest, bare)
+	}
+	return bare, -1
+}
+
+// address reads an address whose regex starts at start, returning it
+// and the offset just past its flags, or -1 when it never closes.
+func address(src string, start int, d byte) (script, int) {
+	end := closing(src, start, d)
+	if end < 0 {
+		return script{}, -1
+	}
+	rest := end + 1
+	for rest < len(src) && strings.IndexByte("IM", src[rest]) >= 0 {
+		rest++
+	}
+	return script{start: start, end: end, delim: d, flags: [2]int{end + 1, rest}}, rest
+}
+
+func addressOrBare(src string, s script, rest int, bare script) (script, int) {
+	switch {
+	case rest < 0:
+		return bare, -1
+	case rest == len(src):
+		return s, -1
+	case src[rest] == ',' && rest+1 < len(src):
+		return s, rest
+	}
+	return bare, -1
+}
+
+// isDelimiter reports whether c can delimit an s command here. sed
+// takes any character but a backslash or newline; letters, digits and
+// spaces are left out so a bare regex like "sky" is not read as one.
+func isDelimiter(c byte) bool {
+	return c > ' ' && c < 0x7f && c != '\\' &&
+		!(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9')
+}
+
+// closing returns the offset of the first d at or after i that no
+// backslash escapes, or -1. Like sed, it does not look inside bracket
+// expressions: in s/[/]/x/ the regex is "[".
+func closing(src string, i int, d byte) int {
+	for i < len(src) {
+		switch src[i] {
+		case '\\':
+			i += 2
+			continue
+		case d:
+			return i
+		}
+		i++
+	}
+	return -1
+}
@@ -0,0 +1,110 @@
+// Package golang implements Go's regular expression flavor: the RE2
+// syntax regexp.Compile accepts. RE2 guarantees linear-time matching,
+// so it leaves out everything that needs backtracking — back-references,
+// lookaround, atomic groups, possessive quantifiers — and this flavor
+// rejects those with an explanation rather than drawing a diagram Go
+// would not compile.
+package golang
+
+import (
+	"github.com/0x4d5352/regolith/internal/ast"
+	"github.com/0x4d5352/regolith/internal/flavor"
+	"github.com/0x4d5352/regolith/internal/flavor/helpers"
+)
+
+func init() {
+	flavor.MustRegister(&Golang{})
+	flavor.MustRegisterAlias("go", "golang")
+}
+
+// Golang implements the Flavor interface for Go's regexp package.
+type Golang struct{}
+
+// Ensure Golang implements the Flavor interface.
+var _ flavor.Flavor = (*Golang)(nil)
+
+func (f *Golang) Name() string {
+	return "golang"
+}
+
+func (f *Golang) Description() string {
+	return "Go regexp (RE2 syntax) - linear-time matching, no back-references or lookaround"
+}
+
+// Family returns the flavor's syntax family.
+func (f *Golang) Family() flavor.Family {
+	return flavor.FamilyPerl
+}
+
+// Notes returns what `regolith flavors` says about the flavor beyond
+// its FeatureSet.
+func (f *Golang) Notes() flavor.Notes {
+	return flavor.Notes{
+		Examples: map[string]string{
+			"named_groups":       `(?P<year>\d{4})`,
+			"unicode_properties": `\pL+`,
+		},
+		Limitations: []string{
+			"Patterns are read as regexp.Compile reads them; regexp.CompilePOSIX's leftmost-longest matching and stricter syntax are not modeled",
+		},
+		Notes: []string{
+			"Syntax is checked by Go's own regexp/syntax, so a pattern is accepted exactly when regexp.Compile accepts it",
+		},
+	}
+}
+
+// Parse parses a pattern as regexp.Compile would. Go patterns have no
+// delimiters; flags are set inline with (?flags).
+func (f *Golang) Parse(pattern string) (*ast.Regexp, error) {
+	root, err := helpers.FinalizeParse(parse(pattern))
+	flavor.Locate(f, pattern, root)
+	return root, flavor.Pinpoint(f, pattern, err)
+}
+
+// syntaxDocs is the reference for RE2 syntax as Go implements it.
+const syntaxDocs = "https://pkg.go.dev/regexp/syntax"
+
+func (f *Golang) SupportedFlags() []flavor.FlagInfo {
+	return []flavor.FlagInfo{
+		{Char: 'i', Name: "case-insensitive", Description: "Case-insensitive matching", DocURL: syntaxDocs},
+		{Char: 'm', Name: "multi-line", Description: "^ and $ match at line boundaries", DocURL: syntaxDocs},
+		{Char: 's', Name: "dot-all", Description: ". matches \\n", DocURL: syntaxDocs},
+		{Char: 'U', Name: "ungreedy", Description: "Swap the meaning of x* and x*?, x+ and x+?, and so on", DocURL: syntaxDocs},
+	}
+}
+
+// Tokenize splits a Go pattern into syntax-highlighting tokens.
+func (f *Golang) Tokenize(pattern string) []flavor.Token {
+	return flavor.TokenizeSyntax(pattern, flavor.Syntax{Perl: true, Quote: true, StringAnchors: true})
+}
+
+// SupportedFeatures returns the feature capabilities of Go's RE2.
+func (f *Golang) SupportedFeatures() flavor.FeatureSet {
+	return flavor.FeatureSet{
+		Lookahead:             false,
+		Lookbehind:            false,
+		LookbehindUnlimited:   false,
+		NamedGroups:           true, // (?P<name>...), and (?<name>...) since Go 1.22
+		AtomicGroups:          false,
+		PossessiveQuantifiers: false,
+		RecursivePatterns:     false,
+		ConditionalPatterns:   false,
+		UnicodeProperties:     true, // \pL, \p{Greek}, \p{^Greek}
+		POSIXClasses:          true, // [[:alpha:]] inside a class
+		BalancedGroups:        false,
+		InlineModifiers:       true, // (?imsU) and (?i:...)
+		Comments:              false,
+		BranchReset:           false,
+		BacktrackingControl:   false,
+		Callouts:              false,
+		ScriptRuns:            false,
+		NonAtomicLookaround:   false,
+		PatternStartOptions:   false,
+		UnicodeSets:           false,
+		OctalEscapes:          true, // \123
+		HexBracedEscapes:      true, // \x{10FFFF}
+		NamedUnicodeEscapes:   false,
+		RelativeBackrefs:      false,
+		SetOperations:         false,
+	}
+}
@@ -0,0 +1,194 @@
+package golang
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+	"github.com/0x4d5352/regolith/internal/flavor"
+)
+
+func TestGolangFlavorName(t *testing.T) {
+	g := &Golang{}
+	if g.Name() != "golang" {
+		t.Errorf("expected name 'golang', got '%s'", g.Name())
+	}
+}
+
+func TestGolangFlavorDescription(t *testing.T) {
+	g := &Golang{}
+	if !strings.Contains(g.Description(), "RE2") {
+		t.Error("description should mention RE2")
+	}
+}
+
+func TestGolangFlavorSupportedFlags(t *testing.T) {
+	g := &Golang{}
+	var chars []byte
+	for _, f := range g.SupportedFlags() {
+		chars = append(chars, byte(f.Char))
+	}
+	if string(chars) != "imsU" {
+		t.Errorf("expected flags imsU, got %s", chars)
+	}
+}
+
+func TestGolangFlavorSupportedFeatures(t *testing.T) {
+	features := (&Golang{}).SupportedFeatures()
+	if !features.NamedGroups || !features.UnicodeProperties || !features.InlineModifiers || !features.POSIXClasses {
+		t.Error("Go should support named groups, Unicode properties, inline modifiers and POSIX classes")
+	}
+	if features.Lookahead || features.Lookbehind || features.AtomicGroups || features.PossessiveQuantifiers {
+		t.Error("Go should not support lookaround, atomic groups or possessive quantifiers")
+	}
+}
+
+func TestGolangFlavorRegistered(t *testing.T) {
+	f, ok := flavor.Get("golang")
+	if !ok {
+		t.Fatal("golang flavor not registered")
+	}
+	if f.Name() != "golang" {
+		t.Errorf("expected name 'golang', got '%s'", f.Name())
+	}
+}
+
+// TestGolangParseAgreesWithCompile checks the flavor's one promise: a
+// pattern parses exactly when regexp.Compile accepts it.
+func TestGolangParseAgreesWithCompile(t *testing.T) {
+	patterns := []string{
+		// Accepted
+		`abc`, `a|b|`, `(?P<year>\d{4})-(?<month>\d\d)`, `(?:a|b)*?`,
+		`\pL\PN\p{Greek}\p{^Greek}\P{^Lu}`, `(?i)abc`, `(?i-s:a.)`, `(?U)a+`,
+		`[[:alpha:]\d_-]`, `[^[:^space:]]`, `[]a]`, `[a-z\x{263A}]`,
+		`x{2,}?`, `x{0}`, `a{`, `a{,3}`, `\Q.*+\E`, `\Q(`, `\A\b\B\z`,
+		`\x41\x{10FFFF}\101\0`, `\a\f\t\n\r\v`, `^$.`, `()`, `\.\*\\`,
+		// Rejected
+		`(?=a)`, `(?!a)`, `(?<=a)`, `(?<!a)`, `(?>a)`, `(?#c)`, `(?|a)`,
+		`(a)\1`, `\k<a>`, `\g1`, `(?P=a)`, `(?(1)a|b)`, `(?R)`, `(?1)`,
+		`a++`, `a*+`, `a**`, `\Z`, `\h`, `\R`, `\e`, `\cA`, `\o{12}`,
+		`\u0041`, `a{1001}`, `a(b`, `a)b`, `[a`, `[z-a]`, `*a`, `[[:foo:]]`,
+		`\pX`, `(?P<>a)`, `(?<a`, `\`,
+	}
+	g := &Golang{}
+	for _, p := range patterns {
+		_, compileErr := regexp.Compile(p)
+		_, parseErr := g.Parse(p)
+		if (compileErr == nil) != (parseErr == nil) {
+			t.Errorf("%q: regexp.Compile error %v, Parse error %v", p, compileErr, parseErr)
+		}
+	}
+}
+
+func TestGolangParseNodes(t *testing.T) {
+	g := &Golang{}
+	first := func(t *testing.T, pattern string) *ast.MatchFragment {
+		t.Helper()
+		result, err := g.Parse(pattern)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", pattern, err)
+		}
+		return result.Matches[0].Fragments[0]
+	}
+
+	t.Run("named group", func(t *testing.T) {
+		for _, p := range []string{`(?P<year>\d)`, `(?<year>\d)`} {
+			subexp, ok := first(t, p).Content.(*ast.Subexp)
+			if !ok || subexp.GroupType != "named_capture" || subexp.Name != "year" || subexp.Number != 1 {
+				t.Errorf("%q: expected named capture year #1, got %#v", p, first(t, p).Content)
+			}
+		}
+	})
+
+	t.Run("one-letter property", func(t *testing.T) {
+		prop, ok := first(t, `\PL`).Content.(*ast.UnicodePropertyEscape)
+		if !ok || prop.Property != "L" || !prop.Negated {
+			t.Errorf("expected negated property L, got %#v", first(t, `\PL`).Content)
+		}
+	})
+
+	t.Run("caret negates a braced property", func(t *testing.T) {
+		prop, ok := first(t, `\p{^Greek}`).Content.(*ast.UnicodePropertyEscape)
+		if !ok || prop.Property != "Greek" || !prop.Negated {
+			t.Errorf("expected negated property Greek, got %#v", first(t, `\p{^Greek}`).Content)
+		}
+	})
+
+	t.Run("flag group", func(t *testing.T) {
+		mod, ok := first(t, `(?i-s)a`).Content.(*ast.InlineModifier)
+		if !ok || mod.Enable != "i" || mod.Disable != "s" || mod.Regexp != nil {
+			t.Errorf("expected (?i-s) modifier, got %#v", first(t, `(?i-s)a`).Content)
+		}
+		scoped, ok := first(t, `(?U:a+)`).Content.(*ast.InlineModifier)
+		if !ok || scoped.Enable != "U" || scoped.Regexp == nil {
+			t.Errorf("expected scoped (?U:...) modifier, got %#v", first(t, `(?U:a+)`).Content)
+		}
+	})
+
+	t.Run("lazy counted repeat", func(t *testing.T) {
+		frag := first(t, `x{2,}?`)
+		if frag.Repeat == nil || frag.Repeat.Min != 2 || frag.Repeat.Max != -1 || frag.Repeat.Greedy {
+			t.Errorf("expected lazy {2,}, got %#v", frag.Repeat)
+		}
+	})
+
+	t.Run("unfinished brace is literal", func(t *testing.T) {
+		result, err := g.Parse(`a{,3}`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, frag := range result.Matches[0].Fragments {
+			if frag.Repeat != nil {
+				t.Errorf("expected no repeat in a{,3}, got %#v", frag.Repeat)
+			}
+		}
+	})
+
+	t.Run("quoted literal", func(t *testing.T) {
+		q, ok := first(t, `\Q.*\E`).Content.(*ast.QuotedLiteral)
+		if !ok || q.Text != ".*" {
+			t.Errorf("expected quoted .*, got %#v", first(t, `\Q.*\E`).Content)
+		}
+	})
+}
+
+func TestGolangParseErrors(t *testing.T) {
+	g := &Golang{}
+	tests := []struct {
+		pattern string
+		pos     string // "line:col (offset)"
+		explain string
+	}{
+		{`ab(?=c)`, "1:3 (2)", "no lookahead"},
+		{`(?<!a)b`, "1:1 (0)", "no lookbehind"},
+		{`(a)\1`, "1:4 (3)", "no back-references"},
+		{`(?P<x>a)(?P=x)`, "1:9 (8)", "no back-references"},
+		{`a++`, "1:2 (1)", "no possessive quantifiers"},
+		{`(?>a)`, "1:1 (0)", "no atomic groups"},
+		{`a\Z`, "1:2 (1)", `use \z`},
+		{`a{1001}`, "1:2 (1)", "limits counted repetition to 1000"},
+		{`\u0041`, "1:1 (0)", `\x{hhhh}`},
+		// Unbalanced brackets are placed at the bracket, as in every
+		// flavor, not where regexp/syntax gave up.
+		{`ab(c`, "1:3 (2)", "unclosed group"},
+		{`a)b`, "1:2 (1)", "unmatched )"},
+		{`a[bc`, "1:2 (1)", "unclosed character class"},
+		{`[[:alpha:]`, "1:1 (0)", "missing closing ]"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.pattern, func(t *testing.T) {
+			_, err := g.Parse(tc.pattern)
+			if err == nil {
+				t.Fatalf("expected error for %q", tc.pattern)
+			}
+			msg := err.Error()
+			if !strings.HasPrefix(msg, "parse error: "+tc.pos+": ") {
+				t.Errorf("expected position %s, got: %v", tc.pos, msg)
+			}
+			if !strings.Contains(msg, tc.explain) {
+				t.Errorf("expected error to contain %q, got: %v", tc.explain, msg)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package golang
+
+import "github.com/0x4d5352/regolith/internal/ast"
+
+// escapeTypes maps the single-letter escapes RE2 accepts to their
+// EscapeType and display Value.
+var escapeTypes = map[string][2]string{
+	"d": {"digit", "digit"},
+	"D": {"non_digit", "non-digit"},
+	"w": {"word", "word"},
+	"W": {"non_word", "non-word"},
+	"s": {"whitespace", "whitespace"},
+	"S": {"non_whitespace", "non-whitespace"},
+	"a": {"alert", "alert (bell)"},
+	"f": {"form_feed", "form feed"},
+	"t": {"tab", "tab"},
+	"n": {"newline", "newline"},
+	"r": {"carriage_return", "carriage return"},
+	"v": {"vertical_tab", "vertical tab"},
+}
+
+// makeEscape creates an Escape node for a single-letter escape code.
+func makeEscape(code string) *ast.Escape {
+	t := escapeTypes[code]
+	return &ast.Escape{EscapeType: t[0], Code: code, Value: t[1]}
+}
@@ -0,0 +1,522 @@
+package golang
+
+import (
+	"errors"
+	"fmt"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+	"github.com/0x4d5352/regolith/internal/flavor"
+)
+
+// Go's regexp package ships its own parser, so this flavor has no PEG
+// grammar: regexp/syntax decides what is valid, with the flags
+// regexp.Compile uses, and the hand-written parser below only builds
+// the AST for patterns it has accepted. That keeps the flavor exactly
+// as strict as regexp.Compile.
+
+// check parses pattern with regexp/syntax and converts a rejection into
+// a positioned parse error, explaining the PCRE constructs RE2 leaves
+// out.
+func check(pattern string) error {
+	_, err := syntax.Parse(pattern, syntax.Perl)
+	if err == nil {
+		return nil
+	}
+	var serr *syntax.Error
+	if !errors.As(err, &serr) {
+		return err
+	}
+	if pe := delimiterError(pattern, serr); pe != nil {
+		return pe
+	}
+	offset := errorOffset(pattern, serr)
+	msg := serr.Error()
+	if why := explain(serr, pattern[offset:]); why != "" {
+		msg += ": " + why
+	}
+	return positioned(pattern, offset, msg)
+}
+
+// delimiterError locates an unbalanced (, ) or [ with the delimiter
+// check every flavor's errors go through (see flavor.Pinpoint), for
+// regexp/syntax reports where it gave up on one, at the end of the
+// pattern or at its start, rather than where the bracket is. It
+// returns nil for other errors.
+func delimiterError(pattern string, serr *syntax.Error) error {
+	switch serr.Code {
+	case syntax.ErrMissingParen, syntax.ErrMissingBracket, syntax.ErrUnexpectedParen:
+	default:
+		return nil
+	}
+	d := flavor.CheckDelimiters(&Golang{}, pattern)
+	if d == nil {
+		return nil
+	}
+	d.Err = serr
+	pe := flavor.NewParseError(pattern, d.Offset, d.Message)
+	pe.Err = d
+	return pe
+}
+
+// errorOffset finds where in pattern regexp/syntax stopped. Its error
+// quotes the offending text but not its position, and that text may
+// also occur earlier, as in (?P<x>a)(?P=x), so the site is the last
+// occurrence the pattern before it does not already fail on.
+func errorOffset(pattern string, serr *syntax.Error) int {
+	switch serr.Code {
+	case syntax.ErrMissingParen:
+		return len(pattern)
+	case syntax.ErrTrailingBackslash:
+		return len(pattern) - 1
+	}
+	if serr.Expr == "" {
+		return 0
+	}
+	for i := strings.LastIndex(pattern, serr.Expr); i > 0; i = strings.LastIndex(pattern[:i], serr.Expr) {
+		var before *syntax.Error
+		if _, err := syntax.Parse(pattern[:i], syntax.Perl); !errors.As(err, &before) ||
+			before.Code != serr.Code || before.Expr != serr.Expr {
+			return i
+		}
+	}
+	return max(strings.Index(pattern, serr.Expr), 0)
+}
+
+// explain says why RE2 rejects a construct other flavors accept, and
+// what to use instead, or returns "".
+func explain(serr *syntax.Error, rest string) string {
+	expr := serr.Expr
+	switch serr.Code {
+	case syntax.ErrInvalidPerlOp:
+		switch {
+		case strings.HasPrefix(rest, "(?=") || strings.HasPrefix(rest, "(?!"):
+			return "RE2 has no lookahead; match the text and inspect it in Go code instead"
+		case strings.HasPrefix(rest, "(?<=") || strings.HasPrefix(rest, "(?<!"):
+			return "RE2 has no lookbehind; capture the preceding text in a group instead"
+		case strings.HasPrefix(rest, "(?>"):
+			return "RE2 has no atomic groups; its matching never backtracks, so none are needed"
+		case strings.HasPrefix(rest, "(?#"):
+			return "RE2 has no inline comments"
+		case strings.HasPrefix(rest, "(?|"):
+			return "RE2 has no branch reset groups"
+		case strings.HasPrefix(rest, "(?P="), strings.HasPrefix(rest, "(?P>"):
+			return "RE2 has no back-references or subroutine calls"
+		case strings.HasPrefix(rest, "(?("):
+			return "RE2 has no conditionals"
+		case strings.HasPrefix(rest, "(?R"), strings.HasPrefix(rest, "(?&"):
+			return "RE2 has no recursion"
+		case strings.HasPrefix(rest, "(?'"):
+			return "name groups as (?P<name>...) or (?<name>...)"
+		}
+		return "RE2 supports only the flags i, m, s and U"
+	case syntax.ErrInvalidEscape:
+		if len(expr) < 2 {
+			return ""
+		}
+		switch c := expr[1]; {
+		case c >= '1' && c <= '9', c == 'k', c == 'g':
+			return "RE2 has no back-references; compare the groups in Go code instead"
+		case c == 'Z':
+			return `use \z, or $ without the m flag, for the end of the text`
+		case c == 'h', c == 'H', c == 'R', c == 'N', c == 'X', c == 'K', c == 'G', c == 'V':
+			return `RE2 does not support \` + string(c)
+		case c == 'u', c == 'U':
+			return `write the code point as \x{hhhh}`
+		case c == 'e', c == 'c', c == 'o':
+			return `write control characters and octal escapes as \x{hh} or \ooo`
+		}
+	case syntax.ErrInvalidRepeatOp:
+		if strings.HasSuffix(expr, "+") {
+			return "RE2 has no possessive quantifiers; its matching never backtracks, so none are needed"
+		}
+	case syntax.ErrInvalidNamedCapture:
+		if strings.HasPrefix(rest, "(?<=") || strings.HasPrefix(rest, "(?<!") {
+			return "RE2 has no lookbehind; capture the preceding text in a group instead"
+		}
+	case syntax.ErrInvalidRepeatSize:
+		return "RE2 limits counted repetition to 1000"
+	case syntax.ErrInvalidCharRange:
+		if strings.Contains(expr, "&&") || strings.Contains(expr, "--") {
+			return "RE2 has no class intersection or subtraction"
+		}
+	}
+	return ""
+}
+
+// positioned reports msg at offset in pattern as a
+// *flavor.ParseError, positioned the way the PEG parsers' errors are.
+func positioned(pattern string, offset int, msg string) error {
+	return flavor.NewParseError(pattern, offset, msg)
+}
+
+// parser builds the AST for a pattern regexp/syntax has accepted.
+type parser struct {
+	src   string
+	pos   int
+	state *ast.ParserState
+}
+
+func parse(pattern string) (*ast.Regexp, error) {
+	if err := check(pattern); err != nil {
+		return nil, err
+	}
+	p := &parser{src: pattern, state: ast.NewParserState()}
+	re := p.regexp()
+	if p.pos < len(p.src) {
+		return nil, positioned(pattern, p.pos, fmt.Sprintf("unexpected %q", p.src[p.pos:p.pos+1]))
+	}
+	return re, nil
+}
+
+func (p *parser) more() bool { return p.pos < len(p.src) }
+
+func (p *parser) peek() byte {
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func (p *parser) has(prefix string) bool {
+	return strings.HasPrefix(p.src[p.pos:], prefix)
+}
+
+// next consumes and returns one UTF-8 character.
+func (p *parser) next() string {
+	_, size := utf8.DecodeRuneInString(p.src[p.pos:])
+	s := p.src[p.pos : p.pos+size]
+	p.pos += size
+	return s
+}
+
+func (p *parser) regexp() *ast.Regexp {
+	re := &ast.Regexp{Matches: []*ast.Match{p.match()}}
+	for p.peek() == '|' {
+		p.pos++
+		re.Matches = append(re.Matches, p.match())
+	}
+	return re
+}
+
+func (p *parser) match() *ast.Match {
+	m := &ast.Match{Fragments: []*ast.MatchFragment{}}
+	for p.more() && p.peek() != '|' && p.peek() != ')' {
+		m.Fragments = append(m.Fragments, p.fragments()...)
+	}
+	return m
+}
+
+// fragments parses one atom and its quantifier. A run of plain
+// characters is one Literal, except that a quantifier applies only to
+// the last character, which then becomes a fragment of its own.
+func (p *parser) fragments() []*ast.MatchFragment {
+	if text := p.literalRun(); text != "" {
+		start := p.pos
+		if r := p.repeat(); r != nil {
+			_, size := utf8.DecodeLastRuneInString(text)
+			last := &ast.MatchFragment{Content: &ast.Literal{Text: text[len(text)-size:]}, Repeat: r}
+			if len(text) == size {
+				return []*ast.MatchFragment{last}
+			}
+			return []*ast.MatchFragment{{Content: &ast.Literal{Text: text[:len(text)-size]}}, last}
+		}
+		p.pos = start
+		return []*ast.MatchFragment{{Content: &ast.Literal{Text: text}}}
+	}
+	frag := &ast.MatchFragment{Content: p.atom()}
+	frag.Repeat = p.repeat()
+	return []*ast.MatchFragment{frag}
+}
+
+// literalRun consumes characters with no special meaning.
+func (p *parser) literalRun() string {
+	start := p.pos
+	for p.more() && !strings.ContainsRune(`\^$.|?*+()[]{`, rune(p.peek())) {
+		p.next()
+	}
+	// An unmatched { is a literal too, but only on its own: x{2} must
+	// still read as a repeat of x.
+	if p.pos == start && p.peek() == '{' && !p.isRepeat() {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *parser) atom() ast.Node {
+	switch c := p.peek(); c {
+	case '(':
+		return p.group()
+	case '[':
+		return p.charset()
+	case '.':
+		p.pos++
+		return &ast.AnyCharacter{}
+	case '^':
+		p.pos++
+		return &ast.Anchor{AnchorType: ast.AnchorStart}
+	case '$':
+		p.pos++
+		return &ast.Anchor{AnchorType: ast.AnchorEnd}
+	case '\\':
+		return p.escape()
+	default:
+		return &ast.Literal{Text: p.next()}
+	}
+}
+
+func (p *parser) group() ast.Node {
+	p.pos++ // (
+	switch {
+	case p.has("?P<"), p.has("?<"):
+		p.pos += strings.IndexByte(p.src[p.pos:], '<') + 1
+		end := strings.IndexByte(p.src[p.pos:], '>')
+		name := p.src[p.pos : p.pos+end]
+		p.pos += end + 1
+		s := &ast.Subexp{GroupType: ast.GroupNamedCapture, Name: name, Number: p.state.NextGroupNumber()}
+		s.Regexp = p.regexp()
+		p.pos++ // )
+		return s
+	case p.has("?:"):
+		p.pos += 2
+		s := &ast.Subexp{GroupType: ast.GroupNonCapture, Regexp: p.regexp()}
+		p.pos++
+		return s
+	case p.has("?"):
+		p.pos++
+		end := strings.IndexAny(p.src[p.pos:], ":)")
+		flags := p.src[p.pos : p.pos+end]
+		p.pos += end
+		im := &ast.InlineModifier{}
+		im.Enable, im.Disable, _ = strings.Cut(flags, "-")
+		if p.peek() == ':' {
+			p.pos++
+			im.Regexp = p.regexp()
+		}
+		p.pos++ // )
+		return im
+	}
+	s := &ast.Subexp{GroupType: ast.GroupCapture, Number: p.state.NextGroupNumber()}
+	s.Regexp = p.regexp()
+	p.pos++
+	return s
+}
+
+// isRepeat reports whether a { at the current position starts a valid
+// counted repetition; otherwise RE2 reads it as a literal.
+func (p *parser) isRepeat() bool {
+	_, _, n := countedRepeat(p.src[p.pos:])
+	return n > 0
+}
+
+// countedRepeat parses {n}, {n,} or {n,m} at the start of s, returning
+// the bounds (max -1 when open) and the length consumed, or 0 when s
+// does not start with one.
+func countedRepeat(s string) (lo, hi, n int) {
+	if !strings.HasPrefix(s, "{") {
+		return 0, 0, 0
+	}
+	end := strings.IndexByte(s, '}')
+	if end < 0 {
+		return 0, 0, 0
+	}
+	minStr, maxStr, comma := strings.Cut(s[1:end], ",")
+	lo, err := strconv.Atoi(minStr)
+	if err != nil || !isDigits(minStr) {
+		return 0, 0, 0
+	}
+	hi = lo
+	if comma {
+		hi = -1
+		if maxStr != "" {
+			if hi, err = strconv.Atoi(maxStr); err != nil || !isDigits(maxStr) {
+				return 0, 0, 0
+			}
+		}
+	}
+	return lo, hi, end + 1
+}
+
+func (p *parser) repeat() *ast.Repeat {
+	var r *ast.Repeat
+	switch p.peek() {
+	case '*':
+		r = &ast.Repeat{Min: 0, Max: -1}
+		p.pos++
+	case '+':
+		r = &ast.Repeat{Min: 1, Max: -1}
+		p.pos++
+	case '?':
+		r = &ast.Repeat{Min: 0, Max: 1}
+		p.pos++
+	case '{':
+		lo, hi, n := countedRepeat(p.src[p.pos:])
+		if n == 0 {
+			return nil
+		}
+		r = &ast.Repeat{Min: lo, Max: hi}
+		p.pos += n
+	default:
+		return nil
+	}
+	r.Greedy = true
+	if p.peek() == '?' {
+		r.Greedy = false
+		p.pos++
+	}
+	return r
+}
+
+// escape parses a backslash sequence outside a class.
+func (p *parser) escape() ast.Node {
+	p.pos++ // \
+	c := p.peek()
+	switch c {
+	case 'A':
+		p.pos++
+		return &ast.Anchor{AnchorType: ast.AnchorStringStart}
+	case 'z':
+		p.pos++
+		return &ast.Anchor{AnchorType: ast.AnchorAbsoluteEnd}
+	case 'b':
+		p.pos++
+		return &ast.Anchor{AnchorType: ast.AnchorWordBoundary}
+	case 'B':
+		p.pos++
+		return &ast.Anchor{AnchorType: ast.AnchorNonWordBoundary}
+	case 'Q':
+		p.pos++
+		end := strings.Index(p.src[p.pos:], `\E`)
+		if end < 0 {
+			text := p.src[p.pos:]
+			p.pos = len(p.src)
+			return &ast.QuotedLiteral{Text: text}
+		}
+		text := p.src[p.pos : p.pos+end]
+		p.pos += end + 2
+		return &ast.QuotedLiteral{Text: text}
+	}
+	p.pos-- // the shared escapes re-read the backslash
+	return p.classEscape()
+}
+
+// classEscape parses a backslash sequence that means the same inside
+// and outside a class: shorthands, properties, control characters and
+// code points, or an escaped punctuation character.
+func (p *parser) classEscape() ast.Node {
+	start := p.pos
+	p.pos++ // \
+	c := p.peek()
+	switch c {
+	case 'd', 'D', 's', 'S', 'w', 'W':
+		p.pos++
+		return makeEscape(string(c))
+	case 'a', 'f', 't', 'n', 'r', 'v':
+		p.pos++
+		return makeEscape(string(c))
+	case 'p', 'P':
+		p.pos++
+		var prop string
+		if p.peek() == '{' {
+			end := strings.IndexByte(p.src[p.pos:], '}')
+			prop = p.src[p.pos+1 : p.pos+end]
+			p.pos += end + 1
+		} else {
+			prop = p.next()
+		}
+		negated := c == 'P'
+		if strings.HasPrefix(prop, "^") {
+			prop, negated = prop[1:], !negated
+		}
+		return &ast.UnicodePropertyEscape{Property: prop, Negated: negated}
+	case 'x':
+		p.pos++
+		if p.peek() == '{' {
+			p.pos += strings.IndexByte(p.src[p.pos:], '}') + 1
+			code := p.src[start:p.pos]
+			return &ast.Escape{EscapeType: "hex_extended", Code: code, Value: code}
+		}
+		p.pos += 2
+		code := p.src[start:p.pos]
+		return &ast.Escape{EscapeType: "hex", Code: code, Value: code}
+	}
+	if c >= '0' && c <= '7' {
+		for p.pos < start+4 && p.peek() >= '0' && p.peek() <= '7' {
+			p.pos++
+		}
+		code := p.src[start:p.pos]
+		return &ast.Escape{EscapeType: "octal", Code: code, Value: code}
+	}
+	return &ast.Literal{Text: p.next()}
+}
+
+func (p *parser) charset() ast.Node {
+	p.pos++ // [
+	cs := &ast.Charset{Items: []ast.CharsetItem{}}
+	if p.peek() == '^' {
+		cs.Inverted = true
+		p.pos++
+	}
+	// A ] right after [ or [^ is a literal, as in POSIX.
+	first := true
+	for p.more() && (first || p.peek() != ']') {
+		first = false
+		if p.has("[:") {
+			if end := strings.Index(p.src[p.pos:], ":]"); end > 0 {
+				name := p.src[p.pos+2 : p.pos+end]
+				p.pos += end + 2
+				negated := strings.HasPrefix(name, "^")
+				cs.Items = append(cs.Items, &ast.POSIXClass{Name: strings.TrimPrefix(name, "^"), Negated: negated})
+				continue
+			}
+		}
+		lo, loText := p.classAtom()
+		if p.peek() == '-' && p.pos+1 < len(p.src) && p.src[p.pos+1] != ']' && loText != "" {
+			p.pos++
+			_, hiText := p.classAtom()
+			cs.Items = append(cs.Items, &ast.CharsetRange{First: loText, Last: hiText})
+			continue
+		}
+		cs.Items = append(cs.Items, lo)
+	}
+	p.pos++ // ]
+	return cs
+}
+
+// classAtom parses one class member. Single characters, escaped or not,
+// also come back as their source text so they can bound a range.
+func (p *parser) classAtom() (ast.CharsetItem, string) {
+	if p.peek() != '\\' {
+		ch := p.next()
+		return &ast.CharsetLiteral{Text: ch}, ch
+	}
+	start := p.pos
+	switch n := p.classEscape().(type) {
+	case *ast.Literal:
+		return &ast.CharsetLiteral{Text: n.Text}, p.src[start:p.pos]
+	case *ast.Escape:
+		switch n.EscapeType {
+		case "digit", "non_digit", "whitespace", "non_whitespace", "word", "non_word":
+			return n, ""
+		}
+		return n, p.src[start:p.pos]
+	case ast.CharsetItem:
+		return n, ""
+	}
+	return &ast.CharsetLiteral{Text: p.src[start:p.pos]}, ""
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
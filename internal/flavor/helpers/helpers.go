@@ -9,6 +9,7 @@ import (
 	"strconv"
 
 	"github.com/0x4d5352/regolith/internal/ast"
+	"github.com/0x4d5352/regolith/internal/flavor"
 )
 
 // ParseInt converts a PEG match result to an int. The pigeon runtime
@@ -65,12 +66,17 @@ func collectString(v any) string {
 
 // FinalizeParse wraps the (result, err) tuple returned by a flavor's
 // generated Parse function, producing the uniform error-wrapping and
-// type-assertion that every flavor previously open-coded.
+// type-assertion that every flavor previously open-coded. A
+// *flavor.ParseError already reads "parse error: ...", so it is
+// returned as it is.
 //
 // Callers use it via argument-passing:
 //
 //	return helpers.FinalizeParse(Parse("", []byte(pattern), opts...))
 func FinalizeParse(result any, err error) (*ast.Regexp, error) {
+	if pe, ok := err.(*flavor.ParseError); ok {
+		return nil, pe
+	}
 	if err != nil {
 		return nil, fmt.Errorf("parse error: %w", err)
 	}
@@ -0,0 +1,105 @@
+package helpers
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// pigeon's Debug option prints one line per expression the parser
+// tries, straight to os.Stdout:
+//
+//	<indent>> 1:4:3: parseRule Group [U+0028 '(']
+//	<indent>MATCH 1:7:6: (ab) [U+0029 ')']
+//	<indent>< 1:7:6: parseRule Group [U+0029 ')']
+//
+// Only the rule lines are useful to someone reading a trace; the
+// expression lines in between (parseSeqExpr, parseChoiceExpr, ...)
+// outnumber them ten to one.
+var debugLine = regexp.MustCompile(`^ *(>|<|MATCH) (\d+:\d+:\d+): (.*) \[[^\]]*\]$`)
+
+// TraceParse runs parse, which must call a generated parser with the
+// Debug(true) option, and writes the grammar rule trace it prints to w:
+// one "enter" line per rule attempt and one "exit" line giving the end
+// position and either the text the rule matched or "failed", indented
+// by rule depth. Positions are line:column:offset.
+//
+// The generated parsers can only print their trace to os.Stdout, so it
+// is redirected through a pipe while parse runs. TraceParse is meant
+// for the CLI's --trace-parse and is not safe for concurrent use.
+func TraceParse(w io.Writer, parse func() (any, error)) (any, error) {
+	r, pw, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("trace: %w", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- filterTrace(r, w)
+	}()
+
+	stdout := os.Stdout
+	os.Stdout = pw
+	result, perr := parse()
+	os.Stdout = stdout
+
+	_ = pw.Close()
+	werr := <-done
+	_ = r.Close()
+	if perr == nil && werr != nil {
+		return result, fmt.Errorf("trace: %w", werr)
+	}
+	return result, perr
+}
+
+// filterTrace copies the rule lines of a pigeon debug trace from r to w.
+func filterTrace(r io.Reader, w io.Writer) error {
+	type frame struct {
+		rule    string
+		matched *string
+	}
+	var stack []frame
+	bw := bufio.NewWriter(w)
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		m := debugLine.FindStringSubmatch(sc.Text())
+		if m == nil {
+			continue
+		}
+		mark, pos, msg := m[1], m[2], m[3]
+		switch mark {
+		case ">":
+			rule, ok := strings.CutPrefix(msg, "parseRule ")
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(bw, "%senter %s at %s\n", strings.Repeat("  ", len(stack)), rule, pos)
+			stack = append(stack, frame{rule: rule})
+		case "MATCH":
+			if len(stack) > 0 {
+				text := msg
+				stack[len(stack)-1].matched = &text
+			}
+		case "<":
+			if !strings.HasPrefix(msg, "parseRule ") || len(stack) == 0 {
+				continue
+			}
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			outcome := "failed"
+			if top.matched != nil {
+				outcome = fmt.Sprintf("matched %q", *top.matched)
+			}
+			fmt.Fprintf(bw, "%sexit %s at %s %s\n", strings.Repeat("  ", len(stack)), top.rule, pos, outcome)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		// Keep draining so the parser never blocks on a full pipe.
+		_, _ = io.Copy(io.Discard, r)
+		return err
+	}
+	return bw.Flush()
+}
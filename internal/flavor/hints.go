@@ -0,0 +1,247 @@
+package flavor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Hinter is implemented by flavors with advice of their own for
+// patterns they reject, such as BRE's backslashed group syntax. It is
+// optional: callers go through Hints, which adds the flavor's hints to
+// the ones every flavor gets.
+type Hinter interface {
+	// ParseHints returns suggestions for pattern, which failed to parse.
+	ParseHints(pattern string) []string
+}
+
+// Hints returns short, actionable suggestions for a pattern that failed
+// to parse under f, for display next to the parser's own message, which
+// only lists the characters it expected. Hints are heuristics read off
+// the raw pattern — constructs f does not support, brackets that never
+// close, quantifiers with nothing to repeat — so they may name a
+// problem other than the one the parser stopped at. offset is the byte
+// offset the parser stopped at, or -1 when the error has no position;
+// when it falls inside a misspelled construct like (*SKIPP) the first
+// hint suggests the closest correct spelling. It returns nil when
+// nothing in pattern looks suspicious.
+func Hints(f Flavor, pattern string, offset int) []string {
+	var hints []string
+	seen := make(map[string]bool)
+	add := func(h string) {
+		if !seen[h] {
+			seen[h] = true
+			hints = append(hints, h)
+		}
+	}
+	if s := suggestConstruct(f, pattern, offset); s != "" {
+		add("did you mean " + s + "?")
+	}
+	features, spans := featureHints(f, pattern)
+	for _, h := range features {
+		add(h)
+	}
+	for _, h := range structureHints(pattern, Tokens(f, pattern), spans) {
+		add(h)
+	}
+	if x, ok := f.(Hinter); ok {
+		for _, h := range x.ParseHints(pattern) {
+			add(h)
+		}
+	}
+	return hints
+}
+
+// construct is a piece of Perl-style syntax that only some flavors
+// accept, matched against the tokens TokenizeSyntax produces.
+type construct struct {
+	name      string
+	kind      TokenKind
+	match     *regexp.Regexp
+	supported func(FeatureSet) bool
+}
+
+var constructs = []construct{
+	{"lookbehind (?<=...)", TokenGroup, regexp.MustCompile(`^\(\?<[=!]`), func(fs FeatureSet) bool { return fs.Lookbehind }},
+	{"lookahead (?=...)", TokenGroup, regexp.MustCompile(`^\(\?[=!]`), func(fs FeatureSet) bool { return fs.Lookahead }},
+	{"named group syntax (?<name>...)", TokenGroup, regexp.MustCompile(`^\(\?(P?<[A-Za-z_]|')`), func(fs FeatureSet) bool { return fs.NamedGroups }},
+	{"atomic group syntax (?>...)", TokenGroup, regexp.MustCompile(`^\(\?>`), func(fs FeatureSet) bool { return fs.AtomicGroups }},
+	{"inline modifier syntax like (?i)", TokenGroup, regexp.MustCompile(`^\(\?\^?[A-Za-z]*(-[A-Za-z]*)?[:)]$`), func(fs FeatureSet) bool { return fs.InlineModifiers }},
+	{"recursion syntax like (?R) or (?1)", TokenGroup, regexp.MustCompile(`^\(\?(R|[+-]?\d+|&)`), func(fs FeatureSet) bool { return fs.RecursivePatterns }},
+	{"recursion syntax like (?R) or (?1)", TokenEscape, regexp.MustCompile(`^\(\?P>`), func(fs FeatureSet) bool { return fs.RecursivePatterns }},
+	{"conditional syntax (?(cond)yes|no)", TokenGroup, regexp.MustCompile(`^\(\?\(`), func(fs FeatureSet) bool { return fs.ConditionalPatterns }},
+	{"branch reset (?|...)", TokenGroup, regexp.MustCompile(`^\(\?\|`), func(fs FeatureSet) bool { return fs.BranchReset }},
+	{"comment syntax (?#...)", TokenComment, regexp.MustCompile(`^\(\?#`), func(fs FeatureSet) bool { return fs.Comments }},
+	{"backtracking verb syntax like (*SKIP)", TokenGroup, regexp.MustCompile(`^\(\*[A-Z]`), func(fs FeatureSet) bool { return fs.BacktrackingControl }},
+	{"possessive quantifier syntax like a++", TokenQuantifier, regexp.MustCompile(`.\+$`), func(fs FeatureSet) bool { return fs.PossessiveQuantifiers }},
+	{"Unicode property syntax \\p{...}", TokenEscape, regexp.MustCompile(`^\\[pP]`), func(fs FeatureSet) bool { return fs.UnicodeProperties }},
+}
+
+// featureHints reports the Perl-style constructs in pattern that f does
+// not support, naming a flavor that does. The pattern is lexed with
+// full Perl rules rather than f's own, because f's lexer may not recognize
+// the very construct that tripped its parser. It also returns the byte
+// spans of the constructs it reported.
+func featureHints(f Flavor, pattern string) ([]string, [][2]int) {
+	fs := f.SupportedFeatures()
+	var hints []string
+	var spans [][2]int
+	for _, tok := range TokenizeSyntax(pattern, PerlSyntax) {
+		for _, c := range constructs {
+			if tok.Kind != c.kind || !c.match.MatchString(tok.Text) {
+				continue
+			}
+			if !c.supported(fs) {
+				hint := fmt.Sprintf("%s is not supported in %s", c.name, f.Name())
+				if alt := supportingFlavor(c.supported); alt != "" {
+					hint += "; try --flavor " + alt
+				}
+				hints = append(hints, hint)
+				spans = append(spans, [2]int{tok.Offset, tok.Offset + len(tok.Text)})
+			}
+			// The first match classifies the token: (?<= is
+			// lookbehind, not also a named group.
+			break
+		}
+	}
+	return hints, spans
+}
+
+// supportingFlavor names a registered flavor with the feature, favoring
+// pcre, which supports the most, and otherwise the first by name.
+func supportingFlavor(supported func(FeatureSet) bool) string {
+	all := All()
+	if p, ok := all["pcre"]; ok && supported(p.SupportedFeatures()) {
+		return "pcre"
+	}
+	for _, name := range List() {
+		if supported(all[name].SupportedFeatures()) {
+			return name
+		}
+	}
+	return ""
+}
+
+// structureHints reports brackets that never close and quantifiers with
+// nothing before them, using f's own tokens so BRE's \( \) count as
+// groups and its bare parentheses as literals. Quantifiers inside the
+// reported spans are skipped: to an ERE lexer the ? of (?<= looks like
+// one with nothing to repeat, but featureHints has already named the
+// real problem.
+func structureHints(pattern string, tokens []Token, reported [][2]int) []string {
+	var hints []string
+	var open []Token
+	prev := TokenAlternation
+	prevText := ""
+	for _, tok := range tokens {
+		switch tok.Kind {
+		case TokenGroup:
+			if closesGroup(tok.Text) {
+				if len(open) == 0 {
+					hints = append(hints, fmt.Sprintf("%s at column %d closes a group that was never opened; escape it to match it literally",
+						tok.Text, column(pattern, tok.Offset)))
+				} else {
+					open = open[:len(open)-1]
+				}
+			} else if opensGroup(tok.Text) {
+				open = append(open, tok)
+			}
+		case TokenClass:
+			if unclosedClass(tok.Text) {
+				hints = append(hints, fmt.Sprintf("the character class opened at column %d is never closed with ]; write \\[ to match a literal bracket",
+					column(pattern, tok.Offset)))
+			}
+		case TokenQuantifier:
+			if inSpans(tok.Offset, reported) {
+				break
+			}
+			if prev == TokenAlternation || (prev == TokenGroup && prevText != ")" && prevText != `\)`) {
+				hints = append(hints, fmt.Sprintf("quantifier %s at column %d has nothing to repeat; escape it to match it literally",
+					tok.Text, column(pattern, tok.Offset)))
+			}
+		}
+		if tok.Kind != TokenComment {
+			prev, prevText = tok.Kind, tok.Text
+		}
+	}
+	for _, tok := range open {
+		closer := ")"
+		if strings.HasPrefix(tok.Text, `\(`) {
+			closer = `\)`
+		}
+		hints = append(hints, fmt.Sprintf("the group opened at column %d is never closed; add %s",
+			column(pattern, tok.Offset), closer))
+	}
+	return hints
+}
+
+// closesGroup reports whether the group token text closes a group.
+func closesGroup(text string) bool {
+	return text == ")" || text == `\)`
+}
+
+// opensGroup reports whether the group token text opens a group that a
+// later token closes. (?(1) ends in a parenthesis but still opens the
+// conditional; (?i) and (?R) are complete.
+func opensGroup(text string) bool {
+	return !strings.HasSuffix(text, ")") || strings.HasPrefix(text, "(?(")
+}
+
+// unclosedClass reports whether the class token text is a bracket
+// expression that runs to the end of the pattern without its ].
+func unclosedClass(text string) bool {
+	return strings.HasPrefix(text, "[") && (len(text) < 2 || !strings.HasSuffix(text, "]"))
+}
+
+// inSpans reports whether offset falls inside one of spans.
+func inSpans(offset int, spans [][2]int) bool {
+	for _, sp := range spans {
+		if offset >= sp[0] && offset < sp[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// column converts a byte offset in pattern to the 1-based rune column
+// the parsers report.
+func column(pattern string, offset int) int {
+	return utf8.RuneCountInString(pattern[:offset]) + 1
+}
+
+// posixShorthands maps the Perl shorthand escapes to the bracket
+// expressions POSIX flavors spell them with.
+var posixShorthands = map[byte]string{
+	'd': "[[:digit:]]", 'D': "[^[:digit:]]",
+	'w': "[[:alnum:]_]", 'W': "[^[:alnum:]_]",
+	's': "[[:space:]]", 'S': "[^[:space:]]",
+}
+
+// ShorthandHints suggests a POSIX bracket expression for each shorthand
+// escape in tokens whose letter is in codes, for flavors that lack
+// them. It is a building block for Hinter implementations.
+func ShorthandHints(name string, tokens []Token, codes string) []string {
+	var hints []string
+	for _, tok := range tokens {
+		if tok.Kind != TokenEscape || len(tok.Text) != 2 || !strings.ContainsRune(codes, rune(tok.Text[1])) {
+			continue
+		}
+		if class, ok := posixShorthands[tok.Text[1]]; ok {
+			hints = append(hints, fmt.Sprintf("%s is not supported in %s; use %s", tok.Text, name, class))
+		}
+	}
+	return hints
+}
+
+// LiteralsContain reports whether any literal token contains one of the
+// characters in chars — in a BRE, a bare ( or + the author probably
+// meant as an operator.
+func LiteralsContain(tokens []Token, chars string) bool {
+	for _, tok := range tokens {
+		if tok.Kind == TokenLiteral && strings.ContainsAny(tok.Text, chars) {
+			return true
+		}
+	}
+	return false
+}
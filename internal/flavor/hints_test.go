@@ -0,0 +1,80 @@
+package flavor_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/0x4d5352/regolith/internal/flavor"
+)
+
+func TestHints(t *testing.T) {
+	tests := []struct {
+		flavor  string
+		pattern string
+		want    []string // substrings, one per expected hint, in order
+	}{
+		{"posix-ere", `(?<=a)b`, []string{"lookbehind (?<=...) is not supported in posix-ere; try --flavor pcre"}},
+		{"javascript", `(?>a)b++`, []string{"atomic group syntax", "possessive quantifier syntax"}},
+		{"java", `(?(1)a|b)`, []string{"conditional syntax (?(cond)yes|no) is not supported in java"}},
+		{"javascript", `(?P<x>a)`, []string{"(?<name>...), not (?P<name>...)"}},
+		{"pcre", `a(b`, []string{"group opened at column 2 is never closed; add )"}},
+		{"pcre", `a)b`, []string{") at column 2 closes a group that was never opened"}},
+		{"pcre", `a[b`, []string{"character class opened at column 2"}},
+		{"pcre", `x|*a`, []string{"quantifier * at column 3 has nothing to repeat"}},
+		{"posix-bre", `a\(b`, []string{`never closed; add \)`}},
+		{"posix-bre", `(a|b)+\)`, []string{`\) at column 7`, `use \( \) for groups`}},
+		{"posix-ere", `\d\s`, []string{"use [[:digit:]]", "use [[:space:]]"}},
+		{"pcre", `a(?i)b`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.flavor+" "+tt.pattern, func(t *testing.T) {
+			f, ok := flavor.Get(tt.flavor)
+			if !ok {
+				t.Fatalf("flavor %s not registered", tt.flavor)
+			}
+			got := flavor.Hints(f, tt.pattern, -1)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d hints %q, want %d", len(got), got, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(got[i], want) {
+					t.Errorf("hint %d = %q, want it to contain %q", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestHintsSuggestConstruct(t *testing.T) {
+	tests := []struct {
+		flavor  string
+		pattern string
+		offset  int
+		want    string // "" means no suggestion
+	}{
+		{"pcre", `(*SKIPP)a`, 7, "did you mean (*SKIP)?"},
+		{"pcre", `(*UFT)a`, 2, "did you mean (*UTF)?"},
+		{"pcre", `(*LIMIT_MACH=1)a`, 2, "did you mean (*LIMIT_MATCH=1)?"},
+		{"pcre", `(*atomc:a)`, 2, "did you mean (*atomic:...)?"},
+		{"pcre", `(?P<year)\d+`, 8, "did you mean (?P<year>...)?"},
+		{"pcre", `(?P=year`, 8, "did you mean (?P=year)?"},
+		{"pcre", `(*SKIP)a)`, 8, ""},
+		{"pcre", `(*X)`, 2, ""},
+		{"java", `(*SKIPP)a`, 1, ""},
+		{"golang", `(?=a)`, 0, ""},
+		{"pcre", `(*SKIPP)a`, -1, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.flavor+" "+tt.pattern, func(t *testing.T) {
+			f, _ := flavor.Get(tt.flavor)
+			hints := flavor.Hints(f, tt.pattern, tt.offset)
+			got := ""
+			if len(hints) > 0 && strings.HasPrefix(hints[0], "did you mean") {
+				got = hints[0]
+			}
+			if got != tt.want {
+				t.Errorf("suggestion = %q, want %q (hints %q)", got, tt.want, hints)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package java
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+	"github.com/0x4d5352/regolith/internal/flavor"
+	"github.com/0x4d5352/regolith/internal/flavor/helpers"
+)
+
+// compileFlags are the java.util.regex.Pattern flag constants, with
+// their inline letters where Java has one. The descriptions are short
+// enough for the diagram's flags panel.
+var compileFlags = []flavor.FlagInfo{
+	{Char: 'd', Name: "UNIX_LINES", Description: "only \\n ends a line"},
+	{Char: 'i', Name: "CASE_INSENSITIVE", Description: "ignore case (US-ASCII)"},
+	{Char: 'x', Name: "COMMENTS", Description: "whitespace and #comments ignored"},
+	{Char: 'm', Name: "MULTILINE", Description: "^ and $ match at lines"},
+	{Name: "LITERAL", Description: "whole pattern is literal text"},
+	{Char: 's', Name: "DOTALL", Description: ". matches line terminators"},
+	{Char: 'u', Name: "UNICODE_CASE", Description: "Unicode case folding"},
+	{Name: "CANON_EQ", Description: "canonically equivalent text matches"},
+	{Char: 'U', Name: "UNICODE_CHARACTER_CLASS", Description: "Unicode \\w, \\d, \\s, \\b"},
+}
+
+// CompileFlags lists the Pattern.compile flags ParseWithFlags accepts.
+func (j *Java) CompileFlags() []flavor.FlagInfo {
+	return compileFlags
+}
+
+// ParseWithFlags parses pattern as Pattern.compile(pattern, flags)
+// would read it. Flag names may be written with or without the
+// "Pattern." prefix.
+//
+// LITERAL makes the whole pattern one quoted literal, as \Q...\E would;
+// of the other flags only CASE_INSENSITIVE and UNICODE_CASE still
+// matter then, though all are recorded. COMMENTS drops whitespace and
+// #-comments before parsing, everywhere but in escapes and \Q...\E,
+// character classes included, as Java does. CANON_EQ changes matching
+// only and is recorded without affecting the tree.
+func (j *Java) ParseWithFlags(pattern string, flags []string) (*ast.Regexp, error) {
+	var letters strings.Builder
+	set := make(map[string]bool)
+	for _, name := range flags {
+		info, ok := lookupCompileFlag(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown Java compile flag %q", name)
+		}
+		set[info.Name] = true
+		if info.Char != 0 && !strings.ContainsRune(letters.String(), info.Char) {
+			letters.WriteRune(info.Char)
+		}
+	}
+
+	var root *ast.Regexp
+	switch {
+	case set["LITERAL"]:
+		root = &ast.Regexp{Matches: []*ast.Match{{Fragments: []*ast.MatchFragment{
+			{Content: &ast.QuotedLiteral{Text: pattern}},
+		}}}}
+	case set["COMMENTS"]:
+		// The tree comes from the stripped body, so its nodes get no
+		// positions in pattern.
+		body, offsets := stripComments(pattern)
+		result, err := Parse("", []byte(body), GlobalStore("state", ast.NewParserState()))
+		if err != nil {
+			restoreErrorPositions(err, pattern, offsets)
+		}
+		if root, err = helpers.FinalizeParse(result, err); err != nil {
+			return nil, parseError(err)
+		}
+	default:
+		var err error
+		if root, err = j.Parse(pattern); err != nil {
+			return nil, err
+		}
+	}
+	root.Flags = letters.String()
+	return root, nil
+}
+
+// lookupCompileFlag finds a compile flag by name.
+func lookupCompileFlag(name string) (flavor.FlagInfo, bool) {
+	name = strings.TrimPrefix(strings.TrimSpace(name), "Pattern.")
+	for _, info := range compileFlags {
+		if strings.EqualFold(info.Name, name) {
+			return info, true
+		}
+	}
+	return flavor.FlagInfo{}, false
+}
+
+// stripComments removes the whitespace and #-comments that COMMENTS
+// mode ignores. offsets[i] is the offset in pattern of byte i of the
+// result; the extra final entry maps the end of the result.
+func stripComments(pattern string) (string, []int) {
+	var out strings.Builder
+	var offsets []int
+	keep := func(from, to int) {
+		out.WriteString(pattern[from:to])
+		for k := from; k < to; k++ {
+			offsets = append(offsets, k)
+		}
+	}
+	for i := 0; i < len(pattern); {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], `\Q`):
+			end := strings.Index(pattern[i+2:], `\E`)
+			if end < 0 {
+				keep(i, len(pattern))
+				i = len(pattern)
+			} else {
+				keep(i, i+2+end+2)
+				i += 2 + end + 2
+			}
+		case c == '\\' && i+1 < len(pattern):
+			_, size := utf8.DecodeRuneInString(pattern[i+1:])
+			keep(i, i+1+size)
+			i += 1 + size
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v':
+			i++
+		case c == '#':
+			if nl := strings.IndexByte(pattern[i:], '\n'); nl >= 0 {
+				i += nl + 1
+			} else {
+				i = len(pattern)
+			}
+		default:
+			keep(i, i+1)
+			i++
+		}
+	}
+	return out.String(), append(offsets, len(pattern))
+}
+
+// restoreErrorPositions moves the positions in a parse error of the
+// stripped pattern back to where they fall in the pattern as written.
+func restoreErrorPositions(err error, pattern string, offsets []int) {
+	var list errList
+	if !errors.As(err, &list) {
+		return
+	}
+	for _, e := range list {
+		pe, ok := e.(*parserError)
+		if !ok || pe.pos.offset < 0 || pe.pos.offset >= len(offsets) {
+			continue
+		}
+		old := fmt.Sprintf("%d:%d (%d)", pe.pos.line, pe.pos.col, pe.pos.offset)
+		offset := offsets[pe.pos.offset]
+		before := pattern[:offset]
+		pe.pos.line = strings.Count(before, "\n") + 1
+		pe.pos.col = utf8.RuneCountInString(before[strings.LastIndexByte(before, '\n')+1:]) + 1
+		pe.pos.offset = offset
+		pe.prefix = strings.Replace(pe.prefix, old,
+			fmt.Sprintf("%d:%d (%d)", pe.pos.line, pe.pos.col, pe.pos.offset), 1)
+	}
+}
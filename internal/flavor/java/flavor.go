@@ -4,6 +4,9 @@
 package java
 
 import (
+	"io"
+	"strings"
+
 	"github.com/0x4d5352/regolith/internal/ast"
 	"github.com/0x4d5352/regolith/internal/flavor"
 	"github.com/0x4d5352/regolith/internal/flavor/helpers"
@@ -25,23 +28,91 @@ func (j *Java) Description() string {
 	return "Java (java.util.regex.Pattern) regular expressions"
 }
 
+// Family returns the flavor's syntax family.
+func (j *Java) Family() flavor.Family {
+	return flavor.FamilyPerl
+}
+
+// Notes returns what `regolith flavors` says about the flavor beyond
+// its FeatureSet.
+func (j *Java) Notes() flavor.Notes {
+	return flavor.Notes{
+		Examples: map[string]string{
+			"posix_classes":  `\p{Alpha}+`,
+			"set_operations": `[\w&&\D]`,
+		},
+		Limitations: []string{
+			`Nested classes in a set operation, as in [a-z&&[^aeiou]], are rejected`,
+			`Named characters such as \N{WHITE SMILING FACE} are rejected`,
+			"Flags passed to Pattern.compile in code are not visible in the pattern; give them with --java-flags",
+		},
+		Unread: []string{"named_unicode_escapes"},
+	}
+}
+
 // Parse parses a Java regex pattern and returns an AST.
 func (j *Java) Parse(pattern string) (*ast.Regexp, error) {
 	state := ast.NewParserState()
-	return helpers.FinalizeParse(Parse("", []byte(pattern), GlobalStore("state", state)))
+	root, err := helpers.FinalizeParse(Parse("", []byte(pattern), GlobalStore("state", state)))
+	flavor.Locate(j, pattern, root)
+	return root, flavor.Pinpoint(j, pattern, parseError(err))
+}
+
+// ParseTrace is Parse with the grammar's rule trace written to w.
+func (j *Java) ParseTrace(pattern string, w io.Writer) (*ast.Regexp, error) {
+	state := ast.NewParserState()
+	root, err := helpers.FinalizeParse(helpers.TraceParse(w, func() (any, error) {
+		return Parse("", []byte(pattern), GlobalStore("state", state), Debug(true))
+	}))
+	flavor.Locate(j, pattern, root)
+	return root, flavor.Pinpoint(j, pattern, parseError(err))
 }
 
 // SupportedFlags returns information about valid flags for Java.
 func (j *Java) SupportedFlags() []flavor.FlagInfo {
 	return []flavor.FlagInfo{
-		{Char: 'd', Name: "UNIX_LINES", Description: "Only \\n is recognized as line terminator"},
-		{Char: 'i', Name: "CASE_INSENSITIVE", Description: "Case-insensitive matching (US-ASCII)"},
-		{Char: 'm', Name: "MULTILINE", Description: "^ and $ match at line boundaries"},
-		{Char: 's', Name: "DOTALL", Description: ". matches any character including line terminators"},
-		{Char: 'u', Name: "UNICODE_CASE", Description: "Unicode-aware case folding"},
-		{Char: 'x', Name: "COMMENTS", Description: "Permit whitespace and comments in pattern"},
-		{Char: 'U', Name: "UNICODE_CHARACTER_CLASS", Description: "Unicode version of predefined character classes"},
+		{Char: 'd', Name: "UNIX_LINES", Description: "Only \\n is recognized as line terminator", DocURL: javadocPattern + "UNIX_LINES"},
+		{Char: 'i', Name: "CASE_INSENSITIVE", Description: "Case-insensitive matching (US-ASCII)", DocURL: javadocPattern + "CASE_INSENSITIVE"},
+		{Char: 'm', Name: "MULTILINE", Description: "^ and $ match at line boundaries", DocURL: javadocPattern + "MULTILINE"},
+		{Char: 's', Name: "DOTALL", Description: ". matches any character including line terminators", DocURL: javadocPattern + "DOTALL"},
+		{Char: 'u', Name: "UNICODE_CASE", Description: "Unicode-aware case folding", DocURL: javadocPattern + "UNICODE_CASE"},
+		{Char: 'x', Name: "COMMENTS", Description: "Permit whitespace and comments in pattern", DocURL: javadocPattern + "COMMENTS"},
+		{Char: 'U', Name: "UNICODE_CHARACTER_CLASS", Description: "Unicode version of predefined character classes", DocURL: javadocPattern + "UNICODE_CHARACTER_CLASS"},
+	}
+}
+
+// javadocPattern is the java.util.regex.Pattern reference; a flag
+// constant's name is its anchor.
+const javadocPattern = "https://docs.oracle.com/en/java/javase/21/docs/api/java.base/java/util/regex/Pattern.html#"
+
+// Tokenize splits a Java pattern into syntax-highlighting tokens.
+func (j *Java) Tokenize(pattern string) []flavor.Token {
+	return flavor.TokenizeSyntax(pattern, flavor.Syntax{Perl: true, Quote: true, Possessive: true, StringAnchors: true})
+}
+
+// ShorthandSet describes Java's shorthand classes: ASCII by default,
+// and the Unicode definitions from UTS #18 once UNICODE_CHARACTER_CLASS
+// is turned on with (?U) or the compile flag.
+func (j *Java) ShorthandSet(code string, root *ast.Regexp) (flavor.ShorthandSet, bool) {
+	if !flavor.HasInlineFlag(root, 'U') && !strings.ContainsRune(root.Flags, 'U') {
+		if code == "s" {
+			return flavor.ShorthandSet{Mode: flavor.ShorthandASCII, Members: `[ \t\n\x0B\f\r]`}, true
+		}
+		set, ok := flavor.ASCIIShorthands[code]
+		return set, ok
+	}
+	switch code {
+	case "d":
+		return flavor.ShorthandSet{Mode: flavor.ShorthandUnicode, Members: `\p{Nd}`}, true
+	case "w":
+		return flavor.ShorthandSet{
+			Mode:    flavor.ShorthandUnicode,
+			Members: `[\p{Alpha}\p{Mn}\p{Me}\p{Mc}\p{Nd}\p{Pc}\p{Join_Control}]`,
+		}, true
+	case "s":
+		return flavor.ShorthandSet{Mode: flavor.ShorthandUnicode, Members: `\p{White_Space}`}, true
 	}
+	return flavor.ShorthandSet{}, false
 }
 
 // SupportedFeatures returns the feature capabilities of Java regex.
@@ -62,10 +133,20 @@ func (j *Java) SupportedFeatures() flavor.FeatureSet {
 		Comments:              true,
 		BranchReset:           false,
 		BacktrackingControl:   false,
+		Callouts:              false,
+		ScriptRuns:            false,
+		NonAtomicLookaround:   false,
+		PatternStartOptions:   false,
+		UnicodeSets:           false,
+		OctalEscapes:          true, // \0n, \0nn, \0mnn
+		HexBracedEscapes:      true,
+		NamedUnicodeEscapes:   true, // Java 9+
+		RelativeBackrefs:      false,
+		SetOperations:         true, // && intersection
 	}
 }
 
 // init registers the Java flavor with the registry.
 func init() {
-	flavor.Register(&Java{})
+	flavor.MustRegister(&Java{})
 }
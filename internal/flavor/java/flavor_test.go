@@ -1,7 +1,10 @@
 package java
 
 import (
+	"strings"
 	"testing"
+
+	"github.com/0x4d5352/regolith/internal/ast"
 )
 
 func TestBasicParsing(t *testing.T) {
@@ -251,3 +254,56 @@ func TestInlineModifiers(t *testing.T) {
 		})
 	}
 }
+
+func TestParseWithFlags(t *testing.T) {
+	j := &Java{}
+
+	t.Run("LITERAL quotes the whole pattern", func(t *testing.T) {
+		root, err := j.ParseWithFlags(`a.b*(`, []string{"LITERAL", "Pattern.CASE_INSENSITIVE"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		q, ok := root.Matches[0].Fragments[0].Content.(*ast.QuotedLiteral)
+		if !ok || q.Text != `a.b*(` {
+			t.Errorf("content = %#v, want quoted literal a.b*(", root.Matches[0].Fragments[0].Content)
+		}
+		if root.Flags != "i" {
+			t.Errorf("Flags = %q, want %q", root.Flags, "i")
+		}
+	})
+
+	t.Run("COMMENTS ignores whitespace and comments", func(t *testing.T) {
+		root, err := j.ParseWithFlags("a b # note\n[c ]\\ ", []string{"comments"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		frags := root.Matches[0].Fragments
+		if len(frags) != 3 {
+			t.Fatalf("got %d fragments, want literal ab, a class, and an escaped space", len(frags))
+		}
+		if cs, ok := frags[1].Content.(*ast.Charset); !ok || len(cs.Items) != 1 {
+			t.Errorf("class = %#v, want [c] with the space dropped", frags[1].Content)
+		}
+		if root.Flags != "x" {
+			t.Errorf("Flags = %q, want %q", root.Flags, "x")
+		}
+	})
+
+	t.Run("COMMENTS error positions", func(t *testing.T) {
+		_, err := j.ParseWithFlags("a  b  (", []string{"COMMENTS"})
+		if err == nil {
+			t.Fatal("expected a parse error")
+		}
+		// The stripped pattern "ab(" fails at offset 3, its end, which
+		// is offset 7 of the pattern as written.
+		if !strings.Contains(err.Error(), "1:8 (7)") {
+			t.Errorf("error position not mapped back: %v", err)
+		}
+	})
+
+	t.Run("unknown flag", func(t *testing.T) {
+		if _, err := j.ParseWithFlags("a", []string{"GLOBAL"}); err == nil {
+			t.Error("expected an error for an unknown flag")
+		}
+	})
+}
@@ -1,7 +1,10 @@
 package java
 
 import (
+	"errors"
+
 	"github.com/0x4d5352/regolith/internal/ast"
+	"github.com/0x4d5352/regolith/internal/flavor"
 	"github.com/0x4d5352/regolith/internal/flavor/helpers"
 )
 
@@ -114,3 +117,22 @@ func makeAnchor(code string) *ast.Anchor {
 		return &ast.Anchor{AnchorType: code}
 	}
 }
+
+// parseError turns the generated parser's error into a
+// *flavor.ParseError carrying the first error's position and the
+// alternatives the grammar expected there. Other errors pass through.
+func parseError(err error) error {
+	var list errList
+	var pe *parserError
+	if !errors.As(err, &list) || len(list) == 0 || !errors.As(list[0], &pe) {
+		return err
+	}
+	return &flavor.ParseError{
+		Offset:   pe.pos.offset,
+		Line:     pe.pos.line,
+		Col:      pe.pos.col,
+		Message:  pe.Inner.Error(),
+		Expected: pe.expected,
+		Err:      pe.Inner,
+	}
+}
@@ -3,6 +3,9 @@
 package javascript
 
 import (
+	"io"
+	"strings"
+
 	"github.com/0x4d5352/regolith/internal/ast"
 	"github.com/0x4d5352/regolith/internal/flavor"
 	"github.com/0x4d5352/regolith/internal/flavor/helpers"
@@ -24,24 +27,128 @@ func (j *JavaScript) Description() string {
 	return "JavaScript (ECMAScript 2018+) regular expressions"
 }
 
+// Family returns the flavor's syntax family.
+func (j *JavaScript) Family() flavor.Family {
+	return flavor.FamilyPerl
+}
+
+// Notes returns what `regolith flavors` says about the flavor beyond
+// its FeatureSet.
+func (j *JavaScript) Notes() flavor.Notes {
+	return flavor.Notes{
+		Examples: map[string]string{
+			"unicode_properties": `/\p{L}+/u`,
+			"hex_braced_escapes": `/\u{263A}/u`,
+			"set_operations":     `/[a-z--[aeiou]]/v`,
+		},
+		Limitations: []string{
+			"ES2025 modifier groups such as (?i:abc) are rejected",
+			"A bare pattern is read with no flags; write /pattern/flags to give them",
+		},
+		Notes: []string{
+			"--js-target checks a pattern against an older ECMAScript edition",
+		},
+	}
+}
+
 // Parse parses a JavaScript regex pattern and returns an AST.
 func (j *JavaScript) Parse(pattern string) (*ast.Regexp, error) {
 	state := ast.NewParserState()
-	return helpers.FinalizeParse(Parse("", []byte(pattern), GlobalStore("state", state)))
+	root, err := helpers.FinalizeParse(Parse("", []byte(pattern), GlobalStore("state", state)))
+	flavor.Locate(j, pattern, root)
+	return root, flavor.Pinpoint(j, pattern, parseError(err))
+}
+
+// ParseTrace is Parse with the grammar's rule trace written to w.
+func (j *JavaScript) ParseTrace(pattern string, w io.Writer) (*ast.Regexp, error) {
+	state := ast.NewParserState()
+	root, err := helpers.FinalizeParse(helpers.TraceParse(w, func() (any, error) {
+		return Parse("", []byte(pattern), GlobalStore("state", state), Debug(true))
+	}))
+	flavor.Locate(j, pattern, root)
+	return root, flavor.Pinpoint(j, pattern, parseError(err))
 }
 
 // SupportedFlags returns information about valid flags for JavaScript.
 func (j *JavaScript) SupportedFlags() []flavor.FlagInfo {
 	return []flavor.FlagInfo{
-		{Char: 'd', Name: "hasIndices", Description: "Generate indices for substring matches"},
-		{Char: 'g', Name: "global", Description: "Find all matches rather than stopping after the first"},
-		{Char: 'i', Name: "ignoreCase", Description: "Case-insensitive matching"},
-		{Char: 'm', Name: "multiline", Description: "^ and $ match line boundaries"},
-		{Char: 's', Name: "dotAll", Description: ". matches newlines"},
-		{Char: 'u', Name: "unicode", Description: "Enable full Unicode matching"},
-		{Char: 'y', Name: "sticky", Description: "Matches only from the lastIndex property"},
-		{Char: 'v', Name: "unicodeSets", Description: "Enable set notation and properties of strings"},
+		{Char: 'd', Name: "hasIndices", Description: "Generate indices for substring matches",
+			DocURL: mdnRegExp + "hasIndices", Localized: map[string]flavor.FlagText{
+				"de": {Label: "Indizes", Description: "Liefert Start- und Endindizes der Teiltreffer"},
+			}},
+		{Char: 'g', Name: "global", Description: "Find all matches rather than stopping after the first",
+			DocURL: mdnRegExp + "global", Localized: map[string]flavor.FlagText{
+				"de": {Label: "global", Description: "Findet alle Treffer statt nur den ersten"},
+			}},
+		{Char: 'i', Name: "ignoreCase", Description: "Case-insensitive matching", Label: "ignore case",
+			DocURL: mdnRegExp + "ignoreCase", Localized: map[string]flavor.FlagText{
+				"de": {Label: "ohne Groß/klein", Description: "Ignoriert Groß- und Kleinschreibung"},
+			}},
+		{Char: 'm', Name: "multiline", Description: "^ and $ match line boundaries",
+			DocURL: mdnRegExp + "multiline", Localized: map[string]flavor.FlagText{
+				"de": {Label: "mehrzeilig", Description: "^ und $ passen an Zeilengrenzen"},
+			}},
+		{Char: 's', Name: "dotAll", Description: ". matches newlines",
+			DocURL: mdnRegExp + "dotAll", Localized: map[string]flavor.FlagText{
+				"de": {Description: ". passt auch auf Zeilenumbrüche"},
+			}},
+		{Char: 'u', Name: "unicode", Description: "Enable full Unicode matching",
+			DocURL: mdnRegExp + "unicode", Localized: map[string]flavor.FlagText{
+				"de": {Label: "Unicode", Description: "Vollständige Unicode-Unterstützung"},
+			}},
+		{Char: 'y', Name: "sticky", Description: "Matches only from the lastIndex property",
+			DocURL: mdnRegExp + "sticky", Localized: map[string]flavor.FlagText{
+				"de": {Label: "haftend", Description: "Sucht nur ab der Position lastIndex"},
+			}},
+		{Char: 'v', Name: "unicodeSets", Description: "Enable set notation and properties of strings",
+			DocURL: mdnRegExp + "unicodeSets", Localized: map[string]flavor.FlagText{
+				"de": {Description: "Mengenoperationen und Eigenschaften von Zeichenketten"},
+			}},
+	}
+}
+
+// mdnRegExp is the MDN reference for RegExp's flag accessors.
+const mdnRegExp = "https://developer.mozilla.org/en-US/docs/Web/JavaScript/Reference/Global_Objects/RegExp/"
+
+// Tokenize splits a JavaScript pattern into syntax-highlighting tokens.
+func (j *JavaScript) Tokenize(pattern string) []flavor.Token {
+	return flavor.TokenizeSyntax(pattern, flavor.Syntax{Perl: true, SlashDelimited: true})
+}
+
+// ParseHints points Python-style named group syntax, which JavaScript
+// rejects, at the spelling it accepts.
+func (j *JavaScript) ParseHints(pattern string) []string {
+	for _, tok := range j.Tokenize(pattern) {
+		if strings.HasPrefix(tok.Text, "(?P<") {
+			return []string{"JavaScript spells named groups (?<name>...), not (?P<name>...)"}
+		}
+		if strings.HasPrefix(tok.Text, "(?P=") {
+			return []string{"JavaScript spells named back-references \\k<name>, not (?P=name)"}
+		}
+	}
+	return nil
+}
+
+// ShorthandSet describes JavaScript's shorthand classes. \d and \w are
+// ASCII-only even with the u or v flag; the only change those flags
+// make is that case-insensitive \w also takes in the two non-ASCII
+// characters that case-fold into it. \s has always been Unicode-aware.
+func (j *JavaScript) ShorthandSet(code string, root *ast.Regexp) (flavor.ShorthandSet, bool) {
+	switch code {
+	case "w":
+		if strings.ContainsRune(root.Flags, 'i') && strings.ContainsAny(root.Flags, "uv") {
+			return flavor.ShorthandSet{Mode: flavor.ShorthandUnicode, Members: `[A-Za-z0-9_\u017F\u212A]`}, true
+		}
+		return flavor.ASCIIShorthands["w"], true
+	case "d":
+		return flavor.ASCIIShorthands["d"], true
+	case "s":
+		return flavor.ShorthandSet{
+			Mode:    flavor.ShorthandUnicode,
+			Members: `[\t\n\v\f\r \u00A0\u1680\u2000-\u200A\u2028\u2029\u202F\u205F\u3000\uFEFF]`,
+		}, true
 	}
+	return flavor.ShorthandSet{}, false
 }
 
 // SupportedFeatures returns the feature capabilities of JavaScript regex.
@@ -63,10 +170,21 @@ func (j *JavaScript) SupportedFeatures() flavor.FeatureSet {
 		BranchReset:           false,
 		BacktrackingControl:   false,
 		UnicodeSets:           true,
+		Callouts:              false,
+		ScriptRuns:            false,
+		NonAtomicLookaround:   false,
+		PatternStartOptions:   false,
+		OctalEscapes:          true, // Annex B legacy octal, outside u and v modes
+		HexBracedEscapes:      true, // \u{...} in u and v modes
+		NamedUnicodeEscapes:   false,
+		RelativeBackrefs:      false,
+		SetOperations:         true, // v mode only
 	}
 }
 
 // init registers the JavaScript flavor with the registry.
 func init() {
-	flavor.Register(&JavaScript{})
+	flavor.MustRegister(&JavaScript{})
+	flavor.MustRegisterAlias("js", "javascript")
+	flavor.MustRegisterAlias("ecmascript", "javascript")
 }
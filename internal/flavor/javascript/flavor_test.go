@@ -1,6 +1,7 @@
 package javascript
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/0x4d5352/regolith/internal/ast"
@@ -413,3 +414,39 @@ func TestJavaScriptFlavorInList(t *testing.T) {
 		t.Error("JavaScript flavor not found in List()")
 	}
 }
+
+func TestJavaScriptCheckVersion(t *testing.T) {
+	tests := []struct {
+		pattern string
+		target  string
+		wantErr string // empty for no error
+	}{
+		{`/a+b/gim`, "es5", ""},
+		{`/a/y`, "es5", "the y (sticky) flag requires ES2015"},
+		{`/(?<=\$)\d+/`, "es2015", "lookbehind requires ES2018"},
+		{`/(?<=\$)\d+/`, "es2018", ""},
+		{`/(?<year>\d{4})/`, "es6", "named group (?<year>...) requires ES2018"},
+		{`/[\p{L}\d]/u`, "es2015", "Unicode property escape requires ES2018"},
+		{`/a/d`, "es2021", "the d (hasIndices) flag requires ES2022"},
+		{`/[\w--\d]/v`, "es2022", "the v (unicodeSets) flag requires ES2024"},
+		{`/(?<n>a)|(?<n>b)/`, "es2024", `duplicate group name "n" requires ES2025`},
+		{`/(?<n>a)|(?<n>b)/`, "es2025", ""},
+		{`/a/`, "es3", "invalid ECMAScript target"},
+	}
+	j := &JavaScript{}
+	for _, tt := range tests {
+		t.Run(tt.pattern+"@"+tt.target, func(t *testing.T) {
+			root, err := j.Parse(tt.pattern)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			err = j.CheckVersion(root, tt.target)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package javascript
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+)
+
+// targets are the ECMAScript editions that changed regex syntax, oldest
+// first. ES5 is what the oldest browsers still in support contracts
+// (Internet Explorer 11) implement.
+var targets = []string{"es5", "es2015", "es2018", "es2022", "es2024", "es2025"}
+
+// Versions lists the ECMAScript targets CheckVersion knows about.
+func (j *JavaScript) Versions() []string {
+	return targets
+}
+
+// requirement is a construct found in a pattern and the first edition
+// that accepts it, as a year.
+type requirement struct {
+	construct string
+	year      int
+}
+
+// CheckVersion reports the first construct in root that the given
+// ECMAScript edition rejects: es5, es6, or esYYYY for 2015 and later.
+func (j *JavaScript) CheckVersion(root *ast.Regexp, target string) error {
+	year, err := parseTarget(target)
+	if err != nil {
+		return err
+	}
+	for _, req := range requirements(root) {
+		if req.year > year {
+			return fmt.Errorf("%s requires ES%d or later (checking against %s)", req.construct, req.year, target)
+		}
+	}
+	return nil
+}
+
+// parseTarget returns the year of an ECMAScript edition name. ES5
+// predates the yearly editions and is given the year it was published.
+func parseTarget(target string) (int, error) {
+	name := strings.ToLower(target)
+	switch name {
+	case "es5":
+		return 2009, nil
+	case "es6":
+		return 2015, nil
+	}
+	if rest, ok := strings.CutPrefix(name, "es"); ok {
+		if year, err := strconv.Atoi(rest); err == nil && year >= 2015 {
+			return year, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid ECMAScript target %q: want one of %s", target, strings.Join(targets, ", "))
+}
+
+// flagEditions gives the edition that introduced each flag newer than
+// ES5's g, i, and m.
+var flagEditions = []struct {
+	flag rune
+	name string
+	year int
+}{
+	{'u', "unicode", 2015},
+	{'y', "sticky", 2015},
+	{'s', "dotAll", 2018},
+	{'d', "hasIndices", 2022},
+	{'v', "unicodeSets", 2024},
+}
+
+// requirements lists the edition-gated constructs in root: its flags
+// first, then the pattern body in order.
+func requirements(root *ast.Regexp) []requirement {
+	var out []requirement
+	for _, fe := range flagEditions {
+		if strings.ContainsRune(root.Flags, fe.flag) {
+			out = append(out, requirement{fmt.Sprintf("the %c (%s) flag", fe.flag, fe.name), fe.year})
+		}
+	}
+	names := make(map[string]bool)
+	ast.Walk(root, func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.Subexp:
+			switch n.GroupType {
+			case ast.GroupPositiveLookbehind, ast.GroupNegativeLookbehind:
+				out = append(out, requirement{"lookbehind", 2018})
+			case ast.GroupNamedCapture:
+				out = append(out, requirement{"named group (?<" + n.Name + ">...)", 2018})
+				if names[n.Name] {
+					out = append(out, requirement{"duplicate group name " + strconv.Quote(n.Name), 2025})
+				}
+				names[n.Name] = true
+			}
+		case *ast.BackReference:
+			if n.Name != "" {
+				out = append(out, requirement{`named backreference \k<` + n.Name + `>`, 2018})
+			}
+		case *ast.Charset:
+			for _, item := range n.Items {
+				if req, ok := escapeRequirement(item); ok {
+					out = append(out, req)
+				}
+			}
+		default:
+			if req, ok := escapeRequirement(n); ok {
+				out = append(out, req)
+			}
+		}
+	})
+	return out
+}
+
+// escapeRequirement reports the edition needed by an escape, which may
+// stand alone or inside a character class.
+func escapeRequirement(n any) (requirement, bool) {
+	switch n := n.(type) {
+	case *ast.UnicodePropertyEscape:
+		return requirement{"Unicode property escape", 2018}, true
+	case *ast.Escape:
+		if n.EscapeType == "unicode_braced" {
+			return requirement{n.Code, 2015}, true
+		}
+	}
+	return requirement{}, false
+}
@@ -0,0 +1,218 @@
+package flavor
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+)
+
+// Locate records on each node of root the part of pattern it was
+// parsed from (see ast.Position). Flavors call it at the end of Parse.
+// The parsers build nodes without positions, so it walks the tree and
+// f's tokens of the pattern side by side: a literal takes as many
+// characters as it has, a group its opening and closing tokens, a
+// quantifier, escape, class or anchor one token, and so on. The
+// members of a character class are part of its one token and get no
+// position of their own.
+//
+// Alignment stops at the first node the tokens do not account for,
+// such as a group a parser made up (GNU sed nesting a repeat of a
+// repeat) or whitespace that free-spacing mode dropped; the nodes
+// after it keep the zero Position. Positions already on the tree are
+// cleared first, so Locate can be run again on a tree that has been
+// spliced together from several parses.
+func Locate(f Flavor, pattern string, root *ast.Regexp) {
+	if root == nil {
+		return
+	}
+	ast.ClearPositions(root)
+	a := &aligner{}
+	for _, tok := range Tokens(f, pattern) {
+		if tok.Kind != TokenDelimiter && tok.Text != "" {
+			a.toks = append(a.toks, tok)
+		}
+	}
+	if len(a.toks) > 0 {
+		a.last = a.toks[0].Offset
+	}
+	a.node(root)
+}
+
+// setPos records p on n.
+func setPos(n ast.Node, p ast.Position) {
+	if s, ok := n.(ast.Positioner); ok {
+		s.SetPos(p.Start, p.End)
+	}
+}
+
+// aligner holds the state of one Locate call.
+type aligner struct {
+	toks   []Token
+	i      int // The next token
+	off    int // Bytes of toks[i] already taken, for a literal split between nodes
+	last   int // The end of what was taken last
+	failed bool
+}
+
+// pos returns the offset of the next byte to take.
+func (a *aligner) pos() int {
+	if a.i < len(a.toks) {
+		return a.toks[a.i].Offset + a.off
+	}
+	return a.last
+}
+
+// take takes the next whole token, which must be of one of kinds (any
+// kind when none are given).
+func (a *aligner) take(kinds ...TokenKind) {
+	if a.failed || a.i >= len(a.toks) || a.off > 0 {
+		a.failed = true
+		return
+	}
+	tok := a.toks[a.i]
+	if len(kinds) > 0 {
+		ok := false
+		for _, k := range kinds {
+			ok = ok || tok.Kind == k
+		}
+		if !ok {
+			a.failed = true
+			return
+		}
+	}
+	a.i++
+	a.last = tok.Offset + len(tok.Text)
+}
+
+// takeChar takes one character of a literal: a character of a literal
+// token, or an escape token such as \. that stands for one.
+func (a *aligner) takeChar() {
+	if a.failed || a.i >= len(a.toks) {
+		a.failed = true
+		return
+	}
+	tok := a.toks[a.i]
+	if tok.Kind != TokenLiteral {
+		a.take(TokenEscape)
+		return
+	}
+	_, size := utf8.DecodeRuneInString(tok.Text[a.off:])
+	a.off += size
+	a.last = tok.Offset + a.off
+	if a.off == len(tok.Text) {
+		a.i++
+		a.off = 0
+	}
+}
+
+// literal takes the characters of a literal's text. Some parsers keep
+// an escape they do not know as it was written, as in \g<1>, so an
+// escape token spelled the same way as what is left of the text is
+// taken whole.
+func (a *aligner) literal(text string) {
+	for text != "" && !a.failed {
+		if a.i < len(a.toks) && a.off == 0 {
+			if tok := a.toks[a.i]; tok.Kind == TokenEscape && len(tok.Text) > 2 && strings.HasPrefix(text, tok.Text) {
+				a.take()
+				text = text[len(tok.Text):]
+				continue
+			}
+		}
+		_, size := utf8.DecodeRuneInString(text)
+		a.takeChar()
+		text = text[size:]
+	}
+}
+
+// group takes a group's opening token, its contents and its closing
+// token.
+func (a *aligner) group(re *ast.Regexp) {
+	a.take(TokenGroup)
+	a.node(re)
+	a.take(TokenGroup)
+}
+
+// node takes the tokens n was parsed from and records its position.
+func (a *aligner) node(n ast.Node) {
+	if a.failed {
+		return
+	}
+	start := a.pos()
+	switch v := n.(type) {
+	case *ast.Regexp:
+		if v == nil {
+			return
+		}
+		for _, opt := range v.Options {
+			optStart := a.pos()
+			a.take(TokenGroup)
+			if !a.failed {
+				setPos(opt, ast.Position{Start: optStart, End: a.last})
+			}
+		}
+		for i, m := range v.Matches {
+			if i > 0 {
+				a.take(TokenAlternation)
+			}
+			a.node(m)
+		}
+	case *ast.Match:
+		for _, frag := range v.Fragments {
+			a.node(frag)
+		}
+	case *ast.MatchFragment:
+		a.node(v.Content)
+		if v.Repeat != nil {
+			repeatStart := a.pos()
+			a.take(TokenQuantifier)
+			if !a.failed {
+				setPos(v.Repeat, ast.Position{Start: repeatStart, End: a.last})
+			}
+		}
+	case *ast.Literal:
+		a.literal(v.Text)
+	case *ast.QuotedLiteral:
+		a.take(TokenEscape)
+		if v.Text != "" {
+			a.take(TokenLiteral)
+		}
+		if a.i < len(a.toks) && a.toks[a.i].Text == `\E` {
+			a.take()
+		}
+	case *ast.Subexp:
+		a.group(v.Regexp)
+	case *ast.AtomicGroup:
+		a.group(v.Regexp)
+	case *ast.BalancedGroup:
+		a.group(v.Regexp)
+	case *ast.BranchReset:
+		a.group(v.Regexp)
+	case *ast.InlineModifier:
+		if v.Regexp == nil {
+			a.take(TokenGroup)
+		} else {
+			a.group(v.Regexp)
+		}
+	case *ast.Conditional:
+		// The condition is part of the opening token: (?(1) or (?(?=a).
+		a.take(TokenGroup)
+		if !a.failed && v.Condition != nil {
+			setPos(v.Condition, ast.Position{Start: start, End: a.last})
+		}
+		a.node(v.TrueMatch)
+		if v.FalseMatch != nil {
+			a.take(TokenAlternation)
+			a.node(v.FalseMatch)
+		}
+		a.take(TokenGroup)
+	default:
+		// Escapes, classes, anchors, back-references, verbs and the
+		// like are one token each.
+		a.take()
+	}
+	if a.failed {
+		return
+	}
+	setPos(n, ast.Position{Start: start, End: max(start, a.last)})
+}
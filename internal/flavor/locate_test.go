@@ -0,0 +1,73 @@
+package flavor_test
+
+import (
+	"testing"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+	"github.com/0x4d5352/regolith/internal/flavor"
+	_ "github.com/0x4d5352/regolith/internal/flavor/gnused"
+	_ "github.com/0x4d5352/regolith/internal/flavor/javascript"
+	_ "github.com/0x4d5352/regolith/internal/flavor/pcre"
+)
+
+// positionTexts parses pattern and returns the source text of every
+// node with a position, in walk order, and of each quantifier after
+// the node it repeats.
+func positionTexts(t *testing.T, flavorName, pattern string) []string {
+	t.Helper()
+	f, ok := flavor.Get(flavorName)
+	if !ok {
+		t.Fatalf("%s flavor not registered", flavorName)
+	}
+	root, err := f.Parse(pattern)
+	if err != nil {
+		t.Fatalf("unexpected error for %q: %v", pattern, err)
+	}
+	var got []string
+	ast.Walk(root, func(n ast.Node) {
+		if p := n.Pos(); p != (ast.Position{}) {
+			got = append(got, pattern[p.Start:p.End])
+		}
+		if frag, ok := n.(*ast.MatchFragment); ok && frag.Repeat != nil {
+			p := frag.Repeat.Pos()
+			got = append(got, "repeat:"+pattern[p.Start:p.End])
+		}
+	})
+	return got
+}
+
+func TestLocate(t *testing.T) {
+	tests := []struct {
+		flavor  string
+		pattern string
+		want    []string
+	}{
+		{"pcre", `ab|c`, []string{`ab|c`, `ab`, `ab`, `ab`, `c`, `c`, `c`}},
+		{"pcre", `(?<y>\d{4})-x`, []string{
+			`(?<y>\d{4})-x`, `(?<y>\d{4})-x`, `(?<y>\d{4})`, `(?<y>\d{4})`,
+			`\d{4}`, `\d{4}`, `\d{4}`, "repeat:{4}", `\d`, `-x`, `-x`,
+		}},
+		{"pcre", `a\.b+`, []string{`a\.b+`, `a\.b+`, `a`, `a`, `\.`, `\.`, `b+`, "repeat:+", `b`}},
+		{"pcre", `/x{2}/i`, []string{`x{2}`, `x{2}`, `x{2}`, "repeat:{2}", `x`}},
+		{"pcre", `(a)(?(1)b|c)`, []string{
+			`(a)(?(1)b|c)`, `(a)(?(1)b|c)`, `(a)`, `(a)`, `a`, `a`, `a`, `a`,
+			`(?(1)b|c)`, `(?(1)b|c)`, `(?(1)`, `b`, `b`, `b`, `b`, `c`, `c`, `c`, `c`,
+		}},
+		{"pcre", `\Qa.b\Ec`, []string{`\Qa.b\Ec`, `\Qa.b\Ec`, `\Qa.b\E`, `\Qa.b\E`, `c`, `c`}},
+		{"javascript", `/a[bc]/g`, []string{`a[bc]`, `a[bc]`, `a`, `a`, `[bc]`, `[bc]`}},
+		{"gnused", `s/a\(b\)/x/`, []string{`a\(b\)`, `a\(b\)`, `a`, `a`, `\(b\)`, `\(b\)`, `b`, `b`, `b`, `b`}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.pattern, func(t *testing.T) {
+			got := positionTexts(t, tc.flavor, tc.pattern)
+			if len(got) != len(tc.want) {
+				t.Fatalf("got positions %q, want %q", got, tc.want)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("position %d: got %q, want %q", i, got[i], tc.want[i])
+				}
+			}
+		})
+	}
+}
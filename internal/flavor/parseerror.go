@@ -0,0 +1,33 @@
+package flavor
+
+import "fmt"
+
+// ParseError is the error every flavor's Parse returns for a pattern
+// it rejects: where parsing stopped, what was wrong there, and, from
+// the generated grammars, what the parser would have accepted instead.
+// Callers read the position from its fields; its text keeps the
+// "parse error: line:column (offset): message" form the parsers have
+// always printed.
+type ParseError struct {
+	Offset   int      // 0-based byte offset into the pattern
+	Line     int      // 1-based line
+	Col      int      // 1-based column, counted in runes
+	Message  string   // What is wrong, without the position
+	Expected []string // What the grammar would have accepted at Offset, if it says
+	Err      error    // The error the parser gave, when it was converted
+}
+
+// NewParseError returns the ParseError for message at offset in
+// pattern, working out its line and column.
+func NewParseError(pattern string, offset int, message string) *ParseError {
+	line, col := lineColumn(pattern, offset)
+	return &ParseError{Offset: offset, Line: line, Col: col, Message: message}
+}
+
+// Error implements error.
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("parse error: %d:%d (%d): %s", e.Line, e.Col, e.Offset, e.Message)
+}
+
+// Unwrap returns the error the parser gave.
+func (e *ParseError) Unwrap() error { return e.Err }
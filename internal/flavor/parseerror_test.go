@@ -0,0 +1,59 @@
+package flavor_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/0x4d5352/regolith/internal/flavor"
+)
+
+func TestParseErrorFromEveryFlavor(t *testing.T) {
+	invalid := map[string]string{
+		"posix-bre":   `a\(b`,
+		"gnugrep-bre": `a[b`,
+		"gnused":      `a\(b`,
+		"gnugrep":     `a[b`,
+		"vim":         `a\(b`,
+	}
+	for _, name := range flavor.List() {
+		pattern, ok := invalid[name]
+		if !ok {
+			pattern = "a(b"
+		}
+		t.Run(name, func(t *testing.T) {
+			f, _ := flavor.Get(name)
+			_, err := f.Parse(pattern)
+			var pe *flavor.ParseError
+			if !errors.As(err, &pe) {
+				t.Fatalf("%q: got %T %v, want a *flavor.ParseError", pattern, err, err)
+			}
+			if pe.Line != 1 || pe.Col != pe.Offset+1 || pe.Message == "" {
+				t.Errorf("%q: unexpected position or message: %+v", pattern, pe)
+			}
+		})
+	}
+}
+
+func TestParseErrorExpected(t *testing.T) {
+	pcre, _ := flavor.Get("pcre")
+	_, err := pcre.Parse("(*SKIPP)")
+	var pe *flavor.ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("got %T %v, want a *flavor.ParseError", err, err)
+	}
+	if pe.Offset != 6 || pe.Line != 1 || pe.Col != 7 {
+		t.Errorf("position = %d:%d (%d), want 1:7 (6)", pe.Line, pe.Col, pe.Offset)
+	}
+	if !slices.Equal(pe.Expected, []string{`")"`, `":"`}) {
+		t.Errorf("Expected = %q", pe.Expected)
+	}
+	if got, want := pe.Error(), `parse error: 1:7 (6): no match found, expected: ")" or ":"`; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+
+	// A multi-line pattern counts lines and columns from its last newline.
+	if got := flavor.NewParseError("ab\ncé(", 6, "x"); got.Line != 2 || got.Col != 3 {
+		t.Errorf("NewParseError position = %d:%d, want 2:3", got.Line, got.Col)
+	}
+}
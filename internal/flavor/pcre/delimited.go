@@ -0,0 +1,151 @@
+package pcre
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Patterns are often pasted straight from Perl or PHP source, delimiters
+// and modifiers included: m{^\d+$}i, qr/foo/x, '#^/api/#u'. Without
+// help, the PCRE grammar draws the delimiters as literal characters and
+// drops the modifiers on the floor. splitDelimited recognizes these
+// forms so Parse can strip them and show the modifiers as flags.
+
+// bracketDelimiters maps an opening bracket delimiter to its closer.
+var bracketDelimiters = map[byte]byte{'{': '}', '(': ')', '[': ']', '<': '>'}
+
+// bareDelimiters are the delimiters accepted without an m or qr prefix.
+// Brackets are left out: a bare (abc) is a capture group, not PHP's
+// bracket-delimited pattern.
+const bareDelimiters = "/#~!@%+`"
+
+// Modifiers accepted after the closing delimiter. matchModifiers change
+// how the pattern matches and are shown as flags; the rest are Perl
+// operator modifiers (/g is kept as the only one users expect to see)
+// or charset selectors, accepted so pasted code parses but not shown.
+const (
+	matchModifiers = "imsxnUJuDASXg"
+	otherModifiers = "adlpcoer"
+)
+
+// delimited describes a recognized delimited pattern: the body is
+// pattern[open:close] and modifiers are the flags that follow it.
+type delimited struct {
+	open, close int
+	modifiers   string
+}
+
+// splitDelimited reports whether pattern is a delimited literal:
+// optionally wrapped in matching quotes, an optional m or qr operator,
+// a delimiter, the body, the matching closing delimiter, and modifier
+// letters. A non-bracket delimiter must not appear unescaped in the
+// body, which keeps a plain pattern such as /usr/bin/ from being
+// mistaken for a delimited one, and neither may an unknown modifier:
+// /usr/bin is a path, not the pattern usr with modifiers b, i, and n.
+func splitDelimited(pattern string) (delimited, bool) {
+	start, end := 0, len(pattern)
+	if end >= 2 && (pattern[0] == '\'' || pattern[0] == '"') && pattern[end-1] == pattern[0] {
+		start, end = 1, end-1
+	}
+	s := pattern[start:end]
+
+	prefixed := false
+	for _, op := range []string{"qr", "m"} {
+		if len(s) > len(op) && strings.HasPrefix(s, op) && isDelimiter(s[len(op)]) {
+			start += len(op)
+			s = s[len(op):]
+			prefixed = true
+			break
+		}
+	}
+	if s == "" {
+		return delimited{}, false
+	}
+	openDelim := s[0]
+	closeDelim, bracket := bracketDelimiters[openDelim]
+	if !bracket {
+		if !prefixed && !strings.ContainsRune(bareDelimiters, rune(openDelim)) {
+			return delimited{}, false
+		}
+		if prefixed && !isDelimiter(openDelim) {
+			return delimited{}, false
+		}
+		closeDelim = openDelim
+	} else if !prefixed {
+		return delimited{}, false
+	}
+
+	closeAt := strings.LastIndexByte(s, closeDelim)
+	if closeAt < 1 {
+		return delimited{}, false
+	}
+	mods := s[closeAt+1:]
+	for i := 0; i < len(mods); i++ {
+		if c := mods[i]; !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z') {
+			return delimited{}, false
+		}
+	}
+	body := s[1:closeAt]
+	if !bracket && hasUnescaped(body, openDelim) {
+		return delimited{}, false
+	}
+
+	var flags strings.Builder
+	for _, m := range mods {
+		switch {
+		case strings.ContainsRune(matchModifiers, m):
+			if !strings.ContainsRune(flags.String(), m) {
+				flags.WriteRune(m)
+			}
+		case strings.ContainsRune(otherModifiers, m):
+		default:
+			return delimited{}, false
+		}
+	}
+	return delimited{open: start + 1, close: start + closeAt, modifiers: flags.String()}, true
+}
+
+// isDelimiter reports whether c can delimit a Perl m or qr operator:
+// any ASCII punctuation except backslash.
+func isDelimiter(c byte) bool {
+	return c < utf8.RuneSelf && c > ' ' && c != '\\' && c != 0x7f &&
+		!('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9')
+}
+
+// hasUnescaped reports whether s contains c not preceded by a backslash.
+func hasUnescaped(s string, c byte) bool {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case c:
+			return true
+		}
+	}
+	return false
+}
+
+// shiftErrorPositions moves the positions in a parse error of the body
+// of a delimited pattern so they point into the pattern as the user
+// wrote it, delimiters and all. Only line 1 is shifted: the prefix is
+// all on the first line.
+func shiftErrorPositions(err error, d delimited, pattern string) {
+	var list errList
+	if !errors.As(err, &list) {
+		return
+	}
+	cols := utf8.RuneCountInString(pattern[:d.open])
+	for _, e := range list {
+		pe, ok := e.(*parserError)
+		if !ok || pe.pos.line != 1 {
+			continue
+		}
+		old := fmt.Sprintf("%d:%d (%d)", pe.pos.line, pe.pos.col, pe.pos.offset)
+		pe.pos.col += cols
+		pe.pos.offset += d.open
+		pe.prefix = strings.Replace(pe.prefix, old,
+			fmt.Sprintf("%d:%d (%d)", pe.pos.line, pe.pos.col, pe.pos.offset), 1)
+	}
+}
@@ -4,13 +4,15 @@
 package pcre
 
 import (
+	"io"
+
 	"github.com/0x4d5352/regolith/internal/ast"
 	"github.com/0x4d5352/regolith/internal/flavor"
 	"github.com/0x4d5352/regolith/internal/flavor/helpers"
 )
 
 func init() {
-	flavor.Register(&PCRE{})
+	flavor.MustRegister(&PCRE{})
 }
 
 // PCRE implements the Flavor interface for PCRE (Perl Compatible Regular Expressions)
@@ -24,25 +26,128 @@ func (f *PCRE) Description() string {
 	return "Perl Compatible Regular Expressions (PCRE2) - the most feature-rich regex flavor"
 }
 
+// Family returns the flavor's syntax family.
+func (f *PCRE) Family() flavor.Family {
+	return flavor.FamilyPerl
+}
+
+// Notes returns what `regolith flavors` says about the flavor beyond
+// its FeatureSet.
+func (f *PCRE) Notes() flavor.Notes {
+	return flavor.Notes{
+		Limitations: []string{
+			`Relative back-references are misread: (a)\g-1 is drawn as the letters g-1, and (a)\g{-1} is rejected`,
+			"With the x modifier, whitespace and # comments in the body are still drawn as literals",
+		},
+		Notes: []string{
+			"Perl and PHP delimiters such as m{...}i and '#...#u' are unwrapped",
+			"--pcre-version checks a pattern against an older PCRE2 release",
+		},
+		Unread: []string{"relative_backrefs"},
+	}
+}
+
+// Parse parses a PCRE pattern. A pattern pasted with Perl or PHP
+// delimiters (m{...}i, /.../x, '#...#u') is unwrapped first, and its
+// modifiers become the root's Flags.
 func (f *PCRE) Parse(pattern string) (*ast.Regexp, error) {
+	root, err := parse(pattern, func(body string, opts ...Option) (any, error) {
+		return Parse("", []byte(body), opts...)
+	})
+	flavor.Locate(f, pattern, root)
+	return root, flavor.Pinpoint(f, pattern, parseError(err))
+}
+
+// ParseTrace is Parse with the grammar's rule trace written to w.
+func (f *PCRE) ParseTrace(pattern string, w io.Writer) (*ast.Regexp, error) {
+	root, err := parse(pattern, func(body string, opts ...Option) (any, error) {
+		return helpers.TraceParse(w, func() (any, error) {
+			return Parse("", []byte(body), append(opts, Debug(true))...)
+		})
+	})
+	flavor.Locate(f, pattern, root)
+	return root, flavor.Pinpoint(f, pattern, parseError(err))
+}
+
+// parse unwraps a delimited pattern and runs the generated parser on
+// the body through run.
+func parse(pattern string, run func(body string, opts ...Option) (any, error)) (*ast.Regexp, error) {
+	d, isDelimited := splitDelimited(pattern)
+	body := pattern
+	if isDelimited {
+		body = pattern[d.open:d.close]
+	}
 	state := ast.NewParserState()
+	result, err := run(body, GlobalStore("state", state))
+	if err != nil && isDelimited {
+		shiftErrorPositions(err, d, pattern)
+	}
 	// Before this refactor PCRE panicked on an unexpected parse result
 	// type via an unchecked type assertion. FinalizeParse surfaces the
 	// same impossible-state condition as a typed error, matching the
 	// other seven flavors without any change for valid patterns.
-	return helpers.FinalizeParse(Parse("", []byte(pattern), GlobalStore("state", state)))
+	root, err := helpers.FinalizeParse(result, err)
+	if err != nil || !isDelimited {
+		return root, err
+	}
+	root.Flags = d.modifiers
+	return root, nil
 }
 
+// phpModifiers documents the modifiers of a delimited pattern, which is
+// where PCRE's flag letters come from.
+const phpModifiers = "https://www.php.net/manual/en/reference.pcre.pattern.modifiers.php"
+
 func (f *PCRE) SupportedFlags() []flavor.FlagInfo {
 	return []flavor.FlagInfo{
-		{Char: 'i', Name: "caseless", Description: "Case-insensitive matching"},
-		{Char: 'm', Name: "multiline", Description: "^ and $ match at newlines"},
-		{Char: 's', Name: "dotall", Description: ". matches newlines"},
-		{Char: 'x', Name: "extended", Description: "Ignore whitespace and allow comments"},
-		{Char: 'J', Name: "dupnames", Description: "Allow duplicate named groups"},
-		{Char: 'U', Name: "ungreedy", Description: "Invert greediness of quantifiers"},
-		{Char: 'n', Name: "no_auto_capture", Description: "Plain (...) groups are non-capturing"},
+		{Char: 'i', Name: "caseless", Description: "Case-insensitive matching", DocURL: phpModifiers},
+		{Char: 'm', Name: "multiline", Description: "^ and $ match at newlines", DocURL: phpModifiers},
+		{Char: 's', Name: "dotall", Description: ". matches newlines", DocURL: phpModifiers},
+		{Char: 'x', Name: "extended", Description: "Ignore whitespace and allow comments", DocURL: phpModifiers},
+		{Char: 'J', Name: "dupnames", Description: "Allow duplicate named groups", Label: "duplicate names", DocURL: phpModifiers},
+		{Char: 'U', Name: "ungreedy", Description: "Invert greediness of quantifiers", DocURL: phpModifiers},
+		{Char: 'n', Name: "no_auto_capture", Description: "Plain (...) groups are non-capturing", Label: "no auto capture", DocURL: phpModifiers},
+		{Char: 'u', Name: "utf", Description: "Treat the pattern and subject as UTF-8 (PHP /u)", Label: "UTF-8", DocURL: phpModifiers},
+		{Char: 'D', Name: "dollar_endonly", Description: "$ matches only at the very end of the subject", Label: "dollar end only", DocURL: phpModifiers},
+		{Char: 'A', Name: "anchored", Description: "Match only at the start of the subject", DocURL: phpModifiers},
+	}
+}
+
+func (f *PCRE) Tokenize(pattern string) []flavor.Token {
+	d, ok := splitDelimited(pattern)
+	if !ok {
+		return flavor.TokenizeSyntax(pattern, flavor.PerlSyntax)
+	}
+	tokens := []flavor.Token{{Kind: flavor.TokenDelimiter, Text: pattern[:d.open]}}
+	for _, t := range flavor.TokenizeSyntax(pattern[d.open:d.close], flavor.PerlSyntax) {
+		t.Offset += d.open
+		tokens = append(tokens, t)
+	}
+	return append(tokens, flavor.Token{Kind: flavor.TokenDelimiter, Text: pattern[d.close:], Offset: d.close})
+}
+
+// ShorthandSet describes PCRE2's shorthand classes: ASCII by default,
+// and Unicode properties once (*UCP) is given.
+func (f *PCRE) ShorthandSet(code string, root *ast.Regexp) (flavor.ShorthandSet, bool) {
+	ucp := false
+	for _, opt := range root.Options {
+		if opt.Name == "UCP" {
+			ucp = true
+		}
+	}
+	if !ucp {
+		set, ok := flavor.ASCIIShorthands[code]
+		return set, ok
+	}
+	switch code {
+	case "d":
+		return flavor.ShorthandSet{Mode: flavor.ShorthandUnicode, Members: `\p{Nd}`}, true
+	case "w":
+		return flavor.ShorthandSet{Mode: flavor.ShorthandUnicode, Members: `[\p{L}\p{N}\p{Mn}\p{Pc}]`}, true
+	case "s":
+		return flavor.ShorthandSet{Mode: flavor.ShorthandUnicode, Members: `[\t\n\v\f\r\p{Z}]`}, true
 	}
+	return flavor.ShorthandSet{}, false
 }
 
 func (f *PCRE) SupportedFeatures() flavor.FeatureSet {
@@ -66,5 +171,11 @@ func (f *PCRE) SupportedFeatures() flavor.FeatureSet {
 		ScriptRuns:            true,
 		NonAtomicLookaround:   true,
 		PatternStartOptions:   true,
+		UnicodeSets:           false,
+		OctalEscapes:          true,
+		HexBracedEscapes:      true,
+		NamedUnicodeEscapes:   true, // \N{U+hh..} in UTF mode
+		RelativeBackrefs:      true,
+		SetOperations:         false, // PCRE2 10.45 extended classes are opt-in
 	}
 }
@@ -1,9 +1,11 @@
 package pcre
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/0x4d5352/regolith/internal/ast"
+	"github.com/0x4d5352/regolith/internal/flavor"
 )
 
 func TestBasicParsing(t *testing.T) {
@@ -726,3 +728,115 @@ func TestComplexPatterns(t *testing.T) {
 		})
 	}
 }
+
+func TestDelimitedPatterns(t *testing.T) {
+	p := &PCRE{}
+
+	tests := []struct {
+		name      string
+		pattern   string
+		wantFlags string
+		wantFirst string // Literal text of the first fragment, if a literal
+	}{
+		{"perl m braces", `m{^\d+$}i`, "i", ""},
+		{"perl qr slashes", `qr/foo/x`, "x", "foo"},
+		{"slashes", `/foo\/bar/ms`, "ms", "foo"},
+		{"php hash in quotes", `'#/api/v1#u'`, "u", "/api/v1"},
+		{"php tilde", `~abc~`, "", "abc"},
+		{"duplicate and operator modifiers", `m/abc/gcgi`, "gi", "abc"},
+		{"plain path", `/usr/bin`, "", "/usr/bin"},
+		{"plain path with slashes", `/usr/bin/`, "", "/usr/bin/"},
+		{"unknown modifier", `/foo/q`, "", "/foo/q"},
+		{"bare parentheses are a group", `(abc)`, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := p.Parse(tt.pattern)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.pattern, err)
+			}
+			if root.Flags != tt.wantFlags {
+				t.Errorf("Flags = %q, want %q", root.Flags, tt.wantFlags)
+			}
+			if tt.wantFirst == "" {
+				return
+			}
+			lit, ok := root.Matches[0].Fragments[0].Content.(*ast.Literal)
+			if !ok || lit.Text != tt.wantFirst {
+				t.Errorf("first fragment = %#v, want literal %q", root.Matches[0].Fragments[0].Content, tt.wantFirst)
+			}
+		})
+	}
+}
+
+func TestDelimitedPatternErrorPosition(t *testing.T) {
+	_, err := (&PCRE{}).Parse(`m{ab\}i`)
+	if err == nil {
+		t.Fatal("expected a parse error")
+	}
+	// The body "ab\" fails at its end, column 4; the m{ prefix shifts
+	// that to column 6 of the pattern as written.
+	if !strings.Contains(err.Error(), "1:6 (5)") {
+		t.Errorf("error position not shifted past the delimiter: %v", err)
+	}
+
+	// An unclosed group is reported at its ( in the pattern as written.
+	_, err = (&PCRE{}).Parse(`m{ab(}i`)
+	if err == nil || !strings.Contains(err.Error(), "1:5 (4): unclosed group") {
+		t.Errorf("unclosed group not pinpointed: %v", err)
+	}
+}
+
+func TestDelimitedTokenize(t *testing.T) {
+	tokens := (&PCRE{}).Tokenize(`m{a+}i`)
+	var got strings.Builder
+	for _, tok := range tokens {
+		got.WriteString(tok.Text)
+	}
+	if got.String() != `m{a+}i` {
+		t.Errorf("tokens do not cover the pattern: %q", got.String())
+	}
+	first, last := tokens[0], tokens[len(tokens)-1]
+	if first.Kind != flavor.TokenDelimiter || first.Text != "m{" {
+		t.Errorf("first token = %+v, want delimiter m{", first)
+	}
+	if last.Kind != flavor.TokenDelimiter || last.Text != "}i" || last.Offset != 4 {
+		t.Errorf("last token = %+v, want delimiter }i at 4", last)
+	}
+}
+
+func TestCheckVersion(t *testing.T) {
+	tests := []struct {
+		pattern string
+		version string
+		wantErr string // empty for no error
+	}{
+		{`(*napla:a)b`, "10.34", ""},
+		{`(*napla:a)b`, "10.33", "non-atomic lookahead (*napla:...) requires PCRE2 10.34"},
+		{`(?<*a)b`, "10.32", "non-atomic lookbehind (*naplb:...) requires PCRE2 10.34"},
+		{`(*sr:\d+)`, "10.32", "script run (*sr:...) requires PCRE2 10.33"},
+		{`\N{U+1F600}`, "10.23", `\N{U+1F600} requires PCRE2 10.30`},
+		{`(*LIMIT_HEAP=100)a`, "10.23", "(*LIMIT_HEAP=...) requires PCRE2 10.30"},
+		{`(?<=a{1,3})b`, "10.42", "variable-length lookbehind requires PCRE2 10.43"},
+		{`(?<=a{1,3})b`, "10.44", ""},
+		{`(?<=a{3})b`, "10.23", ""},
+		{`a`, "11.0", "invalid PCRE2 version"},
+	}
+	f := &PCRE{}
+	for _, tt := range tests {
+		t.Run(tt.pattern+"@"+tt.version, func(t *testing.T) {
+			root, err := f.Parse(tt.pattern)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			err = f.CheckVersion(root, tt.version)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
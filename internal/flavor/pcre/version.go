@@ -0,0 +1,109 @@
+package pcre
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+)
+
+// versions are the PCRE2 releases that changed pattern syntax, plus the
+// releases most often found on servers, so the list reads as the
+// choices a user is likely to need.
+var versions = []string{"10.23", "10.30", "10.32", "10.33", "10.34", "10.39", "10.40", "10.42", "10.43", "10.44", "10.45"}
+
+// Versions lists the PCRE2 releases CheckVersion knows about.
+func (f *PCRE) Versions() []string {
+	return versions
+}
+
+// requirement is a construct found in a pattern and the first PCRE2
+// release that accepts it.
+type requirement struct {
+	construct string
+	minor     int // 10.minor
+}
+
+// CheckVersion reports the first construct in root that the given PCRE2
+// release rejects. Only constructs visible in the tree are checked:
+// alphabetic spellings such as (*pla:...) parse to the same node as
+// (?=...) and cannot be told apart here.
+func (f *PCRE) CheckVersion(root *ast.Regexp, version string) error {
+	minor, err := parseVersion(version)
+	if err != nil {
+		return err
+	}
+	for _, req := range requirements(root) {
+		if req.minor > minor {
+			return fmt.Errorf("%s requires PCRE2 10.%d or later (checking against %s)", req.construct, req.minor, version)
+		}
+	}
+	return nil
+}
+
+// parseVersion accepts "10.N" and returns N.
+func parseVersion(version string) (int, error) {
+	major, minor, ok := strings.Cut(version, ".")
+	n, err := strconv.Atoi(minor)
+	if !ok || major != "10" || err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid PCRE2 version %q: want 10.N, e.g. %s", version, versions[len(versions)-1])
+	}
+	return n, nil
+}
+
+// requirements lists the version-gated constructs in root in pattern
+// order.
+func requirements(root *ast.Regexp) []requirement {
+	var out []requirement
+	for _, opt := range root.Options {
+		switch opt.Name {
+		case "LIMIT_DEPTH", "LIMIT_HEAP":
+			out = append(out, requirement{"(*" + opt.Name + "=...)", 30})
+		case "NUL":
+			out = append(out, requirement{"(*NUL)", 30})
+		}
+	}
+	ast.Walk(root, func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.Escape:
+			if n.EscapeType == "unicode_named" && strings.HasPrefix(n.Code, `\N{U+`) {
+				out = append(out, requirement{n.Code, 30})
+			}
+		case *ast.InlineModifier:
+			if strings.ContainsRune(n.Enable+n.Disable, 'n') {
+				out = append(out, requirement{"the (?n) no-auto-capture modifier", 30})
+			}
+		case *ast.Subexp:
+			switch n.GroupType {
+			case "script_run":
+				out = append(out, requirement{"script run (*sr:...)", 33})
+			case "atomic_script_run":
+				out = append(out, requirement{"atomic script run (*asr:...)", 33})
+			case "non_atomic_positive_lookahead":
+				out = append(out, requirement{"non-atomic lookahead (*napla:...)", 34})
+			case "non_atomic_positive_lookbehind":
+				out = append(out, requirement{"non-atomic lookbehind (*naplb:...)", 34})
+			case "positive_lookbehind", "negative_lookbehind":
+				if variableRepeat(n.Regexp) {
+					out = append(out, requirement{"variable-length lookbehind", 43})
+				}
+			}
+		}
+	})
+	return out
+}
+
+// variableRepeat reports whether re has a bounded quantifier with a
+// range, such as a{1,3}, that PCRE2 before 10.43 rejected in a
+// lookbehind. Unbounded quantifiers are rejected by every release.
+func variableRepeat(re *ast.Regexp) bool {
+	found := false
+	ast.Walk(re, func(n ast.Node) {
+		if frag, ok := n.(*ast.MatchFragment); ok && frag.Repeat != nil &&
+			frag.Repeat.Max >= 0 && frag.Repeat.Min != frag.Repeat.Max {
+			found = true
+		}
+	})
+	return found
+}
@@ -11,6 +11,8 @@
 package posix_bre
 
 import (
+	"io"
+
 	"github.com/0x4d5352/regolith/internal/ast"
 	"github.com/0x4d5352/regolith/internal/flavor"
 	"github.com/0x4d5352/regolith/internal/flavor/helpers"
@@ -32,10 +34,38 @@ func (p *POSIXBRE) Description() string {
 	return "POSIX Basic Regular Expressions (IEEE Std 1003.1) - uses \\( \\) for groups"
 }
 
+// Family returns the flavor's syntax family.
+func (p *POSIXBRE) Family() flavor.Family {
+	return flavor.FamilyPOSIXBasic
+}
+
+// Notes returns what `regolith flavors` says about the flavor beyond
+// its FeatureSet.
+func (p *POSIXBRE) Notes() flavor.Notes {
+	return flavor.Notes{
+		Limitations: []string{
+			"Equivalence classes [[=a=]] and collating symbols [[.a.]] are rejected",
+			"Flags such as grep -i are given outside the pattern and are not visible",
+		},
+	}
+}
+
 // Parse parses a POSIX BRE pattern and returns an AST.
 func (p *POSIXBRE) Parse(pattern string) (*ast.Regexp, error) {
 	state := ast.NewParserState()
-	return helpers.FinalizeParse(Parse("", []byte(pattern), GlobalStore("state", state)))
+	root, err := helpers.FinalizeParse(Parse("", []byte(pattern), GlobalStore("state", state)))
+	flavor.Locate(p, pattern, root)
+	return root, flavor.Pinpoint(p, pattern, parseError(err))
+}
+
+// ParseTrace is Parse with the grammar's rule trace written to w.
+func (p *POSIXBRE) ParseTrace(pattern string, w io.Writer) (*ast.Regexp, error) {
+	state := ast.NewParserState()
+	root, err := helpers.FinalizeParse(helpers.TraceParse(w, func() (any, error) {
+		return Parse("", []byte(pattern), GlobalStore("state", state), Debug(true))
+	}))
+	flavor.Locate(p, pattern, root)
+	return root, flavor.Pinpoint(p, pattern, parseError(err))
 }
 
 // SupportedFlags returns information about valid flags for POSIX BRE.
@@ -44,6 +74,23 @@ func (p *POSIXBRE) SupportedFlags() []flavor.FlagInfo {
 	return []flavor.FlagInfo{}
 }
 
+// Tokenize splits a POSIX BRE pattern into syntax-highlighting tokens.
+func (p *POSIXBRE) Tokenize(pattern string) []flavor.Token {
+	return flavor.TokenizeSyntax(pattern, flavor.Syntax{BRE: true})
+}
+
+// ParseHints adds BRE's syntax reminders to the generic parse hints:
+// the Perl shorthands it lacks, and the backslashed operators when the
+// pattern uses the bare ones that BRE reads as literals.
+func (p *POSIXBRE) ParseHints(pattern string) []string {
+	tokens := p.Tokenize(pattern)
+	hints := flavor.ShorthandHints(p.Name(), tokens, "dDwWsS")
+	if flavor.LiteralsContain(tokens, "()|+?") {
+		hints = append(hints, "in posix-bre ( ) { } + ? | are literal characters: use \\( \\) for groups and \\{ \\} for intervals, or try --flavor posix-ere")
+	}
+	return hints
+}
+
 // SupportedFeatures returns the feature capabilities of POSIX BRE.
 func (p *POSIXBRE) SupportedFeatures() flavor.FeatureSet {
 	return flavor.FeatureSet{
@@ -62,10 +109,20 @@ func (p *POSIXBRE) SupportedFeatures() flavor.FeatureSet {
 		Comments:              false,
 		BranchReset:           false,
 		BacktrackingControl:   false,
+		Callouts:              false,
+		ScriptRuns:            false,
+		NonAtomicLookaround:   false,
+		PatternStartOptions:   false,
+		UnicodeSets:           false,
+		OctalEscapes:          false,
+		HexBracedEscapes:      false,
+		NamedUnicodeEscapes:   false,
+		RelativeBackrefs:      false,
+		SetOperations:         false,
 	}
 }
 
 // init registers the POSIX BRE flavor with the registry.
 func init() {
-	flavor.Register(&POSIXBRE{})
+	flavor.MustRegister(&POSIXBRE{})
 }
@@ -3,6 +3,8 @@
 package posix_ere
 
 import (
+	"io"
+
 	"github.com/0x4d5352/regolith/internal/ast"
 	"github.com/0x4d5352/regolith/internal/flavor"
 	"github.com/0x4d5352/regolith/internal/flavor/helpers"
@@ -24,10 +26,38 @@ func (p *POSIXERE) Description() string {
 	return "POSIX Extended Regular Expressions (IEEE Std 1003.1)"
 }
 
+// Family returns the flavor's syntax family.
+func (p *POSIXERE) Family() flavor.Family {
+	return flavor.FamilyPOSIXExtended
+}
+
+// Notes returns what `regolith flavors` says about the flavor beyond
+// its FeatureSet.
+func (p *POSIXERE) Notes() flavor.Notes {
+	return flavor.Notes{
+		Limitations: []string{
+			"Equivalence classes [[=a=]] and collating symbols [[.a.]] are rejected",
+			"Flags such as grep -i are given outside the pattern and are not visible",
+		},
+	}
+}
+
 // Parse parses a POSIX ERE pattern and returns an AST.
 func (p *POSIXERE) Parse(pattern string) (*ast.Regexp, error) {
 	state := ast.NewParserState()
-	return helpers.FinalizeParse(Parse("", []byte(pattern), GlobalStore("state", state)))
+	root, err := helpers.FinalizeParse(Parse("", []byte(pattern), GlobalStore("state", state)))
+	flavor.Locate(p, pattern, root)
+	return root, flavor.Pinpoint(p, pattern, parseError(err))
+}
+
+// ParseTrace is Parse with the grammar's rule trace written to w.
+func (p *POSIXERE) ParseTrace(pattern string, w io.Writer) (*ast.Regexp, error) {
+	state := ast.NewParserState()
+	root, err := helpers.FinalizeParse(helpers.TraceParse(w, func() (any, error) {
+		return Parse("", []byte(pattern), GlobalStore("state", state), Debug(true))
+	}))
+	flavor.Locate(p, pattern, root)
+	return root, flavor.Pinpoint(p, pattern, parseError(err))
 }
 
 // SupportedFlags returns information about valid flags for POSIX ERE.
@@ -36,6 +66,17 @@ func (p *POSIXERE) SupportedFlags() []flavor.FlagInfo {
 	return []flavor.FlagInfo{}
 }
 
+// Tokenize splits a POSIX ERE pattern into syntax-highlighting tokens.
+func (p *POSIXERE) Tokenize(pattern string) []flavor.Token {
+	return flavor.TokenizeSyntax(pattern, flavor.Syntax{})
+}
+
+// ParseHints suggests bracket expressions for the Perl shorthands POSIX
+// ERE lacks.
+func (p *POSIXERE) ParseHints(pattern string) []string {
+	return flavor.ShorthandHints(p.Name(), p.Tokenize(pattern), "dDwWsS")
+}
+
 // SupportedFeatures returns the feature capabilities of POSIX ERE.
 func (p *POSIXERE) SupportedFeatures() flavor.FeatureSet {
 	return flavor.FeatureSet{
@@ -54,10 +95,20 @@ func (p *POSIXERE) SupportedFeatures() flavor.FeatureSet {
 		Comments:              false,
 		BranchReset:           false,
 		BacktrackingControl:   false,
+		Callouts:              false,
+		ScriptRuns:            false,
+		NonAtomicLookaround:   false,
+		PatternStartOptions:   false,
+		UnicodeSets:           false,
+		OctalEscapes:          false,
+		HexBracedEscapes:      false,
+		NamedUnicodeEscapes:   false,
+		RelativeBackrefs:      false,
+		SetOperations:         false,
 	}
 }
 
 // init registers the POSIX ERE flavor with the registry.
 func init() {
-	flavor.Register(&POSIXERE{})
+	flavor.MustRegister(&POSIXERE{})
 }
@@ -0,0 +1,127 @@
+package flavor
+
+import (
+	"strings"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+)
+
+// Shorthand modes. A shorthand class is ASCII when it matches only the
+// characters listed in its ASCII table, and Unicode when it follows
+// Unicode general categories or properties.
+const (
+	ShorthandASCII   = "ASCII"
+	ShorthandUnicode = "Unicode"
+)
+
+// ShorthandSet is what one shorthand class escape (\d, \w, \s, or a
+// negation) matches under a particular flavor and set of pattern flags.
+type ShorthandSet struct {
+	Mode    string // ShorthandASCII or ShorthandUnicode
+	Members string // The set in class notation, e.g. [A-Za-z0-9_]
+}
+
+// ShorthandExpander is implemented by flavors that know exactly which
+// characters their shorthand classes match. It is optional: callers go
+// through Shorthands, which returns nil for flavors without it.
+//
+// The answer may depend on the pattern itself — PCRE's (*UCP), Java's
+// (?U), JavaScript's u flag — so the parsed root is passed in.
+type ShorthandExpander interface {
+	// ShorthandSet returns the set matched by the shorthand escape with
+	// the given lowercase code ("d", "w", or "s") in root, and false if
+	// the flavor has no such shorthand.
+	ShorthandSet(code string, root *ast.Regexp) (ShorthandSet, bool)
+}
+
+// Shorthands returns the expansion of every shorthand class escape that
+// appears inside a character class in root, keyed by escape code ("w",
+// "W", ...). Negated codes are described as the complement of their
+// lowercase counterpart. It returns nil when f is not a
+// ShorthandExpander or root has no such escapes.
+func Shorthands(f Flavor, root *ast.Regexp) map[string]ShorthandSet {
+	x, ok := f.(ShorthandExpander)
+	if !ok {
+		return nil
+	}
+	var out map[string]ShorthandSet
+	ast.Walk(root, func(n ast.Node) {
+		cs, ok := n.(*ast.Charset)
+		if !ok {
+			return
+		}
+		for _, esc := range charsetEscapes(cs) {
+			if _, done := out[esc.Code]; done {
+				continue
+			}
+			lower := strings.ToLower(esc.Code)
+			if lower != "d" && lower != "w" && lower != "s" {
+				continue
+			}
+			set, ok := x.ShorthandSet(lower, root)
+			if !ok {
+				continue
+			}
+			if esc.Code != lower {
+				set.Members = "not " + set.Members
+			}
+			if out == nil {
+				out = make(map[string]ShorthandSet)
+			}
+			out[esc.Code] = set
+		}
+	})
+	return out
+}
+
+// charsetEscapes returns the escapes among cs's items and set-operation
+// operands, including those of nested classes.
+func charsetEscapes(cs *ast.Charset) []*ast.Escape {
+	var out []*ast.Escape
+	var visit func(n ast.Node)
+	visit = func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.Escape:
+			out = append(out, n)
+		case *ast.Charset:
+			for _, it := range n.Items {
+				visit(it)
+			}
+			visit(n.SetExpression)
+		case *ast.CharsetIntersection:
+			for _, op := range n.Operands {
+				visit(op)
+			}
+		case *ast.CharsetSubtraction:
+			for _, op := range n.Operands {
+				visit(op)
+			}
+		}
+	}
+	visit(cs)
+	return out
+}
+
+// ASCIIShorthands is the traditional Perl table shared by the flavors
+// whose shorthands default to ASCII. Flavors differ on \s: see each
+// flavor's ShorthandSet.
+var ASCIIShorthands = map[string]ShorthandSet{
+	"d": {Mode: ShorthandASCII, Members: "[0-9]"},
+	"w": {Mode: ShorthandASCII, Members: "[A-Za-z0-9_]"},
+	"s": {Mode: ShorthandASCII, Members: `[ \t\n\v\f\r]`},
+}
+
+// HasInlineFlag reports whether an inline modifier anywhere in root
+// turns on flag, as (?U) does in Java. Whether the modifier is in scope
+// at a given escape is not tracked; a pattern that turns a mode on is
+// taken to use it throughout, which is how such flags are written in
+// practice.
+func HasInlineFlag(root *ast.Regexp, flag rune) bool {
+	found := false
+	ast.Walk(root, func(n ast.Node) {
+		if m, ok := n.(*ast.InlineModifier); ok && strings.ContainsRune(m.Enable, flag) {
+			found = true
+		}
+	})
+	return found
+}
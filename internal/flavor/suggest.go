@@ -0,0 +1,203 @@
+package flavor
+
+import (
+	"regexp"
+	"strings"
+)
+
+// spelling is a correctly spelled (?...) or (*...) construct, written
+// with "name" and "n" standing in for a group name and a number.
+// Spellings that end without ")" are group openers.
+type spelling struct {
+	text      string
+	supported func(FeatureSet) bool
+}
+
+func named(fs FeatureSet) bool     { return fs.NamedGroups }
+func recursive(fs FeatureSet) bool { return fs.RecursivePatterns }
+func verbs(fs FeatureSet) bool     { return fs.BacktrackingControl }
+func options(fs FeatureSet) bool   { return fs.PatternStartOptions }
+
+// backrefs gates (?P=name), a named back-reference: Go has named
+// groups but no back-references, and of the flavors with both only PCRE
+// spells them this way.
+func backrefs(fs FeatureSet) bool { return fs.NamedGroups && fs.RelativeBackrefs }
+
+// spellings lists the constructs suggestConstruct knows. Order breaks
+// ties between equally close spellings: (*SKIP) before (*SKIP:name).
+var spellings = []spelling{
+	{"(?<name>", named},
+	{"(?P<name>", named},
+	{"(?'name'", named},
+	{"(?P=name)", backrefs},
+	{"(?P>name)", recursive},
+	{"(?&name)", recursive},
+	{"(?R)", recursive},
+	{"(?<=", func(fs FeatureSet) bool { return fs.Lookbehind }},
+	{"(?<!", func(fs FeatureSet) bool { return fs.Lookbehind }},
+	{"(?=", func(fs FeatureSet) bool { return fs.Lookahead }},
+	{"(?!", func(fs FeatureSet) bool { return fs.Lookahead }},
+	{"(?>", func(fs FeatureSet) bool { return fs.AtomicGroups }},
+	{"(?|", func(fs FeatureSet) bool { return fs.BranchReset }},
+	{"(?#", func(fs FeatureSet) bool { return fs.Comments }},
+	{"(*ACCEPT)", verbs},
+	{"(*FAIL)", verbs},
+	{"(*COMMIT)", verbs},
+	{"(*PRUNE)", verbs},
+	{"(*SKIP)", verbs},
+	{"(*THEN)", verbs},
+	{"(*MARK:name)", verbs},
+	{"(*PRUNE:name)", verbs},
+	{"(*SKIP:name)", verbs},
+	{"(*THEN:name)", verbs},
+	{"(*atomic:", verbs},
+	{"(*positive_lookahead:", verbs},
+	{"(*negative_lookahead:", verbs},
+	{"(*positive_lookbehind:", verbs},
+	{"(*negative_lookbehind:", verbs},
+	{"(*script_run:", func(fs FeatureSet) bool { return fs.ScriptRuns }},
+	{"(*atomic_script_run:", func(fs FeatureSet) bool { return fs.ScriptRuns }},
+	{"(*non_atomic_positive_lookahead:", func(fs FeatureSet) bool { return fs.NonAtomicLookaround }},
+	{"(*non_atomic_positive_lookbehind:", func(fs FeatureSet) bool { return fs.NonAtomicLookaround }},
+	{"(*UTF)", options},
+	{"(*UCP)", options},
+	{"(*NO_AUTO_POSSESS)", options},
+	{"(*NO_DOTSTAR_ANCHOR)", options},
+	{"(*NO_JIT)", options},
+	{"(*NO_START_OPT)", options},
+	{"(*NOTEMPTY)", options},
+	{"(*NOTEMPTY_ATSTART)", options},
+	{"(*ANYCRLF)", options},
+	{"(*BSR_ANYCRLF)", options},
+	{"(*BSR_UNICODE)", options},
+	{"(*LIMIT_MATCH=n)", options},
+	{"(*LIMIT_DEPTH=n)", options},
+	{"(*LIMIT_HEAP=n)", options},
+}
+
+var (
+	spellingName   = regexp.MustCompile(`([<'=>&:])([A-Za-z_][A-Za-z0-9_]*)`)
+	spellingNumber = regexp.MustCompile(`=(\d+)`)
+)
+
+// suggestConstruct returns the construct the author most likely meant
+// when the parser stopped at offset inside a misspelled (?...) or
+// (*...) construct — (*SKIPP), (?P<name) — with the author's own group
+// name or number filled in, or "" when no spelling f supports is close.
+func suggestConstruct(f Flavor, pattern string, offset int) string {
+	if offset < 0 || offset > len(pattern) {
+		return ""
+	}
+	start := constructStart(pattern, offset)
+	if start < 0 {
+		return ""
+	}
+	end := len(pattern)
+	if i := strings.IndexByte(pattern[start:], ')'); i >= 0 {
+		end = start + i + 1
+	}
+	if offset > end || end-start > 48 {
+		return ""
+	}
+
+	// Compare with names and numbers replaced by the placeholders the
+	// spellings use, so only the syntax around them counts.
+	written := pattern[start:end]
+	var name, number string
+	if m := spellingNumber.FindStringSubmatch(written); m != nil {
+		number = m[1]
+	}
+	written = spellingNumber.ReplaceAllString(written, "=n")
+	if m := spellingName.FindStringSubmatch(written); m != nil && m[2] != "n" {
+		name = m[2]
+	}
+	written = spellingName.ReplaceAllStringFunc(written, func(s string) string {
+		if s[1:] == "n" {
+			return s
+		}
+		return s[:1] + "name"
+	})
+
+	fs := f.SupportedFeatures()
+	best, bestDist := "", 3
+	for _, sp := range spellings {
+		d := prefixDistance(sp.text, written)
+		if d == 0 {
+			// The construct is spelled right; the error lies inside
+			// or after it, or f lacks the construct, which
+			// featureHints reports.
+			return ""
+		}
+		if !sp.supported(fs) {
+			continue
+		}
+		if d < bestDist || (d == bestDist && len(sp.text) > len(best)) {
+			if d*4 <= len(sp.text) {
+				best, bestDist = sp.text, d
+			}
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	if name != "" {
+		best = strings.Replace(best, "name", name, 1)
+	}
+	if number != "" {
+		best = strings.Replace(best, "=n", "="+number, 1)
+	}
+	if !strings.HasSuffix(best, ")") {
+		best += "...)"
+	}
+	return best
+}
+
+// constructStart returns the offset of the last unescaped "(?" or "(*"
+// at or before offset, or -1.
+func constructStart(pattern string, offset int) int {
+	for i := min(offset, len(pattern)-2); i >= 0; i-- {
+		if pattern[i] != '(' || (pattern[i+1] != '?' && pattern[i+1] != '*') {
+			continue
+		}
+		backslashes := 0
+		for j := i - 1; j >= 0 && pattern[j] == '\\'; j-- {
+			backslashes++
+		}
+		if backslashes%2 == 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// prefixDistance is the smallest optimal-string-alignment distance
+// between want and any prefix of got, so that "(*atomc:a)" is one edit
+// from "(*atomic:" and "(*UFT)" one transposition from "(*UTF)".
+func prefixDistance(want, got string) int {
+	// d[i][j] is the distance between want[:i] and got[:j].
+	d := make([][]int, len(want)+1)
+	for i := range d {
+		d[i] = make([]int, len(got)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(want); i++ {
+		for j := 1; j <= len(got); j++ {
+			cost := 1
+			if want[i-1] == got[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && want[i-1] == got[j-2] && want[i-2] == got[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	best := d[len(want)][0]
+	for _, v := range d[len(want)] {
+		best = min(best, v)
+	}
+	return best
+}
@@ -0,0 +1,471 @@
+package flavor
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// TokenKind classifies a lexical token of a raw pattern for syntax
+// highlighting. Kinds are coarse on purpose: they drive coloring, not
+// parsing, so they only need to agree with the diagram's categories.
+type TokenKind string
+
+// Token kind constants
+const (
+	TokenLiteral     TokenKind = "literal"     // Plain characters
+	TokenEscape      TokenKind = "escape"      // \d, \n, \p{L}, back-references
+	TokenClass       TokenKind = "class"       // [...] bracket expressions and .
+	TokenGroup       TokenKind = "group"       // ( ) and group prefixes like (?: (?<name>
+	TokenQuantifier  TokenKind = "quantifier"  // * + ? {n,m} plus lazy/possessive suffixes
+	TokenAlternation TokenKind = "alternation" // |
+	TokenAnchor      TokenKind = "anchor"      // ^ $ \b \A ...
+	TokenComment     TokenKind = "comment"     // (?#...)
+	TokenDelimiter   TokenKind = "delimiter"   // /.../flags delimiters (JavaScript)
+)
+
+// TokenKinds lists every TokenKind, in declaration order.
+var TokenKinds = []TokenKind{
+	TokenLiteral, TokenEscape, TokenClass, TokenGroup, TokenQuantifier,
+	TokenAlternation, TokenAnchor, TokenComment, TokenDelimiter,
+}
+
+// Token is one lexical unit of a raw pattern. Offset is the byte offset
+// of Text within the pattern; concatenating every token's Text in order
+// reproduces the pattern exactly.
+type Token struct {
+	Kind   TokenKind
+	Text   string
+	Offset int
+}
+
+// Tokenizer is implemented by flavors that can split a raw pattern into
+// highlighting tokens. It is optional: callers should go through Tokens,
+// which falls back to a single literal token for flavors without it.
+type Tokenizer interface {
+	Tokenize(pattern string) []Token
+}
+
+// Tokens returns the highlighting tokens for pattern under f.
+func Tokens(f Flavor, pattern string) []Token {
+	if t, ok := f.(Tokenizer); ok {
+		return t.Tokenize(pattern)
+	}
+	if pattern == "" {
+		return nil
+	}
+	return []Token{{Kind: TokenLiteral, Text: pattern}}
+}
+
+// Syntax selects the lexical rules TokenizeSyntax applies. The flavors
+// fall into three families — POSIX basic, POSIX extended, and
+// Perl-derived — and each flavor's Tokenize method picks one, adding
+// the Perl extensions its own syntax has.
+type Syntax struct {
+	// BRE treats ( ) { } | + ? as literals and their backslashed
+	// forms as operators.
+	BRE bool
+	// GNU enables the \+ \? \| operators and \< \> \w style escapes
+	// on top of BRE.
+	GNU bool
+	// Perl enables (?...) group prefixes, lazy quantifier suffixes,
+	// and the braced escapes such as \p{L} and \k<name>.
+	Perl bool
+	// Quote enables \Q...\E quoting (PCRE, Java, Go).
+	Quote bool
+	// Possessive enables the possessive quantifier suffix + (PCRE,
+	// Java).
+	Possessive bool
+	// Verbs enables (*VERB) backtracking controls and pattern start
+	// options (PCRE).
+	Verbs bool
+	// Comments enables (?#...) comments (PCRE, .NET).
+	Comments bool
+	// StringAnchors enables the \A \Z \z \G assertions, which every
+	// Perl-derived flavor but JavaScript has.
+	StringAnchors bool
+	// SlashDelimited recognizes the /pattern/flags form (JavaScript).
+	SlashDelimited bool
+}
+
+// PerlSyntax enables every Perl extension, as PCRE has them.
+var PerlSyntax = Syntax{Perl: true, Quote: true, Possessive: true, Verbs: true, Comments: true, StringAnchors: true}
+
+// TokenizeSyntax splits pattern into highlighting tokens. It is a
+// tolerant lexer, not a validator: malformed input (unclosed brackets,
+// dangling escapes) still yields tokens that cover every byte, so
+// highlighting never fails where parsing might.
+func TokenizeSyntax(pattern string, syn Syntax) []Token {
+	lx := &lexer{src: pattern, syn: syn}
+	body, end := 0, len(pattern)
+	if syn.SlashDelimited && strings.HasPrefix(pattern, "/") {
+		if last := strings.LastIndexByte(pattern, '/'); last > 0 {
+			lx.emit(TokenDelimiter, 0, 1)
+			body, end = 1, last
+		}
+	}
+	lx.run(body, end)
+	if end < len(pattern) {
+		lx.emit(TokenDelimiter, end, len(pattern))
+	}
+	return lx.tokens
+}
+
+// lexer carries the state of one TokenizeSyntax call.
+type lexer struct {
+	src    string
+	syn    Syntax
+	tokens []Token
+}
+
+// emit appends src[start:end] as a token, merging adjacent literals so
+// a run like "abc" becomes one token instead of three.
+func (lx *lexer) emit(kind TokenKind, start, end int) {
+	if start >= end {
+		return
+	}
+	if n := len(lx.tokens); n > 0 && kind == TokenLiteral && lx.tokens[n-1].Kind == TokenLiteral &&
+		lx.tokens[n-1].Offset+len(lx.tokens[n-1].Text) == start {
+		lx.tokens[n-1].Text += lx.src[start:end]
+		return
+	}
+	lx.tokens = append(lx.tokens, Token{Kind: kind, Text: lx.src[start:end], Offset: start})
+}
+
+func (lx *lexer) run(i, end int) {
+	for i < end {
+		c := lx.src[i]
+		switch {
+		case c == '\\':
+			i = lx.escape(i, end)
+		case c == '[':
+			j := lx.bracketEnd(i, end)
+			lx.emit(TokenClass, i, j)
+			i = j
+		case c == '.':
+			lx.emit(TokenClass, i, i+1)
+			i++
+		case c == '^' || c == '$':
+			lx.emit(TokenAnchor, i, i+1)
+			i++
+		case c == '*':
+			i = lx.quantifier(i, i+1, end)
+		case !lx.syn.BRE && (c == '+' || c == '?'):
+			i = lx.quantifier(i, i+1, end)
+		case !lx.syn.BRE && c == '{':
+			if j, ok := lx.interval(i+1, end, "}"); ok {
+				i = lx.quantifier(i, j, end)
+			} else {
+				lx.emit(TokenLiteral, i, i+1)
+				i++
+			}
+		case !lx.syn.BRE && c == '|':
+			lx.emit(TokenAlternation, i, i+1)
+			i++
+		case !lx.syn.BRE && c == '(':
+			i = lx.groupOpen(i, end)
+		case !lx.syn.BRE && c == ')':
+			lx.emit(TokenGroup, i, i+1)
+			i++
+		default:
+			_, size := utf8.DecodeRuneInString(lx.src[i:end])
+			lx.emit(TokenLiteral, i, i+size)
+			i += size
+		}
+	}
+}
+
+// quantifier emits src[start:j] plus any lazy (?) or possessive (+)
+// suffix the syntax allows, returning the index after the token.
+func (lx *lexer) quantifier(start, j, end int) int {
+	if lx.syn.Perl && j < end && (lx.src[j] == '?' || lx.syn.Possessive && lx.src[j] == '+') {
+		j++
+	}
+	lx.emit(TokenQuantifier, start, j)
+	return j
+}
+
+// interval reports whether src[i:] begins with the body of an interval
+// expression ("n}", "n,}", "n,m}", ",m}") terminated by closer, and
+// returns the index just past closer.
+func (lx *lexer) interval(i, end int, closer string) (int, bool) {
+	j := i
+	digits := 0
+	for j < end && lx.src[j] >= '0' && lx.src[j] <= '9' {
+		j++
+		digits++
+	}
+	if j < end && lx.src[j] == ',' {
+		j++
+		for j < end && lx.src[j] >= '0' && lx.src[j] <= '9' {
+			j++
+			digits++
+		}
+	}
+	if digits == 0 || !strings.HasPrefix(lx.src[j:end], closer) {
+		return i, false
+	}
+	return j + len(closer), true
+}
+
+// escape lexes a backslash sequence starting at i.
+func (lx *lexer) escape(i, end int) int {
+	if i+1 >= end {
+		lx.emit(TokenLiteral, i, end)
+		return end
+	}
+	next := lx.src[i+1]
+
+	if lx.syn.BRE {
+		switch {
+		case next == '(' || next == ')':
+			lx.emit(TokenGroup, i, i+2)
+			return i + 2
+		case next == '{':
+			if j, ok := lx.interval(i+2, end, `\}`); ok {
+				lx.emit(TokenQuantifier, i, j)
+				return j
+			}
+		case lx.syn.GNU && (next == '+' || next == '?'):
+			lx.emit(TokenQuantifier, i, i+2)
+			return i + 2
+		case lx.syn.GNU && next == '|':
+			lx.emit(TokenAlternation, i, i+2)
+			return i + 2
+		}
+	}
+
+	if isAnchorEscape(next, lx.syn) {
+		lx.emit(TokenAnchor, i, i+2)
+		return i + 2
+	}
+
+	if lx.syn.Perl {
+		switch next {
+		case 'Q':
+			if !lx.syn.Quote {
+				break
+			}
+			// \Q...\E quotes everything up to \E (or the end).
+			lx.emit(TokenEscape, i, i+2)
+			body := i + 2
+			stop := strings.Index(lx.src[body:end], `\E`)
+			if stop < 0 {
+				lx.emit(TokenLiteral, body, end)
+				return end
+			}
+			lx.emit(TokenLiteral, body, body+stop)
+			lx.emit(TokenEscape, body+stop, body+stop+2)
+			return body + stop + 2
+		case 'p', 'P', 'x', 'N', 'g', 'k', 'o', 'u':
+			// Braced / bracketed argument forms: \p{L}, \x{41}, \k<n>, \g{-1}.
+			if j := lx.closeArg(i+2, end); j > 0 {
+				lx.emit(TokenEscape, i, j)
+				return j
+			}
+			// Unbraced code points: \x41, \u0041.
+			if j := unbracedCodePoint(lx.src, i, end); j > 0 {
+				lx.emit(TokenEscape, i, j)
+				return j
+			}
+		case 'c':
+			// \cX names a control character.
+			if i+2 < end && lx.src[i+2] < utf8.RuneSelf {
+				lx.emit(TokenEscape, i, i+3)
+				return i + 3
+			}
+		}
+	}
+
+	_, size := utf8.DecodeRuneInString(lx.src[i+1 : end])
+	lx.emit(TokenEscape, i, i+1+size)
+	return i + 1 + size
+}
+
+// unbracedCodePoint returns the index just past a \x escape with one
+// or two hex digits or a \u escape with four starting at i, or 0.
+func unbracedCodePoint(src string, i, end int) int {
+	digits := 2
+	if src[i+1] == 'u' {
+		digits = 4
+	} else if src[i+1] != 'x' {
+		return 0
+	}
+	j := i + 2
+	for j < end && j < i+2+digits && strings.IndexByte("0123456789abcdefABCDEF", src[j]) >= 0 {
+		j++
+	}
+	if j == i+2 || digits == 4 && j < i+6 {
+		return 0
+	}
+	return j
+}
+
+// isAnchorEscape reports whether \c is a zero-width assertion under syn.
+// GNU adds the word-edge and buffer-edge forms \< \> \` \'.
+func isAnchorEscape(c byte, syn Syntax) bool {
+	switch c {
+	case 'b', 'B':
+		return true
+	case 'A', 'Z', 'z', 'G':
+		return syn.StringAnchors
+	case '<', '>', '`', '\'':
+		return syn.GNU
+	}
+	return false
+}
+
+// closeArg returns the index just past a {...}, <...>, or '...'
+// argument starting at i, or 0 if there is none. Unbraced forms like
+// \pL fall through to the generic two-character escape.
+func (lx *lexer) closeArg(i, end int) int {
+	if i >= end {
+		return 0
+	}
+	var closer byte
+	switch lx.src[i] {
+	case '{':
+		closer = '}'
+	case '<':
+		closer = '>'
+	case '\'':
+		closer = '\''
+	default:
+		return 0
+	}
+	if k := strings.IndexByte(lx.src[i+1:end], closer); k >= 0 {
+		return i + 1 + k + 1
+	}
+	return 0
+}
+
+// bracketEnd returns the index just past the bracket expression that
+// opens at i. A leading ] (after an optional ^) is literal, [:...:]
+// style classes are skipped whole, backslash escapes are honored
+// outside BRE syntax, and Perl-family flavors allow nested brackets
+// (Java unions, JavaScript v-mode). An unclosed bracket runs to end.
+func (lx *lexer) bracketEnd(i, end int) int {
+	j := i + 1
+	if j < end && lx.src[j] == '^' {
+		j++
+	}
+	if j < end && lx.src[j] == ']' {
+		j++
+	}
+	depth := 1
+	for j < end {
+		c := lx.src[j]
+		switch {
+		case c == '\\' && !lx.syn.BRE && j+1 < end:
+			j += 2
+			continue
+		case c == '[' && j+1 < end && (lx.src[j+1] == ':' || lx.src[j+1] == '.' || lx.src[j+1] == '='):
+			closer := string([]byte{lx.src[j+1], ']'})
+			if k := strings.Index(lx.src[j+2:end], closer); k >= 0 {
+				j += 2 + k + 2
+				continue
+			}
+		case c == '[' && lx.syn.Perl:
+			depth++
+		case c == ']':
+			depth--
+			if depth == 0 {
+				return j + 1
+			}
+		}
+		j++
+	}
+	return end
+}
+
+// groupOpen lexes an opening parenthesis and, for Perl syntax, the
+// group prefix that follows it: (?:, (?<name>, (?i), (?#...), (*VERB).
+func (lx *lexer) groupOpen(i, end int) int {
+	if !lx.syn.Perl || i+1 >= end || (lx.src[i+1] != '?' && !(lx.syn.Verbs && lx.src[i+1] == '*')) {
+		lx.emit(TokenGroup, i, i+1)
+		return i + 1
+	}
+
+	if lx.src[i+1] == '*' {
+		// (*VERB), (*VERB:arg), (*UTF), (*sr:...) — the verb name and
+		// its colon form the prefix; scoped forms keep their body.
+		j := i + 2
+		for j < end && lx.src[j] != ')' && lx.src[j] != ':' {
+			j++
+		}
+		if j < end && lx.src[j] == ':' && j+1 < end && lx.src[j+1] != ')' {
+			// Possibly a scoped run like (*atomic:...); arguments
+			// to verbs such as (*MARK:name) stop at ')'.
+			k := strings.IndexByte(lx.src[j:end], ')')
+			if k >= 0 && !strings.ContainsAny(lx.src[j:j+k], "([") {
+				j += k
+			}
+		}
+		if j < end && lx.src[j] == ')' {
+			j++
+		} else if j < end {
+			j++
+		}
+		lx.emit(TokenGroup, i, j)
+		return j
+	}
+
+	// (?...
+	j := i + 2
+	if j >= end {
+		lx.emit(TokenGroup, i, j)
+		return j
+	}
+	switch c := lx.src[j]; {
+	case c == '#' && lx.syn.Comments:
+		k := strings.IndexByte(lx.src[j:end], ')')
+		if k < 0 {
+			lx.emit(TokenComment, i, end)
+			return end
+		}
+		lx.emit(TokenComment, i, j+k+1)
+		return j + k + 1
+	case c == ':' || c == '=' || c == '!' || c == '>' || c == '|':
+		j++
+	case c == '<' && j+1 < end && (lx.src[j+1] == '=' || lx.src[j+1] == '!'):
+		j += 2
+	case c == '<' || c == '\'' || (c == 'P' && j+1 < end && lx.src[j+1] == '<'):
+		// Named group: (?<name>, (?'name', (?P<name>. Also covers
+		// .NET balancing groups (?<a-b>.
+		closer := byte('>')
+		if c == '\'' {
+			closer = '\''
+		}
+		if k := strings.IndexByte(lx.src[j+1:end], closer); k >= 0 {
+			j += 1 + k + 1
+		} else {
+			j = end
+		}
+	case c == 'P' && j+1 < end && (lx.src[j+1] == '=' || lx.src[j+1] == '>'):
+		// (?P=name) back-reference or (?P>name) recursion.
+		if k := strings.IndexByte(lx.src[j:end], ')'); k >= 0 {
+			j += k + 1
+		} else {
+			j = end
+		}
+		lx.emit(TokenEscape, i, j)
+		return j
+	case c == '(':
+		// Conditional (?(cond) — include the condition.
+		if k := strings.IndexByte(lx.src[j:end], ')'); k >= 0 {
+			j += k + 1
+		} else {
+			j = end
+		}
+	default:
+		// Inline modifiers (?i) / (?i-s:...), recursion (?R) (?1)
+		// (?&name), callouts (?C1). Consume up to ':' or ')'.
+		for j < end && lx.src[j] != ')' && lx.src[j] != ':' {
+			j++
+		}
+		if j < end {
+			j++
+		}
+	}
+	lx.emit(TokenGroup, i, j)
+	return j
+}
@@ -0,0 +1,101 @@
+package flavor
+
+import (
+	"strings"
+	"testing"
+)
+
+// kinds renders a token stream as "kind:text" pairs for compact
+// comparisons in table tests.
+func kinds(tokens []Token) string {
+	parts := make([]string, len(tokens))
+	for i, t := range tokens {
+		parts[i] = string(t.Kind) + ":" + t.Text
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestTokenizeSyntax(t *testing.T) {
+	perl := PerlSyntax
+	js := Syntax{Perl: true, SlashDelimited: true}
+	tests := []struct {
+		name    string
+		pattern string
+		syn     Syntax
+		want    string
+	}{
+		{"literal run merges", "abc", perl, "literal:abc"},
+		{"perl group prefix", "(?:ab)", perl, "group:(?: literal:ab group:)"},
+		{"named group", `(?<year>\d{4})`, perl, `group:(?<year> escape:\d quantifier:{4} group:)`},
+		{"lazy and possessive", "a+?b*+", perl, "literal:a quantifier:+? literal:b quantifier:*+"},
+		{"unbraced code points", `\x41\u0041\cAb`, perl, `escape:\x41 escape:\u0041 escape:\cA literal:b`},
+		{"charset with bracket", `[]a\]]x`, perl, `class:[]a\]] literal:x`},
+		{"posix class inside", "[[:alpha:]_]", perl, "class:[[:alpha:]_]"},
+		{"anchors", `^\bfoo$`, perl, `anchor:^ anchor:\b literal:foo anchor:$`},
+		{"comment", "a(?#note)b", perl, "literal:a comment:(?#note) literal:b"},
+		{"quoted", `\Q.*\E`, perl, `escape:\Q literal:.* escape:\E`},
+		{"unicode property", `\p{Lu}`, perl, `escape:\p{Lu}`},
+		{"verb", "(*SKIP)(*FAIL)", perl, "group:(*SKIP) group:(*FAIL)"},
+		{"alternation", "a|b", perl, "literal:a alternation:| literal:b"},
+		{"brace not interval", "a{x}", perl, "literal:a{x}"},
+		{"slash delimited", "/a+/gi", Syntax{Perl: true, SlashDelimited: true}, "delimiter:/ literal:a quantifier:+ delimiter:/gi"},
+		{"bre groups", `\(ab\)\{2\}`, Syntax{BRE: true}, `group:\( literal:ab group:\) quantifier:\{2\}`},
+		{"bre literal parens", "(a|b)+", Syntax{BRE: true}, "literal:(a|b)+"},
+		{"gnu bre operators", `a\+\|b\?`, Syntax{BRE: true, GNU: true}, `literal:a quantifier:\+ alternation:\| literal:b quantifier:\?`},
+		{"gnu word edges", `\<w\>`, Syntax{GNU: true}, `anchor:\< literal:w anchor:\>`},
+		{"ere group", "(a)?", Syntax{}, "group:( literal:a group:) quantifier:?"},
+		{"unclosed bracket", "[abc", perl, "class:[abc"},
+		{"dangling backslash", `a\`, perl, `literal:a\`},
+		{"multibyte literal", "é+", perl, "literal:é quantifier:+"},
+		{"javascript has no quoting", `\Q.*\E`, js, `escape:\Q class:. quantifier:* escape:\E`},
+		{"javascript has no possessive", "a*+", js, "literal:a quantifier:* quantifier:+"},
+		{"javascript has no string anchors", `\A\z\b`, js, `escape:\A escape:\z anchor:\b`},
+		{"javascript has no verbs", "(*F)", js, "group:( quantifier:* literal:F group:)"},
+		{"java has no verbs or comments", "(*F)(?#c)", Syntax{Perl: true, Quote: true, Possessive: true, StringAnchors: true},
+			"group:( quantifier:* literal:F group:) group:(?#c)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := kinds(TokenizeSyntax(tt.pattern, tt.syn))
+			if got != tt.want {
+				t.Errorf("TokenizeSyntax(%q)\n got: %s\nwant: %s", tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestTokenizeCoversInput guarantees the lossless contract: joining the
+// tokens reproduces the pattern, and offsets point at each token's text.
+func TestTokenizeCoversInput(t *testing.T) {
+	patterns := []string{
+		`/(?<y>\d{4})-[0-9]+?|^a\b/gi`,
+		`(?(1)a|b)(?P=name)(?'n'x)[a-z&&[^q]]`,
+		`\(\{[[:digit:]]\}\)\{1,\}`,
+		`((`, `\Qunterminated`, `(?<=a)(?<!b)(?>c)`,
+	}
+	for _, syn := range []Syntax{{Perl: true, SlashDelimited: true}, {BRE: true, GNU: true}, {}} {
+		for _, p := range patterns {
+			var sb strings.Builder
+			for _, tok := range TokenizeSyntax(p, syn) {
+				if p[tok.Offset:tok.Offset+len(tok.Text)] != tok.Text {
+					t.Errorf("%q: token %q has wrong offset %d", p, tok.Text, tok.Offset)
+				}
+				sb.WriteString(tok.Text)
+			}
+			if sb.String() != p {
+				t.Errorf("tokens of %q rejoin to %q", p, sb.String())
+			}
+		}
+	}
+}
+
+func TestTokensFallback(t *testing.T) {
+	mock := &mockFlavor{name: "test"}
+	got := Tokens(mock, "a+b")
+	if len(got) != 1 || got[0].Kind != TokenLiteral || got[0].Text != "a+b" {
+		t.Errorf("expected single literal fallback token, got %v", got)
+	}
+	if Tokens(mock, "") != nil {
+		t.Error("expected nil tokens for empty pattern")
+	}
+}
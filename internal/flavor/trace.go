@@ -0,0 +1,26 @@
+package flavor
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+)
+
+// ParseTracer is implemented by flavors that can record the grammar
+// rule trace of a parse: every rule entered and exited, with positions
+// and outcomes. It is optional: callers should go through ParseTrace,
+// which notes the missing trace and parses normally for flavors
+// without it.
+type ParseTracer interface {
+	ParseTrace(pattern string, w io.Writer) (*ast.Regexp, error)
+}
+
+// ParseTrace parses pattern under f, writing the rule trace to w.
+func ParseTrace(f Flavor, pattern string, w io.Writer) (*ast.Regexp, error) {
+	if t, ok := f.(ParseTracer); ok {
+		return t.ParseTrace(pattern, w)
+	}
+	_, _ = fmt.Fprintf(w, "(no rule trace available for flavor %s)\n", f.Name())
+	return f.Parse(pattern)
+}
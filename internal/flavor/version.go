@@ -0,0 +1,32 @@
+package flavor
+
+import (
+	"fmt"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+)
+
+// VersionChecker is implemented by flavors whose syntax grew release by
+// release, so a pattern that parses here may still be rejected by the
+// engine actually deployed. It is optional: callers go through
+// CheckVersion.
+type VersionChecker interface {
+	// Versions lists the releases the flavor can check against, oldest
+	// first.
+	Versions() []string
+
+	// CheckVersion returns an error naming the first construct in root
+	// that version does not support and the minimum version that does.
+	// version is one of Versions, or any later release.
+	CheckVersion(root *ast.Regexp, version string) error
+}
+
+// CheckVersion checks root against a release of f's engine. It fails
+// when f does not support version checks.
+func CheckVersion(f Flavor, root *ast.Regexp, version string) error {
+	vc, ok := f.(VersionChecker)
+	if !ok {
+		return fmt.Errorf("flavor %s does not support version checks", f.Name())
+	}
+	return vc.CheckVersion(root, version)
+}
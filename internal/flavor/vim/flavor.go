@@ -0,0 +1,116 @@
+// Package vim implements the regular expression dialect of Vim's
+// search and :substitute commands. Which characters are operators
+// depends on the "magic" level, which \v, \m, \M and \V switch for the
+// rest of the pattern, so the same diagram can come from (a|b)+ after
+// \v and \(a\|b\)\+ in the default magic mode.
+package vim
+
+import (
+	"github.com/0x4d5352/regolith/internal/ast"
+	"github.com/0x4d5352/regolith/internal/flavor"
+	"github.com/0x4d5352/regolith/internal/flavor/helpers"
+)
+
+func init() {
+	flavor.MustRegister(&Vim{})
+}
+
+// Vim implements the Flavor interface for Vim patterns.
+type Vim struct{}
+
+// Ensure Vim implements the Flavor interface.
+var _ flavor.Flavor = (*Vim)(nil)
+
+func (f *Vim) Name() string {
+	return "vim"
+}
+
+func (f *Vim) Description() string {
+	return "Vim search patterns - \\v \\m \\M \\V magic levels, \\zs/\\ze match boundaries"
+}
+
+// Family returns the flavor's syntax family.
+func (f *Vim) Family() flavor.Family {
+	return flavor.FamilyEditor
+}
+
+// Notes returns what `regolith flavors` says about the flavor beyond
+// its FeatureSet.
+func (f *Vim) Notes() flavor.Notes {
+	return flavor.Notes{
+		Examples: map[string]string{
+			"lookahead":            `foo\(bar\)\@=`,
+			"lookbehind":           `\(\$\)\@<=\d\+`,
+			"lookbehind_unlimited": `\(\d\+\.\)\@<=\d\+`,
+			"atomic_groups":        `\(a\+\)\@>b`,
+			"inline_modifiers":     `\cfoo`,
+			"octal_escapes":        `\%o101`,
+		},
+		Limitations: []string{
+			`Items that match a buffer position, such as \%23l, \%V and \%#, are rejected`,
+			"Patterns are read in the default magic mode; the 'ignorecase', 'smartcase' and 'magic' options are not visible",
+			"regolith convert cannot target Vim",
+		},
+		Notes: []string{
+			`\v, \m, \M and \V switch the magic level for the rest of the pattern`,
+		},
+	}
+}
+
+// Parse parses a pattern as typed after / in Vim, starting in the
+// default magic mode ('magic' set). Vim has no flag suffix: \c, \C and
+// \Z anywhere in the pattern set its options, and show in the flags
+// panel.
+func (f *Vim) Parse(pattern string) (*ast.Regexp, error) {
+	root, err := helpers.FinalizeParse(parse(pattern))
+	flavor.Locate(f, pattern, root)
+	return root, flavor.Pinpoint(f, pattern, err)
+}
+
+// patternDocs is the reference for Vim's pattern syntax.
+const patternDocs = "https://vimhelp.org/pattern.txt.html"
+
+func (f *Vim) SupportedFlags() []flavor.FlagInfo {
+	return []flavor.FlagInfo{
+		{Char: 'c', Name: "ignore-case", Description: "\\c: ignore case, overriding 'ignorecase'", DocURL: patternDocs + "#%2F%5Cc"},
+		{Char: 'C', Name: "match-case", Description: "\\C: match case, overriding 'ignorecase'", DocURL: patternDocs + "#%2F%5CC"},
+		{Char: 'Z', Name: "ignore-combining", Description: "\\Z: ignore Unicode combining characters", DocURL: patternDocs + "#%2F%5CZ"},
+	}
+}
+
+// Tokenize splits a Vim pattern into syntax-highlighting tokens,
+// following the magic level as it changes.
+func (f *Vim) Tokenize(pattern string) []flavor.Token {
+	return tokenize(pattern)
+}
+
+// SupportedFeatures returns the feature capabilities of Vim patterns.
+func (f *Vim) SupportedFeatures() flavor.FeatureSet {
+	return flavor.FeatureSet{
+		Lookahead:             true, // \@= and \@!
+		Lookbehind:            true, // \@<= and \@<!
+		LookbehindUnlimited:   true, // \@123<= only limits how far back to look
+		NamedGroups:           false,
+		AtomicGroups:          true, // \@>
+		PossessiveQuantifiers: false,
+		RecursivePatterns:     false,
+		ConditionalPatterns:   false,
+		UnicodeProperties:     false,
+		POSIXClasses:          true, // [[:alpha:]] inside a collection
+		BalancedGroups:        false,
+		InlineModifiers:       true, // \c, \C and \v-style magic switches
+		Comments:              false,
+		BranchReset:           false,
+		BacktrackingControl:   false,
+		Callouts:              false,
+		ScriptRuns:            false,
+		NonAtomicLookaround:   false,
+		PatternStartOptions:   false,
+		UnicodeSets:           false,
+		OctalEscapes:          true, // \%o40
+		HexBracedEscapes:      false,
+		NamedUnicodeEscapes:   false,
+		RelativeBackrefs:      false,
+		SetOperations:         false,
+	}
+}
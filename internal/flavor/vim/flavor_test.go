@@ -0,0 +1,245 @@
+package vim
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+	"github.com/0x4d5352/regolith/internal/flavor"
+)
+
+func TestVimFlavorName(t *testing.T) {
+	v := &Vim{}
+	if v.Name() != "vim" {
+		t.Errorf("expected name 'vim', got '%s'", v.Name())
+	}
+}
+
+func TestVimFlavorSupportedFlags(t *testing.T) {
+	var chars []byte
+	for _, f := range (&Vim{}).SupportedFlags() {
+		chars = append(chars, byte(f.Char))
+	}
+	if string(chars) != "cCZ" {
+		t.Errorf("expected flags cCZ, got %s", chars)
+	}
+}
+
+func TestVimFlavorRegistered(t *testing.T) {
+	f, ok := flavor.Get("vim")
+	if !ok {
+		t.Fatal("vim flavor not registered")
+	}
+	if f.Name() != "vim" {
+		t.Errorf("expected name 'vim', got '%s'", f.Name())
+	}
+}
+
+// TestVimMagicLevels checks that one pattern spelled for each magic
+// level parses to the same tree, positions aside.
+func TestVimMagicLevels(t *testing.T) {
+	v := &Vim{}
+	spellings := [][]string{
+		{`\(ab\|c\)\+x*\.`, `\v(ab|c)+x*\.`, `\M\(ab\|c\)\+x\*.`, `\V\(ab\|c\)\+x\*.`},
+		{`\%(a\)\{2,3}`, `\v%(a){2,3}`, `\M\%(a\)\{2,3}`, `\V\%(a\)\{2,3\}`},
+		{`^[a-z].$`, `\v^[a-z].$`, `\M^\[a-z]\.$`, `\V\^\[a-z]\.\$`},
+		{`\<a\@!b\>`, `\v<a@!b>`, `\M\<a\@!b\>`},
+	}
+	for _, set := range spellings {
+		want, err := v.Parse(set[0])
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", set[0], err)
+		}
+		ast.ClearPositions(want)
+		for _, p := range set[1:] {
+			got, err := v.Parse(p)
+			if err != nil {
+				t.Errorf("unexpected error for %q: %v", p, err)
+				continue
+			}
+			ast.ClearPositions(got)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%q parses differently from %q", p, set[0])
+			}
+		}
+	}
+}
+
+func TestVimParseNodes(t *testing.T) {
+	v := &Vim{}
+	parse := func(t *testing.T, pattern string) []*ast.MatchFragment {
+		t.Helper()
+		result, err := v.Parse(pattern)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", pattern, err)
+		}
+		return result.Matches[0].Fragments
+	}
+
+	t.Run("match boundaries", func(t *testing.T) {
+		frags := parse(t, `foo\zsbar\ze;`)
+		if len(frags) != 5 {
+			t.Fatalf("expected 5 fragments, got %d", len(frags))
+		}
+		for i, want := range map[int]string{1: ast.AnchorMatchStart, 3: ast.AnchorMatchEnd} {
+			a, ok := frags[i].Content.(*ast.Anchor)
+			if !ok || a.AnchorType != want {
+				t.Errorf("fragment %d: expected %s anchor, got %#v", i, want, frags[i].Content)
+			}
+		}
+	})
+
+	t.Run("non-capturing group takes no number", func(t *testing.T) {
+		frags := parse(t, `\%(a\)\(b\)`)
+		nc := frags[0].Content.(*ast.Subexp)
+		c := frags[1].Content.(*ast.Subexp)
+		if nc.GroupType != ast.GroupNonCapture || c.GroupType != ast.GroupCapture || c.Number != 1 {
+			t.Errorf("expected non-capture then capture #1, got %#v and %#v", nc, c)
+		}
+	})
+
+	t.Run("lookbehind follows its atom", func(t *testing.T) {
+		for _, p := range []string{`\(foo\)\@<=bar`, `\(foo\)\@12<=bar`} {
+			s, ok := parse(t, p)[0].Content.(*ast.Subexp)
+			if !ok || s.GroupType != ast.GroupPositiveLookbehind {
+				t.Fatalf("%q: expected lookbehind, got %#v", p, parse(t, p)[0].Content)
+			}
+			if inner, ok := s.Regexp.Matches[0].Fragments[0].Content.(*ast.Subexp); !ok || inner.Number != 1 {
+				t.Errorf("%q: expected the lookbehind to hold group #1", p)
+			}
+		}
+	})
+
+	t.Run("lazy braces", func(t *testing.T) {
+		r := parse(t, `a\{-2,}`)[0].Repeat
+		if r == nil || r.Min != 2 || r.Max != -1 || r.Greedy {
+			t.Errorf("expected lazy {2,}, got %#v", r)
+		}
+		r = parse(t, `a\{-}`)[0].Repeat
+		if r == nil || r.Min != 0 || r.Max != -1 || r.Greedy {
+			t.Errorf("expected lazy *, got %#v", r)
+		}
+	})
+
+	t.Run("concat branches become lookaheads", func(t *testing.T) {
+		frags := parse(t, `.*Peter\&.*Bob`)
+		s, ok := frags[0].Content.(*ast.Subexp)
+		if !ok || s.GroupType != ast.GroupPositiveLookahead {
+			t.Fatalf("expected a lookahead first, got %#v", frags[0].Content)
+		}
+		if len(frags) != 3 {
+			t.Errorf("expected lookahead, .* and Bob, got %d fragments", len(frags))
+		}
+	})
+
+	t.Run("optional sequence nests", func(t *testing.T) {
+		frags := parse(t, `r\%[ead]`)
+		if len(frags) != 2 {
+			t.Fatalf("expected r and the sequence, got %d fragments", len(frags))
+		}
+		opt := frags[1].Content.(*ast.Subexp).Regexp.Matches[0].Fragments[0]
+		if opt.Repeat == nil || opt.Repeat.Max != 1 {
+			t.Errorf("expected the sequence to be optional, got %#v", opt.Repeat)
+		}
+	})
+
+	t.Run("underscore adds end of line", func(t *testing.T) {
+		esc, ok := parse(t, `\_s`)[0].Content.(*ast.Escape)
+		if !ok || esc.EscapeType != "whitespace_or_newline" {
+			t.Errorf("expected whitespace or newline, got %#v", parse(t, `\_s`)[0].Content)
+		}
+		cs, ok := parse(t, `\_[ab]`)[0].Content.(*ast.Charset)
+		if !ok || len(cs.Items) != 3 {
+			t.Errorf("expected [ab] plus newline, got %#v", parse(t, `\_[ab]`)[0].Content)
+		}
+	})
+
+	t.Run("star with nothing before it is literal", func(t *testing.T) {
+		lit, ok := parse(t, `*a`)[0].Content.(*ast.Literal)
+		if !ok || lit.Text != "*a" {
+			t.Errorf("expected literal *a, got %#v", parse(t, `*a`)[0].Content)
+		}
+	})
+
+	t.Run("unclosed bracket is literal", func(t *testing.T) {
+		lit, ok := parse(t, `[ab`)[0].Content.(*ast.Literal)
+		if !ok || lit.Text != "[ab" {
+			t.Errorf("expected literal [ab, got %#v", parse(t, `[ab`)[0].Content)
+		}
+	})
+
+	t.Run("option items set flags", func(t *testing.T) {
+		result, err := v.Parse(`\cfoo\Z`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Flags != "cZ" {
+			t.Errorf("expected flags cZ, got %q", result.Flags)
+		}
+	})
+}
+
+func TestVimParseErrors(t *testing.T) {
+	v := &Vim{}
+	tests := []struct {
+		pattern string
+		pos     string // "line:col (offset)"
+		msg     string
+	}{
+		{`a\(b`, "1:2 (1)", `E54: Unmatched \(`},
+		{`\v(a`, "1:3 (2)", `E54: Unmatched (`},
+		{`\%(a`, "1:1 (0)", `E53: Unmatched \%(`},
+		{`a\)`, "1:2 (1)", `E55: Unmatched \)`},
+		{`a**`, "1:3 (2)", `E61: Nested *`},
+		{`a*\+`, "1:3 (2)", `E62: Nested \+`},
+		{`\+a`, "1:1 (0)", `E64: \+ follows nothing`},
+		{`a\{1`, "1:2 (1)", `E554: Syntax error in \{...}`},
+		{`a\@x`, "1:2 (1)", `E59: Invalid character after \@`},
+		{`\(a\)\2`, "1:6 (5)", `E65: Illegal back reference`},
+		{`\(\(\(\(\(\(\(\(\(\(a\)\)\)\)\)\)\)\)\)\)`, "1:19 (18)", `E51: Too many \(`},
+		{`a\zx`, "1:2 (1)", `E68`},
+		{`\_y`, "1:1 (0)", `E63`},
+		{`\y`, "1:1 (0)", `E867: Unknown operator \y`},
+		{`[z-a]`, "1:2 (1)", `E944`},
+		{`\%[]`, "1:1 (0)", `E70`},
+		{`x\%23l`, "1:2 (1)", `\%23l matches a buffer position`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.pattern, func(t *testing.T) {
+			_, err := v.Parse(tc.pattern)
+			if err == nil {
+				t.Fatalf("expected error for %q", tc.pattern)
+			}
+			msg := err.Error()
+			if !strings.HasPrefix(msg, "parse error: "+tc.pos+": ") {
+				t.Errorf("expected position %s, got: %v", tc.pos, msg)
+			}
+			if !strings.Contains(msg, tc.msg) {
+				t.Errorf("expected error to contain %q, got: %v", tc.msg, msg)
+			}
+		})
+	}
+}
+
+func TestVimTokenize(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{`\(a\|b\)\+`, `group:\( literal:a alternation:\| literal:b group:\) quantifier:\+`},
+		{`\v(a|b)+`, `group:\v group:( literal:a alternation:| literal:b group:) quantifier:+`},
+		{`foo\zs\d\{-1,}`, `literal:foo anchor:\zs escape:\d quantifier:\{-1,}`},
+		{`\V.*`, `group:\V literal:.*`},
+		{`x\@<=[a-z]`, `literal:x group:\@<= class:[a-z]`},
+	}
+	for _, tc := range tests {
+		var parts []string
+		for _, tok := range tokenize(tc.pattern) {
+			parts = append(parts, string(tok.Kind)+":"+tok.Text)
+		}
+		if got := strings.Join(parts, " "); got != tc.want {
+			t.Errorf("tokenize(%q):\n got %s\nwant %s", tc.pattern, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package vim
+
+import "github.com/0x4d5352/regolith/internal/ast"
+
+// classEscapes maps Vim's character class items to their EscapeType and
+// display Value. Several follow Vim options ('isident', 'iskeyword',
+// 'isfname', 'isprint'), so the diagram names the option rather than a
+// fixed set; the upper-case partner of those excludes digits instead of
+// negating.
+var classEscapes = map[byte][2]string{
+	's': {"whitespace", "whitespace"},
+	'S': {"non_whitespace", "non-whitespace"},
+	'd': {"digit", "digit"},
+	'D': {"non_digit", "non-digit"},
+	'w': {"word", "word"},
+	'W': {"non_word", "non-word"},
+	'a': {"alpha", "letter"},
+	'A': {"non_alpha", "non-letter"},
+	'l': {"lower", "lowercase letter"},
+	'L': {"non_lower", "non-lowercase"},
+	'u': {"upper", "uppercase letter"},
+	'U': {"non_upper", "non-uppercase"},
+	'x': {"hex_digit", "hex digit"},
+	'X': {"non_hex_digit", "non-hex digit"},
+	'o': {"octal_digit", "octal digit"},
+	'O': {"non_octal_digit", "non-octal digit"},
+	'h': {"word_head", "head of word"},
+	'H': {"non_word_head", "non-head of word"},
+	'i': {"identifier", "identifier ('isident')"},
+	'I': {"identifier_non_digit", "identifier, no digits"},
+	'k': {"keyword", "keyword ('iskeyword')"},
+	'K': {"keyword_non_digit", "keyword, no digits"},
+	'f': {"filename", "file name ('isfname')"},
+	'F': {"filename_non_digit", "file name, no digits"},
+	'p': {"printable", "printable ('isprint')"},
+	'P': {"printable_non_digit", "printable, no digits"},
+}
+
+// controlEscapes maps the escapes for control characters.
+var controlEscapes = map[byte][2]string{
+	'e': {"escape", "escape"},
+	't': {"tab", "tab"},
+	'r': {"carriage_return", "carriage return"},
+	'b': {"backspace", "backspace"},
+	'n': {"newline", "end of line"},
+}
+
+// makeEscape creates an Escape node for a single-letter class or
+// control item, or returns nil when code is neither.
+func makeEscape(code byte) *ast.Escape {
+	t, ok := classEscapes[code]
+	if !ok {
+		if t, ok = controlEscapes[code]; !ok {
+			return nil
+		}
+	}
+	return &ast.Escape{EscapeType: t[0], Code: string(code), Value: t[1]}
+}
@@ -0,0 +1,719 @@
+package vim
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+	"github.com/0x4d5352/regolith/internal/flavor"
+)
+
+// Vim patterns have no PEG grammar: which characters are operators
+// changes mid-pattern with \v, \m, \M and \V, which a grammar cannot
+// follow, so the parser below reads the pattern by hand and asks at
+// each step how the current level spells an operator.
+
+// operators lists every character that is an operator at some magic
+// level, and magic maps each level to the ones that are operators
+// without a backslash. A backslash flips a character between the two
+// at every level: \( opens a group in magic mode and is a literal (
+// after \v.
+const operators = `^$.*[~()|+=?{@%<>&`
+
+var magic = map[byte]string{
+	'v': operators, // \v very magic
+	'm': `^$.*[~`,  // \m magic, the default
+	'M': `^$`,      // \M nomagic
+	'V': ``,        // \V very nomagic
+}
+
+// multis are the operators that repeat or assert on the atom before
+// them.
+const multis = `*+=?{@`
+
+// positioned reports msg at offset in pattern as a
+// *flavor.ParseError, positioned the way the PEG parsers' errors are.
+func positioned(pattern string, offset int, msg string) error {
+	return flavor.NewParseError(pattern, offset, msg)
+}
+
+// parseError carries an error out of the recursive descent; parse
+// recovers it. Messages use Vim's own E-numbers where Vim has one, so
+// they can be looked up with :help.
+type parseError struct{ err error }
+
+type parser struct {
+	src   string
+	pos   int
+	level byte // The magic level: 'v', 'm', 'M' or 'V'
+	state *ast.ParserState
+	flags string
+	refs  [][2]int // Offset and number of each back-reference
+}
+
+func parse(pattern string) (re *ast.Regexp, err error) {
+	p := &parser{src: pattern, level: 'm', state: ast.NewParserState()}
+	defer func() {
+		if r := recover(); r != nil {
+			pe, ok := r.(parseError)
+			if !ok {
+				panic(r)
+			}
+			re, err = nil, pe.err
+		}
+	}()
+	re = p.regexp()
+	if p.more() {
+		// Only a group close ends regexp early.
+		_, n := p.op()
+		p.fail(p.pos, "E55: Unmatched %s", p.src[p.pos:p.pos+n])
+	}
+	for _, ref := range p.refs {
+		if ref[1] > p.state.GroupCounter {
+			p.fail(ref[0], `E65: Illegal back reference \%d: there are %d groups`, ref[1], p.state.GroupCounter)
+		}
+	}
+	re.Flags = p.flags
+	return re, nil
+}
+
+func (p *parser) fail(offset int, format string, args ...any) {
+	panic(parseError{positioned(p.src, offset, fmt.Sprintf(format, args...))})
+}
+
+func (p *parser) more() bool { return p.pos < len(p.src) }
+
+func (p *parser) peek() byte {
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func (p *parser) has(prefix string) bool {
+	return strings.HasPrefix(p.src[p.pos:], prefix)
+}
+
+// next consumes and returns one UTF-8 character.
+func (p *parser) next() string {
+	_, size := utf8.DecodeRuneInString(p.src[p.pos:])
+	s := p.src[p.pos : p.pos+size]
+	p.pos += size
+	return s
+}
+
+// op reports the operator at the current position under the current
+// magic level, and how many bytes spell it, or 0 when there is none.
+func (p *parser) op() (byte, int) {
+	c := p.peek()
+	if c == '\\' {
+		if p.pos+1 < len(p.src) {
+			d := p.src[p.pos+1]
+			if strings.IndexByte(operators, d) >= 0 && strings.IndexByte(magic[p.level], d) < 0 {
+				return d, 2
+			}
+		}
+		return 0, 0
+	}
+	if c != 0 && strings.IndexByte(magic[p.level], c) >= 0 {
+		return c, 1
+	}
+	return 0, 0
+}
+
+// spell returns how the current level writes operator c, for messages.
+func (p *parser) spell(c byte) string {
+	if strings.IndexByte(magic[p.level], c) >= 0 {
+		return string(c)
+	}
+	return `\` + string(c)
+}
+
+// modifier consumes a magic level switch or one of the option items
+// \c, \C and \Z, and reports whether there was one. A level holds for
+// the rest of the pattern, even past the end of a group; an option
+// holds for all of it.
+func (p *parser) modifier() bool {
+	if p.peek() != '\\' || p.pos+1 >= len(p.src) {
+		return false
+	}
+	switch c := p.src[p.pos+1]; c {
+	case 'v', 'm', 'M', 'V':
+		p.level = c
+	case 'c', 'C', 'Z':
+		if strings.IndexByte(p.flags, c) < 0 {
+			p.flags += string(c)
+		}
+	default:
+		return false
+	}
+	p.pos += 2
+	return true
+}
+
+func (p *parser) regexp() *ast.Regexp {
+	re := &ast.Regexp{Matches: []*ast.Match{p.branch()}}
+	for {
+		c, n := p.op()
+		if c != '|' {
+			return re
+		}
+		p.pos += n
+		re.Matches = append(re.Matches, p.branch())
+	}
+}
+
+// branch parses concats joined by \&. A branch matches only where each
+// of them does, consuming what the last one matches, so every concat
+// but the last is drawn as the lookahead it amounts to.
+func (p *parser) branch() *ast.Match {
+	m := p.concat()
+	for {
+		c, n := p.op()
+		if c != '&' {
+			return m
+		}
+		p.pos += n
+		look := &ast.MatchFragment{Content: &ast.Subexp{
+			GroupType: ast.GroupPositiveLookahead,
+			Regexp:    &ast.Regexp{Matches: []*ast.Match{m}},
+		}}
+		next := p.concat()
+		next.Fragments = append([]*ast.MatchFragment{look}, next.Fragments...)
+		m = next
+	}
+}
+
+func (p *parser) concat() *ast.Match {
+	m := &ast.Match{Fragments: []*ast.MatchFragment{}}
+	for p.more() {
+		if p.modifier() {
+			continue
+		}
+		if c, _ := p.op(); c == '|' || c == '&' || c == ')' {
+			break
+		}
+		var prev *ast.MatchFragment
+		if n := len(m.Fragments); n > 0 {
+			prev = m.Fragments[n-1]
+		}
+		frag := &ast.MatchFragment{Content: p.atom(prev)}
+		p.multi(frag)
+		m.Fragments = append(m.Fragments, frag)
+	}
+	m.Fragments = mergeLiterals(m.Fragments)
+	return m
+}
+
+// mergeLiterals joins runs of unrepeated single-character literals into
+// one Literal, the way the other flavors draw plain text.
+func mergeLiterals(frags []*ast.MatchFragment) []*ast.MatchFragment {
+	var out []*ast.MatchFragment
+	for _, f := range frags {
+		lit, ok := f.Content.(*ast.Literal)
+		if ok && f.Repeat == nil && len(out) > 0 {
+			last := out[len(out)-1]
+			if prev, ok := last.Content.(*ast.Literal); ok && last.Repeat == nil {
+				last.Content = &ast.Literal{Text: prev.Text + lit.Text}
+				continue
+			}
+		}
+		out = append(out, f)
+	}
+	if out == nil {
+		out = []*ast.MatchFragment{}
+	}
+	return out
+}
+
+// atEnd reports whether a $ just read ends its branch, the only place
+// it is an anchor.
+func (p *parser) atEnd() bool {
+	if !p.more() || p.has(`\n`) {
+		return true
+	}
+	c, _ := p.op()
+	return c == '|' || c == '&' || c == ')'
+}
+
+// atom parses one atom; prev is the fragment before it in the concat,
+// or nil at its start.
+func (p *parser) atom(prev *ast.MatchFragment) ast.Node {
+	start := p.pos
+	c, n := p.op()
+	if c == 0 {
+		if p.peek() == '\\' {
+			return p.escape()
+		}
+		return &ast.Literal{Text: p.next()}
+	}
+	p.pos += n
+	switch c {
+	case '^':
+		// ^ is an anchor only at the start of a branch; \_^ is one
+		// anywhere.
+		if prev == nil {
+			return &ast.Anchor{AnchorType: ast.AnchorStart}
+		}
+		return &ast.Literal{Text: "^"}
+	case '$':
+		if p.atEnd() {
+			return &ast.Anchor{AnchorType: ast.AnchorEnd}
+		}
+		return &ast.Literal{Text: "$"}
+	case '.':
+		return &ast.AnyCharacter{}
+	case '~':
+		return &ast.Escape{EscapeType: "last_substitute", Code: "~", Value: "last substitute string"}
+	case '[':
+		if cs := p.collection(); cs != nil {
+			return cs
+		}
+		return &ast.Literal{Text: "["}
+	case '(':
+		return p.group(start, false)
+	case '%':
+		return p.percent(start)
+	case '<':
+		return &ast.Anchor{AnchorType: ast.AnchorWordStart}
+	case '>':
+		return &ast.Anchor{AnchorType: ast.AnchorWordEnd}
+	case '*':
+		// A * with nothing to repeat is a literal star.
+		if prev == nil || isStartAnchor(prev.Content) {
+			return &ast.Literal{Text: "*"}
+		}
+	}
+	p.fail(start, "E64: %s follows nothing", p.src[start:p.pos])
+	return nil
+}
+
+func isStartAnchor(n ast.Node) bool {
+	a, ok := n.(*ast.Anchor)
+	return ok && a.AnchorType == ast.AnchorStart
+}
+
+// group parses the rest of a \( or \%( group, whose opener starts at
+// start.
+func (p *parser) group(start int, nonCapture bool) ast.Node {
+	open := p.src[start:p.pos]
+	s := &ast.Subexp{GroupType: ast.GroupCapture}
+	if nonCapture {
+		s.GroupType = ast.GroupNonCapture
+	} else {
+		if p.state.GroupCounter == 9 {
+			p.fail(start, "E51: Too many %s: Vim allows nine capturing groups; use %s%s for the rest", open, p.spell('%'), p.spell('('))
+		}
+		s.Number = p.state.NextGroupNumber()
+	}
+	s.Regexp = p.regexp()
+	c, n := p.op()
+	if c != ')' {
+		if nonCapture {
+			p.fail(start, "E53: Unmatched %s", open)
+		}
+		p.fail(start, "E54: Unmatched %s", open)
+	}
+	p.pos += n
+	return s
+}
+
+// percent parses the \% items that can be drawn: non-capturing groups,
+// optional sequences, start and end of file, and characters by code.
+// The rest match a buffer position — a line, a column, the cursor, a
+// mark or the Visual area — which a pattern on its own does not have.
+func (p *parser) percent(start int) ast.Node {
+	c := p.peek()
+	switch c {
+	case '(':
+		p.pos++
+		return p.group(start, true)
+	case '[':
+		p.pos++
+		return p.optionalSequence(start)
+	case '^':
+		p.pos++
+		return &ast.Anchor{AnchorType: ast.AnchorStringStart}
+	case '$':
+		p.pos++
+		return &ast.Anchor{AnchorType: ast.AnchorAbsoluteEnd}
+	case 'd', 'x', 'X', 'o', 'u', 'U':
+		p.pos++
+		if esc := p.codePoint(c, start); esc != nil {
+			return esc
+		}
+		p.fail(start, "E678: Invalid character after %s: expected a number", p.src[start:p.pos])
+	}
+	if strings.IndexByte("V#<>'C.0123456789", c) >= 0 && c != 0 {
+		if c == '\'' {
+			p.pos++ // \%'m names mark m
+		}
+		for p.more() && strings.IndexByte("<>.0123456789", p.peek()) >= 0 {
+			p.pos++
+		}
+		if p.more() {
+			p.next()
+		}
+		p.fail(start, "%s matches a buffer position, which a diagram cannot show", p.src[start:p.pos])
+	}
+	p.fail(start, "E71: Invalid character after %s", p.src[start:p.pos])
+	return nil
+}
+
+// codeDigits gives, for each code point item, the base and the most
+// digits it reads.
+var codeDigits = map[byte][2]int{
+	'd': {10, 10},
+	'o': {8, 4},
+	'x': {16, 2},
+	'X': {16, 2},
+	'u': {16, 4},
+	'U': {16, 8},
+}
+
+var codeTypes = map[byte]string{
+	'd': "decimal", 'o': "octal", 'x': "hex", 'X': "hex", 'u': "unicode", 'U': "unicode",
+}
+
+// codePoint reads the digits of \%d123, \%x2a, \%u20AC and the like,
+// with the item letter kind already consumed, returning nil when none
+// follow.
+func (p *parser) codePoint(kind byte, start int) *ast.Escape {
+	base, most := codeDigits[kind][0], codeDigits[kind][1]
+	digits := p.pos
+	for p.pos < digits+most && p.more() && digitValue(p.peek()) < base {
+		p.pos++
+	}
+	if p.pos == digits {
+		return nil
+	}
+	code := `\` + strings.TrimPrefix(p.src[start:p.pos], `\`)
+	return &ast.Escape{EscapeType: codeTypes[kind], Code: code, Value: code}
+}
+
+func digitValue(c byte) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'a' && c <= 'f':
+		return int(c-'a') + 10
+	case c >= 'A' && c <= 'F':
+		return int(c-'A') + 10
+	}
+	return 99
+}
+
+// optionalSequence parses the atoms of \%[...], each matched only if
+// the ones before it were: r\%[ead] matches r, re, rea and read. It is
+// drawn as optional groups nested one inside the next.
+func (p *parser) optionalSequence(start int) ast.Node {
+	var atoms []ast.Node
+	for p.peek() != ']' {
+		if !p.more() {
+			p.fail(start, "E69: Missing ] after %s", p.src[start:start+strings.Index(p.src[start:], "[")+1])
+		}
+		if p.modifier() {
+			continue
+		}
+		atoms = append(atoms, p.atom(nil))
+		if c, _ := p.op(); c != 0 && strings.IndexByte(multis, c) >= 0 {
+			p.fail(p.pos, "E70: %s cannot be repeated inside %%[]", p.spell(c))
+		}
+	}
+	p.pos++ // ]
+	if len(atoms) == 0 {
+		p.fail(start, "E70: Empty %s", p.src[start:p.pos])
+	}
+	var inner *ast.MatchFragment
+	for i := len(atoms) - 1; i >= 0; i-- {
+		m := &ast.Match{Fragments: []*ast.MatchFragment{{Content: atoms[i]}}}
+		if inner != nil {
+			m.Fragments = append(m.Fragments, inner)
+		}
+		m.Fragments = mergeLiterals(m.Fragments)
+		inner = &ast.MatchFragment{
+			Content: &ast.Subexp{GroupType: ast.GroupNonCapture, Regexp: &ast.Regexp{Matches: []*ast.Match{m}}},
+			Repeat:  &ast.Repeat{Min: 0, Max: 1, Greedy: true},
+		}
+	}
+	return &ast.Subexp{GroupType: ast.GroupNonCapture, Regexp: &ast.Regexp{Matches: []*ast.Match{{Fragments: []*ast.MatchFragment{inner}}}}}
+}
+
+// multi parses the multi after frag's atom, if any. Vim allows one per
+// atom: a** is an error, not a repeat of a repeat.
+func (p *parser) multi(frag *ast.MatchFragment) {
+	if _, ok := frag.Content.(*ast.Anchor); ok {
+		return
+	}
+	start := p.pos
+	c, n := p.op()
+	p.pos += n
+	switch c {
+	case '*':
+		frag.Repeat = &ast.Repeat{Min: 0, Max: -1, Greedy: true}
+	case '+':
+		frag.Repeat = &ast.Repeat{Min: 1, Max: -1, Greedy: true}
+	case '=', '?':
+		frag.Repeat = &ast.Repeat{Min: 0, Max: 1, Greedy: true}
+	case '{':
+		frag.Repeat = p.braces(start)
+	case '@':
+		p.lookaround(frag, start)
+	default:
+		p.pos = start
+		return
+	}
+	if c, _ := p.op(); c != 0 && strings.IndexByte(multis, c) >= 0 {
+		if c == '*' {
+			p.fail(p.pos, "E61: Nested %s", p.spell(c))
+		}
+		p.fail(p.pos, "E62: Nested %s", p.spell(c))
+	}
+}
+
+// braces parses the rest of \{n,m}: \{n}, \{n,}, \{,m} and \{} repeat
+// greedily, and a leading - (\{-n,m}) makes them match as few as
+// possible. Vim swaps bounds given the wrong way round.
+func (p *parser) braces(start int) *ast.Repeat {
+	r := &ast.Repeat{Greedy: true}
+	if p.peek() == '-' {
+		r.Greedy = false
+		p.pos++
+	}
+	lo, hasLo := p.number()
+	r.Min, r.Max = lo, lo
+	if p.peek() == ',' {
+		p.pos++
+		r.Max = -1
+		if hi, ok := p.number(); ok {
+			r.Max = hi
+		}
+	} else if !hasLo {
+		r.Max = -1
+	}
+	switch {
+	case p.has(`\}`):
+		p.pos += 2
+	case p.peek() == '}':
+		p.pos++
+	default:
+		p.fail(start, "E554: Syntax error in %s{...}", p.src[start:start+strings.IndexByte(p.src[start:], '{')])
+	}
+	if r.Max >= 0 && r.Max < r.Min {
+		r.Min, r.Max = r.Max, r.Min
+	}
+	return r
+}
+
+// number reads a decimal number, reporting whether there was one.
+func (p *parser) number() (int, bool) {
+	start := p.pos
+	for p.peek() >= '0' && p.peek() <= '9' {
+		p.pos++
+	}
+	if p.pos == start {
+		return 0, false
+	}
+	n, err := strconv.Atoi(p.src[start:p.pos])
+	if err != nil {
+		p.fail(start, "number %s is too large", p.src[start:p.pos])
+	}
+	return n, true
+}
+
+// lookaround turns frag into the assertion a \@ multi makes of it:
+// \@= and \@! look ahead, \@<= and \@<! look behind (\@123<= only
+// within 123 bytes), and \@> matches it atomically.
+func (p *parser) lookaround(frag *ast.MatchFragment, start int) {
+	limit := p.pos
+	p.number()
+	limited := p.pos > limit
+	var group string
+	switch {
+	case p.has("<="):
+		group = ast.GroupPositiveLookbehind
+	case p.has("<!"):
+		group = ast.GroupNegativeLookbehind
+	case !limited && p.has("="):
+		group = ast.GroupPositiveLookahead
+	case !limited && p.has("!"):
+		group = ast.GroupNegativeLookahead
+	case !limited && p.has(">"):
+		group = ast.GroupAtomic
+	default:
+		p.fail(start, "E59: Invalid character after %s", p.src[start:p.pos])
+	}
+	if p.peek() == '<' {
+		p.pos++
+	}
+	p.pos++
+	frag.Content = &ast.Subexp{
+		GroupType: group,
+		Regexp:    &ast.Regexp{Matches: []*ast.Match{{Fragments: []*ast.MatchFragment{{Content: frag.Content}}}}},
+	}
+}
+
+// escape parses a backslash item that is not an operator at the current
+// level: a character class, a control character, a back-reference, one
+// of the \z and \_ items, or an escaped character matching itself.
+func (p *parser) escape() ast.Node {
+	start := p.pos
+	p.pos++ // \
+	if !p.more() {
+		p.fail(start, "trailing backslash")
+	}
+	if p.peek() >= utf8.RuneSelf {
+		return &ast.Literal{Text: p.next()}
+	}
+	c := p.src[p.pos]
+	p.pos++
+	if esc := makeEscape(c); esc != nil {
+		return esc
+	}
+	switch {
+	case c >= '1' && c <= '9':
+		p.refs = append(p.refs, [2]int{start, int(c - '0')})
+		return &ast.BackReference{Number: int(c - '0')}
+	case c == 'z':
+		switch p.peek() {
+		case 's':
+			p.pos++
+			return &ast.Anchor{AnchorType: ast.AnchorMatchStart}
+		case 'e':
+			p.pos++
+			return &ast.Anchor{AnchorType: ast.AnchorMatchEnd}
+		}
+		p.fail(start, `E68: Invalid character after \z`)
+	case c == '_':
+		return p.underscore(start)
+	case c == '0' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		p.fail(start, `E867: Unknown operator %s`, p.src[start:p.pos])
+	}
+	return &ast.Literal{Text: string(c)}
+}
+
+// underscore parses the \_ items, which add the end of a line to what
+// they match: \_s is whitespace or a line break, \_. any character at
+// all, and \_^ and \_$ are line anchors anywhere in the pattern.
+func (p *parser) underscore(start int) ast.Node {
+	c := p.peek()
+	p.pos++
+	switch c {
+	case '^':
+		return &ast.Anchor{AnchorType: ast.AnchorStart}
+	case '$':
+		return &ast.Anchor{AnchorType: ast.AnchorEnd}
+	case '.':
+		return &ast.Escape{EscapeType: "any_or_newline", Code: "_.", Value: "any character or newline"}
+	case '[':
+		cs := p.collection()
+		if cs == nil {
+			p.fail(start, `E769: Missing ] after \_[`)
+		}
+		if cs.Inverted {
+			// The line break is matched alongside the inverted class,
+			// not excluded by it.
+			return &ast.Subexp{GroupType: ast.GroupNonCapture, Regexp: &ast.Regexp{Matches: []*ast.Match{
+				{Fragments: []*ast.MatchFragment{{Content: cs}}},
+				{Fragments: []*ast.MatchFragment{{Content: makeEscape('n')}}},
+			}}}
+		}
+		cs.Items = append(cs.Items, makeEscape('n'))
+		return cs
+	}
+	if t, ok := classEscapes[c]; ok {
+		return &ast.Escape{EscapeType: t[0] + "_or_newline", Code: "_" + string(c), Value: t[1] + " or newline"}
+	}
+	p.fail(start, `E63: Invalid use of \_`)
+	return nil
+}
+
+// collection parses a [...] collection after its opener. With no
+// closing ] Vim reads the [ as a literal, so this returns nil and leaves
+// the position after the opener.
+func (p *parser) collection() *ast.Charset {
+	open := p.pos
+	cs := &ast.Charset{Items: []ast.CharsetItem{}}
+	if p.peek() == '^' {
+		cs.Inverted = true
+		p.pos++
+	}
+	// A ] right after [ or [^ is a literal.
+	if p.peek() == ']' {
+		cs.Items = append(cs.Items, &ast.CharsetLiteral{Text: "]"})
+		p.pos++
+	}
+	for p.peek() != ']' {
+		if !p.more() {
+			p.pos = open
+			return nil
+		}
+		if p.has("[:") {
+			if end := strings.Index(p.src[p.pos:], ":]"); end > 2 && isLower(p.src[p.pos+2:p.pos+end]) {
+				cs.Items = append(cs.Items, &ast.POSIXClass{Name: p.src[p.pos+2 : p.pos+end]})
+				p.pos += end + 2
+				continue
+			}
+		}
+		at := p.pos
+		lo, loText := p.member()
+		if p.peek() == '-' && p.pos+1 < len(p.src) && p.src[p.pos+1] != ']' && loText != "" {
+			p.pos++
+			hi, hiText := p.member()
+			if hiText == "" {
+				cs.Items = append(cs.Items, lo, &ast.CharsetLiteral{Text: "-"}, hi)
+				continue
+			}
+			loRune, _ := utf8.DecodeRuneInString(loText)
+			hiRune, _ := utf8.DecodeRuneInString(hiText)
+			if hiRune < loRune {
+				p.fail(at, "E944: Reverse range in character class")
+			}
+			cs.Items = append(cs.Items, &ast.CharsetRange{First: loText, Last: hiText})
+			continue
+		}
+		cs.Items = append(cs.Items, lo)
+	}
+	p.pos++ // ]
+	return cs
+}
+
+// member parses one collection member. Single characters, escaped or
+// not, also come back as the character they stand for so they can bound
+// a range.
+func (p *parser) member() (ast.CharsetItem, string) {
+	if p.peek() != '\\' || p.pos+1 >= len(p.src) {
+		ch := p.next()
+		return &ast.CharsetLiteral{Text: ch}, ch
+	}
+	start := p.pos
+	c := p.src[p.pos+1]
+	switch c {
+	case 'e', 't', 'r', 'b', 'n':
+		p.pos += 2
+		return makeEscape(c), ""
+	case '\\', ']', '^', '-':
+		p.pos += 2
+		return &ast.CharsetLiteral{Text: string(c)}, string(c)
+	case 'd', 'o', 'x', 'u', 'U':
+		p.pos += 2
+		if esc := p.codePoint(c, start); esc != nil {
+			return esc, ""
+		}
+		p.pos = start
+	}
+	// Any other backslash stands for itself.
+	p.pos++
+	return &ast.CharsetLiteral{Text: `\`}, `\`
+}
+
+func isLower(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 'a' || s[i] > 'z' {
+			return false
+		}
+	}
+	return s != ""
+}
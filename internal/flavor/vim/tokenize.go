@@ -0,0 +1,181 @@
+package vim
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/0x4d5352/regolith/internal/flavor"
+)
+
+// tokenize splits pattern into highlighting tokens. Like
+// flavor.TokenizeSyntax it is tolerant: malformed input still yields
+// tokens covering every byte. It reads operators with the parser's own
+// op, so highlighting follows the magic level exactly as parsing does.
+func tokenize(pattern string) []flavor.Token {
+	p := &parser{src: pattern, level: 'm'}
+	var tokens []flavor.Token
+	emit := func(kind flavor.TokenKind, start, end int) {
+		if n := len(tokens); n > 0 && kind == flavor.TokenLiteral && tokens[n-1].Kind == flavor.TokenLiteral {
+			tokens[n-1].Text += pattern[start:end]
+			return
+		}
+		tokens = append(tokens, flavor.Token{Kind: kind, Text: pattern[start:end], Offset: start})
+	}
+	for p.more() {
+		start := p.pos
+		if p.modifier() {
+			emit(flavor.TokenGroup, start, p.pos)
+			continue
+		}
+		c, n := p.op()
+		p.pos += n
+		switch c {
+		case '(', ')':
+			emit(flavor.TokenGroup, start, p.pos)
+		case '|', '&':
+			emit(flavor.TokenAlternation, start, p.pos)
+		case '*', '+', '=', '?':
+			emit(flavor.TokenQuantifier, start, p.pos)
+		case '{':
+			p.skipTo('}')
+			emit(flavor.TokenQuantifier, start, p.pos)
+		case '@':
+			for p.more() && strings.IndexByte("0123456789<", p.peek()) >= 0 {
+				p.pos++
+			}
+			if p.more() {
+				p.pos++
+			}
+			emit(flavor.TokenGroup, start, p.pos)
+		case '^', '$', '<', '>':
+			emit(flavor.TokenAnchor, start, p.pos)
+		case '.':
+			emit(flavor.TokenClass, start, p.pos)
+		case '~':
+			emit(flavor.TokenEscape, start, p.pos)
+		case '[':
+			p.skipCollection()
+			emit(flavor.TokenClass, start, p.pos)
+		case '%':
+			emit(p.percentToken(), start, p.pos)
+		default:
+			emit(p.plainToken(), start, p.pos)
+		}
+	}
+	return tokens
+}
+
+// skipTo moves past the next close, written c or \c.
+func (p *parser) skipTo(c byte) {
+	for p.more() {
+		if p.peek() == c {
+			p.pos++
+			return
+		}
+		if p.peek() == '\\' && p.pos+1 < len(p.src) && p.src[p.pos+1] == c {
+			p.pos += 2
+			return
+		}
+		p.pos++
+	}
+}
+
+// skipCollection moves past the collection whose [ was just read. When
+// it never closes, the [ is a literal and the position stays put.
+func (p *parser) skipCollection() {
+	i := p.pos
+	if i < len(p.src) && p.src[i] == '^' {
+		i++
+	}
+	if i < len(p.src) && p.src[i] == ']' {
+		i++
+	}
+	for i < len(p.src) {
+		switch {
+		case p.src[i] == ']':
+			p.pos = i + 1
+			return
+		case p.src[i] == '\\':
+			i += 2
+		case strings.HasPrefix(p.src[i:], "[:"):
+			if end := strings.Index(p.src[i:], ":]"); end > 0 {
+				i += end + 2
+				continue
+			}
+			i++
+		default:
+			i++
+		}
+	}
+}
+
+// percentToken consumes the rest of a \% item and returns its kind.
+func (p *parser) percentToken() flavor.TokenKind {
+	c := p.peek()
+	switch {
+	case c == '(':
+		p.pos++
+		return flavor.TokenGroup
+	case c == '[':
+		// The sequence's atoms are highlighted one by one.
+		p.pos++
+		return flavor.TokenGroup
+	case c == '^' || c == '$':
+		p.pos++
+		return flavor.TokenAnchor
+	case strings.IndexByte("dxXouU", c) >= 0 && c != 0:
+		p.pos++
+		p.codePoint(c, p.pos)
+		return flavor.TokenEscape
+	}
+	for p.more() && strings.IndexByte("<>0123456789", p.peek()) >= 0 {
+		p.pos++
+	}
+	if p.more() {
+		p.next()
+	}
+	return flavor.TokenAnchor
+}
+
+// plainToken consumes a character or backslash item that is not an
+// operator and returns its kind.
+func (p *parser) plainToken() flavor.TokenKind {
+	if p.peek() != '\\' {
+		p.next()
+		return flavor.TokenLiteral
+	}
+	p.pos++
+	if !p.more() {
+		return flavor.TokenLiteral
+	}
+	c := p.peek()
+	if c >= utf8.RuneSelf {
+		p.next()
+		return flavor.TokenLiteral
+	}
+	p.pos++
+	switch {
+	case c == 'z':
+		if p.more() {
+			p.next()
+		}
+		return flavor.TokenAnchor
+	case c == '_':
+		switch p.peek() {
+		case '^', '$':
+			p.pos++
+			return flavor.TokenAnchor
+		case '[':
+			p.pos++
+			p.skipCollection()
+			return flavor.TokenClass
+		}
+		if p.more() {
+			p.next()
+		}
+		return flavor.TokenEscape
+	case c >= '0' && c <= '9', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		return flavor.TokenEscape
+	}
+	return flavor.TokenLiteral
+}
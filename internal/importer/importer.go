@@ -0,0 +1,131 @@
+// Package importer builds regolith ASTs from structured descriptions
+// produced by other tools, so documentation pipelines that already keep
+// diagrams as data can render them without going back to pattern text.
+//
+// Two formats are recognized, by shape:
+//
+//   - The document `regolith --format json` writes ({"pattern",
+//     "flavor", "root"}). Its tree uses the regexper-derived node
+//     vocabulary regolith has always used, and it is decoded as is, so
+//     a generated or hand-edited tree renders without a pattern.
+//   - A railroad-diagrams tree: objects whose "type" names one of the
+//     railroad-diagrams constructors (Diagram, Sequence, Choice,
+//     Optional, OneOrMore, Terminal, ...) with the constructor's
+//     arguments as fields. See railroad.go for the mapping.
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+)
+
+// Format names for Document.Format.
+const (
+	FormatRegolith = "regolith"
+	FormatRailroad = "railroad-diagrams"
+)
+
+// Document is a decoded import.
+type Document struct {
+	Format string
+	// Pattern and Flavor are copied from a regolith document. A
+	// railroad-diagrams tree has neither, so both are empty.
+	Pattern string
+	Flavor  string
+	Root    *ast.Regexp
+}
+
+// ErrUnknownFormat is returned when the input is valid JSON but matches
+// neither supported format.
+var ErrUnknownFormat = errors.New("unrecognized JSON: expected a regolith --format json document or a railroad-diagrams tree")
+
+// Decode parses data as one of the supported formats.
+func Decode(data []byte) (*Document, error) {
+	var probe any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&probe); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	obj, _ := probe.(map[string]any)
+	if _, ok := obj["root"]; ok {
+		return decodeRegolith(obj)
+	}
+	if isRailroad(probe) {
+		root, err := decodeRailroad(probe)
+		if err != nil {
+			return nil, err
+		}
+		return &Document{Format: FormatRailroad, Root: root}, nil
+	}
+	return nil, ErrUnknownFormat
+}
+
+// Helpers shared by both decoders for reading loosely typed JSON.
+
+func str(obj map[string]any, key string) string {
+	s, _ := obj[key].(string)
+	return s
+}
+
+func boolean(obj map[string]any, key string) bool {
+	b, _ := obj[key].(bool)
+	return b
+}
+
+// integer returns obj[key] as an int, and whether it was present and
+// integral.
+func integer(obj map[string]any, key string) (int, bool) {
+	n, ok := obj[key].(json.Number)
+	if !ok {
+		return 0, false
+	}
+	i, err := n.Int64()
+	if err != nil {
+		return 0, false
+	}
+	return int(i), true
+}
+
+func object(v any, where string) (map[string]any, error) {
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: expected an object, got %s", where, jsonKind(v))
+	}
+	return obj, nil
+}
+
+func array(obj map[string]any, key, where string) ([]any, error) {
+	v, ok := obj[key]
+	if !ok || v == nil {
+		return nil, nil
+	}
+	arr, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%s.%s: expected an array, got %s", where, key, jsonKind(v))
+	}
+	return arr, nil
+}
+
+func jsonKind(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,138 @@
+package importer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+	"github.com/0x4d5352/regolith/internal/flavor"
+	_ "github.com/0x4d5352/regolith/internal/flavor/dotnet"
+	_ "github.com/0x4d5352/regolith/internal/flavor/javascript"
+	_ "github.com/0x4d5352/regolith/internal/flavor/pcre"
+	"github.com/0x4d5352/regolith/internal/output"
+)
+
+// TestRegolithRoundTrip checks that decoding RenderJSON's output gives
+// back a tree that serializes identically, across the node kinds each
+// flavor can produce.
+func TestRegolithRoundTrip(t *testing.T) {
+	tests := []struct{ flavor, pattern string }{
+		{"javascript", `/^a(?<n>b|[^c-d\w])+?\k<n>$/gi`},
+		{"javascript", `/[\p{L}--[a-z]](?=x)(?<!y)[\q{ab|c}]/v`},
+		{"javascript", `a{2,5}|.|\d{3}|`},
+		{"pcre", `(*UTF)(?i)(?|(a)|(b))(?(1)c|d)(?R)(?>e)\Qf*\E(?#note)(*SKIP)(?C1)[[:alpha:]]`},
+		{"dotnet", `(?<open>\()(?<close-open>\))`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.pattern, func(t *testing.T) {
+			f, _ := flavor.Get(tc.flavor)
+			root, err := f.Parse(tc.pattern)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			want, err := output.RenderJSON(root, tc.pattern, tc.flavor)
+			if err != nil {
+				t.Fatal(err)
+			}
+			doc, err := Decode([]byte(want))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if doc.Format != FormatRegolith || doc.Pattern != tc.pattern || doc.Flavor != tc.flavor {
+				t.Errorf("got format %q pattern %q flavor %q", doc.Format, doc.Pattern, doc.Flavor)
+			}
+			got, err := output.RenderJSON(doc.Root, doc.Pattern, doc.Flavor)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Errorf("round trip changed the tree:\nwant %s\ngot  %s", want, got)
+			}
+		})
+	}
+}
+
+// TestRailroad compares each decoded tree through its EBNF rendering,
+// which spells out the structure compactly: separate terminals stay
+// separate, and every group shows up as parentheses.
+func TestRailroad(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"terminals", `{"type":"Diagram","items":["a",{"type":"Terminal","text":"b"}]}`, `"a" , "b"`},
+		{"choice", `{"type":"Diagram","items":[{"type":"Choice","normal":1,"items":["a","b","c"]}]}`, `"a" | "b" | "c"`},
+		{"nested choice", `{"type":"Sequence","items":["x",{"type":"Choice","normal":0,"items":["a","b"]}]}`, `"x" , ( "a" | "b" )`},
+		{"optional", `{"type":"Optional","item":"a","skip":true}`, `[ "a" ]`},
+		{"zero or more", `{"type":"ZeroOrMore","item":{"type":"Sequence","items":["a","b"]}}`, `{ ( "a" , "b" ) }`},
+		{"separator", `{"type":"OneOrMore","item":"a","repeat":","}`, `"a" , { ( "," , "a" ) }`},
+		{"optional separator", `{"type":"ZeroOrMore","item":"a","repeat":","}`, `[ ( "a" , { ( "," , "a" ) } ) ]`},
+		{"comment loop", `{"type":"OneOrMore","item":"a","repeat":{"type":"Comment","text":"again"}}`, `"a" , { "a" }`},
+		{"skip", `{"type":"Choice","normal":0,"items":[{"type":"Skip"},"a"]}`, `(* empty *) | "a"`},
+		{"optional sequence", `{"type":"OptionalSequence","items":["a","b"]}`, `[ "a" ] , [ "b" ]`},
+		{"alternating", `{"type":"AlternatingSequence","items":["a","b"]}`, `( "a" , "b" | "b" , "a" )`},
+		{"start and end", `{"type":"Diagram","items":[{"type":"Start"},"a",{"type":"End"}]}`, `"a"`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := Decode([]byte(tc.json))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if doc.Format != FormatRailroad {
+				t.Errorf("format = %q, want %q", doc.Format, FormatRailroad)
+			}
+			got := output.RenderEBNF(doc.Root, "", "")
+			if want := "pattern = " + tc.want + " ;\n"; !strings.HasSuffix(got, want) {
+				t.Errorf("got\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestRailroadNonTerminalAndGroup(t *testing.T) {
+	doc, err := Decode([]byte(`{"type":"Group","item":{"type":"NonTerminal","text":"expr"},"label":"operand"}`))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	frags := doc.Root.Matches[0].Fragments
+	if len(frags) != 2 {
+		t.Fatalf("expected label comment and group, got %d fragments", len(frags))
+	}
+	if c, ok := frags[0].Content.(*ast.Comment); !ok || c.Text != "operand" {
+		t.Errorf("expected the label as a comment, got %#v", frags[0].Content)
+	}
+	sub, ok := frags[1].Content.(*ast.Subexp)
+	if !ok {
+		t.Fatalf("expected a group, got %#v", frags[1].Content)
+	}
+	esc, ok := sub.Regexp.Matches[0].Fragments[0].Content.(*ast.Escape)
+	if !ok || esc.EscapeType != "nonterminal" || esc.Value != "expr" {
+		t.Errorf("expected a nonterminal box for expr, got %#v", sub.Regexp.Matches[0].Fragments[0].Content)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		json string
+		want string
+	}{
+		{`{`, "invalid JSON"},
+		{`{"type":"Widget"}`, ErrUnknownFormat.Error()},
+		{`[1,2]`, ErrUnknownFormat.Error()},
+		{`{"type":"Sequence","items":[{"type":"Bogus"}]}`, `unknown railroad-diagrams type "Bogus"`},
+		{`{"type":"Sequence","items":"a"}`, "expected an array"},
+		{`{"pattern":"a","root":{"type":"mystery"}}`, `unknown node type "mystery"`},
+	}
+	for _, tc := range tests {
+		_, err := Decode([]byte(tc.json))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Decode(%s) error = %v, want %q", tc.json, err, tc.want)
+		}
+	}
+	if _, err := Decode([]byte(`{}`)); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("expected ErrUnknownFormat for an empty object, got %v", err)
+	}
+}
@@ -0,0 +1,288 @@
+package importer
+
+import (
+	"fmt"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+)
+
+// railroadTypes are the railroad-diagrams constructors decodeRailroad
+// understands. Each node is an object {"type": <constructor>, ...}
+// whose other fields are the constructor's arguments: "items" for
+// Diagram, Sequence, Stack, Choice and friends, "item" for the
+// single-child wrappers, "text" for Terminal, NonTerminal and Comment,
+// "normal" for Choice's default branch, "repeat" for the separator of
+// OneOrMore and ZeroOrMore, and "label" for Group. A bare string is a
+// Terminal, as in the JavaScript API.
+var railroadTypes = map[string]bool{
+	"Diagram": true, "ComplexDiagram": true,
+	"Sequence": true, "Stack": true, "OptionalSequence": true, "AlternatingSequence": true,
+	"Choice": true, "HorizontalChoice": true, "MultipleChoice": true,
+	"Optional": true, "OneOrMore": true, "ZeroOrMore": true, "Group": true,
+	"Terminal": true, "NonTerminal": true, "Comment": true,
+	"Skip": true, "Start": true, "End": true,
+}
+
+func isRailroad(v any) bool {
+	obj, ok := v.(map[string]any)
+	return ok && railroadTypes[str(obj, "type")]
+}
+
+// decodeRailroad maps a railroad-diagrams tree onto the AST the regex
+// parsers produce, so it is drawn by the same renderer:
+//
+//   - Terminal is a literal; NonTerminal is drawn as an escape-style
+//     label, the closest box the renderer has to a rule reference.
+//   - Sequence and Stack concatenate; Stack's line breaks are dropped.
+//   - Choice, HorizontalChoice, and MultipleChoice are alternations,
+//     wrapped in a non-capturing group when they are not the whole
+//     diagram. Which branch is "normal" does not affect the drawing.
+//   - Optional, ZeroOrMore, and OneOrMore are the ?, *, and +
+//     quantifiers. A repeat separator r turns OneOrMore(x, r) into
+//     x (?:r x)*, which is what the railroad loop means.
+//   - OptionalSequence makes every item optional, which also admits
+//     the empty sequence that railroad-diagrams excludes.
+//   - Group is a non-capturing group preceded by its label as a
+//     comment. Comment is a comment; Skip, Start, and End draw nothing.
+func decodeRailroad(v any) (*ast.Regexp, error) {
+	obj := asObject(v)
+	switch str(obj, "type") {
+	case "Diagram", "ComplexDiagram":
+		items, err := array(obj, "items", "Diagram")
+		if err != nil {
+			return nil, err
+		}
+		if len(items) == 1 && isChoice(items[0]) {
+			return railroadChoice(asObject(items[0]), "Diagram.items[0]")
+		}
+		m, err := railroadSequence(items, "Diagram")
+		if err != nil {
+			return nil, err
+		}
+		return &ast.Regexp{Matches: []*ast.Match{m}}, nil
+	}
+	if isChoice(v) {
+		return railroadChoice(obj, str(obj, "type"))
+	}
+	m, err := railroadSequence([]any{v}, "")
+	if err != nil {
+		return nil, err
+	}
+	return &ast.Regexp{Matches: []*ast.Match{m}}, nil
+}
+
+func isChoice(v any) bool {
+	switch str(asObject(v), "type") {
+	case "Choice", "HorizontalChoice", "MultipleChoice":
+		return true
+	}
+	return false
+}
+
+func railroadChoice(obj map[string]any, where string) (*ast.Regexp, error) {
+	items, err := array(obj, "items", where)
+	if err != nil {
+		return nil, err
+	}
+	r := &ast.Regexp{}
+	for i, item := range items {
+		m, err := railroadSequence([]any{item}, fmt.Sprintf("%s.items[%d]", where, i))
+		if err != nil {
+			return nil, err
+		}
+		r.Matches = append(r.Matches, m)
+	}
+	if len(r.Matches) == 0 {
+		r.Matches = []*ast.Match{{}}
+	}
+	return r, nil
+}
+
+// railroadSequence concatenates the fragments of items into one Match.
+func railroadSequence(items []any, where string) (*ast.Match, error) {
+	m := &ast.Match{}
+	for i, item := range items {
+		frags, err := railroadFragments(item, fmt.Sprintf("%s[%d]", where, i))
+		if err != nil {
+			return nil, err
+		}
+		m.Fragments = append(m.Fragments, frags...)
+	}
+	return m, nil
+}
+
+// railroadFragments returns the fragments one railroad item stands for.
+func railroadFragments(v any, where string) ([]*ast.MatchFragment, error) {
+	if s, ok := v.(string); ok {
+		return []*ast.MatchFragment{{Content: &ast.Literal{Text: s}}}, nil
+	}
+	obj, err := object(v, where)
+	if err != nil {
+		return nil, err
+	}
+	typ := str(obj, "type")
+	where += "." + typ
+	switch typ {
+	case "Terminal":
+		return []*ast.MatchFragment{{Content: &ast.Literal{Text: str(obj, "text")}}}, nil
+	case "NonTerminal":
+		text := str(obj, "text")
+		return []*ast.MatchFragment{{Content: &ast.Escape{EscapeType: "nonterminal", Code: text, Value: text}}}, nil
+	case "Comment":
+		return []*ast.MatchFragment{{Content: &ast.Comment{Text: str(obj, "text")}}}, nil
+	case "Skip", "Start", "End":
+		return nil, nil
+	case "Sequence", "Stack", "AlternatingSequence", "Diagram", "ComplexDiagram":
+		items, err := array(obj, "items", where)
+		if err != nil {
+			return nil, err
+		}
+		if typ == "AlternatingSequence" {
+			// AlternatingSequence(a, b) matches a then b or b then a.
+			return railroadAlternating(items, where)
+		}
+		m, err := railroadSequence(items, where+".items")
+		if err != nil {
+			return nil, err
+		}
+		return m.Fragments, nil
+	case "OptionalSequence":
+		items, err := array(obj, "items", where)
+		if err != nil {
+			return nil, err
+		}
+		var out []*ast.MatchFragment
+		for i, item := range items {
+			frag, err := railroadItem(item, fmt.Sprintf("%s.items[%d]", where, i))
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, withRepeat(frag, 0, 1))
+		}
+		return out, nil
+	case "Choice", "HorizontalChoice", "MultipleChoice":
+		r, err := railroadChoice(obj, where)
+		if err != nil {
+			return nil, err
+		}
+		return []*ast.MatchFragment{{Content: &ast.Subexp{GroupType: ast.GroupNonCapture, Regexp: r}}}, nil
+	case "Optional":
+		frag, err := railroadItem(obj["item"], where+".item")
+		if err != nil {
+			return nil, err
+		}
+		return []*ast.MatchFragment{withRepeat(frag, 0, 1)}, nil
+	case "OneOrMore", "ZeroOrMore":
+		min := 1
+		if typ == "ZeroOrMore" {
+			min = 0
+		}
+		return railroadRepeat(obj, min, where)
+	case "Group":
+		frag, err := railroadItem(obj["item"], where+".item")
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := frag.Content.(*ast.Subexp); !ok || frag.Repeat != nil {
+			frag = group([]*ast.MatchFragment{frag})
+		}
+		var out []*ast.MatchFragment
+		if label := str(obj, "label"); label != "" {
+			out = append(out, &ast.MatchFragment{Content: &ast.Comment{Text: label}})
+		}
+		return append(out, frag), nil
+	}
+	return nil, fmt.Errorf("%s: unknown railroad-diagrams type %q", where, typ)
+}
+
+// railroadItem returns v as a single fragment, grouping it when it
+// expands to several.
+func railroadItem(v any, where string) (*ast.MatchFragment, error) {
+	frags, err := railroadFragments(v, where)
+	if err != nil {
+		return nil, err
+	}
+	if len(frags) == 1 && frags[0].Repeat == nil {
+		return frags[0], nil
+	}
+	return group(frags), nil
+}
+
+// railroadRepeat expands OneOrMore/ZeroOrMore. Without a separator (or
+// with only a comment on the loop) the item is simply quantified; with
+// one, x (?:sep x)* is built, made optional as a whole for ZeroOrMore.
+func railroadRepeat(obj map[string]any, min int, where string) ([]*ast.MatchFragment, error) {
+	item, err := railroadItem(obj["item"], where+".item")
+	if err != nil {
+		return nil, err
+	}
+	var sep []*ast.MatchFragment
+	if obj["repeat"] != nil {
+		if sep, err = railroadFragments(obj["repeat"], where+".repeat"); err != nil {
+			return nil, err
+		}
+	}
+	if len(sep) == 0 || isCommentOnly(sep) {
+		return []*ast.MatchFragment{withRepeat(item, min, -1)}, nil
+	}
+	again := group(append(sep, copyFragment(item)))
+	seq := []*ast.MatchFragment{item, withRepeat(again, 0, -1)}
+	if min == 0 {
+		return []*ast.MatchFragment{withRepeat(group(seq), 0, 1)}, nil
+	}
+	return seq, nil
+}
+
+// railroadAlternating expands AlternatingSequence(a, b) into
+// (?:a b|b a).
+func railroadAlternating(items []any, where string) ([]*ast.MatchFragment, error) {
+	if len(items) != 2 {
+		return nil, fmt.Errorf("%s: expected exactly two items, got %d", where, len(items))
+	}
+	a, err := railroadItem(items[0], where+".items[0]")
+	if err != nil {
+		return nil, err
+	}
+	b, err := railroadItem(items[1], where+".items[1]")
+	if err != nil {
+		return nil, err
+	}
+	r := &ast.Regexp{Matches: []*ast.Match{
+		{Fragments: []*ast.MatchFragment{a, b}},
+		{Fragments: []*ast.MatchFragment{copyFragment(b), copyFragment(a)}},
+	}}
+	return []*ast.MatchFragment{{Content: &ast.Subexp{GroupType: ast.GroupNonCapture, Regexp: r}}}, nil
+}
+
+func isCommentOnly(frags []*ast.MatchFragment) bool {
+	for _, f := range frags {
+		if _, ok := f.Content.(*ast.Comment); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// group wraps frags in a non-capturing group.
+func group(frags []*ast.MatchFragment) *ast.MatchFragment {
+	return &ast.MatchFragment{Content: &ast.Subexp{
+		GroupType: ast.GroupNonCapture,
+		Regexp:    &ast.Regexp{Matches: []*ast.Match{{Fragments: frags}}},
+	}}
+}
+
+// withRepeat quantifies f, grouping it first if it already has a
+// quantifier.
+func withRepeat(f *ast.MatchFragment, min, max int) *ast.MatchFragment {
+	if f.Repeat != nil {
+		f = group([]*ast.MatchFragment{f})
+	}
+	return &ast.MatchFragment{Content: f.Content, Repeat: &ast.Repeat{Min: min, Max: max, Greedy: true}}
+}
+
+// copyFragment returns a shallow copy of f, so that an item drawn twice
+// is two distinct nodes; the renderer keys annotations by node.
+func copyFragment(f *ast.MatchFragment) *ast.MatchFragment {
+	c := *f
+	return &c
+}
@@ -0,0 +1,304 @@
+package importer
+
+import (
+	"fmt"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+)
+
+// kindToGroupType inverts the "kind" names output.RenderJSON gives
+// groups.
+var kindToGroupType = map[string]string{
+	"capture":            ast.GroupCapture,
+	"nonCapture":         ast.GroupNonCapture,
+	"positiveLookahead":  ast.GroupPositiveLookahead,
+	"negativeLookahead":  ast.GroupNegativeLookahead,
+	"positiveLookbehind": ast.GroupPositiveLookbehind,
+	"negativeLookbehind": ast.GroupNegativeLookbehind,
+	"namedCapture":       ast.GroupNamedCapture,
+	"atomic":             ast.GroupAtomic,
+}
+
+// decodeRegolith rebuilds the AST from a `regolith --format json`
+// document, reversing output.RenderJSON.
+func decodeRegolith(doc map[string]any) (*Document, error) {
+	root, err := decodeRegexp(doc["root"], "root")
+	if err != nil {
+		return nil, err
+	}
+	if root == nil {
+		root = &ast.Regexp{Matches: []*ast.Match{{}}}
+	}
+	// Flags and pattern options are hoisted onto the root node, whatever
+	// its type.
+	rootObj := asObject(doc["root"])
+	root.Flags = str(rootObj, "flags")
+	opts, err := array(rootObj, "options", "root")
+	if err != nil {
+		return nil, err
+	}
+	for i, o := range opts {
+		n, err := decodeNode(o, fmt.Sprintf("root.options[%d]", i))
+		if err != nil {
+			return nil, err
+		}
+		po, ok := n.(*ast.PatternOption)
+		if !ok {
+			return nil, fmt.Errorf("root.options[%d]: expected a patternOption", i)
+		}
+		root.Options = append(root.Options, po)
+	}
+	return &Document{
+		Format:  FormatRegolith,
+		Pattern: str(doc, "pattern"),
+		Flavor:  str(doc, "flavor"),
+		Root:    root,
+	}, nil
+}
+
+func asObject(v any) map[string]any {
+	obj, _ := v.(map[string]any)
+	return obj
+}
+
+// decodeRegexp reads an alternation, or a single sequence standing for
+// a one-branch regexp. A null body decodes to nil.
+func decodeRegexp(v any, where string) (*ast.Regexp, error) {
+	if v == nil {
+		return nil, nil
+	}
+	obj, err := object(v, where)
+	if err != nil {
+		return nil, err
+	}
+	if str(obj, "type") != "alternation" {
+		m, err := decodeMatch(obj, where)
+		if err != nil {
+			return nil, err
+		}
+		return &ast.Regexp{Matches: []*ast.Match{m}}, nil
+	}
+	alts, err := array(obj, "alternatives", where)
+	if err != nil {
+		return nil, err
+	}
+	r := &ast.Regexp{Flags: str(obj, "flags")}
+	for i, alt := range alts {
+		m, err := decodeMatch(alt, fmt.Sprintf("%s.alternatives[%d]", where, i))
+		if err != nil {
+			return nil, err
+		}
+		r.Matches = append(r.Matches, m)
+	}
+	return r, nil
+}
+
+// decodeMatch reads a sequence. Any other node is taken as a sequence
+// of one.
+func decodeMatch(v any, where string) (*ast.Match, error) {
+	obj, err := object(v, where)
+	if err != nil {
+		return nil, err
+	}
+	if str(obj, "type") != "sequence" {
+		f, err := decodeFragment(obj, where)
+		if err != nil {
+			return nil, err
+		}
+		return &ast.Match{Fragments: []*ast.MatchFragment{f}}, nil
+	}
+	elems, err := array(obj, "elements", where)
+	if err != nil {
+		return nil, err
+	}
+	m := &ast.Match{}
+	for i, e := range elems {
+		f, err := decodeFragment(e, fmt.Sprintf("%s.elements[%d]", where, i))
+		if err != nil {
+			return nil, err
+		}
+		m.Fragments = append(m.Fragments, f)
+	}
+	return m, nil
+}
+
+func decodeFragment(v any, where string) (*ast.MatchFragment, error) {
+	n, err := decodeNode(v, where)
+	if err != nil {
+		return nil, err
+	}
+	f := &ast.MatchFragment{Content: n}
+	if q, ok := asObject(v)["quantifier"].(map[string]any); ok {
+		f.Repeat = &ast.Repeat{Max: -1, Greedy: boolean(q, "greedy"), Possessive: boolean(q, "possessive")}
+		f.Repeat.Min, _ = integer(q, "min")
+		if max, ok := integer(q, "max"); ok {
+			f.Repeat.Max = max
+		}
+	}
+	return f, nil
+}
+
+// decodeNode reads one content node. Inside a character class, use
+// decodeCharsetItem instead, since "literal" means a CharsetLiteral
+// there.
+func decodeNode(v any, where string) (ast.Node, error) {
+	obj, err := object(v, where)
+	if err != nil {
+		return nil, err
+	}
+	switch typ := str(obj, "type"); typ {
+	case "literal":
+		return &ast.Literal{Text: str(obj, "value")}, nil
+	case "anyCharacter":
+		return &ast.AnyCharacter{}, nil
+	case "anchor":
+		return &ast.Anchor{AnchorType: str(obj, "anchorType")}, nil
+	case "escape":
+		return &ast.Escape{EscapeType: str(obj, "escapeType"), Code: str(obj, "code"), Value: str(obj, "value")}, nil
+	case "characterClass":
+		return decodeCharset(obj, where)
+	case "posixClass":
+		return &ast.POSIXClass{Name: str(obj, "name"), Negated: boolean(obj, "negated")}, nil
+	case "unicodeProperty":
+		return &ast.UnicodePropertyEscape{Property: str(obj, "property"), Negated: boolean(obj, "negated")}, nil
+	case "group":
+		body, err := decodeRegexp(obj["body"], where+".body")
+		if err != nil {
+			return nil, err
+		}
+		groupType, ok := kindToGroupType[str(obj, "kind")]
+		if !ok {
+			groupType = str(obj, "kind")
+		}
+		number, _ := integer(obj, "number")
+		return &ast.Subexp{GroupType: groupType, Number: number, Name: str(obj, "name"), Regexp: body}, nil
+	case "backReference":
+		number, _ := integer(obj, "number")
+		return &ast.BackReference{Number: number, Name: str(obj, "name")}, nil
+	case "conditional":
+		cond, err := decodeNode(obj["condition"], where+".condition")
+		if err != nil {
+			return nil, err
+		}
+		yes, err := decodeRegexp(obj["ifTrue"], where+".ifTrue")
+		if err != nil {
+			return nil, err
+		}
+		no, err := decodeRegexp(obj["ifFalse"], where+".ifFalse")
+		if err != nil {
+			return nil, err
+		}
+		return &ast.Conditional{Condition: cond, TrueMatch: yes, FalseMatch: no}, nil
+	case "recursiveReference":
+		return &ast.RecursiveRef{Target: str(obj, "target")}, nil
+	case "comment":
+		return &ast.Comment{Text: str(obj, "text")}, nil
+	case "quotedLiteral":
+		return &ast.QuotedLiteral{Text: str(obj, "text")}, nil
+	case "inlineModifier":
+		body, err := decodeRegexp(obj["body"], where+".body")
+		if err != nil {
+			return nil, err
+		}
+		return &ast.InlineModifier{Enable: str(obj, "enable"), Disable: str(obj, "disable"), Regexp: body}, nil
+	case "branchReset":
+		body, err := decodeRegexp(obj["body"], where+".body")
+		if err != nil {
+			return nil, err
+		}
+		return &ast.BranchReset{Regexp: body}, nil
+	case "balancedGroup":
+		body, err := decodeRegexp(obj["body"], where+".body")
+		if err != nil {
+			return nil, err
+		}
+		return &ast.BalancedGroup{Name: str(obj, "name"), OtherName: str(obj, "otherName"), Regexp: body}, nil
+	case "backtrackControl":
+		return &ast.BacktrackControl{Verb: str(obj, "verb"), Arg: str(obj, "arg")}, nil
+	case "patternOption":
+		return &ast.PatternOption{Name: str(obj, "name"), Value: str(obj, "value")}, nil
+	case "callout":
+		number, ok := integer(obj, "number")
+		if !ok {
+			number = -1
+		}
+		return &ast.Callout{Number: number, Text: str(obj, "text")}, nil
+	case "sequence", "alternation":
+		// A bare sequence or alternation where a node is expected is a
+		// group the writer left implicit.
+		body, err := decodeRegexp(obj, where)
+		if err != nil {
+			return nil, err
+		}
+		return &ast.Subexp{GroupType: ast.GroupNonCapture, Regexp: body}, nil
+	default:
+		return nil, fmt.Errorf("%s: unknown node type %q", where, typ)
+	}
+}
+
+func decodeCharset(obj map[string]any, where string) (*ast.Charset, error) {
+	members, err := array(obj, "members", where)
+	if err != nil {
+		return nil, err
+	}
+	c := &ast.Charset{Inverted: boolean(obj, "negated")}
+	for i, m := range members {
+		item, err := decodeCharsetItem(m, fmt.Sprintf("%s.members[%d]", where, i))
+		if err != nil {
+			return nil, err
+		}
+		switch item.(type) {
+		case *ast.CharsetIntersection, *ast.CharsetSubtraction:
+			c.SetExpression = item
+		default:
+			ci, ok := item.(ast.CharsetItem)
+			if !ok {
+				return nil, fmt.Errorf("%s.members[%d]: %s cannot appear in a character class", where, i, item.Type())
+			}
+			c.Items = append(c.Items, ci)
+		}
+	}
+	return c, nil
+}
+
+func decodeCharsetItem(v any, where string) (ast.Node, error) {
+	obj, err := object(v, where)
+	if err != nil {
+		return nil, err
+	}
+	switch str(obj, "type") {
+	case "literal":
+		return &ast.CharsetLiteral{Text: str(obj, "value")}, nil
+	case "range":
+		return &ast.CharsetRange{First: str(obj, "from"), Last: str(obj, "to")}, nil
+	case "stringDisjunction":
+		arr, err := array(obj, "strings", where)
+		if err != nil {
+			return nil, err
+		}
+		sd := &ast.CharsetStringDisjunction{}
+		for _, s := range arr {
+			text, _ := s.(string)
+			sd.Strings = append(sd.Strings, text)
+		}
+		return sd, nil
+	case "intersection", "subtraction":
+		ops, err := array(obj, "operands", where)
+		if err != nil {
+			return nil, err
+		}
+		var operands []ast.Node
+		for i, op := range ops {
+			n, err := decodeCharsetItem(op, fmt.Sprintf("%s.operands[%d]", where, i))
+			if err != nil {
+				return nil, err
+			}
+			operands = append(operands, n)
+		}
+		if str(obj, "type") == "intersection" {
+			return &ast.CharsetIntersection{Operands: operands}, nil
+		}
+		return &ast.CharsetSubtraction{Operands: operands}, nil
+	}
+	return decodeNode(obj, where)
+}
@@ -0,0 +1,278 @@
+// Package incremental re-parses a pattern after an edit by reusing the
+// parts of the previous AST the edit did not touch.
+//
+// Editor integrations re-render on every keystroke, and for long
+// patterns a full PEG parse per keystroke dominates latency. The
+// strategy here is deliberately conservative: the only structural
+// boundary it splits on is top-level alternation. An edit that stays
+// inside one top-level branch re-parses just that branch and splices
+// it into a fresh Regexp alongside the untouched branches. Anything it
+// cannot prove equivalent to a full parse falls back to one, so the
+// result is always what flavor.Parse would have returned.
+//
+// Splicing is refused (and a full parse done instead) when:
+//   - the flavor has no tokenizer, so branch boundaries are unknown
+//   - the pattern is slash-delimited or starts with pattern options
+//   - a top-level inline modifier such as (?x) could change how later
+//     branches lex
+//   - named groups or branch-reset groups are present, since some
+//     flavors number those out of source order
+//   - the edit adds or removes top-level branches, or changes the
+//     number of capture groups in the edited branch
+package incremental
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+	"github.com/0x4d5352/regolith/internal/flavor"
+)
+
+// Edit describes a change to the pattern text in the style of an
+// editor content-change event: Removed bytes starting at byte Offset
+// are replaced by Inserted.
+type Edit struct {
+	Offset   int
+	Removed  int
+	Inserted string
+}
+
+// branch is the cached parse of one top-level alternative.
+type branch struct {
+	text     string
+	sep      int // Length of the separator after the branch: 1 for |, 2 for \|
+	match    *ast.Match
+	captures int // Capture groups inside this branch
+}
+
+// Document holds a pattern and its most recent parse. It is not safe
+// for concurrent use.
+//
+// ASTs returned by a Document share unchanged *ast.Match values with
+// earlier results, so callers must treat them as read-only. The shared
+// nodes' positions are those of the latest pattern.
+type Document struct {
+	flavor   flavor.Flavor
+	pattern  string
+	ast      *ast.Regexp
+	branches []branch // nil when the current pattern cannot be spliced
+	spliced  bool
+}
+
+// New parses pattern with f and returns a Document ready for edits.
+func New(f flavor.Flavor, pattern string) (*Document, error) {
+	d := &Document{flavor: f}
+	if err := d.fullParse(pattern); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Pattern returns the current pattern text.
+func (d *Document) Pattern() string { return d.pattern }
+
+// AST returns the AST for the current pattern.
+func (d *Document) AST() *ast.Regexp { return d.ast }
+
+// Spliced reports whether the most recent Apply re-parsed a single
+// branch rather than the whole pattern.
+func (d *Document) Spliced() bool { return d.spliced }
+
+// Apply applies e to the pattern and returns the updated AST. On a
+// parse error the Document keeps its previous pattern and AST, so an
+// editor can keep showing the last good diagram while the user types.
+func (d *Document) Apply(e Edit) (*ast.Regexp, error) {
+	if e.Offset < 0 || e.Removed < 0 || e.Offset+e.Removed > len(d.pattern) {
+		return nil, fmt.Errorf("edit [%d,+%d) out of range for pattern of length %d",
+			e.Offset, e.Removed, len(d.pattern))
+	}
+	next := d.pattern[:e.Offset] + e.Inserted + d.pattern[e.Offset+e.Removed:]
+
+	if root, ok := d.trySplice(next, e); ok {
+		return root, nil
+	}
+	if err := d.fullParse(next); err != nil {
+		return nil, err
+	}
+	return d.ast, nil
+}
+
+// fullParse parses pattern from scratch and rebuilds the branch cache.
+func (d *Document) fullParse(pattern string) error {
+	root, err := d.flavor.Parse(pattern)
+	if err != nil {
+		return err
+	}
+	d.pattern = pattern
+	d.ast = root
+	d.spliced = false
+	d.branches = nil
+
+	texts, seps, ok := splitBranches(d.flavor, pattern)
+	if !ok || len(root.Options) > 0 || len(texts) != len(root.Matches) || !numberedInOrder(root) {
+		return nil
+	}
+	d.branches = make([]branch, len(texts))
+	for i, text := range texts {
+		d.branches[i] = branch{
+			text:     text,
+			sep:      seps[i],
+			match:    root.Matches[i],
+			captures: countCaptures(root.Matches[i]),
+		}
+	}
+	return nil
+}
+
+// trySplice re-parses only the branch containing e. ok is false when
+// the edit cannot be applied incrementally; the Document is untouched
+// in that case.
+func (d *Document) trySplice(next string, e Edit) (*ast.Regexp, bool) {
+	if d.branches == nil {
+		return nil, false
+	}
+	texts, _, ok := splitBranches(d.flavor, next)
+	if !ok || len(texts) != len(d.branches) {
+		return nil, false
+	}
+
+	// Locate the single old branch that contains the whole edit. An
+	// insertion just before a '|' belongs to the branch it extends.
+	idx, start := -1, 0
+	for i, b := range d.branches {
+		end := start + len(b.text)
+		if e.Offset >= start && e.Offset+e.Removed <= end {
+			idx = i
+			break
+		}
+		start = end + b.sep
+	}
+	if idx < 0 {
+		return nil, false
+	}
+	for i, text := range texts {
+		if i != idx && text != d.branches[i].text {
+			return nil, false
+		}
+	}
+
+	sub, err := d.flavor.Parse(texts[idx])
+	if err != nil || len(sub.Matches) != 1 || len(sub.Options) > 0 || sub.Flags != "" || !numberedInOrder(sub) {
+		return nil, false
+	}
+	m := sub.Matches[0]
+	captures := countCaptures(m)
+	if captures != d.branches[idx].captures {
+		return nil, false
+	}
+
+	// The branch was numbered as if it were the whole pattern; shift
+	// its groups past the captures of the branches before it.
+	offset := 0
+	for _, b := range d.branches[:idx] {
+		offset += b.captures
+	}
+	renumber(m, offset)
+
+	matches := make([]*ast.Match, len(d.branches))
+	for i, b := range d.branches {
+		matches[i] = b.match
+	}
+	matches[idx] = m
+
+	d.pattern = next
+	d.ast = &ast.Regexp{Matches: matches, Flags: d.ast.Flags}
+	// The branches were positioned against the texts they were parsed
+	// from; position the whole tree against next. This tokenizes the
+	// pattern once, which is cheap next to a full parse.
+	flavor.Locate(d.flavor, next, d.ast)
+	d.branches[idx].text = texts[idx]
+	d.branches[idx].match = m
+	d.spliced = true
+	return d.ast, true
+}
+
+// inlineModifier matches a bare top-level modifier group such as (?i)
+// or (?x-s), whose effect runs on into the following branches.
+var inlineModifier = regexp.MustCompile(`^\(\?[\^a-zA-Z-]+\)$`)
+
+// splitBranches splits pattern at its top-level alternation operators
+// using the flavor's tokenizer, returning each branch's text and the
+// length of the separator that follows it. ok is false when the
+// boundaries cannot be trusted for splicing.
+func splitBranches(f flavor.Flavor, pattern string) (texts []string, seps []int, ok bool) {
+	if _, ok := f.(flavor.Tokenizer); !ok {
+		return nil, nil, false
+	}
+	depth, start := 0, 0
+	for _, tok := range flavor.Tokens(f, pattern) {
+		switch tok.Kind {
+		case flavor.TokenDelimiter:
+			return nil, nil, false
+		case flavor.TokenGroup:
+			if depth == 0 && inlineModifier.MatchString(tok.Text) {
+				return nil, nil, false
+			}
+			// Conditionals lex as "(?(cond)" and verbs as "(*SKIP)", so
+			// count parentheses rather than classifying prefixes.
+			depth += strings.Count(tok.Text, "(") - strings.Count(tok.Text, ")")
+			if depth < 0 {
+				return nil, nil, false
+			}
+		case flavor.TokenAlternation:
+			if depth == 0 {
+				texts = append(texts, pattern[start:tok.Offset])
+				seps = append(seps, len(tok.Text))
+				start = tok.Offset + len(tok.Text)
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, nil, false
+	}
+	return append(texts, pattern[start:]), append(seps, 0), true
+}
+
+// numberedInOrder reports whether every capture group in root is
+// numbered by its position in the source, which is what renumber
+// assumes. Named groups (.NET numbers them after unnamed ones) and
+// branch resets (which reuse numbers) break that.
+func numberedInOrder(root *ast.Regexp) bool {
+	ok := true
+	ast.Walk(root, func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.Subexp:
+			if n.Name != "" {
+				ok = false
+			}
+		case *ast.BranchReset, *ast.BalancedGroup:
+			ok = false
+		}
+	})
+	return ok
+}
+
+// countCaptures returns the number of numbered capture groups in m.
+func countCaptures(m *ast.Match) int {
+	n := 0
+	ast.Walk(m, func(node ast.Node) {
+		if s, ok := node.(*ast.Subexp); ok && s.Number > 0 {
+			n++
+		}
+	})
+	return n
+}
+
+// renumber shifts every capture group number in m by offset.
+func renumber(m *ast.Match, offset int) {
+	if offset == 0 {
+		return
+	}
+	ast.Walk(m, func(node ast.Node) {
+		if s, ok := node.(*ast.Subexp); ok && s.Number > 0 {
+			s.Number += offset
+		}
+	})
+}
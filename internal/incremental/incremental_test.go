@@ -0,0 +1,107 @@
+package incremental
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/0x4d5352/regolith/internal/flavor"
+	_ "github.com/0x4d5352/regolith/internal/flavor/dotnet"
+	_ "github.com/0x4d5352/regolith/internal/flavor/gnugrep_bre"
+	_ "github.com/0x4d5352/regolith/internal/flavor/javascript"
+	_ "github.com/0x4d5352/regolith/internal/flavor/pcre"
+)
+
+func mustFlavor(t *testing.T, name string) flavor.Flavor {
+	t.Helper()
+	f, ok := flavor.Get(name)
+	if !ok {
+		t.Fatalf("flavor %q not registered", name)
+	}
+	return f
+}
+
+// TestApplyMatchesFullParse is the core contract: whatever path Apply
+// takes, the AST equals a from-scratch parse of the edited pattern.
+func TestApplyMatchesFullParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		flavor      string
+		pattern     string
+		edit        Edit
+		wantSpliced bool
+	}{
+		{"insert in middle branch", "javascript", "foo|(b)ar|(baz)", Edit{Offset: 6, Inserted: "x"}, true},
+		{"renumbers later branch groups", "javascript", "(a)|(b)c|(d)", Edit{Offset: 7, Removed: 1, Inserted: "[cd]+"}, true},
+		{"append to last branch", "pcre", "a|b", Edit{Offset: 3, Inserted: `\d+`}, true},
+		{"gnu bre alternation", "gnugrep", `a\|b\|c`, Edit{Offset: 4, Inserted: "x"}, true},
+		{"gnu bre last branch", "gnugrep", `a\|b\|c`, Edit{Offset: 7, Inserted: "d"}, true},
+		{"new top-level branch", "javascript", "a|b", Edit{Offset: 3, Inserted: "|c"}, false},
+		{"removes separator", "javascript", "a|b|c", Edit{Offset: 1, Removed: 1}, false},
+		{"adds capture group", "javascript", "a|b|(c)", Edit{Offset: 2, Removed: 1, Inserted: "(b)"}, false},
+		{"edit spans branches", "javascript", "ab|cd", Edit{Offset: 1, Removed: 3, Inserted: "x|y"}, false},
+		{"inline modifier", "pcre", "(?x)a b|c", Edit{Offset: 8, Inserted: " d"}, false},
+		{"named groups", "dotnet", "(?<n>a)|(b)", Edit{Offset: 9, Inserted: "c"}, false},
+		{"slash delimited", "javascript", "/a|b/i", Edit{Offset: 4, Inserted: "c"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := mustFlavor(t, tt.flavor)
+			doc, err := New(f, tt.pattern)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			got, err := doc.Apply(tt.edit)
+			if err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			if doc.Spliced() != tt.wantSpliced {
+				t.Errorf("Spliced() = %v, want %v", doc.Spliced(), tt.wantSpliced)
+			}
+			want, err := f.Parse(doc.Pattern())
+			if err != nil {
+				t.Fatalf("full parse of %q: %v", doc.Pattern(), err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("AST for %q differs from full parse", doc.Pattern())
+			}
+		})
+	}
+}
+
+func TestApplySharesUntouchedBranches(t *testing.T) {
+	doc, err := New(mustFlavor(t, "javascript"), "one|two|three")
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := doc.AST()
+	after, err := doc.Apply(Edit{Offset: 4, Removed: 3, Inserted: "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after == before {
+		t.Fatal("expected a fresh root")
+	}
+	if after.Matches[0] != before.Matches[0] || after.Matches[2] != before.Matches[2] {
+		t.Error("expected untouched branches to be reused")
+	}
+	if after.Matches[1] == before.Matches[1] {
+		t.Error("expected edited branch to be re-parsed")
+	}
+}
+
+func TestApplyErrorKeepsLastGood(t *testing.T) {
+	doc, err := New(mustFlavor(t, "javascript"), "a|b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	good := doc.AST()
+	if _, err := doc.Apply(Edit{Offset: 3, Inserted: "("}); err == nil {
+		t.Fatal("expected parse error for unbalanced group")
+	}
+	if doc.Pattern() != "a|b" || doc.AST() != good {
+		t.Error("expected failed edit to leave the document unchanged")
+	}
+	if _, err := doc.Apply(Edit{Offset: 10, Inserted: "x"}); err == nil {
+		t.Error("expected out-of-range edit to fail")
+	}
+}
@@ -0,0 +1,167 @@
+// Package manifest reads pattern manifests: JSON or YAML files listing
+// patterns to render, each with its own flavor, flags, theme, title,
+// output file and annotations. A command line applies one set of
+// options to every pattern; a manifest lets one documentation build
+// mix flavors and styles.
+//
+// A manifest is either a list of entries or an object with an optional
+// "defaults" entry, whose fields fill in those an entry leaves empty,
+// and a "patterns" list:
+//
+//	defaults:
+//	  flavor: pcre
+//	  theme: dark
+//	patterns:
+//	  - title: ISO date
+//	    pattern: '^\d{4}-\d{2}-\d{2}$'
+//	    output: date.svg
+//	    annotations: [Dashes are required]
+//	  - pattern: '/^[a-z]+$/i'
+//	    flavor: javascript
+//
+// Only the YAML needed for such files is understood (see yaml.go), so
+// the binary does not carry a YAML library.
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Entry is one pattern in a manifest. Every field but Pattern is
+// optional.
+type Entry struct {
+	Pattern     string   `json:"pattern"`
+	Flavor      string   `json:"flavor,omitempty"`
+	Flags       string   `json:"flags,omitempty"` // Flag letters, e.g. "im", as the flags panel lists them
+	Theme       string   `json:"theme,omitempty"`
+	Title       string   `json:"title,omitempty"`
+	Output      string   `json:"output,omitempty"` // Relative to the manifest's directory
+	Annotations []string `json:"annotations,omitempty"`
+}
+
+// Manifest is a decoded manifest file, with Defaults already applied to
+// each entry.
+type Manifest struct {
+	Defaults Entry   `json:"defaults"`
+	Patterns []Entry `json:"patterns"`
+}
+
+// Parse decodes a manifest. name is used to choose the syntax — .json
+// is JSON, .yaml and .yml are YAML, anything else is JSON when it
+// starts with { or [ — and to prefix error messages.
+func Parse(name string, data []byte) (*Manifest, error) {
+	doc, err := toJSON(name, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Manifest
+	trimmed := bytes.TrimSpace(doc)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		err = decodeStrict(trimmed, &m.Patterns)
+	} else {
+		err = decodeStrict(trimmed, &m)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	if m.Defaults.Pattern != "" || m.Defaults.Output != "" {
+		return nil, fmt.Errorf("%s: defaults cannot set pattern or output", name)
+	}
+	if len(m.Patterns) == 0 {
+		return nil, fmt.Errorf("%s: no patterns", name)
+	}
+	for i := range m.Patterns {
+		e := &m.Patterns[i]
+		if e.Pattern == "" {
+			return nil, fmt.Errorf("%s: pattern %d has no pattern", name, i+1)
+		}
+		e.applyDefaults(m.Defaults)
+	}
+	return &m, nil
+}
+
+// Decode reads another configuration file, such as a lint config, by
+// the same rules as a manifest: JSON or YAML as name and content say,
+// with unknown fields rejected.
+func Decode(name string, data []byte, v any) error {
+	doc, err := toJSON(name, data)
+	if err != nil {
+		return err
+	}
+	if err := decodeStrict(bytes.TrimSpace(doc), v); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// OutputPath returns where an entry's output goes: Output resolved
+// against the directory of the manifest at manifestPath, or "" when
+// the entry names no output.
+func (e *Entry) OutputPath(manifestPath string) string {
+	if e.Output == "" || filepath.IsAbs(e.Output) {
+		return e.Output
+	}
+	return filepath.Join(filepath.Dir(manifestPath), filepath.FromSlash(e.Output))
+}
+
+func (e *Entry) applyDefaults(d Entry) {
+	if e.Flavor == "" {
+		e.Flavor = d.Flavor
+	}
+	if e.Flags == "" {
+		e.Flags = d.Flags
+	}
+	if e.Theme == "" {
+		e.Theme = d.Theme
+	}
+	if e.Title == "" {
+		e.Title = d.Title
+	}
+	if len(e.Annotations) == 0 {
+		e.Annotations = d.Annotations
+	}
+}
+
+// toJSON returns data as JSON, converting it first when name or its
+// content says it is YAML.
+func toJSON(name string, data []byte) ([]byte, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		return data, nil
+	case ".yaml", ".yml":
+	default:
+		if t := bytes.TrimSpace(data); len(t) > 0 && (t[0] == '{' || t[0] == '[') {
+			return data, nil
+		}
+	}
+	v, err := parseYAML(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", name, err)
+	}
+	return json.Marshal(v)
+}
+
+// decodeStrict decodes JSON into v, rejecting unknown fields so a
+// misspelled option fails rather than being silently ignored.
+func decodeStrict(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return fmt.Errorf("%s: want a %s, got %s", typeErr.Field, typeErr.Type, typeErr.Value)
+		}
+		return err
+	}
+	if dec.More() {
+		return errors.New("unexpected data after the manifest")
+	}
+	return nil
+}
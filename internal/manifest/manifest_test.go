@@ -0,0 +1,126 @@
+package manifest
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	src := `# Documentation build
+---
+defaults:
+  flavor: pcre
+  theme: dark
+patterns:
+  - title: ISO date
+    pattern: '^\d{4}-\d{2}-\d{2}$'   # quoted, so the backslashes stay
+    output: svg/date.svg
+    annotations: [Dashes are required, 'Years, not centuries']
+  - pattern: "/^[a-z]+$/"
+    flavor: javascript
+    flags: i
+    annotations:
+    - First note
+    - |
+      Second note,
+      on two lines
+  -
+    pattern: a#b
+    theme: ~
+`
+	m, err := Parse("docs.yaml", []byte(src))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Entry{
+		{
+			Title: "ISO date", Pattern: `^\d{4}-\d{2}-\d{2}$`, Flavor: "pcre", Theme: "dark",
+			Output: "svg/date.svg", Annotations: []string{"Dashes are required", "Years, not centuries"},
+		},
+		{
+			Pattern: "/^[a-z]+$/", Flavor: "javascript", Flags: "i", Theme: "dark",
+			Annotations: []string{"First note", "Second note,\non two lines\n"},
+		},
+		{Pattern: "a#b", Flavor: "pcre", Theme: "dark"},
+	}
+	if !reflect.DeepEqual(m.Patterns, want) {
+		t.Errorf("patterns:\n got %#v\nwant %#v", m.Patterns, want)
+	}
+}
+
+func TestParseJSONList(t *testing.T) {
+	m, err := Parse("list.json", []byte(`[{"pattern": "a+", "flavor": "posix"}, {"pattern": "b"}]`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(m.Patterns) != 2 || m.Patterns[0].Flavor != "posix" || m.Patterns[1].Pattern != "b" {
+		t.Errorf("unexpected patterns: %#v", m.Patterns)
+	}
+}
+
+func TestParseSniffsJSON(t *testing.T) {
+	// Without a telling extension, content starting with { is JSON.
+	m, err := Parse("-", []byte(`{"patterns": [{"pattern": "x"}]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.Patterns[0].Pattern != "x" {
+		t.Errorf("unexpected patterns: %#v", m.Patterns)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{"m.json", `{"patterns": [{"pattern": "a", "colour": "red"}]}`, `unknown field "colour"`},
+		{"m.json", `{"patterns": [{"pattern": "a", "annotations": "one"}]}`, "annotations: want a []string"},
+		{"m.json", `{"patterns": []}`, "no patterns"},
+		{"m.json", `[{"flavor": "pcre"}]`, "pattern 1 has no pattern"},
+		{"m.json", `{"defaults": {"output": "x.svg"}, "patterns": [{"pattern": "a"}]}`, "defaults cannot set pattern or output"},
+		{"m.yaml", "patterns:\n  - pattern: a\n     flavor: pcre\n", "m.yaml:3: unexpected indentation"},
+		{"m.yaml", "patterns:\n\t- pattern: a\n", "m.yaml:2: tabs cannot indent"},
+		{"m.yaml", "defaults: &d\n  flavor: pcre\n", "m.yaml:1: anchors, aliases and tags"},
+		{"m.yaml", "patterns:\n  - pattern: 'a\n", "m.yaml:2: unterminated single-quoted string"},
+		{"m.yaml", "patterns:\n  - pattern: a\n    pattern: b\n", `m.yaml:3: duplicate key "pattern"`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.want, func(t *testing.T) {
+			_, err := Parse(tc.name, []byte(tc.src))
+			if err == nil {
+				t.Fatalf("expected an error for %q", tc.src)
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("expected error to contain %q, got: %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestOutputPath(t *testing.T) {
+	e := Entry{Output: "svg/a.svg"}
+	if got, want := e.OutputPath(filepath.Join("docs", "regex.yaml")), filepath.Join("docs", "svg", "a.svg"); got != want {
+		t.Errorf("OutputPath = %q, want %q", got, want)
+	}
+	if got := (&Entry{}).OutputPath("regex.yaml"); got != "" {
+		t.Errorf("OutputPath with no output = %q, want empty", got)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	var cfg struct {
+		Flavors []string `json:"flavors"`
+	}
+	if err := Decode("lint.yaml", []byte("flavors: [javascript, pcre]\n"), &cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(cfg.Flavors, ",") != "javascript,pcre" {
+		t.Errorf("unexpected flavors: %v", cfg.Flavors)
+	}
+	err := Decode("lint.json", []byte(`{"flavours": ["pcre"]}`), &cfg)
+	if err == nil || !strings.Contains(err.Error(), `lint.json: json: unknown field "flavours"`) {
+		t.Errorf("expected an unknown field error, got: %v", err)
+	}
+}
@@ -0,0 +1,369 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// A YAML subset, enough for manifests:
+//
+//   - block mappings and block sequences, nested by indentation
+//   - plain, 'single-quoted' and "double-quoted" scalars
+//   - flow sequences of scalars: [a, 'b', "c"]
+//   - literal block scalars (| and |-) for multi-line text
+//   - # comments and a leading --- document marker
+//
+// Anchors, aliases, tags, flow mappings, folded and multi-line plain
+// scalars are rejected with the line they appear on rather than being
+// misread. Every scalar decodes as a string; null and ~ as nil.
+
+// yamlLine is one meaningful line: its indentation and its text with
+// the indentation and any trailing comment removed.
+type yamlLine struct {
+	num    int // 1-based line number
+	indent int
+	text   string
+	raw    string // The whole line, for block scalars
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+// yamlError is a parse error on a line; it formats as "N: message" so
+// callers can prefix the file name.
+type yamlError struct {
+	line int
+	msg  string
+}
+
+func (e *yamlError) Error() string { return fmt.Sprintf("%d: %s", e.line, e.msg) }
+
+func parseYAML(data []byte) (any, error) {
+	p := &yamlParser{}
+	for i, raw := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimLeft(raw, " ")
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, &yamlError{i + 1, "tabs cannot indent YAML; use spaces"}
+		}
+		text := stripComment(trimmed)
+		if text == "" && len(p.lines) == 0 {
+			continue
+		}
+		if len(p.lines) == 0 && (text == "---" || strings.HasPrefix(text, "%")) {
+			continue
+		}
+		if text == "..." {
+			break
+		}
+		p.lines = append(p.lines, yamlLine{num: i + 1, indent: len(raw) - len(trimmed), text: text, raw: raw})
+	}
+	p.skipBlank()
+	if p.pos == len(p.lines) {
+		return nil, nil
+	}
+	v, err := p.node(p.lines[p.pos].indent)
+	if err != nil {
+		return nil, err
+	}
+	p.skipBlank()
+	if p.pos < len(p.lines) {
+		l := p.lines[p.pos]
+		return nil, &yamlError{l.num, "unexpected indentation"}
+	}
+	return v, nil
+}
+
+// skipBlank moves past lines with nothing but whitespace or a comment.
+// They are kept in lines only so block scalars can contain them.
+func (p *yamlParser) skipBlank() {
+	for p.pos < len(p.lines) && p.lines[p.pos].text == "" {
+		p.pos++
+	}
+}
+
+// node parses the block node starting at the current line, which is
+// indented by indent.
+func (p *yamlParser) node(indent int) (any, error) {
+	l := p.lines[p.pos]
+	if isSeqItem(l.text) {
+		return p.sequence(indent)
+	}
+	if _, _, ok := splitKey(l.text); ok {
+		return p.mapping(indent)
+	}
+	p.pos++
+	return scalar(l.text, l.num)
+}
+
+func (p *yamlParser) sequence(indent int) (any, error) {
+	items := []any{}
+	for {
+		p.skipBlank()
+		if p.pos == len(p.lines) {
+			return items, nil
+		}
+		l := &p.lines[p.pos]
+		if l.indent != indent || !isSeqItem(l.text) {
+			if l.indent > indent {
+				return nil, &yamlError{l.num, "unexpected indentation"}
+			}
+			return items, nil
+		}
+		rest := strings.TrimLeft(strings.TrimPrefix(l.text, "-"), " ")
+		if rest == "" {
+			// The item is the block on the following lines.
+			p.pos++
+			v, err := p.child(indent, false)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+			continue
+		}
+		if rest == "|" || rest == "|-" {
+			p.pos++
+			items = append(items, p.blockScalar(indent, rest == "|"))
+			continue
+		}
+		// "- key: value" or "- - x": the rest of the line opens a node
+		// indented to where it starts.
+		at := indent + len(l.text) - len(rest)
+		l.indent, l.text = at, rest
+		v, err := p.node(at)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, v)
+	}
+}
+
+func (p *yamlParser) mapping(indent int) (any, error) {
+	m := map[string]any{}
+	for {
+		p.skipBlank()
+		if p.pos == len(p.lines) {
+			return m, nil
+		}
+		l := p.lines[p.pos]
+		if l.indent != indent {
+			if l.indent > indent {
+				return nil, &yamlError{l.num, "unexpected indentation"}
+			}
+			return m, nil
+		}
+		rawKey, value, ok := splitKey(l.text)
+		if !ok {
+			if isSeqItem(l.text) {
+				return m, nil
+			}
+			return nil, &yamlError{l.num, fmt.Sprintf("expected key: value, got %q", l.text)}
+		}
+		key, err := scalar(rawKey, l.num)
+		if err != nil {
+			return nil, err
+		}
+		name, _ := key.(string)
+		if _, dup := m[name]; dup {
+			return nil, &yamlError{l.num, fmt.Sprintf("duplicate key %q", name)}
+		}
+		p.pos++
+		var v any
+		switch value {
+		case "":
+			v, err = p.child(indent, true)
+		case "|", "|-":
+			v = p.blockScalar(indent, value == "|")
+		default:
+			v, err = scalar(value, l.num)
+		}
+		if err != nil {
+			return nil, err
+		}
+		m[name] = v
+	}
+}
+
+// child parses the node nested under a key or an empty sequence item
+// at indent. Under a key, a sequence may sit at the key's own
+// indentation.
+func (p *yamlParser) child(indent int, underKey bool) (any, error) {
+	p.skipBlank()
+	if p.pos == len(p.lines) {
+		return nil, nil
+	}
+	l := p.lines[p.pos]
+	if l.indent > indent || (underKey && l.indent == indent && isSeqItem(l.text)) {
+		return p.node(l.indent)
+	}
+	return nil, nil
+}
+
+// blockScalar reads the lines of a | block scalar indented past
+// indent, keeping a final newline unless the block was |-.
+func (p *yamlParser) blockScalar(indent int, keepNewline bool) string {
+	var lines []string
+	blockIndent := -1
+	for p.pos < len(p.lines) {
+		l := p.lines[p.pos]
+		if strings.TrimSpace(l.raw) != "" {
+			if l.indent <= indent {
+				break
+			}
+			if blockIndent < 0 {
+				blockIndent = l.indent
+			}
+		}
+		line := ""
+		if len(l.raw) > blockIndent && blockIndent >= 0 {
+			line = l.raw[blockIndent:]
+		}
+		lines = append(lines, line)
+		p.pos++
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	s := strings.Join(lines, "\n")
+	if keepNewline && s != "" {
+		s += "\n"
+	}
+	return s
+}
+
+func isSeqItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// splitKey splits "key: value" at the first colon outside quotes that
+// is followed by a space or ends the line.
+func splitKey(text string) (key, value string, ok bool) {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case (c == '\'' || c == '"') && i == 0:
+			quote = c
+		case c == ':' && (i+1 == len(text) || text[i+1] == ' '):
+			return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:]), i > 0
+		}
+	}
+	return "", "", false
+}
+
+// stripComment removes a # comment: one at the start of the text or
+// after whitespace, outside quotes.
+func stripComment(text string) string {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			if i == 0 || strings.ContainsRune(" [,:-", rune(text[i-1])) {
+				quote = c
+			}
+		case c == '#' && (i == 0 || text[i-1] == ' '):
+			return strings.TrimRight(text[:i], " ")
+		}
+	}
+	return strings.TrimRight(text, " ")
+}
+
+// scalar decodes a single-line scalar or flow sequence.
+func scalar(text string, line int) (any, error) {
+	switch {
+	case text == "~" || text == "null":
+		return nil, nil
+	case strings.HasPrefix(text, "'"):
+		if len(text) < 2 || !strings.HasSuffix(text, "'") {
+			return nil, &yamlError{line, "unterminated single-quoted string"}
+		}
+		inner := text[1 : len(text)-1]
+		if strings.Count(strings.ReplaceAll(inner, "''", ""), "'") > 0 {
+			return nil, &yamlError{line, "unexpected text after a single-quoted string"}
+		}
+		return strings.ReplaceAll(inner, "''", "'"), nil
+	case strings.HasPrefix(text, `"`):
+		// YAML's double-quoted escapes are a superset of JSON's; the
+		// common ones decode the same.
+		var s string
+		if err := json.Unmarshal([]byte(text), &s); err != nil {
+			return nil, &yamlError{line, fmt.Sprintf("invalid double-quoted string %s", text)}
+		}
+		return s, nil
+	case strings.HasPrefix(text, "["):
+		return flowSequence(text, line)
+	case text == "{}":
+		return map[string]any{}, nil
+	}
+	switch text[0] {
+	case '{':
+		return nil, &yamlError{line, "flow mappings ({...}) are not supported; use an indented block"}
+	case '&', '*', '!':
+		return nil, &yamlError{line, "anchors, aliases and tags are not supported"}
+	case '>':
+		return nil, &yamlError{line, "folded block scalars (>) are not supported; use |"}
+	case '|':
+		return nil, &yamlError{line, "only | and |- block scalars are supported"}
+	}
+	return text, nil
+}
+
+// flowSequence decodes [a, 'b', "c"], whose items must be scalars.
+func flowSequence(text string, line int) (any, error) {
+	if !strings.HasSuffix(text, "]") {
+		return nil, &yamlError{line, "unterminated flow sequence"}
+	}
+	inner := strings.TrimSpace(text[1 : len(text)-1])
+	items := []any{}
+	if inner == "" {
+		return items, nil
+	}
+	var quote byte
+	start := 0
+	for i := 0; i <= len(inner); i++ {
+		if i < len(inner) {
+			c := inner[i]
+			switch {
+			case quote == '"' && c == '\\':
+				i++
+				continue
+			case quote != 0:
+				if c == quote {
+					quote = 0
+				}
+				continue
+			case c == '\'' || c == '"':
+				quote = c
+				continue
+			case c == '[':
+				return nil, &yamlError{line, "nested flow sequences are not supported"}
+			case c != ',':
+				continue
+			}
+		}
+		item := strings.TrimSpace(inner[start:i])
+		if item == "" {
+			return nil, &yamlError{line, "empty item in flow sequence"}
+		}
+		v, err := scalar(item, line)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, v)
+		start = i + 1
+	}
+	return items, nil
+}
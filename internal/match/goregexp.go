@@ -0,0 +1,141 @@
+package match
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// GoEngine matches with Go's regexp package in its POSIX
+// leftmost-longest mode, the semantics POSIX and GNU grep specify, or,
+// for the golang flavor, in its default leftmost-first mode. Go's
+// engine is linear-time, so it needs no timeout, but it has no
+// back-references: patterns using them fail to compile.
+type GoEngine struct {
+	// BRE reads the pattern as a basic regular expression, translating
+	// it to the extended syntax Go accepts first.
+	BRE bool
+
+	// Perl compiles with regexp.Compile rather than CompilePOSIX, so
+	// the first alternative that matches wins, as in Go programs.
+	Perl bool
+}
+
+func (e *GoEngine) Name() string { return "regexp" }
+
+func (e *GoEngine) Compile(pattern string) (Matcher, error) {
+	if e.BRE {
+		pattern = breToERE(pattern)
+	}
+	compile := regexp.CompilePOSIX
+	if e.Perl {
+		compile = regexp.Compile
+	}
+	re, err := compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("regexp compile: %w", err)
+	}
+	return &goMatcher{re: re}, nil
+}
+
+type goMatcher struct {
+	re *regexp.Regexp
+}
+
+func (m *goMatcher) FindAll(input string, n int) ([]Match, error) {
+	names := m.re.SubexpNames()
+	var matches []Match
+	for _, loc := range m.re.FindAllStringSubmatchIndex(input, n) {
+		out := Match{Start: loc[0], End: loc[1], Groups: make([]Group, len(names))}
+		for i := range names {
+			g := Group{Number: i, Name: names[i], Start: loc[2*i], End: loc[2*i+1]}
+			if g.Matched() {
+				g.Text = input[g.Start:g.End]
+			}
+			out.Groups[i] = g
+		}
+		matches = append(matches, out)
+	}
+	return matches, nil
+}
+
+// breToERE rewrites a basic regular expression in the extended syntax:
+// \( \) \{ \} and GNU's \| \+ \? lose their backslash, the bare
+// characters they stand for gain one, and * ^ $ are escaped where BRE
+// reads them literally. Bracket expressions are copied unchanged.
+func breToERE(bre string) string {
+	var b strings.Builder
+	// atStart is true where a * is literal and a ^ is an anchor: at the
+	// start of the pattern, a group, or an alternative.
+	atStart := true
+	for i := 0; i < len(bre); i++ {
+		c := bre[i]
+		switch {
+		case c == '\\' && i+1 < len(bre):
+			i++
+			switch n := bre[i]; n {
+			case '(', '|':
+				b.WriteByte(n)
+				atStart = true
+				continue
+			case ')', '{', '}', '+', '?':
+				b.WriteByte(n)
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(n)
+			}
+		case c == '[':
+			end := bracketEnd(bre, i)
+			b.WriteString(bre[i:end])
+			i = end - 1
+		case c == '*' && atStart:
+			b.WriteString(`\*`)
+		case c == '^':
+			if atStart {
+				b.WriteByte('^')
+				continue
+			}
+			b.WriteString(`\^`)
+		case c == '$':
+			rest := bre[i+1:]
+			if rest == "" || strings.HasPrefix(rest, `\)`) || strings.HasPrefix(rest, `\|`) {
+				b.WriteByte('$')
+			} else {
+				b.WriteString(`\$`)
+			}
+		case strings.IndexByte("(){}|+?", c) >= 0:
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+		atStart = false
+	}
+	return b.String()
+}
+
+// bracketEnd returns the index just past the bracket expression opening
+// at bre[start], or len(bre) when it is unclosed. A ] first in the list
+// is literal, and [: :], [. .], and [= =] may contain a ].
+func bracketEnd(bre string, start int) int {
+	i := start + 1
+	if i < len(bre) && bre[i] == '^' {
+		i++
+	}
+	if i < len(bre) && bre[i] == ']' {
+		i++
+	}
+	for i < len(bre) {
+		switch {
+		case bre[i] == ']':
+			return i + 1
+		case bre[i] == '[' && i+1 < len(bre) && strings.IndexByte(":.=", bre[i+1]) >= 0:
+			if end := strings.Index(bre[i+2:], string(bre[i+1])+"]"); end >= 0 {
+				i += 2 + end + 2
+				continue
+			}
+		}
+		i++
+	}
+	return len(bre)
+}
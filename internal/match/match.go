@@ -0,0 +1,101 @@
+// Package match runs patterns against input with a real regex engine, so
+// features that show what a pattern matches report the engine's answer
+// rather than an approximation derived from the AST.
+//
+// Matching stays in-process and pure Go, so only the golang flavor runs
+// on its own engine. Each other flavor family maps to the closest one
+// available: Go's regexp in POSIX leftmost-longest mode for the POSIX
+// and GNU grep flavors, and the backtracking dlclark/regexp2 for
+// JavaScript, Java, .NET, and PCRE. regexp2 implements .NET semantics,
+// so for Java and PCRE it is an approximation: constructs it lacks,
+// such as possessive quantifiers, fail to compile instead of matching
+// differently.
+package match
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultTimeout bounds a single match attempt by a backtracking engine,
+// so catastrophic patterns fail instead of hanging the caller.
+const DefaultTimeout = 2 * time.Second
+
+// Engine compiles patterns written in one flavor family.
+type Engine interface {
+	// Name returns the engine identifier, "regexp" or "regexp2".
+	Name() string
+
+	// Compile prepares pattern for matching. The pattern is written as
+	// the flavor's parser accepts it, including a JavaScript /body/flags
+	// literal.
+	Compile(pattern string) (Matcher, error)
+}
+
+// Matcher is a compiled pattern.
+type Matcher interface {
+	// FindAll returns the successive non-overlapping matches in input,
+	// at most n of them, or all of them when n is negative. It fails
+	// when a match attempt exceeds the engine's timeout.
+	FindAll(input string, n int) ([]Match, error)
+}
+
+// Match is one match of a pattern. Offsets are byte offsets into the
+// input.
+type Match struct {
+	Start, End int
+	// Groups holds every capture group in number order; Groups[0] is
+	// the whole match.
+	Groups []Group
+}
+
+// Group is the text a capture group matched in one Match. A group that
+// took no part in the match has Start and End -1.
+type Group struct {
+	Number int
+	Name   string // "" for an unnamed group
+	Start  int
+	End    int
+	Text   string
+}
+
+// Matched reports whether the group took part in the match.
+func (g Group) Matched() bool { return g.Start >= 0 }
+
+// Named returns the groups of m that have names, keyed by name.
+func (m Match) Named() map[string]Group {
+	named := make(map[string]Group)
+	for _, g := range m.Groups {
+		if g.Name != "" {
+			named[g.Name] = g
+		}
+	}
+	return named
+}
+
+// MatchString reports whether pattern, compiled by m, matches anywhere
+// in input.
+func MatchString(m Matcher, input string) (bool, error) {
+	found, err := m.FindAll(input, 1)
+	return len(found) > 0, err
+}
+
+// For returns the engine that matches patterns of the named flavor.
+func For(flavorName string) (Engine, error) {
+	switch flavorName {
+	case "javascript":
+		return &Regexp2Engine{JavaScript: true}, nil
+	case "java", "pcre":
+		return &Regexp2Engine{}, nil
+	case "dotnet":
+		return &Regexp2Engine{DotNet: true}, nil
+	case "posix-ere", "gnugrep-ere":
+		return &GoEngine{}, nil
+	case "posix-bre", "gnugrep-bre":
+		return &GoEngine{BRE: true}, nil
+	case "golang":
+		return &GoEngine{Perl: true}, nil
+	default:
+		return nil, fmt.Errorf("no match engine for flavor %q", flavorName)
+	}
+}
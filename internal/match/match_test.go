@@ -0,0 +1,137 @@
+package match
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func find(t *testing.T, flavorName, pattern, input string) []Match {
+	t.Helper()
+	eng, err := For(flavorName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := eng.Compile(pattern)
+	if err != nil {
+		t.Fatalf("%s: Compile(%q): %v", flavorName, pattern, err)
+	}
+	found, err := m.FindAll(input, -1)
+	if err != nil {
+		t.Fatalf("%s: FindAll(%q): %v", flavorName, input, err)
+	}
+	return found
+}
+
+// texts returns the whole-match text of each match.
+func texts(input string, found []Match) []string {
+	var out []string
+	for _, m := range found {
+		out = append(out, input[m.Start:m.End])
+	}
+	return out
+}
+
+func TestFindAll(t *testing.T) {
+	tests := []struct {
+		flavor, pattern, input string
+		want                   []string
+	}{
+		{"javascript", `/CAT/gi`, "cat Cat dog", []string{"cat", "Cat"}},
+		{"javascript", `a(?=b)`, "ab ac", []string{"a"}},
+		{"pcre", `(?<=\$)\d+`, "$12 and 34", []string{"12"}},
+		{"java", `a|ab`, "ab", []string{"a"}},
+		{"dotnet", `x*`, "axx", []string{"", "xx", ""}},
+		// POSIX picks the longest alternative where backtrackers take
+		// the first.
+		{"posix-ere", `a|ab`, "ab", []string{"ab"}},
+		{"gnugrep-ere", `[[:digit:]]+`, "a1b22", []string{"1", "22"}},
+		{"posix-bre", `\(ab\)\{2\}`, "ababab", []string{"abab"}},
+		{"posix-bre", `*a+`, "*a+ aa", []string{"*a+"}},
+		{"gnugrep-bre", `ab\+\|x`, "abbb x", []string{"abbb", "x"}},
+		{"gnugrep-bre", `a^b$c`, "a^b$c", []string{"a^b$c"}},
+		{"golang", `a|ab`, "ab", []string{"a"}},
+		{"golang", `(?i)\pL+`, "Go 1.22", []string{"Go"}},
+	}
+	for _, tt := range tests {
+		got := texts(tt.input, find(t, tt.flavor, tt.pattern, tt.input))
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+			t.Errorf("%s %q on %q = %q, want %q", tt.flavor, tt.pattern, tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestGroups(t *testing.T) {
+	for flavorName, pattern := range map[string]string{
+		"pcre":       `(?<year>\d{4})-(\d{2})(x)?`,
+		"javascript": `/(?<year>\d{4})-(\d{2})(x)?/g`,
+		"posix-ere":  `([0-9]{4})-([0-9]{2})(x){0,1}`,
+	} {
+		input := "on 2024-06, é 1999-12"
+		found := find(t, flavorName, pattern, input)
+		if len(found) != 2 {
+			t.Fatalf("%s: got %d matches, want 2", flavorName, len(found))
+		}
+		// Offsets are in bytes, past the two-byte é.
+		m := found[1]
+		if m.Start != 15 || input[m.Start:m.End] != "1999-12" {
+			t.Errorf("%s: match at %d = %q", flavorName, m.Start, input[m.Start:m.End])
+		}
+		year, ok := m.Named()["year"]
+		if flavorName == "posix-ere" {
+			// POSIX has no named groups.
+			year, ok = m.Groups[1], len(m.Named()) == 0
+		}
+		if !ok || year.Text != "1999" || year.Number != 1 {
+			t.Errorf("%s: year group = %+v", flavorName, year)
+		}
+		if m.Groups[2].Text != "12" || m.Groups[2].Name != "" {
+			t.Errorf("%s: group 2 = %+v", flavorName, m.Groups[2])
+		}
+		if m.Groups[3].Matched() {
+			t.Errorf("%s: optional group 3 should not take part: %+v", flavorName, m.Groups[3])
+		}
+	}
+}
+
+func TestDotNetNumbering(t *testing.T) {
+	// .NET numbers named groups after the unnamed ones.
+	m := find(t, "dotnet", `(?<a>x)(y)`, "xy")[0]
+	if m.Groups[1].Text != "y" || m.Groups[2].Name != "a" {
+		t.Errorf("groups = %+v", m.Groups)
+	}
+}
+
+func TestMatchString(t *testing.T) {
+	eng, _ := For("javascript")
+	m, err := eng.Compile(`^\d+$`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for input, want := range map[string]bool{"123": true, "12a": false} {
+		if got, err := MatchString(m, input); got != want || err != nil {
+			t.Errorf("MatchString(%q) = %v, %v; want %v", input, got, err, want)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	if _, err := For("nope"); err == nil {
+		t.Error("expected an error for an unknown flavor")
+	}
+	// Go's regexp has no back-references.
+	eng, _ := For("posix-bre")
+	if _, err := eng.Compile(`\(a\)\1`); err == nil {
+		t.Error("expected a compile error for a BRE back-reference")
+	}
+
+	eng = &Regexp2Engine{Timeout: 10 * time.Millisecond}
+	m, err := eng.Compile(`(a+)+$`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = m.FindAll(strings.Repeat("a", 40)+"!", -1)
+	if err == nil || !strings.Contains(err.Error(), "timeout") {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+}
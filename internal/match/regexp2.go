@@ -0,0 +1,217 @@
+package match
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dlclark/regexp2"
+)
+
+// Regexp2Engine matches with dlclark/regexp2, a port of the .NET
+// backtracking engine, which also stands in for Java and PCRE. Unless
+// DotNet is set, \d, \w, and \s are ASCII-only and groups are numbered
+// left to right whether named or not, as in Perl; .NET numbers named
+// groups after all the unnamed ones.
+type Regexp2Engine struct {
+	// JavaScript selects ECMAScript semantics and accepts a /body/flags
+	// literal, whose i, m, s, and u flags set the matching options.
+	JavaScript bool
+	// DotNet keeps .NET's Unicode shorthands and group numbering.
+	DotNet bool
+	// Timeout bounds each match attempt; zero means DefaultTimeout.
+	Timeout time.Duration
+}
+
+func (e *Regexp2Engine) Name() string { return "regexp2" }
+
+func (e *Regexp2Engine) Compile(pattern string) (Matcher, error) {
+	opts := regexp2.RegexOptions(regexp2.RE2)
+	switch {
+	case e.DotNet:
+		opts = regexp2.None
+	case e.JavaScript:
+		opts = regexp2.ECMAScript
+		if body, flags, ok := splitJSLiteral(pattern); ok {
+			pattern = body
+			for _, f := range flags {
+				switch f {
+				case 'i':
+					opts |= regexp2.IgnoreCase
+				case 'm':
+					opts |= regexp2.Multiline
+				case 's':
+					opts |= regexp2.Singleline
+				case 'u', 'v':
+					opts |= regexp2.Unicode
+				}
+			}
+		}
+	}
+	re, err := regexp2.Compile(pattern, opts)
+	if err != nil {
+		return nil, fmt.Errorf("regexp2 compile: %w", err)
+	}
+	re.MatchTimeout = e.Timeout
+	if re.MatchTimeout == 0 {
+		re.MatchTimeout = DefaultTimeout
+	}
+	m := &regexp2Matcher{re: re}
+	if !e.DotNet {
+		m.order = perlOrder(re, captureNames(pattern))
+	}
+	return m, nil
+}
+
+// splitJSLiteral splits a /body/flags literal. It reports false for a
+// bare pattern.
+func splitJSLiteral(pattern string) (body, flags string, ok bool) {
+	if !strings.HasPrefix(pattern, "/") {
+		return "", "", false
+	}
+	end := strings.LastIndexByte(pattern, '/')
+	if end == 0 {
+		return "", "", false
+	}
+	flags = pattern[end+1:]
+	if strings.Trim(flags, "dgimsuvy") != "" {
+		return "", "", false
+	}
+	return pattern[1:end], flags, true
+}
+
+type regexp2Matcher struct {
+	re *regexp2.Regexp
+	// order maps a regexp2 group number to the number Perl would give
+	// the group; nil keeps regexp2's numbering.
+	order map[int]int
+}
+
+// perlOrder maps each regexp2 group number to its left-to-right
+// number, given the names of pattern's groups in order ("" for unnamed
+// ones). regexp2 numbers the unnamed groups 1, 2, ... first, in order,
+// then the named ones. It returns nil if names does not account for
+// every group, as when an unusual construct fooled captureNames.
+func perlOrder(re *regexp2.Regexp, names []string) map[int]int {
+	nums := re.GetGroupNumbers()
+	if len(nums) != len(names)+1 {
+		return nil
+	}
+	order := map[int]int{0: 0}
+	unnamed := 0
+	for i, name := range names {
+		var num int
+		if name == "" {
+			unnamed++
+			num = unnamed
+		} else {
+			num = re.GroupNumberFromName(name)
+		}
+		if num < 0 {
+			return nil
+		}
+		order[num] = i + 1
+	}
+	if len(order) != len(nums) {
+		return nil
+	}
+	return order
+}
+
+// captureNames lists pattern's capture groups in the order their
+// opening parentheses appear, with "" for an unnamed group.
+func captureNames(pattern string) []string {
+	var names []string
+	inClass := false
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			if strings.HasPrefix(pattern[i:], `\Q`) {
+				end := strings.Index(pattern[i:], `\E`)
+				if end < 0 {
+					return names
+				}
+				i += end + 1
+			} else {
+				i++
+			}
+		case '[':
+			if !inClass {
+				inClass = true
+				if strings.HasPrefix(pattern[i+1:], "^") {
+					i++
+				}
+				if strings.HasPrefix(pattern[i+1:], "]") {
+					i++
+				}
+			}
+		case ']':
+			inClass = false
+		case '(':
+			if inClass {
+				continue
+			}
+			rest := pattern[i+1:]
+			switch {
+			case strings.HasPrefix(rest, "?P<"):
+				names = append(names, groupName(rest[3:], '>'))
+			case strings.HasPrefix(rest, "?<") && !strings.HasPrefix(rest, "?<=") && !strings.HasPrefix(rest, "?<!"):
+				names = append(names, groupName(rest[2:], '>'))
+			case strings.HasPrefix(rest, "?'"):
+				names = append(names, groupName(rest[2:], '\''))
+			case !strings.HasPrefix(rest, "?") && !strings.HasPrefix(rest, "*"):
+				names = append(names, "")
+			}
+		}
+	}
+	return names
+}
+
+// groupName returns s up to the closing delimiter.
+func groupName(s string, closer byte) string {
+	if end := strings.IndexByte(s, closer); end >= 0 {
+		return s[:end]
+	}
+	return s
+}
+
+func (m *regexp2Matcher) FindAll(input string, n int) ([]Match, error) {
+	// regexp2 reports rune offsets; byteOff maps them to byte offsets.
+	byteOff := make([]int, 0, len(input)+1)
+	for i := range input {
+		byteOff = append(byteOff, i)
+	}
+	byteOff = append(byteOff, len(input))
+
+	var matches []Match
+	rm, err := m.re.FindStringMatch(input)
+	for ; err == nil && rm != nil && (n < 0 || len(matches) < n); rm, err = m.re.FindNextMatch(rm) {
+		groups := rm.Groups()
+		out := Match{
+			Start:  byteOff[rm.Index],
+			End:    byteOff[rm.Index+rm.Length],
+			Groups: make([]Group, len(groups)),
+		}
+		for _, g := range groups {
+			num := m.re.GroupNumberFromName(g.Name)
+			grp := Group{Number: num, Start: -1, End: -1}
+			if g.Name != strconv.Itoa(num) {
+				grp.Name = g.Name
+			}
+			if len(g.Captures) > 0 {
+				grp.Start, grp.End = byteOff[g.Index], byteOff[g.Index+g.Length]
+				grp.Text = input[grp.Start:grp.End]
+			}
+			if m.order != nil {
+				grp.Number = m.order[num]
+			}
+			out.Groups[grp.Number] = grp
+		}
+		matches = append(matches, out)
+	}
+	if err != nil {
+		return matches, fmt.Errorf("regexp2: %w", err)
+	}
+	return matches, nil
+}
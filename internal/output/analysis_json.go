@@ -12,6 +12,7 @@ type analysisDocument struct {
 	Pattern   string         `json:"pattern"`
 	Flavor    string         `json:"flavor"`
 	Findings  []findingJSON  `json:"findings"`
+	Metrics   *metricsJSON   `json:"metrics,omitempty"`
 	Benchmark *benchmarkJSON `json:"benchmark,omitempty"`
 }
 
@@ -25,6 +26,7 @@ type findingJSON struct {
 	Title       string       `json:"title"`
 	Description string       `json:"description,omitempty"`
 	Suggestion  string       `json:"suggestion,omitempty"`
+	Branches    []int        `json:"branches,omitempty"`
 	Runtime     *runtimeJSON `json:"runtime,omitempty"`
 }
 
@@ -65,6 +67,7 @@ func RenderAnalysisJSON(report *analyzer.AnalysisReport) (string, error) {
 			Title:       f.Title,
 			Description: f.Description,
 			Suggestion:  f.Suggestion,
+			Branches:    f.Branches,
 		}
 		if f.Runtime != nil {
 			fj.Runtime = convertRuntimeResult(f.Runtime)
@@ -72,6 +75,9 @@ func RenderAnalysisJSON(report *analyzer.AnalysisReport) (string, error) {
 		doc.Findings[i] = fj
 	}
 
+	if report.Metrics != nil {
+		doc.Metrics = convertMetrics(report.Metrics)
+	}
 	if report.BenchmarkSummary != nil {
 		doc.Benchmark = convertBenchmarkSummary(report.BenchmarkSummary)
 	}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -91,6 +92,9 @@ func renderFindings(sb *strings.Builder, findings []*analyzer.Finding, markdown
 				if f.Description != "" {
 					fmt.Fprintf(sb, "  %s\n", f.Description)
 				}
+				if len(f.Branches) > 0 {
+					fmt.Fprintf(sb, "  *Branches:* %s\n", branchList(f.Branches))
+				}
 				if f.Suggestion != "" {
 					fmt.Fprintf(sb, "  *Suggestion:* %s\n", f.Suggestion)
 				}
@@ -101,6 +105,9 @@ func renderFindings(sb *strings.Builder, findings []*analyzer.Finding, markdown
 				if f.Description != "" {
 					fmt.Fprintf(sb, "    %s\n", f.Description)
 				}
+				if len(f.Branches) > 0 {
+					fmt.Fprintf(sb, "    %s\n", co.String("Branches: "+branchList(f.Branches)).Faint().String())
+				}
 				if f.Suggestion != "" {
 					styledSugg := co.String("Suggestion: " + f.Suggestion).Faint().Italic().String()
 					fmt.Fprintf(sb, "    %s\n", styledSugg)
@@ -111,6 +118,16 @@ func renderFindings(sb *strings.Builder, findings []*analyzer.Finding, markdown
 	}
 }
 
+// branchList joins 1-based branch numbers as "2, 3", the numbering the
+// text walk's "Branch N" and --number-alternatives use.
+func branchList(branches []int) string {
+	parts := make([]string, len(branches))
+	for i, n := range branches {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ", ")
+}
+
 // renderBenchmarkText writes the benchmark summary section, including per-corpus
 // timing tables with scaling classification derived via ClassifyScaling.
 func renderBenchmarkText(sb *strings.Builder, summary *analyzer.BenchmarkSummary, markdown bool, co *termenv.Output) {
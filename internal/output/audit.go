@@ -0,0 +1,182 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+
+	"github.com/0x4d5352/regolith/internal/analyzer"
+	"github.com/0x4d5352/regolith/internal/audit"
+)
+
+// auditDocument is the top-level JSON envelope for an audit.Report.
+type auditDocument struct {
+	Summary auditSummaryJSON `json:"summary"`
+	Entries []auditEntryJSON `json:"patterns"`
+}
+
+type auditSummaryJSON struct {
+	Files    int            `json:"files"`
+	Patterns int            `json:"patterns"`
+	Invalid  int            `json:"invalid"`
+	Findings map[string]int `json:"findings"` // Keyed by severity name
+}
+
+// auditEntryJSON is one pattern site. Findings reuse the schema of
+// `regolith analyze --format json`; a site that failed to parse carries
+// the same error object as `--error-format json` instead.
+type auditEntryJSON struct {
+	File       string            `json:"file"`
+	Line       int               `json:"line"`
+	Column     int               `json:"column"`
+	Language   string            `json:"language"`
+	Flavor     string            `json:"flavor"`
+	Pattern    string            `json:"pattern"`
+	Complexity int               `json:"complexity,omitempty"`
+	Error      *ParseErrorInfo   `json:"error,omitempty"`
+	Findings   []findingJSON     `json:"findings"`
+	Diagram    string            `json:"diagram,omitempty"`
+	worst      analyzer.Severity // For the HTML template only
+	hasWorst   bool
+}
+
+// RenderAuditJSON serializes an audit report to pretty-printed JSON.
+func RenderAuditJSON(report *audit.Report) (string, error) {
+	b, err := json.MarshalIndent(buildAuditDocument(report), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("json marshal: %w", err)
+	}
+	return string(b), nil
+}
+
+func buildAuditDocument(report *audit.Report) auditDocument {
+	sum := report.Summary()
+	doc := auditDocument{
+		Summary: auditSummaryJSON{
+			Files:    report.Files,
+			Patterns: sum.Patterns,
+			Invalid:  sum.Invalid,
+			Findings: make(map[string]int),
+		},
+		Entries: make([]auditEntryJSON, len(report.Entries)),
+	}
+	for sev, n := range sum.BySeverity {
+		doc.Summary.Findings[sev.String()] = n
+	}
+	for i, e := range report.Entries {
+		ej := auditEntryJSON{
+			File:       e.File,
+			Line:       e.Line,
+			Column:     e.Column,
+			Language:   e.Language,
+			Flavor:     e.Flavor,
+			Pattern:    e.Pattern,
+			Complexity: e.Complexity,
+			Findings:   []findingJSON{},
+			Diagram:    e.Diagram,
+		}
+		ej.worst, ej.hasWorst = e.Worst()
+		if e.ParseError != nil {
+			info := ParseError(e.ParseError)
+			ej.Error = &info
+		} else {
+			for _, f := range e.Report.Findings {
+				ej.Findings = append(ej.Findings, findingJSON{
+					ID:          f.ID,
+					Category:    string(f.Category),
+					Severity:    f.Severity.String(),
+					Title:       f.Title,
+					Description: f.Description,
+					Suggestion:  f.Suggestion,
+				})
+			}
+		}
+		doc.Entries[i] = ej
+	}
+	return doc
+}
+
+// auditPage is the data behind auditTemplate.
+type auditPage struct {
+	Summary auditSummaryJSON
+	Entries []auditHTMLEntry
+}
+
+type auditHTMLEntry struct {
+	auditEntryJSON
+	Severity string        // Worst severity, or "" when clean
+	SVG      template.HTML // Trusted: produced by our own renderer
+}
+
+// RenderAuditHTML renders an audit report as a single self-contained
+// HTML page: a summary table followed by one section per pattern with
+// its location, findings, and inline diagram when the report has them.
+func RenderAuditHTML(report *audit.Report) (string, error) {
+	doc := buildAuditDocument(report)
+	page := auditPage{Summary: doc.Summary, Entries: make([]auditHTMLEntry, len(doc.Entries))}
+	for i, ej := range doc.Entries {
+		he := auditHTMLEntry{auditEntryJSON: ej, SVG: template.HTML(ej.Diagram)}
+		if ej.hasWorst {
+			he.Severity = ej.worst.String()
+		}
+		page.Entries[i] = he
+	}
+	var buf bytes.Buffer
+	if err := auditTemplate.Execute(&buf, page); err != nil {
+		return "", fmt.Errorf("html render: %w", err)
+	}
+	return buf.String(), nil
+}
+
+var auditTemplate = template.Must(template.New("audit").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>regolith audit</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem; color: #222; }
+table { border-collapse: collapse; }
+td, th { padding: 0.25rem 0.75rem; text-align: left; border-bottom: 1px solid #ddd; }
+section { border-left: 4px solid #ccc; padding: 0.5rem 1rem; margin: 1.5rem 0; }
+section.info { border-color: #3b82f6; }
+section.warning { border-color: #f59e0b; }
+section.error { border-color: #ef4444; }
+section.critical { border-color: #7f1d1d; }
+code { background: #f4f4f4; padding: 0.1rem 0.3rem; word-break: break-all; }
+.where { color: #666; font-size: 0.9rem; }
+.diagram { overflow-x: auto; }
+</style>
+</head>
+<body>
+<h1>regolith audit</h1>
+<table>
+<tr><th>Files</th><td>{{.Summary.Files}}</td></tr>
+<tr><th>Patterns</th><td>{{.Summary.Patterns}}</td></tr>
+<tr><th>Invalid</th><td>{{.Summary.Invalid}}</td></tr>
+{{- range $sev, $n := .Summary.Findings}}
+<tr><th>{{$sev}} findings</th><td>{{$n}}</td></tr>
+{{- end}}
+</table>
+{{range .Entries}}
+<section class="{{.Severity}}">
+<div class="where">{{.File}}:{{.Line}}:{{.Column}} &middot; {{.Language}} &rarr; {{.Flavor}}{{if .Complexity}} &middot; complexity {{.Complexity}}{{end}}</div>
+<p><code>{{.Pattern}}</code></p>
+{{- if .Error}}
+<p><strong>Parse error:</strong> {{.Error.Message}}</p>
+{{- end}}
+{{- if .Findings}}
+<ul>
+{{- range .Findings}}
+<li><strong>[{{.Severity}}] {{.Title}}</strong>{{if .Description}} &mdash; {{.Description}}{{end}}{{if .Suggestion}}<br><em>{{.Suggestion}}</em>{{end}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .SVG}}
+<div class="diagram">{{.SVG}}</div>
+{{- end}}
+</section>
+{{- end}}
+</body>
+</html>
+`))
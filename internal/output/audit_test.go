@@ -0,0 +1,61 @@
+package output
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/0x4d5352/regolith/internal/audit"
+	"github.com/0x4d5352/regolith/internal/scan"
+)
+
+func auditFixture() *audit.Report {
+	return audit.Run([]scan.Site{
+		{File: "a.js", Line: 3, Column: 9, Language: "javascript", Flavor: "javascript", Pattern: `^(a+)+$`},
+		{File: "a.js", Line: 7, Column: 2, Language: "javascript", Flavor: "javascript", Pattern: `<b>(`},
+	}, audit.Options{})
+}
+
+func TestRenderAuditJSON(t *testing.T) {
+	got, err := RenderAuditJSON(auditFixture())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Summary struct {
+			Files, Patterns, Invalid int
+			Findings                 map[string]int
+		}
+		Patterns []struct {
+			File     string
+			Line     int
+			Error    *ParseErrorInfo
+			Findings []struct{ ID, Severity string }
+		}
+	}
+	if err := json.Unmarshal([]byte(got), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, got)
+	}
+	if doc.Summary.Files != 1 || doc.Summary.Patterns != 2 || doc.Summary.Invalid != 1 {
+		t.Errorf("summary = %+v", doc.Summary)
+	}
+	if doc.Summary.Findings["error"] == 0 {
+		t.Errorf("summary findings = %v, want an error-level count", doc.Summary.Findings)
+	}
+	if len(doc.Patterns) != 2 || doc.Patterns[1].Error == nil || doc.Patterns[1].Findings == nil {
+		t.Fatalf("patterns = %+v", doc.Patterns)
+	}
+}
+
+func TestRenderAuditHTMLEscapes(t *testing.T) {
+	got, err := RenderAuditHTML(auditFixture())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(got, "<b>(") {
+		t.Error("pattern text was not HTML-escaped")
+	}
+	if !strings.Contains(got, `<section class="error">`) {
+		t.Error("missing error-severity section")
+	}
+}
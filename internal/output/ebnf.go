@@ -0,0 +1,522 @@
+package output
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+)
+
+// RenderEBNF describes the structure of a parsed pattern as an ISO/IEC
+// 14977 EBNF grammar. The whole pattern is the rule "pattern"; every
+// capturing group becomes a rule of its own, named after the group
+// ("group_1", or the group's name), and lookarounds become rules that
+// the assertion refers to. Non-capturing and atomic groups stay inline
+// in parentheses.
+//
+// Alternation and repetition map directly onto "|", "[ ]", "{ }" and
+// "n *". What EBNF has no notation for (character classes, anchors,
+// backreferences, flags) is written as a special sequence, "? ... ?",
+// in the same words the text output uses. The grammar therefore
+// documents the shape of the language the pattern matches; it does not
+// capture matching semantics such as laziness or backtracking, which
+// are noted in comments where they occur.
+func RenderEBNF(root *ast.Regexp, pattern, flavorName string) string {
+	w := newEBNFWriter(root)
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "(* %s (%s) *)\n", ebnfComment(pattern), formatFlavorName(flavorName))
+	if root.Flags != "" {
+		fmt.Fprintf(&buf, "(* flags: %s *)\n", ebnfComment(root.Flags))
+	}
+	for _, opt := range root.Options {
+		fmt.Fprintf(&buf, "(* option: %s *)\n", ebnfComment(strings.TrimPrefix(w.describe(opt), "Option: ")))
+	}
+	buf.WriteByte('\n')
+
+	w.rules = append(w.rules, ebnfRule{name: "pattern", body: root})
+	// Rules are appended while earlier ones are expanded, so this loop
+	// emits them in order of first reference.
+	for i := 0; i < len(w.rules); i++ {
+		rule := w.rules[i]
+		writeEBNFRule(&buf, rule.name, w.branches(rule.body))
+	}
+	return buf.String()
+}
+
+// ebnfLineWidth is the width past which a rule's branches are written
+// one per line.
+const ebnfLineWidth = 72
+
+func writeEBNFRule(buf *strings.Builder, name string, branches []string) {
+	lead := name + " = "
+	if len(branches) > 1 && len(lead)+len(strings.Join(branches, " | ")) > ebnfLineWidth {
+		pad := strings.Repeat(" ", len(lead)-2)
+		buf.WriteString(lead + branches[0])
+		for _, b := range branches[1:] {
+			buf.WriteString("\n" + pad + "| " + b)
+		}
+		buf.WriteString(" ;\n")
+		return
+	}
+	buf.WriteString(lead + strings.Join(branches, " | ") + " ;\n")
+}
+
+type ebnfRule struct {
+	name string
+	body *ast.Regexp
+}
+
+type ebnfWriter struct {
+	rules    []ebnfRule
+	queued   map[ast.Node]bool   // Groups whose rule is already in rules
+	names    map[ast.Node]string // Rule name of each capturing group
+	byNumber map[int]string      // Capture number -> rule name
+	byName   map[string]string   // Group name -> rule name
+	used     map[string]bool     // Rule names taken, including "pattern"
+	counters map[string]int      // Per-prefix counters for lookaround rules
+}
+
+// newEBNFWriter names every capturing group up front, so that
+// backreferences and recursion can refer to a group before its rule
+// has been emitted.
+func newEBNFWriter(root *ast.Regexp) *ebnfWriter {
+	w := &ebnfWriter{
+		queued:   make(map[ast.Node]bool),
+		names:    make(map[ast.Node]string),
+		byNumber: make(map[int]string),
+		byName:   make(map[string]string),
+		used:     map[string]bool{"pattern": true},
+		counters: make(map[string]int),
+	}
+	ast.Walk(root, func(n ast.Node) {
+		switch g := n.(type) {
+		case *ast.Subexp:
+			if g.GroupType != ast.GroupCapture && g.GroupType != ast.GroupNamedCapture {
+				return
+			}
+			base := fmt.Sprintf("group_%d", g.Number)
+			if g.Name != "" {
+				base = ebnfIdentifier(g.Name)
+			}
+			name := w.claim(base)
+			w.names[g] = name
+			if g.Number > 0 {
+				w.byNumber[g.Number] = name
+			}
+			if g.Name != "" {
+				w.byName[g.Name] = name
+			}
+		case *ast.BalancedGroup:
+			base := "balanced_group"
+			if g.Name != "" {
+				base = ebnfIdentifier(g.Name)
+			}
+			w.names[g] = w.claim(base)
+			if g.Name != "" {
+				w.byName[g.Name] = w.names[g]
+			}
+		}
+	})
+	return w
+}
+
+// claim returns base, or base with a numeric suffix when base is taken.
+func (w *ebnfWriter) claim(base string) string {
+	name := base
+	for i := 2; w.used[name]; i++ {
+		name = fmt.Sprintf("%s_%d", base, i)
+	}
+	w.used[name] = true
+	return name
+}
+
+// rule queues body under the group's rule name, once, and returns the
+// name.
+func (w *ebnfWriter) rule(group ast.Node, name string, body *ast.Regexp) string {
+	if !w.queued[group] {
+		w.queued[group] = true
+		w.rules = append(w.rules, ebnfRule{name: name, body: body})
+	}
+	return name
+}
+
+// branches returns the definitions list of r, one entry per branch.
+func (w *ebnfWriter) branches(r *ast.Regexp) []string {
+	if r == nil || len(r.Matches) == 0 {
+		return []string{"(* empty *)"}
+	}
+	out := make([]string, len(r.Matches))
+	for i, m := range r.Matches {
+		out[i] = w.sequence(m)
+	}
+	return out
+}
+
+// group returns r as a single parenthesized primary.
+func (w *ebnfWriter) group(r *ast.Regexp) string {
+	return "( " + strings.Join(w.branches(r), " | ") + " )"
+}
+
+// inline returns r for use inside a sequence: bare when it has one
+// branch, parenthesized otherwise.
+func (w *ebnfWriter) inline(r *ast.Regexp) string {
+	if b := w.branches(r); len(b) == 1 {
+		return b[0]
+	}
+	return w.group(r)
+}
+
+// sequence returns the fragments of m joined by the concatenation
+// operator. Comments are attached to the term that follows them rather
+// than standing as terms of their own.
+func (w *ebnfWriter) sequence(m *ast.Match) string {
+	var parts []string
+	pending := ""
+	for _, f := range m.Fragments {
+		part := w.fragment(f)
+		switch {
+		case part == "":
+		case isEBNFComment(part):
+			pending += part + " "
+		default:
+			parts = append(parts, pending+part)
+			pending = ""
+		}
+	}
+	if pending != "" {
+		if len(parts) == 0 {
+			return strings.TrimSpace(pending)
+		}
+		parts[len(parts)-1] += " " + strings.TrimSpace(pending)
+	}
+	if len(parts) == 0 {
+		return "(* empty *)"
+	}
+	return strings.Join(parts, " , ")
+}
+
+func isEBNFComment(s string) bool {
+	return strings.HasPrefix(s, "(*") && strings.HasSuffix(s, "*)") && !strings.Contains(s[2:len(s)-2], "*)")
+}
+
+// fragment returns f's content with its quantifier expanded into EBNF
+// option, repetition, and count notation.
+func (w *ebnfWriter) fragment(f *ast.MatchFragment) string {
+	p := w.primary(f.Content)
+	r := f.Repeat
+	if r == nil || p == "" {
+		return p
+	}
+
+	var terms []string
+	switch {
+	case r.Min == 1:
+		terms = append(terms, p)
+	case r.Min > 1:
+		terms = append(terms, fmt.Sprintf("%d * %s", r.Min, p))
+	}
+	switch {
+	case r.Max == -1:
+		terms = append(terms, "{ "+p+" }")
+	case r.Max-r.Min == 1:
+		terms = append(terms, "[ "+p+" ]")
+	case r.Max > r.Min:
+		terms = append(terms, fmt.Sprintf("%d * [ %s ]", r.Max-r.Min, p))
+	}
+	if len(terms) == 0 {
+		// {0} or {0,0}: the fragment matches nothing.
+		terms = append(terms, "(* empty *)")
+	}
+	out := strings.Join(terms, " , ")
+	if mod := quantifierModifier(r); mod != "greedy" && r.Min != r.Max {
+		out += " (* " + mod + " *)"
+	}
+	return out
+}
+
+// primary returns n as a single EBNF primary: a terminal, a rule name,
+// a parenthesized group, or a special sequence.
+func (w *ebnfWriter) primary(n ast.Node) string {
+	switch v := n.(type) {
+	case nil:
+		return ""
+	case *ast.Literal:
+		return ebnfTerminal(v.Text)
+	case *ast.QuotedLiteral:
+		return ebnfTerminal(v.Text)
+	case *ast.AnyCharacter:
+		return ebnfSpecial("any character")
+	case *ast.Anchor:
+		return ebnfSpecial(strings.ToLower(strings.TrimPrefix(w.describe(v), "Asserts ")))
+	case *ast.Escape:
+		return ebnfSpecial(strings.ReplaceAll(strings.TrimPrefix(describeEscape(v), "Matches "), "`", ""))
+	case *ast.UnicodePropertyEscape:
+		return ebnfSpecial(charsetItemSource(v))
+	case *ast.Charset:
+		return w.charset(v)
+	case *ast.BackReference:
+		return ebnfSpecial("same text as " + w.groupRef(v.Number, v.Name))
+	case *ast.Subexp:
+		switch v.GroupType {
+		case ast.GroupCapture, ast.GroupNamedCapture:
+			return w.rule(v, w.names[v], v.Regexp)
+		case ast.GroupPositiveLookahead, ast.GroupNegativeLookahead,
+			ast.GroupPositiveLookbehind, ast.GroupNegativeLookbehind:
+			return ebnfSpecial(w.lookaround(v))
+		case ast.GroupAtomic:
+			return w.group(v.Regexp) + " (* atomic *)"
+		}
+		return w.group(v.Regexp)
+	case *ast.AtomicGroup:
+		return w.group(v.Regexp) + " (* atomic *)"
+	case *ast.BranchReset:
+		return w.group(v.Regexp)
+	case *ast.BalancedGroup:
+		return w.rule(v, w.names[v], v.Regexp)
+	case *ast.InlineModifier:
+		flags := ebnfSpecial("f" + strings.TrimSuffix(strings.TrimPrefix(describeInlineModifier(v), "F"), " -- modifies matching behavior"))
+		if v.Regexp == nil {
+			return flags
+		}
+		return "( " + flags + " , " + w.inline(v.Regexp) + " )"
+	case *ast.RecursiveRef:
+		return w.recursion(v.Target)
+	case *ast.Conditional:
+		return w.conditional(v)
+	case *ast.Comment:
+		return "(* " + ebnfComment(v.Text) + " *)"
+	}
+	return ebnfSpecial(w.describe(n))
+}
+
+// describe reuses the text output's wording for nodes that EBNF can
+// only name.
+func (w *ebnfWriter) describe(n ast.Node) string {
+	md := &markdownWriter{buf: &strings.Builder{}}
+	if d := md.describeNode(n); d != "" {
+		return strings.ReplaceAll(d, "`", "")
+	}
+	return n.Type()
+}
+
+// lookaround queues v's body as a rule and returns the assertion that
+// refers to it.
+func (w *ebnfWriter) lookaround(v *ast.Subexp) string {
+	var prefix, verb string
+	switch v.GroupType {
+	case ast.GroupPositiveLookahead:
+		prefix, verb = "lookahead", "followed by "
+	case ast.GroupNegativeLookahead:
+		prefix, verb = "negative_lookahead", "not followed by "
+	case ast.GroupPositiveLookbehind:
+		prefix, verb = "lookbehind", "preceded by "
+	default:
+		prefix, verb = "negative_lookbehind", "not preceded by "
+	}
+	name, ok := w.names[v]
+	if !ok {
+		w.counters[prefix]++
+		name = w.claim(fmt.Sprintf("%s_%d", prefix, w.counters[prefix]))
+		w.names[v] = name
+	}
+	return verb + w.rule(v, name, v.Regexp)
+}
+
+// groupRef returns the rule name for a capture group referred to by
+// number or name, or a description when it cannot be resolved (for
+// instance a relative reference).
+func (w *ebnfWriter) groupRef(number int, name string) string {
+	if name != "" {
+		if rule, ok := w.byName[name]; ok {
+			return rule
+		}
+		return fmt.Sprintf("group %q", name)
+	}
+	if rule, ok := w.byNumber[number]; ok {
+		return rule
+	}
+	return fmt.Sprintf("group %d", number)
+}
+
+// recursion maps a subroutine call onto the rule it re-enters.
+func (w *ebnfWriter) recursion(target string) string {
+	if target == "R" || target == "0" {
+		return "pattern"
+	}
+	if n, err := strconv.Atoi(target); err == nil && target[0] != '+' && target[0] != '-' {
+		if rule, ok := w.byNumber[n]; ok {
+			return rule
+		}
+	} else if rule, ok := w.byName[target]; ok {
+		return rule
+	}
+	return ebnfSpecial("recurse into group " + target)
+}
+
+// conditional writes (?(cond)yes|no) as a choice between the two
+// branches, each led by a special sequence naming the condition.
+func (w *ebnfWriter) conditional(c *ast.Conditional) string {
+	if lit, ok := c.Condition.(*ast.Literal); ok && lit.Text == "DEFINE" {
+		// (?(DEFINE)...) never matches; it only defines groups for
+		// subroutine calls. Queue those rules and contribute nothing.
+		_ = w.branches(c.TrueMatch)
+		return ""
+	}
+
+	var cond string
+	switch v := c.Condition.(type) {
+	case *ast.BackReference:
+		cond = w.groupRef(v.Number, v.Name) + " matched"
+	case *ast.Subexp:
+		cond = w.lookaround(v)
+	case *ast.RecursiveRef:
+		cond = "in recursion"
+		if v.Target != "R" {
+			cond += " " + strings.TrimPrefix(v.Target, "R")
+		}
+	default:
+		cond = w.describe(c.Condition)
+	}
+	yes := ebnfSpecial("if "+cond) + " , " + w.inline(c.TrueMatch)
+	no := ebnfSpecial("otherwise")
+	if c.FalseMatch != nil {
+		no += " , " + w.inline(c.FalseMatch)
+	}
+	return "( " + yes + " | " + no + " )"
+}
+
+// charset writes a class of plain characters as a choice of terminals,
+// and anything else (ranges, escapes, negation, set operations) as a
+// special sequence holding the class in regex notation.
+func (w *ebnfWriter) charset(c *ast.Charset) string {
+	if !c.Inverted && c.SetExpression == nil && len(c.Items) > 0 {
+		terms := make([]string, 0, len(c.Items))
+		for _, item := range c.Items {
+			lit, ok := item.(*ast.CharsetLiteral)
+			if !ok {
+				terms = nil
+				break
+			}
+			terms = append(terms, ebnfTerminal(lit.Text))
+		}
+		if len(terms) == 1 {
+			return terms[0]
+		}
+		if terms != nil {
+			return "( " + strings.Join(terms, " | ") + " )"
+		}
+	}
+	verb := "one of "
+	if c.Inverted {
+		verb = "none of "
+	}
+	return ebnfSpecial(verb + charsetSource(c))
+}
+
+// charsetSource writes c back in bracket notation.
+func charsetSource(c *ast.Charset) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	if c.Inverted {
+		b.WriteByte('^')
+	}
+	if c.SetExpression != nil {
+		b.WriteString(charsetItemSource(c.SetExpression))
+	}
+	for _, item := range c.Items {
+		b.WriteString(charsetItemSource(item))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+func charsetItemSource(n ast.Node) string {
+	switch v := n.(type) {
+	case *ast.CharsetLiteral:
+		return v.Text
+	case *ast.CharsetRange:
+		return v.First + "-" + v.Last
+	case *ast.Escape:
+		return escapeSource(v)
+	case *ast.POSIXClass:
+		if v.Negated {
+			return "[:^" + v.Name + ":]"
+		}
+		return "[:" + v.Name + ":]"
+	case *ast.UnicodePropertyEscape:
+		if v.Negated {
+			return `\P{` + v.Property + `}`
+		}
+		return `\p{` + v.Property + `}`
+	case *ast.Charset:
+		return charsetSource(v)
+	case *ast.CharsetIntersection:
+		return joinCharsetOperands(v.Operands, "&&")
+	case *ast.CharsetSubtraction:
+		return joinCharsetOperands(v.Operands, "--")
+	case *ast.CharsetStringDisjunction:
+		return `\q{` + strings.Join(v.Strings, "|") + `}`
+	}
+	return ""
+}
+
+func joinCharsetOperands(ops []ast.Node, op string) string {
+	parts := make([]string, len(ops))
+	for i, o := range ops {
+		parts[i] = charsetItemSource(o)
+	}
+	return strings.Join(parts, op)
+}
+
+// ebnfTerminal quotes s as a terminal string. A string containing both
+// quote characters is split into a parenthesized concatenation.
+func ebnfTerminal(s string) string {
+	if !strings.Contains(s, `"`) {
+		return `"` + s + `"`
+	}
+	if !strings.Contains(s, "'") {
+		return "'" + s + "'"
+	}
+	var parts []string
+	for i, chunk := range strings.Split(s, `"`) {
+		if i > 0 {
+			parts = append(parts, `'"'`)
+		}
+		if chunk != "" {
+			parts = append(parts, `"`+chunk+`"`)
+		}
+	}
+	return "( " + strings.Join(parts, " , ") + " )"
+}
+
+// ebnfSpecial wraps text in a special sequence. "?" cannot appear
+// inside one, so it is written as \x3F.
+func ebnfSpecial(text string) string {
+	return "? " + strings.ReplaceAll(text, "?", `\x3F`) + " ?"
+}
+
+// ebnfComment makes text safe inside (* ... *).
+func ebnfComment(text string) string {
+	return strings.NewReplacer("*)", `*\x29`, "(*", `\x28*`).Replace(text)
+}
+
+// ebnfIdentifier turns a group name into a meta-identifier: letters,
+// digits, and underscores, starting with a letter.
+func ebnfIdentifier(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	id := b.String()
+	if id == "" || !unicode.IsLetter([]rune(id)[0]) {
+		id = "group_" + id
+	}
+	return id
+}
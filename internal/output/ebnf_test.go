@@ -0,0 +1,86 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/0x4d5352/regolith/internal/parser"
+)
+
+func mustEBNF(t *testing.T, pattern string) string {
+	t.Helper()
+	root, err := parser.ParseRegex(pattern)
+	if err != nil {
+		t.Fatalf("parse %q: %v", pattern, err)
+	}
+	return RenderEBNF(root, pattern, "javascript")
+}
+
+func TestRenderEBNFRules(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"a(b|c)+", []string{
+			`pattern = "a" , group_1 , { group_1 } ;`,
+			`group_1 = "b" | "c" ;`,
+		}},
+		{"(?<year>\\d{4})-(?:0|1)\\k<year>", []string{
+			`pattern = year , "-" , ( "0" | "1" ) , ? same text as year ? ;`,
+			`year = 4 * ? any digit \d (0-9) ? ;`,
+		}},
+		{"x(?=y)", []string{
+			`pattern = "x" , ? followed by lookahead_1 ? ;`,
+			`lookahead_1 = "y" ;`,
+		}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.pattern, func(t *testing.T) {
+			got := mustEBNF(t, tc.pattern)
+			for _, want := range tc.want {
+				if !strings.Contains(got, want+"\n") {
+					t.Errorf("missing rule %s in:\n%s", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderEBNFRepeats(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"a?", `[ "a" ]`},
+		{"a*", `{ "a" }`},
+		{"a+", `"a" , { "a" }`},
+		{"a{3}", `3 * "a"`},
+		{"a{2,}", `2 * "a" , { "a" }`},
+		{"a{1,3}", `"a" , 2 * [ "a" ]`},
+		{"a{0,4}", `4 * [ "a" ]`},
+		{"a*?", `{ "a" } (* lazy *)`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.pattern, func(t *testing.T) {
+			if got := mustEBNF(t, tc.pattern); !strings.Contains(got, "pattern = "+tc.want+" ;\n") {
+				t.Errorf("expected pattern = %s, got:\n%s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestEBNFTerminalQuoting(t *testing.T) {
+	tests := map[string]string{
+		`ab`:    `"ab"`,
+		`a"b`:   `'a"b'`,
+		`a"b'c`: `( "a" , '"' , "b'c" )`,
+	}
+	for in, want := range tests {
+		if got := ebnfTerminal(in); got != want {
+			t.Errorf("ebnfTerminal(%q) = %s, want %s", in, got, want)
+		}
+	}
+	if got := ebnfSpecial("one of [?!]"); got != `? one of [\x3F!] ?` {
+		t.Errorf("special sequence must not contain '?': %s", got)
+	}
+}
@@ -0,0 +1,502 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+)
+
+// RenderExplain describes a parsed pattern in plain English, one
+// construct per line and indented by nesting:
+//
+//	group #1 'year': exactly 4 digits
+//
+// It covers the same ground as the text outline in fewer words. Each
+// quantifier is folded into the phrase it counts, a non-capturing group
+// without one disappears into its parent, and an alternative made of
+// simple steps is written on one line. The output is plain text with
+// no markup, so it can be pasted into a review comment or read by a
+// screen reader as it is.
+func RenderExplain(root *ast.Regexp, pattern, flavorName string) string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "Regex: %s\nFlavor: %s\n", pattern, formatFlavorName(flavorName))
+	if root.Flags != "" {
+		fmt.Fprintf(&buf, "Flags: %s\n", explainFlagList(root.Flags))
+	}
+	for _, opt := range root.Options {
+		fmt.Fprintf(&buf, "%s\n", strings.ReplaceAll((&markdownWriter{}).describeNode(opt), "`", ""))
+	}
+	buf.WriteByte('\n')
+	for _, l := range explainRegexp(root) {
+		buf.WriteString(strings.Repeat("  ", l.depth))
+		buf.WriteString(l.text)
+		buf.WriteByte('\n')
+	}
+	return buf.String()
+}
+
+// explainLine is one line of the explanation at a nesting depth
+// relative to the construct that produced it.
+type explainLine struct {
+	depth int
+	text  string
+}
+
+// explainWidth is the longest an alternative may be when its steps are
+// joined onto one line.
+const explainWidth = 72
+
+// indent returns lines moved by depth levels.
+func indent(lines []explainLine, depth int) []explainLine {
+	out := make([]explainLine, len(lines))
+	for i, l := range lines {
+		out[i] = explainLine{l.depth + depth, l.text}
+	}
+	return out
+}
+
+func explainRegexp(r *ast.Regexp) []explainLine {
+	if r == nil || len(r.Matches) == 0 {
+		return []explainLine{{0, "nothing (the empty string)"}}
+	}
+	if len(r.Matches) == 1 {
+		return explainMatch(r.Matches[0])
+	}
+	out := []explainLine{{0, "one of:"}}
+	for _, m := range r.Matches {
+		out = append(out, explainAlternative(m)...)
+	}
+	return out
+}
+
+// explainAlternative writes one branch of an alternation as a bullet.
+// Simple steps are joined with ", then"; otherwise the steps follow
+// the bullet, each later one led by "then".
+func explainAlternative(m *ast.Match) []explainLine {
+	lines := explainMatch(m)
+	if len(lines) == 0 {
+		return []explainLine{{1, "- nothing (the empty string)"}}
+	}
+	texts := make([]string, 0, len(lines))
+	for _, l := range lines {
+		if l.depth != 0 || strings.HasSuffix(l.text, ":") {
+			texts = nil
+			break
+		}
+		texts = append(texts, l.text)
+	}
+	if joined := strings.Join(texts, ", then "); texts != nil && len(joined) <= explainWidth {
+		return []explainLine{{1, "- " + joined}}
+	}
+	out := []explainLine{{1, "- " + lines[0].text}}
+	for _, l := range lines[1:] {
+		if l.depth == 0 {
+			l.text = "then " + l.text
+		}
+		out = append(out, explainLine{l.depth + 2, l.text})
+	}
+	return out
+}
+
+func explainMatch(m *ast.Match) []explainLine {
+	if m == nil {
+		return nil
+	}
+	var out []explainLine
+	for _, f := range m.Fragments {
+		out = append(out, explainFragment(f)...)
+	}
+	return out
+}
+
+// explainFragment describes f with its quantifier. Parsers keep a run
+// of literal characters together, and a quantifier after it counts the
+// last character only, so "bar+" is explained as "ba" then "r".
+func explainFragment(f *ast.MatchFragment) []explainLine {
+	if text, ok := literalText(f.Content); ok && f.Repeat != nil && utf8.RuneCountInString(text) > 1 {
+		_, size := utf8.DecodeLastRuneInString(text)
+		return []explainLine{
+			{0, fmt.Sprintf("%q", text[:len(text)-size])},
+			{0, countPhrase(explainNoun{one: fmt.Sprintf("%q", text[len(text)-size:])}, f.Repeat)},
+		}
+	}
+
+	r := f.Repeat
+	switch v := f.Content.(type) {
+	case nil:
+		return nil
+	case *ast.Subexp:
+		return explainSubexp(v, r)
+	case *ast.AtomicGroup:
+		return explainContainer(countPhrase(explainNoun{one: "an atomic group (no backtracking into it)"}, r), explainRegexp(v.Regexp))
+	case *ast.BranchReset:
+		return explainContainer(countPhrase(explainNoun{one: "a branch-reset group (each alternative numbers its groups from the same point)"}, r), explainRegexp(v.Regexp))
+	case *ast.BalancedGroup:
+		header := "a balancing group"
+		if v.Name != "" {
+			header += fmt.Sprintf(" '%s'", v.Name)
+		}
+		header += fmt.Sprintf(" (removes the last capture of '%s')", v.OtherName)
+		return explainContainer(countPhrase(explainNoun{one: header}, r), explainRegexp(v.Regexp))
+	case *ast.InlineModifier:
+		if v.Regexp == nil {
+			return []explainLine{{0, "from here on, " + explainModifier(v)}}
+		}
+		return explainContainer(countPhrase(explainNoun{one: explainModifier(v)}, r), explainRegexp(v.Regexp))
+	case *ast.Conditional:
+		return explainConditional(v)
+	}
+	return []explainLine{{0, countPhrase(explainNounFor(f.Content), r)}}
+}
+
+// explainContainer writes header over body, or on the same line when
+// the body is a single simple line.
+func explainContainer(header string, body []explainLine) []explainLine {
+	if len(body) == 1 && body[0].depth == 0 && !strings.HasSuffix(body[0].text, ":") {
+		return []explainLine{{0, header + ": " + body[0].text}}
+	}
+	return append([]explainLine{{0, header + ":"}}, indent(body, 1)...)
+}
+
+func explainSubexp(s *ast.Subexp, r *ast.Repeat) []explainLine {
+	var header string
+	switch s.GroupType {
+	case ast.GroupCapture, ast.GroupNamedCapture:
+		header = fmt.Sprintf("group #%d", s.Number)
+		if s.Name != "" {
+			header += fmt.Sprintf(" '%s'", s.Name)
+		}
+		header = countPhrase(explainNoun{one: header}, r)
+		if r != nil && r.Max != 1 {
+			header += " (captures the last repetition)"
+		}
+	case ast.GroupNonCapture:
+		if r == nil {
+			return explainRegexp(s.Regexp)
+		}
+		header = countPhrase(explainNoun{one: "a group"}, r)
+	case ast.GroupPositiveLookahead:
+		header = "followed by"
+	case ast.GroupNegativeLookahead:
+		header = "not followed by"
+	case ast.GroupPositiveLookbehind:
+		header = "preceded by"
+	case ast.GroupNegativeLookbehind:
+		header = "not preceded by"
+	case ast.GroupAtomic:
+		header = countPhrase(explainNoun{one: "an atomic group (no backtracking into it)"}, r)
+	case ast.GroupSubroutine:
+		header = countPhrase(explainNoun{one: fmt.Sprintf("the pattern of group #%d again", s.Number)}, r)
+	default:
+		header = countPhrase(explainNoun{one: "a " + strings.ReplaceAll(s.GroupType, "_", " ") + " group"}, r)
+	}
+	return explainContainer(header, explainRegexp(s.Regexp))
+}
+
+func explainConditional(c *ast.Conditional) []explainLine {
+	if lit, ok := c.Condition.(*ast.Literal); ok && lit.Text == "DEFINE" {
+		return explainContainer("definitions for later calls (never matched here)", explainRegexp(c.TrueMatch))
+	}
+	var cond string
+	switch v := c.Condition.(type) {
+	case *ast.BackReference:
+		cond = explainGroupRef(v.Number, v.Name) + " has matched"
+	case *ast.RecursiveRef:
+		cond = "inside a recursive call"
+	default:
+		cond = Source(c.Condition) + " matches"
+	}
+	out := explainContainer("if "+cond, explainRegexp(c.TrueMatch))
+	if c.FalseMatch != nil {
+		out = append(out, explainContainer("otherwise", explainRegexp(c.FalseMatch))...)
+	}
+	return out
+}
+
+// explainNoun is what a construct matches, in the singular ("a digit")
+// and, for constructs that are counted rather than repeated, the
+// plural ("digits").
+type explainNoun struct {
+	one, many string
+}
+
+// countPhrase folds r into noun: "exactly 4 digits", or "\"ab\", 1 or
+// more times" when the noun has no plural.
+func countPhrase(noun explainNoun, r *ast.Repeat) string {
+	if r == nil || (r.Min == 1 && r.Max == 1) {
+		return noun.one
+	}
+	var out string
+	if noun.many != "" {
+		switch {
+		case r.Min == 0 && r.Max == 1:
+			out = "optionally " + noun.one
+		case r.Min == r.Max:
+			out = fmt.Sprintf("exactly %d %s", r.Min, noun.many)
+		case r.Max == -1:
+			out = fmt.Sprintf("%d or more %s", r.Min, noun.many)
+		case r.Min == 0:
+			out = fmt.Sprintf("up to %d %s", r.Max, noun.many)
+		default:
+			out = fmt.Sprintf("%d to %d %s", r.Min, r.Max, noun.many)
+		}
+	} else {
+		switch {
+		case r.Min == 0 && r.Max == 1:
+			out = noun.one + ", optionally"
+		case r.Min == r.Max:
+			out = fmt.Sprintf("%s, exactly %d times", noun.one, r.Min)
+		case r.Max == -1:
+			out = fmt.Sprintf("%s, %d or more times", noun.one, r.Min)
+		default:
+			out = fmt.Sprintf("%s, %d to %d times", noun.one, r.Min, r.Max)
+		}
+	}
+	switch {
+	case r.Min == r.Max:
+	case r.Possessive:
+		out += " (possessive: never gives any back)"
+	case !r.Greedy:
+		out += " (as few as possible)"
+	}
+	return out
+}
+
+var explainEscapes = map[string]explainNoun{
+	"digit":                     {"a digit", "digits"},
+	"non_digit":                 {"a non-digit", "non-digits"},
+	"word":                      {"a word character", "word characters"},
+	"non_word":                  {"a non-word character", "non-word characters"},
+	"whitespace":                {"a whitespace character", "whitespace characters"},
+	"non_whitespace":            {"a non-whitespace character", "non-whitespace characters"},
+	"newline":                   {"a newline", "newlines"},
+	"non_newline":               {"a character other than newline", "characters other than newline"},
+	"newline_sequence":          {"a line break", "line breaks"},
+	"linebreak":                 {"a line break", "line breaks"},
+	"any_or_newline":            {"any character or newline", "characters or newlines"},
+	"tab":                       {"a tab", "tabs"},
+	"carriage_return":           {"a carriage return", "carriage returns"},
+	"form_feed":                 {"a form feed", "form feeds"},
+	"vertical_tab":              {"a vertical tab", "vertical tabs"},
+	"null":                      {"a null character", "null characters"},
+	"alert":                     {"a bell character", "bell characters"},
+	"bell":                      {"a bell character", "bell characters"},
+	"escape":                    {"an escape character", "escape characters"},
+	"escape_char":               {"an escape character", "escape characters"},
+	"backspace":                 {"a backspace", "backspaces"},
+	"horizontal_space":          {"a horizontal whitespace character", "horizontal whitespace characters"},
+	"horizontal_whitespace":     {"a horizontal whitespace character", "horizontal whitespace characters"},
+	"non_horizontal_space":      {"a character other than horizontal whitespace", "characters other than horizontal whitespace"},
+	"non_horizontal_whitespace": {"a character other than horizontal whitespace", "characters other than horizontal whitespace"},
+	"vertical_space":            {"a vertical whitespace character", "vertical whitespace characters"},
+	"vertical_whitespace":       {"a vertical whitespace character", "vertical whitespace characters"},
+	"non_vertical_space":        {"a character other than vertical whitespace", "characters other than vertical whitespace"},
+	"non_vertical_whitespace":   {"a character other than vertical whitespace", "characters other than vertical whitespace"},
+	"grapheme":                  {"a grapheme cluster", "grapheme clusters"},
+	"extended_grapheme":         {"a grapheme cluster", "grapheme clusters"},
+	"alpha":                     {"a letter", "letters"},
+	"non_alpha":                 {"a non-letter", "non-letters"},
+	"lower":                     {"a lowercase letter", "lowercase letters"},
+	"non_lower":                 {"a character other than a lowercase letter", "characters other than lowercase letters"},
+	"upper":                     {"an uppercase letter", "uppercase letters"},
+	"non_upper":                 {"a character other than an uppercase letter", "characters other than uppercase letters"},
+	"hex_digit":                 {"a hex digit", "hex digits"},
+	"non_hex_digit":             {"a character other than a hex digit", "characters other than hex digits"},
+	"octal_digit":               {"an octal digit", "octal digits"},
+	"non_octal_digit":           {"a character other than an octal digit", "characters other than octal digits"},
+	"word_head":                 {"a letter or underscore", "letters or underscores"},
+	"non_word_head":             {"a character other than a letter or underscore", "characters other than letters or underscores"},
+	"identifier":                {"an identifier character ('isident')", "identifier characters ('isident')"},
+	"identifier_non_digit":      {"an identifier character other than a digit", "identifier characters other than digits"},
+	"keyword":                   {"a keyword character ('iskeyword')", "keyword characters ('iskeyword')"},
+	"keyword_non_digit":         {"a keyword character other than a digit", "keyword characters other than digits"},
+	"filename":                  {"a file name character ('isfname')", "file name characters ('isfname')"},
+	"filename_non_digit":        {"a file name character other than a digit", "file name characters other than digits"},
+	"printable":                 {"a printable character ('isprint')", "printable characters ('isprint')"},
+	"printable_non_digit":       {"a printable character other than a digit", "printable characters other than digits"},
+}
+
+var explainAnchors = map[string]string{
+	ast.AnchorStart:                   "the start of a line",
+	ast.AnchorEnd:                     "the end of a line",
+	ast.AnchorWordBoundary:            "a word boundary",
+	ast.AnchorNonWordBoundary:         "a position that is not a word boundary",
+	ast.AnchorStringStart:             "the start of the input",
+	ast.AnchorStringEnd:               "the end of the input, or before a final newline",
+	ast.AnchorAbsoluteEnd:             "the very end of the input",
+	ast.AnchorWordStart:               "the start of a word",
+	ast.AnchorWordEnd:                 "the end of a word",
+	ast.AnchorGraphemeClusterBoundary: "a grapheme cluster boundary",
+	ast.AnchorMatchStart:              "the match starts here",
+	ast.AnchorMatchEnd:                "the match ends here",
+	"first_match_position":            "the position where the previous match ended",
+	"end_of_previous_match":           "the position where the previous match ended",
+	"reset_match_start":               "the match starts here (text before is kept out of it)",
+}
+
+// explainNounFor describes a node that fits on one line.
+func explainNounFor(n ast.Node) explainNoun {
+	switch v := n.(type) {
+	case *ast.Literal:
+		return explainNoun{one: fmt.Sprintf("%q", v.Text)}
+	case *ast.QuotedLiteral:
+		return explainNoun{one: fmt.Sprintf("%q", v.Text)}
+	case *ast.AnyCharacter:
+		return explainNoun{"any character", "characters"}
+	case *ast.Anchor:
+		if text, ok := explainAnchors[v.AnchorType]; ok {
+			return explainNoun{one: text}
+		}
+		return explainNoun{one: "the anchor " + Source(v)}
+	case *ast.Escape:
+		if noun, ok := explainEscapes[v.EscapeType]; ok {
+			return noun
+		}
+		if v.EscapeType == "nonterminal" {
+			return explainNoun{one: "the rule " + v.Value}
+		}
+		return explainNoun{one: "the character " + Source(v)}
+	case *ast.UnicodePropertyEscape:
+		if v.Negated {
+			return explainNoun{"a character without Unicode property " + v.Property, "characters without Unicode property " + v.Property}
+		}
+		return explainNoun{"a character with Unicode property " + v.Property, "characters with Unicode property " + v.Property}
+	case *ast.Charset:
+		return explainCharset(v)
+	case *ast.BackReference:
+		return explainNoun{one: "the same text as " + explainGroupRef(v.Number, v.Name)}
+	case *ast.RecursiveRef:
+		switch {
+		case v.Target == "R" || v.Target == "0":
+			return explainNoun{one: "the whole pattern again (recursion)"}
+		case strings.HasPrefix(v.Target, "+") || strings.HasPrefix(v.Target, "-"):
+			return explainNoun{one: fmt.Sprintf("the pattern of the group %s from here again", v.Target)}
+		case isDigits(v.Target):
+			return explainNoun{one: fmt.Sprintf("the pattern of group #%s again", v.Target)}
+		}
+		return explainNoun{one: fmt.Sprintf("the pattern of group '%s' again", v.Target)}
+	case *ast.Comment:
+		return explainNoun{one: fmt.Sprintf("(comment: %s)", v.Text)}
+	}
+	return explainNoun{one: Source(n)}
+}
+
+func explainGroupRef(number int, name string) string {
+	if name != "" {
+		return fmt.Sprintf("group '%s'", name)
+	}
+	return fmt.Sprintf("group #%d", number)
+}
+
+// explainCharset lists a class's members: "one of \"a\", \"b\" or
+// \"c\"", "a character from a to z or digits". Set operations and
+// nested classes are quoted as written.
+func explainCharset(c *ast.Charset) explainNoun {
+	if c.SetExpression != nil {
+		return explainNoun{"a character matching " + Source(c), "characters matching " + Source(c)}
+	}
+	if len(c.Items) == 0 {
+		if c.Inverted {
+			return explainNoun{"any character", "characters"}
+		}
+		return explainNoun{one: "no character (never matches)"}
+	}
+	items := make([]string, len(c.Items))
+	literals := true
+	for i, item := range c.Items {
+		switch v := item.(type) {
+		case *ast.CharsetLiteral:
+			items[i] = fmt.Sprintf("%q", v.Text)
+			continue
+		case *ast.CharsetRange:
+			items[i] = v.First + " to " + v.Last
+		case *ast.POSIXClass:
+			desc, ok := posixClassDescriptions[v.Name]
+			if !ok {
+				desc = "[:" + v.Name + ":]"
+			}
+			if v.Negated {
+				desc = "anything but " + desc
+			}
+			items[i] = desc
+		case *ast.Escape:
+			if noun, ok := explainEscapes[v.EscapeType]; ok {
+				items[i] = noun.many
+			} else {
+				items[i] = Source(v)
+			}
+		default:
+			items[i] = Source(item)
+		}
+		literals = false
+	}
+	list := items[0]
+	if len(items) > 1 {
+		list = strings.Join(items[:len(items)-1], ", ") + " or " + items[len(items)-1]
+	}
+	switch {
+	case c.Inverted:
+		return explainNoun{"any character except " + list, "characters other than " + list}
+	case literals && len(items) > 1:
+		return explainNoun{"one of " + list, "characters from " + list}
+	}
+	return explainNoun{"a character from " + list, "characters from " + list}
+}
+
+var explainFlagNames = map[rune]string{
+	'd': "match indices",
+	'g': "global",
+	'i': "case-insensitive",
+	'J': "duplicate group names",
+	'm': "multiline: ^ and $ match at line breaks",
+	'n': "explicit capture",
+	's': "dot matches newline",
+	'U': "quantifiers lazy by default",
+	'u': "Unicode",
+	'v': "Unicode sets",
+	'x': "free-spacing",
+	'y': "sticky",
+}
+
+// explainFlagList names each flag letter: "g (global), i
+// (case-insensitive)".
+func explainFlagList(flags string) string {
+	parts := make([]string, 0, len(flags))
+	for _, f := range flags {
+		if name, ok := explainFlagNames[f]; ok {
+			parts = append(parts, fmt.Sprintf("%c (%s)", f, name))
+		} else {
+			parts = append(parts, string(f))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// explainModifier describes the flags an inline modifier turns on and
+// off: "with i (case-insensitive), without s (dot matches newline)".
+func explainModifier(im *ast.InlineModifier) string {
+	var parts []string
+	if im.Enable != "" {
+		parts = append(parts, "with "+explainFlagList(im.Enable))
+	}
+	if im.Disable != "" {
+		parts = append(parts, "without "+explainFlagList(im.Disable))
+	}
+	if len(parts) == 0 {
+		return "with default flags"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// literalText is the text of a Literal or QuotedLiteral.
+func literalText(n ast.Node) (string, bool) {
+	switch v := n.(type) {
+	case *ast.Literal:
+		return v.Text, true
+	case *ast.QuotedLiteral:
+		return v.Text, true
+	}
+	return "", false
+}
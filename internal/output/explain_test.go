@@ -0,0 +1,62 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/0x4d5352/regolith/internal/parser"
+)
+
+func mustExplain(t *testing.T, pattern string) string {
+	t.Helper()
+	root, err := parser.ParseRegex(pattern)
+	if err != nil {
+		t.Fatalf("parse %q: %v", pattern, err)
+	}
+	return RenderExplain(root, pattern, "javascript")
+}
+
+func TestRenderExplain(t *testing.T) {
+	got := mustExplain(t, `^(?<year>\d{4})-(?:0[1-9]|1[0-2])$`)
+	want := `Regex: ^(?<year>\d{4})-(?:0[1-9]|1[0-2])$
+Flavor: JavaScript
+
+the start of a line
+group #1 'year': exactly 4 digits
+"-"
+one of:
+  - "0", then a character from 1 to 9
+  - "1", then a character from 0 to 2
+the end of a line
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRenderExplainPhrases(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{`\d?`, "optionally a digit"},
+		{`\w{2,}`, "2 or more word characters"},
+		{`.{0,3}?`, "up to 3 characters (as few as possible)"},
+		{`bar+`, "\"ba\"\n\"r\", 1 or more times"},
+		{`[^abc]`, `any character except "a", "b" or "c"`},
+		{`[abc]{2}`, `exactly 2 characters from "a", "b" or "c"`},
+		{`(a)+`, `group #1, 1 or more times (captures the last repetition): "a"`},
+		{`(?:ab)*`, `a group, 0 or more times: "ab"`},
+		{`x(?!y)`, `not followed by: "y"`},
+		{`(?<n>a)\k<n>`, "the same text as group 'n'"},
+		{`/a/gi`, "Flags: g (global), i (case-insensitive)"},
+		{`a|bc(d|e)`, "one of:\n  - \"a\"\n  - \"bc\"\n    then group #1:\n      one of:"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.pattern, func(t *testing.T) {
+			if got := mustExplain(t, tc.pattern); !strings.Contains(got, tc.want) {
+				t.Errorf("missing %q in:\n%s", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package output
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"sort"
+)
+
+// GalleryGenerator is the generator meta tag every gallery page
+// carries, so a later run can tell its own index.html from a file it
+// must not overwrite.
+const GalleryGenerator = `<meta name="generator" content="regolith gallery">`
+
+// GalleryEntry is one pattern in a gallery page.
+type GalleryEntry struct {
+	Pattern string
+	Flavor  string
+	Title   string   // Optional heading for the card
+	Notes   []string // Optional annotations listed under the pattern
+	File    string   // Diagram path relative to the page; "" when rendering failed
+	SVG     []byte   // The diagram, inlined as the thumbnail
+	Error   string   // Why there is no diagram
+}
+
+// galleryPage is the data behind galleryTemplate.
+type galleryPage struct {
+	Flavors []string
+	Failed  int
+	Entries []galleryHTMLEntry
+}
+
+type galleryHTMLEntry struct {
+	GalleryEntry
+	Seq   int
+	Thumb template.URL // data: URL of the SVG; trusted, produced by our own renderer
+}
+
+// RenderGalleryHTML renders a single self-contained HTML page listing
+// entries as cards — thumbnail, pattern text, and flavor badge — with a
+// search box and flavor filter. Thumbnails are inlined, so the page
+// still shows them when copied away from the diagrams; each card links
+// to its diagram file.
+func RenderGalleryHTML(entries []GalleryEntry) (string, error) {
+	page := galleryPage{Entries: make([]galleryHTMLEntry, len(entries))}
+	flavors := make(map[string]bool)
+	for i, e := range entries {
+		he := galleryHTMLEntry{GalleryEntry: e, Seq: i + 1}
+		if len(e.SVG) > 0 {
+			he.Thumb = template.URL("data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString(e.SVG))
+		}
+		if e.Error != "" {
+			page.Failed++
+		}
+		if !flavors[e.Flavor] {
+			flavors[e.Flavor] = true
+			page.Flavors = append(page.Flavors, e.Flavor)
+		}
+		page.Entries[i] = he
+	}
+	sort.Strings(page.Flavors)
+	var buf bytes.Buffer
+	if err := galleryTemplate.Execute(&buf, page); err != nil {
+		return "", fmt.Errorf("html render: %w", err)
+	}
+	return buf.String(), nil
+}
+
+var galleryTemplate = template.Must(template.New("gallery").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+` + GalleryGenerator + `
+<title>regolith gallery</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem; color: #222; }
+.controls { display: flex; gap: 0.75rem; margin: 1rem 0 1.5rem; }
+.controls input { flex: 1; max-width: 32rem; padding: 0.4rem 0.6rem; font-size: 1rem; }
+.controls select { padding: 0.4rem; }
+.grid { display: grid; grid-template-columns: repeat(auto-fill, minmax(18rem, 1fr)); gap: 1rem; }
+.card { border: 1px solid #ddd; border-radius: 6px; padding: 0.75rem; display: flex; flex-direction: column; gap: 0.5rem; }
+.card.failed { border-color: #ef4444; }
+.thumb { display: block; height: 8rem; background: #fafafa; border-radius: 4px; }
+.thumb img { width: 100%; height: 100%; object-fit: contain; }
+code { background: #f4f4f4; padding: 0.1rem 0.3rem; word-break: break-all; }
+.meta { display: flex; justify-content: space-between; color: #666; font-size: 0.85rem; }
+.badge { background: #e0e7ff; color: #3730a3; border-radius: 999px; padding: 0.05rem 0.55rem; }
+.error { color: #b91c1c; font-size: 0.9rem; }
+.card h2 { font-size: 1rem; margin: 0; }
+.notes { margin: 0; padding-left: 1.2rem; font-size: 0.9rem; }
+</style>
+</head>
+<body>
+<h1>regolith gallery</h1>
+<p>{{len .Entries}} patterns{{if .Failed}}, {{.Failed}} failed to render{{end}}</p>
+<div class="controls">
+<input id="search" type="search" placeholder="Filter patterns" aria-label="Filter patterns">
+<select id="flavor" aria-label="Flavor">
+<option value="">All flavors</option>
+{{- range .Flavors}}
+<option>{{.}}</option>
+{{- end}}
+</select>
+</div>
+<div class="grid">
+{{- range .Entries}}
+<div class="card{{if .Error}} failed{{end}}" data-pattern="{{.Pattern}}" data-title="{{.Title}}" data-flavor="{{.Flavor}}">
+{{- if .Title}}
+<h2>{{.Title}}</h2>
+{{- end}}
+{{- if .Thumb}}
+<a class="thumb" href="{{.File}}"><img src="{{.Thumb}}" alt="Diagram of {{.Pattern}}" loading="lazy"></a>
+{{- end}}
+<code>{{.Pattern}}</code>
+{{- if .Notes}}
+<ul class="notes">
+{{- range .Notes}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Error}}
+<div class="error">{{.Error}}</div>
+{{- end}}
+<div class="meta"><span>#{{.Seq}}{{if .File}} &middot; <a href="{{.File}}">{{.File}}</a>{{end}}</span><span class="badge">{{.Flavor}}</span></div>
+</div>
+{{- end}}
+</div>
+<script>
+(function () {
+  var search = document.getElementById("search");
+  var flavor = document.getElementById("flavor");
+  var cards = document.querySelectorAll(".card");
+  function apply() {
+    var q = search.value.toLowerCase();
+    cards.forEach(function (card) {
+      var text = (card.dataset.pattern + "\n" + card.dataset.title).toLowerCase();
+      var match = text.indexOf(q) >= 0 &&
+        (flavor.value === "" || card.dataset.flavor === flavor.value);
+      card.style.display = match ? "" : "none";
+    });
+  }
+  search.addEventListener("input", apply);
+  flavor.addEventListener("change", apply);
+})();
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,34 @@
+package output
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderGalleryHTML(t *testing.T) {
+	got, err := RenderGalleryHTML([]GalleryEntry{
+		{Pattern: `a+<b>`, Flavor: "pcre", Title: "Tags", Notes: []string{"One <b> only"}, File: "p-1.svg", SVG: []byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`)},
+		{Pattern: `a(`, Flavor: "javascript", Error: "Parse error: no match found"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		GalleryGenerator,
+		`2 patterns, 1 failed to render`,
+		`<option>javascript</option>`,
+		`<option>pcre</option>`,
+		`href="p-1.svg"`,
+		`src="data:image/svg&#43;xml;base64,`,
+		`<code>a&#43;&lt;b&gt;</code>`,
+		`<div class="card failed" data-pattern="a(" data-title="" data-flavor="javascript">`,
+		`<h2>Tags</h2>`,
+		`<li>One &lt;b&gt; only</li>`,
+		`Parse error: no match found`,
+		`id="search"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("gallery missing %q", want)
+		}
+	}
+}
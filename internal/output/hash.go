@@ -0,0 +1,58 @@
+package output
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+)
+
+// hashSchema versions the canonical form fed to Hash. Bump it whenever
+// the JSON schema or the canonicalization rules change, so stored
+// hashes are invalidated deliberately rather than by accident.
+const hashSchema = "regolith-ast-v1"
+
+// Hash returns a hex SHA-256 of the canonical form of root. Two
+// patterns hash equal when they parse to the same structure, however
+// they were spelled: a{1,} and a+ match, and so do /x/gi and /x/ig.
+// Docs pipelines can compare hashes to decide whether a diagram needs
+// regenerating, without churning on cosmetic edits.
+//
+// The canonical form is the JSON schema that RenderJSON emits (compact,
+// with map keys sorted by encoding/json), with the flag string sorted
+// and deduplicated. The pattern text and flavor name are not hashed, so
+// flavor aliases such as gnugrep and gnugrep-bre hash the same.
+func Hash(root *ast.Regexp) (string, error) {
+	canon := *root
+	canon.Flags = canonicalFlags(root.Flags)
+	b, err := json.Marshal(convertRegexp(&canon, true))
+	if err != nil {
+		return "", fmt.Errorf("json marshal: %w", err)
+	}
+	h := sha256.New()
+	h.Write([]byte(hashSchema))
+	h.Write([]byte{0})
+	h.Write(b)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// canonicalFlags sorts and deduplicates a flag string. Flag order is
+// never significant in any supported flavor.
+func canonicalFlags(flags string) string {
+	if len(flags) < 2 {
+		return flags
+	}
+	chars := strings.Split(flags, "")
+	sort.Strings(chars)
+	out := chars[:1]
+	for _, c := range chars[1:] {
+		if c != out[len(out)-1] {
+			out = append(out, c)
+		}
+	}
+	return strings.Join(out, "")
+}
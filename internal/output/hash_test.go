@@ -0,0 +1,58 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/0x4d5352/regolith/internal/parser"
+)
+
+func mustHash(t *testing.T, pattern string) string {
+	t.Helper()
+	root, err := parser.ParseRegex(pattern)
+	if err != nil {
+		t.Fatalf("parse %q: %v", pattern, err)
+	}
+	h, err := Hash(root)
+	if err != nil {
+		t.Fatalf("hash %q: %v", pattern, err)
+	}
+	return h
+}
+
+func TestHashStableAcrossSpelling(t *testing.T) {
+	pairs := [][2]string{
+		{"a{1,}", "a+"},
+		{"a{0,1}", "a?"},
+		{"/x/gi", "/x/ig"},
+		{"/x/gig", "/x/gi"},
+	}
+	for _, p := range pairs {
+		if mustHash(t, p[0]) != mustHash(t, p[1]) {
+			t.Errorf("expected %q and %q to hash equal", p[0], p[1])
+		}
+	}
+}
+
+func TestHashDistinguishesStructure(t *testing.T) {
+	pairs := [][2]string{
+		{"a+", "a*"},
+		{"a|b", "b|a"},
+		{"(a)", "(?:a)"},
+		{"/x/g", "/x/i"},
+	}
+	for _, p := range pairs {
+		if mustHash(t, p[0]) == mustHash(t, p[1]) {
+			t.Errorf("expected %q and %q to hash differently", p[0], p[1])
+		}
+	}
+}
+
+func TestHashFormat(t *testing.T) {
+	h := mustHash(t, "abc")
+	if len(h) != 64 {
+		t.Errorf("expected 64 hex digits, got %d: %s", len(h), h)
+	}
+	if h != mustHash(t, "abc") {
+		t.Error("hash must be deterministic")
+	}
+}
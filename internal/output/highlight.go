@@ -0,0 +1,106 @@
+package output
+
+import (
+	"io"
+	"strings"
+
+	"github.com/muesli/termenv"
+
+	"github.com/0x4d5352/regolith/internal/flavor"
+)
+
+// groupColors colors brackets by how deeply they nest, cycling when a
+// pattern nests deeper than there are colors. Both brackets of a pair
+// get the same color, so a reader can find an opener's closer by eye.
+var groupColors = []termenv.ANSIColor{
+	termenv.ANSIColor(5),  // magenta
+	termenv.ANSIColor(4),  // blue
+	termenv.ANSIColor(13), // bright magenta
+	termenv.ANSIColor(12), // bright blue
+}
+
+// Highlight writes a pattern back from its tokens (see flavor.Tokens)
+// with ANSI coloring by kind: escapes green, classes cyan, quantifiers
+// yellow, anchors bright cyan, comments and delimiters faint, and
+// literals plain. Group brackets are bold and colored by nesting depth,
+// as is each | with the group it divides; a bracket with no partner is
+// shown reversed in red. With an Ascii profile, or a nil co, the
+// pattern comes back unchanged.
+func Highlight(tokens []flavor.Token, co *termenv.Output) string {
+	if co == nil {
+		co = termenv.NewOutput(io.Discard, termenv.WithProfile(termenv.Ascii))
+	}
+	unmatched := unmatchedBrackets(tokens)
+	var b strings.Builder
+	depth := 0
+	for i, tok := range tokens {
+		s := co.String(tok.Text)
+		switch tok.Kind {
+		case flavor.TokenEscape:
+			s = s.Foreground(termenv.ANSIColor(2))
+		case flavor.TokenClass:
+			s = s.Foreground(termenv.ANSIColor(6))
+		case flavor.TokenQuantifier:
+			s = s.Foreground(termenv.ANSIColor(3))
+		case flavor.TokenAnchor:
+			s = s.Foreground(termenv.ANSIColor(14))
+		case flavor.TokenComment, flavor.TokenDelimiter:
+			s = s.Faint()
+		case flavor.TokenAlternation:
+			if depth > 0 {
+				s = s.Bold().Foreground(groupColors[(depth-1)%len(groupColors)])
+			} else {
+				s = s.Bold()
+			}
+		case flavor.TokenGroup:
+			switch balance := bracketBalance(tok.Text); {
+			case unmatched[i]:
+				s = s.Bold().Reverse().Foreground(termenv.ANSIColor(1))
+			case balance < 0:
+				s = s.Bold().Foreground(groupColors[(depth-1)%len(groupColors)])
+				depth--
+			case balance > 0:
+				depth++
+				s = s.Bold().Foreground(groupColors[(depth-1)%len(groupColors)])
+			default:
+				s = s.Bold().Foreground(groupColors[depth%len(groupColors)])
+			}
+		}
+		b.WriteString(s.String())
+	}
+	return b.String()
+}
+
+// unmatchedBrackets returns the indexes of the group tokens in tokens
+// that open a group never closed or close one never opened.
+func unmatchedBrackets(tokens []flavor.Token) map[int]bool {
+	unmatched := map[int]bool{}
+	var open []int
+	for i, tok := range tokens {
+		if tok.Kind != flavor.TokenGroup {
+			continue
+		}
+		switch bracketBalance(tok.Text) {
+		case -1:
+			if len(open) == 0 {
+				unmatched[i] = true
+			} else {
+				open = open[:len(open)-1]
+			}
+		case 1:
+			open = append(open, i)
+		}
+	}
+	for _, i := range open {
+		unmatched[i] = true
+	}
+	return unmatched
+}
+
+// bracketBalance returns +1 for a group token that opens a group a
+// later token closes, such as ( or (?(1), -1 for one that closes a
+// group, ) or a POSIX basic regex's \), and 0 for one that closes
+// itself, such as the inline modifier (?i) or the verb (*SKIP).
+func bracketBalance(text string) int {
+	return strings.Count(text, "(") - strings.Count(text, ")")
+}
@@ -0,0 +1,44 @@
+package output
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/muesli/termenv"
+
+	"github.com/0x4d5352/regolith/internal/flavor"
+)
+
+func TestHighlight(t *testing.T) {
+	tokens := []flavor.Token{
+		{Kind: flavor.TokenGroup, Text: "(?(1)"},
+		{Kind: flavor.TokenGroup, Text: "("},
+		{Kind: flavor.TokenEscape, Text: `\d`},
+		{Kind: flavor.TokenGroup, Text: ")"},
+		{Kind: flavor.TokenAlternation, Text: "|"},
+		{Kind: flavor.TokenGroup, Text: "(?i)"},
+		{Kind: flavor.TokenGroup, Text: ")"},
+		{Kind: flavor.TokenGroup, Text: ")"},
+	}
+	if got := Highlight(tokens, nil); got != `(?(1)(\d)|(?i)))` {
+		t.Errorf("Highlight without color = %q, want the pattern unchanged", got)
+	}
+
+	co := termenv.NewOutput(io.Discard, termenv.WithProfile(termenv.ANSI))
+	got := Highlight(tokens, co)
+	for _, want := range []string{
+		"\x1b[1;35m(?(1)\x1b[0m", // depth 1
+		"\x1b[1;34m(\x1b[0m",     // depth 2
+		"\x1b[32m\\d\x1b[0m",     // escape
+		"\x1b[1;34m)\x1b[0m",     // closes depth 2
+		"\x1b[1;35m|\x1b[0m",     // divides the depth 1 group
+		"\x1b[1;34m(?i)\x1b[0m",  // inside depth 1, opens nothing
+		"\x1b[1;35m)\x1b[0m",     // closes depth 1
+		"\x1b[1;7;31m)\x1b[0m",   // closes nothing
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Highlight = %q, missing %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package output
+
+import (
+	"strings"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+)
+
+// Hovercard is what the interactive HTML diagram shows for one node:
+// the fragment it was drawn from, a plain-English explanation, notes on
+// how the flavor treats it, and where to read more.
+type Hovercard struct {
+	Fragment    string
+	Explanation string
+	Notes       []string
+	Docs        string // Reference page URL
+}
+
+// DescribeNode builds the hovercard for n under the named flavor. The
+// explanation is the one the Markdown outline gives, without its
+// markup; the fragment comes from Source.
+func DescribeNode(n ast.Node, flavorName string) Hovercard {
+	w := &markdownWriter{buf: &strings.Builder{}}
+	var explain string
+	switch v := n.(type) {
+	case *ast.Charset:
+		w.renderCharsetItems(0, v)
+		var items []string
+		for _, line := range strings.Split(strings.TrimSpace(w.buf.String()), "\n") {
+			items = append(items, strings.TrimPrefix(strings.TrimSpace(line), "- "))
+		}
+		explain = w.charsetHeader(v, nil) + " " + strings.Join(items, "; ")
+	case *ast.Subexp:
+		explain = w.subexpHeader(v, nil)
+	case *ast.Conditional, *ast.BranchReset, *ast.BalancedGroup:
+		explain = w.describeComplexHeader(&ast.MatchFragment{Content: n})
+	case *ast.CharsetRange:
+		explain = "Matches " + w.describeRange(v)
+	case *ast.POSIXClass:
+		explain = "Matches " + w.describePOSIXClass(v)
+	default:
+		explain = w.describeNode(n)
+	}
+	return Hovercard{
+		Fragment:    Source(n),
+		Explanation: strings.NewReplacer("**", "", "`", "").Replace(explain),
+		Notes:       flavorNotes[noteKey(n)][flavorName],
+		Docs:        docsURL(n, flavorName),
+	}
+}
+
+// noteKey names the construct n is for the flavorNotes table.
+func noteKey(n ast.Node) string {
+	switch v := n.(type) {
+	case *ast.AnyCharacter:
+		return "dot"
+	case *ast.Anchor:
+		return "anchor:" + v.AnchorType
+	case *ast.Escape:
+		return "escape:" + v.EscapeType
+	case *ast.Subexp:
+		return "group:" + v.GroupType
+	case *ast.BackReference:
+		if v.Name != "" {
+			return "named-backref"
+		}
+		return "backref"
+	case *ast.UnicodePropertyEscape:
+		return "unicode-property"
+	case *ast.POSIXClass:
+		return "posix-class"
+	}
+	return ""
+}
+
+// flavorNotes maps a construct (see noteKey) and flavor to what a
+// reader coming from another flavor should know about it.
+var flavorNotes = map[string]map[string][]string{
+	"dot": {
+		"javascript":  {"Does not match line terminators unless the s (dotAll) flag is set."},
+		"java":        {"Does not match line terminators unless DOTALL or (?s) is set."},
+		"dotnet":      {`Does not match \n unless RegexOptions.Singleline or (?s) is set.`},
+		"pcre":        {"Does not match a newline unless PCRE2_DOTALL or (?s) is set."},
+		"posix-ere":   {"Matches any character; newline handling is up to the tool applying the pattern."},
+		"posix-bre":   {"Matches any character; newline handling is up to the tool applying the pattern."},
+		"gnugrep-ere": {"grep matches line by line, so there is never a newline to match."},
+		"gnugrep-bre": {"grep matches line by line, so there is never a newline to match."},
+		"gnused":      {"Also matches the newlines N, G and H leave in the pattern space, unless the M flag is set."},
+		"gnused-ere":  {"Also matches the newlines N, G and H leave in the pattern space, unless the M flag is set."},
+		"golang":      {`Does not match \n unless the s flag is set with (?s).`},
+	},
+	"anchor:start": {
+		"javascript": {"Matches only at the start of input unless the m flag is set."},
+		"java":       {"Matches only at the start of input unless MULTILINE or (?m) is set."},
+		"dotnet":     {"Matches only at the start of input unless RegexOptions.Multiline or (?m) is set."},
+		"pcre":       {"Matches only at the start of the subject unless PCRE2_MULTILINE or (?m) is set."},
+		"posix-bre":  {"Is an anchor only at the start of the pattern (in some implementations, of a subexpression); elsewhere it is a literal ^."},
+		"golang":     {"Matches only at the start of the text unless the m flag is set with (?m)."},
+		"gnused":     {"Is an anchor only where the regex, a \\( \\) group or a \\| branch starts, or after another anchor; elsewhere it is a literal ^. With the M flag it also matches after each newline in the pattern space."},
+		"gnused-ere": {"Matches at the start of the pattern space, and with the M flag after each newline in it."},
+	},
+	"anchor:end": {
+		"javascript": {"Matches only at the end of input unless the m flag is set; it never matches before a final newline."},
+		"java":       {"Also matches before a final line terminator; with MULTILINE it matches at every line end."},
+		"dotnet":     {`Also matches before a final \n; with RegexOptions.Multiline it matches at every line end.`},
+		"pcre":       {"Also matches before a final newline unless PCRE2_DOLLAR_ENDONLY is set; with (?m) it matches at every line end."},
+		"posix-bre":  {"Is an anchor only at the end of the pattern (in some implementations, of a subexpression); elsewhere it is a literal $."},
+		"golang":     {`Matches only at the very end of the text, never before a final newline, unless the m flag is set with (?m).`},
+		"gnused":     {"Is an anchor only where the regex, a \\( \\) group or a \\| branch ends; elsewhere it is a literal $. With the M flag it also matches before each newline in the pattern space."},
+		"gnused-ere": {"Matches at the end of the pattern space, and with the M flag before each newline in it."},
+	},
+	"anchor:string_start": {
+		"gnused":     {"\\` matches only at the start of the pattern space, even with the M flag."},
+		"gnused-ere": {"\\` matches only at the start of the pattern space, even with the M flag."},
+	},
+	"escape:newline": {
+		"gnused":     {"sed strips the newline that ends each input line; \\n matches the ones N, G and H put into the pattern space."},
+		"gnused-ere": {"sed strips the newline that ends each input line; \\n matches the ones N, G and H put into the pattern space."},
+	},
+	"anchor:word_boundary": {
+		"javascript": {"Word characters are ASCII [A-Za-z0-9_] only."},
+		"dotnet":     {"Word characters include Unicode letters and digits unless RegexOptions.ECMAScript is set."},
+		"pcre":       {"Word characters are ASCII unless PCRE2_UCP or (*UCP) is set."},
+		"golang":     {"Word characters are ASCII [0-9A-Za-z_] only."},
+	},
+	"escape:digit": {
+		"javascript": {"Matches ASCII 0-9 only, even with the u flag."},
+		"java":       {"Matches ASCII 0-9 only unless UNICODE_CHARACTER_CLASS or (?U) is set."},
+		"dotnet":     {"Matches any Unicode decimal digit (\\p{Nd}) unless RegexOptions.ECMAScript is set."},
+		"pcre":       {"Matches ASCII 0-9 only unless PCRE2_UCP or (*UCP) is set."},
+		"golang":     {`Matches ASCII 0-9 only; use \p{Nd} for any decimal digit.`},
+	},
+	"escape:word": {
+		"javascript": {"Matches ASCII [A-Za-z0-9_] only."},
+		"java":       {"Matches ASCII [a-zA-Z_0-9] only unless UNICODE_CHARACTER_CLASS or (?U) is set."},
+		"dotnet":     {"Matches Unicode letters, digits and connector punctuation unless RegexOptions.ECMAScript is set."},
+		"pcre":       {"Matches ASCII word characters only unless PCRE2_UCP or (*UCP) is set."},
+		"golang":     {"Matches ASCII [0-9A-Za-z_] only."},
+	},
+	"escape:whitespace": {
+		"javascript": {"Matches Unicode whitespace, including U+00A0 and U+FEFF."},
+		"java":       {"Matches [ \\t\\n\\x0B\\f\\r] only unless UNICODE_CHARACTER_CLASS or (?U) is set."},
+	},
+	"group:named_capture": {
+		"javascript": {`Reference it later with \k<name>; the name also appears in match.groups.`},
+		"java":       {`Reference it later with \k<name>; names must start with a letter and contain only ASCII letters and digits.`},
+		"dotnet":     {`Named groups are numbered after all unnamed ones; reference it later with \k<name>.`},
+		"pcre":       {`(?P<name>...) and (?'name'...) are equivalent spellings; reference it later with \k<name> or (?P=name).`},
+		"golang":     {"(?P<name>...) and, since Go 1.22, (?<name>...) are equivalent; there are no back-references, so read the group with SubexpIndex."},
+	},
+	"group:positive_lookbehind": {
+		"javascript": {"Any pattern may appear inside, including unbounded repetition (ES2018)."},
+		"java":       {"The contents must have a bounded maximum length."},
+		"dotnet":     {"Any pattern may appear inside, including unbounded repetition."},
+		"pcre":       {"Each alternative must have a bounded length (fixed length before PCRE2 10.43)."},
+	},
+	"group:negative_lookbehind": {
+		"javascript": {"Any pattern may appear inside, including unbounded repetition (ES2018)."},
+		"java":       {"The contents must have a bounded maximum length."},
+		"dotnet":     {"Any pattern may appear inside, including unbounded repetition."},
+		"pcre":       {"Each alternative must have a bounded length (fixed length before PCRE2 10.43)."},
+	},
+	"group:atomic": {
+		"java": {"Once the group has matched, the engine never backtracks into it; a possessive quantifier is shorthand for one."},
+		"pcre": {"Once the group has matched, the engine never backtracks into it; a possessive quantifier is shorthand for one."},
+	},
+	"backref": {
+		"javascript":  {"A reference to a group that has not matched matches the empty string."},
+		"java":        {"A reference to a group that has not matched fails."},
+		"dotnet":      {"A reference to a group that has not matched fails unless RegexOptions.ECMAScript is set."},
+		"pcre":        {`A reference to a group that has not matched fails. \g{n} avoids ambiguity with octal escapes.`},
+		"posix-bre":   {`Back-references \1 to \9 are the only ones POSIX defines.`},
+		"gnugrep-ere": {"Back-references are a GNU extension to ERE."},
+		"gnused":      {`In an s command's replacement, \1 to \9 insert what the groups matched; sed rejects one with no group behind it.`},
+		"gnused-ere":  {`Back-references are a GNU extension to ERE. In an s command's replacement, \1 to \9 insert what the groups matched.`},
+	},
+	"named-backref": {
+		"pcre": {`(?P=name), \k{name} and \g{name} are equivalent spellings.`},
+	},
+	"unicode-property": {
+		"javascript": {"Requires the u or v flag; without it \\p is a literal p."},
+		"java":       {"Accepts scripts, blocks (In...), categories and binary properties (Is...)."},
+		"dotnet":     {"Supports general categories and named blocks (IsGreek), but not scripts."},
+		"golang":     {`Supports general categories and scripts; one-letter categories may drop the braces (\pL), and \p{^Greek} negates.`},
+	},
+	"posix-class": {
+		"java": {`Java spells POSIX classes \p{Alpha} and friends; [:alpha:] is not a class.`},
+	},
+}
+
+// Reference pages for each flavor, by topic. Flavors whose manual is a
+// single page map every topic to it.
+var docsPages = map[string]map[string]string{
+	"javascript": {
+		"":           "https://developer.mozilla.org/en-US/docs/Web/JavaScript/Reference/Regular_expressions",
+		"dot":        "https://developer.mozilla.org/en-US/docs/Web/JavaScript/Reference/Regular_expressions/Wildcard",
+		"literal":    "https://developer.mozilla.org/en-US/docs/Web/JavaScript/Reference/Regular_expressions/Literal_character",
+		"anchor":     "https://developer.mozilla.org/en-US/docs/Web/JavaScript/Reference/Regular_expressions/Input_boundary_assertion",
+		"boundary":   "https://developer.mozilla.org/en-US/docs/Web/JavaScript/Reference/Regular_expressions/Word_boundary_assertion",
+		"class":      "https://developer.mozilla.org/en-US/docs/Web/JavaScript/Reference/Regular_expressions/Character_class",
+		"shorthand":  "https://developer.mozilla.org/en-US/docs/Web/JavaScript/Reference/Regular_expressions/Character_class_escape",
+		"escape":     "https://developer.mozilla.org/en-US/docs/Web/JavaScript/Reference/Regular_expressions/Character_escape",
+		"property":   "https://developer.mozilla.org/en-US/docs/Web/JavaScript/Reference/Regular_expressions/Unicode_character_class_escape",
+		"capture":    "https://developer.mozilla.org/en-US/docs/Web/JavaScript/Reference/Regular_expressions/Capturing_group",
+		"named":      "https://developer.mozilla.org/en-US/docs/Web/JavaScript/Reference/Regular_expressions/Named_capturing_group",
+		"group":      "https://developer.mozilla.org/en-US/docs/Web/JavaScript/Reference/Regular_expressions/Non-capturing_group",
+		"lookahead":  "https://developer.mozilla.org/en-US/docs/Web/JavaScript/Reference/Regular_expressions/Lookahead_assertion",
+		"lookbehind": "https://developer.mozilla.org/en-US/docs/Web/JavaScript/Reference/Regular_expressions/Lookbehind_assertion",
+		"backref":    "https://developer.mozilla.org/en-US/docs/Web/JavaScript/Reference/Regular_expressions/Backreference",
+		"named-ref":  "https://developer.mozilla.org/en-US/docs/Web/JavaScript/Reference/Regular_expressions/Named_backreference",
+		"modifier":   "https://developer.mozilla.org/en-US/docs/Web/JavaScript/Reference/Regular_expressions/Modifier",
+	},
+	"java": {
+		"": "https://docs.oracle.com/en/java/javase/21/docs/api/java.base/java/util/regex/Pattern.html",
+	},
+	"dotnet": {
+		"":           "https://learn.microsoft.com/en-us/dotnet/standard/base-types/regular-expression-language-quick-reference",
+		"anchor":     "https://learn.microsoft.com/en-us/dotnet/standard/base-types/anchors-in-regular-expressions",
+		"boundary":   "https://learn.microsoft.com/en-us/dotnet/standard/base-types/anchors-in-regular-expressions",
+		"class":      "https://learn.microsoft.com/en-us/dotnet/standard/base-types/character-classes-in-regular-expressions",
+		"shorthand":  "https://learn.microsoft.com/en-us/dotnet/standard/base-types/character-classes-in-regular-expressions",
+		"property":   "https://learn.microsoft.com/en-us/dotnet/standard/base-types/character-classes-in-regular-expressions",
+		"dot":        "https://learn.microsoft.com/en-us/dotnet/standard/base-types/character-classes-in-regular-expressions",
+		"escape":     "https://learn.microsoft.com/en-us/dotnet/standard/base-types/character-escapes-in-regular-expressions",
+		"literal":    "https://learn.microsoft.com/en-us/dotnet/standard/base-types/character-escapes-in-regular-expressions",
+		"capture":    "https://learn.microsoft.com/en-us/dotnet/standard/base-types/grouping-constructs-in-regular-expressions",
+		"named":      "https://learn.microsoft.com/en-us/dotnet/standard/base-types/grouping-constructs-in-regular-expressions",
+		"group":      "https://learn.microsoft.com/en-us/dotnet/standard/base-types/grouping-constructs-in-regular-expressions",
+		"lookahead":  "https://learn.microsoft.com/en-us/dotnet/standard/base-types/grouping-constructs-in-regular-expressions",
+		"lookbehind": "https://learn.microsoft.com/en-us/dotnet/standard/base-types/grouping-constructs-in-regular-expressions",
+		"backref":    "https://learn.microsoft.com/en-us/dotnet/standard/base-types/backreference-constructs-in-regular-expressions",
+		"named-ref":  "https://learn.microsoft.com/en-us/dotnet/standard/base-types/backreference-constructs-in-regular-expressions",
+		"modifier":   "https://learn.microsoft.com/en-us/dotnet/standard/base-types/miscellaneous-constructs-in-regular-expressions",
+	},
+	"pcre": {
+		"": "https://www.pcre.org/current/doc/html/pcre2pattern.html",
+	},
+	"posix-bre": {
+		"": "https://pubs.opengroup.org/onlinepubs/9799919799/basedefs/V1_chap09.html",
+	},
+	"posix-ere": {
+		"": "https://pubs.opengroup.org/onlinepubs/9799919799/basedefs/V1_chap09.html",
+	},
+	"gnugrep-bre": {
+		"": "https://www.gnu.org/software/grep/manual/html_node/Regular-Expressions.html",
+	},
+	"gnugrep-ere": {
+		"": "https://www.gnu.org/software/grep/manual/html_node/Regular-Expressions.html",
+	},
+	"golang": {
+		"": "https://pkg.go.dev/regexp/syntax",
+	},
+	"gnused": {
+		"": "https://www.gnu.org/software/sed/manual/html_node/BRE-syntax.html",
+	},
+	"gnused-ere": {
+		"": "https://www.gnu.org/software/sed/manual/html_node/ERE-syntax.html",
+	},
+	"vim": {
+		"": "https://vimhelp.org/pattern.txt.html",
+	},
+}
+
+// docsURL picks the reference page for n under the named flavor,
+// falling back to the flavor's overview page.
+func docsURL(n ast.Node, flavorName string) string {
+	pages := docsPages[flavorName]
+	if url, ok := pages[docsTopic(n)]; ok {
+		return url
+	}
+	return pages[""]
+}
+
+func docsTopic(n ast.Node) string {
+	switch v := n.(type) {
+	case *ast.AnyCharacter:
+		return "dot"
+	case *ast.Literal, *ast.QuotedLiteral:
+		return "literal"
+	case *ast.Anchor:
+		switch v.AnchorType {
+		case ast.AnchorWordBoundary, ast.AnchorNonWordBoundary:
+			return "boundary"
+		}
+		return "anchor"
+	case *ast.Charset, *ast.CharsetRange, *ast.POSIXClass:
+		return "class"
+	case *ast.Escape:
+		if _, ok := escapeShortCodes[v.EscapeType]; ok {
+			return "shorthand"
+		}
+		return "escape"
+	case *ast.UnicodePropertyEscape:
+		return "property"
+	case *ast.Subexp:
+		switch v.GroupType {
+		case ast.GroupCapture:
+			return "capture"
+		case ast.GroupNamedCapture:
+			return "named"
+		case ast.GroupPositiveLookahead, ast.GroupNegativeLookahead:
+			return "lookahead"
+		case ast.GroupPositiveLookbehind, ast.GroupNegativeLookbehind:
+			return "lookbehind"
+		}
+		return "group"
+	case *ast.BackReference:
+		if v.Name != "" {
+			return "named-ref"
+		}
+		return "backref"
+	case *ast.InlineModifier:
+		return "modifier"
+	}
+	return ""
+}
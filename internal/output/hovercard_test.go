@@ -0,0 +1,77 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+)
+
+func TestDescribeNode(t *testing.T) {
+	tests := []struct {
+		name     string
+		node     ast.Node
+		flavor   string
+		fragment string
+		explain  string
+		note     string
+		docs     string
+	}{
+		{
+			name:     "escape",
+			node:     &ast.Escape{EscapeType: "digit", Code: "d"},
+			flavor:   "javascript",
+			fragment: `\d`,
+			explain:  `Matches any digit \d (0-9)`,
+			note:     "ASCII 0-9 only",
+			docs:     "Character_class_escape",
+		},
+		{
+			name: "named group",
+			node: &ast.Subexp{GroupType: ast.GroupNamedCapture, Number: 1, Name: "y", Regexp: &ast.Regexp{
+				Matches: []*ast.Match{{Fragments: []*ast.MatchFragment{{Content: &ast.Literal{Text: "a"}}}}},
+			}},
+			flavor:   "dotnet",
+			fragment: `(?<y>a)`,
+			explain:  `Named capture group #1 "y"`,
+			note:     "numbered after all unnamed ones",
+			docs:     "grouping-constructs",
+		},
+		{
+			name: "charset",
+			node: &ast.Charset{Inverted: true, Items: []ast.CharsetItem{
+				&ast.CharsetRange{First: "0", Last: "9"}, &ast.CharsetLiteral{Text: "_"},
+			}},
+			flavor:   "pcre",
+			fragment: `[^0-9_]`,
+			explain:  "Matches any character NOT in: 0 to 9 (digits); _",
+			docs:     "pcre2pattern.html",
+		},
+		{
+			name:     "dot",
+			node:     &ast.AnyCharacter{},
+			flavor:   "gnugrep-ere",
+			fragment: ".",
+			explain:  "Matches any character",
+			note:     "line by line",
+			docs:     "gnu.org/software/grep",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := DescribeNode(tc.node, tc.flavor)
+			if h.Fragment != tc.fragment {
+				t.Errorf("Fragment = %q, want %q", h.Fragment, tc.fragment)
+			}
+			if !strings.HasPrefix(h.Explanation, tc.explain) {
+				t.Errorf("Explanation = %q, want prefix %q", h.Explanation, tc.explain)
+			}
+			if notes := strings.Join(h.Notes, "\n"); !strings.Contains(notes, tc.note) || (tc.note == "") != (notes == "") {
+				t.Errorf("Notes = %q, want %q", notes, tc.note)
+			}
+			if !strings.Contains(h.Docs, tc.docs) {
+				t.Errorf("Docs = %q, want %q", h.Docs, tc.docs)
+			}
+		})
+	}
+}
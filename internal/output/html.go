@@ -0,0 +1,199 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// htmlPage is the data behind htmlTemplate.
+type htmlPage struct {
+	Pattern string
+	Flavor  string
+	Pages   []template.HTML // Trusted: produced by our own renderer
+	Chars   []patternChar   // The pattern under the diagram, one element per character
+}
+
+// patternChar is one character of the pattern and its byte offset,
+// which the script compares with the nodes' data-src-start and
+// data-src-end.
+type patternChar struct {
+	At   int
+	Text string
+}
+
+// HTMLData returns the data-* attributes, without the prefix, that
+// carry a node's hovercard in an SVG diagram (see renderer.Group.Data).
+// RenderHTML's script reads them back; an SVG drawn without them still
+// renders, just without hovercards.
+func (h Hovercard) HTMLData() map[string]string {
+	data := map[string]string{
+		"fragment": h.Fragment,
+		"explain":  h.Explanation,
+	}
+	if len(h.Notes) > 0 {
+		data["notes"] = strings.Join(h.Notes, "\n")
+	}
+	if h.Docs != "" {
+		data["docs"] = h.Docs
+	}
+	return data
+}
+
+// RenderHTML renders an interactive page around the SVG diagram pages
+// of a pattern. Nodes carrying HTMLData show a hovercard with their
+// fragment, explanation, flavor notes and a reference link; the card
+// stays open while the pointer is over it, so the link can be
+// followed, and a click pins it.
+//
+// The pattern is shown again under the diagram. Nodes that also carry
+// src-start and src-end data light up their part of it while hovered,
+// and hovering a character of it opens the card of the smallest node
+// drawn from that character.
+func RenderHTML(pages []string, pattern, flavorName string) (string, error) {
+	page := htmlPage{Pattern: pattern, Flavor: formatFlavorName(flavorName)}
+	for at, r := range pattern {
+		page.Chars = append(page.Chars, patternChar{At: at, Text: string(r)})
+	}
+	for _, svg := range pages {
+		page.Pages = append(page.Pages, template.HTML(svg))
+	}
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, page); err != nil {
+		return "", fmt.Errorf("html render: %w", err)
+	}
+	return buf.String(), nil
+}
+
+var htmlTemplate = template.Must(template.New("diagram").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="generator" content="regolith">
+<title>{{.Pattern}} — regolith</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem; color: #222; }
+code { background: #f4f4f4; padding: 0.1rem 0.3rem; word-break: break-all; }
+.badge { background: #e0e7ff; color: #3730a3; border-radius: 999px; padding: 0.05rem 0.55rem; font-size: 0.85rem; }
+.diagram { overflow-x: auto; margin: 1.5rem 0; }
+.diagram svg { max-width: none; }
+.diagram [data-explain] { cursor: help; }
+.diagram .hovered { filter: drop-shadow(0 0 3px #3b82f6); }
+#hovercard { position: absolute; display: none; max-width: 26rem; background: #fff; border: 1px solid #ccc; border-radius: 6px; box-shadow: 0 4px 14px rgba(0, 0, 0, 0.15); padding: 0.6rem 0.8rem; font-size: 0.9rem; line-height: 1.4; z-index: 10; }
+#hovercard.pinned { border-color: #3b82f6; }
+#hovercard code { display: inline-block; margin-bottom: 0.35rem; }
+#hovercard ul { margin: 0.4rem 0 0; padding-left: 1.1rem; color: #555; }
+#hovercard a { display: inline-block; margin-top: 0.4rem; }
+#source { font-size: 1.1rem; white-space: pre-wrap; word-break: break-all; background: #f4f4f4; padding: 0.5rem 0.7rem; border-radius: 4px; }
+#source span[data-at] { cursor: help; }
+#source .lit { background: #bfdbfe; }
+</style>
+</head>
+<body>
+<h1><code>{{.Pattern}}</code></h1>
+<p><span class="badge">{{.Flavor}}</span> Hover over a part of the diagram to see what it does; click to pin the card.</p>
+{{- range .Pages}}
+<div class="diagram">{{.}}</div>
+{{- end}}
+<p>Hover over the pattern to find each part in the diagram.</p>
+<pre id="source">{{range .Chars}}<span data-at="{{.At}}">{{.Text}}</span>{{end}}</pre>
+<div id="hovercard" role="tooltip"></div>
+<script>
+(function () {
+  var card = document.getElementById("hovercard");
+  var source = document.getElementById("source");
+  var current = null, pinned = false, timer = null;
+  function node(el) { return el && el.closest ? el.closest(".diagram [data-explain]") : null; }
+  function light(n) {
+    var start = -1, end = -1;
+    if (n && n.dataset.srcStart !== undefined) {
+      start = +n.dataset.srcStart;
+      end = +n.dataset.srcEnd;
+    }
+    Array.prototype.forEach.call(source.children, function (c) {
+      var at = +c.dataset.at;
+      c.classList.toggle("lit", at >= start && at < end);
+    });
+  }
+  function at(offset) {
+    var best = null, size = Infinity;
+    Array.prototype.forEach.call(document.querySelectorAll(".diagram [data-src-start]"), function (n) {
+      var start = +n.dataset.srcStart, end = +n.dataset.srcEnd;
+      // Nested nodes come later, so <= picks the innermost of equals.
+      if (start <= offset && offset < end && end - start <= size) {
+        best = n;
+        size = end - start;
+      }
+    });
+    return best;
+  }
+  function show(n, x, y) {
+    if (current) current.classList.remove("hovered");
+    current = n;
+    n.classList.add("hovered");
+    light(n);
+    card.textContent = "";
+    var code = document.createElement("code");
+    code.textContent = n.dataset.fragment;
+    card.appendChild(code);
+    var p = document.createElement("div");
+    p.textContent = n.dataset.explain;
+    card.appendChild(p);
+    if (n.dataset.notes) {
+      var ul = document.createElement("ul");
+      n.dataset.notes.split("\n").forEach(function (note) {
+        var li = document.createElement("li");
+        li.textContent = note;
+        ul.appendChild(li);
+      });
+      card.appendChild(ul);
+    }
+    if (n.dataset.docs) {
+      var a = document.createElement("a");
+      a.href = n.dataset.docs;
+      a.target = "_blank";
+      a.rel = "noopener";
+      a.textContent = "Reference";
+      card.appendChild(a);
+    }
+    card.style.left = (x + 12) + "px";
+    card.style.top = (y + 12) + "px";
+    card.style.display = "block";
+  }
+  function hide() {
+    if (pinned) return;
+    card.style.display = "none";
+    if (current) current.classList.remove("hovered");
+    current = null;
+    light(null);
+  }
+  document.addEventListener("mouseover", function (e) {
+    if (pinned || card.contains(e.target)) { clearTimeout(timer); return; }
+    var n = source.contains(e.target) && e.target.dataset.at !== undefined ? at(+e.target.dataset.at) : node(e.target);
+    if (n) {
+      clearTimeout(timer);
+      if (n !== current) show(n, e.pageX, e.pageY);
+    } else {
+      clearTimeout(timer);
+      timer = setTimeout(hide, 300);
+    }
+  });
+  document.addEventListener("click", function (e) {
+    if (card.contains(e.target)) return;
+    var n = node(e.target);
+    pinned = false;
+    if (n) {
+      show(n, e.pageX, e.pageY);
+      pinned = true;
+      card.classList.add("pinned");
+    } else {
+      card.classList.remove("pinned");
+      hide();
+    }
+  });
+})();
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,32 @@
+package output
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderHTML(t *testing.T) {
+	svg := `<svg xmlns="http://www.w3.org/2000/svg"><g data-explain="x"></g></svg>`
+	got, err := RenderHTML([]string{svg, svg}, `a<b`, "pcre")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<title>a&lt;b — regolith</title>`,
+		`<span class="badge">PCRE</span>`,
+		`<div class="diagram">` + svg + `</div>`,
+		`id="hovercard"`,
+		`<pre id="source"><span data-at="0">a</span><span data-at="1">&lt;</span>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("page missing %q", want)
+		}
+	}
+	if n := strings.Count(got, `<div class="diagram">`); n != 2 {
+		t.Errorf("page has %d diagrams, want 2", n)
+	}
+	data := Hovercard{Fragment: "a", Explanation: "Matches a"}.HTMLData()
+	if _, ok := data["notes"]; ok {
+		t.Errorf("HTMLData without notes = %v, want no notes key", data)
+	}
+}
@@ -0,0 +1,53 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/0x4d5352/regolith/internal/analyzer"
+	"github.com/0x4d5352/regolith/internal/ast"
+	"github.com/0x4d5352/regolith/internal/teach"
+)
+
+// RenderLessonMarkdown writes the handout for one `regolith teach`
+// example: what it teaches, its diagram (the SVG file diagram, relative
+// to the handout), sample input, the RenderMarkdown outline of root,
+// and the analyzer's findings.
+func RenderLessonMarkdown(ex teach.Example, root *ast.Regexp, report *analyzer.AnalysisReport, flavorName, diagram string) string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "# %s (%s)\n\n", ex.Title, formatFlavorName(flavorName))
+	fmt.Fprintf(&buf, "%s\n\n", ex.Lesson)
+	fmt.Fprintf(&buf, "```\n%s\n```\n\n", ex.Pattern)
+	if ex.Limit != "" {
+		fmt.Fprintf(&buf, "> **Note:** %s\n\n", ex.Limit)
+	}
+	fmt.Fprintf(&buf, "![%s diagram](%s)\n\n", ex.Title, diagram)
+
+	writeSamples := func(heading string, samples []string) {
+		if len(samples) == 0 {
+			return
+		}
+		fmt.Fprintf(&buf, "## %s\n\n", heading)
+		for _, s := range samples {
+			fmt.Fprintf(&buf, "- `%s`\n", s)
+		}
+		buf.WriteByte('\n')
+	}
+	writeSamples("Matches", ex.Matches)
+	writeSamples("Does not match", ex.Rejects)
+
+	buf.WriteString("## How it reads\n\n")
+	w := &markdownWriter{buf: &buf}
+	for _, opt := range root.Options {
+		w.renderPatternOption(0, opt)
+	}
+	w.renderRegexp(0, root, true)
+
+	if report != nil && len(report.Findings) > 0 {
+		buf.WriteString("\n## Findings\n\n")
+		for _, f := range report.Findings {
+			fmt.Fprintf(&buf, "- **%s** (%s): %s\n", f.Title, f.Severity, f.Description)
+		}
+	}
+	return buf.String()
+}
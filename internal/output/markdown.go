@@ -16,6 +16,10 @@ var flavorDisplayNames = map[string]string{
 	"posix-ere":   "POSIX ERE",
 	"gnugrep-bre": "GNU grep BRE",
 	"gnugrep-ere": "GNU grep ERE",
+	"golang":      "Go (RE2)",
+	"gnused":      "GNU sed",
+	"gnused-ere":  "GNU sed -E",
+	"vim":         "Vim",
 }
 
 func formatFlavorName(name string) string {
@@ -36,6 +40,8 @@ var anchorDescriptions = map[string]string{
 	ast.AnchorWordStart:               "Asserts start of word",
 	ast.AnchorWordEnd:                 "Asserts end of word",
 	ast.AnchorGraphemeClusterBoundary: "Asserts grapheme cluster boundary",
+	ast.AnchorMatchStart:              "Starts the match here",
+	ast.AnchorMatchEnd:                "Ends the match here",
 }
 
 // escapeInfo maps escape type to [shortName, detail].
@@ -352,6 +358,10 @@ func (w *markdownWriter) describeNode(n ast.Node) string {
 }
 
 func describeEscape(e *ast.Escape) string {
+	if e.EscapeType == "nonterminal" {
+		// Only produced by importing a railroad-diagrams NonTerminal.
+		return fmt.Sprintf("Matches rule `%s`", e.Value)
+	}
 	if info, ok := escapeDescriptions[e.EscapeType]; ok {
 		if code, ok2 := escapeShortCodes[e.EscapeType]; ok2 {
 			if info.detail != "" {
@@ -562,6 +572,8 @@ func (w *markdownWriter) subexpHeader(s *ast.Subexp, repeat *ast.Repeat) string
 		header = fmt.Sprintf("**Capture group #%d** -- captures matched text for back-reference as `\\%d`%s", s.Number, s.Number, suffix)
 	case ast.GroupNamedCapture:
 		header = fmt.Sprintf("**Named capture group #%d %q** -- captures matched text for back-reference as `\\%d` or by name%s", s.Number, s.Name, s.Number, suffix)
+	case ast.GroupSubroutine:
+		header = fmt.Sprintf("**Subroutine call to group #%d** -- matches what that group's pattern matches, written out here%s", s.Number, suffix)
 	default:
 		annotation := ""
 		if ann, ok := groupAnnotations[s.GroupType]; ok {
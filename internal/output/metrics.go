@@ -0,0 +1,81 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/0x4d5352/regolith/internal/analyzer"
+)
+
+// metricsJSON is the JSON form of analyzer.Metrics, keyed by the names
+// in analyzer.MetricNames.
+type metricsJSON struct {
+	Nodes       int `json:"nodes"`
+	Depth       int `json:"depth"`
+	Branches    int `json:"branches"`
+	Quantifiers int `json:"quantifiers"`
+	Groups      int `json:"groups"`
+}
+
+// metricsDocument is the JSON envelope for `analyze --stats`.
+type metricsDocument struct {
+	Pattern string       `json:"pattern"`
+	Flavor  string       `json:"flavor"`
+	Metrics *metricsJSON `json:"metrics"`
+}
+
+func convertMetrics(m *analyzer.Metrics) *metricsJSON {
+	return &metricsJSON{
+		Nodes:       m.Nodes,
+		Depth:       m.MaxDepth,
+		Branches:    m.Branches,
+		Quantifiers: m.Quantifiers,
+		Groups:      m.CaptureGroups,
+	}
+}
+
+// metricLabels describes each of analyzer.MetricNames for the text
+// report.
+var metricLabels = map[string]string{
+	"nodes":       "constructs",
+	"depth":       "deepest group nesting",
+	"branches":    "alternation branches",
+	"quantifiers": "quantified items",
+	"groups":      "capturing groups",
+}
+
+// RenderMetricsText writes the complexity metrics of report, one per
+// line, as a Markdown table when markdown is set.
+func RenderMetricsText(report *analyzer.AnalysisReport, markdown bool) string {
+	var sb strings.Builder
+	if markdown {
+		fmt.Fprintf(&sb, "# Metrics: `%s`\n\n", report.Pattern)
+		fmt.Fprintf(&sb, "**Flavor:** %s\n\n", formatFlavorName(report.Flavor))
+		sb.WriteString("| Metric | Value | |\n|---|---:|---|\n")
+	} else {
+		fmt.Fprintf(&sb, "Metrics: %s  (%s)\n\n", report.Pattern, report.Flavor)
+	}
+	for _, name := range analyzer.MetricNames {
+		v, _ := report.Metrics.Metric(name)
+		if markdown {
+			fmt.Fprintf(&sb, "| %s | %d | %s |\n", name, v, metricLabels[name])
+		} else {
+			fmt.Fprintf(&sb, "  %-12s %4d  %s\n", name, v, metricLabels[name])
+		}
+	}
+	return sb.String()
+}
+
+// RenderMetricsJSON serializes the complexity metrics of report.
+func RenderMetricsJSON(report *analyzer.AnalysisReport) (string, error) {
+	b, err := json.MarshalIndent(metricsDocument{
+		Pattern: report.Pattern,
+		Flavor:  report.Flavor,
+		Metrics: convertMetrics(report.Metrics),
+	}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("json marshal: %w", err)
+	}
+	return string(b), nil
+}
@@ -0,0 +1,249 @@
+package output
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/0x4d5352/regolith/internal/analyzer"
+	"github.com/0x4d5352/regolith/internal/audit"
+)
+
+// SARIF 2.1.0, the interchange format GitHub code scanning and most CI
+// security dashboards ingest. Only the subset those consumers read is
+// modelled here.
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	toolURI      = "https://github.com/0x4d5352/regolith"
+
+	// invalidPatternRule is the rule reported for sites whose flavor
+	// rejected the pattern. It is not an analyzer rule, since there is
+	// no AST to analyze.
+	invalidPatternRule = "invalid-pattern"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Version        string      `json:"version,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string              `json:"id"`
+	ShortDescription     sarifText           `json:"shortDescription"`
+	FullDescription      *sarifText          `json:"fullDescription,omitempty"`
+	Help                 *sarifText          `json:"help,omitempty"`
+	DefaultConfiguration sarifConfiguration  `json:"defaultConfiguration"`
+	Properties           sarifRuleProperties `json:"properties"`
+}
+
+type sarifText struct {
+	Text string `json:"text"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+// sarifRuleProperties carries GitHub's extensions: tags for filtering,
+// and security-severity, a CVSS-style score that GitHub maps to its
+// low/medium/high/critical labels.
+type sarifRuleProperties struct {
+	Tags             []string `json:"tags"`
+	SecuritySeverity string   `json:"security-severity,omitempty"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             sarifText         `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// sarifLevel maps a severity to SARIF's three result levels.
+func sarifLevel(sev analyzer.Severity) string {
+	switch sev {
+	case analyzer.SeverityInfo:
+		return "note"
+	case analyzer.SeverityWarning:
+		return "warning"
+	default:
+		return "error"
+	}
+}
+
+// securitySeverity scores backtracking findings for GitHub's security
+// view. Other categories are code-quality hints and get no score, so
+// GitHub lists them as ordinary alerts rather than security issues.
+func securitySeverity(cat analyzer.Category, sev analyzer.Severity) string {
+	if cat != analyzer.CategoryBacktracking {
+		return ""
+	}
+	switch sev {
+	case analyzer.SeverityCritical:
+		return "9.0"
+	case analyzer.SeverityError:
+		return "7.5"
+	case analyzer.SeverityWarning:
+		return "5.0"
+	default:
+		return "2.0"
+	}
+}
+
+// RenderAuditSARIF serializes an audit report as a SARIF 2.1.0 log with
+// one result per finding and one per invalid pattern. Relative file
+// paths are emitted relative to %SRCROOT%, which is what code scanning
+// expects when the audit runs from the repository root.
+//
+// Each result carries a partial fingerprint over the file, rule,
+// pattern text, and the finding's ordinal among that rule's findings on
+// the pattern, so an alert keeps its identity when unrelated edits
+// move the pattern to another line.
+func RenderAuditSARIF(report *audit.Report, toolVersion string) (string, error) {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "regolith",
+			InformationURI: toolURI,
+			Version:        toolVersion,
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+	ruleIndex := make(map[string]int)
+	addRule := func(r sarifRule) int {
+		if i, ok := ruleIndex[r.ID]; ok {
+			return i
+		}
+		ruleIndex[r.ID] = len(run.Tool.Driver.Rules)
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, r)
+		return ruleIndex[r.ID]
+	}
+
+	for _, e := range report.Entries {
+		loc := sarifSiteLocation(e)
+		if e.ParseError != nil {
+			idx := addRule(sarifRule{
+				ID:                   invalidPatternRule,
+				ShortDescription:     sarifText{"Pattern does not parse"},
+				FullDescription:      &sarifText{"The pattern is not valid in the flavor inferred from its source language."},
+				DefaultConfiguration: sarifConfiguration{Level: "error"},
+				Properties:           sarifRuleProperties{Tags: []string{"correctness"}},
+			})
+			run.Results = append(run.Results, sarifResult{
+				RuleID:              invalidPatternRule,
+				RuleIndex:           idx,
+				Level:               "error",
+				Message:             sarifText{fmt.Sprintf("Invalid %s pattern %q: %s", e.Flavor, e.Pattern, ParseError(e.ParseError).Message)},
+				Locations:           []sarifLocation{loc},
+				PartialFingerprints: sarifFingerprint(e, invalidPatternRule, 1),
+			})
+			continue
+		}
+		seen := make(map[string]int)
+		for _, f := range e.Report.Findings {
+			seen[f.ID]++
+			rule := sarifRule{
+				ID:                   f.ID,
+				ShortDescription:     sarifText{f.Title},
+				DefaultConfiguration: sarifConfiguration{Level: sarifLevel(f.Severity)},
+				Properties: sarifRuleProperties{
+					Tags:             []string{string(f.Category)},
+					SecuritySeverity: securitySeverity(f.Category, f.Severity),
+				},
+			}
+			// A finding's Description may quote its own pattern, so the
+			// rule, shared by every result, gets the general one.
+			if d := analyzer.RuleDescription(f.ID); d != "" {
+				rule.FullDescription = &sarifText{d}
+			}
+			if f.Suggestion != "" {
+				rule.Help = &sarifText{f.Suggestion}
+			}
+			if rule.Properties.SecuritySeverity != "" {
+				rule.Properties.Tags = append(rule.Properties.Tags, "security")
+			}
+			msg := fmt.Sprintf("%s in %s pattern %q", f.Title, e.Flavor, e.Pattern)
+			if f.Description != "" {
+				msg += ": " + strings.TrimSuffix(f.Description, ".")
+			}
+			if f.Suggestion != "" {
+				msg += ". " + f.Suggestion
+			}
+			run.Results = append(run.Results, sarifResult{
+				RuleID:              f.ID,
+				RuleIndex:           addRule(rule),
+				Level:               sarifLevel(f.Severity),
+				Message:             sarifText{msg},
+				Locations:           []sarifLocation{loc},
+				PartialFingerprints: sarifFingerprint(e, f.ID, seen[f.ID]),
+			})
+		}
+	}
+
+	b, err := json.MarshalIndent(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("json marshal: %w", err)
+	}
+	return string(b), nil
+}
+
+func sarifSiteLocation(e *audit.Entry) sarifLocation {
+	art := sarifArtifactLocation{URI: e.File}
+	if path.IsAbs(e.File) || (len(e.File) > 1 && e.File[1] == ':') {
+		art.URI = "file:///" + strings.TrimPrefix(e.File, "/")
+	} else {
+		art.URI = strings.TrimPrefix(e.File, "./")
+		art.URIBaseID = "%SRCROOT%"
+	}
+	return sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+		ArtifactLocation: art,
+		Region:           sarifRegion{StartLine: e.Line, StartColumn: e.Column},
+	}}
+}
+
+func sarifFingerprint(e *audit.Entry, ruleID string, n int) map[string]string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "%s\x00%s\x00%s\x00%d", e.File, ruleID, e.Pattern, n))
+	return map[string]string{"regolithPattern/v1": hex.EncodeToString(sum[:16])}
+}
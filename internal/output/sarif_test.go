@@ -0,0 +1,145 @@
+package output
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/0x4d5352/regolith/internal/analyzer"
+	"github.com/0x4d5352/regolith/internal/audit"
+	"github.com/0x4d5352/regolith/internal/scan"
+)
+
+func TestRenderAuditSARIF(t *testing.T) {
+	report := audit.Run([]scan.Site{
+		{File: "src/a.js", Line: 3, Column: 9, Flavor: "javascript", Pattern: `^(a+)+$`},
+		{File: "/abs/b.js", Line: 7, Column: 2, Flavor: "javascript", Pattern: `(`},
+	}, audit.Options{})
+
+	got, err := RenderAuditSARIF(report, "1.2.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log struct {
+		Version string
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Version string
+					Rules   []struct {
+						ID         string
+						Properties struct {
+							Tags             []string
+							SecuritySeverity string `json:"security-severity"`
+						}
+					}
+				}
+			}
+			Results []struct {
+				RuleID    string
+				RuleIndex int
+				Level     string
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI       string
+							URIBaseID string `json:"uriBaseId"`
+						}
+						Region struct{ StartLine, StartColumn int }
+					}
+				}
+				PartialFingerprints map[string]string
+			}
+		}
+	}
+	if err := json.Unmarshal([]byte(got), &log); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, got)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || log.Runs[0].Tool.Driver.Version != "1.2.3" {
+		t.Fatalf("unexpected envelope:\n%s", got)
+	}
+	run := log.Runs[0]
+	rules := run.Tool.Driver.Rules
+
+	fingerprints := make(map[string]bool)
+	var sawNested, sawInvalid bool
+	for _, r := range run.Results {
+		if r.RuleIndex >= len(rules) || rules[r.RuleIndex].ID != r.RuleID {
+			t.Errorf("result %s has ruleIndex %d pointing elsewhere", r.RuleID, r.RuleIndex)
+		}
+		fp := r.PartialFingerprints["regolithPattern/v1"]
+		if fp == "" || fingerprints[fp] {
+			t.Errorf("result %s: missing or duplicate fingerprint %q", r.RuleID, fp)
+		}
+		fingerprints[fp] = true
+
+		loc := r.Locations[0].PhysicalLocation
+		switch r.RuleID {
+		case "nested-quantifier":
+			sawNested = true
+			if r.Level != "error" || rules[r.RuleIndex].Properties.SecuritySeverity == "" {
+				t.Errorf("nested-quantifier: level %s, security-severity %q", r.Level, rules[r.RuleIndex].Properties.SecuritySeverity)
+			}
+			if loc.ArtifactLocation.URI != "src/a.js" || loc.ArtifactLocation.URIBaseID != "%SRCROOT%" ||
+				loc.Region.StartLine != 3 || loc.Region.StartColumn != 9 {
+				t.Errorf("nested-quantifier location = %+v", loc)
+			}
+		case "invalid-pattern":
+			sawInvalid = true
+			if loc.ArtifactLocation.URI != "file:///abs/b.js" || loc.ArtifactLocation.URIBaseID != "" {
+				t.Errorf("invalid-pattern location = %+v", loc)
+			}
+		}
+	}
+	if !sawNested || !sawInvalid {
+		t.Errorf("missing results: nested=%v invalid=%v\n%s", sawNested, sawInvalid, got)
+	}
+}
+
+func TestRenderAuditSARIFRuleDescription(t *testing.T) {
+	report := audit.Run([]scan.Site{
+		{File: "a.js", Line: 1, Column: 1, Flavor: "javascript", Pattern: `^(\w+\s?)+$`},
+		{File: "b.js", Line: 1, Column: 1, Flavor: "javascript", Pattern: `^(a+)+$`},
+	}, audit.Options{})
+
+	got, err := RenderAuditSARIF(report, "1.2.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log struct {
+		Runs []struct {
+			Tool struct {
+				Driver struct {
+					Rules []struct {
+						ID              string
+						FullDescription struct{ Text string }
+					}
+				}
+			}
+			Results []struct {
+				RuleID  string
+				Message struct{ Text string }
+			}
+		}
+	}
+	if err := json.Unmarshal([]byte(got), &log); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, got)
+	}
+	run := log.Runs[0]
+	for _, r := range run.Tool.Driver.Rules {
+		if r.ID == "nested-quantifier" && r.FullDescription.Text != analyzer.RuleDescription("nested-quantifier") {
+			t.Errorf("rule fullDescription = %q, want the rule's own description", r.FullDescription.Text)
+		}
+	}
+	// Each result carries its own pattern's witness.
+	var witnesses []string
+	for _, r := range run.Results {
+		if r.RuleID != "nested-quantifier" {
+			continue
+		}
+		witnesses = append(witnesses, r.Message.Text)
+	}
+	if len(witnesses) != 2 || !strings.Contains(witnesses[0], "`\\w+`") || !strings.Contains(witnesses[1], "`a+`") {
+		t.Errorf("nested-quantifier messages lack their own findings' descriptions: %q", witnesses)
+	}
+}
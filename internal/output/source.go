@@ -0,0 +1,245 @@
+package output
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+)
+
+// Source writes n back in regex notation. The parsers keep no source
+// positions, so this is a reconstruction rather than a slice of the
+// original pattern: it uses the common Perl-style syntax ((?<name>...),
+// \k<name>, (?=...)) whatever the flavor, and escapes metacharacters in
+// literals even where the pattern spelled them another way. For the
+// flavors that share that syntax it reads back as the fragment the
+// user wrote.
+func Source(n ast.Node) string {
+	var b strings.Builder
+	writeSource(&b, n)
+	return b.String()
+}
+
+func writeSource(b *strings.Builder, n ast.Node) {
+	switch v := n.(type) {
+	case *ast.Regexp:
+		for _, opt := range v.Options {
+			writeSource(b, opt)
+		}
+		for i, m := range v.Matches {
+			if i > 0 {
+				b.WriteByte('|')
+			}
+			writeSource(b, m)
+		}
+	case *ast.Match:
+		for _, f := range v.Fragments {
+			writeSource(b, f)
+		}
+	case *ast.MatchFragment:
+		writeSource(b, v.Content)
+		if v.Repeat != nil {
+			b.WriteString(quantifierSource(v.Repeat))
+		}
+	case *ast.Literal:
+		b.WriteString(escapeLiteral(v.Text))
+	case *ast.QuotedLiteral:
+		b.WriteString(`\Q` + v.Text + `\E`)
+	case *ast.AnyCharacter:
+		b.WriteByte('.')
+	case *ast.Anchor:
+		b.WriteString(anchorSources[v.AnchorType])
+	case *ast.Escape:
+		b.WriteString(escapeSource(v))
+	case *ast.UnicodePropertyEscape, *ast.POSIXClass, *ast.CharsetLiteral,
+		*ast.CharsetRange, *ast.CharsetIntersection, *ast.CharsetSubtraction,
+		*ast.CharsetStringDisjunction:
+		b.WriteString(charsetItemSource(v))
+	case *ast.Charset:
+		b.WriteString(charsetSource(v))
+	case *ast.Subexp:
+		b.WriteString(subexpOpen(v))
+		writeSource(b, v.Regexp)
+		b.WriteByte(')')
+	case *ast.AtomicGroup:
+		b.WriteString("(?>")
+		writeSource(b, v.Regexp)
+		b.WriteByte(')')
+	case *ast.BranchReset:
+		b.WriteString("(?|")
+		writeSource(b, v.Regexp)
+		b.WriteByte(')')
+	case *ast.BalancedGroup:
+		b.WriteString("(?<" + v.Name + "-" + v.OtherName + ">")
+		writeSource(b, v.Regexp)
+		b.WriteByte(')')
+	case *ast.InlineModifier:
+		flags := v.Enable
+		if v.Disable != "" {
+			flags += "-" + v.Disable
+		}
+		if v.Regexp == nil {
+			b.WriteString("(?" + flags + ")")
+			return
+		}
+		b.WriteString("(?" + flags + ":")
+		writeSource(b, v.Regexp)
+		b.WriteByte(')')
+	case *ast.Conditional:
+		b.WriteString("(?")
+		switch c := v.Condition.(type) {
+		case *ast.BackReference:
+			if c.Name != "" {
+				b.WriteString("(<" + c.Name + ">)")
+			} else {
+				b.WriteString("(" + strconv.Itoa(c.Number) + ")")
+			}
+		case *ast.Subexp:
+			// A lookaround condition already brings its parentheses.
+			writeSource(b, c)
+		default:
+			b.WriteByte('(')
+			writeSource(b, c)
+			b.WriteByte(')')
+		}
+		writeSource(b, v.TrueMatch)
+		if v.FalseMatch != nil {
+			b.WriteByte('|')
+			writeSource(b, v.FalseMatch)
+		}
+		b.WriteByte(')')
+	case *ast.BackReference:
+		if v.Name != "" {
+			b.WriteString(`\k<` + v.Name + ">")
+		} else {
+			b.WriteString(`\` + strconv.Itoa(v.Number))
+		}
+	case *ast.RecursiveRef:
+		switch {
+		case v.Target == "R" || v.Target == "0":
+			b.WriteString("(?R)")
+		case isDigits(v.Target):
+			b.WriteString("(?" + v.Target + ")")
+		default:
+			b.WriteString("(?&" + v.Target + ")")
+		}
+	case *ast.BacktrackControl:
+		b.WriteString("(*" + v.Verb)
+		if v.Arg != "" {
+			b.WriteString(":" + v.Arg)
+		}
+		b.WriteByte(')')
+	case *ast.PatternOption:
+		b.WriteString("(*" + v.Name)
+		if v.Value != "" {
+			b.WriteString("=" + v.Value)
+		}
+		b.WriteByte(')')
+	case *ast.Callout:
+		if v.Number == -1 {
+			b.WriteString("(?C" + strconv.Quote(v.Text) + ")")
+		} else {
+			b.WriteString("(?C" + strconv.Itoa(v.Number) + ")")
+		}
+	case *ast.Comment:
+		b.WriteString("(?#" + v.Text + ")")
+	}
+}
+
+var anchorSources = map[string]string{
+	ast.AnchorStart:                   "^",
+	ast.AnchorEnd:                     "$",
+	ast.AnchorWordBoundary:            `\b`,
+	ast.AnchorNonWordBoundary:         `\B`,
+	ast.AnchorStringStart:             `\A`,
+	ast.AnchorStringEnd:               `\Z`,
+	ast.AnchorAbsoluteEnd:             `\z`,
+	ast.AnchorWordStart:               `\<`,
+	ast.AnchorWordEnd:                 `\>`,
+	ast.AnchorGraphemeClusterBoundary: `\b{g}`,
+	ast.AnchorMatchStart:              `\zs`,
+	ast.AnchorMatchEnd:                `\ze`,
+}
+
+func subexpOpen(s *ast.Subexp) string {
+	switch s.GroupType {
+	case ast.GroupCapture:
+		return "("
+	case ast.GroupNamedCapture:
+		return "(?<" + s.Name + ">"
+	case ast.GroupPositiveLookahead:
+		return "(?="
+	case ast.GroupNegativeLookahead:
+		return "(?!"
+	case ast.GroupPositiveLookbehind:
+		return "(?<="
+	case ast.GroupNegativeLookbehind:
+		return "(?<!"
+	case ast.GroupAtomic:
+		return "(?>"
+	default:
+		return "(?:"
+	}
+}
+
+func quantifierSource(r *ast.Repeat) string {
+	var q string
+	switch {
+	case r.Min == 0 && r.Max == -1:
+		q = "*"
+	case r.Min == 1 && r.Max == -1:
+		q = "+"
+	case r.Min == 0 && r.Max == 1:
+		q = "?"
+	case r.Min == r.Max:
+		q = "{" + strconv.Itoa(r.Min) + "}"
+	case r.Max == -1:
+		q = "{" + strconv.Itoa(r.Min) + ",}"
+	default:
+		q = "{" + strconv.Itoa(r.Min) + "," + strconv.Itoa(r.Max) + "}"
+	}
+	switch {
+	case r.Possessive:
+		q += "+"
+	case !r.Greedy:
+		q += "?"
+	}
+	return q
+}
+
+// escapeSource writes an escape back with its backslash. Most parsers
+// store just the code after it (d for \d), but some keep the whole
+// escape (\x41).
+func escapeSource(e *ast.Escape) string {
+	if code, ok := escapeShortCodes[e.EscapeType]; ok {
+		return code
+	}
+	if strings.HasPrefix(e.Code, `\`) {
+		return e.Code
+	}
+	return `\` + e.Code
+}
+
+// escapeLiteral backslash-escapes the regex metacharacters in text.
+func escapeLiteral(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		if strings.ContainsRune(`\^$.|?*+()[]{}`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
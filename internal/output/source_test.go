@@ -0,0 +1,26 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/0x4d5352/regolith/internal/parser"
+)
+
+func TestSourceRoundTrip(t *testing.T) {
+	for _, pattern := range []string{
+		`^a+b*?c{2,5}d{3}$`,
+		`(?<year>\d{4})-(?:0|1)\k<year>`,
+		`x(?=y)(?!z)(?<=w)(?<!v)`,
+		`[^a-z_\d]\./?`,
+		`(a)|\1\b\B`,
+		`\x41B\p{L}\P{N}`,
+	} {
+		root, err := parser.ParseRegex(pattern)
+		if err != nil {
+			t.Fatalf("parse %q: %v", pattern, err)
+		}
+		if got := Source(root); got != pattern {
+			t.Errorf("Source(%q) = %q", pattern, got)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package output
+
+// Go template output mode.
+//
+// Power users occasionally want a bespoke rendering (custom HTML,
+// TikZ, AsciiDoc, ...) that regolith will never ship first-class. Rather
+// than grow a format per request, --format gotemplate hands the parsed
+// model to a user-supplied text/template and writes whatever it
+// produces.
+//
+// The template receives two views of the same pattern: Root is the
+// consumer-friendly tree that RenderJSON emits (maps with a "type"
+// discriminator, stable across releases), and AST is the raw
+// *ast.Regexp for callers who need a field the JSON schema omits.
+// Templates written against Root are the supported contract. Layout is
+// the diagram the svg format would draw, as positioned boxes, text and
+// paths, for templates that draw it in another language, such as TikZ.
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+	"github.com/0x4d5352/regolith/internal/renderer"
+)
+
+// TemplateData is the value passed as dot to a user template.
+type TemplateData struct {
+	Pattern string      // Original pattern string
+	Flavor  string      // Flavor identifier, e.g. "pcre"
+	Root    any         // JSON-schema view of the AST (see RenderJSON)
+	AST     *ast.Regexp // Raw AST; field names may change between releases
+	Layout  *renderer.Layout
+}
+
+// templateFuncs are the helpers available inside a user template on top
+// of text/template's builtins. Kept deliberately small: anything more
+// elaborate belongs in the template itself.
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	},
+	"indent": func(n int, s string) string {
+		pad := strings.Repeat(" ", n)
+		return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+	},
+	"repeat": func(n int, s string) string {
+		if n < 0 {
+			n = 0
+		}
+		return strings.Repeat(s, n)
+	},
+	"add": func(a, b int) int { return a + b },
+}
+
+// RenderTemplate executes the Go text/template in tmplText against the
+// parsed AST and its diagram layout and returns the output. name is
+// used in template error messages (typically the template's file path).
+func RenderTemplate(root *ast.Regexp, layout *renderer.Layout, pattern, flavorName, name, tmplText string) (string, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(tmplText)
+	if err != nil {
+		return "", fmt.Errorf("template parse: %w", err)
+	}
+	data := TemplateData{
+		Pattern: pattern,
+		Flavor:  flavorName,
+		Root:    convertRegexp(root, true),
+		AST:     root,
+		Layout:  layout,
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("template execute: %w", err)
+	}
+	return sb.String(), nil
+}
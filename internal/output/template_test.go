@@ -0,0 +1,60 @@
+package output
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderTemplateReceivesModel(t *testing.T) {
+	tmpl := `{{.Flavor}}: {{.Pattern}}
+{{range .Root.alternatives}}- {{.type}}
+{{end}}`
+	got, err := RenderTemplate(sampleRegexp(), nil, "foo\\d|^", "javascript", "test", tmpl)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "javascript: foo\\d|^\n- sequence\n- sequence\n"
+	if got != want {
+		t.Errorf("template output mismatch\n--- want ---\n%s\n--- got ---\n%s", want, got)
+	}
+}
+
+func TestRenderTemplateRawAST(t *testing.T) {
+	got, err := RenderTemplate(sampleRegexp(), nil, "foo\\d|^", "javascript", "test",
+		`{{len .AST.Matches}} branches`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "2 branches" {
+		t.Errorf("got %q, want %q", got, "2 branches")
+	}
+}
+
+func TestRenderTemplateFuncs(t *testing.T) {
+	got, err := RenderTemplate(sampleRegexp(), nil, "x", "javascript", "test",
+		`{{json .Pattern}}|{{repeat 3 "-"}}|{{add 1 2}}|{{indent 2 "a\nb"}}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `"x"|---|3|  a` + "\n  b"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRenderTemplateParseError(t *testing.T) {
+	_, err := RenderTemplate(sampleRegexp(), nil, "x", "javascript", "broken.tmpl", `{{.Pattern`)
+	if err == nil {
+		t.Fatal("expected parse error, got nil")
+	}
+	if !strings.Contains(err.Error(), "broken.tmpl") {
+		t.Errorf("expected error to name the template, got: %v", err)
+	}
+}
+
+func TestRenderTemplateExecuteError(t *testing.T) {
+	_, err := RenderTemplate(sampleRegexp(), nil, "x", "javascript", "test", `{{.NoSuchField}}`)
+	if err == nil {
+		t.Fatal("expected execute error, got nil")
+	}
+}
@@ -0,0 +1,27 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RenderValidationGNU formats a parse error in the GNU error message
+// convention, "source:line:column: message", which Emacs compilation
+// mode, Vim's quickfix list and most other editors jump from. Each hint
+// follows on its own line at the same position. An error without a
+// position is reported as "source: message". Messages are kept to one
+// line each, since the editors read one error per line.
+func RenderValidationGNU(source string, perr *ParseErrorInfo) string {
+	prefix := source + ":"
+	if perr.Line > 0 {
+		prefix = fmt.Sprintf("%s:%d:%d:", source, perr.Line, perr.Column)
+	}
+	oneLine := strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ")
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s", prefix, oneLine.Replace(perr.Message))
+	for _, h := range perr.Hints {
+		fmt.Fprintf(&b, "\n%s hint: %s", prefix, oneLine.Replace(h))
+	}
+	return b.String()
+}
@@ -0,0 +1,23 @@
+package output
+
+import "testing"
+
+func TestRenderValidationGNU(t *testing.T) {
+	perr := &ParseErrorInfo{
+		Line:    1,
+		Column:  2,
+		Offset:  1,
+		Message: "unclosed group: ( has no matching )",
+		Hints:   []string{"the group opened at column 2 is never closed; add )"},
+	}
+	want := "route.regex:1:2: unclosed group: ( has no matching )\n" +
+		"route.regex:1:2: hint: the group opened at column 2 is never closed; add )"
+	if got := RenderValidationGNU("route.regex", perr); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	got := RenderValidationGNU("<pattern>", &ParseErrorInfo{Message: "bad\nthing"})
+	if got != "<pattern>: bad thing" {
+		t.Errorf("positionless error = %q", got)
+	}
+}
@@ -0,0 +1,66 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/0x4d5352/regolith/internal/flavor"
+)
+
+// ParseErrorInfo is the location and message of a pattern parse error.
+// Line and Column are 1-based, with Column counted in runes; Offset is
+// the 0-based byte offset into the pattern. All three are zero when the
+// error carried no position. Expected lists what the grammar would have
+// accepted there, when it says. Hints are suggestions for fixing the
+// pattern, filled in by callers that know its flavor.
+type ParseErrorInfo struct {
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+	Offset   int      `json:"offset"`
+	Message  string   `json:"message"`
+	Expected []string `json:"expected,omitempty"`
+	Hints    []string `json:"hints,omitempty"`
+}
+
+// ParseError extracts the position and message from a flavor parse
+// error, the *flavor.ParseError in its chain. Other errors come back
+// with a zero position and the full error text as the message.
+func ParseError(err error) ParseErrorInfo {
+	var pe *flavor.ParseError
+	if !errors.As(err, &pe) {
+		return ParseErrorInfo{Message: err.Error()}
+	}
+	return ParseErrorInfo{
+		Line:     pe.Line,
+		Column:   pe.Col,
+		Offset:   pe.Offset,
+		Message:  pe.Message,
+		Expected: pe.Expected,
+	}
+}
+
+// validationDocument is the JSON envelope for --check and for parse
+// errors reported with --error-format json.
+type validationDocument struct {
+	Valid   bool            `json:"valid"`
+	Pattern string          `json:"pattern"`
+	Flavor  string          `json:"flavor"`
+	Error   *ParseErrorInfo `json:"error,omitempty"`
+}
+
+// RenderValidationJSON serializes the outcome of validating pattern
+// under flavorName. A nil perr means the pattern parsed cleanly.
+func RenderValidationJSON(pattern, flavorName string, perr *ParseErrorInfo) (string, error) {
+	doc := validationDocument{
+		Valid:   perr == nil,
+		Pattern: pattern,
+		Flavor:  flavorName,
+		Error:   perr,
+	}
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("json marshal: %w", err)
+	}
+	return string(b), nil
+}
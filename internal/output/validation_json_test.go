@@ -0,0 +1,68 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/0x4d5352/regolith/internal/flavor"
+)
+
+func TestRenderValidationJSON(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		got, err := RenderValidationJSON("a+", "pcre", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var parsed map[string]any
+		if err := json.Unmarshal([]byte(got), &parsed); err != nil {
+			t.Fatalf("invalid JSON: %v\n%s", err, got)
+		}
+		if parsed["valid"] != true || parsed["flavor"] != "pcre" {
+			t.Errorf("unexpected document: %v", parsed)
+		}
+		if _, ok := parsed["error"]; ok {
+			t.Error("valid pattern must not carry an error object")
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		perr := &ParseErrorInfo{Line: 1, Column: 3, Offset: 2, Message: "no match found"}
+		got, err := RenderValidationJSON("a(", "javascript", perr)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var parsed struct {
+			Valid bool            `json:"valid"`
+			Error *ParseErrorInfo `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(got), &parsed); err != nil {
+			t.Fatalf("invalid JSON: %v\n%s", err, got)
+		}
+		if parsed.Valid || parsed.Error == nil || !reflect.DeepEqual(*parsed.Error, *perr) {
+			t.Errorf("unexpected document: %s", got)
+		}
+	})
+}
+
+func TestParseError(t *testing.T) {
+	perr := &flavor.ParseError{Line: 1, Col: 3, Offset: 2, Message: "no match found", Expected: []string{`")"`}}
+	got := ParseError(fmt.Errorf("parse error: %w", perr))
+	want := ParseErrorInfo{Line: 1, Column: 3, Offset: 2, Message: "no match found", Expected: []string{`")"`}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// Position-like text is not taken for a position.
+	got = ParseError(errors.New("parse error: 1:3 (2): no match found"))
+	if got.Line != 0 {
+		t.Errorf("position scraped from the error text: %+v", got)
+	}
+
+	got = ParseError(errors.New("something else"))
+	if !reflect.DeepEqual(got, ParseErrorInfo{Message: "something else"}) {
+		t.Errorf("unpositioned error: got %+v", got)
+	}
+}
@@ -0,0 +1,389 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+)
+
+// Match is one node a query selected.
+type Match struct {
+	// Pointer locates the node as an RFC 6901 JSON Pointer into the
+	// document `regolith --format json` prints for the same pattern,
+	// e.g. /root/elements/0/body. A repeat and the item it quantifies
+	// share a pointer, since the JSON schema folds the quantifier into
+	// the item.
+	Pointer string
+	// Description is a short human-readable label for the node.
+	Description string
+	Node        ast.Node
+}
+
+// item is a node in the tree the query is evaluated over. It follows
+// the JSON output schema rather than the raw AST: single-branch
+// regexps and sequences are transparent, quantified fragments are
+// "repeat" items wrapping their content, and charset members are
+// children of their charset.
+type item struct {
+	node     ast.Node
+	pointer  string
+	parent   *item
+	children []*item
+}
+
+// Find returns every node in root that q selects, in source order.
+func (q *Query) Find(root *ast.Regexp) []Match {
+	top := &item{}
+	build(top, root, "/root")
+
+	var out []Match
+	var visit func(it *item)
+	visit = func(it *item) {
+		if it.node != nil && q.root.matches(it) {
+			out = append(out, Match{Pointer: it.pointer, Description: describe(it.node), Node: it.node})
+		}
+		for _, c := range it.children {
+			visit(c)
+		}
+	}
+	visit(top)
+	return out
+}
+
+func (p *item) add(n ast.Node, pointer string) *item {
+	it := &item{node: n, pointer: pointer, parent: p}
+	p.children = append(p.children, it)
+	return it
+}
+
+// build adds n beneath parent, mirroring output.convertRegexp and
+// friends so that pointers resolve against the JSON document.
+func build(parent *item, n ast.Node, ptr string) {
+	switch n := n.(type) {
+	case nil:
+	case *ast.Regexp:
+		if n == nil {
+			return
+		}
+		if len(n.Matches) == 1 {
+			build(parent, n.Matches[0], ptr)
+			return
+		}
+		alt := parent.add(n, ptr)
+		for i, m := range n.Matches {
+			build(alt, m, fmt.Sprintf("%s/alternatives/%d", ptr, i))
+		}
+	case *ast.Match:
+		for i, frag := range n.Fragments {
+			build(parent, frag, fmt.Sprintf("%s/elements/%d", ptr, i))
+		}
+	case *ast.MatchFragment:
+		if n.Repeat != nil {
+			parent = parent.add(n, ptr)
+		}
+		build(parent, n.Content, ptr)
+	case *ast.Charset:
+		it := parent.add(n, ptr)
+		if n.SetExpression != nil {
+			build(it, n.SetExpression, ptr+"/members/0")
+			return
+		}
+		for i, m := range n.Items {
+			build(it, m, fmt.Sprintf("%s/members/%d", ptr, i))
+		}
+	case *ast.CharsetIntersection:
+		it := parent.add(n, ptr)
+		for i, op := range n.Operands {
+			build(it, op, fmt.Sprintf("%s/operands/%d", ptr, i))
+		}
+	case *ast.CharsetSubtraction:
+		it := parent.add(n, ptr)
+		for i, op := range n.Operands {
+			build(it, op, fmt.Sprintf("%s/operands/%d", ptr, i))
+		}
+	case *ast.Subexp:
+		build(parent.add(n, ptr), n.Regexp, ptr+"/body")
+	case *ast.AtomicGroup:
+		build(parent.add(n, ptr), n.Regexp, ptr+"/body")
+	case *ast.BalancedGroup:
+		build(parent.add(n, ptr), n.Regexp, ptr+"/body")
+	case *ast.BranchReset:
+		build(parent.add(n, ptr), n.Regexp, ptr+"/body")
+	case *ast.InlineModifier:
+		build(parent.add(n, ptr), n.Regexp, ptr+"/body")
+	case *ast.Conditional:
+		it := parent.add(n, ptr)
+		build(it, n.Condition, ptr+"/condition")
+		build(it, n.TrueMatch, ptr+"/ifTrue")
+		build(it, n.FalseMatch, ptr+"/ifFalse")
+	default:
+		parent.add(n, ptr)
+	}
+}
+
+// matches reports whether it satisfies s, including s's relation.
+func (s *selector) matches(it *item) bool {
+	if !isKind(s.noun, it.node) {
+		return false
+	}
+	for _, adj := range s.adjectives {
+		if !hasAdjective(adj, it.node) {
+			return false
+		}
+	}
+	switch s.relation {
+	case "containing":
+		if s.sub == nil && containsText(it.node, s.text) {
+			return true
+		}
+		return anyDescendant(it, func(d *item) bool {
+			if s.sub != nil {
+				return s.sub.matches(d)
+			}
+			return containsText(d.node, s.text)
+		})
+	case "inside":
+		for a := it.parent; a != nil && a.node != nil; a = a.parent {
+			if s.sub.matches(a) {
+				return true
+			}
+		}
+		return false
+	case "of":
+		// The content item is the repeat's only child.
+		return len(it.children) == 1 && s.sub.matches(it.children[0])
+	}
+	return true
+}
+
+func anyDescendant(it *item, fn func(*item) bool) bool {
+	for _, c := range it.children {
+		if fn(c) || anyDescendant(c, fn) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsText(n ast.Node, text string) bool {
+	switch n := n.(type) {
+	case *ast.Literal:
+		return strings.Contains(n.Text, text)
+	case *ast.CharsetLiteral:
+		return n.Text == text
+	case *ast.QuotedLiteral:
+		return strings.Contains(n.Text, text)
+	case *ast.CharsetRange:
+		return n.First == text || n.Last == text
+	}
+	return false
+}
+
+func isKind(noun string, n ast.Node) bool {
+	switch noun {
+	case "node":
+		return true
+	case "literal":
+		switch n.(type) {
+		case *ast.Literal, *ast.CharsetLiteral, *ast.QuotedLiteral:
+			return true
+		}
+	case "escape":
+		_, ok := n.(*ast.Escape)
+		return ok
+	case "charset":
+		_, ok := n.(*ast.Charset)
+		return ok
+	case "range":
+		_, ok := n.(*ast.CharsetRange)
+		return ok
+	case "dot":
+		_, ok := n.(*ast.AnyCharacter)
+		return ok
+	case "anchor":
+		_, ok := n.(*ast.Anchor)
+		return ok
+	case "group":
+		switch n.(type) {
+		case *ast.Subexp, *ast.AtomicGroup, *ast.BalancedGroup, *ast.BranchReset:
+			return true
+		}
+	case "capture":
+		s, ok := n.(*ast.Subexp)
+		return ok && (s.GroupType == ast.GroupCapture || s.GroupType == ast.GroupNamedCapture)
+	case "lookahead":
+		s, ok := n.(*ast.Subexp)
+		return ok && (s.GroupType == ast.GroupPositiveLookahead || s.GroupType == ast.GroupNegativeLookahead)
+	case "lookbehind":
+		s, ok := n.(*ast.Subexp)
+		return ok && (s.GroupType == ast.GroupPositiveLookbehind || s.GroupType == ast.GroupNegativeLookbehind)
+	case "lookaround":
+		return isKind("lookahead", n) || isKind("lookbehind", n)
+	case "atomic":
+		if _, ok := n.(*ast.AtomicGroup); ok {
+			return true
+		}
+		s, ok := n.(*ast.Subexp)
+		return ok && s.GroupType == ast.GroupAtomic
+	case "backref":
+		_, ok := n.(*ast.BackReference)
+		return ok
+	case "recursion":
+		_, ok := n.(*ast.RecursiveRef)
+		return ok
+	case "conditional":
+		_, ok := n.(*ast.Conditional)
+		return ok
+	case "alternation":
+		_, ok := n.(*ast.Regexp)
+		return ok
+	case "repeat":
+		_, ok := n.(*ast.MatchFragment)
+		return ok
+	case "property":
+		_, ok := n.(*ast.UnicodePropertyEscape)
+		return ok
+	case "comment":
+		_, ok := n.(*ast.Comment)
+		return ok
+	case "modifier":
+		_, ok := n.(*ast.InlineModifier)
+		return ok
+	case "verb":
+		_, ok := n.(*ast.BacktrackControl)
+		return ok
+	case "callout":
+		_, ok := n.(*ast.Callout)
+		return ok
+	}
+	return false
+}
+
+func hasAdjective(adj string, n ast.Node) bool {
+	switch n := n.(type) {
+	case *ast.Subexp:
+		switch adj {
+		case "positive":
+			return n.GroupType == ast.GroupPositiveLookahead || n.GroupType == ast.GroupPositiveLookbehind
+		case "negative":
+			return n.GroupType == ast.GroupNegativeLookahead || n.GroupType == ast.GroupNegativeLookbehind
+		case "named":
+			return n.Name != ""
+		}
+	case *ast.Charset:
+		return (adj == "negative" || adj == "negated") && n.Inverted
+	case *ast.UnicodePropertyEscape:
+		return (adj == "negative" || adj == "negated") && n.Negated
+	case *ast.BackReference:
+		return adj == "named" && n.Name != ""
+	case *ast.MatchFragment:
+		r := n.Repeat
+		switch adj {
+		case "unbounded":
+			return r.Max == -1
+		case "bounded":
+			return r.Max != -1
+		case "lazy":
+			return !r.Greedy && !r.Possessive
+		case "greedy":
+			return r.Greedy && !r.Possessive
+		case "possessive":
+			return r.Possessive
+		}
+	}
+	return false
+}
+
+var groupDescriptions = map[string]string{
+	ast.GroupCapture:            "capture group",
+	ast.GroupNonCapture:         "non-capturing group",
+	ast.GroupPositiveLookahead:  "positive lookahead",
+	ast.GroupNegativeLookahead:  "negative lookahead",
+	ast.GroupPositiveLookbehind: "positive lookbehind",
+	ast.GroupNegativeLookbehind: "negative lookbehind",
+	ast.GroupNamedCapture:       "named capture group",
+	ast.GroupAtomic:             "atomic group",
+}
+
+// describe returns a short label for n in query vocabulary.
+func describe(n ast.Node) string {
+	switch n := n.(type) {
+	case *ast.Regexp:
+		return fmt.Sprintf("alternation of %d branches", len(n.Matches))
+	case *ast.MatchFragment:
+		return "repeat " + quantifier(n.Repeat)
+	case *ast.Literal:
+		return "literal " + strconv.Quote(n.Text)
+	case *ast.CharsetLiteral:
+		return "literal " + strconv.Quote(n.Text)
+	case *ast.QuotedLiteral:
+		return "literal " + strconv.Quote(n.Text)
+	case *ast.CharsetRange:
+		return fmt.Sprintf("range %s-%s", n.First, n.Last)
+	case *ast.Escape:
+		return `escape \` + n.Code
+	case *ast.Charset:
+		if n.Inverted {
+			return "negated charset"
+		}
+		return "charset"
+	case *ast.AnyCharacter:
+		return "dot"
+	case *ast.Anchor:
+		return "anchor " + n.AnchorType
+	case *ast.Subexp:
+		desc := groupDescriptions[n.GroupType]
+		if desc == "" {
+			desc = n.GroupType + " group"
+		}
+		if n.Name != "" {
+			desc += " <" + n.Name + ">"
+		}
+		if n.Number > 0 {
+			desc += " #" + strconv.Itoa(n.Number)
+		}
+		return desc
+	case *ast.AtomicGroup:
+		return "atomic group"
+	case *ast.BackReference:
+		if n.Name != "" {
+			return "backref <" + n.Name + ">"
+		}
+		return "backref #" + strconv.Itoa(n.Number)
+	case *ast.UnicodePropertyEscape:
+		if n.Negated {
+			return `property \P{` + n.Property + "}"
+		}
+		return `property \p{` + n.Property + "}"
+	case *ast.RecursiveRef:
+		return "recursion " + n.Target
+	case *ast.BacktrackControl:
+		return "verb " + n.Verb
+	case *ast.InlineModifier:
+		return "modifier"
+	}
+	return strings.ReplaceAll(n.Type(), "_", " ")
+}
+
+// quantifier formats r in brace notation with its mode, e.g. "{1,}" or
+// "{0,3} lazy".
+func quantifier(r *ast.Repeat) string {
+	var s string
+	switch {
+	case r.Max == -1:
+		s = fmt.Sprintf("{%d,}", r.Min)
+	case r.Min == r.Max:
+		s = fmt.Sprintf("{%d}", r.Min)
+	default:
+		s = fmt.Sprintf("{%d,%d}", r.Min, r.Max)
+	}
+	switch {
+	case r.Possessive:
+		s += " possessive"
+	case !r.Greedy:
+		s += " lazy"
+	}
+	return s
+}
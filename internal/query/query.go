@@ -0,0 +1,231 @@
+// Package query implements the small structural query language behind
+// `regolith query`. Reviewers use it to find specific constructs, such
+// as lookbehinds or nested unbounded repeats, across many patterns
+// without reading each diagram.
+//
+// A query names the kind of node to find. Adjectives narrow it, and an
+// optional relation constrains what it contains or sits inside:
+//
+//	query    = selector
+//	selector = { adjective } noun [ relation operand ]
+//	relation = "containing" | "inside" | "of"
+//	operand  = selector | text
+//
+// Relations nest to the right, so "repeat inside lookbehind containing
+// -" reads as "repeat inside (lookbehind containing -)".
+//
+//   - "X containing Y": X has a descendant matching Y. When Y is text
+//     rather than a selector, X itself or a descendant must be a literal
+//     whose text includes Y, or a charset range with Y as an endpoint.
+//     So "charset containing -" finds character classes with a literal
+//     hyphen, and "literal containing foo" finds literals mentioning foo.
+//   - "X inside Y": X has an ancestor matching Y.
+//   - "X of Y": X is a repeat whose quantified content matches Y, as in
+//     "unbounded repeat of group".
+//
+// A word outside the vocabulary is read as text. Double quotes force
+// text, so "literal containing \"of\"" searches for the word "of".
+package query
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// nouns maps each node kind a query can name to its one-line help.
+var nouns = map[string]string{
+	"node":        "any node",
+	"literal":     "literal text, including literal charset members and \\Q...\\E",
+	"escape":      "escape sequence such as \\d or \\n",
+	"charset":     "character class [...]",
+	"class":       "alias for charset",
+	"range":       "range inside a character class, such as a-z",
+	"dot":         "the any-character wildcard .",
+	"anchor":      "anchor or boundary such as ^, $, \\b",
+	"group":       "any parenthesized group",
+	"capture":     "capturing group, numbered or named",
+	"lookahead":   "lookahead assertion",
+	"lookbehind":  "lookbehind assertion",
+	"lookaround":  "lookahead or lookbehind assertion",
+	"atomic":      "atomic group (?>...)",
+	"backref":     "back-reference",
+	"recursion":   "recursive reference such as (?R)",
+	"conditional": "conditional (?(cond)yes|no)",
+	"alternation": "alternation with two or more branches",
+	"repeat":      "quantified item",
+	"property":    "Unicode property escape \\p{...}",
+	"comment":     "comment (?#...)",
+	"modifier":    "inline modifier such as (?i)",
+	"verb":        "backtracking control verb such as (*SKIP)",
+	"callout":     "callout (?C...)",
+}
+
+// adjectives maps each adjective to the nouns it can narrow.
+var adjectives = map[string][]string{
+	"positive":   {"lookahead", "lookbehind", "lookaround"},
+	"negative":   {"lookahead", "lookbehind", "lookaround", "charset", "class", "property"},
+	"negated":    {"charset", "class", "property"},
+	"named":      {"capture", "backref"},
+	"unbounded":  {"repeat"},
+	"bounded":    {"repeat"},
+	"lazy":       {"repeat"},
+	"greedy":     {"repeat"},
+	"possessive": {"repeat"},
+}
+
+var relations = map[string]bool{"containing": true, "inside": true, "of": true}
+
+// Query is a parsed structural query.
+type Query struct {
+	source string
+	root   *selector
+}
+
+// String returns the query text as written.
+func (q *Query) String() string { return q.source }
+
+type selector struct {
+	adjectives []string
+	noun       string
+	relation   string    // "", "containing", "inside", or "of"
+	sub        *selector // Operand when it is a selector
+	text       string    // Operand when it is text
+}
+
+// Parse compiles a query string.
+func Parse(src string) (*Query, error) {
+	words, err := split(src)
+	if err != nil {
+		return nil, err
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("empty query")
+	}
+	sel, rest, err := parseSelector(words)
+	if err != nil {
+		return nil, fmt.Errorf("query %q: %w", src, err)
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("query %q: unexpected %q after %q", src, rest[0].text, sel.noun)
+	}
+	return &Query{source: src, root: sel}, nil
+}
+
+// word is one query token. quoted words are always text.
+type word struct {
+	text   string
+	quoted bool
+}
+
+func split(src string) ([]word, error) {
+	var words []word
+	for i := 0; i < len(src); {
+		switch c := src[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '"':
+			end := strings.IndexByte(src[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("query %q: unterminated quote", src)
+			}
+			words = append(words, word{text: src[i+1 : i+1+end], quoted: true})
+			i += end + 2
+		default:
+			end := strings.IndexAny(src[i:], " \t")
+			if end < 0 {
+				end = len(src) - i
+			}
+			words = append(words, word{text: src[i : i+end]})
+			i += end
+		}
+	}
+	return words, nil
+}
+
+func parseSelector(words []word) (*selector, []word, error) {
+	sel := &selector{}
+	for len(words) > 0 && !words[0].quoted {
+		w := strings.ToLower(words[0].text)
+		if _, ok := adjectives[w]; !ok {
+			break
+		}
+		sel.adjectives = append(sel.adjectives, w)
+		words = words[1:]
+	}
+	if len(words) == 0 {
+		return nil, nil, fmt.Errorf("expected a node kind after %q", strings.Join(sel.adjectives, " "))
+	}
+	noun := strings.ToLower(words[0].text)
+	if _, ok := nouns[noun]; !ok || words[0].quoted {
+		return nil, nil, fmt.Errorf("unknown node kind %q (available: %s)", words[0].text, strings.Join(Nouns(), ", "))
+	}
+	if noun == "class" {
+		noun = "charset"
+	}
+	sel.noun = noun
+	words = words[1:]
+	for _, adj := range sel.adjectives {
+		if !applies(adj, noun) {
+			return nil, nil, fmt.Errorf("%q does not apply to %s", adj, noun)
+		}
+	}
+
+	if len(words) == 0 || words[0].quoted || !relations[strings.ToLower(words[0].text)] {
+		return sel, words, nil
+	}
+	sel.relation = strings.ToLower(words[0].text)
+	words = words[1:]
+	if len(words) == 0 {
+		return nil, nil, fmt.Errorf("expected an operand after %q", sel.relation)
+	}
+	if sel.relation == "of" && noun != "repeat" {
+		return nil, nil, fmt.Errorf(`"of" only applies to repeat`)
+	}
+
+	if isSelectorStart(words[0]) {
+		sub, rest, err := parseSelector(words)
+		if err != nil {
+			return nil, nil, err
+		}
+		sel.sub = sub
+		return sel, rest, nil
+	}
+	if sel.relation != "containing" {
+		return nil, nil, fmt.Errorf("%q needs a node kind, got %q", sel.relation, words[0].text)
+	}
+	sel.text = words[0].text
+	return sel, words[1:], nil
+}
+
+func isSelectorStart(w word) bool {
+	if w.quoted {
+		return false
+	}
+	lw := strings.ToLower(w.text)
+	_, isNoun := nouns[lw]
+	_, isAdj := adjectives[lw]
+	return isNoun || isAdj
+}
+
+func applies(adj, noun string) bool {
+	for _, n := range adjectives[adj] {
+		if n == noun {
+			return true
+		}
+	}
+	return false
+}
+
+// Nouns returns the node kinds a query can name, sorted.
+func Nouns() []string {
+	out := make([]string, 0, len(nouns))
+	for n := range nouns {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// NounHelp returns the one-line description of noun.
+func NounHelp(noun string) string { return nouns[noun] }
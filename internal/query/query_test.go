@@ -0,0 +1,155 @@
+package query
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/0x4d5352/regolith/internal/flavor"
+	_ "github.com/0x4d5352/regolith/internal/flavor/javascript"
+	_ "github.com/0x4d5352/regolith/internal/flavor/pcre"
+	"github.com/0x4d5352/regolith/internal/output"
+)
+
+func find(t *testing.T, flavorName, pattern, query string) []Match {
+	t.Helper()
+	f, ok := flavor.Get(flavorName)
+	if !ok {
+		t.Fatalf("flavor %s not registered", flavorName)
+	}
+	root, err := f.Parse(pattern)
+	if err != nil {
+		t.Fatalf("parse %q: %v", pattern, err)
+	}
+	q, err := Parse(query)
+	if err != nil {
+		t.Fatalf("parse query %q: %v", query, err)
+	}
+	return q.Find(root)
+}
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		pattern string
+		query   string
+		want    []string // "pointer description"
+	}{
+		{`(?<=a)b(?<!c)`, "lookbehind", []string{
+			"/root/elements/0 positive lookbehind",
+			"/root/elements/2 negative lookbehind",
+		}},
+		{`(?<=a)b(?<!c)`, "negative lookbehind", []string{"/root/elements/2 negative lookbehind"}},
+		{`[a-z-][_-]x[-]`, "charset containing -", []string{
+			"/root/elements/0 charset",
+			"/root/elements/1 charset",
+			"/root/elements/3 charset",
+		}},
+		{`[a-z]`, "charset containing z", []string{"/root/elements/0 charset"}},
+		{`(ab)+c+(d)`, "unbounded repeat of group", []string{"/root/elements/0 repeat {1,}"}},
+		{`(a+)+|b*`, "unbounded repeat inside unbounded repeat", []string{
+			"/root/alternatives/0/elements/0/body/elements/0 repeat {1,}",
+		}},
+		{`(a+)+b*`, "alternation", nil},
+		{`x|(a|b)`, "alternation", []string{
+			"/root alternation of 2 branches",
+			"/root/alternatives/1/elements/0/body alternation of 2 branches",
+		}},
+		{`a+?b{2,3}?c*`, "lazy repeat", []string{
+			"/root/elements/0 repeat {1,} lazy",
+			"/root/elements/1 repeat {2,3} lazy",
+		}},
+		{`(?<y>a)\k<y>\1`, "named backref", []string{"/root/elements/1 backref <y>"}},
+		{`(a)(?:b)`, "capture", []string{"/root/elements/0 capture group #1"}},
+		{`foo(?=bar)`, `literal containing "ar"`, []string{"/root/elements/1/body/elements/0 literal \"bar\""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.query, func(t *testing.T) {
+			var got []string
+			for _, m := range find(t, "javascript", tt.pattern, tt.query) {
+				got = append(got, m.Pointer+" "+m.Description)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestFindPCREPossessive(t *testing.T) {
+	got := find(t, "pcre", `a++b*`, "possessive repeat")
+	if len(got) != 1 || got[0].Description != "repeat {1,} possessive" {
+		t.Errorf("got %+v", got)
+	}
+}
+
+// TestPointersResolve checks every pointer against the JSON document
+// the CLI prints, so the walk in build cannot drift from output's
+// schema unnoticed.
+func TestPointersResolve(t *testing.T) {
+	patterns := []string{`x|(a|[b-d\w])+?(?<=e)`, `(?:a(?<n>b)c)*\k<n>`, `[^a-z]{2}$`}
+	f, _ := flavor.Get("javascript")
+	for _, pattern := range patterns {
+		root, err := f.Parse(pattern)
+		if err != nil {
+			t.Fatalf("parse %q: %v", pattern, err)
+		}
+		doc, err := output.RenderJSON(root, pattern, f.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var tree any
+		if err := json.Unmarshal([]byte(doc), &tree); err != nil {
+			t.Fatal(err)
+		}
+		q, _ := Parse("node")
+		for _, m := range q.Find(root) {
+			if _, ok := resolve(tree, m.Pointer); !ok {
+				t.Errorf("%s: pointer %s (%s) does not resolve", pattern, m.Pointer, m.Description)
+			}
+		}
+	}
+}
+
+func resolve(v any, pointer string) (any, bool) {
+	for _, tok := range strings.Split(pointer, "/")[1:] {
+		switch node := v.(type) {
+		case map[string]any:
+			var ok bool
+			if v, ok = node[tok]; !ok {
+				return nil, false
+			}
+		case []any:
+			i, err := strconv.Atoi(tok)
+			if err != nil || i >= len(node) {
+				return nil, false
+			}
+			v = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "empty query"},
+		{"widget", `unknown node kind "widget"`},
+		{"lazy charset", `"lazy" does not apply to charset`},
+		{"group of literal", `"of" only applies to repeat`},
+		{"repeat inside", `expected an operand after "inside"`},
+		{"repeat inside x", `"inside" needs a node kind`},
+		{"charset containing - extra", `unexpected "extra"`},
+		{`literal containing "oops`, "unterminated quote"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.query)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) error = %v, want containing %q", tt.query, err, tt.want)
+		}
+	}
+}
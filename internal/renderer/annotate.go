@@ -25,150 +25,26 @@ func (r *Renderer) RenderAnnotated(root *parser.Regexp, report *analyzer.Analysi
 
 	// Build the mapping from AST node pointers to their worst-severity finding.
 	r.nodeFindings = buildNodeFindingMap(report.Findings)
+	if r.GroupLegend {
+		r.groupAnchors = map[int]bool{}
+	}
 
 	// Render the diagram. Because nodeFindings is non-nil, annotateNode will
 	// add overlays to any node that has a finding.
-	rendered := r.renderRegexp(root)
-
-	// Clear the map so subsequent Render calls are unaffected.
-	defer func() { r.nodeFindings = nil }()
+	rendered, exitY := r.renderRoot(root)
 
-	padding := r.Config.Padding
-	leftMargin := contentLeftMargin(padding)
-	rightMargin := contentRightMargin(padding)
+	// Clear the maps so subsequent Render calls are unaffected.
+	defer func() { r.nodeFindings, r.groupAnchors = nil, nil }()
 
-	// Render the legend below the diagram.
+	// The findings legend goes below everything else.
+	f := r.frame(root, rendered, exitY)
 	legend := r.renderLegend(report.Findings)
-
-	// diagramWidth is the width the diagram itself needs (with marker
-	// margins); totalWidth also accounts for the legend, which may be
-	// wider than the diagram. These two widths are tracked separately
-	// so the end connector / dot marker can terminate at the diagram's
-	// right edge instead of stretching across the legend area.
-	legendGap := padding
-	diagramWidth := rendered.BBox.Width + leftMargin + rightMargin
-	legendWidth := legend.BBox.Width + 2*padding
-	totalWidth := diagramWidth
-	if legendWidth > totalWidth {
-		totalWidth = legendWidth
-	}
-
-	diagramHeight := rendered.BBox.Height + 2*padding
-	totalHeight := diagramHeight + legendGap + legend.BBox.Height + padding
-
-	// Check for flags and render them.
-	var flagsElement SVGElement
-	var flagsRendered RenderedNode
-	flagsWidth := 0.0
-	if root.Flags != "" {
-		flagsRendered = r.renderFlags(root.Flags)
-		flagsElement = flagsRendered.Element
-		flagsWidth = flagsRendered.BBox.Width + padding
-		diagramWidth += flagsWidth
-		if diagramWidth > totalWidth {
-			totalWidth = diagramWidth
-		}
-		if flagsRendered.BBox.Height+2*padding > diagramHeight {
-			diagramHeight = flagsRendered.BBox.Height + 2*padding
-			totalHeight = diagramHeight + legendGap + legend.BBox.Height + padding
-		}
-	}
-
-	// Check for pattern start options (PCRE).
-	var bannerHeight float64
-	var bannerElement SVGElement
-	if len(root.Options) > 0 {
-		bannerRendered := r.renderPatternOptions(root.Options)
-		bannerElement = bannerRendered.Element
-		bannerHeight = bannerRendered.BBox.Height + padding/2
-
-		bannerWidth := bannerRendered.BBox.Width + 2*padding
-		if bannerWidth > totalWidth {
-			totalWidth = bannerWidth
-		}
-		totalHeight += bannerHeight
-	}
-
-	// Start/end connector lines. The end line is computed from
-	// diagramWidth (not totalWidth) so the end dot stays at the visual
-	// end of the diagram even when the legend below is wider.
-	anchorY := bannerHeight + padding + rendered.BBox.AnchorY
-	startX := padding / 2
-	contentEndX := diagramWidth - rightMargin - flagsWidth
-	endLineLength := float64(visibleConnectorWidth + endDotRadius)
-
-	startLine := &Line{
-		X1: startX, Y1: anchorY,
-		X2: leftMargin, Y2: anchorY,
-		Stroke:      r.Config.Connector.Color,
-		StrokeWidth: r.Config.Connector.StrokeWidth,
-		MarkerStart: startMarkerRef(r.Config.Connector.StartMarker),
-	}
-	endLine := &Line{
-		X1: contentEndX, Y1: anchorY,
-		X2: contentEndX + endLineLength, Y2: anchorY,
-		Stroke:      r.Config.Connector.Color,
-		StrokeWidth: r.Config.Connector.StrokeWidth,
-		MarkerEnd:   endMarkerRef(r.Config.Connector.EndMarker),
-	}
-
-	contentGroup := &Group{
-		Transform: "translate(" + fmtFloat(leftMargin) + "," + fmtFloat(bannerHeight+padding) + ")",
-		Children:  []SVGElement{rendered.Element},
-	}
-
-	// Mirror Render()'s background-fill behavior so annotated SVGs get
-	// the same --background-fill treatment as plain renders. Width/
-	// height here use the totalWidth/totalHeight that the SVG root is
-	// built with below, so the rect covers the diagram AND the legend
-	// area underneath.
-	var children []SVGElement
-	if r.Config.BackgroundFill != "" {
-		children = append(children, &Rect{
-			X:      0,
-			Y:      0,
-			Width:  totalWidth,
-			Height: totalHeight,
-			Fill:   r.Config.BackgroundFill,
-		})
-	}
-	children = append(children, startLine, endLine, contentGroup)
-
-	if bannerElement != nil {
-		children = append(children, &Group{
-			Transform: "translate(" + fmtFloat(padding) + "," + fmtFloat(padding/2) + ")",
-			Children:  []SVGElement{bannerElement},
-		})
-	}
-
-	if flagsElement != nil {
-		// Flags box sits against the right edge of the diagram
-		// (not the total width) so it stays aligned with the
-		// railroad diagram when the legend is wider.
-		children = append(children, &Group{
-			Transform: "translate(" + fmtFloat(diagramWidth-padding-flagsWidth+padding/2) + "," + fmtFloat(bannerHeight+padding) + ")",
-			Children:  []SVGElement{flagsElement},
-		})
-	}
-
-	// Place legend below the diagram.
-	legendY := bannerHeight + diagramHeight + legendGap
-	children = append(children, &Group{
-		Transform: "translate(" + fmtFloat(padding) + "," + fmtFloat(legendY) + ")",
-		Children:  []SVGElement{legend.Element},
-		Class:     "analysis-legend",
+	f.below = append(f.below, RenderedNode{
+		Element: &Group{Class: "analysis-legend", Children: []SVGElement{legend.Element}},
+		BBox:    legend.BBox,
 	})
-
-	svg := &SVG{
-		Width:    totalWidth,
-		Height:   totalHeight,
-		ViewBox:  "0 0 " + fmtFloat(totalWidth) + " " + fmtFloat(totalHeight),
-		Defs:     r.getDefs(),
-		Style:    r.getStyles() + r.getAnnotationStyles(),
-		Children: children,
-	}
-
-	return svg.Render()
+	f.style = r.getAnnotationStyles()
+	return r.compose(f)
 }
 
 // ================================================================================
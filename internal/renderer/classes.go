@@ -0,0 +1,112 @@
+package renderer
+
+import (
+	"github.com/0x4d5352/regolith/internal/flavor"
+)
+
+// ================================================================================
+// CSS Class Names
+// ================================================================================
+
+// ClassInfo describes one CSS class the renderer puts on SVG elements.
+// Stylesheets and tests outside regolith select on these names, so they
+// are a compatibility contract: a class may be added, but renaming or
+// removing one breaks its users.
+type ClassInfo struct {
+	Name string `json:"name"`
+	// Kind groups the classes: "node" for the boxes NodeStyles colors,
+	// "structure" for containers, "label" for text inside them,
+	// "modifier" for classes added next to another one, "connector"
+	// for track paths, "source" for the --show-source line, and
+	// "analysis" for annotated diagrams.
+	Kind string `json:"kind"`
+	// Element is the SVG element the class is put on.
+	Element     string `json:"element"`
+	Description string `json:"description"`
+}
+
+// Classes lists every CSS class Render, RenderPages, RenderStack, and
+// annotated rendering can emit, grouped by kind. TestClassesComplete
+// renders a corpus covering every node type and option and fails on
+// any class missing from it, so a new class must be added here to ship.
+var Classes = classList()
+
+func classList() []ClassInfo {
+	classes := []ClassInfo{
+		{"literal", "node", "g", "A literal string, drawn quoted"},
+		{"escape", "node", "g", "An escape sequence, back-reference, or Unicode property"},
+		{"charset", "node", "g", "A character class"},
+		{"anchor", "node", "g", "An anchor or word boundary"},
+		{"any-character", "node", "g", "The . wildcard"},
+		{"flags", "node", "g", "The flags panel, or an inline modifier group"},
+		{"recursive-ref", "node", "g", "A recursion or subroutine call"},
+		{"callout", "node", "g", "A PCRE callout"},
+		{"backtrack-control", "node", "g", "A backtracking control verb such as (*SKIP)"},
+		{"conditional", "node", "g", "A conditional group"},
+		{"comment", "node", "g", "A comment"},
+		{"unknown", "node", "g", "A node this renderer does not know how to draw"},
+
+		{"regexp", "structure", "g", "An alternation: the branches stacked between their connectors"},
+		{"match", "structure", "g", "One sequence of items along the track"},
+		{"subexp", "structure", "g", "A group box"},
+		{"repeat", "structure", "g", "A quantified item with its loop and skip paths"},
+		{"flattened", "structure", "g", "A branch lifted out of a nested alternation; its title tells where from"},
+		{"branch-number", "structure", "g", "A numbered alternation branch (NumberAlternatives)"},
+		{"condition-yes", "structure", "g", "The then branch of a conditional"},
+		{"condition-no", "structure", "g", "The else branch of a conditional"},
+		{"pattern-options", "structure", "g", "The banner of pattern start options such as (*UTF)"},
+		{"chip", "structure", "g", "A group reduced to a labeled chip in a summary diagram"},
+		{"recursion-links", "structure", "g", "The dashed links from recursive calls to their targets"},
+		{"page-marker", "structure", "g", "The continuation marks of a paginated diagram"},
+		{"ruler", "structure", "g", "The column ruler under the source line"},
+		{"group-legend", "structure", "g", "The capture group legend beside the diagram (GroupLegend)"},
+		{"group-legend-entry", "structure", "g", "One group's row in the legend, inside a link to the group's box"},
+		{"stack-entry", "structure", "g", "One pattern, with its title, in a RenderStack document"},
+		{"wrapped", "structure", "g", "A top-level sequence folded onto rows (WrapWidth)"},
+
+		{"quote", "label", "tspan", "The quote marks around a literal"},
+		{"subexp-label", "label", "text", "A group's name or number"},
+		{"charset-label", "label", "text", "A character class header such as \"One of:\""},
+		{"flags-label", "label", "text", "The flags panel header"},
+		{"conditional-label", "label", "text", "A conditional's condition"},
+		{"condition-label", "label", "g", "The then and else markers of a conditional"},
+		{"repeat-label", "label", "text", "A quantifier's count, such as \"1+ times\""},
+		{"pattern-options-label", "label", "text", "The pattern start options header"},
+		{"comment-text", "label", "text", "The text of a comment"},
+		{"group-legend-title", "label", "text", "The capture group legend's title"},
+		{"group-legend-label", "label", "text", "A group's number and name in the legend"},
+		{"group-legend-snippet", "label", "text", "The start of a group's subpattern in the legend"},
+		{"pattern-title", "label", "text", "A pattern's title in a RenderStack document"},
+		{"diagram-title", "label", "text", "A line of the title above the diagram (Title)"},
+		{"diagram-caption", "label", "text", "A line of the caption below the diagram (Caption)"},
+		{"grapheme-note", "label", "text", "The note under an escape or anchor that works on grapheme clusters"},
+		{"grapheme-icon", "label", "circle, text", "The icon of a grapheme-cluster escape or anchor"},
+
+		{"grapheme", "modifier", "g", "Added to escape or anchor when it works on grapheme clusters"},
+		{"lazy-preferred", "modifier", "path", "Added to skip-path when a lazy quantifier prefers to stop (skip-first layout)"},
+		{"lazy-secondary", "modifier", "path", "Added to loop-path when a lazy quantifier repeats only on backtracking (skip-first layout)"},
+
+		{"skip-path", "connector", "path", "The path around an optional item"},
+		{"loop-path", "connector", "path", "The path back for a repeated item"},
+		{"lookaround-spur", "connector", "path", "The dashed spur to a lookaround drawn off the track"},
+		{"recursion-link", "connector", "path", "A dashed link from a recursive call to its target group"},
+		{"wrap-path", "connector", "path", "The track into, between, and out of the rows of a wrapped diagram"},
+
+		{"source", "source", "text", "The pattern drawn under the diagram (--show-source)"},
+		{"source-markers", "source", "g", "The markers under the source line spanning each token"},
+	}
+	for _, kind := range flavor.TokenKinds {
+		classes = append(classes, ClassInfo{
+			"src-" + string(kind), "source", "tspan",
+			"A " + string(kind) + " token in the source line",
+		})
+	}
+	return append(classes,
+		ClassInfo{"analysis-border", "analysis", "rect", "The dashed border around a node with a finding"},
+		ClassInfo{"analysis-badge", "analysis", "circle", "The severity badge on a node with a finding"},
+		ClassInfo{"analysis-badge-label", "analysis", "text", "The severity mark inside a badge"},
+		ClassInfo{"analysis-legend", "analysis", "g", "The findings legend under an annotated diagram"},
+		ClassInfo{"analysis-legend-title", "analysis", "text", "The legend's title"},
+		ClassInfo{"analysis-suggestion", "analysis", "text", "A finding's suggestion in the legend"},
+	)
+}
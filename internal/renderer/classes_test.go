@@ -0,0 +1,97 @@
+package renderer
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/0x4d5352/regolith/internal/analyzer"
+	"github.com/0x4d5352/regolith/internal/ast"
+	"github.com/0x4d5352/regolith/internal/flavor"
+	_ "github.com/0x4d5352/regolith/internal/flavor/java"
+	_ "github.com/0x4d5352/regolith/internal/flavor/javascript"
+	_ "github.com/0x4d5352/regolith/internal/flavor/pcre"
+)
+
+// TestClassesComplete renders a corpus that reaches every node type and
+// option, and checks that the classes it emits are exactly Classes.
+func TestClassesComplete(t *testing.T) {
+	tests := []struct {
+		flavor, pattern string
+		setup           func(r *Renderer, root *ast.Regexp)
+	}{
+		{"javascript", `/^(?<y>\d{4})-[a-z\d]+.\b(?=x)(?!y)$/gi`, nil},
+		{"javascript", `a*?b+?`, func(r *Renderer, _ *ast.Regexp) { r.Config.Connector.LazyLayout = "skip-first" }},
+		{"javascript", `(?=.*\d)\w+`, func(r *Renderer, _ *ast.Regexp) { r.Config.LookaroundLayout = "above" }},
+		{"javascript", `a|b|(?:c|d)`, func(r *Renderer, root *ast.Regexp) {
+			r.Config.NumberAlternatives = true
+			ast.FlattenAlternations(root)
+		}},
+		{"javascript", `/(a)|[b]+\d?.$/`, func(r *Renderer, _ *ast.Regexp) {
+			f, _ := flavor.Get("javascript")
+			r.Source = flavor.Tokens(f, `/(a)|[b]+\d?.$/`)
+			r.Ruler = true
+		}},
+		{"javascript", `(a)(b)c`, func(r *Renderer, _ *ast.Regexp) { r.Summary = true }},
+		{"javascript", `(?<x>a)b`, func(r *Renderer, _ *ast.Regexp) { r.GroupLegend, r.Pattern = true, `(?<x>a)b` }},
+		{"javascript", `abc\d+xyz`, func(r *Renderer, _ *ast.Regexp) { r.Config.WrapWidth = 100 }},
+		{"javascript", `a`, func(r *Renderer, _ *ast.Regexp) { r.Title, r.Caption = "Title", "Caption" }},
+		{"pcre", `(*UTF)(?#note)(a(?1)?b)(?C1)(*SKIP)(?(1)x|y)\X(?i:z)`, func(r *Renderer, _ *ast.Regexp) {
+			f, _ := flavor.Get("pcre")
+			r.Source = flavor.Tokens(f, `(?#note)a`)
+		}},
+		{"java", `\b{g}`, nil},
+	}
+
+	seen := make(map[string]bool)
+	collect := func(svg string) {
+		for _, m := range regexp.MustCompile(`class="([^"]*)"`).FindAllStringSubmatch(svg, -1) {
+			for _, name := range strings.Fields(m[1]) {
+				seen[name] = true
+			}
+		}
+	}
+	for _, tc := range tests {
+		f, _ := flavor.Get(tc.flavor)
+		root, err := f.Parse(tc.pattern)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.pattern, err)
+		}
+		r := New(DefaultConfig())
+		if tc.setup != nil {
+			tc.setup(r, root)
+		}
+		collect(r.Render(root))
+	}
+
+	f, _ := flavor.Get("javascript")
+	long, _ := f.Parse(`abc\d+def\s*ghi[jkl]mno`)
+	for _, page := range New(DefaultConfig()).RenderPages(long, 150) {
+		collect(page)
+	}
+	first, _ := f.Parse(`a+`)
+	second, _ := f.Parse(`b|c`)
+	collect(New(DefaultConfig()).RenderStack([]StackEntry{{Title: "a+", Root: first}, {Title: "b|c", Root: second}}))
+	slow, _ := f.Parse(`.*.*=.*`)
+	report := analyzer.Analyze(slow, `.*.*=.*`, "javascript", f.SupportedFeatures())
+	collect(New(DefaultConfig()).RenderAnnotated(slow, report))
+
+	listed := make(map[string]bool)
+	for _, c := range Classes {
+		if listed[c.Name] {
+			t.Errorf("class %q listed twice", c.Name)
+		}
+		listed[c.Name] = true
+	}
+	for name := range seen {
+		if !listed[name] {
+			t.Errorf("class %q is emitted but missing from Classes", name)
+		}
+	}
+	for name := range listed {
+		// unknown only appears for a node type the renderer lacks.
+		if !seen[name] && name != "unknown" {
+			t.Errorf("class %q is listed but the corpus never emits it", name)
+		}
+	}
+}
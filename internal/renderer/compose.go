@@ -0,0 +1,186 @@
+package renderer
+
+// ================================================================================
+// Document Composition
+// ================================================================================
+
+// frame holds the parts of an SVG document before they are placed: the
+// diagram and the panels drawn around it, each rendered at its own
+// origin.
+type frame struct {
+	diagram RenderedNode   // The railroad diagram, from renderRoot
+	exitY   float64        // Where the track leaves the diagram, from renderRoot
+	side    []RenderedNode // Right of the diagram, top-aligned with it: the flags panel, the group legend
+	above   []RenderedNode // Over the diagram: the pattern options banner
+	below   []RenderedNode // Under it: the source line, the findings legend
+	style   string         // CSS added after getStyles
+}
+
+// compose places the parts of f and returns the SVG document. It works
+// in two passes. The first only measures: SpaceHorizontally lines up
+// the diagram, with its start and end connectors, and the side panels
+// in a row, and SpaceVertically stacks the parts above, that row, and
+// the parts below into a column. The second draws each part where the
+// first put it. Every position and the document size follow from the
+// sizes of all the parts, so a long flag list or a wide banner pushes
+// the rest aside instead of overlapping it or being clipped, and the
+// end connector stays on the diagram however wide the document gets.
+// The title goes above every other part and the caption below.
+func (r *Renderer) compose(f frame) string {
+	if r.Title != "" {
+		f.above = append([]RenderedNode{r.renderTitle()}, f.above...)
+	}
+	if r.Caption != "" {
+		f.below = append(f.below, r.renderCaption())
+	}
+	placed := r.place(f)
+	return r.document(placed.Element.(*Group).Children, placed.BBox.Width, placed.BBox.Height, f.style)
+}
+
+// place runs both passes of compose and returns the placed parts as
+// one group, sized to the document they would fill, without the
+// document itself or its background.
+func (r *Renderer) place(f frame) RenderedNode {
+	padding := r.Config.Padding
+	leftMargin := contentLeftMargin(padding)
+	rightMargin := contentRightMargin(padding)
+
+	// Pass one. Every box gets the zero AnchorY, so SpaceHorizontally
+	// aligns the row's tops.
+	row := []RenderedNode{{BBox: BoundingBox{
+		Width:  leftMargin + f.diagram.BBox.Width + rightMargin,
+		Height: f.diagram.BBox.Height,
+	}}}
+	for _, p := range f.side {
+		row = append(row, RenderedNode{BBox: BoundingBox{Width: p.BBox.Width, Height: p.BBox.Height}})
+	}
+	row, rowBox := SpaceHorizontally(row, padding/2)
+	if len(f.side) > 0 {
+		// The track ends in its own edge clearance; the panels do not.
+		rowBox.Width += padding
+	}
+
+	// The parts above and below are inset by padding on either side.
+	// Giving every box in the column the same width keeps
+	// SpaceVertically from centering them, so they stay left-aligned.
+	heights := make([]float64, 0, len(f.above)+1+len(f.below))
+	width := rowBox.Width
+	for _, p := range append(append([]RenderedNode{}, f.above...), f.below...) {
+		width = max(width, p.BBox.Width+2*padding)
+	}
+	for _, p := range f.above {
+		heights = append(heights, p.BBox.Height)
+	}
+	heights = append(heights, rowBox.Height)
+	for _, p := range f.below {
+		heights = append(heights, p.BBox.Height)
+	}
+	column := make([]RenderedNode, len(heights))
+	for i, h := range heights {
+		column[i] = RenderedNode{BBox: BoundingBox{Width: width, Height: h}}
+	}
+	column, columnBox := SpaceVertically(column, padding)
+	margin := padding
+	if len(f.above) > 0 {
+		// The banner sits closer to the top edge than the diagram.
+		margin = padding / 2
+	}
+	top := func(i int) float64 { return margin + column[i].BBox.Y }
+	height := margin + columnBox.Height + padding
+
+	var pageMarkers RenderedNode
+	pageY := height - padding/2
+	if r.page != nil {
+		pageMarkers = r.renderPageMarkers(width)
+		width = pageMarkers.BBox.Width
+		height += pageMarkers.BBox.Height
+	}
+
+	// Pass two.
+	var children []SVGElement
+
+	rowY := top(len(f.above))
+	anchorY := rowY + f.diagram.BBox.AnchorY
+	exitY := rowY + f.exitY
+	contentEndX := leftMargin + f.diagram.BBox.Width
+	endLine := &Line{
+		X1:          contentEndX,
+		Y1:          exitY,
+		X2:          contentEndX + visibleConnectorWidth + endDotRadius,
+		Y2:          exitY,
+		Stroke:      r.Config.Connector.Color,
+		StrokeWidth: r.Config.Connector.StrokeWidth,
+		MarkerEnd:   endMarkerRef(r.Config.Connector.EndMarker),
+	}
+	if r.page != nil && r.page.Number < r.page.Total {
+		// The match does not end here; the next page carries on.
+		endLine.MarkerEnd = ""
+	}
+	children = append(children,
+		&Line{
+			X1:          padding / 2,
+			Y1:          anchorY,
+			X2:          leftMargin,
+			Y2:          anchorY,
+			Stroke:      r.Config.Connector.Color,
+			StrokeWidth: r.Config.Connector.StrokeWidth,
+			MarkerStart: startMarkerRef(r.Config.Connector.StartMarker),
+		},
+		endLine,
+		translated(f.diagram.Element, leftMargin, rowY),
+	)
+
+	for i, p := range f.above {
+		children = append(children, translated(p.Element, padding, top(i)))
+	}
+	for i, p := range f.side {
+		children = append(children, translated(p.Element, row[i+1].BBox.X, rowY))
+	}
+	for i, p := range f.below {
+		children = append(children, translated(p.Element, padding, top(len(f.above)+1+i)))
+	}
+	if r.page != nil {
+		children = append(children, translated(pageMarkers.Element, 0, pageY))
+	}
+	return RenderedNode{Element: &Group{Children: children}, BBox: NewBoundingBox(0, 0, width, height)}
+}
+
+// document wraps children, drawn in a width by height area, in the SVG
+// document with its background, defs and stylesheet, plus style, and
+// scales it down to MaxWidth and MaxHeight. OnDocument sees it here.
+func (r *Renderer) document(children []SVGElement, width, height float64, style string) string {
+	if r.Config.BackgroundFill != "" {
+		children = append([]SVGElement{&Rect{Width: width, Height: height, Fill: r.Config.BackgroundFill}}, children...)
+	}
+	svg := &SVG{
+		Width:    width,
+		Height:   height,
+		ViewBox:  "0 0 " + fmtFloat(width) + " " + fmtFloat(height),
+		Defs:     r.getDefs(),
+		Style:    r.getStyles() + style,
+		Children: children,
+		Comment:  r.provenanceComment(),
+	}
+	scale := 1.0
+	if limit := r.Config.MaxWidth; limit > 0 && width > limit {
+		scale = limit / width
+	}
+	if limit := r.Config.MaxHeight; limit > 0 && height*scale > limit {
+		scale = limit / height
+	}
+	if scale < 1 {
+		svg.Width, svg.Height = width*scale, height*scale
+	}
+	if r.OnDocument != nil {
+		r.OnDocument(svg)
+	}
+	return svg.Render()
+}
+
+// translated wraps e in a group moved to x, y.
+func translated(e SVGElement, x, y float64) SVGElement {
+	return &Group{
+		Transform: "translate(" + fmtFloat(x) + "," + fmtFloat(y) + ")",
+		Children:  []SVGElement{e},
+	}
+}
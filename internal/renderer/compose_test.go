@@ -0,0 +1,67 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestComposeLayout checks that compose places every part of a frame
+// clear of the others, whichever is widest or tallest.
+func TestComposeLayout(t *testing.T) {
+	part := func(class string, w, h float64) RenderedNode {
+		return RenderedNode{Element: &Group{Class: class}, BBox: BoundingBox{Width: w, Height: h}}
+	}
+	cfg := DefaultConfig()
+	cfg.Padding = 10
+	r := New(cfg)
+	svg := r.compose(frame{
+		diagram: RenderedNode{Element: &Group{Class: "diagram"}, BBox: BoundingBox{Width: 100, Height: 20, AnchorY: 10}},
+		exitY:   10,
+		side:    []RenderedNode{part("tall", 50, 200), part("narrow", 30, 10)},
+		above:   []RenderedNode{part("wide", 500, 20)},
+		below:   []RenderedNode{part("source", 40, 10)},
+	})
+	for _, want := range []string{
+		`width="520" height="265"`,
+		// The end connector starts where the diagram ends, not at the
+		// right edge of the wider banner.
+		`<line x1="125" y1="45" x2="138"`,
+		`<g transform="translate(25,35)"><g class="diagram">`,
+		`<g transform="translate(10,5)"><g class="wide">`,
+		`<g transform="translate(151,35)"><g class="tall">`,
+		`<g transform="translate(206,35)"><g class="narrow">`,
+		// Below the tall side panel, not the diagram.
+		`<g transform="translate(10,245)"><g class="source">`,
+	} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("missing %s in\n%s", want, svg)
+		}
+	}
+}
+
+// TestComposeMaxSize checks that MaxWidth and MaxHeight scale the
+// document by whichever limit needs more, keeping its viewBox.
+func TestComposeMaxSize(t *testing.T) {
+	tests := []struct {
+		maxWidth, maxHeight float64
+		want                string
+	}{
+		{0, 0, `width="146" height="40" viewBox="0 0 146 40"`},
+		{292, 80, `width="146" height="40" viewBox="0 0 146 40"`},
+		{73, 0, `width="73" height="20" viewBox="0 0 146 40"`},
+		{0, 10, `width="36.5" height="10" viewBox="0 0 146 40"`},
+		{73, 10, `width="36.5" height="10" viewBox="0 0 146 40"`},
+	}
+	for _, tc := range tests {
+		cfg := DefaultConfig()
+		cfg.Padding = 10
+		cfg.MaxWidth, cfg.MaxHeight = tc.maxWidth, tc.maxHeight
+		svg := New(cfg).compose(frame{
+			diagram: RenderedNode{Element: &Group{}, BBox: BoundingBox{Width: 100, Height: 20, AnchorY: 10}},
+			exitY:   10,
+		})
+		if !strings.Contains(svg, tc.want) {
+			t.Errorf("max %gx%g: want %s in\n%.200s", tc.maxWidth, tc.maxHeight, tc.want, svg)
+		}
+	}
+}
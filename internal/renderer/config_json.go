@@ -0,0 +1,72 @@
+package renderer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// ================================================================================
+// JSON Import/Export
+// ================================================================================
+
+// A renderer configuration file is the JSON form of Config, with every
+// field under its camelCase name:
+//
+//	{
+//	  "padding": 10,
+//	  "nodeStyles": {"literal": {"fill": "#fee2e2"}},
+//	  "subexpColors": ["#dbeafe", "#dcfce7"],
+//	  "connector": {"color": "#64748b"}
+//	}
+//
+// Export writes every field, so the file documents the whole look of a
+// diagram; import applies only the fields a file names, so a team can
+// keep just the options it changed and layer them over a theme.
+
+// MarshalJSON writes every field of c.
+func (c *Config) MarshalJSON() ([]byte, error) {
+	type plain Config
+	return json.Marshal((*plain)(c))
+}
+
+// UnmarshalJSON applies a configuration file to c. Fields the file
+// leaves out keep their current value, and a node style is merged per
+// field, so {"nodeStyles": {"literal": {"fill": "#fff"}}} changes one
+// fill and nothing else. SubexpColors is replaced as a whole. Unknown
+// fields are an error, so a misspelled option is not silently ignored.
+func (c *Config) UnmarshalJSON(data []byte) error {
+	type plain Config
+	doc := struct {
+		*plain
+		NodeStyles map[string]json.RawMessage `json:"nodeStyles"`
+	}{plain: (*plain)(c)}
+	if err := decodeStrict(data, &doc); err != nil {
+		return err
+	}
+	if len(doc.NodeStyles) > 0 && c.NodeStyles == nil {
+		c.NodeStyles = make(map[string]NodeStyle, len(doc.NodeStyles))
+	}
+	for class, raw := range doc.NodeStyles {
+		style := c.NodeStyles[class]
+		if err := decodeStrict(raw, &style); err != nil {
+			return fmt.Errorf("nodeStyles.%s: %w", class, err)
+		}
+		c.NodeStyles[class] = style
+	}
+	return nil
+}
+
+// decodeStrict decodes a single JSON value into v, rejecting unknown
+// fields.
+func decodeStrict(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if dec.More() {
+		return fmt.Errorf("unexpected data after the configuration")
+	}
+	return nil
+}
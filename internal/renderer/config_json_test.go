@@ -0,0 +1,89 @@
+package renderer
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConfigJSONRoundTrip(t *testing.T) {
+	want := DefaultConfig()
+	want.SubexpColors = []string{"#111", "#222"}
+	want.Connector.LazyLayout = "skip-first"
+	data, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Config
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&got, want) {
+		t.Errorf("round trip changed the config:\n got %+v\nwant %+v", got, *want)
+	}
+}
+
+// TestConfigJSONNamesEveryField keeps new Config options reachable from
+// a configuration file.
+func TestConfigJSONNamesEveryField(t *testing.T) {
+	for _, typ := range []reflect.Type{reflect.TypeOf(Config{}), reflect.TypeOf(NodeStyle{}), reflect.TypeOf(ConnectorStyle{})} {
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			want := strings.ToLower(f.Name[:1]) + f.Name[1:]
+			if name != want {
+				t.Errorf("%s.%s: json name %q, want %q", typ.Name(), f.Name, name, want)
+			}
+		}
+	}
+}
+
+func TestConfigJSONOverlay(t *testing.T) {
+	cfg := DefaultConfig()
+	literal := cfg.NodeStyles["literal"]
+	doc := `{
+		"padding": 24,
+		"subexpColors": ["#abc"],
+		"nodeStyles": {"literal": {"fill": "#fff"}, "custom": {"fill": "#000"}},
+		"connector": {"color": "#123"}
+	}`
+	if err := json.Unmarshal([]byte(doc), cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Padding != 24 {
+		t.Errorf("Padding = %g, want 24", cfg.Padding)
+	}
+	if cfg.FontSize != DefaultConfig().FontSize {
+		t.Errorf("FontSize = %g, want it left alone", cfg.FontSize)
+	}
+	if !reflect.DeepEqual(cfg.SubexpColors, []string{"#abc"}) {
+		t.Errorf("SubexpColors = %v, want [#abc]", cfg.SubexpColors)
+	}
+	got := cfg.NodeStyles["literal"]
+	if got.Fill != "#fff" || got.Stroke != literal.Stroke || got.TextColor != literal.TextColor {
+		t.Errorf("literal style = %+v, want only the fill changed from %+v", got, literal)
+	}
+	if cfg.NodeStyles["custom"].Fill != "#000" {
+		t.Errorf("custom style = %+v, want a new entry", cfg.NodeStyles["custom"])
+	}
+	if cfg.NodeStyles["charset"] != DefaultConfig().NodeStyles["charset"] {
+		t.Errorf("charset style changed: %+v", cfg.NodeStyles["charset"])
+	}
+	if cfg.Connector.Color != "#123" || cfg.Connector.StrokeWidth != DefaultConfig().Connector.StrokeWidth {
+		t.Errorf("Connector = %+v, want only the color changed", cfg.Connector)
+	}
+}
+
+func TestConfigJSONErrors(t *testing.T) {
+	for _, doc := range []string{
+		`{"paddin": 10}`,
+		`{"nodeStyles": {"literal": {"fil": "#fff"}}}`,
+		`{"padding": "10"}`,
+		`{"padding": 10} {}`,
+	} {
+		if err := json.Unmarshal([]byte(doc), DefaultConfig()); err == nil {
+			t.Errorf("%s: no error", doc)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package renderer
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// WCAGMinContrast is the WCAG 2.x AA minimum contrast ratio for normal
+// body text. Every label regolith draws is well under the 18pt "large
+// text" threshold, so the stricter 4.5:1 ratio applies throughout.
+const WCAGMinContrast = 4.5
+
+// ContrastIssue describes a foreground/background pair whose contrast
+// ratio falls below WCAGMinContrast.
+type ContrastIssue struct {
+	Element    string  // What the pair styles, e.g. "literal text"
+	Foreground string  // Configured text color
+	Background string  // Configured fill (or effective background)
+	Ratio      float64 // Computed WCAG contrast ratio
+}
+
+func (c ContrastIssue) String() string {
+	return fmt.Sprintf("%s: %s on %s has contrast %.2f:1 (WCAG AA needs %.1f:1)",
+		c.Element, c.Foreground, c.Background, c.Ratio, WCAGMinContrast)
+}
+
+// CheckContrast validates every text/fill pair the renderer will emit
+// for cfg and returns the pairs that fail WCAG AA. Colors that cannot
+// be resolved to an opaque RGB value ("none", "transparent", CSS names
+// outside the small table below) are skipped rather than guessed at,
+// so a nil result means "no provable problem", not "verified legible".
+//
+// Transparent fills (outermost subexpressions, "none" node fills) are
+// checked against the effective page background: BackgroundFill when
+// set, otherwise the theme's advisory BackgroundColor.
+func CheckContrast(cfg *Config) []ContrastIssue {
+	var issues []ContrastIssue
+	bg := cfg.BackgroundFill
+	if bg == "" {
+		bg = cfg.BackgroundColor
+	}
+
+	check := func(element, fg, fill string) {
+		if _, ok := parseColor(fill); !ok {
+			fill = bg
+		}
+		ratio, ok := ContrastRatio(fg, fill)
+		if ok && ratio < WCAGMinContrast {
+			issues = append(issues, ContrastIssue{
+				Element:    element,
+				Foreground: fg,
+				Background: fill,
+				Ratio:      ratio,
+			})
+		}
+	}
+
+	// Walk categories in the same stable order getStyles uses so the
+	// warning list reads top-to-bottom like the emitted stylesheet.
+	for _, class := range styleCategories {
+		style, ok := cfg.NodeStyles[class]
+		if !ok {
+			continue
+		}
+		check(class+" text", style.TextColor, style.Fill)
+	}
+
+	// Subexpression labels inherit the base text color and sit on the
+	// depth-cycled fill of their own box.
+	check("group label (depth 0)", cfg.TextColor, cfg.SubexpFill)
+	for i, fill := range cfg.SubexpColors {
+		check(fmt.Sprintf("group label (depth %d)", i+1), cfg.TextColor, fill)
+	}
+
+	check("repeat label", cfg.RepeatLabelColor, bg)
+	return issues
+}
+
+// ContrastRatio returns the WCAG 2.x contrast ratio between two colors.
+// ok is false when either color cannot be resolved to an opaque RGB
+// value.
+func ContrastRatio(a, b string) (ratio float64, ok bool) {
+	ca, ok := parseColor(a)
+	if !ok {
+		return 0, false
+	}
+	cb, ok := parseColor(b)
+	if !ok {
+		return 0, false
+	}
+	la, lb := relativeLuminance(ca), relativeLuminance(cb)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05), true
+}
+
+// rgb is an opaque sRGB color with 8-bit channels.
+type rgb struct{ r, g, b uint8 }
+
+// namedColors covers the CSS keywords users most commonly pass to the
+// --*-fill flags. It is not the full CSS table; unknown names are
+// reported as unresolvable rather than mapped to a guess.
+var namedColors = map[string]rgb{
+	"black":  {0, 0, 0},
+	"white":  {255, 255, 255},
+	"red":    {255, 0, 0},
+	"green":  {0, 128, 0},
+	"blue":   {0, 0, 255},
+	"yellow": {255, 255, 0},
+	"orange": {255, 165, 0},
+	"purple": {128, 0, 128},
+	"gray":   {128, 128, 128},
+	"grey":   {128, 128, 128},
+	"silver": {192, 192, 192},
+	"navy":   {0, 0, 128},
+}
+
+// parseColor resolves #rgb, #rrggbb, and the keywords in namedColors.
+func parseColor(s string) (rgb, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if c, ok := namedColors[s]; ok {
+		return c, true
+	}
+	if !strings.HasPrefix(s, "#") {
+		return rgb{}, false
+	}
+	hex := s[1:]
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return rgb{}, false
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return rgb{}, false
+	}
+	return rgb{uint8(v >> 16), uint8(v >> 8), uint8(v)}, true
+}
+
+// relativeLuminance implements the WCAG 2.x definition over sRGB.
+func relativeLuminance(c rgb) float64 {
+	channel := func(v uint8) float64 {
+		f := float64(v) / 255
+		if f <= 0.03928 {
+			return f / 12.92
+		}
+		return math.Pow((f+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(c.r) + 0.7152*channel(c.g) + 0.0722*channel(c.b)
+}
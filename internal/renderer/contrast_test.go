@@ -0,0 +1,71 @@
+package renderer
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestContrastRatioKnownValues(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"#000000", "#ffffff", 21},
+		{"#fff", "#fff", 1},
+		{"white", "black", 21},
+		{"#777777", "#ffffff", 4.48},
+	}
+	for _, tt := range tests {
+		got, ok := ContrastRatio(tt.a, tt.b)
+		if !ok {
+			t.Errorf("ContrastRatio(%q, %q): expected ok", tt.a, tt.b)
+			continue
+		}
+		if math.Abs(got-tt.want) > 0.01 {
+			t.Errorf("ContrastRatio(%q, %q) = %.3f, want %.2f", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestContrastRatioUnresolvable(t *testing.T) {
+	for _, c := range []string{"none", "transparent", "rebeccapurple", "#12345", ""} {
+		if _, ok := ContrastRatio(c, "#ffffff"); ok {
+			t.Errorf("ContrastRatio(%q, ...) should not resolve", c)
+		}
+	}
+}
+
+func TestCheckContrastFlagsIllegiblePair(t *testing.T) {
+	cfg := DefaultConfig()
+	s := cfg.NodeStyles["anchor"]
+	s.TextColor = "#3a4a5a" // dark text on the dark slate anchor fill
+	cfg.NodeStyles["anchor"] = s
+
+	var found bool
+	for _, issue := range CheckContrast(cfg) {
+		if issue.Element == "anchor text" {
+			found = true
+			if !strings.Contains(issue.String(), "#3a4a5a on #334155") {
+				t.Errorf("unexpected issue text: %s", issue)
+			}
+		}
+	}
+	if !found {
+		t.Error("expected anchor text contrast issue")
+	}
+}
+
+func TestCheckContrastTransparentFillUsesBackground(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.NodeStyles = map[string]NodeStyle{
+		"literal": {Fill: "none", TextColor: "#111111"},
+	}
+	cfg.SubexpColors = nil
+	cfg.BackgroundFill = "#000000"
+
+	issues := CheckContrast(cfg)
+	if len(issues) == 0 || issues[0].Element != "literal text" || issues[0].Background != "#000000" {
+		t.Errorf("expected literal text checked against background fill, got %v", issues)
+	}
+}
@@ -0,0 +1,70 @@
+package renderer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// ================================================================================
+// Font Embedding and Text Fitting
+// ================================================================================
+
+// embeddedFontFamily is the family name EmbedFont declares its font
+// under.
+const embeddedFontFamily = "regolith-embedded"
+
+// fontTypes maps the signature a font file starts with to its media
+// type.
+var fontTypes = []struct {
+	magic     string
+	mediaType string
+}{
+	{"wOF2", "font/woff2"},
+	{"wOFF", "font/woff"},
+	{"OTTO", "font/otf"},
+	{"\x00\x01\x00\x00", "font/ttf"},
+	{"true", "font/ttf"},
+}
+
+// EmbedFont makes font, the contents of a WOFF2, WOFF, TrueType or
+// OpenType file, the content font of every diagram c draws: it goes
+// into the SVG's style as a base64 data URL under @font-face, and
+// FontFamily names it first, keeping the old families as fallbacks.
+// Subsetting a font to ASCII before embedding keeps the SVG small.
+func (c *Config) EmbedFont(font []byte) error {
+	mediaType := ""
+	for _, t := range fontTypes {
+		if bytes.HasPrefix(font, []byte(t.magic)) {
+			mediaType = t.mediaType
+			break
+		}
+	}
+	if mediaType == "" {
+		return errors.New("not a WOFF2, WOFF, TrueType or OpenType font")
+	}
+	c.FontFace = "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(font)
+	if !strings.HasPrefix(c.FontFamily, embeddedFontFamily+",") {
+		c.FontFamily = embeddedFontFamily + ", " + c.FontFamily
+	}
+	return nil
+}
+
+// fontFaceRule returns the @font-face rule for Config.FontFace, or ""
+// when no font is embedded.
+func (r *Renderer) fontFaceRule() string {
+	if r.Config.FontFace == "" {
+		return ""
+	}
+	return "\n\t\t@font-face { font-family: " + embeddedFontFamily + "; src: url(\"" + r.Config.FontFace + "\"); }"
+}
+
+// fitText pins t, content text measured at width, to that width when
+// Config.TextLength is set.
+func (r *Renderer) fitText(t *Text, width float64) *Text {
+	if r.Config.TextLength {
+		t.TextLength = width
+	}
+	return t
+}
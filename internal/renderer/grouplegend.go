@@ -0,0 +1,143 @@
+package renderer
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	parser "github.com/0x4d5352/regolith/internal/ast"
+)
+
+// ================================================================================
+// Capture Group Legend
+// ================================================================================
+
+// legendSnippetRunes is how much of a group's subpattern a legend entry
+// quotes before cutting it short with "...".
+const legendSnippetRunes = 32
+
+// groupAnchorID returns the id given to the box of capturing group n,
+// which legend entries link to. RenderStack prefixes it per pattern so
+// the groups of one pattern do not take the ids of another's.
+func (r *Renderer) groupAnchorID(n int) string {
+	return r.anchorPrefix + "group-" + strconv.Itoa(n)
+}
+
+// anchorGroup gives the box of a capturing group the id its legend
+// entry links to, when Render is collecting them for GroupLegend. Only
+// the first box drawn for a number gets it: a branch reset group or a
+// duplicate .NET name can number several groups alike.
+func (r *Renderer) anchorGroup(subexp *parser.Subexp, rn RenderedNode) {
+	if r.groupAnchors == nil || subexp.Number == 0 || r.groupAnchors[subexp.Number] {
+		return
+	}
+	if subexp.GroupType != parser.GroupCapture && subexp.GroupType != parser.GroupNamedCapture {
+		return
+	}
+	if g, ok := rn.Element.(*Group); ok {
+		g.ID = r.groupAnchorID(subexp.Number)
+		r.groupAnchors[subexp.Number] = true
+	}
+}
+
+// renderGroupLegend draws the GroupLegend panel for root: one row per
+// capturing group with its number, name, and the start of its
+// subpattern as written in Pattern. A row links to its group's box when
+// the diagram drew one; groups folded into a summary chip, or numbered
+// like an earlier group, are listed without a link. It reports false
+// when root has no capturing groups.
+func (r *Renderer) renderGroupLegend(root *parser.Regexp) (RenderedNode, bool) {
+	var groups []*parser.Subexp
+	listed := make(map[int]bool)
+	parser.Walk(root, func(n parser.Node) {
+		s, ok := n.(*parser.Subexp)
+		if !ok || listed[s.Number] || (s.GroupType != parser.GroupCapture && s.GroupType != parser.GroupNamedCapture) {
+			return
+		}
+		listed[s.Number] = true
+		groups = append(groups, s)
+	})
+	if len(groups) == 0 {
+		return RenderedNode{}, false
+	}
+
+	cfg := r.Config
+	lineHeight := cfg.FontSize + 6
+	title := "Capture groups"
+	children := []SVGElement{&Text{
+		Y:          cfg.FontSize,
+		Content:    title,
+		FontFamily: cfg.LabelFontFamily,
+		FontSize:   cfg.FontSize,
+		Fill:       cfg.TextColor,
+		Class:      "group-legend-title",
+	}}
+	width := MeasureLabelText(title, cfg)
+
+	labels := make([]string, len(groups))
+	labelWidth := 0.0
+	for i, s := range groups {
+		labels[i] = "#" + strconv.Itoa(s.Number)
+		if s.Name != "" {
+			labels[i] += " '" + s.Name + "'"
+		}
+		labelWidth = max(labelWidth, MeasureLabelText(labels[i], cfg))
+	}
+	snippetX := labelWidth + cfg.Padding
+
+	y := cfg.FontSize + lineHeight
+	for i, s := range groups {
+		row := &Group{Class: "group-legend-entry", Children: []SVGElement{&Text{
+			Y:          y,
+			Content:    labels[i],
+			FontFamily: cfg.LabelFontFamily,
+			FontSize:   cfg.LabelFontSize,
+			Fill:       cfg.TextColor,
+			Class:      "group-legend-label",
+		}}}
+		rowWidth := labelWidth
+		if full, snippet := r.groupSnippet(s); snippet != "" {
+			row.Children = append(row.Children, &Text{
+				X:          snippetX,
+				Y:          y,
+				Content:    snippet,
+				FontFamily: cfg.FontFamily,
+				FontSize:   cfg.LabelFontSize,
+				Fill:       cfg.TextColor,
+				Class:      "group-legend-snippet",
+			})
+			if snippet != full {
+				row.Children = append(row.Children, &Title{Content: full})
+			}
+			rowWidth = snippetX + MeasureText(snippet, cfg)
+		}
+		width = max(width, rowWidth)
+		var entry SVGElement = row
+		if r.groupAnchors[s.Number] {
+			entry = &Link{Href: "#" + r.groupAnchorID(s.Number), Children: []SVGElement{row}}
+		}
+		children = append(children, entry)
+		y += lineHeight
+	}
+
+	height := y - lineHeight + cfg.FontSize/2
+	return RenderedNode{
+		Element: &Group{Class: "group-legend", Children: children},
+		BBox:    BoundingBox{Width: width, Height: height},
+	}, true
+}
+
+// groupSnippet returns the text of s in Pattern, and that text cut to
+// legendSnippetRunes. Both are "" when s has no recorded position.
+func (r *Renderer) groupSnippet(s *parser.Subexp) (full, snippet string) {
+	pos := s.Pos()
+	if pos == (parser.Position{}) || pos.End > len(r.Pattern) || pos.Start > pos.End {
+		return "", ""
+	}
+	full = r.Pattern[pos.Start:pos.End]
+	if utf8.RuneCountInString(full) <= legendSnippetRunes {
+		return full, full
+	}
+	runes := []rune(full)
+	return full, strings.TrimRight(string(runes[:legendSnippetRunes-3]), " ") + "..."
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/0x4d5352/regolith/internal/flavor/posix_bre"
 	"github.com/0x4d5352/regolith/internal/flavor/posix_ere"
 	"github.com/0x4d5352/regolith/internal/parser"
+	"github.com/0x4d5352/regolith/internal/svgtest"
 )
 
 // Integration tests that verify the complete pipeline works
@@ -259,8 +260,8 @@ func TestGoldenFiles(t *testing.T) {
 			}
 
 			// Compare with golden file
-			if svg != string(golden) {
-				t.Errorf("SVG output differs from golden file %s", goldenPath)
+			if err := svgtest.Compare([]byte(svg), golden, svgtest.Options{}); err != nil {
+				t.Errorf("SVG output differs from golden file %s: %v", goldenPath, err)
 				t.Logf("Run with GOLDEN_UPDATE=1 to update golden files")
 			}
 		})
@@ -334,8 +335,8 @@ func TestPOSIXEREGoldenFiles(t *testing.T) {
 			}
 
 			// Compare with golden file
-			if svg != string(golden) {
-				t.Errorf("SVG output differs from golden file %s", goldenPath)
+			if err := svgtest.Compare([]byte(svg), golden, svgtest.Options{}); err != nil {
+				t.Errorf("SVG output differs from golden file %s: %v", goldenPath, err)
 				t.Logf("Run with GOLDEN_UPDATE=1 to update golden files")
 			}
 		})
@@ -460,8 +461,8 @@ func TestPOSIXBREGoldenFiles(t *testing.T) {
 			}
 
 			// Compare with golden file
-			if svg != string(golden) {
-				t.Errorf("SVG output differs from golden file %s", goldenPath)
+			if err := svgtest.Compare([]byte(svg), golden, svgtest.Options{}); err != nil {
+				t.Errorf("SVG output differs from golden file %s: %v", goldenPath, err)
 				t.Logf("Run with GOLDEN_UPDATE=1 to update golden files")
 			}
 		})
@@ -617,8 +618,8 @@ func TestJavaGoldenFiles(t *testing.T) {
 				t.Fatalf("failed to read golden file %s (run with GOLDEN_UPDATE=1 to create): %v", goldenPath, err)
 			}
 
-			if svg != string(expected) {
-				t.Errorf("SVG output differs from golden file %s", goldenPath)
+			if err := svgtest.Compare([]byte(svg), expected, svgtest.Options{}); err != nil {
+				t.Errorf("SVG output differs from golden file %s: %v", goldenPath, err)
 				t.Logf("Run with GOLDEN_UPDATE=1 to update golden files")
 			}
 		})
@@ -791,8 +792,8 @@ func TestDotNetGoldenFiles(t *testing.T) {
 				t.Fatalf("failed to read golden file %s (run with GOLDEN_UPDATE=1 to create): %v", goldenPath, err)
 			}
 
-			if svg != string(expected) {
-				t.Errorf("SVG output differs from golden file %s", goldenPath)
+			if err := svgtest.Compare([]byte(svg), expected, svgtest.Options{}); err != nil {
+				t.Errorf("SVG output differs from golden file %s: %v", goldenPath, err)
 				t.Logf("Run with GOLDEN_UPDATE=1 to update golden files")
 			}
 		})
@@ -1048,8 +1049,8 @@ func TestPCREGoldenFiles(t *testing.T) {
 				t.Fatalf("failed to read golden file %s (run with GOLDEN_UPDATE=1 to create): %v", goldenPath, err)
 			}
 
-			if svg != string(expected) {
-				t.Errorf("SVG output differs from golden file %s", goldenPath)
+			if err := svgtest.Compare([]byte(svg), expected, svgtest.Options{}); err != nil {
+				t.Errorf("SVG output differs from golden file %s: %v", goldenPath, err)
 				t.Logf("Run with GOLDEN_UPDATE=1 to update golden files")
 			}
 		})
@@ -1206,8 +1207,8 @@ func TestGNUGrepBREGoldenFiles(t *testing.T) {
 				t.Fatalf("failed to read golden file %s (run with GOLDEN_UPDATE=1 to create): %v", goldenPath, err)
 			}
 
-			if svg != string(expected) {
-				t.Errorf("SVG output differs from golden file %s", goldenPath)
+			if err := svgtest.Compare([]byte(svg), expected, svgtest.Options{}); err != nil {
+				t.Errorf("SVG output differs from golden file %s: %v", goldenPath, err)
 				t.Logf("Run with GOLDEN_UPDATE=1 to update golden files")
 			}
 		})
@@ -1387,8 +1388,8 @@ func TestGNUGrepEREGoldenFiles(t *testing.T) {
 				t.Fatalf("failed to read golden file %s (run with GOLDEN_UPDATE=1 to create): %v", goldenPath, err)
 			}
 
-			if svg != string(expected) {
-				t.Errorf("SVG output differs from golden file %s", goldenPath)
+			if err := svgtest.Compare([]byte(svg), expected, svgtest.Options{}); err != nil {
+				t.Errorf("SVG output differs from golden file %s: %v", goldenPath, err)
 				t.Logf("Run with GOLDEN_UPDATE=1 to update golden files")
 			}
 		})
@@ -1466,6 +1467,7 @@ func TestJavaScriptVModeGoldenFiles(t *testing.T) {
 			}
 
 			r := New(nil)
+			r.Flavor = jsFlavor
 			svg := r.Render(ast)
 
 			goldenPath := filepath.Join(goldenDir, tc.name+".svg")
@@ -1483,8 +1485,8 @@ func TestJavaScriptVModeGoldenFiles(t *testing.T) {
 				t.Fatalf("failed to read golden file %s (run with GOLDEN_UPDATE=1 to create): %v", goldenPath, err)
 			}
 
-			if svg != string(expected) {
-				t.Errorf("SVG output differs from golden file %s", goldenPath)
+			if err := svgtest.Compare([]byte(svg), expected, svgtest.Options{}); err != nil {
+				t.Errorf("SVG output differs from golden file %s: %v", goldenPath, err)
 				t.Logf("Run with GOLDEN_UPDATE=1 to update golden files")
 			}
 		})
@@ -1523,3 +1525,89 @@ func TestJavaScriptVModeIntegration(t *testing.T) {
 		})
 	}
 }
+
+func TestIntegrationGraphemeAnnotations(t *testing.T) {
+	testCases := []struct {
+		name      string
+		flavor    flavor.Flavor
+		pattern   string
+		class     string
+		wantNote  string
+		wantTitle string
+	}{
+		{"pcre-X", &pcre.PCRE{}, `a\Xb`, "escape grapheme", "may match several code points", "Extended grapheme cluster:"},
+		{"java-X", &java.Java{}, `\X+`, "escape grapheme", "may match several code points", "Extended grapheme cluster:"},
+		{"java-boundary", &java.Java{}, `\b{g}\w`, "anchor grapheme", "between user-perceived characters", "Grapheme cluster boundary:"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ast, err := tc.flavor.Parse(tc.pattern)
+			if err != nil {
+				t.Fatalf("parse error: %v", err)
+			}
+			svg := New(nil).Render(ast)
+			validateSVG(t, svg)
+
+			for _, want := range []string{
+				`class="` + tc.class + `"`,
+				`class="grapheme-icon"`,
+				`class="grapheme-note">` + tc.wantNote,
+				"<title>" + tc.wantTitle,
+			} {
+				if !strings.Contains(svg, want) {
+					t.Errorf("SVG missing %q", want)
+				}
+			}
+		})
+	}
+}
+
+func TestIntegrationShorthandExpansion(t *testing.T) {
+	testCases := []struct {
+		name    string
+		flavor  flavor.Flavor
+		pattern string
+		want    []string
+	}{
+		{"javascript-ascii", &javascript.JavaScript{}, `/[\w\d]/u`, []string{
+			"word = [A-Za-z0-9_] (ASCII)",
+			"digit = [0-9] (ASCII)",
+		}},
+		{"javascript-ignore-case", &javascript.JavaScript{}, `/[\w]/iu`, []string{
+			`word = [A-Za-z0-9_\u017F\u212A] (Unicode)`,
+		}},
+		{"pcre-default", &pcre.PCRE{}, `[\W]`, []string{"non-word = not [A-Za-z0-9_] (ASCII)"}},
+		{"pcre-ucp", &pcre.PCRE{}, `(*UCP)[\w]`, []string{`word = [\p{L}\p{N}\p{Mn}\p{Pc}] (Unicode)`}},
+		{"java-default", &java.Java{}, `[\d]`, []string{"digit = [0-9] (ASCII)"}},
+		{"java-unicode-class", &java.Java{}, `(?U)[\d]`, []string{`digit = \p{Nd} (Unicode)`}},
+		{"dotnet", &dotnet.DotNet{}, `[\d]`, []string{`digit = \p{Nd} (Unicode)`}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ast, err := tc.flavor.Parse(tc.pattern)
+			if err != nil {
+				t.Fatalf("parse error: %v", err)
+			}
+			r := New(nil)
+			r.Shorthands = flavor.Shorthands(tc.flavor, ast)
+			svg := r.Render(ast)
+			validateSVG(t, svg)
+			for _, want := range tc.want {
+				if !strings.Contains(svg, want) {
+					t.Errorf("SVG missing %q", want)
+				}
+			}
+		})
+	}
+
+	// Without Shorthands the display names are unchanged.
+	ast, err := (&pcre.PCRE{}).Parse(`[\w]`)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if svg := New(nil).Render(ast); strings.Contains(svg, "[A-Za-z0-9_]") {
+		t.Error("shorthand expanded without Shorthands set")
+	}
+}
@@ -4,6 +4,13 @@ import (
 	"math"
 	"strconv"
 	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/rivo/uniseg"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
 )
 
 // BoundingBox represents the dimensions and anchor points of a rendered element
@@ -189,11 +196,83 @@ func wrapWithTransform(elem SVGElement, dx, dy float64) SVGElement {
 	}
 }
 
-// MeasureText estimates the width of content text (monospace) given
+// MeasureText returns the width of content text (monospace) given
 // the configuration. Use this for anything that represents user-written
 // regex syntax — literals, charset items, escape sequences.
+//
+// Each glyph is measured by its advance in the font it is drawn in:
+// the font EmbedFont embedded, at FontSize, or else Go Mono, the
+// font RasterizePNG sets content in, scaled so that one of its cells
+// is CharWidth wide, the width configured for the viewer's own
+// monospace font. A glyph the font lacks, such as a CJK ideograph or an
+// emoji in Go Mono, is drawn from a fallback font, and is measured as
+// the one or two cells textCells gives it.
 func MeasureText(text string, cfg *Config) float64 {
-	return float64(len(text)) * cfg.CharWidth
+	f, size := embeddedFont(cfg), cfg.FontSize
+	if f == nil {
+		f = goFonts()[0][0][0]
+		size = cfg.CharWidth / goMonoAdvance()
+	}
+	var buf sfnt.Buffer
+	width := 0.0
+	state := -1
+	for len(text) > 0 {
+		var cluster string
+		cluster, text, _, state = uniseg.FirstGraphemeClusterInString(text, state)
+		r, _ := utf8.DecodeRuneInString(cluster)
+		if adv, ok := glyphAdvance(f, &buf, r); ok {
+			width += adv * size
+		} else {
+			width += float64(textCells(cluster)) * cfg.CharWidth
+		}
+	}
+	return width
+}
+
+// glyphAdvance returns how far r moves the pen in f, in ems, or false
+// when f has no glyph for it.
+func glyphAdvance(f *sfnt.Font, buf *sfnt.Buffer, r rune) (float64, bool) {
+	idx, err := f.GlyphIndex(buf, r)
+	if err != nil || idx == 0 {
+		return 0, false
+	}
+	upem := fixed.Int26_6(f.UnitsPerEm())
+	adv, err := f.GlyphAdvance(buf, idx, upem, font.HintingNone)
+	if err != nil {
+		return 0, false
+	}
+	return float64(adv) / float64(upem), true
+}
+
+// goMonoAdvance is the width of a Go Mono cell in ems.
+var goMonoAdvance = sync.OnceValue(func() float64 {
+	adv, _ := glyphAdvance(goFonts()[0][0][0], &sfnt.Buffer{}, '0')
+	return adv
+})
+
+// textCells returns how many character cells text fills in a monospace
+// font, which gives every glyph the same advance or twice it. CJK
+// ideographs, fullwidth forms, and emoji take two cells; combining
+// marks, variation selectors and zero-width joiners take none, so "e"
+// plus a combining acute, or a family emoji built from four code
+// points, is counted as the one glyph it draws rather than by its UTF-8
+// length. ASCII control characters count one cell, as the byte count
+// did. Labels, whose sans-serif font is the viewer's choice, are
+// measured on this grid; so are glyphs MeasureText finds no font
+// metrics for.
+func textCells(text string) int {
+	cells := 0
+	state := -1
+	for len(text) > 0 {
+		var cluster string
+		var width int
+		cluster, text, width, state = uniseg.FirstGraphemeClusterInString(text, state)
+		if width == 0 && cluster[0] < utf8.RuneSelf {
+			width = 1
+		}
+		cells += width
+	}
+	return cells
 }
 
 // MeasureLabelText estimates the width of structural label text
@@ -201,9 +280,10 @@ func MeasureText(text string, cfg *Config) float64 {
 // labels that regolith generates — "one of", "1+ times", group names,
 // anchor descriptions, and so on. Sans-serif glyphs are narrower on
 // average than monospace, so a separate char-width estimate avoids
-// oversized label boxes.
+// oversized label boxes. Wide and zero-width glyphs count as in
+// MeasureText.
 func MeasureLabelText(text string, cfg *Config) float64 {
-	return float64(len(text)) * cfg.LabelCharWidth
+	return float64(textCells(text)) * cfg.LabelCharWidth
 }
 
 // PathBuilder helps construct SVG path data
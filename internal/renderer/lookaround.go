@@ -0,0 +1,218 @@
+package renderer
+
+import (
+	"math"
+
+	parser "github.com/0x4d5352/regolith/internal/ast"
+)
+
+// Off-track lookarounds (Config.LookaroundLayout "above" or "below").
+//
+// A lookaround tests the text at one point without consuming any, so
+// drawn inline it interrupts the track with a box the match never
+// passes through. Off track, each run of consecutive lookarounds is
+// lifted into a lane above or below the track and hung from a dashed
+// spur at the point it tests:
+//
+//	       .-[lookahead .*\d]-[lookahead .*[a-z]]
+//	       :
+//	--[^]--+--[any 8+ times]--[$]--
+//
+// The track only grows where one lane entry would otherwise run into
+// the next.
+
+// isLookaround reports whether frag is an unquantified lookaround
+// group, the only kind moved off the track: a quantified one is rare
+// and its loop would have nowhere to attach.
+func isLookaround(frag *parser.MatchFragment) bool {
+	if frag.Repeat != nil {
+		return false
+	}
+	subexp, ok := frag.Content.(*parser.Subexp)
+	if !ok {
+		return false
+	}
+	switch subexp.GroupType {
+	case "positive_lookahead", "negative_lookahead",
+		"positive_lookbehind", "negative_lookbehind",
+		"non_atomic_positive_lookahead", "non_atomic_positive_lookbehind":
+		return true
+	}
+	return false
+}
+
+// offTrackRun is a run of consecutive lookarounds hung from the track
+// before track item at.
+type offTrackRun struct {
+	at       int
+	rendered RenderedNode // The run laid out left to right, not yet placed
+}
+
+// renderMatchOffTrack renders a sequence with its lookarounds in a lane
+// above the track, or below it when above is false. It returns false
+// when there is nothing to move, or nothing would be left on the track,
+// and the caller should draw the sequence inline.
+func (r *Renderer) renderMatchOffTrack(fragments []*parser.MatchFragment, above bool) (RenderedNode, bool) {
+	cfg := r.Config
+	gap := cfg.HorizontalGap
+
+	var track []RenderedNode
+	var runs []offTrackRun
+	var pending []RenderedNode
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		spaced, bbox := SpaceHorizontally(pending, gap)
+		runs = append(runs, offTrackRun{at: len(track), rendered: r.connectRow(spaced, bbox)})
+		pending = nil
+	}
+	for _, frag := range fragments {
+		if isLookaround(frag) {
+			pending = append(pending, r.renderMatchFragment(frag))
+			continue
+		}
+		flush()
+		track = append(track, r.renderMatchFragment(frag))
+	}
+	flush()
+	if len(runs) == 0 || len(track) == 0 {
+		return RenderedNode{}, false
+	}
+
+	// Each run hangs from a spacer in the track, so the spur gets a gap
+	// of its own. A spacer starts with no width and widens only to keep
+	// its run clear of the one before, and a tail spacer extends the
+	// track under a run that would overhang its end.
+	spacers := make([]float64, len(runs))
+	tail := -1.0
+	layout := func() ([]RenderedNode, BoundingBox, []int) {
+		items := make([]RenderedNode, 0, len(track)+len(runs)+1)
+		spacerAt := make([]int, len(runs))
+		spacer := func(width float64) RenderedNode {
+			return RenderedNode{Element: &Group{}, BBox: NewBoundingBox(0, 0, width, 0)}
+		}
+		next := 0
+		for i, item := range track {
+			for next < len(runs) && runs[next].at == i {
+				spacerAt[next] = len(items)
+				items = append(items, spacer(spacers[next]))
+				next++
+			}
+			items = append(items, item)
+		}
+		for ; next < len(runs); next++ {
+			spacerAt[next] = len(items)
+			items = append(items, spacer(spacers[next]))
+		}
+		if tail >= 0 {
+			items = append(items, spacer(tail))
+		}
+		spaced, bbox := SpaceHorizontally(items, gap)
+		return spaced, bbox, spacerAt
+	}
+	spaced, bbox, spacerAt := layout()
+	shift, prevEnd := 0.0, 0.0
+	for i, run := range runs {
+		x := spaced[spacerAt[i]].BBox.X + shift
+		if i > 0 && x < prevEnd+gap {
+			spacers[i] = prevEnd + gap - x
+			shift += spacers[i]
+			x += spacers[i]
+		}
+		prevEnd = x + gap + run.rendered.BBox.Width
+	}
+	if prevEnd > bbox.Width+shift {
+		tail = math.Max(0, prevEnd-(bbox.Width+shift)-gap)
+	}
+	spaced, bbox, spacerAt = layout()
+
+	// The lane sits clear of the tallest track item, with every run's
+	// own track on one line.
+	laneGap := 2 * cfg.VerticalGap
+	laneY := 0.0
+	for _, run := range runs {
+		b := run.rendered.BBox
+		if above {
+			laneY = math.Min(laneY, bbox.Y-laneGap-(b.Y2()-b.AnchorY))
+		} else {
+			laneY = math.Max(laneY, bbox.Y2()+laneGap+(b.AnchorY-b.Y))
+		}
+	}
+
+	var children []SVGElement
+	trackPath := NewPathBuilder()
+	for i := 0; i+1 < len(spaced); i++ {
+		trackPath.MoveTo(spaced[i].BBox.AnchorRight, bbox.AnchorY)
+		trackPath.LineTo(spaced[i+1].BBox.AnchorLeft, bbox.AnchorY)
+	}
+	// Spacers have no box of their own, so the track runs across them.
+	for _, item := range spaced {
+		if item.BBox.Height == 0 && item.BBox.Width > 0 {
+			trackPath.MoveTo(item.BBox.AnchorLeft, bbox.AnchorY)
+			trackPath.LineTo(item.BBox.AnchorRight, bbox.AnchorY)
+		}
+	}
+	spurs := NewPathBuilder()
+	for i, run := range runs {
+		x := spaced[spacerAt[i]].BBox.X2()
+		dx, dy := x+gap-run.rendered.BBox.X, laneY-run.rendered.BBox.AnchorY
+		spurs.MoveTo(x, bbox.AnchorY)
+		spurs.VerticalTo(laneY)
+		spurs.HorizontalTo(run.rendered.BBox.AnchorLeft + dx)
+		children = append(children, wrapWithTransform(run.rendered.Element, dx, dy))
+
+		placed := run.rendered.BBox.Translate(dx, dy)
+		bbox = unionBox(bbox, placed)
+	}
+	children = append([]SVGElement{
+		&Path{D: trackPath.String(), Stroke: cfg.Connector.Color, StrokeWidth: cfg.Connector.StrokeWidth},
+		&Path{D: spurs.String(), Stroke: cfg.Connector.Color, StrokeWidth: cfg.Connector.StrokeWidth, DashArray: "5 3", Class: "lookaround-spur"},
+	}, children...)
+	for _, item := range spaced {
+		children = append(children, item.Element)
+	}
+
+	// Normalize so the box starts at y=0 like every other node.
+	dy := -bbox.Y
+	return RenderedNode{
+		Element: wrapWithTransform(&Group{Class: "match", Children: children}, 0, dy),
+		BBox: BoundingBox{
+			Width: bbox.Width, Height: bbox.Height,
+			AnchorLeft: spaced[0].BBox.AnchorLeft, AnchorRight: spaced[len(spaced)-1].BBox.AnchorRight,
+			AnchorY: bbox.AnchorY + dy,
+		},
+	}, true
+}
+
+// connectRow joins a run of lookarounds laid out by SpaceHorizontally
+// with dashed segments, continuing its spur.
+func (r *Renderer) connectRow(items []RenderedNode, bbox BoundingBox) RenderedNode {
+	children := make([]SVGElement, 0, len(items)+1)
+	if len(items) > 1 {
+		pb := NewPathBuilder()
+		for i := 0; i+1 < len(items); i++ {
+			pb.MoveTo(items[i].BBox.AnchorRight, bbox.AnchorY)
+			pb.LineTo(items[i+1].BBox.AnchorLeft, bbox.AnchorY)
+		}
+		children = append(children, &Path{
+			D: pb.String(), Stroke: r.Config.Connector.Color, StrokeWidth: r.Config.Connector.StrokeWidth,
+			DashArray: "5 3", Class: "lookaround-spur",
+		})
+	}
+	for _, item := range items {
+		children = append(children, item.Element)
+	}
+	return RenderedNode{Element: &Group{Children: children}, BBox: bbox}
+}
+
+// unionBox returns the smallest box holding a and b, keeping a's
+// anchors.
+func unionBox(a, b BoundingBox) BoundingBox {
+	x, y := math.Min(a.X, b.X), math.Min(a.Y, b.Y)
+	u := a
+	u.X, u.Y = x, y
+	u.Width = math.Max(a.X2(), b.X2()) - x
+	u.Height = math.Max(a.Y2(), b.Y2()) - y
+	return u
+}
@@ -0,0 +1,176 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/0x4d5352/regolith/internal/parser"
+)
+
+// ================================================================================
+// Layout Model
+// ================================================================================
+
+// Layout is the diagram Render draws, flattened to the shapes in it,
+// each at its absolute position in SVG units, for tools that draw the
+// diagram themselves, such as a --format gotemplate template writing
+// TikZ. Shapes are listed in drawing order, so later ones are on top.
+type Layout struct {
+	Width, Height float64
+	Boxes         []LayoutBox
+	Texts         []LayoutText
+	Paths         []LayoutPath
+	Circles       []LayoutCircle
+}
+
+// LayoutBox is a rectangle: a node's box, a group's frame, a panel.
+type LayoutBox struct {
+	// Category is the node category styling the box, such as
+	// "literal" or "charset", or "" for a box no category styles.
+	Category            string
+	Class               string
+	X, Y, Width, Height float64
+	Rx                  float64 // Corner radius
+	Fill, Stroke        string
+	Dashed              bool
+}
+
+// LayoutText is a run of text. Y is its baseline, and Anchor says
+// whether X is where the text starts, its middle, or its end.
+type LayoutText struct {
+	Category string
+	Class    string
+	X, Y     float64
+	Content  string
+	Anchor   string // start, middle or end
+	FontSize float64
+	Fill     string
+}
+
+// LayoutPath is a connector, loop, or other stroked path. Its D holds
+// SVG path data in the coordinates of the group it was drawn in: add X
+// and Y to every point for its absolute position.
+type LayoutPath struct {
+	Class  string
+	X, Y   float64
+	D      string
+	Stroke string
+	Dashed bool
+}
+
+// LayoutCircle is a circle, such as an analysis badge.
+type LayoutCircle struct {
+	Class        string
+	X, Y, R      float64
+	Fill, Stroke string
+}
+
+// Layout lays root out as Render would and returns the result as a
+// Layout instead of SVG text. It is always the whole diagram on one
+// page: Render does not paginate, so a --paginate that splits the SVG
+// into pages leaves the Layout whole. Width and Height are the
+// viewBox's, the units the shapes are placed in, before MaxWidth or
+// MaxHeight scales the SVG down.
+func (r *Renderer) Layout(root *parser.Regexp) (*Layout, error) {
+	var doc *SVG
+	onDocument := r.OnDocument
+	defer func() { r.OnDocument = onDocument }()
+	r.OnDocument = func(d *SVG) { doc = d }
+	r.Render(root)
+	if doc == nil {
+		return nil, errors.New("the renderer produced no document")
+	}
+	width, height, err := viewBox(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &Layout{Width: width, Height: height}
+	for _, child := range doc.Children {
+		l.add(r.Config, child, 0, 0, "")
+	}
+	return l, nil
+}
+
+// add flattens e, moved by dx, dy, into l. category is the node
+// category styling the shapes inside e, resolved as RasterizePNG does.
+func (l *Layout) add(cfg *Config, e SVGElement, dx, dy float64, category string) {
+	switch e := e.(type) {
+	case *Group:
+		var x, y float64
+		if _, err := fmt.Sscanf(e.Transform, "translate(%g,%g)", &x, &y); err == nil {
+			dx, dy = dx+x, dy+y
+		}
+		category = styleCategory(cfg, e.Class, category)
+		for _, child := range e.Children {
+			l.add(cfg, child, dx, dy, category)
+		}
+	case *Link:
+		for _, child := range e.Children {
+			l.add(cfg, child, dx, dy, category)
+		}
+	case *Rect:
+		box := LayoutBox{
+			Category: category, Class: e.Class,
+			X: e.X + dx, Y: e.Y + dy, Width: e.Width, Height: e.Height, Rx: e.Rx,
+			Fill: e.Fill, Stroke: e.Stroke, Dashed: e.StrokeDashArray != "",
+		}
+		if category != "" {
+			style := cfg.NodeStyles[category]
+			box.Fill, box.Stroke, box.Dashed = style.Fill, style.Stroke, category == "comment"
+		}
+		l.Boxes = append(l.Boxes, box)
+	case *Circle:
+		l.Circles = append(l.Circles, LayoutCircle{
+			Class: e.Class, X: e.Cx + dx, Y: e.Cy + dy, R: e.R, Fill: e.Fill, Stroke: e.Stroke,
+		})
+	case *Path:
+		l.Paths = append(l.Paths, LayoutPath{
+			Class: e.Class, X: dx, Y: dy, D: e.D, Stroke: e.Stroke, Dashed: e.DashArray != "",
+		})
+	case *Line:
+		l.Paths = append(l.Paths, LayoutPath{
+			Class: e.Class, X: dx, Y: dy, Stroke: e.Stroke,
+			D: "M " + fmtFloat(e.X1) + " " + fmtFloat(e.Y1) + " L " + fmtFloat(e.X2) + " " + fmtFloat(e.Y2),
+		})
+	case *Text:
+		l.addText(cfg, e, dx, dy, category)
+	}
+}
+
+// addText adds e as one LayoutText, in the size and color the
+// stylesheet gives it, or as one per span placed at its own x, in that
+// span's class and color, as the source line's tokens are.
+func (l *Layout) addText(cfg *Config, e *Text, dx, dy float64, category string) {
+	look := lookOf(cfg, e, category)
+	text := LayoutText{
+		Category: category, Class: e.Class,
+		X: e.X + dx, Y: e.Y + dy, Content: e.Content,
+		Anchor: e.Anchor, FontSize: look.size, Fill: look.fill,
+	}
+	if text.Anchor == "" {
+		text.Anchor = "start"
+	}
+	if len(e.Spans) == 0 {
+		l.Texts = append(l.Texts, text)
+		return
+	}
+	text.Content = ""
+	for i, span := range e.Spans {
+		if span.X > 0 {
+			if i > 0 {
+				l.Texts = append(l.Texts, text)
+				text.Content = ""
+			}
+			text.X = span.X + dx
+			if span.Class != "" {
+				text.Class = span.Class
+			}
+			if span.Fill != "" {
+				text.Fill = span.Fill
+			}
+		}
+		text.Content += span.Content
+	}
+	l.Texts = append(l.Texts, text)
+}
@@ -0,0 +1,58 @@
+package renderer
+
+import (
+	"testing"
+
+	"github.com/0x4d5352/regolith/internal/parser"
+)
+
+func TestLayout(t *testing.T) {
+	root, err := parser.ParseRegex("ab|c")
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	cfg := DefaultConfig()
+	r := New(cfg)
+	called := false
+	r.OnDocument = func(*SVG) { called = true }
+	l, err := r.Layout(root)
+	if err != nil {
+		t.Fatalf("Layout: %v", err)
+	}
+	if l.Width <= 0 || l.Height <= 0 {
+		t.Fatalf("layout is %gx%g", l.Width, l.Height)
+	}
+	if called || r.OnDocument == nil {
+		t.Error("Layout should restore OnDocument without calling it")
+	}
+
+	literal := cfg.NodeStyles["literal"]
+	for _, content := range []string{`"ab"`, `"c"`} {
+		var text *LayoutText
+		for i := range l.Texts {
+			if l.Texts[i].Content == content {
+				text = &l.Texts[i]
+			}
+		}
+		if text == nil {
+			t.Fatalf("no text %q in %+v", content, l.Texts)
+		}
+		if text.Category != "literal" || text.Fill != literal.TextColor || text.FontSize != cfg.FontSize {
+			t.Errorf("text %q = %+v, want the literal style at size %g", content, *text, cfg.FontSize)
+		}
+		// The text sits in a literal box, placed in document coordinates.
+		var inBox bool
+		for _, box := range l.Boxes {
+			if box.Category == "literal" && box.Fill == literal.Fill && box.Stroke == literal.Stroke &&
+				box.X < text.X && text.X < box.X+box.Width && box.Y < text.Y && text.Y < box.Y+box.Height {
+				inBox = true
+			}
+		}
+		if !inBox {
+			t.Errorf("text %q at (%g,%g) is in no literal box: %+v", content, text.X, text.Y, l.Boxes)
+		}
+	}
+	if len(l.Paths) == 0 {
+		t.Error("layout has no connectors")
+	}
+}
@@ -0,0 +1,128 @@
+package renderer
+
+import (
+	"strconv"
+
+	parser "github.com/0x4d5352/regolith/internal/ast"
+)
+
+// pageInfo identifies the page being drawn by RenderPages so Render can
+// add continuation markers. Number is 1-based.
+type pageInfo struct {
+	Number, Total int
+}
+
+// RenderPages renders ast as one or more SVG pages, each at most
+// maxWidth pixels wide where possible, for printing and slides where a
+// single very wide diagram is unusable.
+//
+// Only a top-level sequence is split, and only between its items: a
+// pattern whose top level is an alternation, or a single item wider
+// than maxWidth, still gets a page of its own at its natural width.
+// Every page but the last ends with "⟶ continued on page N" and every
+// page but the first starts with "⟵ continued from page N". The pattern
+// options banner and source line appear on the first page only; flags
+// apply to the whole pattern, so every page shows them. A maxWidth of
+// zero or less, or a pattern that already fits, yields exactly what
+// Render does.
+func (r *Renderer) RenderPages(ast *parser.Regexp, maxWidth float64) []string {
+	if maxWidth <= 0 || len(ast.Matches) != 1 || r.pageWidth(ast) <= maxWidth {
+		return []string{r.Render(ast)}
+	}
+
+	var chunks [][]*parser.MatchFragment
+	var cur []*parser.MatchFragment
+	for _, frag := range ast.Matches[0].Fragments {
+		next := append(cur[:len(cur):len(cur)], frag)
+		if len(cur) > 0 && r.pageWidth(pageRegexp(ast, next, len(chunks) == 0)) > maxWidth {
+			chunks = append(chunks, cur)
+			next = []*parser.MatchFragment{frag}
+		}
+		cur = next
+	}
+	chunks = append(chunks, cur)
+	if len(chunks) == 1 {
+		return []string{r.Render(ast)}
+	}
+
+	source := r.Source
+	defer func() { r.Source, r.page = source, nil }()
+	pages := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		r.page = &pageInfo{Number: i + 1, Total: len(chunks)}
+		if i > 0 {
+			r.Source = nil
+		}
+		pages[i] = r.Render(pageRegexp(ast, chunk, i == 0))
+	}
+	return pages
+}
+
+// pageRegexp is the pattern drawn on one page: the page's share of the
+// top-level sequence, the pattern's flags, and (on the first page only)
+// its start options.
+func pageRegexp(ast *parser.Regexp, frags []*parser.MatchFragment, first bool) *parser.Regexp {
+	page := &parser.Regexp{
+		Matches: []*parser.Match{{Fragments: frags}},
+		Flags:   ast.Flags,
+	}
+	if first {
+		page.Options = ast.Options
+	}
+	return page
+}
+
+// pageWidth is the width Render would give ast, less the source line,
+// which is as wide as the whole pattern and is never split.
+func (r *Renderer) pageWidth(ast *parser.Regexp) float64 {
+	padding := r.Config.Padding
+	width := r.renderRegexp(ast).BBox.Width + contentLeftMargin(padding) + contentRightMargin(padding)
+	if r.hasFlags(ast) {
+		width += r.renderFlags(ast.Flags).BBox.Width + padding
+	}
+	return width
+}
+
+// renderPageMarkers draws the continuation notes for the current page
+// along the bottom edge of the diagram: "continued from" at the left,
+// "continued on" at the right. The row is width wide, or wider if the
+// two notes would not otherwise fit side by side.
+func (r *Renderer) renderPageMarkers(width float64) RenderedNode {
+	cfg := r.Config
+	var from, on string
+	if r.page.Number > 1 {
+		from = "⟵ continued from page " + strconv.Itoa(r.page.Number-1)
+	}
+	if r.page.Number < r.page.Total {
+		on = "⟶ continued on page " + strconv.Itoa(r.page.Number+1)
+	}
+	need := MeasureLabelText(from, cfg) + MeasureLabelText(on, cfg) + 2*cfg.Padding
+	if need > width {
+		width = need
+	}
+
+	y := cfg.LabelFontSize
+	g := &Group{Class: "page-marker"}
+	if from != "" {
+		g.Children = append(g.Children, &Text{
+			X:          cfg.Padding / 2,
+			Y:          y,
+			Content:    from,
+			FontFamily: cfg.LabelFontFamily,
+			FontSize:   cfg.LabelFontSize,
+			Fill:       cfg.RepeatLabelColor,
+		})
+	}
+	if on != "" {
+		g.Children = append(g.Children, &Text{
+			X:          width - cfg.Padding/2,
+			Y:          y,
+			Content:    on,
+			FontFamily: cfg.LabelFontFamily,
+			FontSize:   cfg.LabelFontSize,
+			Fill:       cfg.RepeatLabelColor,
+			Anchor:     "end",
+		})
+	}
+	return RenderedNode{Element: g, BBox: NewBoundingBox(0, 0, width, y+cfg.LabelFontSize/3)}
+}
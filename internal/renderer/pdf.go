@@ -0,0 +1,339 @@
+package renderer
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image/color"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// ================================================================================
+// PDF Output
+// ================================================================================
+
+// pdfZoom is how many content-stream units DrawPDF gives an SVG unit
+// at 96 dpi. Curves are cut into segments a couple of units long, so
+// drawing at four times that size keeps them smooth under a zoom.
+const pdfZoom = 4
+
+// DrawPDF draws doc, a document this renderer produced (see
+// OnDocument), as a one-page vector PDF the size of the diagram, taking
+// an SVG unit as a CSS pixel, 3/4 of a point.
+//
+// It walks the same element tree RasterizePNG does and draws the same
+// shapes, as filled outlines instead of pixels, so the two always
+// agree. Text stays text, set in the fonts RasterizePNG uses, which are
+// embedded whole, so it can be selected and searched.
+func (r *Renderer) DrawPDF(doc *SVG) ([]byte, error) {
+	vbWidth, _, err := viewBox(doc)
+	if err != nil {
+		return nil, err
+	}
+	page := &pdfPage{fonts: map[*opentype.Font]*pdfFont{}}
+	r.paint(doc, page, pdfZoom*doc.Width/vbWidth)
+	return page.document(doc.Width*0.75, doc.Height*0.75)
+}
+
+// pdfPage is the canvas of DrawPDF: the page's content stream and the
+// fonts it uses.
+type pdfPage struct {
+	content bytes.Buffer
+	fonts   map[*opentype.Font]*pdfFont
+	order   []*pdfFont // fonts in the order first used
+	buf     sfnt.Buffer
+
+	// Graphics state the content stream has set so far.
+	color  color.Color
+	inText bool
+	font   *pdfFont
+	size   float64
+}
+
+// pdfFont is a font the page uses, with the glyphs it draws and the
+// characters they stand for.
+type pdfFont struct {
+	f      *opentype.Font
+	name   string // Resource name, F1, F2, ...
+	glyphs map[sfnt.GlyphIndex]rune
+}
+
+func (p *pdfPage) fill(s shape, col color.Color) {
+	p.endText()
+	p.setColor(col)
+	for _, poly := range s {
+		p.num(poly[0].x, poly[0].y)
+		p.content.WriteString("m\n")
+		for _, pt := range poly[1:] {
+			p.num(pt.x, pt.y)
+			p.content.WriteString("l\n")
+		}
+		p.content.WriteString("h\n")
+	}
+	p.content.WriteString("f\n")
+}
+
+func (p *pdfPage) glyph(f *opentype.Font, _ font.Face, size float64, c rune, at point, col color.Color) {
+	pf := p.fonts[f]
+	if pf == nil {
+		pf = &pdfFont{f: f, name: "F" + strconv.Itoa(len(p.order)+1), glyphs: map[sfnt.GlyphIndex]rune{}}
+		p.fonts[f] = pf
+		p.order = append(p.order, pf)
+	}
+	gid, err := f.GlyphIndex(&p.buf, c)
+	if err != nil {
+		gid = 0
+	}
+	if _, ok := pf.glyphs[gid]; !ok {
+		pf.glyphs[gid] = c
+	}
+	if !p.inText {
+		p.content.WriteString("BT\n")
+		p.inText = true
+	}
+	p.setColor(col)
+	if pf != p.font || size != p.size {
+		p.font, p.size = pf, size
+		p.content.WriteString("/" + pf.name + " ")
+		p.num(size)
+		p.content.WriteString("Tf\n")
+	}
+	// The page's y axis points down, so the text matrix flips glyphs
+	// back up.
+	p.content.WriteString("1 0 0 -1 ")
+	p.num(at.x, at.y)
+	fmt.Fprintf(&p.content, "Tm <%04X> Tj\n", uint16(gid))
+}
+
+func (p *pdfPage) endText() {
+	if p.inText {
+		p.content.WriteString("ET\n")
+		p.inText = false
+	}
+}
+
+func (p *pdfPage) setColor(col color.Color) {
+	if p.color == col {
+		return
+	}
+	p.color = col
+	r, g, b, _ := col.RGBA()
+	p.num(float64(r)/0xffff, float64(g)/0xffff, float64(b)/0xffff)
+	p.content.WriteString("rg\n")
+}
+
+// num writes each of vs to the content stream, followed by a space.
+func (p *pdfPage) num(vs ...float64) {
+	for _, v := range vs {
+		p.content.WriteString(pdfNumber(v))
+		p.content.WriteByte(' ')
+	}
+}
+
+// pdfNumber formats v with at most three decimals.
+func pdfNumber(v float64) string {
+	v = math.Round(v*1000) / 1000
+	if v == 0 {
+		return "0"
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// document returns the PDF file holding the page, width by height
+// points.
+func (p *pdfPage) document(width, height float64) ([]byte, error) {
+	p.endText()
+	k := strconv.FormatFloat(0.75/pdfZoom, 'f', -1, 64)
+	content := append([]byte(k+" 0 0 -"+k+" 0 "+pdfNumber(height)+" cm\n"), p.content.Bytes()...)
+
+	// Objects 1 to 4 are the catalog, page tree, page and content;
+	// each font then takes five.
+	const fontObjects = 5
+	var fonts strings.Builder
+	for i, pf := range p.order {
+		fmt.Fprintf(&fonts, "/%s %d 0 R ", pf.name, 5+i*fontObjects)
+	}
+	w := &pdfWriter{}
+	w.buf.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+	w.object("<< /Type /Catalog /Pages 2 0 R >>")
+	w.object("<< /Type /Pages /Kids [3 0 R] /Count 1 >>")
+	w.object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << %s>> >> /Contents 4 0 R >>",
+		pdfNumber(width), pdfNumber(height), fonts.String()))
+	if err := w.stream("", content); err != nil {
+		return nil, err
+	}
+	for i, pf := range p.order {
+		if err := w.font(pf, 5+i*fontObjects, &p.buf); err != nil {
+			return nil, err
+		}
+	}
+	return w.finish(), nil
+}
+
+// pdfWriter writes a PDF file's objects, numbered from 1 in the order
+// written, and the cross-reference table that finds them.
+type pdfWriter struct {
+	buf     bytes.Buffer
+	offsets []int
+}
+
+// object writes the next object, whose body is dict.
+func (w *pdfWriter) object(dict string) {
+	w.offsets = append(w.offsets, w.buf.Len())
+	fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nendobj\n", len(w.offsets), dict)
+}
+
+// stream writes the next object as a stream of data, compressed, with
+// the entries in dict besides its length and filter.
+func (w *pdfWriter) stream(dict string, data []byte) error {
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	w.offsets = append(w.offsets, w.buf.Len())
+	fmt.Fprintf(&w.buf, "%d 0 obj\n<< %s/Filter /FlateDecode /Length %d >>\nstream\n", len(w.offsets), dict, z.Len())
+	w.buf.Write(z.Bytes())
+	w.buf.WriteString("\nendstream\nendobj\n")
+	return nil
+}
+
+// font writes pf as objects first to first+4: a composite font whose
+// two-byte codes are glyph indexes, its descendant font, that font's
+// descriptor, the font file and the map from glyphs back to text.
+func (w *pdfWriter) font(pf *pdfFont, first int, buf *sfnt.Buffer) error {
+	var raw bytes.Buffer
+	if _, err := pf.f.WriteSourceTo(buf, &raw); err != nil {
+		return err
+	}
+	// Fonts with CFF outlines embed as OpenType; TrueType ones as they
+	// are.
+	cff := bytes.HasPrefix(raw.Bytes(), []byte("OTTO"))
+	name := postScriptName(pf.f, buf)
+
+	// Widths and metrics are in thousandths of an em.
+	em := fixed.I(1000)
+	units := func(v fixed.Int26_6) string { return strconv.Itoa(int(math.Round(float64(v) / 64))) }
+	gids := make([]sfnt.GlyphIndex, 0, len(pf.glyphs))
+	for gid := range pf.glyphs {
+		gids = append(gids, gid)
+	}
+	slices.Sort(gids)
+	var widths strings.Builder
+	for _, gid := range gids {
+		adv, err := pf.f.GlyphAdvance(buf, gid, em, font.HintingNone)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&widths, "%d [%s] ", gid, units(adv))
+	}
+
+	w.object(fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		name, first+1, first+4))
+	subtype, gidMap := "CIDFontType2", " /CIDToGIDMap /Identity"
+	if cff {
+		subtype, gidMap = "CIDFontType0", ""
+	}
+	w.object(fmt.Sprintf("<< /Type /Font /Subtype /%s /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /W [%s]%s >>",
+		subtype, name, first+2, widths.String(), gidMap))
+
+	metrics, err := pf.f.Metrics(buf, em, font.HintingNone)
+	if err != nil {
+		return err
+	}
+	bounds, err := pf.f.Bounds(buf, em, font.HintingNone)
+	if err != nil {
+		return err
+	}
+	post := pf.f.PostTable()
+	flags := 4 // Symbolic: glyphs are found by index, not by a standard encoding
+	if post != nil && post.IsFixedPitch {
+		flags |= 1
+	}
+	italic := 0.0
+	if post != nil && post.ItalicAngle != 0 {
+		italic = post.ItalicAngle
+		flags |= 64
+	}
+	file := "FontFile2"
+	if cff {
+		file = "FontFile3"
+	}
+	w.object(fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags %d /FontBBox [%s %s %s %s] /ItalicAngle %s /Ascent %s /Descent -%s /CapHeight %s /StemV 80 /%s %d 0 R >>",
+		name, flags, units(bounds.Min.X), units(-bounds.Max.Y), units(bounds.Max.X), units(-bounds.Min.Y),
+		pdfNumber(italic), units(metrics.Ascent), units(metrics.Descent), units(metrics.CapHeight), file, first+3))
+
+	dict := fmt.Sprintf("/Length1 %d ", raw.Len())
+	if cff {
+		dict = "/Subtype /OpenType "
+	}
+	if err := w.stream(dict, raw.Bytes()); err != nil {
+		return err
+	}
+	return w.stream("", toUnicode(pf.glyphs, gids))
+}
+
+// postScriptName returns f's PostScript name, which PDF uses as the
+// font's name, keeping only the characters a PDF name may hold bare.
+func postScriptName(f *opentype.Font, buf *sfnt.Buffer) string {
+	name, _ := f.Name(buf, sfnt.NameIDPostScript)
+	name = strings.Map(func(r rune) rune {
+		if r < '!' || r > '~' || strings.ContainsRune("()<>[]{}/%#", r) {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "" {
+		return "Regolith"
+	}
+	return name
+}
+
+// toUnicode returns the CMap mapping each of gids, but the missing
+// glyph, to the character drawn with it, so the text can be copied.
+func toUnicode(glyphs map[sfnt.GlyphIndex]rune, gids []sfnt.GlyphIndex) []byte {
+	var b bytes.Buffer
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	gids = slices.DeleteFunc(slices.Clone(gids), func(gid sfnt.GlyphIndex) bool { return gid == 0 })
+	// A bfchar block may hold at most 100 entries.
+	for chunk := range slices.Chunk(gids, 100) {
+		fmt.Fprintf(&b, "%d beginbfchar\n", len(chunk))
+		for _, gid := range chunk {
+			fmt.Fprintf(&b, "<%04X> <", uint16(gid))
+			for _, u := range utf16.Encode([]rune{glyphs[gid]}) {
+				fmt.Fprintf(&b, "%04X", u)
+			}
+			b.WriteString(">\n")
+		}
+		b.WriteString("endbfchar\n")
+	}
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return b.Bytes()
+}
+
+// finish writes the cross-reference table and trailer and returns the
+// file.
+func (w *pdfWriter) finish() []byte {
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", len(w.offsets)+1)
+	for _, off := range w.offsets {
+		fmt.Fprintf(&w.buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(w.offsets)+1, xref)
+	return w.buf.Bytes()
+}
@@ -0,0 +1,110 @@
+package renderer
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/0x4d5352/regolith/internal/parser"
+)
+
+// pdfStreams returns the decompressed streams of a PDF DrawPDF wrote,
+// by object number, after checking that its cross-reference table
+// finds every object.
+func pdfStreams(t *testing.T, pdf []byte) map[int]string {
+	t.Helper()
+	_, tail, ok := bytes.Cut(pdf, []byte("startxref\n"))
+	if !ok {
+		t.Fatal("no startxref")
+	}
+	xref, err := strconv.Atoi(string(bytes.Fields(tail)[0]))
+	if err != nil || !bytes.HasPrefix(pdf[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %s does not point at the xref table", bytes.Fields(tail)[0])
+	}
+	lines := strings.Split(string(pdf[xref:]), "\n")
+	var count int
+	if _, err := fmt.Sscanf(lines[1], "0 %d", &count); err != nil {
+		t.Fatalf("xref subsection %q: %v", lines[1], err)
+	}
+	for n := 1; n < count; n++ {
+		off, _ := strconv.Atoi(lines[2+n][:10])
+		if want := fmt.Sprintf("%d 0 obj\n", n); !bytes.HasPrefix(pdf[off:], []byte(want)) {
+			t.Errorf("xref sends object %d to %q", n, pdf[off:min(off+20, len(pdf))])
+		}
+	}
+
+	streams := map[int]string{}
+	re := regexp.MustCompile(`(\d+) 0 obj\n<<[^\n]*/Length (\d+) >>\nstream\n`)
+	for _, m := range re.FindAllSubmatchIndex(pdf, -1) {
+		n, _ := strconv.Atoi(string(pdf[m[2]:m[3]]))
+		length, _ := strconv.Atoi(string(pdf[m[4]:m[5]]))
+		zr, err := zlib.NewReader(bytes.NewReader(pdf[m[1] : m[1]+length]))
+		if err != nil {
+			t.Fatalf("object %d: %v", n, err)
+		}
+		data, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatalf("object %d: %v", n, err)
+		}
+		streams[n] = string(data)
+	}
+	return streams
+}
+
+func TestDrawPDF(t *testing.T) {
+	root, err := parser.ParseRegex("ab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := DefaultConfig()
+	r := New(cfg)
+	var doc *SVG
+	r.OnDocument = func(d *SVG) { doc = d }
+	r.Render(root)
+	pdf, err := r.DrawPDF(doc)
+	if err != nil {
+		t.Fatalf("DrawPDF: %v", err)
+	}
+	if !bytes.HasPrefix(pdf, []byte("%PDF-")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatalf("not a PDF: %q...", pdf[:min(len(pdf), 20)])
+	}
+	// An SVG unit is 3/4 of a point.
+	box := fmt.Sprintf("/MediaBox [0 0 %s %s]", pdfNumber(doc.Width*0.75), pdfNumber(doc.Height*0.75))
+	if !bytes.Contains(pdf, []byte(box)) {
+		t.Errorf("PDF lacks %s", box)
+	}
+
+	streams := pdfStreams(t, pdf)
+	content := streams[4]
+	// The literal's box is filled in the stylesheet's literal color.
+	c, _ := parseColor(cfg.NodeStyles["literal"].Fill)
+	fill := fmt.Sprintf("%s %s %s rg\n", pdfNumber(float64(c.r)/0xff), pdfNumber(float64(c.g)/0xff), pdfNumber(float64(c.b)/0xff))
+	if !strings.Contains(content, fill) {
+		t.Errorf("content never sets the literal fill %q", fill)
+	}
+	if !strings.Contains(content, "BT\n") || strings.Count(content, " Tj\n") < 2 {
+		t.Errorf("content draws no text:\n%s", content)
+	}
+	// The label's letters can be copied out: some ToUnicode map sends
+	// a glyph to each of them.
+	var cmaps string
+	for _, s := range streams {
+		if strings.Contains(s, "begincmap") {
+			cmaps += s
+		}
+	}
+	for _, u := range []string{"<0061>", "<0062>"} {
+		if !strings.Contains(cmaps, u) {
+			t.Errorf("no ToUnicode entry maps to %s", u)
+		}
+	}
+
+	if _, err := New(nil).DrawPDF(&SVG{Width: 10, Height: 10}); err == nil {
+		t.Error("expected an error for a document without a viewBox")
+	}
+}
@@ -0,0 +1,63 @@
+package renderer
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ================================================================================
+// Provenance comment
+// ================================================================================
+
+// Provenance identifies what produced a diagram. When set on a
+// Renderer it is written as an XML comment at the top of the SVG, so a
+// diagram found in a docs tree can be traced back to its pattern and
+// regenerated:
+//
+//	<!-- regolith
+//	pattern: "a|b"
+//	flavor: javascript
+//	version: 0.2.0
+//	generated: 2026-01-02T15:04:05Z
+//	-->
+//
+// The pattern is a Go-quoted string (strconv.Unquote reverses it), with
+// "--" written as \x2d\x2d because XML comments may not contain it.
+// Version and Time are omitted when empty or zero, which is how
+// reproducible builds keep the output byte-identical across runs.
+type Provenance struct {
+	Pattern string
+	Flavor  string
+	Version string
+	Time    time.Time
+}
+
+// comment renders p as the body of an XML comment.
+func (p *Provenance) comment() string {
+	quoted := strings.ReplaceAll(strconv.Quote(p.Pattern), "--", `\x2d\x2d`)
+	var b strings.Builder
+	b.WriteString("regolith\npattern: ")
+	b.WriteString(quoted)
+	b.WriteString("\nflavor: ")
+	b.WriteString(p.Flavor)
+	if p.Version != "" {
+		b.WriteString("\nversion: ")
+		b.WriteString(p.Version)
+	}
+	if !p.Time.IsZero() {
+		b.WriteString("\ngenerated: ")
+		b.WriteString(p.Time.UTC().Format(time.RFC3339))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// provenanceComment returns the comment body for r's SVG, or "" when r
+// has no Provenance.
+func (r *Renderer) provenanceComment() string {
+	if r.Provenance == nil {
+		return ""
+	}
+	return r.Provenance.comment()
+}
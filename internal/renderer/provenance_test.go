@@ -0,0 +1,64 @@
+package renderer
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0x4d5352/regolith/internal/flavor"
+)
+
+func TestProvenanceComment(t *testing.T) {
+	f, _ := flavor.Get("javascript")
+	pattern := `a--b-->c-`
+	parsed, err := f.Parse(pattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := New(nil)
+	r.Provenance = &Provenance{
+		Pattern: pattern,
+		Flavor:  "javascript",
+		Version: "1.2.3",
+		Time:    time.Unix(1700000000, 0),
+	}
+	svg := r.Render(parsed)
+
+	m := regexp.MustCompile(`(?s)<!--(.*?)-->`).FindStringSubmatch(svg)
+	if m == nil {
+		t.Fatalf("no comment in SVG: %.200s", svg)
+	}
+	body := m[1]
+	if strings.Contains(body, "--") {
+		t.Errorf("comment body contains --: %q", body)
+	}
+	for _, want := range []string{"flavor: javascript", "version: 1.2.3", "generated: 2023-11-14T22:13:20Z"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("comment missing %q:\n%s", want, body)
+		}
+	}
+	quoted := regexp.MustCompile(`pattern: (".*")`).FindStringSubmatch(body)
+	if quoted == nil {
+		t.Fatalf("no pattern line:\n%s", body)
+	}
+	if got, err := strconv.Unquote(quoted[1]); err != nil || got != pattern {
+		t.Errorf("pattern round-trip = %q, %v; want %q", got, err, pattern)
+	}
+}
+
+func TestProvenanceOmitsEmptyFields(t *testing.T) {
+	f, _ := flavor.Get("javascript")
+	parsed, _ := f.Parse("a")
+	r := New(nil)
+	r.Provenance = &Provenance{Pattern: "a", Flavor: "javascript"}
+	svg := r.Render(parsed)
+	if strings.Contains(svg, "version:") || strings.Contains(svg, "generated:") {
+		t.Errorf("empty Version/Time should be omitted: %.200s", svg)
+	}
+	if plain := New(nil).Render(parsed); strings.Contains(plain, "<!--") {
+		t.Error("Render without Provenance should not emit a comment")
+	}
+}
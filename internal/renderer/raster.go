@@ -0,0 +1,880 @@
+package renderer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/gobolditalic"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/gomonobold"
+	"golang.org/x/image/font/gofont/gomonobolditalic"
+	"golang.org/x/image/font/gofont/gomonoitalic"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// ================================================================================
+// PNG Rasterization
+// ================================================================================
+
+// RasterizePNG draws doc, a document this renderer produced (see
+// OnDocument), to PNG at dpi dots per inch, where 96 draws one pixel
+// per SVG unit and 192 is a 2x image for high-density screens.
+//
+// It draws the element tree itself instead of reading the SVG text
+// back, so it needs no SVG library or converter and places every box,
+// track and label where the SVG does. Class rules from the stylesheet
+// win over an element's own attributes, as they do in a browser. Text
+// is set in the Go fonts, monospace for content and sans-serif for
+// labels, or in the font EmbedFont embedded when it is TrueType or
+// OpenType; characters a font lacks are drawn as boxes.
+func (r *Renderer) RasterizePNG(doc *SVG, dpi float64) ([]byte, error) {
+	if dpi <= 0 {
+		return nil, fmt.Errorf("dpi must be positive, got %g", dpi)
+	}
+	vbWidth, _, err := viewBox(doc)
+	if err != nil {
+		return nil, err
+	}
+	zoom := dpi / 96
+	px := &pixels{img: image.NewRGBA(image.Rect(0, 0, int(math.Ceil(doc.Width*zoom)), int(math.Ceil(doc.Height*zoom))))}
+	r.paint(doc, px, zoom*doc.Width/vbWidth)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, px.img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// viewBox returns the width and height of doc's viewBox.
+func viewBox(doc *SVG) (float64, float64, error) {
+	var width, height float64
+	if _, err := fmt.Sscanf(doc.ViewBox, "0 0 %g %g", &width, &height); err != nil || width <= 0 || height <= 0 {
+		return 0, 0, fmt.Errorf("document has no usable viewBox (%q)", doc.ViewBox)
+	}
+	return width, height, nil
+}
+
+// paint draws doc's element tree on out at scale pixels per SVG unit.
+func (r *Renderer) paint(doc *SVG, out canvas, scale float64) {
+	ra := &rasterizer{
+		cfg:      r.Config,
+		out:      out,
+		scale:    scale,
+		embedded: embeddedFont(r.Config),
+		faces:    map[faceKey]font.Face{},
+	}
+	defer ra.close()
+	for _, child := range doc.Children {
+		ra.draw(child, 0, 0, "")
+	}
+}
+
+// rasterizer holds the state of one RasterizePNG or DrawPDF call.
+type rasterizer struct {
+	cfg      *Config
+	out      canvas
+	scale    float64        // Pixels per SVG unit
+	embedded *opentype.Font // From Config.FontFace; nil for none
+	faces    map[faceKey]font.Face
+}
+
+// canvas is what a rasterizer draws on. It works in pixels, with y
+// pointing down, and is given only visible colors.
+type canvas interface {
+	// fill paints the polygons of s in col, their windings added up.
+	fill(s shape, col color.Color)
+	// glyph draws c with its baseline origin at, in f at size pixels,
+	// which face is.
+	glyph(f *opentype.Font, face font.Face, size float64, c rune, at point, col color.Color)
+}
+
+// pixels is the canvas of RasterizePNG.
+type pixels struct {
+	img *image.RGBA
+	z   vector.Rasterizer
+}
+
+type faceKey struct {
+	font *opentype.Font
+	size float64
+}
+
+// point is a position in pixels.
+type point struct{ x, y float64 }
+
+// subpath is one run of a path between moves, flattened to points.
+type subpath struct {
+	pts    []point
+	closed bool
+}
+
+// shape is an outline to fill: polygons whose windings add up, so
+// polygons wound the same way cover their union and one wound the
+// other way cuts a hole.
+type shape [][]point
+
+func (ra *rasterizer) close() {
+	for _, f := range ra.faces {
+		_ = f.Close()
+	}
+}
+
+// at maps x, y in the SVG units of an element translated by dx, dy to
+// pixels.
+func (ra *rasterizer) at(x, y, dx, dy float64) point {
+	return point{(x + dx) * ra.scale, (y + dy) * ra.scale}
+}
+
+// draw paints e, moved by dx, dy. category is the node category whose
+// rules style the rects and text inside e (see category).
+func (ra *rasterizer) draw(e SVGElement, dx, dy float64, category string) {
+	switch e := e.(type) {
+	case *Group:
+		var x, y float64
+		if _, err := fmt.Sscanf(e.Transform, "translate(%g,%g)", &x, &y); err == nil {
+			dx, dy = dx+x, dy+y
+		}
+		category = styleCategory(ra.cfg, e.Class, category)
+		for _, child := range e.Children {
+			ra.draw(child, dx, dy, category)
+		}
+	case *Link:
+		for _, child := range e.Children {
+			ra.draw(child, dx, dy, category)
+		}
+	case *Rect:
+		ra.drawRect(e, dx, dy, category)
+	case *Circle:
+		c, r := ra.at(e.Cx, e.Cy, dx, dy), e.R*ra.scale
+		ra.fill(shape{circle(c, r, false)}, fillColor(e.Fill))
+		if col, ok := paint(e.Stroke); ok {
+			ra.fill(shape{circle(c, r+ra.scale/2, false), circle(c, max(r-ra.scale/2, 0), true)}, col)
+		}
+	case *Path:
+		paths := ra.flattenPath(e.D, dx, dy)
+		var s shape
+		for _, p := range paths {
+			s = append(s, p.pts)
+		}
+		// Path.Render writes fill="none" when Fill is unset.
+		if col, ok := paint(e.Fill); ok {
+			ra.fill(s, col)
+		}
+		if col, ok := paint(e.Stroke); ok {
+			ra.fill(ra.stroke(paths, e.StrokeWidth, e.DashArray), col)
+		}
+	case *Line:
+		ra.drawLine(e, dx, dy)
+	case *Text:
+		ra.drawText(e, dx, dy, category)
+	}
+}
+
+// styleCategory returns the node category cfg styles the rects and text
+// inside an element of class with, itself inside one styled by outer.
+// The stylesheet gives every category rule the same specificity, so of
+// two nested categories the one whose rule comes later wins, not the
+// inner one.
+func styleCategory(cfg *Config, class, outer string) string {
+	best := slices.Index(styleCategories, outer)
+	for _, c := range strings.Fields(class) {
+		if _, ok := cfg.NodeStyles[c]; !ok {
+			continue
+		}
+		if i := slices.Index(styleCategories, c); i > best {
+			best, outer = i, c
+		}
+	}
+	return outer
+}
+
+func (ra *rasterizer) drawRect(e *Rect, dx, dy float64, category string) {
+	if e.Width <= 0 || e.Height <= 0 {
+		return
+	}
+	fill, stroke, width, dash := e.Fill, e.Stroke, e.StrokeWidth, e.StrokeDashArray
+	if category != "" {
+		style := ra.cfg.NodeStyles[category]
+		fill, stroke, width = style.Fill, style.Stroke, ra.cfg.NodeStrokeWidth
+		if category == "comment" {
+			dash = "4,2"
+		}
+	}
+	rx, ry := e.Rx, e.Ry
+	if rx == 0 {
+		rx = ry
+	} else if ry == 0 {
+		ry = rx
+	}
+	x, y, w, h := e.X, e.Y, e.Width, e.Height
+	ra.fill(shape{ra.roundedRect(x, y, w, h, rx, ry, dx, dy)}, fillColor(fill))
+	col, ok := paint(stroke)
+	if !ok {
+		return
+	}
+	if width <= 0 {
+		width = 1
+	}
+	if dash != "" {
+		outline := ra.roundedRect(x, y, w, h, rx, ry, dx, dy)
+		ra.fill(ra.stroke([]subpath{{outline, true}}, width, dash), col)
+		return
+	}
+	half := width / 2
+	inner := ra.roundedRect(x+half, y+half, w-width, h-width, max(rx-half, 0), max(ry-half, 0), dx, dy)
+	slices.Reverse(inner)
+	ra.fill(shape{ra.roundedRect(x-half, y-half, w+width, h+width, rx+half, ry+half, dx, dy), inner}, col)
+}
+
+// roundedRect returns the outline of a rectangle with corner radii rx
+// and ry, wound clockwise.
+func (ra *rasterizer) roundedRect(x, y, w, h, rx, ry, dx, dy float64) []point {
+	if w <= 0 || h <= 0 {
+		return nil
+	}
+	rx, ry = min(rx, w/2), min(ry, h/2)
+	if rx <= 0 || ry <= 0 {
+		return []point{ra.at(x, y, dx, dy), ra.at(x+w, y, dx, dy), ra.at(x+w, y+h, dx, dy), ra.at(x, y+h, dx, dy)}
+	}
+	var pts []point
+	n := arcSegments(max(rx, ry) * ra.scale * math.Pi / 2)
+	for _, corner := range []struct{ cx, cy, from float64 }{
+		{x + w - rx, y + ry, -math.Pi / 2},
+		{x + w - rx, y + h - ry, 0},
+		{x + rx, y + h - ry, math.Pi / 2},
+		{x + rx, y + ry, math.Pi},
+	} {
+		for i := 0; i <= n; i++ {
+			t := corner.from + math.Pi/2*float64(i)/float64(n)
+			pts = append(pts, ra.at(corner.cx+rx*math.Cos(t), corner.cy+ry*math.Sin(t), dx, dy))
+		}
+	}
+	return pts
+}
+
+// drawLine strokes e and draws the connector markers getDefs defines at
+// its ends. Markers scale with the stroke width and turn with the line,
+// as markerUnits="strokeWidth" and orient="auto" do.
+func (ra *rasterizer) drawLine(e *Line, dx, dy float64) {
+	col, ok := paint(e.Stroke)
+	if !ok {
+		return
+	}
+	width := e.StrokeWidth
+	if width <= 0 {
+		width = 1
+	}
+	from, to := ra.at(e.X1, e.Y1, dx, dy), ra.at(e.X2, e.Y2, dx, dy)
+	ra.fill(ra.stroke([]subpath{{pts: []point{from, to}}}, width, ""), col)
+
+	marker, ok := paint(ra.cfg.Connector.Color)
+	if !ok {
+		return
+	}
+	unit := width * ra.scale
+	if e.MarkerStart == startMarkerRef("arrow") {
+		angle := math.Atan2(to.y-from.y, to.x-from.x)
+		sin, cos := math.Sincos(angle)
+		var arrow []point
+		// The marker's polygon, less its reference point (0, 3.5).
+		for _, p := range []point{{0, -3.5}, {10, 0}, {0, 3.5}} {
+			arrow = append(arrow, point{from.x + unit*(p.x*cos-p.y*sin), from.y + unit*(p.x*sin+p.y*cos)})
+		}
+		ra.fill(shape{arrow}, marker)
+	}
+	if e.MarkerEnd == endMarkerRef("dot") {
+		ra.fill(shape{circle(to, endDotRadius*unit, false)}, marker)
+	}
+}
+
+// stroke returns the outline of a stroke width SVG units wide along
+// paths, dashed by dashArray: a quadrilateral per segment, which gives
+// butt caps, and a disc at each joint, which rounds the corners.
+func (ra *rasterizer) stroke(paths []subpath, width float64, dashArray string) shape {
+	if width <= 0 {
+		width = 1
+	}
+	half := width * ra.scale / 2
+	dash := ra.dashPattern(dashArray)
+	var s shape
+	for _, p := range paths {
+		pts := p.pts
+		if p.closed && len(pts) > 2 {
+			pts = append(slices.Clip(pts), pts[0])
+		}
+		runs := [][]point{pts}
+		if dash != nil {
+			runs = dashed(pts, dash)
+		}
+		for _, run := range runs {
+			for i := 1; i < len(run); i++ {
+				if i > 1 {
+					s = append(s, circle(run[i-1], half, false))
+				}
+				if q := segment(run[i-1], run[i], half); q != nil {
+					s = append(s, q)
+				}
+			}
+		}
+		if p.closed && dash == nil && len(pts) > 3 {
+			s = append(s, circle(pts[0], half, false))
+		}
+	}
+	return s
+}
+
+// dashPattern parses a stroke-dasharray into pixels, repeating an odd
+// list as SVG does. It returns nil for a solid stroke.
+func (ra *rasterizer) dashPattern(s string) []float64 {
+	var pattern []float64
+	total := 0.0
+	for _, f := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }) {
+		v, err := strconv.ParseFloat(f, 64)
+		if err != nil || v < 0 {
+			return nil
+		}
+		pattern = append(pattern, v*ra.scale)
+		total += v
+	}
+	if total <= 0 {
+		return nil
+	}
+	if len(pattern)%2 == 1 {
+		pattern = append(pattern, pattern...)
+	}
+	return pattern
+}
+
+// dashed cuts the polyline pts into the runs pattern leaves drawn,
+// starting with a dash.
+func dashed(pts []point, pattern []float64) [][]point {
+	var runs [][]point
+	i, left, on := 0, pattern[0], true
+	run := []point{pts[0]}
+	for k := 1; k < len(pts); k++ {
+		a, b := pts[k-1], pts[k]
+		for {
+			seg := math.Hypot(b.x-a.x, b.y-a.y)
+			if left >= seg {
+				left -= seg
+				break
+			}
+			t := left / seg
+			a = point{a.x + (b.x-a.x)*t, a.y + (b.y-a.y)*t}
+			if on {
+				runs = append(runs, append(run, a))
+				run = nil
+			} else {
+				run = []point{a}
+			}
+			on = !on
+			i = (i + 1) % len(pattern)
+			left = pattern[i]
+		}
+		if on {
+			run = append(run, b)
+		}
+	}
+	if on && len(run) > 1 {
+		runs = append(runs, run)
+	}
+	return runs
+}
+
+// segment returns the quadrilateral covering a stroke half wide on
+// either side of the line from a to b, or nil when a is b. Every
+// segment is wound the same way, counterclockwise.
+func segment(a, b point, half float64) []point {
+	length := math.Hypot(b.x-a.x, b.y-a.y)
+	if length == 0 {
+		return nil
+	}
+	nx, ny := -(b.y-a.y)/length*half, (b.x-a.x)/length*half
+	return []point{{a.x + nx, a.y + ny}, {b.x + nx, b.y + ny}, {b.x - nx, b.y - ny}, {a.x - nx, a.y - ny}}
+}
+
+// circle returns the outline of a circle, wound counterclockwise like
+// segment, or clockwise when reverse is set.
+func circle(c point, r float64, reverse bool) []point {
+	if r <= 0 {
+		return nil
+	}
+	n := max(arcSegments(2*math.Pi*r), 8)
+	pts := make([]point, n)
+	for i := range pts {
+		t := -2 * math.Pi * float64(i) / float64(n)
+		if reverse {
+			t = -t
+		}
+		pts[i] = point{c.x + r*math.Cos(t), c.y + r*math.Sin(t)}
+	}
+	return pts
+}
+
+// arcSegments returns how many straight segments draw a curve about
+// length pixels long smoothly.
+func arcSegments(length float64) int {
+	return min(max(int(math.Ceil(length/2)), 2), 128)
+}
+
+// fill paints s in col. Polygons with fewer than three points add
+// nothing, and an invisible color paints nothing.
+func (ra *rasterizer) fill(s shape, col color.Color) {
+	if col == nil {
+		return
+	}
+	var polys shape
+	for _, poly := range s {
+		if len(poly) >= 3 {
+			polys = append(polys, poly)
+		}
+	}
+	if len(polys) > 0 {
+		ra.out.fill(polys, col)
+	}
+}
+
+func (px *pixels) fill(s shape, col color.Color) {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, poly := range s {
+		for _, p := range poly {
+			minX, minY = min(minX, p.x), min(minY, p.y)
+			maxX, maxY = max(maxX, p.x), max(maxY, p.y)
+		}
+	}
+	bounds := image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX)), int(math.Ceil(maxY))).Intersect(px.img.Bounds())
+	if bounds.Empty() {
+		return
+	}
+	ox, oy := float64(bounds.Min.X), float64(bounds.Min.Y)
+	px.z.Reset(bounds.Dx(), bounds.Dy())
+	px.z.DrawOp = draw.Over
+	for _, poly := range s {
+		px.z.MoveTo(float32(poly[0].x-ox), float32(poly[0].y-oy))
+		for _, p := range poly[1:] {
+			px.z.LineTo(float32(p.x-ox), float32(p.y-oy))
+		}
+		px.z.ClosePath()
+	}
+	px.z.Draw(px.img, bounds, image.NewUniform(col), image.Point{})
+}
+
+func (px *pixels) glyph(_ *opentype.Font, face font.Face, _ float64, c rune, at point, col color.Color) {
+	d := font.Drawer{Dst: px.img, Src: image.NewUniform(col), Face: face}
+	d.Dot = fixed.Point26_6{X: fixed.Int26_6(math.Round(at.x * 64)), Y: fixed.Int26_6(math.Round(at.y * 64))}
+	d.DrawString(string(c))
+}
+
+// paint resolves a CSS color. It reports false for none, transparent,
+// and the values parseColor does not know, which paint nothing.
+func paint(s string) (color.Color, bool) {
+	c, ok := parseColor(s)
+	if !ok {
+		return nil, false
+	}
+	return color.RGBA{c.r, c.g, c.b, 0xff}, true
+}
+
+// fillColor resolves a fill attribute, where leaving it out means
+// black.
+func fillColor(s string) color.Color {
+	if s == "" {
+		s = "black"
+	}
+	col, _ := paint(s)
+	return col
+}
+
+// ================================================================================
+// Path Data
+// ================================================================================
+
+// flattenPath reads d, path data as PathBuilder writes it, into
+// subpaths in pixels, cutting curves and arcs into segments.
+func (ra *rasterizer) flattenPath(d string, dx, dy float64) []subpath {
+	tokens := strings.Fields(strings.ReplaceAll(d, ",", " "))
+	var paths []subpath
+	var cur []point
+	var x, y float64 // Current point, in SVG units
+	cmd := byte(0)
+	next := func(n int) ([]float64, bool) {
+		if len(tokens) < n {
+			return nil, false
+		}
+		args := make([]float64, n)
+		for i := range args {
+			v, err := strconv.ParseFloat(tokens[i], 64)
+			if err != nil {
+				return nil, false
+			}
+			args[i] = v
+		}
+		tokens = tokens[n:]
+		return args, true
+	}
+	flush := func(closed bool) {
+		if len(cur) > 1 {
+			paths = append(paths, subpath{cur, closed})
+		}
+		cur = nil
+	}
+	lineTo := func(nx, ny float64) {
+		x, y = nx, ny
+		cur = append(cur, ra.at(x, y, dx, dy))
+	}
+	var startX, startY float64 // Where the subpath began, for Z
+	for len(tokens) > 0 {
+		if t := tokens[0]; len(t) == 1 && strings.Contains("MLHVQCAZ", t) {
+			cmd = t[0]
+			tokens = tokens[1:]
+		}
+		if cmd == 'Z' {
+			flush(true)
+			x, y = startX, startY
+			cmd = 0
+			continue
+		}
+		args, ok := next(pathArity[cmd])
+		if cmd == 0 || !ok {
+			break
+		}
+		from := ra.at(x, y, dx, dy)
+		switch cmd {
+		case 'M':
+			flush(false)
+			x, y = args[0], args[1]
+			startX, startY = x, y
+			cur = []point{ra.at(x, y, dx, dy)}
+			cmd = 'L' // Further pairs are line-tos
+		case 'L':
+			lineTo(args[0], args[1])
+		case 'H':
+			lineTo(args[0], y)
+		case 'V':
+			lineTo(x, args[0])
+		case 'Q':
+			c, to := ra.at(args[0], args[1], dx, dy), ra.at(args[2], args[3], dx, dy)
+			n := arcSegments(dist(from, c) + dist(c, to))
+			for i := 1; i <= n; i++ {
+				t := float64(i) / float64(n)
+				u := 1 - t
+				cur = append(cur, point{u*u*from.x + 2*u*t*c.x + t*t*to.x, u*u*from.y + 2*u*t*c.y + t*t*to.y})
+			}
+			x, y = args[2], args[3]
+		case 'C':
+			c1, c2, to := ra.at(args[0], args[1], dx, dy), ra.at(args[2], args[3], dx, dy), ra.at(args[4], args[5], dx, dy)
+			n := arcSegments(dist(from, c1) + dist(c1, c2) + dist(c2, to))
+			for i := 1; i <= n; i++ {
+				t := float64(i) / float64(n)
+				u := 1 - t
+				cur = append(cur, point{
+					u*u*u*from.x + 3*u*u*t*c1.x + 3*u*t*t*c2.x + t*t*t*to.x,
+					u*u*u*from.y + 3*u*u*t*c1.y + 3*u*t*t*c2.y + t*t*t*to.y,
+				})
+			}
+			x, y = args[4], args[5]
+		case 'A':
+			for _, p := range arcPoints(x, y, args[0], args[1], args[2], args[3] != 0, args[4] != 0, args[5], args[6], ra.scale) {
+				cur = append(cur, ra.at(p.x, p.y, dx, dy))
+			}
+			x, y = args[5], args[6]
+		}
+	}
+	flush(false)
+	return paths
+}
+
+// pathArity is how many numbers each path command takes.
+var pathArity = map[byte]int{'M': 2, 'L': 2, 'H': 1, 'V': 1, 'Q': 4, 'C': 6, 'A': 7}
+
+func dist(a, b point) float64 { return math.Hypot(b.x-a.x, b.y-a.y) }
+
+// arcPoints returns points along the elliptical arc from x1, y1 to x2,
+// y2 as the SVG A command describes it, in the same units, after the
+// first point. It converts the endpoints to a center and angles as in
+// the SVG specification's implementation notes (F.6.5 and F.6.6).
+func arcPoints(x1, y1, rx, ry, rotation float64, largeArc, sweep bool, x2, y2, scale float64) []point {
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 || (x1 == x2 && y1 == y2) {
+		return []point{{x2, y2}}
+	}
+	sin, cos := math.Sincos(rotation * math.Pi / 180)
+	hx, hy := (x1-x2)/2, (y1-y2)/2
+	px, py := cos*hx+sin*hy, -sin*hx+cos*hy
+	if l := px*px/(rx*rx) + py*py/(ry*ry); l > 1 {
+		rx, ry = rx*math.Sqrt(l), ry*math.Sqrt(l)
+	}
+	num := rx*rx*ry*ry - rx*rx*py*py - ry*ry*px*px
+	den := rx*rx*py*py + ry*ry*px*px
+	coef := math.Sqrt(max(num/den, 0))
+	if largeArc == sweep {
+		coef = -coef
+	}
+	cpx, cpy := coef*rx*py/ry, -coef*ry*px/rx
+	cx, cy := cos*cpx-sin*cpy+(x1+x2)/2, sin*cpx+cos*cpy+(y1+y2)/2
+	angle := func(ux, uy, vx, vy float64) float64 { return math.Atan2(ux*vy-uy*vx, ux*vx+uy*vy) }
+	start := angle(1, 0, (px-cpx)/rx, (py-cpy)/ry)
+	delta := angle((px-cpx)/rx, (py-cpy)/ry, (-px-cpx)/rx, (-py-cpy)/ry)
+	if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	} else if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	}
+	n := arcSegments(math.Abs(delta) * max(rx, ry) * scale)
+	pts := make([]point, n)
+	for i := range pts {
+		t := start + delta*float64(i+1)/float64(n)
+		ex, ey := rx*math.Cos(t), ry*math.Sin(t)
+		pts[i] = point{cos*ex - sin*ey + cx, sin*ex + cos*ey + cy}
+	}
+	pts[n-1] = point{x2, y2}
+	return pts
+}
+
+// ================================================================================
+// Text
+// ================================================================================
+
+// goFonts are the Go fonts text is set in, by [label][bold][italic]:
+// Go Mono for content, Go Regular for labels.
+var goFonts = sync.OnceValue(func() (fonts [2][2][2]*opentype.Font) {
+	for i, ttf := range [][]byte{
+		gomono.TTF, gomonoitalic.TTF, gomonobold.TTF, gomonobolditalic.TTF,
+		goregular.TTF, goitalic.TTF, gobold.TTF, gobolditalic.TTF,
+	} {
+		f, err := opentype.Parse(ttf)
+		if err != nil {
+			panic("renderer: parsing a Go font: " + err.Error())
+		}
+		fonts[i/4][i/2%2][i%2] = f
+	}
+	return fonts
+})
+
+// embeddedFont returns the font EmbedFont gave cfg, or nil when there
+// is none or it is a WOFF or WOFF2 file, which opentype cannot read.
+// MeasureText asks for it for every label, so the last font parsed is
+// kept; comparing a FontFace with the one it came from is cheap, as the
+// two share their bytes.
+func embeddedFont(cfg *Config) *opentype.Font {
+	lastEmbedded.Lock()
+	defer lastEmbedded.Unlock()
+	if cfg.FontFace != lastEmbedded.face {
+		lastEmbedded.face, lastEmbedded.font = cfg.FontFace, parseFontFace(cfg.FontFace)
+	}
+	return lastEmbedded.font
+}
+
+var lastEmbedded struct {
+	sync.Mutex
+	face string
+	font *opentype.Font
+}
+
+func parseFontFace(face string) *opentype.Font {
+	_, data, ok := strings.Cut(face, ";base64,")
+	if !ok {
+		return nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil
+	}
+	f, err := opentype.Parse(raw)
+	if err != nil {
+		return nil
+	}
+	return f
+}
+
+// face returns f at size pixels, made once per document drawn.
+func (ra *rasterizer) face(f *opentype.Font, size float64) font.Face {
+	key := faceKey{f, size}
+	if face, ok := ra.faces[key]; ok {
+		return face
+	}
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingNone})
+	if err != nil {
+		return nil
+	}
+	ra.faces[key] = face
+	return face
+}
+
+// textRun is the part of a text element set in one color.
+type textRun struct {
+	text string
+	fill color.Color
+	x    float64 // Absolute x in SVG units; 0 continues the previous run
+}
+
+// textLook is how the stylesheet draws a Text: its size and color, its
+// weight and slant, and whether it is set in the sans-serif label font
+// rather than the diagram's monospace one.
+type textLook struct {
+	size                float64
+	fill                string
+	bold, italic, label bool
+}
+
+// lookOf returns how cfg's stylesheet draws e, inside an element styled
+// by category.
+func lookOf(cfg *Config, e *Text, category string) textLook {
+	classes := strings.Fields(e.Class)
+	has := func(c string) bool { return slices.Contains(classes, c) }
+
+	label := has("subexp-label") || has("charset-label") || has("flags-label") || has("repeat-label")
+	size := cfg.FontSize
+	if label {
+		size = cfg.LabelFontSize
+	}
+	if has("analysis-badge-label") {
+		size = cfg.FontSize - 3
+	}
+	bold := has("analysis-badge-label") || has("analysis-legend-title")
+	italic := has("analysis-suggestion") || category == "comment"
+	fill := cfg.TextColor
+	if has("repeat-label") {
+		fill = cfg.RepeatLabelColor
+	}
+	if category != "" {
+		fill = cfg.NodeStyles[category].TextColor
+	}
+	var free *TextStyle
+	switch {
+	case has("diagram-title"):
+		free = &cfg.TitleStyle
+	case has("diagram-caption"):
+		free = &cfg.CaptionStyle
+	}
+	if free != nil {
+		style := free.resolved(cfg)
+		size, fill, bold = style.FontSize, style.Color, style.bold()
+		label = !strings.Contains(style.FontFamily, "monospace")
+	}
+	return textLook{size, fill, bold, italic, label}
+}
+
+// drawText sets e with the font, size and color the stylesheet gives
+// it (see getStyles and getAnnotationStyles): the base text rule
+// overrides the element's own attributes, the label and analysis rules
+// override that, and the rule of the category it is in overrides all.
+func (ra *rasterizer) drawText(e *Text, dx, dy float64, category string) {
+	look := lookOf(ra.cfg, e, category)
+	size, fill, bold, italic, label := look.size, look.fill, look.bold, look.italic, look.label
+	col, _ := paint(fill)
+
+	f := ra.embedded
+	if f == nil || label {
+		f = goFonts()[btoi(label)][btoi(bold)][btoi(italic)]
+	}
+	face := ra.face(f, size*ra.scale)
+	if face == nil || size <= 0 {
+		return
+	}
+
+	runs := []textRun{{e.Content, col, 0}}
+	if len(e.Spans) > 0 {
+		runs = runs[:0]
+		for _, span := range e.Spans {
+			spanCol := col
+			if c, ok := paint(span.Fill); ok {
+				spanCol = c
+			}
+			runs = append(runs, textRun{span.Content, spanCol, span.X})
+		}
+	}
+	collapseSpace(runs)
+
+	// The anchor moves the whole text by its width; spans placed at
+	// their own x are taken as starting there.
+	natural := 0.0
+	for _, run := range runs {
+		natural += advance(face, run.text)
+	}
+	stretch, width := 1.0, natural
+	if e.TextLength > 0 && natural > 0 {
+		width = e.TextLength * ra.scale
+		stretch = width / natural
+	}
+	pen := ra.at(e.X, e.Y, dx, dy)
+	switch e.Anchor {
+	case "middle":
+		pen.x -= width / 2
+	case "end":
+		pen.x -= width
+	}
+	for _, run := range runs {
+		if run.x > 0 {
+			pen.x = ra.at(run.x, 0, dx, 0).x
+		}
+		prev := rune(-1)
+		for _, c := range run.text {
+			if prev >= 0 {
+				pen.x += float64(face.Kern(prev, c)) / 64 * stretch
+			}
+			if run.fill != nil {
+				ra.out.glyph(f, face, size*ra.scale, c, pen, run.fill)
+			}
+			a, _ := face.GlyphAdvance(c)
+			pen.x += float64(a) / 64 * stretch
+			prev = c
+		}
+	}
+}
+
+// advance returns how far s moves the pen in face, in pixels.
+func advance(face font.Face, s string) float64 {
+	return float64(font.MeasureString(face, s)) / 64
+}
+
+// collapseSpace folds runs' white space as SVG text does by default:
+// tabs and newlines become spaces, a run of spaces becomes one, and
+// the text loses its leading and trailing spaces.
+func collapseSpace(runs []textRun) {
+	space := true // Drops a leading space
+	for i := range runs {
+		var b strings.Builder
+		for _, c := range runs[i].text {
+			if c == '\t' || c == '\n' || c == '\r' {
+				c = ' '
+			}
+			if c == ' ' && space {
+				continue
+			}
+			space = c == ' '
+			b.WriteRune(c)
+		}
+		runs[i].text = b.String()
+	}
+	for i := len(runs) - 1; i >= 0; i-- {
+		runs[i].text = strings.TrimRight(runs[i].text, " ")
+		if runs[i].text != "" {
+			break
+		}
+	}
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,122 @@
+package renderer
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/0x4d5352/regolith/internal/parser"
+)
+
+// rasterize renders pattern with cfg and draws the document it
+// produced at dpi.
+func rasterize(t *testing.T, cfg *Config, pattern string, dpi float64) (*SVG, image.Image) {
+	t.Helper()
+	root, err := parser.ParseRegex(pattern)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	r := New(cfg)
+	var doc *SVG
+	r.OnDocument = func(d *SVG) { doc = d }
+	r.Render(root)
+	if doc == nil {
+		t.Fatal("OnDocument was not called")
+	}
+	data, err := r.RasterizePNG(doc, dpi)
+	if err != nil {
+		t.Fatalf("RasterizePNG: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decoding the PNG: %v", err)
+	}
+	return doc, img
+}
+
+func TestRasterizePNG(t *testing.T) {
+	for _, dpi := range []float64{96, 192} {
+		doc, img := rasterize(t, DefaultConfig(), "abc", dpi)
+		zoom := dpi / 96
+		want := image.Pt(int(math.Ceil(doc.Width*zoom)), int(math.Ceil(doc.Height*zoom)))
+		if got := img.Bounds().Size(); got != want {
+			t.Errorf("at %g dpi: got %v pixels, want %v", dpi, got, want)
+		}
+		// Without a background fill the corner stays transparent.
+		if _, _, _, a := img.At(0, 0).RGBA(); a != 0 {
+			t.Errorf("at %g dpi: corner alpha = %d, want 0", dpi, a)
+		}
+	}
+
+	// The literal box takes its fill from the stylesheet's literal rule,
+	// and its label from the same rule's text color.
+	cfg := DefaultConfig()
+	_, img := rasterize(t, cfg, "aaaaaaaa", 96)
+	fill, _ := parseColor(cfg.NodeStyles["literal"].Fill)
+	text, _ := parseColor(cfg.NodeStyles["literal"].TextColor)
+	counts := map[rgb]int{}
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			if c.A == 0xff {
+				counts[rgb{c.R, c.G, c.B}]++
+			}
+		}
+	}
+	if counts[fill] < 100 {
+		t.Errorf("literal fill %s covers %d pixels, want a box", cfg.NodeStyles["literal"].Fill, counts[fill])
+	}
+	if counts[text] == 0 {
+		t.Errorf("no pixels in the literal text color %s", cfg.NodeStyles["literal"].TextColor)
+	}
+
+	cfg = DefaultConfig()
+	cfg.BackgroundFill = "#123456"
+	_, img = rasterize(t, cfg, "a", 96)
+	if got := color.NRGBAModel.Convert(img.At(0, 0)); got != (color.NRGBA{0x12, 0x34, 0x56, 0xff}) {
+		t.Errorf("background corner = %v, want #123456", got)
+	}
+
+	if _, err := New(nil).RasterizePNG(&SVG{Width: 10, Height: 10, ViewBox: "0 0 10 10"}, 0); err == nil {
+		t.Error("expected an error for a zero DPI")
+	}
+}
+
+func TestFlattenPath(t *testing.T) {
+	ra := &rasterizer{scale: 2}
+	paths := ra.flattenPath("M 0 0 L 10 0 V 5 Z M 1 1 A 5 5 0 0 1 11 1", 1, 0)
+	if len(paths) != 2 {
+		t.Fatalf("got %d subpaths, want 2", len(paths))
+	}
+	if !paths[0].closed || len(paths[0].pts) != 3 || paths[0].pts[2] != (point{22, 10}) {
+		t.Errorf("first subpath = %+v", paths[0])
+	}
+	arc := paths[1].pts
+	if arc[0] != (point{4, 2}) || arc[len(arc)-1] != (point{24, 2}) {
+		t.Errorf("arc runs from %v to %v, want (4,2) to (24,2)", arc[0], arc[len(arc)-1])
+	}
+	// A half circle of radius 5 bulges 5 units, 10 pixels, off its chord.
+	lowest := 0.0
+	for _, p := range arc {
+		lowest = min(lowest, p.y)
+	}
+	if math.Abs(lowest-(-8)) > 0.1 {
+		t.Errorf("arc reaches y = %g, want -8", lowest)
+	}
+}
+
+func TestDashed(t *testing.T) {
+	runs := dashed([]point{{0, 0}, {10, 0}}, []float64{3, 2})
+	var got []string
+	for _, run := range runs {
+		got = append(got, fmtFloat(run[0].x)+"-"+fmtFloat(run[len(run)-1].x))
+	}
+	if strings.Join(got, " ") != "0-3 5-8" {
+		t.Errorf("dashes = %v, want [0-3 5-8]", got)
+	}
+}
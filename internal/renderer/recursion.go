@@ -0,0 +1,147 @@
+package renderer
+
+import (
+	"fmt"
+	"math"
+
+	parser "github.com/0x4d5352/regolith/internal/ast"
+)
+
+// recursionLinks holds what renderRoot needs to connect each recursive
+// call to the node it re-enters.
+type recursionLinks struct {
+	targets map[*parser.RecursiveRef]parser.Node // See parser.Recursion
+	drawn   map[parser.Node]RenderedNode         // Calls and targets as drawn
+}
+
+// renderRoot draws root like renderRegexp and then links each recursive
+// subroutine call, (?R), (?1) or (?&name) made from inside the group it
+// calls, to that group's box with a dashed connector ending in an arrow.
+// Expanding such a call would never end, so the link stands for going
+// round again. Calls that are not recursion are drawn as plain
+// references, or written out by ExpandSubroutines. Like renderTop, it
+// also reports the y at which the track leaves the diagram.
+func (r *Renderer) renderRoot(root *parser.Regexp) (RenderedNode, float64) {
+	targets := parser.Recursion(root)
+	if len(targets) == 0 {
+		return r.renderTop(root)
+	}
+	r.recursion = &recursionLinks{targets: targets, drawn: map[parser.Node]RenderedNode{}}
+	defer func() { r.recursion = nil }()
+	rendered, exitY := r.renderTop(root)
+	r.recursion.drawn[root] = rendered
+
+	// Find where each drawn node ended up by following the translate
+	// transforms down to it.
+	at := map[SVGElement]BoundingBox{}
+	for _, rn := range r.recursion.drawn {
+		at[rn.Element] = rn.BBox
+	}
+	placed := map[SVGElement]BoundingBox{}
+	var walk func(e SVGElement, dx, dy float64)
+	walk = func(e SVGElement, dx, dy float64) {
+		if bbox, ok := at[e]; ok {
+			placed[e] = bbox.Translate(dx, dy)
+		}
+		g, ok := e.(*Group)
+		if !ok {
+			return
+		}
+		var x, y float64
+		if _, err := fmt.Sscanf(g.Transform, "translate(%g,%g)", &x, &y); err == nil {
+			dx, dy = dx+x, dy+y
+		}
+		for _, child := range g.Children {
+			walk(child, dx, dy)
+		}
+	}
+	walk(rendered.Element, 0, 0)
+
+	// Each link gets a lane of its own above the diagram so that links
+	// running side by side do not merge; the diagram moves down to make
+	// room.
+	var pairs [][2]BoundingBox
+	parser.Walk(root, func(n parser.Node) {
+		call, ok := n.(*parser.RecursiveRef)
+		if !ok || targets[call] == nil {
+			return
+		}
+		from, ok1 := placed[r.recursion.drawn[call].Element]
+		to, ok2 := placed[r.recursion.drawn[targets[call]].Element]
+		if ok1 && ok2 {
+			pairs = append(pairs, [2]BoundingBox{from, to})
+		}
+	})
+	if len(pairs) == 0 {
+		return rendered, exitY
+	}
+	gap := r.Config.Padding / 2
+	shift := float64(len(pairs)) * gap
+	links := &Group{Class: "recursion-links"}
+	for i, p := range pairs {
+		from, to := p[0].Translate(0, shift), p[1].Translate(0, shift)
+		laneY := math.Min(from.Y, to.Y) - float64(i+1)*gap
+		links.Children = append(links.Children, r.recursionLink(from, to, laneY)...)
+	}
+	bbox := rendered.BBox
+	bbox.Height += shift
+	bbox.AnchorY += shift
+	return RenderedNode{
+		Element: &Group{Children: []SVGElement{wrapWithTransform(rendered.Element, 0, shift), links}},
+		BBox:    bbox,
+	}, exitY + shift
+}
+
+// noteRecursion records where a recursive call or a node one re-enters
+// was drawn, for renderRoot to link them.
+func (r *Renderer) noteRecursion(node parser.Node, rn RenderedNode) {
+	if r.recursion == nil {
+		return
+	}
+	if call, ok := node.(*parser.RecursiveRef); ok && r.recursion.targets[call] != nil {
+		r.recursion.drawn[node] = rn
+		return
+	}
+	for _, t := range r.recursion.targets {
+		if t == node {
+			r.recursion.drawn[node] = rn
+			return
+		}
+	}
+}
+
+// recursionLink draws the connector from a call at from to the box at
+// to: up from the call to laneY, along it, and down onto the top edge
+// of the target beside where its label starts.
+func (r *Renderer) recursionLink(from, to BoundingBox, laneY float64) []SVGElement {
+	cfg := r.Config
+	color := cfg.GetNodeStyle("recursive-ref").Stroke
+	if color == "" {
+		color = cfg.Connector.Color
+	}
+	callX := from.CenterX()
+	targetX := to.X + cfg.Padding
+	arrowSize := 5.0
+
+	path := NewPathBuilder()
+	path.MoveTo(callX, from.Y)
+	path.VerticalTo(laneY)
+	path.HorizontalTo(targetX)
+	path.VerticalTo(to.Y)
+	return []SVGElement{
+		&Path{
+			D:           path.String(),
+			Stroke:      color,
+			StrokeWidth: cfg.Connector.StrokeWidth,
+			DashArray:   "4 3",
+			Class:       "recursion-link",
+		},
+		&Path{
+			D: "M " + fmtFloat(targetX-arrowSize) + " " + fmtFloat(to.Y-arrowSize) +
+				" L " + fmtFloat(targetX) + " " + fmtFloat(to.Y) +
+				" L " + fmtFloat(targetX+arrowSize) + " " + fmtFloat(to.Y-arrowSize),
+			Stroke:      color,
+			StrokeWidth: cfg.Connector.StrokeWidth,
+		},
+	}
+}
@@ -3,17 +3,77 @@ package renderer
 import (
 	"fmt"
 	"math"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/0x4d5352/regolith/internal/analyzer"
+	"github.com/0x4d5352/regolith/internal/flavor"
 	"github.com/0x4d5352/regolith/internal/parser"
+	"github.com/rivo/uniseg"
 )
 
 // Renderer handles rendering regex AST to SVG
 type Renderer struct {
-	Config       *Config
-	subexpDepth  int // Tracks nesting depth for subexpressions
+	Config *Config
+	// Source, when non-empty, is drawn beneath the diagram as the raw
+	// pattern with per-token syntax coloring (see renderSource).
+	Source []flavor.Token
+	// Ruler adds a character-position ruler under the source line so
+	// offsets quoted in error messages or review comments can be read
+	// straight off the diagram. It has no effect without Source.
+	Ruler bool
+	// Shorthands, when non-nil, annotates shorthand escapes inside
+	// character classes with the set they match, keyed by escape code
+	// (see flavor.Shorthands). \w is ASCII in JavaScript but Unicode in
+	// .NET, so the display name alone can mislead.
+	Shorthands map[string]flavor.ShorthandSet
+	// Provenance, when non-nil, is embedded as an XML comment naming
+	// the pattern, flavor, and (optionally) version and time.
+	Provenance *Provenance
+	// Summary draws a compact one-line overview for thumbnails: every
+	// group, and a top-level alternation, becomes a labeled chip with
+	// no inner detail (see renderSummaryNode).
+	Summary bool
+	// FlagLabels, when non-empty, is listed in the flags panel in place
+	// of the pattern's flag letters. Flags given to the engine outside
+	// the pattern, like Java's LITERAL, may have no letter at all.
+	FlagLabels []string
+	// Flavor, when set, names the pattern's flag letters in the flags
+	// panel (see flavor.DescribeFlags), translated into Language, a
+	// language tag such as "de". A letter's meaning varies by flavor:
+	// Java's U is UNICODE_CHARACTER_CLASS, PCRE's is ungreedy. Without
+	// a Flavor the letters are shown bare.
+	Flavor   flavor.Flavor
+	Language string
+	// GroupLegend lists every capturing group in a panel beside the
+	// diagram, by number and name with the start of its subpattern cut
+	// from Pattern, the text the diagram was parsed from (see
+	// renderGroupLegend). Each entry links to its group's box.
+	GroupLegend bool
+	Pattern     string
+	// Title and Caption, when non-empty, are drawn above and below the
+	// diagram in Config.TitleStyle and Config.CaptionStyle, a line per
+	// line of text. RenderStack draws them once, around every pattern.
+	Title   string
+	Caption string
+	// PreRender and PostRender, when set, are called for every atom
+	// the diagram draws (see RenderHook), so tools can collect per-node
+	// geometry, tag elements, or leave node types out without touching
+	// the render methods.
+	PreRender  RenderHook
+	PostRender RenderHook
+	// OnDocument, when set, is called with every document the renderer
+	// produces, before it becomes text, so a backend that draws from
+	// the element tree, like RasterizePNG, gets the diagram the SVG
+	// shows.
+	OnDocument   func(doc *SVG)
+	page         *pageInfo // Set by RenderPages while drawing one page
+	subexpDepth  int       // Tracks nesting depth for subexpressions
 	nodeFindings map[parser.Node]*analyzer.Finding
+	recursion    *recursionLinks // Set by renderRoot while drawing
+	groupAnchors map[int]bool    // Groups given an id, set by Render for GroupLegend
+	anchorPrefix string          // Prefixes group ids, set by RenderStack per pattern
 }
 
 // New creates a new Renderer with the given config
@@ -24,7 +84,6 @@ func New(cfg *Config) *Renderer {
 	return &Renderer{Config: cfg}
 }
 
-// Render renders a regex AST to SVG
 // Marker layout constants. These need to stay consistent with the
 // marker definitions in getDefs() — startArrowReach is the polygon
 // width and endDotRadius is the circle r attribute.
@@ -65,131 +124,37 @@ func contentRightMargin(padding float64) float64 {
 	return visibleConnectorWidth + 2*endDotRadius + edgeClearance
 }
 
+// Render renders a regex AST to SVG
 func (r *Renderer) Render(ast *parser.Regexp) string {
-	rendered := r.renderRegexp(ast)
-
-	// Add padding around the diagram. The content area is offset on
-	// each side by contentLeftMargin / contentRightMargin, which
-	// reserve space for the start/end markers and a visible connector
-	// segment between the marker and the first/last content node.
-	padding := r.Config.Padding
-	leftMargin := contentLeftMargin(padding)
-	rightMargin := contentRightMargin(padding)
-	width := rendered.BBox.Width + leftMargin + rightMargin
-	height := rendered.BBox.Height + 2*padding
-
-	// Check for flags and render them
-	var flagsElement SVGElement
-	var flagsRendered RenderedNode
-	flagsWidth := 0.0
-	if ast.Flags != "" {
-		flagsRendered = r.renderFlags(ast.Flags)
-		flagsElement = flagsRendered.Element
-		flagsWidth = flagsRendered.BBox.Width + padding
-		width += flagsWidth
-		// Ensure height accommodates flags box
-		if flagsRendered.BBox.Height+2*padding > height {
-			height = flagsRendered.BBox.Height + 2*padding
-		}
-	}
-
-	// Check for pattern start options (PCRE)
-	var bannerHeight float64
-	var bannerElement SVGElement
-	if len(ast.Options) > 0 {
-		bannerRendered := r.renderPatternOptions(ast.Options)
-		bannerElement = bannerRendered.Element
-		bannerHeight = bannerRendered.BBox.Height + padding/2
-
-		// Ensure the SVG is wide enough for the banner
-		bannerWidth := bannerRendered.BBox.Width + 2*padding
-		if bannerWidth > width {
-			width = bannerWidth
-		}
-
-		height += bannerHeight
-	}
-
-	// Create start and end connectors. The start line spans from the
-	// left edge clearance out to leftMargin (where content begins),
-	// hosting the arrow marker plus a visible connector segment. The
-	// end line mirrors this on the right with the dot marker.
-	startX := padding / 2
-	anchorY := bannerHeight + padding + rendered.BBox.AnchorY
-	contentEndX := width - rightMargin - flagsWidth
-	endLineLength := float64(visibleConnectorWidth + endDotRadius)
-
-	startLine := &Line{
-		X1:          startX,
-		Y1:          anchorY,
-		X2:          leftMargin,
-		Y2:          anchorY,
-		Stroke:      r.Config.Connector.Color,
-		StrokeWidth: r.Config.Connector.StrokeWidth,
-		MarkerStart: startMarkerRef(r.Config.Connector.StartMarker),
-	}
-
-	endLine := &Line{
-		X1:          contentEndX,
-		Y1:          anchorY,
-		X2:          contentEndX + endLineLength,
-		Y2:          anchorY,
-		Stroke:      r.Config.Connector.Color,
-		StrokeWidth: r.Config.Connector.StrokeWidth,
-		MarkerEnd:   endMarkerRef(r.Config.Connector.EndMarker),
-	}
-
-	// Wrap the rendered content in a group offset by leftMargin so
-	// the first node sits at the end of the start connector line.
-	contentGroup := &Group{
-		Transform: "translate(" + fmtFloat(leftMargin) + "," + fmtFloat(bannerHeight+padding) + ")",
-		Children:  []SVGElement{rendered.Element},
-	}
-
-	// When BackgroundFill is set, prepend a full-viewBox rect so it
-	// paints behind every other child. Width/height here are the final
-	// SVG dimensions, already adjusted above for the banner and flags
-	// add-ons, so the rect covers the entire visible surface.
-	var children []SVGElement
-	if r.Config.BackgroundFill != "" {
-		children = append(children, &Rect{
-			X:      0,
-			Y:      0,
-			Width:  width,
-			Height: height,
-			Fill:   r.Config.BackgroundFill,
-		})
+	if r.GroupLegend {
+		r.groupAnchors = map[int]bool{}
+		defer func() { r.groupAnchors = nil }()
 	}
-	children = append(children, startLine, endLine, contentGroup)
+	rendered, exitY := r.renderRoot(ast)
+	return r.compose(r.frame(ast, rendered, exitY))
+}
 
-	// Add banner if present
-	if bannerElement != nil {
-		bannerGroup := &Group{
-			Transform: "translate(" + fmtFloat(padding) + "," + fmtFloat(padding/2) + ")",
-			Children:  []SVGElement{bannerElement},
+// frame gathers the parts compose places around rendered, the diagram
+// of ast whose track leaves it at exitY: the flags panel and group
+// legend beside it, the pattern start options banner (PCRE) above it,
+// and the source line below.
+func (r *Renderer) frame(ast *parser.Regexp, rendered RenderedNode, exitY float64) frame {
+	f := frame{diagram: rendered, exitY: exitY}
+	if r.hasFlags(ast) {
+		f.side = append(f.side, r.renderFlags(ast.Flags))
+	}
+	if r.GroupLegend {
+		if legend, ok := r.renderGroupLegend(ast); ok {
+			f.side = append(f.side, legend)
 		}
-		children = append(children, bannerGroup)
 	}
-
-	// Add flags if present
-	if flagsElement != nil {
-		flagsGroup := &Group{
-			Transform: "translate(" + fmtFloat(width-padding-flagsWidth+padding/2) + "," + fmtFloat(bannerHeight+padding) + ")",
-			Children:  []SVGElement{flagsElement},
-		}
-		children = append(children, flagsGroup)
+	if len(ast.Options) > 0 {
+		f.above = append(f.above, r.renderPatternOptions(ast.Options))
 	}
-
-	svg := &SVG{
-		Width:    width,
-		Height:   height,
-		ViewBox:  "0 0 " + fmtFloat(width) + " " + fmtFloat(height),
-		Defs:     r.getDefs(),
-		Style:    r.getStyles(),
-		Children: children,
+	if len(r.Source) > 0 {
+		f.below = append(f.below, r.renderSource(r.Source))
 	}
-
-	return svg.Render()
+	return f
 }
 
 // startMarkerRef returns the SVG marker reference string for a
@@ -240,6 +205,202 @@ func (r *Renderer) getDefs() string {
 	return b.String()
 }
 
+// renderSource renders the raw pattern as a single line of monospace
+// text, one <tspan> per token, with a marker under each token spanning
+// the characters it covers. Every span is pinned to its column with an
+// explicit x, measured as MeasureText measures the diagram's content,
+// so the text lines up with the layout even when the viewer's
+// monospace font is slightly wider or narrower, and wide or combining
+// glyphs take the room they draw in.
+func (r *Renderer) renderSource(tokens []flavor.Token) RenderedNode {
+	cfg := r.Config
+	text := &Text{
+		X:          0,
+		Y:          cfg.FontSize,
+		FontFamily: cfg.FontFamily,
+		FontSize:   cfg.FontSize,
+		Class:      "source",
+	}
+	xs, width := sourceColumns(tokens, cfg)
+	markers := &Group{Class: "source-markers"}
+	col := 0
+	for _, tok := range tokens {
+		start := xs[col]
+		col += utf8.RuneCountInString(tok.Text)
+		end := width
+		if col < len(xs) {
+			end = xs[col]
+		}
+		fill := r.sourceTokenColor(tok.Kind)
+		text.Spans = append(text.Spans, &TSpan{
+			Content: tok.Text,
+			Class:   "src-" + string(tok.Kind),
+			Fill:    fill,
+			X:       start,
+		})
+		if end > start {
+			markers.Children = append(markers.Children, &Path{
+				D: NewPathBuilder().MoveTo(start+1, 0).LineTo(start+1, sourceMarkerTick).
+					LineTo(end-1, sourceMarkerTick).LineTo(end-1, 0).String(),
+				Stroke:      fill,
+				StrokeWidth: 1,
+				Fill:        "none",
+			})
+		}
+	}
+	height := cfg.FontSize + sourceMarkerGap
+	children := []SVGElement{text, wrapWithTransform(markers, 0, height)}
+	height += sourceMarkerTick + cfg.Padding/2
+	if !r.Ruler {
+		return RenderedNode{
+			Element: &Group{Children: children},
+			BBox:    NewBoundingBox(0, 0, width, height),
+		}
+	}
+
+	ruler := r.renderRuler(xs, width)
+	return RenderedNode{
+		Element: &Group{Children: append(children, wrapWithTransform(ruler.Element, 0, height))},
+		BBox:    NewBoundingBox(0, 0, math.Max(width, ruler.BBox.Width), height+ruler.BBox.Height),
+	}
+}
+
+// Source marker geometry: the gap between the source text's baseline
+// and the markers, and how far each marker's ends rise.
+const (
+	sourceMarkerGap  = 4
+	sourceMarkerTick = 3
+)
+
+// sourceColumns returns the x at which each character of the tokens'
+// text starts, one per rune, and the width of the whole line. Each
+// grapheme cluster advances by its MeasureText width, so a wide glyph
+// takes two cells and the combining marks after a letter share its x.
+func sourceColumns(tokens []flavor.Token, cfg *Config) ([]float64, float64) {
+	var xs []float64
+	x := 0.0
+	for _, tok := range tokens {
+		text := tok.Text
+		state := -1
+		for len(text) > 0 {
+			var cluster string
+			cluster, text, _, state = uniseg.FirstGraphemeClusterInString(text, state)
+			for range utf8.RuneCountInString(cluster) {
+				xs = append(xs, x)
+			}
+			x += MeasureText(cluster, cfg)
+		}
+	}
+	return xs, x
+}
+
+// Ruler geometry. Minor ticks mark every column, major ticks every
+// rulerMajorEvery columns, and numbered labels the first column and
+// every rulerLabelEvery.
+const (
+	rulerMinorTick  = 3
+	rulerMajorTick  = 6
+	rulerMajorEvery = 5
+	rulerLabelEvery = 10
+)
+
+// renderRuler draws a character-position ruler under the source line
+// whose characters start at xs and which ends at width. Ticks sit under
+// the center of each character and labels are 1-based rune columns,
+// the column a parse error reports (flavor.ParseError.Col).
+func (r *Renderer) renderRuler(xs []float64, width float64) RenderedNode {
+	cfg := r.Config
+	center := func(i int) float64 {
+		end := width
+		if i+1 < len(xs) {
+			end = xs[i+1]
+		}
+		return (xs[i] + end) / 2
+	}
+
+	children := []SVGElement{&Line{
+		X1: 0, Y1: 0, X2: width, Y2: 0,
+		Stroke:      cfg.Connector.Color,
+		StrokeWidth: 1,
+	}}
+	pb := NewPathBuilder()
+	for i := range xs {
+		tick := float64(rulerMinorTick)
+		if (i+1)%rulerMajorEvery == 0 {
+			tick = rulerMajorTick
+		}
+		pb.MoveTo(center(i), 0).LineTo(center(i), tick)
+	}
+	if len(xs) > 0 {
+		children = append(children, &Path{
+			D:           pb.String(),
+			Stroke:      cfg.Connector.Color,
+			StrokeWidth: 1,
+		})
+	}
+
+	labelY := rulerMajorTick + cfg.LabelFontSize
+	labeled := func(col int) bool { return col == 1 || col%rulerLabelEvery == 0 }
+	last := 0
+	for col := 1; col <= len(xs); col++ {
+		if !labeled(col) {
+			continue
+		}
+		last = col
+		children = append(children, &Text{
+			X:          center(col - 1),
+			Y:          labelY,
+			Content:    strconv.Itoa(col),
+			FontFamily: cfg.LabelFontFamily,
+			FontSize:   cfg.LabelFontSize,
+			Fill:       cfg.RepeatLabelColor,
+			Anchor:     "middle",
+		})
+	}
+
+	// The last label is centered on its tick and can overhang the final
+	// column by half its own width.
+	if last > 0 {
+		overhang := center(last-1) + MeasureLabelText(strconv.Itoa(last), cfg)/2
+		width = math.Max(width, overhang)
+	}
+	return RenderedNode{
+		Element: &Group{Class: "ruler", Children: children},
+		BBox:    NewBoundingBox(0, 0, width, labelY+cfg.Padding/2),
+	}
+}
+
+// sourceTokenColor maps a token kind onto the color of the diagram
+// element it corresponds to, so a reader can match "(" in the source to
+// a group box and "\d" to an escape node by color alone. Node strokes
+// rather than fills are used: the source sits on the page background,
+// and strokes are the saturated half of each category pair.
+func (r *Renderer) sourceTokenColor(kind flavor.TokenKind) string {
+	cfg := r.Config
+	switch kind {
+	case flavor.TokenLiteral:
+		return cfg.GetNodeStyle("literal").Stroke
+	case flavor.TokenEscape:
+		return cfg.GetNodeStyle("escape").Stroke
+	case flavor.TokenClass:
+		return cfg.GetNodeStyle("charset").Stroke
+	case flavor.TokenAnchor:
+		return cfg.GetNodeStyle("anchor").Stroke
+	case flavor.TokenComment:
+		return cfg.GetNodeStyle("comment").TextColor
+	case flavor.TokenDelimiter:
+		return cfg.GetNodeStyle("flags").Stroke
+	case flavor.TokenGroup:
+		return cfg.SubexpStroke
+	case flavor.TokenQuantifier:
+		return cfg.RepeatLabelColor
+	case flavor.TokenAlternation:
+		return cfg.Connector.Color
+	default:
+		return cfg.TextColor
+	}
+}
+
 // renderPatternOptions renders PCRE pattern start options as a banner.
 // The banner text is a structural description regolith generates, so
 // it uses the sans-serif label font family.
@@ -295,6 +456,14 @@ func (r *Renderer) renderPatternOptions(options []*parser.PatternOption) Rendere
 	}
 }
 
+// styleCategories lists the NodeStyles keys the stylesheet emits rules
+// for, in emission order.
+var styleCategories = []string{
+	"literal", "escape", "charset", "anchor", "any-character",
+	"flags", "recursive-ref", "callout", "backtrack-control",
+	"conditional", "comment",
+}
+
 // getStyles returns the CSS styles for the SVG.
 //
 // The stylesheet is generated from r.Config.NodeStyles so that a theme
@@ -313,13 +482,8 @@ func (r *Renderer) getStyles() string {
 
 	// Category rules — iterate in a stable, readable order rather
 	// than whatever order range-over-map yields.
-	categories := []string{
-		"literal", "escape", "charset", "anchor", "any-character",
-		"flags", "recursive-ref", "callout", "backtrack-control",
-		"conditional", "comment",
-	}
 	strokeWidth := fmtFloat(cfg.NodeStrokeWidth)
-	for _, class := range categories {
+	for _, class := range styleCategories {
 		style, ok := cfg.NodeStyles[class]
 		if !ok {
 			continue
@@ -347,6 +511,7 @@ func (r *Renderer) getStyles() string {
 	// deliberately NOT set globally — each category rule above sets
 	// it per class, and TextColor on cfg is only a fallback for
 	// elements outside any category.
+	b.WriteString(r.fontFaceRule())
 	fmt.Fprintf(&b,
 		"\n\t\ttext { font-family: %s; font-size: %spx; fill: %s; }",
 		cfg.FontFamily, fmtFloat(cfg.FontSize), cfg.TextColor)
@@ -360,15 +525,60 @@ func (r *Renderer) getStyles() string {
 	fmt.Fprintf(&b,
 		"\n\t\t.repeat-label { fill: %s; font-family: %s; font-size: %spx; }",
 		cfg.RepeatLabelColor, cfg.LabelFontFamily, fmtFloat(cfg.LabelFontSize))
+	b.WriteString(r.titleStyles())
 
 	b.WriteString("\n\t")
 	return b.String()
 }
 
-// renderNode dispatches to the appropriate render method based on node type.
-// The result is passed through annotateNode, which overlays severity markers
-// when an analysis report is active (nodeFindings is non-nil).
+// RenderHook observes or changes the rendering of one AST node.
+//
+// Hooks see every atom the diagram draws — literals, escapes, classes,
+// groups and the like — but not the Regexp and Match nodes that only
+// arrange atoms into sequences and alternatives.
+//
+// A PreRender hook is called before the node is drawn, with rn zeroed.
+// Setting rn.Element replaces the node's drawing with rn — an empty
+// &Group{} with a zero BBox vetoes it, leaving a bare connector — and
+// the node's children are then not visited. A PostRender hook is called
+// after the node and its children are drawn, with rn holding the
+// result; it may read rn.BBox, which is in the node's own coordinates
+// before its parent positions it, or replace rn.Element, for instance
+// with a Group carrying Data attributes. Hooks run for nested nodes
+// first, so a PostRender hook sees a group's contents before the group.
+type RenderHook func(node parser.Node, rn *RenderedNode)
+
+// renderNode runs the PreRender hook, dispatches to the appropriate
+// render method based on node type, and passes the result through
+// annotateNode, which overlays severity markers when an analysis report
+// is active (nodeFindings is non-nil), and then the PostRender hook.
 func (r *Renderer) renderNode(node parser.Node) RenderedNode {
+	if r.PreRender != nil {
+		var rn RenderedNode
+		r.PreRender(node, &rn)
+		if rn.Element != nil {
+			return r.postRender(node, rn)
+		}
+	}
+	if r.Summary {
+		if chip, ok := r.renderSummaryNode(node); ok {
+			return r.postRender(node, r.annotateNode(node, chip))
+		}
+	}
+	return r.postRender(node, r.annotateNode(node, r.dispatchNode(node)))
+}
+
+// postRender passes rn through the PostRender hook, if any.
+func (r *Renderer) postRender(node parser.Node, rn RenderedNode) RenderedNode {
+	if r.PostRender != nil {
+		r.PostRender(node, &rn)
+	}
+	r.noteRecursion(node, rn)
+	return rn
+}
+
+// dispatchNode draws node with the render method for its type.
+func (r *Renderer) dispatchNode(node parser.Node) RenderedNode {
 	var rendered RenderedNode
 	switch n := node.(type) {
 	case *parser.Regexp:
@@ -420,7 +630,7 @@ func (r *Renderer) renderNode(node parser.Node) RenderedNode {
 	default:
 		rendered = r.renderStructuralLabel(fmt.Sprintf("<%s>", node.Type()), "unknown")
 	}
-	return r.annotateNode(node, rendered)
+	return rendered
 }
 
 // cornerRadiusFor returns the effective corner radius for a node class.
@@ -455,14 +665,14 @@ func (r *Renderer) renderLabel(text, class string) RenderedNode {
 		Ry:     radius,
 	}
 
-	textElem := &Text{
+	textElem := r.fitText(&Text{
 		X:          width / 2,
 		Y:          height/2 + cfg.FontSize/3, // Approximate vertical centering
 		Content:    text,
 		FontFamily: cfg.FontFamily,
 		FontSize:   cfg.FontSize,
 		Anchor:     "middle",
-	}
+	}, textWidth)
 
 	group := &Group{
 		Class:    class,
@@ -534,44 +744,44 @@ func (r *Renderer) renderStructuralLabel(text, class string) RenderedNode {
 	}
 }
 
-// renderFlags renders regex flags (gimuy) as a labeled box
+// hasFlags reports whether the flags panel is drawn for ast.
+func (r *Renderer) hasFlags(ast *parser.Regexp) bool {
+	return ast.Flags != "" || len(r.FlagLabels) > 0
+}
+
+// renderFlags renders the pattern's flags as a labeled box, one item
+// per flag. Items come from FlagLabels when set, and otherwise from the
+// Flavor's description of each letter in flags, in Language, with the
+// flag's description as a tooltip and a link to its documentation.
+// Without a Flavor the letters are shown as written.
 func (r *Renderer) renderFlags(flags string) RenderedNode {
 	cfg := r.Config
 	padding := cfg.Padding
 
-	// Build flag descriptions
-	var flagItems []string
-	for _, f := range flags {
-		switch f {
-		case 'd':
-			flagItems = append(flagItems, "hasIndices")
-		case 'g':
-			flagItems = append(flagItems, "global")
-		case 'i':
-			flagItems = append(flagItems, "ignore case")
-		case 'm':
-			flagItems = append(flagItems, "multiline")
-		case 's':
-			flagItems = append(flagItems, "dotAll")
-		case 'u':
-			flagItems = append(flagItems, "unicode")
-		case 'y':
-			flagItems = append(flagItems, "sticky")
-		case 'v':
-			flagItems = append(flagItems, "unicodeSets")
+	var flagItems []flavor.FlagInfo
+	switch {
+	case len(r.FlagLabels) > 0:
+		for _, l := range r.FlagLabels {
+			flagItems = append(flagItems, flavor.FlagInfo{Label: l})
+		}
+	case r.Flavor != nil:
+		flagItems = flavor.DescribeFlags(r.Flavor, flags, r.Language)
+	default:
+		for _, c := range flags {
+			flagItems = append(flagItems, flavor.FlagInfo{Char: c, Label: string(c)})
 		}
 	}
 
 	label := "Flags:"
 
 	// Calculate dimensions. Both the header and the flag item names
-	// ("global", "ignore case", ...) are English descriptions regolith
-	// generates, so both are measured against the sans-serif label
+	// ("global", "ignore case", ...) are prose rather than pattern
+	// text, so both are measured against the sans-serif label
 	// char-width.
 	labelWidth := MeasureLabelText(label, cfg)
 	maxItemWidth := 0.0
 	for _, item := range flagItems {
-		w := MeasureLabelText(item, cfg)
+		w := MeasureLabelText(item.Label, cfg)
 		if w > maxItemWidth {
 			maxItemWidth = w
 		}
@@ -614,14 +824,21 @@ func (r *Renderer) renderFlags(flags string) RenderedNode {
 	// Flag items
 	y := labelHeight + cfg.FontSize
 	for _, item := range flagItems {
-		children = append(children, &Text{
+		var elem SVGElement = &Text{
 			X:          width / 2,
 			Y:          y,
-			Content:    item,
+			Content:    item.Label,
 			FontFamily: cfg.LabelFontFamily,
 			FontSize:   cfg.LabelFontSize,
 			Anchor:     "middle",
-		})
+		}
+		if item.Description != "" {
+			elem = &Group{Children: []SVGElement{&Title{Content: item.Description}, elem}}
+		}
+		if item.DocURL != "" {
+			elem = &Link{Href: item.DocURL, Children: []SVGElement{elem}}
+		}
+		children = append(children, elem)
 		y += itemHeight
 	}
 
@@ -657,7 +874,7 @@ func (r *Renderer) renderQuotedLabel(text, class string) RenderedNode {
 	}
 
 	// Create text with styled quotes
-	textElem := &Text{
+	textElem := r.fitText(&Text{
 		X:          width / 2,
 		Y:          height/2 + cfg.FontSize/3,
 		FontFamily: cfg.FontFamily,
@@ -668,7 +885,7 @@ func (r *Renderer) renderQuotedLabel(text, class string) RenderedNode {
 			{Content: text},
 			{Content: `"`, Class: "quote"},
 		},
-	}
+	}, textWidth)
 
 	group := &Group{
 		Class:    class,
@@ -688,39 +905,155 @@ func (r *Renderer) renderLiteral(lit *parser.Literal) RenderedNode {
 
 // renderEscape renders an escape sequence
 func (r *Renderer) renderEscape(esc *parser.Escape) RenderedNode {
+	switch esc.EscapeType {
+	case "extended_grapheme", "grapheme":
+		return r.renderGrapheme(esc.Value, "escape", graphemeClusterNote, graphemeClusterTitle)
+	}
 	return r.renderLabel(esc.Value, "escape")
 }
 
-// renderAnchor renders an anchor (^, $, \b, \B, \<, \>, \A, \Z, \z, \G)
+// Explanations drawn under \X and \b{g}, the grapheme constructs readers
+// most often take for "one code point". The short note is always
+// visible; the longer text is the node's tooltip.
+const (
+	graphemeClusterNote  = "may match several code points, e.g. e + accent"
+	graphemeClusterTitle = "Extended grapheme cluster: one user-perceived character, " +
+		"which may be several code points, e.g. e followed by a combining accent (U+0301), " +
+		"or an emoji with skin-tone and joiner sequences."
+	graphemeBoundaryNote  = "between user-perceived characters"
+	graphemeBoundaryTitle = "Grapheme cluster boundary: matches between two user-perceived " +
+		"characters and never inside one, so it does not split e + combining accent " +
+		"or an emoji sequence."
+)
+
+// renderGrapheme draws a grapheme construct as its usual box with a
+// cluster icon (an accented e in a ring) ahead of the label, the short
+// note centered underneath, and the long explanation as a tooltip. The
+// node's anchor stays on the box so the track runs through it, not
+// through the note.
+func (r *Renderer) renderGrapheme(label, class, note, title string) RenderedNode {
+	cfg := r.Config
+	style := cfg.GetNodeStyle(class)
+	radius := r.cornerRadiusFor(class)
+
+	padding := cfg.Padding / 2
+	if style.CornerRadius > padding {
+		padding = style.CornerRadius
+	}
+	iconR := cfg.FontSize * 0.55
+	gap := cfg.Padding / 2
+
+	// Escapes are regex content (monospace); anchors are descriptions.
+	fontFamily, fontSize, textWidth := cfg.FontFamily, cfg.FontSize, MeasureText(label, cfg)
+	if class == "anchor" {
+		fontFamily, fontSize, textWidth = cfg.LabelFontFamily, cfg.LabelFontSize, MeasureLabelText(label, cfg)
+	}
+
+	boxWidth := padding + 2*iconR + gap + textWidth + padding
+	boxHeight := cfg.FontSize + 2*(cfg.Padding/2)
+	noteWidth := MeasureLabelText(note, cfg)
+	width := math.Max(boxWidth, noteWidth)
+	boxX := (width - boxWidth) / 2
+	cy := boxHeight / 2
+
+	labelText := &Text{
+		X:          padding + 2*iconR + gap + textWidth/2,
+		Y:          cy + fontSize/3,
+		Content:    label,
+		FontFamily: fontFamily,
+		FontSize:   fontSize,
+		Anchor:     "middle",
+	}
+	if class != "anchor" {
+		r.fitText(labelText, textWidth)
+	}
+	box := &Group{
+		Transform: "translate(" + fmtFloat(boxX) + ",0)",
+		Children: []SVGElement{
+			&Rect{Width: boxWidth, Height: boxHeight, Rx: radius, Ry: radius},
+			&Circle{Cx: padding + iconR, Cy: cy, R: iconR, Fill: "none", Stroke: style.TextColor, Class: "grapheme-icon"},
+			&Text{
+				X:          padding + iconR,
+				Y:          cy + cfg.LabelFontSize/3,
+				Content:    "é",
+				FontFamily: cfg.LabelFontFamily,
+				FontSize:   cfg.LabelFontSize,
+				Anchor:     "middle",
+				Class:      "grapheme-icon",
+			},
+			labelText,
+		},
+	}
+	noteY := boxHeight + cfg.LabelFontSize
+	noteText := &Text{
+		X:          width / 2,
+		Y:          noteY,
+		Content:    note,
+		FontFamily: cfg.LabelFontFamily,
+		FontSize:   cfg.LabelFontSize,
+		Fill:       cfg.RepeatLabelColor,
+		Anchor:     "middle",
+		Class:      "grapheme-note",
+	}
+
+	bbox := NewBoundingBox(0, 0, width, noteY+cfg.LabelFontSize/3)
+	bbox.AnchorY = cy
+	if boxX > 0 {
+		// Connectors stop at the box edges; the wider note must not
+		// leave a gap in the track.
+		box.Children = append(box.Children,
+			&Line{X1: -boxX, Y1: cy, X2: 0, Y2: cy, Stroke: cfg.Connector.Color, StrokeWidth: cfg.Connector.StrokeWidth},
+			&Line{X1: boxWidth, Y1: cy, X2: boxWidth + boxX, Y2: cy, Stroke: cfg.Connector.Color, StrokeWidth: cfg.Connector.StrokeWidth},
+		)
+	}
+	return RenderedNode{
+		Element: &Group{
+			Class:    class + " grapheme",
+			Children: []SVGElement{box, noteText, &Title{Content: title}},
+		},
+		BBox: bbox,
+	}
+}
+
+// renderAnchor renders an anchor (^, $, \b, \B, \<, \>, \A, \Z, \z, \G, \zs, \ze)
 func (r *Renderer) renderAnchor(anchor *parser.Anchor) RenderedNode {
-	var label string
+	if anchor.AnchorType == "grapheme_cluster_boundary" {
+		return r.renderGrapheme(anchorLabel(anchor), "anchor", graphemeBoundaryNote, graphemeBoundaryTitle)
+	}
+	return r.renderStructuralLabel(anchorLabel(anchor), "anchor")
+}
+
+// anchorLabel returns the description drawn for an anchor.
+func anchorLabel(anchor *parser.Anchor) string {
 	switch anchor.AnchorType {
 	case "start":
-		label = "Start of line"
+		return "Start of line"
 	case "end":
-		label = "End of line"
+		return "End of line"
 	case "word_boundary":
-		label = "Word boundary"
+		return "Word boundary"
 	case "non_word_boundary":
-		label = "Non-word boundary"
+		return "Non-word boundary"
 	case "word_start":
-		label = "Start of word"
+		return "Start of word"
 	case "word_end":
-		label = "End of word"
+		return "End of word"
 	case "string_start":
-		label = "Start of input"
+		return "Start of input"
 	case "string_end":
-		label = "End of input"
+		return "End of input"
 	case "absolute_end":
-		label = "Absolute end"
+		return "Absolute end"
 	case "end_of_previous_match":
-		label = "End of previous match"
+		return "End of previous match"
+	case "match_start":
+		return "Match starts here"
+	case "match_end":
+		return "Match ends here"
 	case "grapheme_cluster_boundary":
-		label = "Grapheme cluster boundary"
-	default:
-		label = anchor.AnchorType
+		return "Grapheme cluster boundary"
 	}
-	return r.renderStructuralLabel(label, "anchor")
+	return anchor.AnchorType
 }
 
 // renderAnyCharacter renders the . metacharacter
@@ -732,26 +1065,30 @@ func (r *Renderer) renderAnyCharacter(_ *parser.AnyCharacter) RenderedNode {
 // The label is a description ("back reference #1"), not raw regex
 // syntax, so it renders in the sans-serif structural font.
 func (r *Renderer) renderBackReference(br *parser.BackReference) RenderedNode {
-	var label string
+	return r.renderStructuralLabel(backReferenceLabel(br), "escape")
+}
+
+// backReferenceLabel returns the description drawn for a back-reference.
+func backReferenceLabel(br *parser.BackReference) string {
 	if br.Name != "" {
-		label = fmt.Sprintf("back reference '%s'", br.Name)
-	} else {
-		label = fmt.Sprintf("back reference #%d", br.Number)
+		return fmt.Sprintf("back reference '%s'", br.Name)
 	}
-	return r.renderStructuralLabel(label, "escape")
+	return fmt.Sprintf("back reference #%d", br.Number)
 }
 
 // renderUnicodePropertyEscape renders a Unicode property escape like
 // \p{Letter} or \P{Number}. Like back-references, the label is a
 // description ("Unicode Letter") and uses the structural font.
 func (r *Renderer) renderUnicodePropertyEscape(upe *parser.UnicodePropertyEscape) RenderedNode {
-	var label string
+	return r.renderStructuralLabel(unicodePropertyLabel(upe), "escape")
+}
+
+// unicodePropertyLabel returns the description drawn for \p{...}.
+func unicodePropertyLabel(upe *parser.UnicodePropertyEscape) string {
 	if upe.Negated {
-		label = fmt.Sprintf("NOT Unicode %s", upe.Property)
-	} else {
-		label = fmt.Sprintf("Unicode %s", upe.Property)
+		return fmt.Sprintf("NOT Unicode %s", upe.Property)
 	}
-	return r.renderStructuralLabel(label, "escape")
+	return fmt.Sprintf("Unicode %s", upe.Property)
 }
 
 // renderQuotedLiteral renders a \Q...\E quoted literal sequence
@@ -804,17 +1141,7 @@ func (r *Renderer) renderComment(comment *parser.Comment) RenderedNode {
 
 // renderInlineModifier renders inline flag modifiers like (?i) or (?i:...)
 func (r *Renderer) renderInlineModifier(im *parser.InlineModifier) RenderedNode {
-	// Build the modifier label
-	var label string
-	if im.Enable != "" && im.Disable != "" {
-		label = fmt.Sprintf("flags: +%s -%s", im.Enable, im.Disable)
-	} else if im.Enable != "" {
-		label = fmt.Sprintf("flags: +%s", im.Enable)
-	} else if im.Disable != "" {
-		label = fmt.Sprintf("flags: -%s", im.Disable)
-	} else {
-		label = "flags"
-	}
+	label := inlineModifierLabel(im)
 
 	// If scoped (has Regexp), render as a group with the content
 	if im.Regexp != nil {
@@ -827,17 +1154,22 @@ func (r *Renderer) renderInlineModifier(im *parser.InlineModifier) RenderedNode
 	return r.renderStructuralLabel(label, "flags")
 }
 
+// inlineModifierLabel returns the label for (?i), (?-s) or (?i-s:...).
+func inlineModifierLabel(im *parser.InlineModifier) string {
+	switch {
+	case im.Enable != "" && im.Disable != "":
+		return fmt.Sprintf("flags: +%s -%s", im.Enable, im.Disable)
+	case im.Enable != "":
+		return fmt.Sprintf("flags: +%s", im.Enable)
+	case im.Disable != "":
+		return fmt.Sprintf("flags: -%s", im.Disable)
+	}
+	return "flags"
+}
+
 // renderBalancedGroup renders a .NET balanced group (?<name-other>...) or (?<-other>...)
 func (r *Renderer) renderBalancedGroup(bg *parser.BalancedGroup) RenderedNode {
-	// Build the label based on whether it's capturing or non-capturing
-	var label string
-	if bg.Name != "" {
-		// Capturing balanced group: (?<name-other>...)
-		label = fmt.Sprintf("balanced group '%s' (pop '%s')", bg.Name, bg.OtherName)
-	} else {
-		// Non-capturing balanced group: (?<-other>...)
-		label = fmt.Sprintf("balance (pop '%s')", bg.OtherName)
-	}
+	label := balancedGroupLabel(bg)
 
 	// Increment depth before rendering nested content
 	r.subexpDepth++
@@ -863,53 +1195,21 @@ func (r *Renderer) renderBalancedGroup(bg *parser.BalancedGroup) RenderedNode {
 	return r.renderSubexpBox(label, content, fill)
 }
 
+// balancedGroupLabel returns the box label for a balanced group.
+func balancedGroupLabel(bg *parser.BalancedGroup) string {
+	if bg.Name != "" {
+		// Capturing balanced group: (?<name-other>...)
+		return fmt.Sprintf("balanced group '%s' (pop '%s')", bg.Name, bg.OtherName)
+	}
+	// Non-capturing balanced group: (?<-other>...)
+	return fmt.Sprintf("balance (pop '%s')", bg.OtherName)
+}
+
 // renderConditional renders a conditional pattern (?(cond)yes|no)
 func (r *Renderer) renderConditional(cond *parser.Conditional) RenderedNode {
 	cfg := r.Config
 
-	// Build condition label
-	var condLabel string
-	switch c := cond.Condition.(type) {
-	case *parser.BackReference:
-		if c.Name != "" {
-			condLabel = fmt.Sprintf("if '%s' matched", c.Name)
-		} else if c.Number >= 0 {
-			condLabel = fmt.Sprintf("if group %d matched", c.Number)
-		} else {
-			condLabel = fmt.Sprintf("if group %d matched", -c.Number)
-		}
-	case *parser.RecursiveRef:
-		switch c.Target {
-		case "R":
-			condLabel = "if in recursion"
-		case "DEFINE", "":
-			condLabel = "DEFINE"
-		default:
-			condLabel = fmt.Sprintf("if in recursion to '%s'", c.Target)
-		}
-	case *parser.Literal:
-		if c.Text == "DEFINE" {
-			condLabel = "DEFINE"
-		} else {
-			condLabel = fmt.Sprintf("if %s", c.Text)
-		}
-	case *parser.Subexp:
-		// Assertion as condition
-		switch c.GroupType {
-		case parser.GroupPositiveLookahead:
-			condLabel = "if followed by..."
-		case parser.GroupNegativeLookahead:
-			condLabel = "if not followed by..."
-		case parser.GroupPositiveLookbehind:
-			condLabel = "if preceded by..."
-		case parser.GroupNegativeLookbehind:
-			condLabel = "if not preceded by..."
-		default:
-			condLabel = "if assertion"
-		}
-	default:
-		condLabel = "if condition"
-	}
+	condLabel := conditionLabel(cond)
 
 	// Render the yes (true) branch
 	yesContent := r.renderRegexp(cond.TrueMatch)
@@ -972,27 +1272,66 @@ func (r *Renderer) renderConditional(cond *parser.Conditional) RenderedNode {
 	return r.renderLabeledBoxWithContent(condLabel, contentNode, "conditional")
 }
 
+// conditionLabel returns the label for a conditional's condition, e.g.
+// "if group 1 matched".
+func conditionLabel(cond *parser.Conditional) string {
+	switch c := cond.Condition.(type) {
+	case *parser.BackReference:
+		if c.Name != "" {
+			return fmt.Sprintf("if '%s' matched", c.Name)
+		}
+		if c.Number < 0 {
+			return fmt.Sprintf("if group %d matched", -c.Number)
+		}
+		return fmt.Sprintf("if group %d matched", c.Number)
+	case *parser.RecursiveRef:
+		switch c.Target {
+		case "R":
+			return "if in recursion"
+		case "DEFINE", "":
+			return "DEFINE"
+		}
+		return fmt.Sprintf("if in recursion to '%s'", c.Target)
+	case *parser.Literal:
+		if c.Text == "DEFINE" {
+			return "DEFINE"
+		}
+		return fmt.Sprintf("if %s", c.Text)
+	case *parser.Subexp:
+		// Assertion as condition
+		switch c.GroupType {
+		case parser.GroupPositiveLookahead:
+			return "if followed by..."
+		case parser.GroupNegativeLookahead:
+			return "if not followed by..."
+		case parser.GroupPositiveLookbehind:
+			return "if preceded by..."
+		case parser.GroupNegativeLookbehind:
+			return "if not preceded by..."
+		}
+		return "if assertion"
+	}
+	return "if condition"
+}
+
 // renderRecursiveRef renders a recursive pattern reference (?R), (?n), (?&name)
 func (r *Renderer) renderRecursiveRef(ref *parser.RecursiveRef) RenderedNode {
-	var label string
+	return r.renderStructuralLabel(recursiveRefLabel(ref), "recursive-ref")
+}
+
+// recursiveRefLabel returns the description drawn for (?R), (?1) or (?&name).
+func recursiveRefLabel(ref *parser.RecursiveRef) string {
 	switch ref.Target {
 	case "R", "0":
-		label = "recurse whole pattern"
-	default:
-		// Check if it's a number or name
-		if len(ref.Target) > 0 {
-			first := ref.Target[0]
-			if first == '+' || first == '-' || (first >= '0' && first <= '9') {
-				label = fmt.Sprintf("recurse to group %s", ref.Target)
-			} else {
-				label = fmt.Sprintf("recurse to '%s'", ref.Target)
-			}
-		} else {
-			label = "recurse"
-		}
+		return "recurse whole pattern"
+	case "":
+		return "recurse"
 	}
-
-	return r.renderStructuralLabel(label, "recursive-ref")
+	// Check if it's a number or name
+	if first := ref.Target[0]; first == '+' || first == '-' || (first >= '0' && first <= '9') {
+		return fmt.Sprintf("recurse to group %s", ref.Target)
+	}
+	return fmt.Sprintf("recurse to '%s'", ref.Target)
 }
 
 // renderBranchReset renders a branch reset group (?|...)
@@ -1023,58 +1362,57 @@ func (r *Renderer) renderBranchReset(br *parser.BranchReset) RenderedNode {
 
 // renderBacktrackControl renders a backtracking control verb (*FAIL), (*PRUNE), etc.
 func (r *Renderer) renderBacktrackControl(bc *parser.BacktrackControl) RenderedNode {
-	var label string
+	return r.renderStructuralLabel(backtrackControlLabel(bc), "backtrack-control")
+}
+
+// backtrackControlLabel returns the description drawn for a verb such as (*PRUNE).
+func backtrackControlLabel(bc *parser.BacktrackControl) string {
 	switch bc.Verb {
 	case "ACCEPT":
-		label = "accept match"
+		return "accept match"
 	case "FAIL":
-		label = "force fail"
+		return "force fail"
 	case "MARK":
 		if bc.Arg != "" {
-			label = fmt.Sprintf("mark '%s'", bc.Arg)
-		} else {
-			label = "mark"
+			return fmt.Sprintf("mark '%s'", bc.Arg)
 		}
+		return "mark"
 	case "COMMIT":
-		label = "commit (no retry)"
+		return "commit (no retry)"
 	case "PRUNE":
 		if bc.Arg != "" {
-			label = fmt.Sprintf("prune '%s'", bc.Arg)
-		} else {
-			label = "prune"
+			return fmt.Sprintf("prune '%s'", bc.Arg)
 		}
+		return "prune"
 	case "SKIP":
 		if bc.Arg != "" {
-			label = fmt.Sprintf("skip to '%s'", bc.Arg)
-		} else {
-			label = "skip"
+			return fmt.Sprintf("skip to '%s'", bc.Arg)
 		}
+		return "skip"
 	case "THEN":
 		if bc.Arg != "" {
-			label = fmt.Sprintf("then '%s'", bc.Arg)
-		} else {
-			label = "then (try next alt)"
+			return fmt.Sprintf("then '%s'", bc.Arg)
 		}
+		return "then (try next alt)"
 	default:
 		if bc.Arg != "" {
-			label = fmt.Sprintf("*%s:%s", bc.Verb, bc.Arg)
-		} else {
-			label = fmt.Sprintf("*%s", bc.Verb)
+			return fmt.Sprintf("*%s:%s", bc.Verb, bc.Arg)
 		}
+		return fmt.Sprintf("*%s", bc.Verb)
 	}
-
-	return r.renderStructuralLabel(label, "backtrack-control")
 }
 
 // renderCallout renders a PCRE callout (?C), (?Cn), (?C"text")
 func (r *Renderer) renderCallout(n *parser.Callout) RenderedNode {
-	var label string
+	return r.renderStructuralLabel(calloutLabel(n), "callout")
+}
+
+// calloutLabel returns the label drawn for a callout.
+func calloutLabel(n *parser.Callout) string {
 	if n.Number >= 0 {
-		label = fmt.Sprintf("callout (%d)", n.Number)
-	} else {
-		label = fmt.Sprintf("callout \"%s\"", n.Text)
+		return fmt.Sprintf("callout (%d)", n.Number)
 	}
-	return r.renderStructuralLabel(label, "callout")
+	return fmt.Sprintf("callout \"%s\"", n.Text)
 }
 
 // renderMatch renders a sequence of fragments
@@ -1087,12 +1425,27 @@ func (r *Renderer) renderMatch(match *parser.Match) RenderedNode {
 		}
 	}
 
+	fragments := match.Fragments
+	if r.Config.MergeLiterals {
+		fragments = r.mergeLiteralRuns(fragments)
+	}
+	if l := r.Config.LookaroundLayout; l == "above" || l == "below" {
+		if rendered, ok := r.renderMatchOffTrack(fragments, l == "above"); ok {
+			return rendered
+		}
+	}
+
 	// Render all fragments
-	items := make([]RenderedNode, len(match.Fragments))
-	for i, frag := range match.Fragments {
+	items := make([]RenderedNode, len(fragments))
+	for i, frag := range fragments {
 		items[i] = r.renderMatchFragment(frag)
 	}
+	return r.joinSequence(items)
+}
 
+// joinSequence lines items up left to right on one track, joined by
+// connector lines.
+func (r *Renderer) joinSequence(items []RenderedNode) RenderedNode {
 	// Space horizontally
 	spacedItems, totalBBox := SpaceHorizontally(items, r.Config.HorizontalGap)
 
@@ -1134,6 +1487,44 @@ func (r *Renderer) renderMatch(match *parser.Match) RenderedNode {
 	}
 }
 
+// mergeLiteralRuns joins each run of adjacent unquantified literal
+// fragments into one, for Config.MergeLiterals. Fragments carrying an
+// analysis finding are left alone so their markers still point at the
+// right box.
+func (r *Renderer) mergeLiteralRuns(frags []*parser.MatchFragment) []*parser.MatchFragment {
+	mergeable := func(frag *parser.MatchFragment) (*parser.Literal, bool) {
+		lit, ok := frag.Content.(*parser.Literal)
+		if !ok || frag.Repeat != nil || r.nodeFindings[frag] != nil || r.nodeFindings[lit] != nil {
+			return nil, false
+		}
+		return lit, true
+	}
+	merged := make([]*parser.MatchFragment, 0, len(frags))
+	for i := 0; i < len(frags); i++ {
+		lit, ok := mergeable(frags[i])
+		if !ok {
+			merged = append(merged, frags[i])
+			continue
+		}
+		text := lit.Text
+		j := i + 1
+		for ; j < len(frags); j++ {
+			next, ok := mergeable(frags[j])
+			if !ok {
+				break
+			}
+			text += next.Text
+		}
+		if j == i+1 {
+			merged = append(merged, frags[i])
+		} else {
+			merged = append(merged, &parser.MatchFragment{Content: &parser.Literal{Text: text}})
+		}
+		i = j - 1
+	}
+	return merged
+}
+
 // renderMatchFragment renders a fragment (content with optional repeat)
 func (r *Renderer) renderMatchFragment(frag *parser.MatchFragment) RenderedNode {
 	content := r.renderNode(frag.Content)
@@ -1152,7 +1543,7 @@ func (r *Renderer) renderMatchFragment(frag *parser.MatchFragment) RenderedNode
 // renderWithRepeat adds skip/loop paths for quantifiers
 func (r *Renderer) renderWithRepeat(content RenderedNode, repeat *parser.Repeat) RenderedNode {
 	cfg := r.Config
-	curveRadius := 10.0
+	curveRadius := cfg.CurveRadius
 
 	hasSkip := repeat.Min == 0 // Optional: can skip content
 	hasLoop := repeat.Max != 1 // Can repeat: show loop
@@ -1167,48 +1558,103 @@ func (r *Renderer) renderWithRepeat(content RenderedNode, repeat *parser.Repeat)
 		loopHeight = curveRadius * 2
 	}
 
+	// The label ("1+ times", "2 to 5 times") goes under the loop.
+	label := ""
+	if hasLoop {
+		label = r.getRepeatLabel(repeat)
+		if cfg.TerseRepeatLabels && trivialRepeat(repeat) {
+			label = ""
+		}
+	}
+
 	// Adjust content position
 	contentOffsetY := skipHeight
 	contentOffsetX := curveRadius
 
-	// Calculate new bounding box
+	// Calculate new bounding box. A label wider than the loop would
+	// hang past its sides, across the loop of an enclosing quantifier
+	// or into the next item's label, so the repeat widens to hold it
+	// and centers the content.
 	width := content.BBox.Width + 2*curveRadius
+	if labelWidth := MeasureLabelText(label, cfg); labelWidth > width {
+		contentOffsetX += (labelWidth - width) / 2
+		width = labelWidth
+	}
 	height := content.BBox.Height + skipHeight + loopHeight
 	anchorY := contentOffsetY + content.BBox.AnchorY
 
+	// With the skip-first lazy layout, the way out of the repeat is the
+	// primary track and the loop back is secondary. For x*? and x?? the
+	// way out is the skip path; for x+? there is none, so only the loop
+	// changes.
+	skipWidth := cfg.Connector.StrokeWidth
+	skipClass, loopClass, loopDash := "skip-path", "loop-path", ""
+	if !repeat.Greedy && !repeat.Possessive && cfg.Connector.LazyLayout == "skip-first" {
+		skipWidth *= 2
+		skipClass += " lazy-preferred"
+		loopClass += " lazy-secondary"
+		loopDash = "4 3"
+	}
+
 	var children []SVGElement
 
-	// Create skip path (above content)
+	// Create skip path (above content). When the content reaches higher
+	// above its anchor than the skip leaves room for, as the upper
+	// branches of an alternation do, the skip climbs beside it and runs
+	// over its top instead of cutting through it.
 	if hasSkip {
+		skipY := anchorY - curveRadius
+		if content.BBox.AnchorY-content.BBox.Y > skipHeight {
+			skipY = contentOffsetY + content.BBox.Y - curveRadius
+		}
 		skipPath := NewPathBuilder()
 		skipPath.MoveTo(0, anchorY)
-		skipPath.QuadraticTo(0, anchorY-curveRadius, curveRadius, anchorY-curveRadius)
+		if skipY < anchorY-curveRadius {
+			skipPath.VerticalTo(skipY + curveRadius)
+		}
+		skipPath.QuadraticTo(0, skipY, curveRadius, skipY)
 		skipPath.HorizontalTo(width - curveRadius)
-		skipPath.QuadraticTo(width, anchorY-curveRadius, width, anchorY)
+		skipPath.QuadraticTo(width, skipY, width, skipY+curveRadius)
+		if skipY < anchorY-curveRadius {
+			skipPath.VerticalTo(anchorY)
+		}
 
 		children = append(children, &Path{
 			D:           skipPath.String(),
 			Stroke:      cfg.Connector.Color,
-			StrokeWidth: cfg.Connector.StrokeWidth,
-			Class:       "skip-path",
+			StrokeWidth: skipWidth,
+			Class:       skipClass,
 		})
 	}
 
-	// Create loop path (below content)
+	// Create loop path (below content). Under content that reaches
+	// further below its anchor than one curve, the loop drops straight
+	// down beside it and only curves at the corners, so the way back
+	// keeps clear of the lower branch connectors rather than sweeping
+	// across them.
 	if hasLoop {
 		loopY := contentOffsetY + content.BBox.Height + curveRadius
 
 		loopPath := NewPathBuilder()
 		loopPath.MoveTo(width, anchorY)
-		loopPath.QuadraticTo(width, loopY, width-curveRadius, loopY)
-		loopPath.HorizontalTo(curveRadius)
-		loopPath.QuadraticTo(0, loopY, 0, anchorY)
+		if content.BBox.Height-content.BBox.AnchorY > loopHeight {
+			loopPath.VerticalTo(loopY - curveRadius)
+			loopPath.QuadraticTo(width, loopY, width-curveRadius, loopY)
+			loopPath.HorizontalTo(curveRadius)
+			loopPath.QuadraticTo(0, loopY, 0, loopY-curveRadius)
+			loopPath.VerticalTo(anchorY)
+		} else {
+			loopPath.QuadraticTo(width, loopY, width-curveRadius, loopY)
+			loopPath.HorizontalTo(curveRadius)
+			loopPath.QuadraticTo(0, loopY, 0, anchorY)
+		}
 
 		children = append(children, &Path{
 			D:           loopPath.String(),
 			Stroke:      cfg.Connector.Color,
 			StrokeWidth: cfg.Connector.StrokeWidth,
-			Class:       "loop-path",
+			DashArray:   loopDash,
+			Class:       loopClass,
 		})
 
 		// Add arrow on loop to indicate direction
@@ -1236,10 +1682,9 @@ func (r *Renderer) renderWithRepeat(content RenderedNode, repeat *parser.Repeat)
 			})
 		}
 
-		// Add repeat label. The label ("1+ times", "2 to 5 times") is
-		// a structural description and uses the sans-serif label font
-		// — the CSS class also recolors it to the connector gray.
-		label := r.getRepeatLabel(repeat)
+		// Add repeat label. The label is a structural description and
+		// uses the sans-serif label font — the CSS class also recolors
+		// it to the connector gray.
 		if label != "" {
 			children = append(children, &Text{
 				X:          width / 2,
@@ -1334,6 +1779,38 @@ func (r *Renderer) getRepeatLabel(repeat *parser.Repeat) string {
 	return label
 }
 
+// trivialRepeat reports whether repeat is *, +, or ?, lazy or
+// possessive, whose skip and loop paths need no label to be read.
+func trivialRepeat(repeat *parser.Repeat) bool {
+	return repeat.Min <= 1 && (repeat.Max == 1 || repeat.Max == -1)
+}
+
+// numberBranch sets an alternation branch's number ("1.") over a lead-in
+// of track ahead of it (see Config.NumberAlternatives).
+func (r *Renderer) numberBranch(item RenderedNode, n int) RenderedNode {
+	cfg := r.Config
+	label := strconv.Itoa(n) + "."
+	lead := MeasureLabelText(label, cfg) + cfg.HorizontalGap/2
+	b := item.BBox
+	baseline := b.AnchorY - cfg.LabelFontSize/3
+	// A branch whose box is no taller than the label pushes itself down
+	// to make room above the track.
+	dy := math.Max(0, b.Y-(baseline-cfg.LabelFontSize))
+	g := &Group{Class: "branch-number", Children: []SVGElement{
+		&Line{X1: 0, Y1: b.AnchorY, X2: lead + b.AnchorLeft, Y2: b.AnchorY, Stroke: cfg.Connector.Color, StrokeWidth: cfg.Connector.StrokeWidth},
+		&Text{X: 0, Y: baseline, Content: label, FontFamily: cfg.LabelFontFamily, FontSize: cfg.LabelFontSize, Fill: cfg.RepeatLabelColor},
+		wrapWithTransform(item.Element, lead, 0),
+	}}
+	return RenderedNode{
+		Element: wrapWithTransform(g, 0, dy),
+		BBox: BoundingBox{
+			Width: lead + b.Width, Height: b.Height + dy,
+			AnchorLeft: 0, AnchorRight: lead + b.AnchorRight,
+			AnchorY: b.AnchorY + dy,
+		},
+	}
+}
+
 // renderRegexp renders alternation
 func (r *Renderer) renderRegexp(regexp *parser.Regexp) RenderedNode {
 	if len(regexp.Matches) == 0 {
@@ -1347,19 +1824,33 @@ func (r *Renderer) renderRegexp(regexp *parser.Regexp) RenderedNode {
 		// No alternation, just render the match
 		return r.renderMatch(regexp.Matches[0])
 	}
+	if r.Summary {
+		return r.renderChip(fmt.Sprintf("one of %d alternatives", len(regexp.Matches)))
+	}
 
 	// Render all alternatives
 	items := make([]RenderedNode, len(regexp.Matches))
 	for i, match := range regexp.Matches {
 		items[i] = r.renderMatch(match)
+		// Branches lifted by ast.FlattenAlternations keep their old
+		// position as a hover tooltip.
+		if match.Origin != "" {
+			items[i].Element = &Group{
+				Class:    "flattened",
+				Children: []SVGElement{items[i].Element, &Title{Content: match.Origin}},
+			}
+		}
+		if r.Config.NumberAlternatives {
+			items[i] = r.numberBranch(items[i], i+1)
+		}
 	}
 
 	// Space vertically
 	spacedItems, totalBBox := SpaceVertically(items, r.Config.VerticalGap*2)
 
 	cfg := r.Config
-	curveRadius := 10.0
-	connectorWidth := 20.0
+	curveRadius := cfg.CurveRadius
+	connectorWidth := cfg.ConnectorWidth
 
 	// Adjust for connector space
 	width := totalBBox.Width + 2*connectorWidth
@@ -1452,33 +1943,53 @@ func (r *Renderer) renderRegexp(regexp *parser.Regexp) RenderedNode {
 
 // renderCharset renders a character class
 func (r *Renderer) renderCharset(charset *parser.Charset) RenderedNode {
-	if charset.SetExpression != nil {
-		return r.renderCharsetSetExpression(charset)
-	}
-
-	// Render charset items
-	var itemTexts []string
-	for _, item := range charset.Items {
-		itemTexts = append(itemTexts, r.charsetItemText(item))
+	label, items, ok := r.charsetContents(charset)
+	if !ok {
+		return r.renderStructuralLabel(label, "charset")
 	}
+	return r.renderLabeledBox(label, items, "charset")
+}
 
-	label := "One of:"
-	if charset.Inverted {
-		label = "None of:"
+// charsetContents returns the header ("One of:") and member lines drawn
+// for a character class. ok is false for a set expression with no
+// member list, whose label is then drawn on its own.
+func (r *Renderer) charsetContents(charset *parser.Charset) (label string, items []string, ok bool) {
+	switch expr := charset.SetExpression.(type) {
+	case nil:
+		for _, item := range charset.Items {
+			items = append(items, r.charsetItemText(item))
+		}
+		label = "One of:"
+		if charset.Inverted {
+			label = "None of:"
+		}
+	case *parser.CharsetIntersection:
+		items = r.charsetOperandTexts(expr.Operands)
+		label = "Intersection:"
+		if charset.Inverted {
+			label = "NOT Intersection:"
+		}
+	case *parser.CharsetSubtraction:
+		items = r.charsetOperandTexts(expr.Operands)
+		label = "Subtraction:"
+		if charset.Inverted {
+			label = "NOT Subtraction:"
+		}
+	default:
+		return "<set-expression>", nil, false
 	}
-
-	return r.renderLabeledBox(label, itemTexts, "charset")
+	return label, items, true
 }
 
 // charsetItemText returns the display text for a single charset item
 func (r *Renderer) charsetItemText(item parser.CharsetItem) string {
 	switch it := item.(type) {
 	case *parser.CharsetLiteral:
-		return fmt.Sprintf(`"%s"`, it.Text)
+		return charsetCharText(it.Text)
 	case *parser.CharsetRange:
-		return fmt.Sprintf(`"%s" - "%s"`, it.First, it.Last)
+		return charsetCharText(it.First) + " - " + charsetCharText(it.Last)
 	case *parser.Escape:
-		return it.Value
+		return r.charsetEscapeText(it)
 	case *parser.POSIXClass:
 		return r.getPOSIXClassLabel(it)
 	case *parser.Charset:
@@ -1492,27 +2003,26 @@ func (r *Renderer) charsetItemText(item parser.CharsetItem) string {
 	}
 }
 
-// renderCharsetSetExpression renders a charset that uses v-mode set operations
-func (r *Renderer) renderCharsetSetExpression(charset *parser.Charset) RenderedNode {
-	var texts []string
-	switch expr := charset.SetExpression.(type) {
-	case *parser.CharsetIntersection:
-		texts = r.charsetOperandTexts(expr.Operands)
-		label := "Intersection:"
-		if charset.Inverted {
-			label = "NOT Intersection:"
-		}
-		return r.renderLabeledBox(label, texts, "charset")
-	case *parser.CharsetSubtraction:
-		texts = r.charsetOperandTexts(expr.Operands)
-		label := "Subtraction:"
-		if charset.Inverted {
-			label = "NOT Subtraction:"
-		}
-		return r.renderLabeledBox(label, texts, "charset")
-	default:
-		return r.renderStructuralLabel("<set-expression>", "charset")
+// charsetWhitespaceNames names the whitespace characters that would
+// otherwise show up as a blank pair of quotes in a charset item list.
+// The names match the ones flavors give the equivalent escapes.
+var charsetWhitespaceNames = map[string]string{
+	" ":      "space",
+	"\t":     "tab",
+	"\n":     "new line",
+	"\r":     "carriage return",
+	"\f":     "form feed",
+	"\v":     "vertical tab",
+	"\u00a0": "no-break space",
+}
+
+// charsetCharText returns the display text for a literal character in
+// a charset: whitespace by name, anything else quoted
+func charsetCharText(text string) string {
+	if name, ok := charsetWhitespaceNames[text]; ok {
+		return name
 	}
+	return fmt.Sprintf(`"%s"`, text)
 }
 
 // renderCharsetIntersection renders a CharsetIntersection node
@@ -1578,7 +2088,7 @@ func (r *Renderer) charsetOperandText(node parser.Node) string {
 		}
 		return fmt.Sprintf(`\p{%s}`, n.Property)
 	case *parser.Escape:
-		return n.Value
+		return r.charsetEscapeText(n)
 	case *parser.CharsetStringDisjunction:
 		return fmt.Sprintf(`\q{%s}`, strings.Join(n.Strings, "|"))
 	default:
@@ -1586,6 +2096,17 @@ func (r *Renderer) charsetOperandText(node parser.Node) string {
 	}
 }
 
+// charsetEscapeText returns the display text for an escape inside a
+// character class, followed by its expansion when r.Shorthands has one,
+// e.g. "word = [A-Za-z0-9_] (ASCII)".
+func (r *Renderer) charsetEscapeText(esc *parser.Escape) string {
+	set, ok := r.Shorthands[esc.Code]
+	if !ok {
+		return esc.Value
+	}
+	return esc.Value + " = " + set.Members + " (" + set.Mode + ")"
+}
+
 // getPOSIXClassLabel returns a human-readable label for a POSIX character class
 func (r *Renderer) getPOSIXClassLabel(pc *parser.POSIXClass) string {
 	labels := map[string]string{
@@ -1616,7 +2137,38 @@ func (r *Renderer) getPOSIXClassLabel(pc *parser.POSIXClass) string {
 
 // renderSubexp renders a subexpression group
 func (r *Renderer) renderSubexp(subexp *parser.Subexp) RenderedNode {
-	// Get label based on group type
+	label := subexpLabel(subexp)
+
+	// Determine fill color based on depth
+	// Depth 0 (outermost) = transparent, depth 1+ = cycle through colors
+	currentDepth := r.subexpDepth
+	var fill string
+	if currentDepth == 0 {
+		fill = r.Config.SubexpFill // "none" by default
+	} else if len(r.Config.SubexpColors) > 0 {
+		// Cycle through colors for nested subexps (depth 1, 2, 3...)
+		colorIndex := (currentDepth - 1) % len(r.Config.SubexpColors)
+		fill = r.Config.SubexpColors[colorIndex]
+	} else {
+		fill = r.Config.SubexpFill
+	}
+
+	// Increment depth before rendering nested content
+	r.subexpDepth++
+
+	// Render the contained regexp
+	content := r.renderRegexp(subexp.Regexp)
+
+	// Decrement depth after rendering
+	r.subexpDepth--
+
+	rn := r.renderSubexpBox(label, content, fill)
+	r.anchorGroup(subexp, rn)
+	return rn
+}
+
+// subexpLabel returns the box label for a group, e.g. "group #1".
+func subexpLabel(subexp *parser.Subexp) string {
 	var label string
 	switch subexp.GroupType {
 	case "capture":
@@ -1643,34 +2195,15 @@ func (r *Renderer) renderSubexp(subexp *parser.Subexp) RenderedNode {
 		label = "atomic script run"
 	case "atomic":
 		label = "atomic group"
+	case "subroutine":
+		label = fmt.Sprintf("call to group #%d", subexp.Number)
+		if subexp.Name != "" {
+			label = fmt.Sprintf("call to group #%d '%s'", subexp.Number, subexp.Name)
+		}
 	default:
 		label = subexp.GroupType
 	}
-
-	// Determine fill color based on depth
-	// Depth 0 (outermost) = transparent, depth 1+ = cycle through colors
-	currentDepth := r.subexpDepth
-	var fill string
-	if currentDepth == 0 {
-		fill = r.Config.SubexpFill // "none" by default
-	} else if len(r.Config.SubexpColors) > 0 {
-		// Cycle through colors for nested subexps (depth 1, 2, 3...)
-		colorIndex := (currentDepth - 1) % len(r.Config.SubexpColors)
-		fill = r.Config.SubexpColors[colorIndex]
-	} else {
-		fill = r.Config.SubexpFill
-	}
-
-	// Increment depth before rendering nested content
-	r.subexpDepth++
-
-	// Render the contained regexp
-	content := r.renderRegexp(subexp.Regexp)
-
-	// Decrement depth after rendering
-	r.subexpDepth--
-
-	return r.renderSubexpBox(label, content, fill)
+	return label
 }
 
 // renderLabeledBox creates a labeled box with text items (for charset).
@@ -1729,14 +2262,14 @@ func (r *Renderer) renderLabeledBox(label string, items []string, class string)
 	// Items (regex content)
 	y := labelHeight + cfg.FontSize
 	for _, item := range items {
-		children = append(children, &Text{
+		children = append(children, r.fitText(&Text{
 			X:          width / 2,
 			Y:          y,
 			Content:    item,
 			FontFamily: cfg.FontFamily,
 			FontSize:   cfg.FontSize,
 			Anchor:     "middle",
-		})
+		}, MeasureText(item, cfg)))
 		y += itemHeight
 	}
 
@@ -1,10 +1,23 @@
 package renderer
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
 	"strings"
 	"testing"
 
+	"github.com/0x4d5352/regolith/internal/ast"
+	"github.com/0x4d5352/regolith/internal/flavor"
+	_ "github.com/0x4d5352/regolith/internal/flavor/gnused"
+	"github.com/0x4d5352/regolith/internal/flavor/javascript"
+	"github.com/0x4d5352/regolith/internal/flavor/pcre"
 	"github.com/0x4d5352/regolith/internal/parser"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
 )
 
 // TestRenderBackgroundFill exercises the background-rect injection
@@ -163,6 +176,25 @@ func TestRenderCharsetRange(t *testing.T) {
 	}
 }
 
+func TestRenderCharsetWhitespace(t *testing.T) {
+	ast, err := parser.ParseRegex("[\\ \t -~]")
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	r := New(nil)
+	svg := r.Render(ast)
+
+	for _, want := range []string{">space<", ">tab<", ">space - &#34;~&#34;<"} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("expected %q in the item list", want)
+		}
+	}
+	if strings.Contains(svg, "&#34; &#34;") || strings.Contains(svg, "&#34;\t&#34;") {
+		t.Error("whitespace should be named, not quoted")
+	}
+}
+
 func TestRenderQuantifiers(t *testing.T) {
 	tests := []struct {
 		pattern string
@@ -198,6 +230,281 @@ func TestRenderQuantifiers(t *testing.T) {
 	}
 }
 
+// TestRenderLazyLayout checks that the skip-first layout only restyles
+// lazy repeats, and that the default layout leaves them untouched.
+func TestRenderLazyLayout(t *testing.T) {
+	tests := []struct {
+		pattern       string
+		layout        string
+		wantPreferred bool // skip path drawn as the primary track
+		wantSecondary bool // loop drawn dashed
+	}{
+		{"a*?", "skip-first", true, true},
+		{"a+?", "skip-first", false, true},
+		{"a??", "skip-first", true, false},
+		{"a*", "skip-first", false, false},
+		{"a*?", "arrow", false, false},
+		{"a*?", "", false, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.pattern+" "+tc.layout, func(t *testing.T) {
+			ast, err := parser.ParseRegex(tc.pattern)
+			if err != nil {
+				t.Fatalf("parse error: %v", err)
+			}
+			cfg := DefaultConfig()
+			cfg.Connector.LazyLayout = tc.layout
+			svg := New(cfg).Render(ast)
+
+			if got := strings.Contains(svg, "lazy-preferred"); got != tc.wantPreferred {
+				t.Errorf("lazy-preferred = %v, want %v", got, tc.wantPreferred)
+			}
+			if got := strings.Contains(svg, `stroke-dasharray="4 3" class="loop-path lazy-secondary"`); got != tc.wantSecondary {
+				t.Errorf("dashed loop = %v, want %v", got, tc.wantSecondary)
+			}
+			if tc.wantPreferred && !strings.Contains(svg, `stroke-width="3" class="skip-path lazy-preferred"`) {
+				t.Error("preferred skip path should be double the connector width")
+			}
+		})
+	}
+}
+
+// TestRenderRepeatAroundAlternation checks that the skip and loop of a
+// repeated alternation run clear of its top and bottom branches, and
+// that a repeated single item keeps the plain curves.
+func TestRenderRepeatAroundAlternation(t *testing.T) {
+	tests := []struct {
+		pattern string
+		skip    string
+		loop    string
+	}{
+		{
+			"(a|b|c|d)*",
+			`d="M 0 104 V 20 Q 0 10 10 10 H 103.4 Q 113.4 10 113.4 20 V 104"`,
+			`d="M 113.4 104 V 175 Q 113.4 185 103.4 185 H 10 Q 0 185 0 175 V 104"`,
+		},
+		{
+			"a*",
+			`d="M 0 31.5 Q 0 21.5 10 21.5 H 43.4 Q 53.4 21.5 53.4 31.5"`,
+			`d="M 53.4 31.5 Q 53.4 53 43.4 53 H 10 Q 0 53 0 31.5"`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.pattern, func(t *testing.T) {
+			ast, err := parser.ParseRegex(tc.pattern)
+			if err != nil {
+				t.Fatalf("parse error: %v", err)
+			}
+			svg := New(DefaultConfig()).Render(ast)
+			if !strings.Contains(svg, tc.skip) {
+				t.Errorf("skip path is not %s", tc.skip)
+			}
+			if !strings.Contains(svg, tc.loop) {
+				t.Errorf("loop path is not %s", tc.loop)
+			}
+		})
+	}
+}
+
+// TestMeasureText checks that text is measured by the cells its glyphs
+// fill in a monospace font, not by its UTF-8 length.
+func TestMeasureText(t *testing.T) {
+	cfg := DefaultConfig()
+	tests := []struct {
+		text  string
+		cells int
+	}{
+		{"abc", 3},
+		{"café", 4},
+		{"cafe\u0301", 4}, // e and a combining acute
+		{"日本", 4},
+		{"👍", 2},
+		{"👨\u200d👩\u200d👧", 2}, // one family emoji
+		{"a\tb", 3},
+	}
+	for _, tc := range tests {
+		if got, want := MeasureText(tc.text, cfg), float64(tc.cells)*cfg.CharWidth; got != want {
+			t.Errorf("MeasureText(%q) = %g, want %g", tc.text, got, want)
+		}
+		if got, want := MeasureLabelText(tc.text, cfg), float64(tc.cells)*cfg.LabelCharWidth; got != want {
+			t.Errorf("MeasureLabelText(%q) = %g, want %g", tc.text, got, want)
+		}
+	}
+}
+
+// TestMeasureTextEmbeddedFont checks that content text is measured by
+// the advances of an embedded font's glyphs, which in a proportional
+// font differ from one glyph to the next.
+func TestMeasureTextEmbeddedFont(t *testing.T) {
+	cfg := DefaultConfig()
+	if err := cfg.EmbedFont(goregular.TTF); err != nil {
+		t.Fatal(err)
+	}
+	narrow, wide := MeasureText("iiii", cfg), MeasureText("MMMM", cfg)
+	if narrow >= wide {
+		t.Errorf("MeasureText(iiii) = %g, not less than MeasureText(MMMM) = %g", narrow, wide)
+	}
+	f, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adv, ok := glyphAdvance(f, &sfnt.Buffer{}, 'M')
+	if !ok {
+		t.Fatal("Go Regular has no M")
+	}
+	if want := 4 * adv * cfg.FontSize; math.Abs(wide-want) > 1e-9 {
+		t.Errorf("MeasureText(MMMM) = %g, want %g", wide, want)
+	}
+	// Go Regular has no CJK glyphs, so they keep two cells each.
+	if got, want := MeasureText("日本", cfg), 4*cfg.CharWidth; got != want {
+		t.Errorf("MeasureText(日本) = %g, want %g", got, want)
+	}
+}
+
+// TestRenderTextLengthAndFont checks that TextLength pins content
+// text, not labels, to its measured width, and that an embedded font
+// is declared and named first.
+func TestRenderTextLengthAndFont(t *testing.T) {
+	ast, err := parser.ParseRegex(`^ab[xy]\d`)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	cfg := DefaultConfig()
+	cfg.TextLength = true
+	if err := cfg.EmbedFont([]byte("wOF2 font data")); err != nil {
+		t.Fatal(err)
+	}
+	svg := New(cfg).Render(ast)
+	for _, want := range []string{
+		`textLength="` + fmtFloat(MeasureText(`"ab"`, cfg)) + `" lengthAdjust="spacingAndGlyphs"`,
+		`textLength="` + fmtFloat(MeasureText(`"x"`, cfg)) + `"`,
+		`@font-face { font-family: regolith-embedded; src: url("data:font/woff2;base64,d09GMiBmb250IGRhdGE="); }`,
+		`font-family="regolith-embedded, monospace"`,
+	} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("SVG missing %s", want)
+		}
+	}
+	if n := strings.Count(svg, "textLength="); n != 4 {
+		t.Errorf("got %d textLength attributes, want 4 (literal, two class items, escape)", n)
+	}
+
+	if err := cfg.EmbedFont([]byte("wOF2")); err != nil || strings.Count(cfg.FontFamily, "regolith-embedded") != 1 {
+		t.Errorf("embedding again should keep one family entry, got %q (%v)", cfg.FontFamily, err)
+	}
+	if err := cfg.EmbedFont([]byte("<svg>")); err == nil {
+		t.Error("EmbedFont accepted a file that is not a font")
+	}
+}
+
+// TestRenderRepeatLabelFits checks that a repeat is wide enough for its
+// label, so that the label of a nested quantifier stays inside the loop
+// of the one around it.
+func TestRenderRepeatLabelFits(t *testing.T) {
+	ast, err := parser.ParseRegex(`(?:a{2,3}){2,4}`)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	r := New(DefaultConfig())
+	outer := ast.Matches[0].Fragments[0]
+	inner := outer.Content.(*parser.Subexp).Regexp.Matches[0].Fragments[0]
+
+	innerBox := r.renderMatchFragment(inner).BBox
+	if want := MeasureLabelText("2 to 3 times", r.Config); innerBox.Width < want {
+		t.Errorf("inner repeat is %g wide, narrower than its label (%g)", innerBox.Width, want)
+	}
+	outerBox := r.renderMatchFragment(outer).BBox
+	if outerBox.Width < innerBox.Width+2*r.Config.CurveRadius {
+		t.Errorf("outer repeat (%g wide) does not clear the inner one (%g wide)", outerBox.Width, innerBox.Width)
+	}
+}
+
+// TestRenderWrapWidth checks that WrapWidth folds a long top-level
+// sequence onto rows that fit, with the end connector on the last row,
+// and leaves a sequence that fits on one row alone.
+func TestRenderWrapWidth(t *testing.T) {
+	ast, err := parser.ParseRegex(`(\d{4})-(\d{2})-(\d{2})T(\d{2}):(\d{2})`)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	cfg := DefaultConfig()
+	cfg.WrapWidth = 300
+	r := New(cfg)
+	rendered, exitY := r.renderTop(ast)
+	if rendered.BBox.Width > cfg.WrapWidth {
+		t.Errorf("wrapped width %g exceeds WrapWidth %g", rendered.BBox.Width, cfg.WrapWidth)
+	}
+	if exitY <= rendered.BBox.AnchorY {
+		t.Errorf("exit %g should be on a row below the entry at %g", exitY, rendered.BBox.AnchorY)
+	}
+	svg := r.Render(ast)
+	if !strings.Contains(svg, `class="wrap-path"`) {
+		t.Error("expected the wrap path between rows")
+	}
+	if flat := New(DefaultConfig()).Render(ast); flat == svg {
+		t.Error("WrapWidth did not change the diagram")
+	}
+
+	short, err := parser.ParseRegex(`ab`)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if got, want := New(cfg).Render(short), New(DefaultConfig()).Render(short); got != want {
+		t.Error("a sequence that fits should be drawn as without WrapWidth")
+	}
+}
+
+// TestRenderLookaroundLayout checks that off-track layouts lift
+// unquantified lookarounds off the track onto a spur, keep runs of
+// them from overlapping, and leave a sequence of nothing else inline.
+func TestRenderLookaroundLayout(t *testing.T) {
+	render := func(t *testing.T, pattern, layout string) (RenderedNode, string) {
+		t.Helper()
+		ast, err := parser.ParseRegex(pattern)
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		cfg := DefaultConfig()
+		cfg.LookaroundLayout = layout
+		return New(cfg).renderRegexp(ast), New(cfg).Render(ast)
+	}
+	spurX := regexp.MustCompile(`M ([\d.]+) [\d.]+ V [-\d.]+ H`)
+
+	inline, svg := render(t, `^(?=.*\d)x$`, "inline")
+	if strings.Contains(svg, "lookaround-spur") {
+		t.Error("inline layout should draw no spurs")
+	}
+	above, svg := render(t, `^(?=.*\d)x$`, "above")
+	if len(spurX.FindAllString(svg, -1)) != 1 {
+		t.Errorf("expected one spur above the track:\n%s", svg)
+	}
+	if above.BBox.Y != 0 || above.BBox.AnchorY <= inline.BBox.AnchorY {
+		t.Errorf("the lane above should push the track down: inline anchor %g, above %+v", inline.BBox.AnchorY, above.BBox)
+	}
+	below, _ := render(t, `^(?=.*\d)x$`, "below")
+	if below.BBox.AnchorY >= inline.BBox.AnchorY || below.BBox.Height <= inline.BBox.Height {
+		t.Errorf("the lane below should sit under the track: inline %+v, below %+v", inline.BBox, below.BBox)
+	}
+
+	// Runs at neighboring points must not overlap.
+	first, _ := render(t, `(?=aaaaaaaaaaaa)`, "inline")
+	_, svg = render(t, `(?=aaaaaaaaaaaa)b(?=cccc)`, "above")
+	m := spurX.FindAllStringSubmatch(svg, -1)
+	if len(m) != 2 {
+		t.Fatalf("expected two spurs, got %d:\n%s", len(m), svg)
+	}
+	x1, _ := strconv.ParseFloat(m[0][1], 64)
+	x2, _ := strconv.ParseFloat(m[1][1], 64)
+	if min := x1 + first.BBox.Width + 2*DefaultConfig().HorizontalGap; x2 < min {
+		t.Errorf("second spur at %g overlaps the first run, want at least %g", x2, min)
+	}
+
+	// With nothing left to draw on the track, lookarounds stay on it.
+	if _, svg := render(t, `(?=a)(?!b)`, "above"); strings.Contains(svg, "lookaround-spur") {
+		t.Error("a sequence of only lookarounds should stay inline")
+	}
+}
+
 func TestRenderCaptureGroup(t *testing.T) {
 	ast, err := parser.ParseRegex("(abc)")
 	if err != nil {
@@ -378,6 +685,7 @@ func TestRenderNewFlags(t *testing.T) {
 			}
 
 			r := New(nil)
+			r.Flavor = &javascript.JavaScript{}
 			svg := r.Render(ast)
 
 			if !strings.Contains(svg, tc.label) {
@@ -475,6 +783,7 @@ func TestRenderFlags(t *testing.T) {
 	ast.Flags = "gi"
 
 	r := New(nil)
+	r.Flavor = &javascript.JavaScript{}
 	svg := r.Render(ast)
 
 	if !strings.Contains(svg, `class="flags"`) {
@@ -500,6 +809,7 @@ func TestRenderAllFlags(t *testing.T) {
 	ast.Flags = "gimuy"
 
 	r := New(nil)
+	r.Flavor = &javascript.JavaScript{}
 	svg := r.Render(ast)
 
 	expectedFlags := []string{"global", "ignore case", "multiline", "unicode", "sticky"}
@@ -510,6 +820,40 @@ func TestRenderAllFlags(t *testing.T) {
 	}
 }
 
+func TestRenderFlagsByFlavor(t *testing.T) {
+	ast, err := parser.ParseRegex("test")
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	ast.Flags = "ux"
+
+	r := New(nil)
+	r.Flavor = &pcre.PCRE{}
+	svg := r.Render(ast)
+	for _, want := range []string{">UTF-8</text>", ">extended</text>", "<title>Ignore whitespace and allow comments</title>",
+		`<a href="https://www.php.net/manual/en/reference.pcre.pattern.modifiers.php">`} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("PCRE flags panel missing %q", want)
+		}
+	}
+	if strings.Contains(svg, ">unicode<") {
+		t.Error("PCRE u must not be labeled with JavaScript's name")
+	}
+
+	ast.Flags = "y"
+	r = New(nil)
+	r.Flavor = &javascript.JavaScript{}
+	r.Language = "de_AT.UTF-8"
+	if svg := r.Render(ast); !strings.Contains(svg, ">haftend</text>") {
+		t.Error("expected the German label for y")
+	}
+
+	r = New(nil)
+	if svg := r.Render(ast); !strings.Contains(svg, ">y</text>") {
+		t.Error("expected the bare letter without a flavor")
+	}
+}
+
 func TestCustomConfig(t *testing.T) {
 	ast, err := parser.ParseRegex("abc")
 	if err != nil {
@@ -579,3 +923,579 @@ func TestComplexPattern(t *testing.T) {
 		t.Error("expected valid SVG output")
 	}
 }
+
+// TestRenderSourceLine checks the --show-source strip: one tspan per
+// token, classed by kind, laid out on the monospace column grid, and
+// absent entirely when Source is unset.
+func TestRenderSourceLine(t *testing.T) {
+	ast, err := parser.ParseRegex("a+")
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	cfg := DefaultConfig()
+
+	plain := New(cfg).Render(ast)
+	if strings.Contains(plain, `class="source"`) {
+		t.Error("source line rendered without Source tokens")
+	}
+
+	r := New(cfg)
+	r.Source = []flavor.Token{
+		{Kind: flavor.TokenLiteral, Text: "a", Offset: 0},
+		{Kind: flavor.TokenQuantifier, Text: "+", Offset: 1},
+	}
+	svg := r.Render(ast)
+	for _, want := range []string{
+		`class="source"`,
+		`class="src-literal"`,
+		`class="src-quantifier"`,
+		`x="` + fmtFloat(cfg.CharWidth) + `"`,
+	} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("expected %s in source line output", want)
+		}
+	}
+}
+
+// TestRenderSourceWideGlyphs checks that the source line places each
+// token after the cells the glyphs before it fill, not after their rune
+// count, and marks every token's span.
+func TestRenderSourceWideGlyphs(t *testing.T) {
+	root, err := parser.ParseRegex("x")
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	cfg := DefaultConfig()
+	r := New(cfg)
+	r.Source = []flavor.Token{
+		{Kind: flavor.TokenLiteral, Text: "日e\u0301", Offset: 0},
+		{Kind: flavor.TokenQuantifier, Text: "+", Offset: 6},
+	}
+	svg := r.Render(root)
+	plus := `<tspan x="` + fmtFloat(3*cfg.CharWidth) + `"`
+	if !regexp.MustCompile(regexp.QuoteMeta(plus) + `[^>]*>\+</tspan>`).MatchString(svg) {
+		t.Errorf("expected + at x=%s, three cells in:\n%s", fmtFloat(3*cfg.CharWidth), svg)
+	}
+	markers := regexp.MustCompile(`(?s)<g class="source-markers">(.*?)</g>`).FindStringSubmatch(svg)
+	if markers == nil {
+		t.Fatal("expected source markers")
+	}
+	if n := strings.Count(markers[1], "<path"); n != 2 {
+		t.Errorf("got %d source markers, want one per token", n)
+	}
+	if end := fmtFloat(3*cfg.CharWidth - 1); !strings.Contains(markers[1], end) {
+		t.Errorf("first marker does not end at %s: %s", end, markers[1])
+	}
+}
+
+func TestRenderSourceRuler(t *testing.T) {
+	ast, err := parser.ParseRegex("abcdefghijkl")
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	source := []flavor.Token{{Kind: flavor.TokenLiteral, Text: "abcdefghijkl"}}
+
+	r := New(DefaultConfig())
+	r.Source = source
+	if strings.Contains(r.Render(ast), `class="ruler"`) {
+		t.Error("ruler rendered without Ruler set")
+	}
+
+	r.Ruler = true
+	svg := r.Render(ast)
+	if !strings.Contains(svg, `class="ruler"`) {
+		t.Fatal("expected ruler group")
+	}
+	// 12 columns: labels at columns 1 and 10 only.
+	if !strings.Contains(svg, `text-anchor="middle">1</text>`) ||
+		!strings.Contains(svg, `text-anchor="middle">10</text>`) {
+		t.Error("expected ruler labels at 1 and 10")
+	}
+	if strings.Contains(svg, `>0</text>`) || strings.Contains(svg, `>20</text>`) {
+		t.Error("unexpected ruler label outside columns 1 to 12")
+	}
+}
+
+// TestRenderRulerErrorColumn renders a pattern with wide and multibyte
+// characters before a parse error, and checks that the ruler label for
+// the error's column sits under the character the error points at.
+func TestRenderRulerErrorColumn(t *testing.T) {
+	const pattern = `日本語ééééé` + `a\{`
+	f, ok := flavor.Get("gnused")
+	if !ok {
+		t.Fatal("gnused not registered")
+	}
+	_, err := f.Parse(pattern)
+	var perr *flavor.ParseError
+	if !errors.As(err, &perr) || perr.Col != 10 {
+		t.Fatalf("Parse(%q) = %v, want a ParseError at column 10", pattern, err)
+	}
+
+	cfg := DefaultConfig()
+	r := New(cfg)
+	r.Source = flavor.Tokens(f, pattern)
+	r.Ruler = true
+	root, _ := parser.ParseRegex("x")
+	svg := r.Render(root)
+
+	// The character at the error is the backslash: three wide
+	// ideographs and six narrow characters come before it.
+	span := regexp.MustCompile(`<tspan x="([0-9.]+)"[^>]*>\\\{</tspan>`).FindStringSubmatch(svg)
+	if span == nil {
+		t.Fatalf("no span for \\{ in:\n%s", svg)
+	}
+	if want := fmtFloat(12 * cfg.CharWidth); span[1] != want {
+		t.Errorf("\\{ drawn at x=%s, want %s", span[1], want)
+	}
+	label := regexp.MustCompile(`<text x="([0-9.]+)"[^>]*>` + strconv.Itoa(perr.Col) + `</text>`).FindStringSubmatch(svg)
+	if label == nil {
+		t.Fatalf("no ruler label for column %d", perr.Col)
+	}
+	if want := fmtFloat(12*cfg.CharWidth + cfg.CharWidth/2); label[1] != want {
+		t.Errorf("label %d at x=%s, want %s, the center of the backslash", perr.Col, label[1], want)
+	}
+}
+
+// TestRenderFlattenedAlternation covers --flatten: lifted branches are
+// drawn as siblings of the outer alternation, each wrapped in a
+// "flattened" group whose <title> records where it came from.
+// Capturing and quantified groups must be left as written.
+func TestRenderFlattenedAlternation(t *testing.T) {
+	tests := []struct {
+		pattern      string
+		wantBranches int
+		wantTitles   []string
+	}{
+		{"a|(?:b|c)", 3, []string{
+			"<title>branch 1 of 2 in a flattened (?:…) group</title>",
+			"<title>branch 2 of 2 in a flattened (?:…) group</title>",
+		}},
+		{"a|(?:b|(?:c|d))", 4, []string{
+			"<title>branch 1 of 2 in a flattened (?:…) group, itself branch 2 of 2 in a flattened (?:…) group</title>",
+		}},
+		{"a|(b|c)", 2, nil},
+		{"a|(?:b|c)+", 2, nil},
+		{"a|x(?:b|c)", 2, nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.pattern, func(t *testing.T) {
+			root, err := parser.ParseRegex(tc.pattern)
+			if err != nil {
+				t.Fatalf("parse error: %v", err)
+			}
+			ast.FlattenAlternations(root)
+			if got := len(root.Matches); got != tc.wantBranches {
+				t.Fatalf("branches = %d, want %d", got, tc.wantBranches)
+			}
+			svg := New(DefaultConfig()).Render(root)
+			if got := strings.Contains(svg, `class="flattened"`); got != (tc.wantTitles != nil) {
+				t.Errorf("flattened group present = %v, want %v", got, tc.wantTitles != nil)
+			}
+			for _, want := range tc.wantTitles {
+				if !strings.Contains(svg, want) {
+					t.Errorf("SVG missing %s", want)
+				}
+			}
+		})
+	}
+}
+
+// TestRenderExpandedSubroutines covers --expand-subroutines: a call to
+// a group is drawn as a "call to group" box holding a copy of it, and a
+// call that is recursion stays a reference.
+func TestRenderExpandedSubroutines(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		depth     int
+		wantCalls int
+		wantRefs  int
+	}{
+		{`(?(DEFINE)(?<oct>\d+))(?&oct)\.(?&oct)`, 3, 2, 0},
+		{`(a)(b)(?-2)(?+1)(c)`, 3, 2, 0},
+		{`(a(?1)?b)(?1)`, 3, 1, 2},
+		{`(?<x>a)(?<y>(?&x))(?&y)`, 3, 3, 0},
+		{`(?<x>a)(?<y>(?&x))(?&y)`, 1, 2, 1},
+		{`a(?R)?`, 3, 0, 1},
+		{`(a)(?9)`, 3, 0, 1},
+	}
+	for _, tc := range tests {
+		t.Run(tc.pattern, func(t *testing.T) {
+			f, _ := flavor.Get("pcre")
+			root, err := f.Parse(tc.pattern)
+			if err != nil {
+				t.Fatalf("parse error: %v", err)
+			}
+			ast.ExpandSubroutines(root, tc.depth)
+			calls, refs := 0, 0
+			ast.Walk(root, func(n ast.Node) {
+				switch n := n.(type) {
+				case *ast.Subexp:
+					if n.GroupType == ast.GroupSubroutine {
+						calls++
+					}
+				case *ast.RecursiveRef:
+					refs++
+				}
+			})
+			if calls != tc.wantCalls || refs != tc.wantRefs {
+				t.Errorf("got %d expanded calls and %d references, want %d and %d", calls, refs, tc.wantCalls, tc.wantRefs)
+			}
+			if tc.wantCalls > 0 && !strings.Contains(New(DefaultConfig()).Render(root), "call to group #") {
+				t.Error("expected a call to group label in the SVG")
+			}
+		})
+	}
+}
+
+func TestRenderRecursionLinks(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		wantLinks int
+	}{
+		{`\((?:[^()]|(?R))*\)`, 1},
+		{`(a(?1)?b)`, 1},
+		{`(?<a>x(?&b)?)(?<b>y(?&a)?)`, 2},
+		{`(a(?-1)?b)(?1)`, 1},
+		{`(a)(?1)`, 0},
+		{`(?<x>a)(?<y>(?&x))(?&y)`, 0},
+	}
+	for _, tc := range tests {
+		t.Run(tc.pattern, func(t *testing.T) {
+			f, _ := flavor.Get("pcre")
+			root, err := f.Parse(tc.pattern)
+			if err != nil {
+				t.Fatalf("parse error: %v", err)
+			}
+			if n := len(ast.Recursion(root)); n != tc.wantLinks {
+				t.Errorf("got %d recursive calls, want %d", n, tc.wantLinks)
+			}
+			svg := New(DefaultConfig()).Render(root)
+			if n := strings.Count(svg, `class="recursion-link"`); n != tc.wantLinks {
+				t.Errorf("got %d recursion links, want %d", n, tc.wantLinks)
+			}
+		})
+	}
+}
+
+func TestRenderPages(t *testing.T) {
+	root, err := parser.ParseRegex(`(\d{4})-(\d{2})-(\d{2})T(\d{2}):(\d{2}):(\d{2})`)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	r := New(DefaultConfig())
+
+	if got := r.RenderPages(root, 0); len(got) != 1 || got[0] != r.Render(root) {
+		t.Error("RenderPages with no width limit should match Render")
+	}
+	if got := r.RenderPages(root, 100000); len(got) != 1 || got[0] != r.Render(root) {
+		t.Error("RenderPages for a pattern that fits should match Render")
+	}
+
+	pages := r.RenderPages(root, 400)
+	if len(pages) < 2 {
+		t.Fatalf("got %d pages, want several", len(pages))
+	}
+	for i, page := range pages {
+		n := i + 1
+		wantFrom := n > 1
+		wantOn := n < len(pages)
+		if got := strings.Contains(page, "continued from page "+strconv.Itoa(n-1)); got != wantFrom {
+			t.Errorf("page %d: continued-from marker present = %v, want %v", n, got, wantFrom)
+		}
+		if got := strings.Contains(page, "continued on page "+strconv.Itoa(n+1)); got != wantOn {
+			t.Errorf("page %d: continued-on marker present = %v, want %v", n, got, wantOn)
+		}
+		if got := strings.Contains(page, "url(#end-dot)"); got == wantOn {
+			t.Errorf("page %d: end dot present = %v, want %v", n, got, !wantOn)
+		}
+	}
+	// Every capture group lands on exactly one page.
+	joined := strings.Join(pages, "")
+	for g := 1; g <= 6; g++ {
+		if c := strings.Count(joined, "group #"+strconv.Itoa(g)+"<"); c != 1 {
+			t.Errorf("group #%d drawn %d times across pages, want 1", g, c)
+		}
+	}
+}
+
+func TestRenderStack(t *testing.T) {
+	var entries []StackEntry
+	for _, pattern := range []string{`(\d+)-(\w+)`, `(a)|b`} {
+		root, err := parser.ParseRegex(pattern)
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		entries = append(entries, StackEntry{Title: pattern, Root: root, Pattern: pattern})
+	}
+	r := New(DefaultConfig())
+	r.GroupLegend = true
+	svg := r.RenderStack(entries)
+
+	if n := strings.Count(svg, "<svg"); n != 1 {
+		t.Errorf("got %d svg elements, want 1", n)
+	}
+	if n := strings.Count(svg, `class="stack-entry"`); n != 2 {
+		t.Errorf("got %d stack entries, want 2", n)
+	}
+	for _, want := range []string{
+		`class="pattern-title">(\d+)-(\w+)<`,
+		`class="pattern-title">(a)|b<`,
+		// Each legend links into its own diagram.
+		`id="p1-group-2"`, `href="#p1-group-2"`,
+		`id="p2-group-1"`, `href="#p2-group-1"`,
+	} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+	if strings.Contains(svg, `id="group-`) {
+		t.Error("group ids should carry the pattern's prefix")
+	}
+	// The second diagram starts below the first.
+	first := r.Render(entries[0].Root)
+	var h1, h float64
+	if _, err := fmt.Sscanf(first[strings.Index(first, `height="`):], `height="%g"`, &h1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fmt.Sscanf(svg[strings.Index(svg, `height="`):], `height="%g"`, &h); err != nil {
+		t.Fatal(err)
+	}
+	if h <= h1 {
+		t.Errorf("stack height %g should exceed the first diagram's %g", h, h1)
+	}
+	if r.anchorPrefix != "" || r.groupAnchors != nil {
+		t.Error("RenderStack should reset the group anchors it set")
+	}
+}
+
+func TestRenderTitleAndCaption(t *testing.T) {
+	root, err := parser.ParseRegex(`\d+`)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	cfg := DefaultConfig()
+	cfg.CaptionStyle.Color = "#336699"
+	r := New(cfg)
+	plain := r.Render(root)
+	if strings.Contains(plain, "diagram-title") || strings.Contains(plain, "diagram-caption") {
+		t.Error("a diagram without a title or caption should not style them")
+	}
+
+	r.Title, r.Caption = "Digits", "One or more\nASCII digits"
+	svg := r.Render(root)
+	for _, want := range []string{
+		`class="diagram-title">Digits<`,
+		`class="diagram-caption">One or more<`,
+		`class="diagram-caption">ASCII digits<`,
+		".diagram-title { font-family: " + cfg.LabelFontFamily + "; font-size: 16px; font-weight: bold; fill: #000; }",
+		".diagram-caption { font-family: " + cfg.LabelFontFamily + "; font-size: 12px; fill: #336699; }",
+	} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+	// The title and caption add their lines to the document's height.
+	var h0, h float64
+	if _, err := fmt.Sscanf(plain[strings.Index(plain, `height="`):], `height="%g"`, &h0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fmt.Sscanf(svg[strings.Index(svg, `height="`):], `height="%g"`, &h); err != nil {
+		t.Fatal(err)
+	}
+	if want := h0 + 16*titleLineHeight + 2*12*titleLineHeight; h < want {
+		t.Errorf("height %g with a title and two caption lines, want at least %g", h, want)
+	}
+
+	// A stack gets one title and one caption, not one per pattern.
+	stack := r.RenderStack([]StackEntry{{Title: "a", Root: root}, {Title: "b", Root: root}})
+	if strings.Count(stack, `class="diagram-title"`) != 1 || strings.Count(stack, `class="diagram-caption"`) != 2 {
+		t.Error("expected one title and one two-line caption in a stack")
+	}
+}
+
+func TestRenderSummary(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string
+		notWant []string
+	}{
+		{`^(\d+)-(?:x)[a-z]+$`,
+			[]string{`class="chip"`, ">group #1<", ">non-capturing group<", ">one of 1<"},
+			[]string{"digit", `"a" - "z"`}},
+		{`foo|bar|baz`, []string{">one of 3 alternatives<"}, []string{`"foo"`}},
+		{`(?=x)\w`, []string{">positive lookahead<", "word"}, []string{`"x"`}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.pattern, func(t *testing.T) {
+			root, err := parser.ParseRegex(tc.pattern)
+			if err != nil {
+				t.Fatalf("parse error: %v", err)
+			}
+			r := New(DefaultConfig())
+			r.Summary = true
+			svg := r.Render(root)
+			for _, want := range tc.want {
+				if !strings.Contains(svg, want) {
+					t.Errorf("summary SVG missing %q", want)
+				}
+			}
+			for _, bad := range tc.notWant {
+				if strings.Contains(svg, bad) {
+					t.Errorf("summary SVG should not contain inner detail %q", bad)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderGroupLegend(t *testing.T) {
+	pattern := `(?<year>\d{4})-(?:x)(\w+)(a very long group that gets cut short)`
+	root, err := parser.ParseRegex(pattern)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	r := New(DefaultConfig())
+	r.GroupLegend, r.Pattern = true, pattern
+	svg := r.Render(root)
+	for _, want := range []string{
+		`<g id="group-1" class="subexp">`,
+		`<a href="#group-1"><g class="group-legend-entry">`,
+		`>#1 &#39;year&#39;</text>`,
+		`>(?&lt;year&gt;\d{4})</text>`,
+		`>#2</text>`,
+		`<a href="#group-3">`,
+		`>(a very long group that gets...</text><title>(a very long group that gets cut short)</title>`,
+	} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("legend SVG missing %q", want)
+		}
+	}
+	if strings.Contains(svg, "group-4") {
+		t.Error("the non-capturing group was listed")
+	}
+	if New(DefaultConfig()).Render(root) == svg {
+		t.Error("GroupLegend did not change the diagram")
+	}
+
+	// Groups folded into summary chips have no box to link to.
+	r.Summary = true
+	if svg := r.Render(root); !strings.Contains(svg, `class="group-legend"`) || strings.Contains(svg, `href="#group-`) {
+		t.Error("summary legend should list the groups without links")
+	}
+
+	plain, _ := parser.ParseRegex(`a+b`)
+	if strings.Contains(r.Render(plain), "group-legend") {
+		t.Error("legend drawn for a pattern without capturing groups")
+	}
+}
+
+func TestRenderHooks(t *testing.T) {
+	root, err := parser.ParseRegex(`a(b|\d)`)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	plain := New(DefaultConfig()).Render(root)
+
+	t.Run("geometry and attributes", func(t *testing.T) {
+		var types []string
+		r := New(DefaultConfig())
+		r.PostRender = func(node parser.Node, rn *RenderedNode) {
+			if rn.BBox.Width <= 0 || rn.BBox.Height <= 0 {
+				t.Errorf("%s: empty bounding box %+v", node.Type(), rn.BBox)
+			}
+			types = append(types, node.Type())
+			if node.Type() == "subexp" {
+				rn.Element = &Group{Data: map[string]string{"node": `sub"exp`}, Children: []SVGElement{rn.Element}}
+			}
+		}
+		svg := r.Render(root)
+		if got := strings.Join(types, " "); got != "literal literal escape subexp" {
+			t.Errorf("PostRender saw %q, want a group's contents before the group", got)
+		}
+		if !strings.Contains(svg, `<g data-node="sub&#34;exp">`) {
+			t.Error("SVG missing the data attribute PostRender attached")
+		}
+	})
+
+	t.Run("veto", func(t *testing.T) {
+		r := New(DefaultConfig())
+		r.PreRender = func(node parser.Node, rn *RenderedNode) {
+			if node.Type() == "subexp" {
+				rn.Element = &Group{}
+			}
+		}
+		var visited []string
+		r.PostRender = func(node parser.Node, rn *RenderedNode) {
+			visited = append(visited, node.Type())
+		}
+		svg := r.Render(root)
+		if strings.Contains(svg, "group #1") || strings.Contains(svg, "digit") {
+			t.Error("vetoed group was still drawn")
+		}
+		if !strings.Contains(svg, `class="literal"`) {
+			t.Error("literal outside the vetoed group is missing")
+		}
+		for _, typ := range visited {
+			if typ == "escape" {
+				t.Error("children of a vetoed node were visited")
+			}
+		}
+		if svg == plain {
+			t.Error("veto did not change the diagram")
+		}
+	})
+}
+
+func TestRenderCompact(t *testing.T) {
+	root, err := (&pcre.PCRE{}).Parse(`ab\.c(x|y)++`)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	plain := New(DefaultConfig()).Render(root)
+	cfg := DefaultConfig()
+	cfg.Compact()
+	compact := New(cfg).Render(root)
+
+	if !strings.Contains(plain, ">possessive<") {
+		t.Fatal("default render should label the possessive loop")
+	}
+	if strings.Contains(compact, ">possessive<") {
+		t.Error("compact render should drop the label on a trivial quantifier")
+	}
+	if got := strings.Count(compact, `class="literal"`); got != 3 {
+		t.Errorf("compact render should merge ab, ., and c into one literal box beside x and y; got %d literal boxes", got)
+	}
+	if !strings.Contains(compact, "<tspan>ab.c</tspan>") {
+		t.Error("merged literal box missing")
+	}
+
+	counted, err := parser.ParseRegex(`a{2,5}`)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if !strings.Contains(New(cfg).Render(counted), "2 to 5 times") {
+		t.Error("compact render should keep labels on counted repeats")
+	}
+}
+
+func TestRenderNumberAlternatives(t *testing.T) {
+	root, err := parser.ParseRegex(`foo|(x|y|z)`)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if strings.Contains(New(DefaultConfig()).Render(root), "branch-number") {
+		t.Fatal("default render should not number branches")
+	}
+	cfg := DefaultConfig()
+	cfg.NumberAlternatives = true
+	svg := New(cfg).Render(root)
+	if got := strings.Count(svg, `class="branch-number"`); got != 5 {
+		t.Errorf("expected 5 numbered branches, got %d", got)
+	}
+	// Numbering restarts in the nested alternation.
+	for _, label := range []string{">1.<", ">2.<", ">3.<"} {
+		if !strings.Contains(svg, label) {
+			t.Errorf("missing branch label %s", label)
+		}
+	}
+}
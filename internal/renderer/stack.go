@@ -0,0 +1,79 @@
+package renderer
+
+import (
+	"strconv"
+
+	parser "github.com/0x4d5352/regolith/internal/ast"
+	"github.com/0x4d5352/regolith/internal/flavor"
+)
+
+// ================================================================================
+// Stacked Patterns
+// ================================================================================
+
+// StackEntry is one pattern in a RenderStack document.
+type StackEntry struct {
+	Title   string // Drawn over the diagram
+	Root    *parser.Regexp
+	Source  []flavor.Token // Used as Renderer.Source for this pattern
+	Pattern string         // Used as Renderer.Pattern for this pattern
+}
+
+// RenderStack renders several patterns into one SVG document, each
+// diagram under its title and the next one below it, left-aligned.
+// Every diagram gets the panels Render would give it, from its own
+// entry's Source and Pattern; the renderer's own are left as they
+// were. Group ids are prefixed with the pattern's position ("p2-") so
+// each group legend links into its own diagram. Title and Caption go
+// above the first diagram and below the last.
+func (r *Renderer) RenderStack(entries []StackEntry) string {
+	source, pattern := r.Source, r.Pattern
+	defer func() {
+		r.Source, r.Pattern = source, pattern
+		r.groupAnchors, r.anchorPrefix = nil, ""
+	}()
+
+	cfg := r.Config
+	padding := cfg.Padding
+	var children []SVGElement
+	width, y := 0.0, padding/2
+	if r.Title != "" {
+		title := r.renderTitle()
+		children = append(children, translated(title.Element, padding, y))
+		width = title.BBox.Width + 2*padding
+		y += title.BBox.Height + padding/2
+	}
+	for i, e := range entries {
+		r.Source, r.Pattern = e.Source, e.Pattern
+		r.anchorPrefix = "p" + strconv.Itoa(i+1) + "-"
+		if r.GroupLegend {
+			r.groupAnchors = map[int]bool{}
+		}
+		rendered, exitY := r.renderRoot(e.Root)
+		body := r.place(r.frame(e.Root, rendered, exitY))
+
+		titleHeight := cfg.FontSize + padding/2
+		entry := &Group{Class: "stack-entry", Children: []SVGElement{
+			&Text{
+				X:          padding,
+				Y:          cfg.FontSize,
+				Content:    e.Title,
+				FontFamily: cfg.FontFamily,
+				FontSize:   cfg.FontSize,
+				Fill:       cfg.TextColor,
+				Class:      "pattern-title",
+			},
+			translated(body.Element, 0, titleHeight),
+		}}
+		children = append(children, translated(entry, 0, y))
+		width = max(width, body.BBox.Width, MeasureText(e.Title, cfg)+2*padding)
+		y += titleHeight + body.BBox.Height
+	}
+	if r.Caption != "" {
+		caption := r.renderCaption()
+		children = append(children, translated(caption.Element, padding, y))
+		width = max(width, caption.BBox.Width+2*padding)
+		y += caption.BBox.Height + padding
+	}
+	return r.document(children, width, y, "")
+}
@@ -6,10 +6,10 @@ package renderer
 // Config.CornerRadius. This keeps the theming contract narrow —
 // replacing a theme is a matter of replacing the NodeStyles map.
 type NodeStyle struct {
-	Fill         string
-	Stroke       string
-	TextColor    string
-	CornerRadius float64 // 0 = inherit Config.CornerRadius
+	Fill         string  `json:"fill"`
+	Stroke       string  `json:"stroke"`
+	TextColor    string  `json:"textColor"`
+	CornerRadius float64 `json:"cornerRadius"` // 0 = inherit Config.CornerRadius
 }
 
 // ConnectorStyle groups the look of the "railroad track" (connector
@@ -17,10 +17,28 @@ type NodeStyle struct {
 // Keeping these in their own struct means a theme can tune the
 // trackwork independently of the nodes.
 type ConnectorStyle struct {
-	Color       string
-	StrokeWidth float64
-	StartMarker string // "arrow" | "none"
-	EndMarker   string // "dot" | "none"
+	Color       string  `json:"color"`
+	StrokeWidth float64 `json:"strokeWidth"`
+	StartMarker string  `json:"startMarker"` // "arrow" | "none"
+	EndMarker   string  `json:"endMarker"`   // "dot" | "none"
+	// LazyLayout picks how lazy quantifiers are told apart from greedy
+	// ones. "arrow" (the default, also used when empty) only flips the
+	// direction arrow on the loop. "skip-first" also draws the exit
+	// path as the primary track (double width) and the loop as a
+	// dashed secondary one, showing that the engine tries to stop
+	// before it tries another iteration.
+	LazyLayout string `json:"lazyLayout"` // "arrow" | "skip-first"
+}
+
+// TextStyle sets the look of a line of free text, such as the title
+// and caption (Renderer.Title and Renderer.Caption). An empty
+// FontFamily falls back to Config.LabelFontFamily, an empty Color to
+// Config.TextColor.
+type TextStyle struct {
+	FontFamily string  `json:"fontFamily"`
+	FontSize   float64 `json:"fontSize"`
+	FontWeight string  `json:"fontWeight"` // CSS font-weight: "normal", "bold", "600"...
+	Color      string  `json:"color"`
 }
 
 // Config holds all styling and dimension configuration
@@ -28,27 +46,86 @@ type Config struct {
 	// ================================================================
 	// Dimensions
 	// ================================================================
-	Padding       float64
-	HorizontalGap float64
-	VerticalGap   float64
-	CornerRadius  float64
+	Padding       float64 `json:"padding"`
+	HorizontalGap float64 `json:"horizontalGap"`
+	VerticalGap   float64 `json:"verticalGap"`
+	CornerRadius  float64 `json:"cornerRadius"`
+	// CurveRadius rounds the bends where alternation branches fan out
+	// and rejoin and where repeat loops and skips turn. ConnectorWidth
+	// is the run an alternation spends on each side fanning out, so it
+	// should be at least CurveRadius.
+	CurveRadius    float64 `json:"curveRadius"`
+	ConnectorWidth float64 `json:"connectorWidth"`
+	// MergeLiterals draws a run of adjacent unquantified literals, such
+	// as the escaped \. in abc\.d, as a single box. TerseRepeatLabels
+	// drops the label under quantifiers whose loop shape already says
+	// everything — *, +, ? and their possessive forms — leaving labels
+	// only for counted repeats like {2,5}. Both save space in compact
+	// diagrams (see Compact).
+	MergeLiterals     bool `json:"mergeLiterals"`
+	TerseRepeatLabels bool `json:"terseRepeatLabels"`
+	// LookaroundLayout places lookaround groups, which match no text.
+	// "inline" (the default, also used when empty) draws them on the
+	// track like any group. "above" and "below" lift them off it onto a
+	// dashed spur from the point they test, so the text the pattern
+	// consumes reads as one continuous track; password-policy patterns
+	// that open with several (?=.*x) checks benefit most. Quantified
+	// lookarounds stay inline.
+	LookaroundLayout string `json:"lookaroundLayout"` // "inline" | "above" | "below"
+	// NumberAlternatives labels each alternation branch with its
+	// number ("1.", "2.", ...), counting from 1 in every alternation as
+	// the text walk's "Branch N" does, so a review can refer to one
+	// branch of a long alternation.
+	NumberAlternatives bool `json:"numberAlternatives"`
+	// MaxWidth and MaxHeight, when positive, cap the size the SVG asks
+	// to be shown at. A larger diagram keeps its layout and viewBox and
+	// is scaled down as a whole, text included, by whichever limit needs
+	// more, so it fits a fixed-size slot without being distorted.
+	MaxWidth  float64 `json:"maxWidth"`
+	MaxHeight float64 `json:"maxHeight"`
+	// WrapWidth, when positive, folds a long top-level sequence onto
+	// rows about this many pixels wide, joined by a track that runs
+	// from the end of each row back to the start of the next, so a long
+	// pattern fits a slide without being scaled down. Only the top
+	// level wraps, between its items, and not with an off-track
+	// LookaroundLayout.
+	WrapWidth float64 `json:"wrapWidth"`
 
 	// ================================================================
 	// Typography
 	// ================================================================
 	// Regex-content text (literals, escape labels, charset items) uses
 	// the monospace family — it is code, and should read as code.
-	FontFamily string
-	FontSize   float64
-	CharWidth  float64 // Approximate character width for content text
+	FontFamily string  `json:"fontFamily"`
+	FontSize   float64 `json:"fontSize"`
+	CharWidth  float64 `json:"charWidth"` // Width of one monospace cell of content text
 
 	// Structural labels (anchor descriptions, "one of" headers, repeat
 	// labels, group names) use a sans-serif family. The contrast with
 	// the monospace content creates a visual hierarchy between "what
 	// the regex says" and "what regolith says about it".
-	LabelFontFamily string
-	LabelFontSize   float64
-	LabelCharWidth  float64
+	LabelFontFamily string  `json:"labelFontFamily"`
+	LabelFontSize   float64 `json:"labelFontSize"`
+	LabelCharWidth  float64 `json:"labelCharWidth"`
+
+	// TitleStyle and CaptionStyle set the heading drawn above the
+	// diagram and the note drawn below it. Both are measured on the
+	// LabelCharWidth grid, scaled by their size.
+	TitleStyle   TextStyle `json:"titleStyle"`
+	CaptionStyle TextStyle `json:"captionStyle"`
+
+	// Content text is measured with Go Mono's advances scaled to
+	// CharWidth, but a viewer draws it in whatever monospace font it
+	// has, which may be wider. Two settings keep the boxes fitted.
+	// TextLength pins each content text to its measured width with the
+	// SVG textLength attribute, so the glyphs are stretched or squeezed
+	// to fit rather than overflowing. FontFace, set by EmbedFont, is a
+	// data URL of a font file that the style declares with @font-face
+	// and FontFamily names first, so every viewer draws the same
+	// glyphs, and content text is then measured by that font's own
+	// advances.
+	TextLength bool   `json:"textLength"`
+	FontFace   string `json:"fontFace,omitempty"`
 
 	// ================================================================
 	// Global stroke / background
@@ -60,14 +137,14 @@ type Config struct {
 	// means a theme can suggest a background color without forcing every
 	// rendered SVG (including historical golden files) to suddenly grow
 	// an opaque backdrop.
-	BackgroundColor string
+	BackgroundColor string `json:"backgroundColor"`
 	// BackgroundFill, when non-empty, causes the renderer to inject a
 	// <rect> filling the entire viewBox as the first child of the root
 	// <svg>. Set by the --background-fill CLI flag; themes leave it
 	// alone.
-	BackgroundFill  string
-	TextColor       string  // Fallback for text without a category color
-	NodeStrokeWidth float64 // Default stroke width for node borders
+	BackgroundFill  string  `json:"backgroundFill"`
+	TextColor       string  `json:"textColor"`       // Fallback for text without a category color
+	NodeStrokeWidth float64 `json:"nodeStrokeWidth"` // Default stroke width for node borders
 
 	// ================================================================
 	// Node palette
@@ -77,36 +154,36 @@ type Config struct {
 	// "flags", "recursive-ref", "callout", "backtrack-control",
 	// "conditional", "comment"). A theme feature (see issue #5) will
 	// ship by replacing this map wholesale.
-	NodeStyles map[string]NodeStyle
+	NodeStyles map[string]NodeStyle `json:"nodeStyles"`
 
 	// Subexpression styling is depth-cycled and does not fit the
 	// category-keyed map. It stays as flat fields for now.
-	SubexpFill   string   // Used for outermost subexp (depth 0)
-	SubexpStroke string   // Stroke color for subexp boxes
-	SubexpColors []string // Colors cycled through for nested depths (1+)
+	SubexpFill   string   `json:"subexpFill"`   // Used for outermost subexp (depth 0)
+	SubexpStroke string   `json:"subexpStroke"` // Stroke color for subexp boxes
+	SubexpColors []string `json:"subexpColors"` // Colors cycled through for nested depths (1+)
 
 	// RepeatLabelColor is the color of the "1+ times" style labels
 	// below repeat loops. Defaulted to the connector color so loops
 	// and their labels read as one unit, but kept as its own field so
 	// a theme could override independently.
-	RepeatLabelColor string
+	RepeatLabelColor string `json:"repeatLabelColor"`
 
 	// ================================================================
 	// Connectors
 	// ================================================================
-	Connector ConnectorStyle
+	Connector ConnectorStyle `json:"connector"`
 
 	// ================================================================
 	// Analysis annotation colors (used by annotated SVG output)
 	// ================================================================
 	// These are severity-driven, not category-driven, and stay
 	// unchanged by themes that only swap NodeStyles.
-	ErrorBorderColor   string
-	WarningBorderColor string
-	InfoBorderColor    string
-	ErrorBadgeColor    string
-	WarningBadgeColor  string
-	InfoBadgeColor     string
+	ErrorBorderColor   string `json:"errorBorderColor"`
+	WarningBorderColor string `json:"warningBorderColor"`
+	InfoBorderColor    string `json:"infoBorderColor"`
+	ErrorBadgeColor    string `json:"errorBadgeColor"`
+	WarningBadgeColor  string `json:"warningBadgeColor"`
+	InfoBadgeColor     string `json:"infoBadgeColor"`
 }
 
 // GetNodeStyle returns the style bundle for a node class, falling back
@@ -130,10 +207,14 @@ func DefaultConfig() *Config {
 	return &Config{
 		// Dimensions. Spacing stayed constant across the refresh; only
 		// corner radius changed (3 -> 8) for the rounder silhouette.
-		Padding:       10,
-		HorizontalGap: 10,
-		VerticalGap:   5,
-		CornerRadius:  8,
+		Padding:        10,
+		HorizontalGap:  10,
+		VerticalGap:    5,
+		CornerRadius:   8,
+		CurveRadius:    10,
+		ConnectorWidth: 20,
+
+		LookaroundLayout: "inline",
 
 		// Typography. Content font is a smidge smaller (14 -> 13) to
 		// read closer in weight to the new sans-serif label font.
@@ -150,6 +231,8 @@ func DefaultConfig() *Config {
 		// glyph but capitals, "m", "w", and digits push the
 		// effective average closer to 8 for English prose.
 		LabelCharWidth: 8.0,
+		TitleStyle:     TextStyle{FontSize: 16, FontWeight: "bold"},
+		CaptionStyle:   TextStyle{FontSize: 12},
 
 		// Background / baseline text / node stroke
 		BackgroundColor: "transparent",
@@ -206,6 +289,7 @@ func DefaultConfig() *Config {
 			StrokeWidth: 1.5,
 			StartMarker: "arrow",
 			EndMarker:   "dot",
+			LazyLayout:  "arrow",
 		},
 
 		// Analysis annotation colors — unchanged by the visual refresh.
@@ -219,3 +303,27 @@ func DefaultConfig() *Config {
 		InfoBadgeColor:     "#3182ce",
 	}
 }
+
+// Compact sets c up for diagrams set inline in prose or API reference
+// tables, where vertical space is scarce: the smallest padding, gaps,
+// and radii that still leave text and curves legible, including the
+// per-category corner radius overrides a theme sets, such as the
+// anchor pill, plus MergeLiterals and TerseRepeatLabels. Colors and
+// fonts are left alone. Themes replace NodeStyles wholesale, so apply
+// Compact after a theme.
+func (c *Config) Compact() {
+	c.Padding = 4
+	c.HorizontalGap = 4
+	c.VerticalGap = 2
+	c.CornerRadius = 3
+	c.CurveRadius = 5
+	c.ConnectorWidth = 10
+	c.MergeLiterals = true
+	c.TerseRepeatLabels = true
+	for class, style := range c.NodeStyles {
+		if style.CornerRadius > 0 {
+			style.CornerRadius /= 2
+			c.NodeStyles[class] = style
+		}
+	}
+}
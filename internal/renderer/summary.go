@@ -0,0 +1,87 @@
+package renderer
+
+import (
+	"fmt"
+
+	parser "github.com/0x4d5352/regolith/internal/ast"
+)
+
+// renderSummaryNode returns the chip drawn for node in summary mode,
+// and false for nodes that are drawn as usual. Anything with inner
+// structure collapses to its label: groups and lookarounds, scoped
+// modifiers, conditionals, and character classes, whose member lists
+// are the tallest boxes in most diagrams. Quantifiers still wrap the
+// chip, so (\d+)+ reads as a repeated "group #1".
+func (r *Renderer) renderSummaryNode(node parser.Node) (RenderedNode, bool) {
+	var label string
+	switch n := node.(type) {
+	case *parser.Subexp:
+		label = subexpLabel(n)
+	case *parser.BalancedGroup:
+		label = "balanced group"
+		if n.Name != "" {
+			label = fmt.Sprintf("balanced group '%s'", n.Name)
+		}
+	case *parser.BranchReset:
+		label = "branch reset"
+	case *parser.InlineModifier:
+		if n.Regexp == nil {
+			return RenderedNode{}, false
+		}
+		label = "flags: +" + n.Enable
+		if n.Disable != "" {
+			label += " -" + n.Disable
+		}
+	case *parser.Conditional:
+		label = "conditional"
+	case *parser.Charset:
+		label = fmt.Sprintf("one of %d", len(n.Items))
+		switch {
+		case n.SetExpression != nil:
+			label = "set expression"
+		case n.Inverted:
+			label = fmt.Sprintf("none of %d", len(n.Items))
+		}
+		return r.renderStructuralLabel(label, "charset"), true
+	default:
+		return RenderedNode{}, false
+	}
+	return r.renderChip(label), true
+}
+
+// renderChip draws a pill-shaped group chip in the subexpression
+// colors, so a chip reads as "a group was here" at thumbnail size.
+func (r *Renderer) renderChip(label string) RenderedNode {
+	cfg := r.Config
+	fill := cfg.SubexpFill
+	if len(cfg.SubexpColors) > 0 {
+		fill = cfg.SubexpColors[0]
+	}
+	height := cfg.FontSize + cfg.Padding
+	width := MeasureLabelText(label, cfg) + height
+	return RenderedNode{
+		Element: &Group{
+			Class: "chip",
+			Children: []SVGElement{
+				&Rect{
+					Width:       width,
+					Height:      height,
+					Rx:          height / 2,
+					Ry:          height / 2,
+					Fill:        fill,
+					Stroke:      cfg.SubexpStroke,
+					StrokeWidth: cfg.NodeStrokeWidth,
+				},
+				&Text{
+					X:          width / 2,
+					Y:          height/2 + cfg.LabelFontSize/3,
+					Content:    label,
+					FontFamily: cfg.LabelFontFamily,
+					FontSize:   cfg.LabelFontSize,
+					Anchor:     "middle",
+				},
+			},
+		},
+		BBox: NewBoundingBox(0, 0, width, height),
+	}
+}
@@ -2,6 +2,7 @@ package renderer
 
 import (
 	"html"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -100,15 +101,30 @@ type SVGElement interface {
 
 // Group represents an SVG <g> element
 type Group struct {
+	ID        string // Target of #id links; "" for none
 	Class     string
 	Transform string
-	Children  []SVGElement
+	// Data is written as data-* attributes in key order, so tools
+	// reading the SVG can find elements by what they represent (see
+	// Renderer.PostRender). Values are escaped; keys must be valid
+	// attribute name characters.
+	Data     map[string]string
+	Children []SVGElement
 }
 
 func (g *Group) Render() string {
 	var a svgAttrs
+	a.Str("id", g.ID)
 	a.Str("class", g.Class)
 	a.Str("transform", g.Transform)
+	keys := make([]string, 0, len(g.Data))
+	for k := range g.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		a.StrAlways("data-"+k, html.EscapeString(g.Data[k]))
+	}
 
 	var children strings.Builder
 	for _, child := range g.Children {
@@ -127,6 +143,26 @@ func (g *Group) Render() string {
 	return out.String()
 }
 
+// Link represents an SVG <a> element, making its children a hyperlink.
+type Link struct {
+	Href     string
+	Children []SVGElement
+}
+
+func (l *Link) Render() string {
+	var a svgAttrs
+	a.Str("href", l.Href)
+	var out strings.Builder
+	out.WriteString("<a ")
+	out.WriteString(a.String())
+	out.WriteByte('>')
+	for _, child := range l.Children {
+		out.WriteString(child.Render())
+	}
+	out.WriteString("</a>")
+	return out.String()
+}
+
 // Rect represents an SVG <rect> element
 type Rect struct {
 	X, Y            float64
@@ -186,6 +222,9 @@ type Text struct {
 	Anchor     string // text-anchor: start, middle, end
 	Class      string
 	Spans      []*TSpan // Optional tspan children
+	// TextLength, when positive, makes the viewer stretch or squeeze
+	// the glyphs to exactly this width (lengthAdjust spacingAndGlyphs).
+	TextLength float64
 }
 
 func (t *Text) Render() string {
@@ -197,6 +236,10 @@ func (t *Text) Render() string {
 	a.Str("fill", t.Fill)
 	a.Str("text-anchor", t.Anchor)
 	a.Str("class", t.Class)
+	if t.TextLength > 0 {
+		a.Num("textLength", t.TextLength)
+		a.Str("lengthAdjust", "spacingAndGlyphs")
+	}
 
 	var content string
 	if len(t.Spans) > 0 {
@@ -217,10 +260,12 @@ type TSpan struct {
 	Content string
 	Class   string
 	Fill    string
+	X       float64 // Absolute x position; 0 continues after the previous span
 }
 
 func (ts *TSpan) Render() string {
 	var a svgAttrs
+	a.NumPositive("x", ts.X)
 	a.Str("class", ts.Class)
 	a.Str("fill", ts.Fill)
 
@@ -242,6 +287,7 @@ type Path struct {
 	Fill        string
 	Stroke      string
 	StrokeWidth float64
+	DashArray   string // stroke-dasharray; omitted when empty
 	Class       string
 }
 
@@ -257,6 +303,7 @@ func (p *Path) Render() string {
 	}
 	a.Str("stroke", p.Stroke)
 	a.NumPositive("stroke-width", p.StrokeWidth)
+	a.Str("stroke-dasharray", p.DashArray)
 	a.Str("class", p.Class)
 	return "<path " + a.String() + "/>"
 }
@@ -309,6 +356,9 @@ type SVG struct {
 	Defs     string
 	Style    string
 	Children []SVGElement
+	// Comment, when non-empty, is emitted as an XML comment before
+	// everything else. The caller guarantees it contains no "--".
+	Comment string
 }
 
 func (s *SVG) Render() string {
@@ -319,6 +369,11 @@ func (s *SVG) Render() string {
 	a.Str("viewBox", s.ViewBox)
 
 	var children strings.Builder
+	if s.Comment != "" {
+		children.WriteString("<!-- ")
+		children.WriteString(s.Comment)
+		children.WriteString("-->")
+	}
 	if s.Defs != "" {
 		children.WriteString("<defs>")
 		children.WriteString(s.Defs)
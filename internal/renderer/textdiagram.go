@@ -0,0 +1,497 @@
+package renderer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/0x4d5352/regolith/internal/parser"
+)
+
+// The text diagram is a second layout engine over the same AST: it
+// lays a railroad diagram out on a grid of characters instead of SVG
+// coordinates, for reading a pattern in a terminal. It follows the SVG
+// layout where a grid allows: literals and escapes are boxes on the
+// track, alternatives stack with rails on either side, an optional
+// item has a skip path above it and a repeated one a loop below it
+// carrying the repeat label, and groups are frames labeled on the top
+// edge. Node labels are the ones the SVG diagram draws.
+
+// textGlyphs is the set of characters a text diagram is drawn with.
+// Each junction is named for the directions its arms point.
+type textGlyphs struct {
+	h, v                          rune // track
+	downRight, downLeft           rune // ┌ ┐ box corners
+	upRight, upLeft               rune // └ ┘
+	roundDR, roundDL              rune // ╭ ╮ corners of description boxes
+	roundUR, roundUL              rune // ╰ ╯
+	lrDown, lrUp                  rune // ┬ ┴
+	udRight, udLeft               rune // ├ ┤
+	cross                         rune // ┼
+	greedyArrow, lazyArrow, start rune
+}
+
+var (
+	unicodeGlyphs = textGlyphs{
+		h: '─', v: '│',
+		downRight: '┌', downLeft: '┐', upRight: '└', upLeft: '┘',
+		roundDR: '╭', roundDL: '╮', roundUR: '╰', roundUL: '╯',
+		lrDown: '┬', lrUp: '┴', udRight: '├', udLeft: '┤', cross: '┼',
+		greedyArrow: '◄', lazyArrow: '►', start: '●',
+	}
+	asciiGlyphs = textGlyphs{
+		h: '-', v: '|',
+		downRight: '+', downLeft: '+', upRight: '+', upLeft: '+',
+		roundDR: '+', roundDL: '+', roundUR: '+', roundUL: '+',
+		lrDown: '+', lrUp: '+', udRight: '+', udLeft: '+', cross: '+',
+		greedyArrow: '<', lazyArrow: '>', start: 'o',
+	}
+)
+
+// textBlock is a laid-out piece of a text diagram: a grid of runes and
+// the row the track enters it on the left and leaves it on the right.
+type textBlock struct {
+	rows  [][]rune
+	track int
+}
+
+// newTextBlock returns a blank block of the given size.
+func newTextBlock(width, height, track int) textBlock {
+	rows := make([][]rune, height)
+	for i := range rows {
+		rows[i] = []rune(strings.Repeat(" ", width))
+	}
+	return textBlock{rows: rows, track: track}
+}
+
+func (b textBlock) width() int {
+	if len(b.rows) == 0 {
+		return 0
+	}
+	return len(b.rows[0])
+}
+
+func (b textBlock) height() int { return len(b.rows) }
+
+// put writes s at row y from column x.
+func (b textBlock) put(y, x int, s string) {
+	for _, c := range s {
+		b.rows[y][x] = c
+		x++
+	}
+}
+
+// hline draws track across columns [from, to) of row y.
+func (b textBlock) hline(y, from, to int, g *textGlyphs) {
+	for x := from; x < to; x++ {
+		b.rows[y][x] = g.h
+	}
+}
+
+// vline draws a rail down column x over rows [from, to).
+func (b textBlock) vline(x, from, to int, g *textGlyphs) {
+	for y := from; y < to; y++ {
+		b.rows[y][x] = g.v
+	}
+}
+
+// blit copies src into b with its top-left corner at (y, x).
+func (b textBlock) blit(src textBlock, y, x int) {
+	for i, row := range src.rows {
+		copy(b.rows[y+i][x:], row)
+	}
+}
+
+// String returns the block's rows without trailing spaces.
+func (b textBlock) String() string {
+	var sb strings.Builder
+	for _, row := range b.rows {
+		sb.WriteString(strings.TrimRight(string(row), " "))
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// textDiagram holds the state of one RenderTextDiagram call.
+type textDiagram struct {
+	r *Renderer
+	g *textGlyphs
+}
+
+// RenderTextDiagram lays ast out as a railroad diagram in box-drawing
+// characters, or in plain ASCII (- | +) when ascii is set, for a
+// terminal or a plain-text file. It honours the Config options that
+// change what the diagram says rather than how it looks:
+// NumberAlternatives, TerseRepeatLabels and MergeLiterals, as well as
+// Renderer.Shorthands.
+func (r *Renderer) RenderTextDiagram(ast *parser.Regexp, ascii bool) string {
+	d := &textDiagram{r: r, g: &unicodeGlyphs}
+	if ascii {
+		d.g = &asciiGlyphs
+	}
+	body := d.regexp(ast)
+
+	// A marker and a stretch of track on either side, as in the SVG.
+	w := body.width()
+	out := newTextBlock(w+6, body.height(), body.track)
+	out.blit(body, 0, 3)
+	out.rows[out.track][0] = d.g.start
+	out.hline(out.track, 1, 3, d.g)
+	out.hline(out.track, w+3, w+5, d.g)
+	out.rows[out.track][w+5] = d.g.start
+
+	var sb strings.Builder
+	if len(ast.Options) > 0 {
+		var opts []string
+		for _, o := range ast.Options {
+			opts = append(opts, "(*"+o.Name+")")
+		}
+		sb.WriteString("options: " + strings.Join(opts, " ") + "\n")
+	}
+	sb.WriteString(out.String())
+	if r.hasFlags(ast) {
+		sb.WriteString("flags: " + ast.Flags + "\n")
+	}
+	return sb.String()
+}
+
+// node lays out a single AST node.
+func (d *textDiagram) node(node parser.Node) textBlock {
+	switch n := node.(type) {
+	case *parser.Regexp:
+		return d.regexp(n)
+	case *parser.Match:
+		return d.match(n)
+	case *parser.MatchFragment:
+		return d.fragment(n)
+	case *parser.Literal:
+		return d.box(`"`+n.Text+`"`, false)
+	case *parser.QuotedLiteral:
+		return d.box(`"`+n.Text+`"`, false)
+	case *parser.Escape:
+		return d.box(n.Value, false)
+	case *parser.Anchor:
+		return d.box(anchorLabel(n), true)
+	case *parser.AnyCharacter:
+		return d.box("any character", true)
+	case *parser.Charset:
+		label, items, ok := d.r.charsetContents(n)
+		if !ok {
+			return d.box(label, true)
+		}
+		return d.list(label, items)
+	case *parser.CharsetIntersection:
+		return d.list("Intersection:", d.r.charsetOperandTexts(n.Operands))
+	case *parser.CharsetSubtraction:
+		return d.list("Subtraction:", d.r.charsetOperandTexts(n.Operands))
+	case *parser.CharsetStringDisjunction:
+		return d.box(d.r.charsetOperandText(n), false)
+	case *parser.Subexp:
+		return d.frame(subexpLabel(n), d.regexp(n.Regexp))
+	case *parser.BackReference:
+		return d.box(backReferenceLabel(n), true)
+	case *parser.UnicodePropertyEscape:
+		return d.box(unicodePropertyLabel(n), true)
+	case *parser.Comment:
+		return d.box("# "+n.Text, true)
+	case *parser.InlineModifier:
+		if n.Regexp != nil {
+			return d.frame(inlineModifierLabel(n), d.regexp(n.Regexp))
+		}
+		return d.box(inlineModifierLabel(n), true)
+	case *parser.BalancedGroup:
+		return d.frame(balancedGroupLabel(n), d.regexp(n.Regexp))
+	case *parser.BranchReset:
+		return d.frame("branch reset", d.regexp(n.Regexp))
+	case *parser.Conditional:
+		return d.conditional(n)
+	case *parser.RecursiveRef:
+		return d.box(recursiveRefLabel(n), true)
+	case *parser.BacktrackControl:
+		return d.box(backtrackControlLabel(n), true)
+	case *parser.Callout:
+		return d.box(calloutLabel(n), true)
+	}
+	return d.box(fmt.Sprintf("<%s>", node.Type()), true)
+}
+
+// box draws label in a box on the track. Descriptions such as "any
+// character" get round corners, like the pills of the SVG diagram;
+// regex content gets square ones.
+func (d *textDiagram) box(label string, description bool) textBlock {
+	g := d.g
+	tl, tr, bl, br := g.downRight, g.downLeft, g.upRight, g.upLeft
+	if description {
+		tl, tr, bl, br = g.roundDR, g.roundDL, g.roundUR, g.roundUL
+	}
+	w := utf8.RuneCountInString(label) + 2
+	b := newTextBlock(w, 3, 1)
+	b.rows[0][0], b.rows[0][w-1] = tl, tr
+	b.rows[2][0], b.rows[2][w-1] = bl, br
+	b.hline(0, 1, w-1, g)
+	b.hline(2, 1, w-1, g)
+	b.rows[1][0], b.rows[1][w-1] = g.udLeft, g.udRight
+	b.put(1, 1, label)
+	return b
+}
+
+// list draws a character class: its header on the top edge and one
+// member per line, with the track entering beside the first.
+func (d *textDiagram) list(header string, items []string) textBlock {
+	inner := utf8.RuneCountInString(header) + 1
+	for _, item := range items {
+		inner = max(inner, utf8.RuneCountInString(item)+2)
+	}
+	content := newTextBlock(inner, max(len(items), 1), 0)
+	for i, item := range items {
+		content.put(i, 1, item)
+	}
+	return d.enclose(header, content, false)
+}
+
+// frame draws a group around content with label on its top edge.
+func (d *textDiagram) frame(label string, content textBlock) textBlock {
+	return d.enclose(label, d.pad(content, 1), true)
+}
+
+// enclose draws a box around content, with label set into the top edge
+// after one stretch of border. When through is set the track crosses
+// the box's sides; otherwise it stops at them.
+func (d *textDiagram) enclose(label string, content textBlock, through bool) textBlock {
+	g := d.g
+	w := max(content.width(), utf8.RuneCountInString(label)+1) + 2
+	h := content.height() + 2
+	b := newTextBlock(w, h, content.track+1)
+	b.blit(content, 1, 1)
+	if through {
+		b.hline(b.track, 1+content.width(), w-1, g)
+	}
+	b.hline(0, 1, w-1, g)
+	b.hline(h-1, 1, w-1, g)
+	b.vline(0, 1, h-1, g)
+	b.vline(w-1, 1, h-1, g)
+	b.rows[0][0], b.rows[0][w-1] = g.downRight, g.downLeft
+	b.rows[h-1][0], b.rows[h-1][w-1] = g.upRight, g.upLeft
+	b.put(0, 2, label)
+	if through {
+		b.rows[b.track][0], b.rows[b.track][w-1] = g.cross, g.cross
+	} else {
+		b.rows[b.track][0], b.rows[b.track][w-1] = g.udLeft, g.udRight
+	}
+	return b
+}
+
+// pad adds n columns of track to either side of b.
+func (d *textDiagram) pad(b textBlock, n int) textBlock {
+	out := newTextBlock(b.width()+2*n, b.height(), b.track)
+	out.blit(b, 0, n)
+	out.hline(out.track, 0, n, d.g)
+	out.hline(out.track, n+b.width(), out.width(), d.g)
+	return out
+}
+
+// widen extends b's track on the right to width w.
+func (d *textDiagram) widen(b textBlock, w int) textBlock {
+	if b.width() >= w {
+		return b
+	}
+	out := newTextBlock(w, b.height(), b.track)
+	out.blit(b, 0, 0)
+	out.hline(out.track, b.width(), w, d.g)
+	return out
+}
+
+// sequence lays blocks out left to right, joined by one stretch of
+// track and aligned on their tracks.
+func (d *textDiagram) sequence(blocks []textBlock) textBlock {
+	if len(blocks) == 0 {
+		return newTextBlock(0, 1, 0)
+	}
+	above, below, w := 0, 0, len(blocks)-1
+	for _, b := range blocks {
+		above = max(above, b.track)
+		below = max(below, b.height()-b.track-1)
+		w += b.width()
+	}
+	out := newTextBlock(w, above+below+1, above)
+	x := 0
+	for i, b := range blocks {
+		if i > 0 {
+			out.rows[above][x] = d.g.h
+			x++
+		}
+		out.blit(b, above-b.track, x)
+		x += b.width()
+	}
+	return out
+}
+
+// stack lays blocks out top to bottom between two rails, with the
+// track running through the first. It draws alternatives.
+func (d *textDiagram) stack(blocks []textBlock) textBlock {
+	g := d.g
+	inner := 0
+	for _, b := range blocks {
+		inner = max(inner, b.width())
+	}
+	h := 0
+	for _, b := range blocks {
+		h += b.height()
+	}
+	w := inner + 4
+	out := newTextBlock(w, h, blocks[0].track)
+	y, last := 0, 0
+	for i, b := range blocks {
+		out.blit(d.widen(b, inner), y, 2)
+		t := y + b.track
+		out.rows[t][1], out.rows[t][w-2] = g.h, g.h
+		switch {
+		case i == 0:
+			out.rows[t][0], out.rows[t][w-1] = g.lrDown, g.lrDown
+		case i == len(blocks)-1:
+			out.vline(0, last+1, t, g)
+			out.vline(w-1, last+1, t, g)
+			out.rows[t][0], out.rows[t][w-1] = g.upRight, g.upLeft
+		default:
+			out.vline(0, last+1, t, g)
+			out.vline(w-1, last+1, t, g)
+			out.rows[t][0], out.rows[t][w-1] = g.udRight, g.udLeft
+		}
+		last = t
+		y += b.height()
+	}
+	return out
+}
+
+// regexp lays out an alternation, or its only branch.
+func (d *textDiagram) regexp(re *parser.Regexp) textBlock {
+	if len(re.Matches) == 0 {
+		return newTextBlock(0, 1, 0)
+	}
+	if len(re.Matches) == 1 {
+		return d.match(re.Matches[0])
+	}
+	branches := make([]textBlock, len(re.Matches))
+	for i, m := range re.Matches {
+		branches[i] = d.match(m)
+		if d.r.Config.NumberAlternatives {
+			branches[i] = d.number(branches[i], i+1)
+		}
+	}
+	return d.stack(branches)
+}
+
+// number sets an alternative's number ahead of it on the track.
+func (d *textDiagram) number(b textBlock, n int) textBlock {
+	label := strconv.Itoa(n) + "."
+	lead := utf8.RuneCountInString(label) + 1
+	out := newTextBlock(lead+b.width(), b.height(), b.track)
+	out.put(out.track, 0, label)
+	out.rows[out.track][lead-1] = d.g.h
+	out.blit(b, 0, lead)
+	return out
+}
+
+// match lays out a sequence of fragments.
+func (d *textDiagram) match(m *parser.Match) textBlock {
+	frags := m.Fragments
+	if d.r.Config.MergeLiterals {
+		frags = d.r.mergeLiteralRuns(frags)
+	}
+	blocks := make([]textBlock, len(frags))
+	for i, frag := range frags {
+		blocks[i] = d.fragment(frag)
+	}
+	return d.sequence(blocks)
+}
+
+// fragment lays out a node with its quantifier, if any.
+func (d *textDiagram) fragment(frag *parser.MatchFragment) textBlock {
+	content := d.node(frag.Content)
+	if frag.Repeat == nil {
+		return content
+	}
+	return d.repeat(content, frag.Repeat)
+}
+
+// repeat draws the skip path of an optional item above it and the loop
+// of a repeated one below it, with the repeat label and an arrow on the
+// loop: pointing back for a greedy quantifier, forward for a lazy one.
+func (d *textDiagram) repeat(content textBlock, rep *parser.Repeat) textBlock {
+	g := d.g
+	hasSkip := rep.Min == 0
+	hasLoop := rep.Max != 1
+
+	loop := ""
+	if hasLoop {
+		arrow := g.greedyArrow
+		if !rep.Greedy {
+			arrow = g.lazyArrow
+		}
+		loop = string(arrow)
+		label := d.r.getRepeatLabel(rep)
+		if d.r.Config.TerseRepeatLabels && trivialRepeat(rep) {
+			label = ""
+		}
+		if label != "" {
+			loop += " " + label
+		}
+		// Room for the loop's text between its corners and a stretch
+		// of track on either side of it.
+		content = d.widen(content, utf8.RuneCountInString(loop)+2)
+	}
+
+	top := 0
+	if hasSkip {
+		top = 1
+	}
+	w := content.width() + 4
+	h := top + content.height()
+	if hasLoop {
+		h++
+	}
+	b := newTextBlock(w, h, top+content.track)
+	b.blit(content, top, 2)
+	b.rows[b.track][1], b.rows[b.track][w-2] = g.h, g.h
+
+	junction := g.h
+	switch {
+	case hasSkip && hasLoop:
+		junction = g.cross
+	case hasSkip:
+		junction = g.lrUp
+	case hasLoop:
+		junction = g.lrDown
+	}
+	b.rows[b.track][0], b.rows[b.track][w-1] = junction, junction
+	if hasSkip {
+		b.hline(0, 1, w-1, g)
+		b.rows[0][0], b.rows[0][w-1] = g.downRight, g.downLeft
+		b.vline(0, 1, b.track, g)
+		b.vline(w-1, 1, b.track, g)
+	}
+	if hasLoop {
+		y := h - 1
+		b.hline(y, 1, w-1, g)
+		b.rows[y][0], b.rows[y][w-1] = g.upRight, g.upLeft
+		b.vline(0, b.track+1, y, g)
+		b.vline(w-1, b.track+1, y, g)
+		b.put(y, (w-utf8.RuneCountInString(loop))/2, loop)
+	}
+	return b
+}
+
+// conditional draws the "then" and "else" branches as alternatives
+// inside a frame labeled with the condition.
+func (d *textDiagram) conditional(cond *parser.Conditional) textBlock {
+	branches := []textBlock{d.sequence([]textBlock{d.box("then", true), d.regexp(cond.TrueMatch)})}
+	if cond.FalseMatch != nil {
+		branches = append(branches, d.sequence([]textBlock{d.box("else", true), d.regexp(cond.FalseMatch)}))
+	}
+	content := branches[0]
+	if len(branches) > 1 {
+		content = d.stack(branches)
+	}
+	return d.frame(conditionLabel(cond), content)
+}
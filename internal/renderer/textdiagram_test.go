@@ -0,0 +1,100 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/0x4d5352/regolith/internal/flavor"
+)
+
+func renderTextDiagram(t *testing.T, pattern string, cfg *Config, ascii bool) string {
+	t.Helper()
+	f, _ := flavor.Get("pcre")
+	parsed, err := f.Parse(pattern)
+	if err != nil {
+		t.Fatalf("parse %q: %v", pattern, err)
+	}
+	return New(cfg).RenderTextDiagram(parsed, ascii)
+}
+
+func TestRenderTextDiagram(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{`ab`, `
+   ┌────┐
+●──┤"ab"├──●
+   └────┘
+`},
+		{`a|bc`, `
+     ┌───┐
+●──┬─┤"a"├──┬──●
+   │ └───┘  │
+   │ ┌────┐ │
+   └─┤"bc"├─┘
+     └────┘
+`},
+		{`(x)+?y?`, `
+     ┌─group #1┐   ┌───────┐
+     │ ┌───┐   │   │ ┌───┐ │
+●──┬─┼─┤"x"├───┼─┬─┴─┤"y"├─┴──●
+   │ │ └───┘   │ │   └───┘
+   │ └─────────┘ │
+   └──────►──────┘
+`},
+		{`[a-c.]{2}$`, `
+     ┌─One of:───┐   ╭───────────╮
+●──┬─┤ "a" - "c" ├─┬─┤End of line├──●
+   │ │ "."       │ │ ╰───────────╯
+   │ └───────────┘ │
+   └───◄ 2 times───┘
+`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.pattern, func(t *testing.T) {
+			got := renderTextDiagram(t, tc.pattern, nil, false)
+			if want := strings.TrimPrefix(tc.want, "\n"); got != want {
+				t.Errorf("diagram for %q:\n%s\nwant:\n%s", tc.pattern, got, want)
+			}
+		})
+	}
+}
+
+func TestRenderTextDiagramASCII(t *testing.T) {
+	got := renderTextDiagram(t, `a*`, nil, true)
+	want := `   +-------+
+   | +---+ |
+o--+-+"a"+-+--o
+   | +---+ |
+   +---<---+
+`
+	if got != want {
+		t.Errorf("diagram:\n%s\nwant:\n%s", got, want)
+	}
+	for _, r := range got {
+		if r > 0x7f {
+			t.Fatalf("non-ASCII %q in --ascii diagram:\n%s", r, got)
+		}
+	}
+}
+
+func TestRenderTextDiagramOptions(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.NumberAlternatives = true
+	got := renderTextDiagram(t, `a|b`, cfg, false)
+	for _, want := range []string{"1.─┤\"a\"", "2.─┤\"b\""} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q with NumberAlternatives:\n%s", want, got)
+		}
+	}
+
+	f, _ := flavor.Get("javascript")
+	parsed, err := f.Parse(`/a/gi`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := New(nil).RenderTextDiagram(parsed, false); !strings.HasSuffix(got, "\nflags: gi\n") {
+		t.Errorf("expected a flags line after the diagram:\n%s", got)
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/0x4d5352/regolith/internal/flavor/javascript"
 	"github.com/0x4d5352/regolith/internal/renderer"
+	"github.com/0x4d5352/regolith/internal/svgtest"
 )
 
 // goldenPattern is a single representative regex used to render a
@@ -64,8 +65,8 @@ func TestThemeGoldens(t *testing.T) {
 			if err != nil {
 				t.Fatalf("read golden %s (run with GOLDEN_UPDATE=1 to create): %v", goldenPath, err)
 			}
-			if svg != string(want) {
-				t.Errorf("SVG differs from %s (run with GOLDEN_UPDATE=1 to update)", goldenPath)
+			if err := svgtest.Compare([]byte(svg), want, svgtest.Options{}); err != nil {
+				t.Errorf("SVG differs from %s (run with GOLDEN_UPDATE=1 to update): %v", goldenPath, err)
 			}
 		})
 	}
@@ -102,7 +103,7 @@ func TestDefaultGolden(t *testing.T) {
 	if err != nil {
 		t.Fatalf("read golden %s (run with GOLDEN_UPDATE=1 to create): %v", goldenPath, err)
 	}
-	if svg != string(want) {
-		t.Errorf("SVG differs from %s (run with GOLDEN_UPDATE=1 to update)", goldenPath)
+	if err := svgtest.Compare([]byte(svg), want, svgtest.Options{}); err != nil {
+		t.Errorf("SVG differs from %s (run with GOLDEN_UPDATE=1 to update): %v", goldenPath, err)
 	}
 }
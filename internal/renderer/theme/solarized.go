@@ -0,0 +1,104 @@
+package theme
+
+import "github.com/0x4d5352/regolith/internal/renderer"
+
+// Solarized palette.
+// Source: https://ethanschoonover.com/solarized/ (MIT License, Ethan
+// Schoonover). Hex values are verbatim from the published table.
+// Solarized is one set of sixteen colors used for both variants: the
+// eight accents are shared, and the monotone base03..base3 ramp is
+// read in opposite directions, so dark draws base0 text on base03 and
+// light draws base00 text on base3. The palette below names each role
+// rather than each base so applySolarized can stay variant-agnostic.
+
+// solarizedPalette assigns the base tones to the roles the renderer
+// needs. The accents are shared by both variants. Light text is one
+// step further from the background than Solarized's usual base01 so
+// node text clears WCAG AA on the base2 panels.
+type solarizedPalette struct {
+	bg      string // background: base03 / base3
+	surface string // node panels: base02 / base2
+	ink     string // node and label text: base1 / base02
+	fg      string // repeat labels: base0 / base01
+	muted   string // connectors and outlines: base01 / base1
+	// nest tints the blue, green, yellow, violet, and cyan accents
+	// toward bg for nested group panels, which sit behind label text.
+	nest []string
+}
+
+// Solarized accents, identical in both variants.
+const (
+	solarizedYellow  = "#b58900"
+	solarizedOrange  = "#cb4b16"
+	solarizedRed     = "#dc322f"
+	solarizedMagenta = "#d33682"
+	solarizedViolet  = "#6c71c4"
+	solarizedBlue    = "#268bd2"
+	solarizedCyan    = "#2aa198"
+	solarizedGreen   = "#859900"
+)
+
+var (
+	solarizedDark = solarizedPalette{
+		bg:      "#002b36", // base03
+		surface: "#073642", // base02
+		ink:     "#93a1a1", // base1
+		fg:      "#839496", // base0
+		muted:   "#586e75", // base01
+		// 15% accent over base03.
+		nest: []string{"#06394d", "#143c2e", "#1b392e", "#10364b", "#053b44"},
+	}
+
+	solarizedLight = solarizedPalette{
+		bg:      "#fdf6e3", // base3
+		surface: "#eee8d5", // base2
+		ink:     "#073642", // base02
+		fg:      "#586e75", // base01
+		muted:   "#93a1a1", // base1
+		// 20% accent over base3.
+		nest: []string{"#d2e1e0", "#e5e3b6", "#efe0b6", "#e0dbdd", "#d3e5d4"},
+	}
+)
+
+// applySolarized rewrites cfg's colors from a solarized palette. Every
+// category is a surface panel told apart by its accent stroke, the
+// comment outline is muted, and the anchor pill inverts to an ink fill
+// with background-colored text, as in every other theme.
+func applySolarized(c *renderer.Config, p solarizedPalette) {
+	c.BackgroundColor = p.bg
+	c.TextColor = p.ink
+
+	c.NodeStyles = map[string]renderer.NodeStyle{
+		"literal":           {Fill: p.surface, Stroke: solarizedRed, TextColor: p.ink},
+		"charset":           {Fill: p.surface, Stroke: solarizedYellow, TextColor: p.ink},
+		"escape":            {Fill: p.surface, Stroke: solarizedGreen, TextColor: p.ink},
+		"anchor":            {Fill: p.ink, Stroke: p.muted, TextColor: p.bg, CornerRadius: 14},
+		"any-character":     {Fill: p.surface, Stroke: solarizedBlue, TextColor: p.ink},
+		"flags":             {Fill: p.surface, Stroke: solarizedBlue, TextColor: p.ink},
+		"recursive-ref":     {Fill: p.surface, Stroke: solarizedViolet, TextColor: p.ink},
+		"callout":           {Fill: p.surface, Stroke: solarizedOrange, TextColor: p.ink},
+		"backtrack-control": {Fill: p.surface, Stroke: solarizedMagenta, TextColor: p.ink},
+		"conditional":       {Fill: p.surface, Stroke: solarizedCyan, TextColor: p.ink},
+		"comment":           {Fill: p.surface, Stroke: p.muted, TextColor: p.ink},
+	}
+
+	c.SubexpFill = "none"
+	c.SubexpStroke = p.muted
+	c.SubexpColors = p.nest
+
+	c.RepeatLabelColor = p.fg
+	c.Connector.Color = p.muted
+}
+
+func init() {
+	Register(&paletteTheme{
+		name:        "solarized-dark",
+		description: "Solarized Dark — Ethan Schoonover's precision palette on base03",
+		apply:       func(c *renderer.Config) { applySolarized(c, solarizedDark) },
+	})
+	Register(&paletteTheme{
+		name:        "solarized-light",
+		description: "Solarized Light — the same accents on the base3 cream field",
+		apply:       func(c *renderer.Config) { applySolarized(c, solarizedLight) },
+	})
+}
@@ -27,6 +27,8 @@ var expectedThemes = []string{
 	"light",
 	"pastels-dark",
 	"pastels-light",
+	"solarized-dark",
+	"solarized-light",
 }
 
 // expectedNodeCategories is every category the renderer currently
@@ -0,0 +1,103 @@
+package renderer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ================================================================================
+// Title and Caption
+// ================================================================================
+
+// titleLineHeight is the distance between the baselines of two lines
+// of a title or caption, in multiples of its font size.
+const titleLineHeight = 1.25
+
+// resolved returns s with its empty fields filled in from cfg: the
+// label font family and size, and the fallback text color.
+func (s TextStyle) resolved(cfg *Config) TextStyle {
+	if s.FontFamily == "" {
+		s.FontFamily = cfg.LabelFontFamily
+	}
+	if s.FontSize <= 0 {
+		s.FontSize = cfg.LabelFontSize
+	}
+	if s.Color == "" {
+		s.Color = cfg.TextColor
+	}
+	return s
+}
+
+// bold reports whether the style's font weight is bold or heavier.
+func (s TextStyle) bold() bool {
+	if s.FontWeight == "bold" || s.FontWeight == "bolder" {
+		return true
+	}
+	n, err := strconv.Atoi(s.FontWeight)
+	return err == nil && n >= 600
+}
+
+// renderFreeText draws text, one line per line of it, left-aligned at
+// the origin, in style. The lines are measured on the LabelCharWidth
+// grid, scaled from LabelFontSize to the style's size.
+func (r *Renderer) renderFreeText(text, class string, style TextStyle) RenderedNode {
+	cfg := r.Config
+	style = style.resolved(cfg)
+	charWidth := cfg.LabelCharWidth
+	if cfg.LabelFontSize > 0 {
+		charWidth *= style.FontSize / cfg.LabelFontSize
+	}
+
+	lines := strings.Split(text, "\n")
+	g := &Group{}
+	width := 0.0
+	for i, line := range lines {
+		g.Children = append(g.Children, &Text{
+			X:          0,
+			Y:          style.FontSize + float64(i)*style.FontSize*titleLineHeight,
+			Content:    line,
+			FontFamily: style.FontFamily,
+			FontSize:   style.FontSize,
+			Fill:       style.Color,
+			Class:      class,
+		})
+		width = max(width, float64(textCells(line))*charWidth)
+	}
+	height := float64(len(lines)) * style.FontSize * titleLineHeight
+	return RenderedNode{Element: g, BBox: NewBoundingBox(0, 0, width, height)}
+}
+
+// renderTitle draws Title in TitleStyle, for the top of the document.
+func (r *Renderer) renderTitle() RenderedNode {
+	return r.renderFreeText(r.Title, "diagram-title", r.Config.TitleStyle)
+}
+
+// renderCaption draws Caption in CaptionStyle, for the bottom of the
+// document.
+func (r *Renderer) renderCaption() RenderedNode {
+	return r.renderFreeText(r.Caption, "diagram-caption", r.Config.CaptionStyle)
+}
+
+// titleStyles returns the stylesheet rules for the title and caption,
+// when the document has them. They are rules rather than attributes
+// because the base text rule in getStyles would override attributes.
+func (r *Renderer) titleStyles() string {
+	var b strings.Builder
+	rule := func(class string, s TextStyle) {
+		s = s.resolved(r.Config)
+		weight := ""
+		if s.FontWeight != "" {
+			weight = " font-weight: " + s.FontWeight + ";"
+		}
+		fmt.Fprintf(&b, "\n\t\t.%s { font-family: %s; font-size: %spx;%s fill: %s; }",
+			class, s.FontFamily, fmtFloat(s.FontSize), weight, s.Color)
+	}
+	if r.Title != "" {
+		rule("diagram-title", r.Config.TitleStyle)
+	}
+	if r.Caption != "" {
+		rule("diagram-caption", r.Config.CaptionStyle)
+	}
+	return b.String()
+}
@@ -0,0 +1,120 @@
+package renderer
+
+import (
+	parser "github.com/0x4d5352/regolith/internal/ast"
+)
+
+// ================================================================================
+// Wrapped Layout
+// ================================================================================
+
+// renderTop draws root, the whole pattern, and reports the y at which
+// its track leaves the diagram: its AnchorY, unless Config.WrapWidth
+// folds the top-level sequence onto rows and the track leaves from the
+// last one (see renderWrapped).
+func (r *Renderer) renderTop(root *parser.Regexp) (RenderedNode, float64) {
+	cfg := r.Config
+	offTrack := cfg.LookaroundLayout == "above" || cfg.LookaroundLayout == "below"
+	if cfg.WrapWidth > 0 && !offTrack && len(root.Matches) == 1 && len(root.Matches[0].Fragments) > 1 {
+		return r.renderWrapped(root.Matches[0])
+	}
+	rendered := r.renderRegexp(root)
+	return rendered, rendered.BBox.AnchorY
+}
+
+// renderWrapped draws the top-level sequence match in rows at most
+// Config.WrapWidth wide, for slides and pages a single long track would
+// overflow. Items fill a row until the next would not fit; an item
+// wider than a row gets one to itself, so nothing is split. Between
+// rows the track runs on past the end of the row, down into the gap
+// under it, back along the gap to the left edge, and down into the
+// start of the next row. A sequence that fits is drawn as renderMatch
+// would draw it.
+func (r *Renderer) renderWrapped(match *parser.Match) (RenderedNode, float64) {
+	cfg := r.Config
+	curveRadius := cfg.CurveRadius
+	// Each side keeps a lane for the turns between rows.
+	lane := 2 * curveRadius
+
+	fragments := match.Fragments
+	if cfg.MergeLiterals {
+		fragments = r.mergeLiteralRuns(fragments)
+	}
+	var rows [][]RenderedNode
+	rowWidth := 0.0
+	for _, frag := range fragments {
+		item := r.renderMatchFragment(frag)
+		last := len(rows) - 1
+		if last >= 0 && rowWidth+cfg.HorizontalGap+item.BBox.Width <= cfg.WrapWidth-2*lane {
+			rows[last] = append(rows[last], item)
+			rowWidth += cfg.HorizontalGap + item.BBox.Width
+			continue
+		}
+		rows = append(rows, []RenderedNode{item})
+		rowWidth = item.BBox.Width
+	}
+	if len(rows) == 1 {
+		rendered := r.joinSequence(rows[0])
+		return rendered, rendered.BBox.AnchorY
+	}
+
+	joined := make([]RenderedNode, len(rows))
+	maxWidth := 0.0
+	for i, row := range rows {
+		joined[i] = r.joinSequence(row)
+		maxWidth = max(maxWidth, joined[i].BBox.Width)
+	}
+	width := lane + maxWidth + lane
+
+	var children []SVGElement
+	track := NewPathBuilder()
+	var top, anchorY, exitY float64
+	for i, row := range joined {
+		bbox := row.BBox
+		if i > 0 {
+			// The track turns down at the right edge below the previous
+			// row's anchor, runs back under that row, and turns down
+			// again to meet this row's anchor.
+			prev := joined[i-1].BBox.Translate(lane, top)
+			gapY := max(prev.Y2()+curveRadius, prev.AnchorY+2*curveRadius)
+			top = max(gapY+curveRadius, gapY+2*curveRadius-bbox.AnchorY)
+			next := top + bbox.AnchorY
+			track.MoveTo(prev.X2(), prev.AnchorY)
+			track.HorizontalTo(width - curveRadius)
+			track.QuadraticTo(width, prev.AnchorY, width, prev.AnchorY+curveRadius)
+			track.VerticalTo(gapY - curveRadius)
+			track.QuadraticTo(width, gapY, width-curveRadius, gapY)
+			track.HorizontalTo(curveRadius)
+			track.QuadraticTo(0, gapY, 0, gapY+curveRadius)
+			track.VerticalTo(next - curveRadius)
+			track.QuadraticTo(0, next, curveRadius, next)
+			track.HorizontalTo(lane)
+		} else {
+			anchorY = bbox.AnchorY
+			track.MoveTo(0, anchorY)
+			track.HorizontalTo(lane)
+		}
+		children = append(children, wrapWithTransform(row.Element, lane, top))
+		exitY = top + bbox.AnchorY
+	}
+	last := joined[len(joined)-1].BBox
+	track.MoveTo(lane+last.Width, exitY)
+	track.HorizontalTo(width)
+	children = append([]SVGElement{&Path{
+		D:           track.String(),
+		Stroke:      cfg.Connector.Color,
+		StrokeWidth: cfg.Connector.StrokeWidth,
+		Class:       "wrap-path",
+	}}, children...)
+
+	return RenderedNode{
+		Element: &Group{Class: "wrapped", Children: children},
+		BBox: BoundingBox{
+			Width:       width,
+			Height:      top + last.Height,
+			AnchorLeft:  0,
+			AnchorRight: width,
+			AnchorY:     anchorY,
+		},
+	}, exitY
+}
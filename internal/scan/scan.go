@@ -0,0 +1,285 @@
+// Package scan finds regular expressions in source code.
+//
+// Extraction is lexical, not a real parse of each language: a table of
+// per-language regexes picks out the call sites and literal forms that
+// almost always hold a pattern (new RegExp("..."), re.compile(r"..."),
+// Pattern.compile("..."), and so on). That misses patterns assembled
+// at runtime and can be fooled by look-alike text in comments, but it
+// needs no toolchain for the scanned language and runs in one pass
+// over a large tree. Every Site records where it came from so a false
+// positive is easy to dismiss.
+//
+// Go sources are deliberately not scanned. Go's regexp package is RE2,
+// which runs in linear time and has no ReDoS exposure, and regolith has
+// no RE2 flavor to parse its syntax with.
+package scan
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/0x4d5352/regolith/internal/unescape"
+)
+
+// Site is one regular expression found in a source file.
+type Site struct {
+	File     string // Path as walked, slash-separated
+	Line     int    // 1-based line of the pattern's opening quote
+	Column   int    // 1-based byte column of the opening quote
+	Language string // Source language, e.g. "python"
+	Flavor   string // regolith flavor the pattern is parsed with
+	Pattern  string // Pattern text after the language's string unescaping
+}
+
+// extractor recognizes one source form. The regex's first submatch is
+// the raw pattern body; toPattern turns it into the text regolith
+// parses, and may return "" to skip the match.
+type extractor struct {
+	re        *regexp.Regexp
+	toPattern func(m []string) string
+}
+
+type language struct {
+	name       string
+	flavor     string
+	extensions []string
+	extractors []extractor
+}
+
+// body returns the first submatch unchanged.
+func body(m []string) string { return m[1] }
+
+// unescaped applies C-style string unescaping to the first submatch.
+func unescaped(m []string) string { return unescape.JavaStringLiteral(m[1]) }
+
+// Quoted-string bodies, one per quote style. Go's regexp has no
+// backreferences, so each quote character needs its own alternative.
+const (
+	dq = `"((?:\\.|[^"\\\n])*)"`
+	sq = `'((?:\\.|[^'\\\n])*)'`
+)
+
+var languages = []language{
+	{
+		name:       "javascript",
+		flavor:     "javascript",
+		extensions: []string{".js", ".mjs", ".cjs", ".jsx", ".ts", ".mts", ".cts", ".tsx"},
+		extractors: []extractor{
+			{regexp.MustCompile(`new\s+RegExp\(\s*` + dq), unescaped},
+			{regexp.MustCompile(`new\s+RegExp\(\s*` + sq), unescaped},
+			// A regex literal can only start where an expression can, which
+			// is what tells it apart from division.
+			{
+				regexp.MustCompile(`(?:^|[=(,:;!&|?{}\[]|\breturn|\btypeof)\s*(/(?:\\.|\[(?:\\.|[^\]\\\n])*\]|[^/\\\[\n*])(?:\\.|\[(?:\\.|[^\]\\\n])*\]|[^/\\\[\n])*/[dgimsuyv]*)`),
+				body,
+			},
+		},
+	},
+	{
+		name:       "python",
+		flavor:     "pcre",
+		extensions: []string{".py"},
+		extractors: []extractor{
+			{regexp.MustCompile(`\bre\.(?:compile|match|search|fullmatch|findall|finditer|sub|subn|split)\(\s*[rR]` + dq), body},
+			{regexp.MustCompile(`\bre\.(?:compile|match|search|fullmatch|findall|finditer|sub|subn|split)\(\s*[rR]` + sq), body},
+			{regexp.MustCompile(`\bre\.(?:compile|match|search|fullmatch|findall|finditer|sub|subn|split)\(\s*` + dq), unescaped},
+			{regexp.MustCompile(`\bre\.(?:compile|match|search|fullmatch|findall|finditer|sub|subn|split)\(\s*` + sq), unescaped},
+		},
+	},
+	{
+		name:       "java",
+		flavor:     "java",
+		extensions: []string{".java", ".kt"},
+		extractors: []extractor{
+			{regexp.MustCompile(`\bPattern\.(?:compile|matches)\(\s*` + dq), unescaped},
+			{regexp.MustCompile(`\.(?:matches|replaceAll|replaceFirst|split)\(\s*` + dq), unescaped},
+		},
+	},
+	{
+		name:       "csharp",
+		flavor:     "dotnet",
+		extensions: []string{".cs"},
+		extractors: []extractor{
+			{regexp.MustCompile(`new\s+Regex\(\s*@"((?:""|[^"])*)"`), verbatim},
+			{regexp.MustCompile(`new\s+Regex\(\s*` + dq), unescaped},
+			{regexp.MustCompile(`\bRegex\.(?:IsMatch|Match|Matches|Replace|Split)\([^,()]*,\s*@"((?:""|[^"])*)"`), verbatim},
+			{regexp.MustCompile(`\bRegex\.(?:IsMatch|Match|Matches|Replace|Split)\([^,()]*,\s*` + dq), unescaped},
+		},
+	},
+	{
+		name:       "php",
+		flavor:     "pcre",
+		extensions: []string{".php"},
+		extractors: []extractor{
+			{regexp.MustCompile(`\bpreg_(?:match|match_all|replace|replace_callback|split|grep)\(\s*` + sq), phpPattern},
+			{regexp.MustCompile(`\bpreg_(?:match|match_all|replace|replace_callback|split|grep)\(\s*` + dq), phpPattern},
+		},
+	},
+}
+
+// verbatim undoes C# @"..." quoting, where "" stands for one quote.
+func verbatim(m []string) string { return strings.ReplaceAll(m[1], `""`, `"`) }
+
+// phpFlags are the PCRE modifiers that have an inline (?x) equivalent.
+const phpFlags = "imsx"
+
+// phpPattern strips PHP's pattern delimiters. Modifiers with an inline
+// equivalent become a leading (?...) group so they still affect the
+// parse; the rest (u, U, D, ...) are dropped.
+func phpPattern(m []string) string {
+	s := unescape.JavaStringLiteral(m[1])
+	if len(s) < 2 {
+		return ""
+	}
+	open := s[0]
+	closeDelim := map[byte]byte{'(': ')', '{': '}', '[': ']', '<': '>'}[open]
+	if closeDelim == 0 {
+		closeDelim = open
+	}
+	end := strings.LastIndexByte(s, closeDelim)
+	if end <= 0 {
+		return ""
+	}
+	var inline strings.Builder
+	for _, c := range s[end+1:] {
+		if strings.ContainsRune(phpFlags, c) && !strings.ContainsRune(inline.String(), c) {
+			inline.WriteRune(c)
+		}
+	}
+	pattern := s[1:end]
+	if inline.Len() > 0 {
+		pattern = "(?" + inline.String() + ")" + pattern
+	}
+	return pattern
+}
+
+// languageFor returns the language that handles path's extension.
+func languageFor(path string) *language {
+	ext := strings.ToLower(filepath.Ext(path))
+	for i := range languages {
+		for _, e := range languages[i].extensions {
+			if e == ext {
+				return &languages[i]
+			}
+		}
+	}
+	return nil
+}
+
+// Extract returns the regexes in src, which was read from path. The
+// language is chosen by path's extension; unrecognized files yield
+// nothing.
+func Extract(path string, src []byte) []Site {
+	lang := languageFor(path)
+	if lang == nil || strings.HasSuffix(path, ".min.js") {
+		return nil
+	}
+	text := string(src)
+	seen := make(map[int]bool)
+	var sites []Site
+	for _, ex := range lang.extractors {
+		for _, idx := range ex.re.FindAllStringSubmatchIndex(text, -1) {
+			start := idx[2]
+			if seen[start] {
+				continue
+			}
+			m := make([]string, len(idx)/2)
+			for i := range m {
+				if idx[2*i] >= 0 {
+					m[i] = text[idx[2*i]:idx[2*i+1]]
+				}
+			}
+			pattern := ex.toPattern(m)
+			if pattern == "" {
+				continue
+			}
+			seen[start] = true
+			// Point at the opening quote (or slash) rather than the body.
+			quote := start - 1
+			if lang.name == "javascript" && strings.HasPrefix(m[1], "/") {
+				quote = start
+			}
+			line, col := position(text, quote)
+			sites = append(sites, Site{
+				File:     filepath.ToSlash(path),
+				Line:     line,
+				Column:   col,
+				Language: lang.name,
+				Flavor:   lang.flavor,
+				Pattern:  pattern,
+			})
+		}
+	}
+	sort.Slice(sites, func(i, j int) bool {
+		if sites[i].Line != sites[j].Line {
+			return sites[i].Line < sites[j].Line
+		}
+		return sites[i].Column < sites[j].Column
+	})
+	return sites
+}
+
+func position(text string, offset int) (line, col int) {
+	if offset < 0 {
+		offset = 0
+	}
+	before := text[:offset]
+	line = strings.Count(before, "\n") + 1
+	col = offset - strings.LastIndexByte(before, '\n')
+	return line, col
+}
+
+// skipDirs are directory names never descended into: VCS metadata and
+// vendored dependencies, whose patterns are not the caller's to fix.
+var skipDirs = map[string]bool{
+	".git":         true,
+	".hg":          true,
+	".svn":         true,
+	"node_modules": true,
+	"vendor":       true,
+}
+
+// maxFileSize skips generated bundles and other huge files, which are
+// slow to scan and rarely hand-written.
+const maxFileSize = 1 << 20
+
+// Dir walks root and returns the regexes in every recognized source
+// file beneath it, ordered by file then position. root may use the Go
+// tool's "dir/..." spelling; every walk is recursive either way. A
+// single file may also be given.
+func Dir(root string) ([]Site, error) {
+	root = strings.TrimSuffix(strings.TrimSuffix(root, "..."), "/")
+	if root == "" || root == "." {
+		root = "."
+	}
+	var sites []Site
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && skipDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if languageFor(path) == nil {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() > maxFileSize {
+			return nil
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		sites = append(sites, Extract(path, src)...)
+		return nil
+	})
+	return sites, err
+}
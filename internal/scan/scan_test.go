@@ -0,0 +1,77 @@
+package scan
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		path string
+		src  string
+		want []string // "line:col flavor pattern"
+	}{
+		{"a.js", "const re = /^(a+)+$/gi;\nconst half = total / 2 / 3;\n",
+			[]string{"1:12 javascript /^(a+)+$/gi"}},
+		{"a.ts", `const r = new RegExp("\\d+\\.\\d*");`,
+			[]string{`1:22 javascript \d+\.\d*`}},
+		{"a.js", `if (/[/]x/.test(s)) {}`,
+			[]string{"1:5 javascript /[/]x/"}},
+		{"a.min.js", `x=/a+/`, nil},
+		{"a.py", "import re\nP = re.compile(r'\\w+\\.py')\nre.sub(\"\\\\s+\", ' ', s)\n",
+			[]string{`2:17 pcre \w+\.py`, `3:8 pcre \s+`}},
+		{"A.java", `Pattern.compile("\\d{4}"); s.split(",\\s*");`,
+			[]string{`1:17 java \d{4}`, `1:36 java ,\s*`}},
+		{"A.cs", `new Regex(@"^""\d+""$"); Regex.IsMatch(s, "\\w+");`,
+			[]string{`1:12 dotnet ^"\d+"$`, `1:43 dotnet \w+`}},
+		{"a.php", `preg_match('/^foo$/im', $s); preg_split('{\s*,\s*}', $s);`,
+			[]string{`1:12 pcre (?im)^foo$`, `1:41 pcre \s*,\s*`}},
+		{"main.go", `regexp.MustCompile("(a+)+")`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			var got []string
+			for _, s := range Extract(tt.path, []byte(tt.src)) {
+				got = append(got, formatSite(s))
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func formatSite(s Site) string {
+	return fmt.Sprintf("%d:%d %s %s", s.Line, s.Column, s.Flavor, s.Pattern)
+}
+
+func TestDirSkipsVendored(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, src string) {
+		t.Helper()
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("src/app.js", "x = /keep/;\n")
+	write("node_modules/dep/index.js", "x = /skip/;\n")
+	write("vendor/lib.py", "re.compile(r'skip')\n")
+	write("README.md", "x = /skip/;\n")
+
+	for _, arg := range []string{root, root + "/..."} {
+		sites, err := Dir(arg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sites) != 1 || sites[0].Pattern != "/keep/" {
+			t.Errorf("Dir(%q) = %+v, want only /keep/", arg, sites)
+		}
+	}
+}
@@ -0,0 +1,324 @@
+// Package selfupdate replaces a running regolith binary with the latest
+// GitHub release, for `regolith self-update`.
+//
+// A release carries one archive per platform, named as GoReleaser names
+// them (see ArchiveName), a checksums.txt listing the SHA-256 of each,
+// and checksums.txt.sig, the base64 Ed25519 signature of checksums.txt
+// made with the release signing key. An update trusts nothing it
+// downloads until the signature verifies against the public key built
+// into the binary and the archive matches its listed checksum, so a
+// compromised mirror or release page can at worst withhold an update.
+package selfupdate
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DefaultAPI is the GitHub API endpoint for the latest release.
+const DefaultAPI = "https://api.github.com/repos/0x4d5352/regolith/releases/latest"
+
+// Names of the release assets besides the archives.
+const (
+	ChecksumsAsset = "checksums.txt"
+	SignatureAsset = "checksums.txt.sig"
+)
+
+// maxAssetBytes caps each download. The archives are a few MiB; the cap
+// only stops a wrong or hostile URL from filling memory.
+const maxAssetBytes = 100 << 20
+
+// Release is a published release: its version, without the tag's "v",
+// and the download URL of each asset by name.
+type Release struct {
+	Version string
+	Assets  map[string]string
+}
+
+// Updater fetches releases. The zero value is not usable; set Client
+// and API.
+type Updater struct {
+	Client    *http.Client
+	API       string // The latest-release endpoint, DefaultAPI outside tests
+	UserAgent string
+	PublicKey ed25519.PublicKey // Verifies checksums.txt.sig
+}
+
+// ParsePublicKey decodes the base64 form of an Ed25519 public key, as
+// the release workflow passes it to the build.
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	if s == "" {
+		return nil, errors.New("this build has no release signing key, so updates cannot be verified; install a release build")
+	}
+	key, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, errors.New("malformed release signing key")
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// Latest returns the latest release.
+func (u *Updater) Latest(ctx context.Context) (*Release, error) {
+	data, err := u.get(ctx, u.API)
+	if err != nil {
+		return nil, err
+	}
+	var body struct {
+		TagName string `json:"tag_name"`
+		Assets  []struct {
+			Name string `json:"name"`
+			URL  string `json:"browser_download_url"`
+		} `json:"assets"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, fmt.Errorf("reading release: %w", err)
+	}
+	if body.TagName == "" {
+		return nil, errors.New("reading release: no tag name")
+	}
+	rel := &Release{Version: strings.TrimPrefix(body.TagName, "v"), Assets: make(map[string]string)}
+	for _, a := range body.Assets {
+		rel.Assets[a.Name] = a.URL
+	}
+	return rel, nil
+}
+
+// Download fetches the archive of rel for goos and goarch, verifies it,
+// and returns the regolith executable inside it. Verification fails
+// unless checksums.txt.sig is a valid signature of checksums.txt under
+// PublicKey and checksums.txt lists the archive's SHA-256.
+func (u *Updater) Download(ctx context.Context, rel *Release, goos, goarch string) ([]byte, error) {
+	name := ArchiveName(rel.Version, goos, goarch)
+	fetch := func(asset string) ([]byte, error) {
+		url, ok := rel.Assets[asset]
+		if !ok {
+			return nil, fmt.Errorf("release %s has no %s", rel.Version, asset)
+		}
+		return u.get(ctx, url)
+	}
+	sums, err := fetch(ChecksumsAsset)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := fetch(SignatureAsset)
+	if err != nil {
+		return nil, err
+	}
+	if err := Verify(u.PublicKey, sums, sig); err != nil {
+		return nil, err
+	}
+	want, err := Checksum(sums, name)
+	if err != nil {
+		return nil, err
+	}
+	archive, err := fetch(name)
+	if err != nil {
+		return nil, err
+	}
+	if got := sha256.Sum256(archive); hex.EncodeToString(got[:]) != want {
+		return nil, fmt.Errorf("%s: checksum mismatch", name)
+	}
+	return Extract(archive, name, BinaryName(goos))
+}
+
+// Verify checks that sig, a base64 Ed25519 signature as
+// checksums.txt.sig holds it, signs sums under key.
+func Verify(key ed25519.PublicKey, sums, sig []byte) error {
+	raw, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(sig)))
+	if err != nil || !ed25519.Verify(key, sums, raw) {
+		return fmt.Errorf("%s: signature does not verify", ChecksumsAsset)
+	}
+	return nil
+}
+
+// get GETs url and returns its body, failing on any status but 200 OK.
+func (u *Updater) get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if u.UserAgent != "" {
+		req.Header.Set("User-Agent", u.UserAgent)
+	}
+	resp, err := u.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAssetBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxAssetBytes {
+		return nil, fmt.Errorf("%s: larger than the %d MiB limit", url, maxAssetBytes>>20)
+	}
+	return data, nil
+}
+
+// ArchiveName is the name of the release archive for version, goos,
+// and goarch: a zip on Windows and a gzipped tarball elsewhere.
+func ArchiveName(version, goos, goarch string) string {
+	ext := ".tar.gz"
+	if goos == "windows" {
+		ext = ".zip"
+	}
+	return "regolith_" + version + "_" + goos + "_" + goarch + ext
+}
+
+// BinaryName is the name of the executable in the archive for goos.
+func BinaryName(goos string) string {
+	if goos == "windows" {
+		return "regolith.exe"
+	}
+	return "regolith"
+}
+
+// Checksum returns the hex SHA-256 that sums, in sha256sum's
+// "<hex>  <name>" format, lists for name.
+func Checksum(sums []byte, name string) (string, error) {
+	sc := bufio.NewScanner(bytes.NewReader(sums))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("%s does not list %s", ChecksumsAsset, name)
+}
+
+// Extract returns the file called binary from the archive data, which
+// is a zip when name ends in .zip and a gzipped tarball otherwise.
+func Extract(data []byte, name, binary string) ([]byte, error) {
+	if strings.HasSuffix(name, ".zip") {
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		for _, f := range zr.File {
+			if path.Base(f.Name) != binary {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			defer func() { _ = rc.Close() }()
+			return io.ReadAll(io.LimitReader(rc, maxAssetBytes))
+		}
+		return nil, fmt.Errorf("%s has no %s", name, binary)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s has no %s", name, binary)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if hdr.Typeflag == tar.TypeReg && path.Base(hdr.Name) == binary {
+			return io.ReadAll(io.LimitReader(tr, maxAssetBytes))
+		}
+	}
+}
+
+// Replace swaps the executable at exe for data. The new binary is
+// written beside it and renamed over it, so a failed update leaves the
+// old one in place. Windows will not rename over a running executable,
+// so there the old one is first moved aside to exe.old, which the next
+// update removes.
+func Replace(exe string, data []byte) error {
+	info, err := os.Stat(exe)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(exe), "."+filepath.Base(exe)+".new-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()|0o111); err != nil {
+		return err
+	}
+	old := exe + ".old"
+	_ = os.Remove(old)
+	if err := os.Rename(tmp.Name(), exe); err == nil {
+		return nil
+	}
+	if err := os.Rename(exe, old); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), exe); err != nil {
+		_ = os.Rename(old, exe)
+		return err
+	}
+	return nil
+}
+
+// Newer reports whether version a is newer than b. Both are dotted
+// numbers, optionally with a "v" prefix and a "-" prerelease suffix,
+// which sorts before the release it precedes. A version that does not
+// parse is never newer.
+func Newer(a, b string) bool {
+	an, apre, aok := parseVersion(a)
+	bn, bpre, bok := parseVersion(b)
+	if !aok || !bok {
+		return false
+	}
+	for i := range an {
+		if an[i] != bn[i] {
+			return an[i] > bn[i]
+		}
+	}
+	return apre == "" && bpre != ""
+}
+
+// parseVersion splits v into its major, minor, and patch numbers and
+// its prerelease suffix.
+func parseVersion(v string) (nums [3]int, pre string, ok bool) {
+	v, pre, _ = strings.Cut(strings.TrimPrefix(v, "v"), "-")
+	parts := strings.Split(v, ".")
+	if len(parts) > 3 {
+		return nums, "", false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return nums, "", false
+		}
+		nums[i] = n
+	}
+	return nums, pre, true
+}
@@ -0,0 +1,221 @@
+package selfupdate
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// tarball returns a gzipped tarball holding one file.
+func tarball(t *testing.T, name string, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// releaseServer serves a latest release with a linux/amd64 archive
+// holding binary, its checksums, and their signature by key. tamper, if
+// set, edits the assets after they are signed.
+func releaseServer(t *testing.T, key ed25519.PrivateKey, binary []byte, tamper func(map[string][]byte)) *httptest.Server {
+	t.Helper()
+	name := ArchiveName("0.3.0", "linux", "amd64")
+	archive := tarball(t, "regolith", binary)
+	sum := sha256.Sum256(archive)
+	sums := []byte(hex.EncodeToString(sum[:]) + "  " + name + "\n")
+	assets := map[string][]byte{
+		name:           archive,
+		ChecksumsAsset: sums,
+		SignatureAsset: []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(key, sums)) + "\n"),
+	}
+	if tamper != nil {
+		tamper(assets)
+	}
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/latest" {
+			type asset struct {
+				Name string `json:"name"`
+				URL  string `json:"browser_download_url"`
+			}
+			var list []asset
+			for n := range assets {
+				list = append(list, asset{n, srv.URL + "/download/" + n})
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"tag_name": "v0.3.0", "assets": list})
+			return
+		}
+		data, ok := assets[strings.TrimPrefix(r.URL.Path, "/download/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestDownload(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary := []byte("#!/bin/sh\necho new\n")
+
+	tests := []struct {
+		name    string
+		tamper  func(map[string][]byte)
+		wantErr string
+	}{
+		{name: "valid"},
+		{
+			name: "bad signature",
+			tamper: func(a map[string][]byte) {
+				a[SignatureAsset] = []byte(base64.StdEncoding.EncodeToString(make([]byte, 64)))
+			},
+			wantErr: "signature does not verify",
+		},
+		{
+			name: "swapped archive",
+			tamper: func(a map[string][]byte) {
+				a[ArchiveName("0.3.0", "linux", "amd64")] = tarball(t, "regolith", []byte("evil"))
+			},
+			wantErr: "checksum mismatch",
+		},
+		{
+			name:    "unsigned",
+			tamper:  func(a map[string][]byte) { delete(a, SignatureAsset) },
+			wantErr: "has no checksums.txt.sig",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := releaseServer(t, priv, binary, tt.tamper)
+			u := &Updater{Client: srv.Client(), API: srv.URL + "/latest", PublicKey: pub}
+			rel, err := u.Latest(context.Background())
+			if err != nil {
+				t.Fatalf("Latest: %v", err)
+			}
+			if rel.Version != "0.3.0" {
+				t.Errorf("Version = %q, want 0.3.0", rel.Version)
+			}
+			got, err := u.Download(context.Background(), rel, "linux", "amd64")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Download error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Download: %v", err)
+			}
+			if !bytes.Equal(got, binary) {
+				t.Errorf("Download = %q, want %q", got, binary)
+			}
+		})
+	}
+}
+
+func TestExtractZip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("regolith_0.3.0_windows_amd64/regolith.exe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = w.Write([]byte("MZ"))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Extract(buf.Bytes(), "regolith_0.3.0_windows_amd64.zip", BinaryName("windows"))
+	if err != nil || string(got) != "MZ" {
+		t.Errorf("Extract = %q, %v; want MZ", got, err)
+	}
+	if _, err := Extract(buf.Bytes(), "x.zip", "regolith"); err == nil {
+		t.Error("Extract found a binary the archive lacks")
+	}
+}
+
+func TestReplace(t *testing.T) {
+	exe := filepath.Join(t.TempDir(), "regolith")
+	if err := os.WriteFile(exe, []byte("old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := Replace(exe, []byte("new")); err != nil {
+		t.Fatalf("Replace: %v", err)
+	}
+	got, err := os.ReadFile(exe)
+	if err != nil || string(got) != "new" {
+		t.Errorf("after Replace = %q, %v; want new", got, err)
+	}
+	info, _ := os.Stat(exe)
+	if info.Mode().Perm()&0100 == 0 {
+		t.Errorf("mode = %v, want executable", info.Mode())
+	}
+	entries, _ := os.ReadDir(filepath.Dir(exe))
+	if len(entries) != 1 {
+		t.Errorf("left %d files beside the binary, want none", len(entries)-1)
+	}
+}
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"0.3.0", "0.2.0", true},
+		{"v0.10.0", "0.9.9", true},
+		{"0.2.0", "0.2.0", false},
+		{"0.2.0", "0.3.0", false},
+		{"0.3.0", "0.3.0-rc1", true},
+		{"0.3.0-rc1", "0.3.0", false},
+		{"1.0", "0.9.1", true},
+		{"dev", "0.2.0", false},
+	}
+	for _, tt := range tests {
+		if got := Newer(tt.a, tt.b); got != tt.want {
+			t.Errorf("Newer(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	if _, err := ParsePublicKey(""); err == nil || !strings.Contains(err.Error(), "no release signing key") {
+		t.Errorf("ParsePublicKey(\"\") error = %v", err)
+	}
+	if _, err := ParsePublicKey("c2hvcnQ="); err == nil {
+		t.Error("ParsePublicKey accepted a short key")
+	}
+	pub, _, _ := ed25519.GenerateKey(nil)
+	key, err := ParsePublicKey(base64.StdEncoding.EncodeToString(pub))
+	if err != nil || !key.Equal(pub) {
+		t.Errorf("ParsePublicKey = %v, %v", key, err)
+	}
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"container/list"
+	"sync"
+)
+
+// cache is a fixed-capacity LRU of rendered response bodies. A capacity
+// of zero disables it: get always misses and add stores nothing.
+type cache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // Front is most recently used; values are *cacheEntry
+	entries  map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key  string
+	body []byte
+}
+
+func newCache(capacity int) *cache {
+	return &cache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// get returns the body stored under key and marks it recently used.
+func (c *cache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*cacheEntry).body, true
+}
+
+// add stores body under key and reports whether that pushed the least
+// recently used entry out.
+func (c *cache) add(key string, body []byte) (evicted bool) {
+	if c.capacity <= 0 {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		el.Value.(*cacheEntry).body = body
+		c.order.MoveToFront(el)
+		return false
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, body: body})
+	if c.order.Len() <= c.capacity {
+		return false
+	}
+	oldest := c.order.Back()
+	c.order.Remove(oldest)
+	delete(c.entries, oldest.Value.(*cacheEntry).key)
+	return true
+}
+
+// len returns the number of cached entries.
+func (c *cache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
@@ -0,0 +1,115 @@
+package server
+
+// Abuse limits for public deployments.
+//
+// A regex diagram service is cheap per request but takes arbitrary
+// user input, so a single client can pin every CPU with a stream of
+// large or pathological patterns. Four independent limits cover that:
+//
+//   - a per-client token bucket (RateLimit, RateBurst), answered with 429
+//   - a cap on renders in flight (MaxConcurrent), answered with 503
+//   - a per-render deadline (Timeout), answered with 504
+//   - a pattern length cap (MaxPatternLength), answered with 413
+//
+// Cache hits skip the concurrency cap and deadline since they do no
+// work. Parsing cannot be interrupted, so a render that misses its
+// deadline keeps running in the background; it holds its concurrency
+// slot until it finishes, which is what keeps timed-out work from
+// piling up unbounded.
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// limiter is a per-client token bucket. Each client starts with burst
+// tokens and regains rate tokens per second up to burst.
+type limiter struct {
+	mu        sync.Mutex
+	rate      float64
+	burst     float64
+	clients   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// sweepInterval is how often idle clients are dropped from the map.
+const sweepInterval = time.Minute
+
+func newLimiter(rate float64, burst int) *limiter {
+	if burst < 1 {
+		burst = int(math.Max(1, math.Ceil(rate)))
+	}
+	return &limiter{
+		rate:    rate,
+		burst:   float64(burst),
+		clients: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// allow takes a token for client. When none is left it reports how
+// long until one will be.
+func (l *limiter) allow(client string) (ok bool, retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.sweep(now)
+
+	b, exists := l.clients[client]
+	if !exists {
+		b = &bucket{tokens: l.burst, last: now}
+		l.clients[client] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := (1 - b.tokens) / l.rate
+	return false, time.Duration(math.Ceil(wait)) * time.Second
+}
+
+// sweep drops clients whose bucket has refilled completely, since a
+// fresh bucket would be identical. Without it the map grows by one
+// entry per distinct address ever seen.
+func (l *limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for client, b := range l.clients {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.clients, client)
+		}
+	}
+}
+
+// clientKey identifies the client for rate limiting. Behind a reverse
+// proxy every request arrives from the proxy's address, so with
+// trustProxy the left-most X-Forwarded-For entry is used instead. Only
+// enable that when the proxy overwrites the header; otherwise clients
+// can pick their own key.
+func clientKey(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+			first, _, _ := strings.Cut(xff, ",")
+			return strings.TrimSpace(first)
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,147 @@
+package server
+
+// Prometheus metrics for serve mode.
+//
+// The text exposition format is simple enough to write by hand, and
+// doing so keeps the Prometheus client library (and its dependency
+// tree) out of a binary that is mostly used as a CLI. Only the series
+// an operator needs for dashboards and alerts are exported:
+//
+//	regolith_http_requests_total{flavor,format,code}  counter
+//	regolith_request_duration_seconds{flavor}         histogram
+//	regolith_cache_{hits,misses,evictions}_total      counters
+//	regolith_cache_entries                            gauge
+//
+// Error rate is requests_total filtered on code, e.g.
+// sum(rate(regolith_http_requests_total{code=~"4..|5.."}[5m])).
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// durationBuckets are the histogram upper bounds in seconds. Most
+// patterns render in well under a millisecond; the long tail is what
+// the upper buckets are for.
+var durationBuckets = []float64{0.0005, 0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+
+type requestKey struct {
+	flavor, format string
+	code           int
+}
+
+type histogram struct {
+	counts []uint64 // Per-bucket (non-cumulative) counts; last slot is +Inf
+	sum    float64
+	total  uint64
+}
+
+type metrics struct {
+	mu        sync.Mutex
+	requests  map[requestKey]uint64
+	durations map[string]*histogram // By flavor
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		requests:  make(map[requestKey]uint64),
+		durations: make(map[string]*histogram),
+	}
+}
+
+func (m *metrics) observeRequest(flavor, format string, code int, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[requestKey{flavor, format, code}]++
+
+	h, ok := m.durations[flavor]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(durationBuckets)+1)}
+		m.durations[flavor] = h
+	}
+	secs := d.Seconds()
+	i := sort.SearchFloat64s(durationBuckets, secs)
+	h.counts[i]++
+	h.sum += secs
+	h.total++
+}
+
+func (m *metrics) observeCache(hit bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if hit {
+		m.hits++
+	} else {
+		m.misses++
+	}
+}
+
+func (m *metrics) observeEviction() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.evictions++
+}
+
+// write emits every series in the Prometheus text format. Series are
+// sorted so consecutive scrapes diff cleanly.
+func (m *metrics) write(w io.Writer, cacheEntries int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, _ = fmt.Fprintln(w, "# HELP regolith_http_requests_total Render requests by flavor, format, and HTTP status code.")
+	_, _ = fmt.Fprintln(w, "# TYPE regolith_http_requests_total counter")
+	keys := make([]requestKey, 0, len(m.requests))
+	for k := range m.requests {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.flavor != b.flavor {
+			return a.flavor < b.flavor
+		}
+		if a.format != b.format {
+			return a.format < b.format
+		}
+		return a.code < b.code
+	})
+	for _, k := range keys {
+		_, _ = fmt.Fprintf(w, "regolith_http_requests_total{flavor=%q,format=%q,code=\"%d\"} %d\n",
+			k.flavor, k.format, k.code, m.requests[k])
+	}
+
+	_, _ = fmt.Fprintln(w, "# HELP regolith_request_duration_seconds Render request latency by flavor.")
+	_, _ = fmt.Fprintln(w, "# TYPE regolith_request_duration_seconds histogram")
+	flavors := make([]string, 0, len(m.durations))
+	for f := range m.durations {
+		flavors = append(flavors, f)
+	}
+	sort.Strings(flavors)
+	for _, f := range flavors {
+		h := m.durations[f]
+		var cumulative uint64
+		for i, le := range durationBuckets {
+			cumulative += h.counts[i]
+			_, _ = fmt.Fprintf(w, "regolith_request_duration_seconds_bucket{flavor=%q,le=%q} %d\n",
+				f, strconv.FormatFloat(le, 'g', -1, 64), cumulative)
+		}
+		_, _ = fmt.Fprintf(w, "regolith_request_duration_seconds_bucket{flavor=%q,le=\"+Inf\"} %d\n", f, h.total)
+		_, _ = fmt.Fprintf(w, "regolith_request_duration_seconds_sum{flavor=%q} %s\n",
+			f, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		_, _ = fmt.Fprintf(w, "regolith_request_duration_seconds_count{flavor=%q} %d\n", f, h.total)
+	}
+
+	counter := func(name, help string, v uint64) {
+		_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", name, help, name, name, v)
+	}
+	counter("regolith_cache_hits_total", "Render requests answered from the response cache.", m.hits)
+	counter("regolith_cache_misses_total", "Render requests that had to parse and render.", m.misses)
+	counter("regolith_cache_evictions_total", "Cached responses dropped to make room for newer ones.", m.evictions)
+	_, _ = fmt.Fprintf(w, "# HELP regolith_cache_entries Responses currently cached.\n# TYPE regolith_cache_entries gauge\nregolith_cache_entries %d\n", cacheEntries)
+}
@@ -0,0 +1,337 @@
+// Package server implements `regolith serve`: an HTTP front end that
+// renders patterns on request so documentation sites and editor
+// plugins can fetch diagrams without shelling out to the CLI.
+//
+// Endpoints:
+//
+//	GET|POST /render   pattern, flavor, format (svg, json, text) as query
+//	                   or form values; returns the rendered body
+//	GET      /healthz  liveness probe; always "ok" while the process serves
+//	GET      /metrics  Prometheus text exposition (see metrics.go)
+//	POST     /share    store pattern, flavor, format, expires; returns
+//	                   a short /s/{id} URL as JSON (see share.go)
+//	GET      /s/{id}   renders a stored share
+//
+// Rendered bodies are cached by (flavor, format, pattern). A diagram is
+// a pure function of those three and the server-wide renderer config,
+// which is fixed for the life of the process.
+//
+// Every limit in Options is off at its zero value; `regolith serve`
+// turns them on with conservative defaults (see limits.go).
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"time"
+
+	"github.com/0x4d5352/regolith/internal/flavor"
+	"github.com/0x4d5352/regolith/internal/output"
+	"github.com/0x4d5352/regolith/internal/renderer"
+)
+
+// Options configures a Server.
+type Options struct {
+	DefaultFlavor string           // Flavor used when a request names none
+	Config        *renderer.Config // SVG styling shared by every request; nil uses renderer.DefaultConfig
+	CacheSize     int              // Maximum cached responses; 0 disables the cache
+
+	Flavors          []string      // Flavors clients may request; empty allows every registered flavor
+	MaxPatternLength int           // Longest accepted pattern in bytes; 0 is unlimited
+	MaxConcurrent    int           // Renders allowed in flight at once; 0 is unlimited
+	Timeout          time.Duration // Per-render deadline; 0 is none
+	RateLimit        float64       // Sustained requests per second per client; 0 is unlimited
+	RateBurst        int           // Requests a client may make at once before RateLimit applies
+	TrustProxy       bool          // Identify clients by X-Forwarded-For rather than the peer address
+
+	Shares      bool           // Enables POST /share and GET /s/{id}
+	ShareTTL    time.Duration  // Expiry of shares that do not ask for one; 0 keeps them until exit
+	MaxShareTTL time.Duration  // Longest expiry a share may have; 0 is unlimited
+	MaxShares   int            // Shares stored at once; 0 is unlimited
+	ShareAllow  []netip.Prefix // Clients that may create shares; empty allows every client
+	ShareDeny   []netip.Prefix // Clients that may not, whether or not ShareAllow matches
+	BaseURL     string         // Scheme and host of returned share URLs; empty uses the request's
+}
+
+// maxBodyBytes bounds POST bodies independently of MaxPatternLength, so
+// form parsing cannot be made to buffer arbitrary amounts of data.
+const maxBodyBytes = 1 << 20
+
+// Server is an http.Handler serving the regolith endpoints. It is safe
+// for concurrent use.
+type Server struct {
+	opts    Options
+	mux     *http.ServeMux
+	cache   *cache
+	metrics *metrics
+	allowed map[string]bool // nil when every flavor is allowed
+	limiter *limiter        // nil when RateLimit is off
+	slots   chan struct{}   // nil when MaxConcurrent is off
+	shares  *shareStore     // nil when Shares is off
+}
+
+// New returns a Server configured by opts.
+func New(opts Options) *Server {
+	if opts.DefaultFlavor == "" {
+		opts.DefaultFlavor = "javascript"
+	}
+	if opts.Config == nil {
+		opts.Config = renderer.DefaultConfig()
+	}
+	s := &Server{
+		opts:    opts,
+		mux:     http.NewServeMux(),
+		cache:   newCache(opts.CacheSize),
+		metrics: newMetrics(),
+	}
+	if len(opts.Flavors) > 0 {
+		s.allowed = make(map[string]bool, len(opts.Flavors))
+		for _, name := range opts.Flavors {
+			if f, ok := flavor.Get(name); ok {
+				name = f.Name()
+			}
+			s.allowed[name] = true
+		}
+	}
+	if opts.RateLimit > 0 {
+		s.limiter = newLimiter(opts.RateLimit, opts.RateBurst)
+	}
+	if opts.MaxConcurrent > 0 {
+		s.slots = make(chan struct{}, opts.MaxConcurrent)
+	}
+	s.mux.HandleFunc("/render", s.handleRender)
+	s.mux.HandleFunc("/healthz", s.handleHealth)
+	s.mux.HandleFunc("/metrics", s.handleMetrics)
+	if opts.Shares {
+		s.shares = newShareStore(opts.MaxShares)
+		s.mux.HandleFunc("/share", s.handleShare)
+		s.mux.HandleFunc("/s/", s.handleShared)
+	}
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// contentTypes maps each supported format to its response Content-Type.
+var contentTypes = map[string]string{
+	"svg":  "image/svg+xml",
+	"json": "application/json",
+	"text": "text/markdown; charset=utf-8",
+}
+
+// httpError is a render failure caused by the request rather than the
+// server. body, when set, replaces the plain-text message: parse errors
+// carry the validation JSON document locating the error.
+type httpError struct {
+	code int
+	msg  string
+	body []byte
+}
+
+func (e *httpError) Error() string { return e.msg }
+
+func (e *httpError) write(w http.ResponseWriter) {
+	if e.body == nil {
+		http.Error(w, e.msg, e.code)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(e.code)
+	_, _ = w.Write(e.body)
+}
+
+func badRequest(format string, args ...any) *httpError {
+	return &httpError{code: http.StatusBadRequest, msg: fmt.Sprintf(format, args...)}
+}
+
+func (s *Server) handleRender(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+
+	flavorName := r.FormValue("flavor")
+	if flavorName == "" {
+		flavorName = s.opts.DefaultFlavor
+	}
+	format := r.FormValue("format")
+	if format == "" {
+		format = "svg"
+	}
+	s.serveRender(w, r, flavorName, format, r.FormValue("pattern"))
+}
+
+// serveRender answers a render request, from /render or a share link,
+// through the rate limit, the cache and the render limits.
+func (s *Server) serveRender(w http.ResponseWriter, r *http.Request, flavorName, format, pattern string) {
+	start := time.Now()
+
+	// Unknown flavors and formats are folded into one label value so a
+	// client cycling through garbage names cannot blow up metric
+	// cardinality.
+	flavorLabel, formatLabel := "unknown", format
+	if f, ok := flavor.Get(flavorName); ok {
+		flavorLabel = f.Name()
+	}
+	if _, ok := contentTypes[format]; !ok {
+		formatLabel = "unknown"
+	}
+
+	status := http.StatusOK
+	defer func() { s.metrics.observeRequest(flavorLabel, formatLabel, status, time.Since(start)) }()
+	fail := func(e *httpError) {
+		status = e.code
+		e.write(w)
+	}
+
+	if s.limiter != nil {
+		if ok, retry := s.limiter.allow(clientKey(r, s.opts.TrustProxy)); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(retry/time.Second)))
+			fail(&httpError{code: http.StatusTooManyRequests, msg: "rate limit exceeded"})
+			return
+		}
+	}
+	if e := s.checkRequest(flavorName, format, pattern); e != nil {
+		fail(e)
+		return
+	}
+
+	key := flavorName + "\x00" + format + "\x00" + pattern
+	body, hit := s.cache.get(key)
+	s.metrics.observeCache(hit)
+	if !hit {
+		var err error
+		body, err = s.renderLimited(flavorName, format, pattern)
+		var herr *httpError
+		switch {
+		case errors.As(err, &herr):
+			fail(herr)
+			return
+		case err != nil:
+			fail(&httpError{code: http.StatusInternalServerError, msg: err.Error()})
+			return
+		}
+		if evicted := s.cache.add(key, body); evicted {
+			s.metrics.observeEviction()
+		}
+	}
+
+	w.Header().Set("Content-Type", contentTypes[format])
+	_, _ = w.Write(body)
+}
+
+// checkRequest validates the request parameters before any parsing is
+// attempted.
+func (s *Server) checkRequest(flavorName, format, pattern string) *httpError {
+	if pattern == "" {
+		return badRequest("missing pattern parameter")
+	}
+	if s.opts.MaxPatternLength > 0 && len(pattern) > s.opts.MaxPatternLength {
+		return &httpError{
+			code: http.StatusRequestEntityTooLarge,
+			msg:  fmt.Sprintf("pattern is %d bytes; the limit is %d", len(pattern), s.opts.MaxPatternLength),
+		}
+	}
+	f, ok := flavor.Get(flavorName)
+	if !ok {
+		return badRequest("unknown flavor %q", flavorName)
+	}
+	if s.allowed != nil && !s.allowed[f.Name()] {
+		return &httpError{code: http.StatusForbidden, msg: fmt.Sprintf("flavor %q is not enabled on this server", flavorName)}
+	}
+	if _, ok := contentTypes[format]; !ok {
+		return badRequest("unknown format %q (available: json, svg, text)", format)
+	}
+	return nil
+}
+
+// renderLimited runs render under the MaxConcurrent and Timeout limits.
+func (s *Server) renderLimited(flavorName, format, pattern string) ([]byte, error) {
+	release := func() {}
+	if s.slots != nil {
+		select {
+		case s.slots <- struct{}{}:
+			release = func() { <-s.slots }
+		default:
+			return nil, &httpError{code: http.StatusServiceUnavailable, msg: "server busy, try again shortly"}
+		}
+	}
+	if s.opts.Timeout <= 0 {
+		defer release()
+		return s.render(flavorName, format, pattern)
+	}
+
+	type result struct {
+		body []byte
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer release()
+		body, err := s.render(flavorName, format, pattern)
+		done <- result{body, err}
+	}()
+	timer := time.NewTimer(s.opts.Timeout)
+	defer timer.Stop()
+	select {
+	case res := <-done:
+		return res.body, res.err
+	case <-timer.C:
+		return nil, &httpError{code: http.StatusGatewayTimeout, msg: fmt.Sprintf("render exceeded %s", s.opts.Timeout)}
+	}
+}
+
+// render parses and renders one pattern whose parameters have passed
+// checkRequest. Parse errors come back as a 422 *httpError carrying
+// the validation JSON document.
+func (s *Server) render(flavorName, format, pattern string) ([]byte, error) {
+	f, _ := flavor.Get(flavorName)
+	root, err := f.Parse(pattern)
+	if err != nil {
+		info := output.ParseError(err)
+		offset := -1
+		if info.Line > 0 {
+			offset = info.Offset
+		}
+		info.Hints = flavor.Hints(f, pattern, offset)
+		doc, jerr := output.RenderValidationJSON(pattern, f.Name(), &info)
+		if jerr != nil {
+			return nil, jerr
+		}
+		return nil, &httpError{code: http.StatusUnprocessableEntity, msg: err.Error(), body: []byte(doc)}
+	}
+
+	switch format {
+	case "json":
+		doc, err := output.RenderJSON(root, pattern, f.Name())
+		if err != nil {
+			return nil, err
+		}
+		return []byte(doc), nil
+	case "text":
+		return []byte(output.RenderMarkdown(root, pattern, f.Name())), nil
+	default:
+		// Renderer carries per-render state, so each request gets its
+		// own; the Config it points at is only read.
+		r := renderer.New(s.opts.Config)
+		r.Flavor = f
+		return []byte(r.Render(root)), nil
+	}
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = fmt.Fprintln(w, "ok")
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	s.metrics.write(w, s.cache.len())
+}
@@ -0,0 +1,336 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/0x4d5352/regolith/internal/flavor/javascript"
+	_ "github.com/0x4d5352/regolith/internal/flavor/pcre"
+)
+
+func get(t *testing.T, h http.Handler, path string) (int, http.Header, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	body, _ := io.ReadAll(rec.Result().Body)
+	return rec.Code, rec.Header(), string(body)
+}
+
+func renderPath(pattern, flavor, format string) string {
+	q := url.Values{"pattern": {pattern}}
+	if flavor != "" {
+		q.Set("flavor", flavor)
+	}
+	if format != "" {
+		q.Set("format", format)
+	}
+	return "/render?" + q.Encode()
+}
+
+func TestRender(t *testing.T) {
+	s := New(Options{CacheSize: 8})
+
+	tests := []struct {
+		name        string
+		path        string
+		code        int
+		contentType string
+		contains    string
+	}{
+		{"svg default", renderPath("a+b", "", ""), 200, "image/svg+xml", "<svg"},
+		{"json", renderPath("a+b", "pcre", "json"), 200, "application/json", `"flavor": "pcre"`},
+		{"text", renderPath("a|b", "", "text"), 200, "text/markdown", "Alternation"},
+		{"parse error", renderPath("a(", "", ""), 422, "application/json", `"valid": false`},
+		{"missing pattern", "/render", 400, "text/plain", "missing pattern"},
+		{"unknown flavor", renderPath("a", "cobol", ""), 400, "text/plain", "unknown flavor"},
+		{"unknown format", renderPath("a", "", "png"), 400, "text/plain", "unknown format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, header, body := get(t, s, tt.path)
+			if code != tt.code {
+				t.Errorf("status = %d, want %d (body %q)", code, tt.code, body)
+			}
+			if ct := header.Get("Content-Type"); !strings.HasPrefix(ct, tt.contentType) {
+				t.Errorf("Content-Type = %q, want prefix %q", ct, tt.contentType)
+			}
+			if !strings.Contains(body, tt.contains) {
+				t.Errorf("body missing %q:\n%s", tt.contains, body)
+			}
+		})
+	}
+}
+
+func TestRenderMethodNotAllowed(t *testing.T) {
+	rec := httptest.NewRecorder()
+	New(Options{}).ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/render?pattern=a", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want 405", rec.Code)
+	}
+}
+
+func TestHealthz(t *testing.T) {
+	code, _, body := get(t, New(Options{}), "/healthz")
+	if code != 200 || body != "ok\n" {
+		t.Errorf("healthz = %d %q", code, body)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	s := New(Options{CacheSize: 1})
+	get(t, s, renderPath("a", "", ""))
+	get(t, s, renderPath("a", "", "")) // hit
+	get(t, s, renderPath("b", "", "")) // evicts a
+	get(t, s, renderPath("a(", "", ""))
+	get(t, s, renderPath("a", "cobol", ""))
+
+	_, header, body := get(t, s, "/metrics")
+	if ct := header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	for _, want := range []string{
+		`regolith_http_requests_total{flavor="javascript",format="svg",code="200"} 3`,
+		`regolith_http_requests_total{flavor="javascript",format="svg",code="422"} 1`,
+		`regolith_http_requests_total{flavor="unknown",format="svg",code="400"} 1`,
+		`regolith_request_duration_seconds_bucket{flavor="javascript",le="+Inf"} 4`,
+		`regolith_request_duration_seconds_count{flavor="javascript"} 4`,
+		"# TYPE regolith_request_duration_seconds histogram",
+		"regolith_cache_hits_total 1",
+		"regolith_cache_misses_total 3",
+		"regolith_cache_evictions_total 1",
+		"regolith_cache_entries 1",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestCacheLRU(t *testing.T) {
+	c := newCache(2)
+	c.add("a", []byte("1"))
+	c.add("b", []byte("2"))
+	c.get("a") // b is now least recently used
+	if !c.add("c", []byte("3")) {
+		t.Error("expected eviction when exceeding capacity")
+	}
+	if _, ok := c.get("b"); ok {
+		t.Error("b should have been evicted")
+	}
+	if _, ok := c.get("a"); !ok {
+		t.Error("a should still be cached")
+	}
+
+	off := newCache(0)
+	off.add("a", []byte("1"))
+	if _, ok := off.get("a"); ok || off.len() != 0 {
+		t.Error("zero-capacity cache must not store entries")
+	}
+}
+
+func TestLimits(t *testing.T) {
+	t.Run("pattern length", func(t *testing.T) {
+		s := New(Options{MaxPatternLength: 4})
+		if code, _, _ := get(t, s, renderPath("abcd", "", "")); code != 200 {
+			t.Errorf("pattern at limit: status %d", code)
+		}
+		if code, _, _ := get(t, s, renderPath("abcde", "", "")); code != http.StatusRequestEntityTooLarge {
+			t.Errorf("pattern over limit: status %d, want 413", code)
+		}
+	})
+
+	t.Run("flavor allowlist", func(t *testing.T) {
+		s := New(Options{Flavors: []string{"pcre"}, DefaultFlavor: "pcre"})
+		if code, _, _ := get(t, s, renderPath("a", "pcre", "")); code != 200 {
+			t.Errorf("allowed flavor: status %d", code)
+		}
+		if code, _, _ := get(t, s, renderPath("a", "javascript", "")); code != http.StatusForbidden {
+			t.Errorf("disallowed flavor: status %d, want 403", code)
+		}
+	})
+
+	t.Run("rate limit", func(t *testing.T) {
+		s := New(Options{RateLimit: 1, RateBurst: 2})
+		for i := 0; i < 2; i++ {
+			if code, _, _ := get(t, s, renderPath("a", "", "")); code != 200 {
+				t.Fatalf("request %d within burst: status %d", i, code)
+			}
+		}
+		code, header, _ := get(t, s, renderPath("a", "", ""))
+		if code != http.StatusTooManyRequests {
+			t.Fatalf("status %d, want 429", code)
+		}
+		if header.Get("Retry-After") != "1" {
+			t.Errorf("Retry-After = %q, want 1", header.Get("Retry-After"))
+		}
+	})
+
+	t.Run("concurrency", func(t *testing.T) {
+		s := New(Options{MaxConcurrent: 1})
+		s.slots <- struct{}{} // occupy the only slot
+		if code, _, _ := get(t, s, renderPath("a", "", "")); code != http.StatusServiceUnavailable {
+			t.Errorf("status %d, want 503", code)
+		}
+		<-s.slots
+		if code, _, _ := get(t, s, renderPath("a", "", "")); code != 200 {
+			t.Errorf("after release: status %d", code)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		s := New(Options{Timeout: time.Nanosecond, MaxConcurrent: 1})
+		code, _, _ := get(t, s, renderPath(strings.Repeat("(a|b)+", 200), "", ""))
+		if code != http.StatusGatewayTimeout {
+			t.Errorf("status %d, want 504", code)
+		}
+	})
+}
+
+func TestLimiterRefill(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newLimiter(2, 1)
+	l.now = func() time.Time { return now }
+	if ok, _ := l.allow("x"); !ok {
+		t.Fatal("first request must pass")
+	}
+	if ok, _ := l.allow("x"); ok {
+		t.Fatal("second immediate request must be limited")
+	}
+	if ok, _ := l.allow("y"); !ok {
+		t.Error("clients must be limited independently")
+	}
+	now = now.Add(500 * time.Millisecond)
+	if ok, _ := l.allow("x"); !ok {
+		t.Error("bucket should refill at rate")
+	}
+
+	now = now.Add(2 * sweepInterval)
+	l.allow("z")
+	if _, ok := l.clients["x"]; ok {
+		t.Error("idle clients should be swept")
+	}
+}
+
+func TestClientKey(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/render", nil)
+	r.RemoteAddr = "10.0.0.1:5555"
+	r.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+	if got := clientKey(r, false); got != "10.0.0.1" {
+		t.Errorf("untrusted: got %q", got)
+	}
+	if got := clientKey(r, true); got != "203.0.113.7" {
+		t.Errorf("trusted proxy: got %q", got)
+	}
+}
+
+func post(t *testing.T, h http.Handler, path string, form url.Values, remoteAddr string) (int, http.Header, string) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if remoteAddr != "" {
+		req.RemoteAddr = remoteAddr
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	body, _ := io.ReadAll(rec.Result().Body)
+	return rec.Code, rec.Header(), string(body)
+}
+
+func TestShare(t *testing.T) {
+	s := New(Options{Shares: true, BaseURL: "https://regex.example.com/"})
+	code, header, body := post(t, s, "/share", url.Values{"pattern": {"a+b"}, "flavor": {"pcre"}, "expires": {"1h"}}, "")
+	if code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201 (body %q)", code, body)
+	}
+	var resp shareResponse
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		t.Fatalf("invalid JSON %q: %v", body, err)
+	}
+	if resp.URL != "https://regex.example.com/s/"+resp.ID || header.Get("Location") != resp.URL {
+		t.Errorf("url = %q, Location = %q", resp.URL, header.Get("Location"))
+	}
+	if resp.Expires == "" {
+		t.Error("expected an expiry for expires=1h")
+	}
+
+	if code, header, body := get(t, s, "/s/"+resp.ID); code != 200 || header.Get("Content-Type") != "image/svg+xml" || !strings.Contains(body, "<svg") {
+		t.Errorf("share = %d %q", code, header.Get("Content-Type"))
+	}
+	if _, _, body := get(t, s, "/s/"+resp.ID+"?format=json"); !strings.Contains(body, `"flavor": "pcre"`) {
+		t.Errorf("format override ignored:\n%s", body)
+	}
+	if code, _, _ := get(t, s, "/s/nope"); code != http.StatusNotFound {
+		t.Errorf("unknown share: status %d, want 404", code)
+	}
+
+	s.shares.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if code, _, _ := get(t, s, "/s/"+resp.ID); code != http.StatusGone {
+		t.Errorf("expired share: status %d, want 410", code)
+	}
+}
+
+func TestShareLimits(t *testing.T) {
+	t.Run("off by default", func(t *testing.T) {
+		if code, _, _ := post(t, New(Options{}), "/share", url.Values{"pattern": {"a"}}, ""); code != http.StatusNotFound {
+			t.Errorf("status %d, want 404", code)
+		}
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		s := New(Options{Shares: true, MaxShareTTL: time.Hour})
+		if code, _, _ := post(t, s, "/share", url.Values{"pattern": {"a"}, "expires": {"2h"}}, ""); code != http.StatusBadRequest {
+			t.Errorf("expiry over limit: status %d, want 400", code)
+		}
+		if code, _, _ := post(t, s, "/share", url.Values{"pattern": {"a"}, "expires": {"soon"}}, ""); code != http.StatusBadRequest {
+			t.Errorf("malformed expiry: status %d, want 400", code)
+		}
+		_, _, body := post(t, s, "/share", url.Values{"pattern": {"a"}}, "")
+		if !strings.Contains(body, `"expires"`) {
+			t.Errorf("default expiry should be capped at MaxShareTTL:\n%s", body)
+		}
+	})
+
+	t.Run("store full", func(t *testing.T) {
+		s := New(Options{Shares: true, MaxShares: 1})
+		post(t, s, "/share", url.Values{"pattern": {"a"}}, "")
+		if code, _, _ := post(t, s, "/share", url.Values{"pattern": {"b"}}, ""); code != http.StatusInsufficientStorage {
+			t.Errorf("status %d, want 507", code)
+		}
+	})
+
+	t.Run("request checks", func(t *testing.T) {
+		s := New(Options{Shares: true, Flavors: []string{"pcre"}, DefaultFlavor: "pcre"})
+		if code, _, _ := post(t, s, "/share", url.Values{"pattern": {"a"}, "flavor": {"javascript"}}, ""); code != http.StatusForbidden {
+			t.Errorf("disallowed flavor: status %d, want 403", code)
+		}
+		if code, _, _ := get(t, s, "/share?pattern=a"); code != http.StatusMethodNotAllowed {
+			t.Errorf("GET /share: status %d, want 405", code)
+		}
+	})
+
+	t.Run("allow and deny", func(t *testing.T) {
+		s := New(Options{
+			Shares:     true,
+			ShareAllow: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+			ShareDeny:  []netip.Prefix{netip.MustParsePrefix("10.0.9.0/24")},
+		})
+		for addr, want := range map[string]int{
+			"10.1.2.3:4000":      http.StatusCreated,
+			"10.0.9.7:4000":      http.StatusForbidden,
+			"192.0.2.1:4000":     http.StatusForbidden,
+			"[::1]:4000":         http.StatusForbidden,
+			"[::ffff:a01:203]:1": http.StatusCreated,
+		} {
+			if code, _, _ := post(t, s, "/share", url.Values{"pattern": {"a"}}, addr); code != want {
+				t.Errorf("%s: status %d, want %d", addr, code, want)
+			}
+		}
+	})
+}
@@ -0,0 +1,277 @@
+package server
+
+// Share links.
+//
+// POST /share stores a pattern with its flavor and format and answers
+// with a short URL, /s/{id}, that renders it: a lightweight way for a
+// team to pass a regex around without pasting it into a chat. Shares
+// live in memory for the life of the process, so a restart forgets
+// them; that is the point of keeping the service this small.
+//
+// Shares are off unless Options.Shares is set. Who may create them is
+// controlled by ShareAllow and ShareDeny, matched against the same
+// client address the rate limiter uses; viewing a share is open to
+// anyone who may call /render.
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// share is one stored pattern. A zero expires never expires.
+type share struct {
+	pattern, flavor, format string
+	expires                 time.Time
+}
+
+func (sh *share) expired(now time.Time) bool {
+	return !sh.expires.IsZero() && !now.Before(sh.expires)
+}
+
+// errSharesFull is returned by shareStore.add when MaxShares live
+// shares are already stored.
+var errSharesFull = errors.New("share store is full")
+
+// shareStore holds shares by id. Expired shares are dropped when they
+// are looked up, and all at once when the store fills up.
+type shareStore struct {
+	mu     sync.Mutex
+	limit  int // 0 is unlimited
+	shares map[string]*share
+	now    func() time.Time
+}
+
+func newShareStore(limit int) *shareStore {
+	return &shareStore{limit: limit, shares: make(map[string]*share), now: time.Now}
+}
+
+// add stores sh under a new random id.
+func (st *shareStore) add(sh *share) (string, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.limit > 0 && len(st.shares) >= st.limit {
+		now := st.now()
+		for id, old := range st.shares {
+			if old.expired(now) {
+				delete(st.shares, id)
+			}
+		}
+		if len(st.shares) >= st.limit {
+			return "", errSharesFull
+		}
+	}
+	for {
+		id, err := newShareID()
+		if err != nil {
+			return "", err
+		}
+		if _, taken := st.shares[id]; !taken {
+			st.shares[id] = sh
+			return id, nil
+		}
+	}
+}
+
+// get returns the share stored under id. gone reports a share that
+// existed but has expired, so the caller can answer 410 rather than
+// 404.
+func (st *shareStore) get(id string) (sh *share, gone bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	sh, ok := st.shares[id]
+	if !ok {
+		return nil, false
+	}
+	if sh.expired(st.now()) {
+		delete(st.shares, id)
+		return nil, true
+	}
+	return sh, false
+}
+
+// newShareID returns 48 random bits in URL-safe base64: eight
+// characters, too many to guess at any rate limit worth running.
+func newShareID() (string, error) {
+	var b [6]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("share id: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b[:]), nil
+}
+
+// shareResponse is the JSON body answering POST /share.
+type shareResponse struct {
+	ID      string `json:"id"`
+	URL     string `json:"url"`
+	Expires string `json:"expires,omitempty"` // RFC 3339; omitted when the share never expires
+}
+
+func (s *Server) handleShare(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+
+	client := clientKey(r, s.opts.TrustProxy)
+	if !s.mayShare(client) {
+		http.Error(w, "this client may not create share links", http.StatusForbidden)
+		return
+	}
+	if s.limiter != nil {
+		if ok, retry := s.limiter.allow(client); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(retry/time.Second)))
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+	}
+
+	sh := &share{
+		pattern: r.FormValue("pattern"),
+		flavor:  r.FormValue("flavor"),
+		format:  r.FormValue("format"),
+	}
+	if sh.flavor == "" {
+		sh.flavor = s.opts.DefaultFlavor
+	}
+	if sh.format == "" {
+		sh.format = "svg"
+	}
+	if e := s.checkRequest(sh.flavor, sh.format, sh.pattern); e != nil {
+		e.write(w)
+		return
+	}
+	ttl, e := s.shareTTL(r.FormValue("expires"))
+	if e != nil {
+		e.write(w)
+		return
+	}
+	if ttl > 0 {
+		sh.expires = s.shares.now().Add(ttl)
+	}
+
+	id, err := s.shares.add(sh)
+	switch {
+	case errors.Is(err, errSharesFull):
+		http.Error(w, "share store is full, try again later", http.StatusInsufficientStorage)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := shareResponse{ID: id, URL: s.baseURL(r) + "/s/" + id}
+	if !sh.expires.IsZero() {
+		resp.Expires = sh.expires.UTC().Format(time.RFC3339)
+	}
+	body, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", resp.URL)
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(append(body, '\n'))
+}
+
+// handleShared renders the share named by the path. A format query
+// value overrides the stored one, so /s/{id}?format=json works too.
+func (s *Server) handleShared(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/s/")
+	sh, gone := s.shares.get(id)
+	switch {
+	case gone:
+		http.Error(w, "share link has expired", http.StatusGone)
+		return
+	case sh == nil:
+		http.NotFound(w, r)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = sh.format
+	}
+	s.serveRender(w, r, sh.flavor, format, sh.pattern)
+}
+
+// mayShare applies ShareAllow and ShareDeny to a client address. A
+// client whose address does not parse, such as a garbled
+// X-Forwarded-For entry, is only let through when neither list is set.
+func (s *Server) mayShare(client string) bool {
+	if len(s.opts.ShareAllow) == 0 && len(s.opts.ShareDeny) == 0 {
+		return true
+	}
+	addr, err := netip.ParseAddr(client)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range s.opts.ShareDeny {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	if len(s.opts.ShareAllow) == 0 {
+		return true
+	}
+	for _, p := range s.opts.ShareAllow {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// shareTTL resolves the expires form value, a Go duration such as
+// "24h", against ShareTTL and MaxShareTTL.
+func (s *Server) shareTTL(expires string) (time.Duration, *httpError) {
+	ttl := s.opts.ShareTTL
+	if expires != "" {
+		d, err := time.ParseDuration(expires)
+		if err != nil || d <= 0 {
+			return 0, badRequest("invalid expires %q: want a positive duration such as 24h", expires)
+		}
+		if limit := s.opts.MaxShareTTL; limit > 0 && d > limit {
+			return 0, badRequest("expires %s is longer than this server allows (%s)", d, limit)
+		}
+		return d, nil
+	}
+	if limit := s.opts.MaxShareTTL; limit > 0 && (ttl == 0 || ttl > limit) {
+		ttl = limit
+	}
+	return ttl, nil
+}
+
+// baseURL is the scheme and host share URLs start with: BaseURL when
+// set, otherwise what the request was addressed to.
+func (s *Server) baseURL(r *http.Request) string {
+	if s.opts.BaseURL != "" {
+		return strings.TrimSuffix(s.opts.BaseURL, "/")
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if s.opts.TrustProxy {
+		if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+			scheme = proto
+		}
+	}
+	return scheme + "://" + r.Host
+}
@@ -0,0 +1,304 @@
+package svgtest
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Change is one difference Diff found between two SVG documents.
+type Change struct {
+	// Kind is "changed" for an element present in both documents with
+	// different attributes or text, "added" for one only in the new
+	// document, and "removed" for one only in the old.
+	Kind string
+	// Path names the element as Compare does, in the new document for
+	// changed and added elements and in the old one for removed ones.
+	Path string
+	// Detail describes a changed element's first differences, e.g.
+	// `x="11", was "10"`; it is empty for added and removed elements.
+	Detail string
+}
+
+func (c Change) String() string {
+	if c.Detail == "" {
+		return c.Kind + " " + c.Path
+	}
+	return c.Kind + " " + c.Path + ": " + c.Detail
+}
+
+// Diff is the full structural comparison of two SVG documents: every
+// difference, where Compare stops at the first. Children are aligned
+// by element name and class before they are compared, so one inserted
+// box reports as one addition rather than a change to every sibling
+// after it.
+type Diff struct {
+	Changes []Change
+
+	old, new *node
+	// marks records the change kind of each element in either tree.
+	marks map[*node]string
+	tol   float64
+}
+
+// Compute diffs old against new. Numbers are compared to within the
+// tolerance in opts, as in Compare.
+func Compute(old, new []byte, opts Options) (*Diff, error) {
+	tol := opts.Tolerance
+	if tol == 0 {
+		tol = DefaultTolerance
+	}
+	o, err := parse(old)
+	if err != nil {
+		return nil, fmt.Errorf("old: %w", err)
+	}
+	n, err := parse(new)
+	if err != nil {
+		return nil, fmt.Errorf("new: %w", err)
+	}
+	d := &Diff{old: o, new: n, marks: make(map[*node]string), tol: tol}
+	if o.name != n.name {
+		d.mark(o, "removed", o.name, "")
+		d.mark(n, "added", n.name, "")
+	} else {
+		d.diffNodes(o, n, n.name, o.name)
+	}
+	return d, nil
+}
+
+func (d *Diff) mark(n *node, kind, path, detail string) {
+	d.marks[n] = kind
+	d.Changes = append(d.Changes, Change{Kind: kind, Path: path, Detail: detail})
+}
+
+func (d *Diff) diffNodes(old, new *node, path, oldPath string) {
+	if detail := d.nodeDetail(old, new); detail != "" {
+		d.mark(new, "changed", path, detail)
+		d.marks[old] = "changed"
+	}
+	pairs := alignChildren(old.children, new.children)
+	seenNew, seenOld := make(map[string]int), make(map[string]int)
+	for _, p := range pairs {
+		var childPath, childOldPath string
+		if p.new != nil {
+			seenNew[p.new.name]++
+			childPath = fmt.Sprintf("%s/%s[%d]", path, p.new.name, seenNew[p.new.name])
+		}
+		if p.old != nil {
+			seenOld[p.old.name]++
+			childOldPath = fmt.Sprintf("%s/%s[%d]", oldPath, p.old.name, seenOld[p.old.name])
+		}
+		switch {
+		case p.old == nil:
+			d.mark(p.new, "added", childPath, "")
+		case p.new == nil:
+			d.mark(p.old, "removed", childOldPath, "")
+		default:
+			d.diffNodes(p.old, p.new, childPath, childOldPath)
+		}
+	}
+}
+
+// nodeDetail describes how old and new differ in their own attributes
+// and text, ignoring children, or returns "" when they do not.
+func (d *Diff) nodeDetail(old, new *node) string {
+	var parts []string
+	names := make([]string, 0, len(old.attrs)+len(new.attrs))
+	for name := range new.attrs {
+		names = append(names, name)
+	}
+	for name := range old.attrs {
+		if _, ok := new.attrs[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		o, ook := old.attrs[name]
+		n, nok := new.attrs[name]
+		switch {
+		case !ook:
+			parts = append(parts, fmt.Sprintf("%s=%q added", name, n))
+		case !nok:
+			parts = append(parts, fmt.Sprintf("%s=%q removed", name, o))
+		case !equalValues(n, o, d.tol):
+			parts = append(parts, fmt.Sprintf("%s=%q, was %q", name, n, o))
+		}
+	}
+	ot, nt := strings.TrimSpace(old.text), strings.TrimSpace(new.text)
+	if !equalValues(nt, ot, d.tol) {
+		parts = append(parts, fmt.Sprintf("text %q, was %q", nt, ot))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// pair is an aligned child: both set for a match, one nil for an
+// element only one side has.
+type pair struct {
+	old, new *node
+}
+
+// alignChildren pairs old and new children along their longest common
+// subsequence of element name and class, keeping document order, so
+// renderer output where one node was inserted or removed still lines
+// the other siblings up.
+func alignChildren(old, new []*node) []pair {
+	key := func(n *node) string { return n.name + "." + n.attrs["class"] }
+	// lcs[i][j] is the LCS length of old[i:] and new[j:].
+	lcs := make([][]int, len(old)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(new)+1)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(new) - 1; j >= 0; j-- {
+			if key(old[i]) == key(new[j]) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var pairs []pair
+	i, j := 0, 0
+	for i < len(old) && j < len(new) {
+		switch {
+		case key(old[i]) == key(new[j]):
+			pairs = append(pairs, pair{old[i], new[j]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			pairs = append(pairs, pair{old: old[i]})
+			i++
+		default:
+			pairs = append(pairs, pair{new: new[j]})
+			j++
+		}
+	}
+	for ; i < len(old); i++ {
+		pairs = append(pairs, pair{old: old[i]})
+	}
+	for ; j < len(new); j++ {
+		pairs = append(pairs, pair{new: new[j]})
+	}
+	return pairs
+}
+
+// Overlay colors: changed elements in amber, added in green, and the
+// old version of changed and removed elements as a red ghost. Text is
+// recolored rather than outlined so it stays readable.
+const overlayStyle = `
+		.svgdiff-changed, .svgdiff-changed * { stroke: #f59e0b !important; stroke-width: 3px !important; }
+		.svgdiff-added, .svgdiff-added * { stroke: #16a34a !important; stroke-width: 3px !important; }
+		.svgdiff-changed text, text.svgdiff-changed, .svgdiff-changed tspan, tspan.svgdiff-changed { fill: #b45309 !important; stroke: none !important; }
+		.svgdiff-added text, text.svgdiff-added, .svgdiff-added tspan, tspan.svgdiff-added { fill: #15803d !important; stroke: none !important; }
+		.svgdiff-old * { fill: none !important; stroke: #dc2626 !important; stroke-width: 1.5px !important; stroke-dasharray: 4 3; }
+		.svgdiff-old text, .svgdiff-old tspan { fill: #dc2626 !important; stroke: none !important; }
+	`
+
+// Overlay returns an SVG document for reviewing the diff: the new
+// document, with changed elements outlined in amber and added ones in
+// green, drawn over a dashed red ghost of the old version of every
+// changed or removed element. Unchanged parts of the old document are
+// hidden, so only what moved or disappeared shows through. The canvas
+// is large enough for both documents.
+func (d *Diff) Overlay() []byte {
+	var b bytes.Buffer
+	root := d.new
+	attrs := make(map[string]string, len(root.attrs))
+	for k, v := range root.attrs {
+		attrs[k] = v
+	}
+	if root.name == "svg" && d.old.name == "svg" {
+		w := math.Max(attrNumber(root, "width"), attrNumber(d.old, "width"))
+		h := math.Max(attrNumber(root, "height"), attrNumber(d.old, "height"))
+		if w > 0 && h > 0 {
+			attrs["width"], attrs["height"] = formatNumber(w), formatNumber(h)
+			attrs["viewBox"] = "0 0 " + formatNumber(w) + " " + formatNumber(h)
+		}
+	}
+	attrs["xmlns"] = "http://www.w3.org/2000/svg"
+	b.WriteString("<svg")
+	writeAttrs(&b, attrs, "")
+	b.WriteString(">")
+	b.WriteString("<style>" + overlayStyle + "</style>")
+
+	// The old layer is hidden as a whole, and each changed or removed
+	// element is made visible again. Its <style> and <defs> are left
+	// out: the ghost is drawn entirely by overlayStyle, and the new
+	// document's marker ids must stay unique.
+	b.WriteString(`<g class="svgdiff-old" visibility="hidden">`)
+	for _, c := range d.old.children {
+		if c.name == "style" || c.name == "defs" {
+			continue
+		}
+		d.writeNode(&b, c, true)
+	}
+	b.WriteString("</g>")
+	for _, c := range d.new.children {
+		d.writeNode(&b, c, false)
+	}
+	b.WriteString("</svg>\n")
+	return b.Bytes()
+}
+
+func (d *Diff) writeNode(b *bytes.Buffer, n *node, old bool) {
+	b.WriteString("<" + n.name)
+	kind := d.marks[n]
+	switch {
+	case old && kind != "":
+		writeAttrs(b, n.attrs, "")
+		b.WriteString(` visibility="visible"`)
+	case !old && kind != "":
+		writeAttrs(b, n.attrs, "svgdiff-"+kind)
+	default:
+		writeAttrs(b, n.attrs, "")
+	}
+	b.WriteString(">")
+	if text := strings.TrimSpace(n.text); text != "" || n.name == "style" {
+		if n.name == "style" {
+			text = n.text
+		}
+		_ = xml.EscapeText(b, []byte(text))
+	}
+	for _, c := range n.children {
+		d.writeNode(b, c, old)
+	}
+	b.WriteString("</" + n.name + ">")
+}
+
+// writeAttrs writes attrs in name order, adding class to any existing
+// class attribute.
+func writeAttrs(b *bytes.Buffer, attrs map[string]string, class string) {
+	names := make([]string, 0, len(attrs)+1)
+	for name := range attrs {
+		names = append(names, name)
+	}
+	if _, ok := attrs["class"]; !ok && class != "" {
+		names = append(names, "class")
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		v := attrs[name]
+		if name == "class" && class != "" {
+			v = strings.TrimSpace(v + " " + class)
+		}
+		b.WriteString(" " + name + `="`)
+		_ = xml.EscapeText(b, []byte(v))
+		b.WriteString(`"`)
+	}
+}
+
+// attrNumber returns the leading number of a length attribute, or 0.
+func attrNumber(n *node, name string) float64 {
+	m := number.FindString(n.attrs[name])
+	v, _ := strconv.ParseFloat(m, 64)
+	return v
+}
+
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
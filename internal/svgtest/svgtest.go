@@ -0,0 +1,189 @@
+// Package svgtest compares SVG documents by structure rather than by
+// bytes, for golden tests. Two documents match when they have the same
+// elements in the same order, the same attributes on each, and the same
+// text, with numbers compared to within a tolerance. Attribute order,
+// insignificant whitespace, comments (the provenance comment included),
+// and float formatting noise such as 12.5 versus 12.500001 no longer
+// force a mass golden update; a moved box, a missing element, or a
+// changed color still fails.
+//
+// Compute goes further for reviewing a golden update: it lists every
+// difference rather than the first, and draws them as an overlay SVG
+// (see `regolith svgdiff`).
+package svgtest
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultTolerance is the largest difference between two numbers that
+// still counts as equal: far below a pixel, far above float noise.
+const DefaultTolerance = 0.01
+
+// Options tunes Compare.
+type Options struct {
+	// Tolerance is the numeric tolerance; zero means DefaultTolerance.
+	Tolerance float64
+}
+
+// node is one parsed SVG element.
+type node struct {
+	name     string
+	attrs    map[string]string
+	text     string
+	children []*node
+}
+
+// Compare reports the first structural difference between got and
+// want, or nil if they match. The error names the element by its path
+// from the root, e.g. svg/g[2]/rect[1], with 1-based positions among
+// siblings of the same name.
+func Compare(got, want []byte, opts Options) error {
+	tol := opts.Tolerance
+	if tol == 0 {
+		tol = DefaultTolerance
+	}
+	g, err := parse(got)
+	if err != nil {
+		return fmt.Errorf("got: %w", err)
+	}
+	w, err := parse(want)
+	if err != nil {
+		return fmt.Errorf("want: %w", err)
+	}
+	return compareNodes(g, w, g.name, tol)
+}
+
+// parse reads an SVG document into a tree of elements.
+func parse(data []byte) (*node, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var stack []*node
+	var root *node
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &node{name: t.Name.Local, attrs: make(map[string]string, len(t.Attr))}
+			for _, a := range t.Attr {
+				n.attrs[a.Name.Local] = a.Value
+			}
+			if len(stack) == 0 {
+				root = n
+			} else {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(t)
+			}
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("no root element")
+	}
+	return root, nil
+}
+
+func compareNodes(got, want *node, path string, tol float64) error {
+	if got.name != want.name {
+		return fmt.Errorf("%s: element <%s>, want <%s>", path, got.name, want.name)
+	}
+	if err := compareAttrs(got.attrs, want.attrs, path, tol); err != nil {
+		return err
+	}
+	if !equalValues(strings.TrimSpace(got.text), strings.TrimSpace(want.text), tol) {
+		return fmt.Errorf("%s: text %q, want %q", path, strings.TrimSpace(got.text), strings.TrimSpace(want.text))
+	}
+
+	seen := make(map[string]int)
+	for i := 0; i < len(got.children) || i < len(want.children); i++ {
+		switch {
+		case i >= len(got.children):
+			return fmt.Errorf("%s: missing child <%s> at position %d", path, want.children[i].name, i+1)
+		case i >= len(want.children):
+			return fmt.Errorf("%s: unexpected child <%s> at position %d", path, got.children[i].name, i+1)
+		}
+		name := want.children[i].name
+		seen[name]++
+		childPath := fmt.Sprintf("%s/%s[%d]", path, name, seen[name])
+		if err := compareNodes(got.children[i], want.children[i], childPath, tol); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func compareAttrs(got, want map[string]string, path string, tol float64) error {
+	names := make([]string, 0, len(want)+len(got))
+	for name := range want {
+		names = append(names, name)
+	}
+	for name := range got {
+		if _, ok := want[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		g, gok := got[name]
+		w, wok := want[name]
+		switch {
+		case !gok:
+			return fmt.Errorf("%s: missing attribute %s=%q", path, name, w)
+		case !wok:
+			return fmt.Errorf("%s: unexpected attribute %s=%q", path, name, g)
+		case !equalValues(g, w, tol):
+			return fmt.Errorf("%s: %s=%q, want %q", path, name, g, w)
+		}
+	}
+	return nil
+}
+
+// number matches the numbers inside attribute values and text: plain
+// coordinates, path data, transforms, and lengths with units.
+var number = regexp.MustCompile(`[-+]?(?:\d+\.?\d*|\.\d+)(?:[eE][-+]?\d+)?`)
+
+// equalValues compares two strings with every number in them compared
+// to within tol and everything else compared exactly, after collapsing
+// runs of whitespace.
+func equalValues(got, want string, tol float64) bool {
+	if got == want {
+		return true
+	}
+	got, want = strings.Join(strings.Fields(got), " "), strings.Join(strings.Fields(want), " ")
+	gn, wn := number.FindAllStringIndex(got, -1), number.FindAllStringIndex(want, -1)
+	if len(gn) != len(wn) {
+		return false
+	}
+	gPrev, wPrev := 0, 0
+	for i := range gn {
+		if got[gPrev:gn[i][0]] != want[wPrev:wn[i][0]] {
+			return false
+		}
+		g, gerr := strconv.ParseFloat(got[gn[i][0]:gn[i][1]], 64)
+		w, werr := strconv.ParseFloat(want[wn[i][0]:wn[i][1]], 64)
+		if gerr != nil || werr != nil || math.Abs(g-w) > tol {
+			return false
+		}
+		gPrev, wPrev = gn[i][1], wn[i][1]
+	}
+	return got[gPrev:] == want[wPrev:]
+}
@@ -0,0 +1,115 @@
+package svgtest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	const want = `<svg width="100" height="40"><!-- regolith 1.0 -->` +
+		`<g class="literal"><rect x="10" y="5" fill="#fff"/><text x="12.5">abc</text></g>` +
+		`<path d="M0,20 L10,20"/></svg>`
+	tests := []struct {
+		name    string
+		got     string
+		wantErr string // empty for a match
+	}{
+		{"identical", want, ""},
+		{"attribute order and whitespace",
+			`<svg height="40"  width="100">
+  <g class="literal"><rect fill="#fff" y="5" x="10"/><text x="12.5">abc</text></g>
+  <path d="M0,20  L10,20"/>
+</svg>`, ""},
+		{"float noise and comments",
+			`<svg width="100.000001" height="40"><g class="literal"><rect x="10.004" y="5" fill="#fff"/>` +
+				`<text x="12.5">abc</text></g><path d="M0,20.001 L10,20"/></svg>`, ""},
+		{"moved box",
+			`<svg width="100" height="40"><g class="literal"><rect x="11" y="5" fill="#fff"/>` +
+				`<text x="12.5">abc</text></g><path d="M0,20 L10,20"/></svg>`, "svg/g[1]/rect[1]: x=\"11\""},
+		{"changed color",
+			`<svg width="100" height="40"><g class="literal"><rect x="10" y="5" fill="#000"/>` +
+				`<text x="12.5">abc</text></g><path d="M0,20 L10,20"/></svg>`, "fill="},
+		{"changed text",
+			`<svg width="100" height="40"><g class="literal"><rect x="10" y="5" fill="#fff"/>` +
+				`<text x="12.5">abd</text></g><path d="M0,20 L10,20"/></svg>`, "text \"abd\""},
+		{"missing element",
+			`<svg width="100" height="40"><g class="literal"><rect x="10" y="5" fill="#fff"/>` +
+				`<text x="12.5">abc</text></g></svg>`, "missing child <path>"},
+		{"extra attribute",
+			`<svg width="100" height="40"><g class="literal" id="x"><rect x="10" y="5" fill="#fff"/>` +
+				`<text x="12.5">abc</text></g><path d="M0,20 L10,20"/></svg>`, "unexpected attribute id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Compare([]byte(tt.got), []byte(want), Options{})
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("unexpected difference: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCompareTolerance(t *testing.T) {
+	got, want := []byte(`<svg width="100.3"/>`), []byte(`<svg width="100"/>`)
+	if err := Compare(got, want, Options{}); err == nil {
+		t.Error("0.3 apart should differ at the default tolerance")
+	}
+	if err := Compare(got, want, Options{Tolerance: 0.5}); err != nil {
+		t.Errorf("0.3 apart should match at tolerance 0.5: %v", err)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old := `<svg width="100" height="40"><style>.literal rect { fill: red; }</style>` +
+		`<g class="literal"><rect x="10" y="5"/><text x="12.5">abc</text></g>` +
+		`<g class="escape"><rect x="50"/></g><path d="M0,20 L10,20"/></svg>`
+	new := `<svg width="120" height="40"><style>.literal rect { fill: red; }</style>` +
+		`<g class="literal"><rect x="10.001" y="5"/><text x="12.5">abd</text></g>` +
+		`<g class="charset"><rect x="30"/></g><g class="escape"><rect x="50"/></g></svg>`
+	d, err := Compute([]byte(old), []byte(new), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range d.Changes {
+		got = append(got, c.String())
+	}
+	want := []string{
+		`changed svg: width="120", was "100"`,
+		`changed svg/g[1]/text[1]: text "abd", was "abc"`,
+		// The inserted charset box does not shift the escape box out of
+		// alignment.
+		`added svg/g[2]`,
+		`removed svg/path[1]`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("changes:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	overlay := string(d.Overlay())
+	if err := Compare(d.Overlay(), d.Overlay(), Options{}); err != nil {
+		t.Fatalf("overlay is not well-formed: %v", err)
+	}
+	for _, s := range []string{
+		`<g class="charset svgdiff-added">`,
+		`<text class="svgdiff-changed" x="12.5">abd</text>`,
+		`<path d="M0,20 L10,20" visibility="visible">`,
+		`<g class="svgdiff-old" visibility="hidden">`,
+	} {
+		if !strings.Contains(overlay, s) {
+			t.Errorf("overlay missing %s:\n%s", s, overlay)
+		}
+	}
+	// The old document's stylesheet stays out of the ghost layer.
+	if strings.Count(overlay, ".literal rect") != 1 {
+		t.Errorf("old <style> copied into the overlay:\n%s", overlay)
+	}
+
+	same, err := Compute([]byte(old), []byte(old), Options{})
+	if err != nil || len(same.Changes) != 0 {
+		t.Errorf("identical documents: %v, %v", same.Changes, err)
+	}
+}
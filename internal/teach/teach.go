@@ -0,0 +1,157 @@
+// Package teach holds the canonical teaching examples behind `regolith
+// teach`: everyday patterns (an email address, a URL, an IPv4 address,
+// a log line, a CSV field) written out for every flavor, each with a
+// sentence on what it teaches and sample input it does and does not
+// match.
+package teach
+
+import "fmt"
+
+// Example is one teaching example, written for one flavor.
+type Example struct {
+	Name    string // File name stem, e.g. "ipv4"
+	Title   string
+	Lesson  string // What the example teaches
+	Pattern string // The pattern in the flavor's syntax
+	// Limit says why the pattern checks less than the lesson describes,
+	// when the flavor's syntax cannot express all of it; "" otherwise.
+	Limit   string
+	Matches []string // Input the pattern matches
+	Rejects []string // Input it does not match; nil when Limit is set
+}
+
+// syntax is a flavor family whose members share a pattern syntax.
+type syntax int
+
+const (
+	perl syntax = iota // JavaScript, Java, .NET, PCRE, Go
+	ere                // POSIX and GNU extended
+	bre                // POSIX and GNU basic
+	vim                // Vim's default magic mode
+)
+
+// families maps each flavor to the syntax its examples are written in.
+var families = map[string]syntax{
+	"javascript":  perl,
+	"java":        perl,
+	"dotnet":      perl,
+	"pcre":        perl,
+	"golang":      perl,
+	"posix-ere":   ere,
+	"gnugrep-ere": ere,
+	"gnused-ere":  ere,
+	"posix-bre":   bre,
+	"gnugrep":     bre,
+	"gnugrep-bre": bre,
+	"gnused":      bre,
+	"vim":         vim,
+}
+
+// lesson is an example with its pattern in every syntax.
+type lesson struct {
+	name, title, lesson string
+	patterns            [4]string
+	limits              map[syntax]string
+	matches, rejects    []string
+}
+
+var lessons = []lesson{
+	{
+		name:   "email",
+		title:  "Email address",
+		lesson: "Character classes and + build the parts of an address; the group repeated with + allows any number of subdomains.",
+		patterns: [4]string{
+			perl: `^[\w.%+-]+@[\w-]+(\.[\w-]+)+$`,
+			ere:  `^[[:alnum:]_.%+-]+@[[:alnum:]_-]+(\.[[:alnum:]_-]+)+$`,
+			bre:  `^[[:alnum:]_.%+-]\{1,\}@[[:alnum:]_-]\{1,\}\(\.[[:alnum:]_-]\{1,\}\)\{1,\}$`,
+			vim:  `^[0-9A-Za-z_.%+-]\+@[0-9A-Za-z_-]\+\(\.[0-9A-Za-z_-]\+\)\+$`,
+		},
+		matches: []string{"ada@example.com", "first.last+tag@mail.example.org"},
+		rejects: []string{"ada@localhost", "@example.com", "ada example@example.com"},
+	},
+	{
+		name:   "url",
+		title:  "URL",
+		lesson: "Optional groups (?) pick out the port, path, query and fragment, each captured on its own; s? makes the s of https optional.",
+		patterns: [4]string{
+			perl: `^(https?)://([^/:\s]+)(:\d+)?(/[^\s?#]*)?(\?[^\s#]*)?(#\S*)?$`,
+			ere:  `^(https?)://([^/:[:space:]]+)(:[0-9]+)?(/[^[:space:]?#]*)?(\?[^[:space:]#]*)?(#[^[:space:]]*)?$`,
+			bre:  `^\(https\{0,1\}\)://\([^/:[:space:]]\{1,\}\)\(:[0-9]\{1,\}\)\{0,1\}\(/[^[:space:]?#]*\)\{0,1\}\(?[^[:space:]#]*\)\{0,1\}\(#[^[:space:]]*\)\{0,1\}$`,
+			vim:  `^\(https\=\)://\([^/: \t]\+\)\(:\d\+\)\=\(/[^ \t?#]*\)\=\(?[^ \t#]*\)\=\(#\S*\)\=$`,
+		},
+		matches: []string{"https://example.com", "http://example.com:8080/docs/index.html?lang=en#intro"},
+		rejects: []string{"ftp://example.com", "https://", "https://exa mple.com"},
+	},
+	{
+		name:   "ipv4",
+		title:  "IPv4 address",
+		lesson: "Alternation checks each number is 0 to 255, one range of digits per branch, and {3} repeats the number-and-dot group.",
+		patterns: [4]string{
+			perl: `^((25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)\.){3}(25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)$`,
+			ere:  `^((25[0-5]|2[0-4][0-9]|1[0-9][0-9]|[1-9]?[0-9])\.){3}(25[0-5]|2[0-4][0-9]|1[0-9][0-9]|[1-9]?[0-9])$`,
+			bre:  `^\([0-9]\{1,3\}\.\)\{3\}[0-9]\{1,3\}$`,
+			vim:  `^\(\(25[0-5]\|2[0-4]\d\|1\d\d\|[1-9]\=\d\)\.\)\{3}\(25[0-5]\|2[0-4]\d\|1\d\d\|[1-9]\=\d\)$`,
+		},
+		limits: map[syntax]string{
+			bre: "Basic syntax has no alternation, so this only checks for four groups of one to three digits: 999.1.1.1 matches too.",
+		},
+		matches: []string{"192.168.0.1", "0.0.0.0", "255.255.255.255"},
+		rejects: []string{"192.168.0", "1.2.3.4.5", "a.b.c.d"},
+	},
+	{
+		name:   "log-line",
+		title:  "Log line",
+		lesson: "Capturing groups split a line into date, time, level and message; \\[ and \\] match literal brackets.",
+		patterns: [4]string{
+			perl: `^(\d{4}-\d{2}-\d{2}) (\d{2}:\d{2}:\d{2}) \[(DEBUG|INFO|WARN|ERROR)\] (.*)$`,
+			ere:  `^([0-9]{4}-[0-9]{2}-[0-9]{2}) ([0-9]{2}:[0-9]{2}:[0-9]{2}) \[(DEBUG|INFO|WARN|ERROR)\] (.*)$`,
+			bre:  `^\([0-9]\{4\}-[0-9]\{2\}-[0-9]\{2\}\) \([0-9]\{2\}:[0-9]\{2\}:[0-9]\{2\}\) \[\([A-Z]\{1,\}\)\] \(.*\)$`,
+			vim:  `^\(\d\{4}-\d\{2}-\d\{2}\) \(\d\{2}:\d\{2}:\d\{2}\) \[\(DEBUG\|INFO\|WARN\|ERROR\)\] \(.*\)$`,
+		},
+		limits: map[syntax]string{
+			bre: "Basic syntax has no alternation, so the level is any run of capital letters.",
+		},
+		matches: []string{"2024-05-01 12:30:00 [INFO] server started", "2024-05-01 12:30:01 [ERROR] disk full"},
+		rejects: []string{"2024-05-01 [INFO] no time", "12:30:00 2024-05-01 [INFO] swapped"},
+	},
+	{
+		name:   "csv-field",
+		title:  "CSV field",
+		lesson: `A field is either quoted, where "" stands for one quote and commas are allowed, or unquoted up to the next comma; the field ends at a comma or the end of the line.`,
+		patterns: [4]string{
+			perl: `^("(?:[^"]|"")*"|[^",]*)(,|$)`,
+			ere:  `^("([^"]|"")*"|[^",]*)(,|$)`,
+			bre:  `^\([^",]*\),\{0,1\}`,
+			vim:  `^\("\%([^"]\|""\)*"\|[^",]*\)\(,\|$\)`,
+		},
+		limits: map[syntax]string{
+			bre: "Basic syntax has no alternation, so this only reads unquoted fields.",
+		},
+		matches: []string{`plain,rest`, `"quoted, with comma",rest`, `"say ""hi""",rest`, `last`},
+		rejects: []string{`"unterminated`},
+	},
+}
+
+// For returns the teaching examples written for the named flavor.
+func For(flavorName string) ([]Example, error) {
+	s, ok := families[flavorName]
+	if !ok {
+		return nil, fmt.Errorf("no teaching examples for flavor %q", flavorName)
+	}
+	out := make([]Example, len(lessons))
+	for i, l := range lessons {
+		out[i] = Example{
+			Name:    l.name,
+			Title:   l.title,
+			Lesson:  l.lesson,
+			Pattern: l.patterns[s],
+			Limit:   l.limits[s],
+			Matches: l.matches,
+		}
+		// A looser pattern may accept what the full one rejects.
+		if out[i].Limit == "" {
+			out[i].Rejects = l.rejects
+		}
+	}
+	return out, nil
+}
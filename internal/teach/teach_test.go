@@ -0,0 +1,64 @@
+package teach
+
+import (
+	"testing"
+
+	"github.com/0x4d5352/regolith/internal/flavor"
+	_ "github.com/0x4d5352/regolith/internal/flavor/dotnet"
+	_ "github.com/0x4d5352/regolith/internal/flavor/gnugrep_bre"
+	_ "github.com/0x4d5352/regolith/internal/flavor/gnugrep_ere"
+	_ "github.com/0x4d5352/regolith/internal/flavor/gnused"
+	_ "github.com/0x4d5352/regolith/internal/flavor/golang"
+	_ "github.com/0x4d5352/regolith/internal/flavor/java"
+	_ "github.com/0x4d5352/regolith/internal/flavor/javascript"
+	_ "github.com/0x4d5352/regolith/internal/flavor/pcre"
+	_ "github.com/0x4d5352/regolith/internal/flavor/posix_bre"
+	_ "github.com/0x4d5352/regolith/internal/flavor/posix_ere"
+	_ "github.com/0x4d5352/regolith/internal/flavor/vim"
+	"github.com/0x4d5352/regolith/internal/match"
+)
+
+// TestExamples checks that every example parses in every flavor and,
+// where a match engine exists for the flavor, that it matches its
+// samples and none of its rejects.
+func TestExamples(t *testing.T) {
+	for _, name := range flavor.List() {
+		examples, err := For(name)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		f, _ := flavor.Get(name)
+		engine, engineErr := match.For(name)
+		for _, ex := range examples {
+			if _, err := f.Parse(ex.Pattern); err != nil {
+				t.Errorf("%s/%s: parse %q: %v", name, ex.Name, ex.Pattern, err)
+				continue
+			}
+			if engineErr != nil {
+				continue
+			}
+			m, err := engine.Compile(ex.Pattern)
+			if err != nil {
+				t.Errorf("%s/%s: compile %q: %v", name, ex.Name, ex.Pattern, err)
+				continue
+			}
+			for _, in := range ex.Matches {
+				if ok, _ := match.MatchString(m, in); !ok {
+					t.Errorf("%s/%s: %q does not match %q", name, ex.Name, ex.Pattern, in)
+				}
+			}
+			for _, in := range ex.Rejects {
+				if ok, _ := match.MatchString(m, in); ok {
+					t.Errorf("%s/%s: %q matches reject %q", name, ex.Name, ex.Pattern, in)
+				}
+			}
+		}
+	}
+}
+
+func TestForUnknownFlavor(t *testing.T) {
+	if _, err := For("nope"); err == nil {
+		t.Error("For(nope) succeeded")
+	}
+}
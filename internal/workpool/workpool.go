@@ -0,0 +1,75 @@
+// Package workpool runs independent jobs, such as the patterns of a
+// batch render or the sites of an audit, on a bounded number of
+// goroutines. Each job's failure, panics included, is kept to that job,
+// and results can be streamed back in input order while later jobs are
+// still running.
+package workpool
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// Workers returns n if it is positive and the number of usable CPUs
+// otherwise, so a zero-valued option means "as many as make sense".
+func Workers(n int) int {
+	if n > 0 {
+		return n
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// Run calls job(i) for every i in [0, n) on at most workers goroutines
+// (see Workers) and returns each call's error, indexed like the jobs.
+// A job that panics is recovered and its panic reported as its error,
+// so one bad input cannot take down the rest.
+//
+// If done is non-nil it is called once per job, in index order, as soon
+// as that job and every job before it have finished. Calls to done are
+// never concurrent, so it can write a job's buffered output straight to
+// a shared writer.
+func Run(n, workers int, job func(i int) error, done func(i int, err error)) []error {
+	errs := make([]error, n)
+	finished := make([]bool, n)
+	var mu sync.Mutex
+	next := 0
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := min(Workers(workers), n); w > 0; w-- {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				err := call(job, i)
+
+				mu.Lock()
+				errs[i], finished[i] = err, true
+				for next < n && finished[next] {
+					if done != nil {
+						done(next, errs[next])
+					}
+					next++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return errs
+}
+
+// call runs job(i), turning a panic into an error.
+func call(job func(i int) error, i int) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return job(i)
+}
@@ -0,0 +1,76 @@
+package workpool
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunCollectsErrorsByIndex(t *testing.T) {
+	errs := Run(5, 3, func(i int) error {
+		if i%2 == 1 {
+			return errors.New("odd")
+		}
+		return nil
+	}, nil)
+	for i, err := range errs {
+		if (err != nil) != (i%2 == 1) {
+			t.Errorf("errs[%d] = %v", i, err)
+		}
+	}
+}
+
+func TestRunIsolatesPanics(t *testing.T) {
+	errs := Run(3, 2, func(i int) error {
+		if i == 1 {
+			panic("boom")
+		}
+		return nil
+	}, nil)
+	if errs[0] != nil || errs[2] != nil {
+		t.Errorf("panic leaked into other jobs: %v", errs)
+	}
+	if errs[1] == nil || !strings.Contains(errs[1].Error(), "boom") {
+		t.Errorf("errs[1] = %v, want the recovered panic", errs[1])
+	}
+}
+
+func TestRunDoneInOrder(t *testing.T) {
+	var order []int
+	Run(6, 4, func(i int) error {
+		// Later jobs finish first, so done has to hold them back.
+		time.Sleep(time.Duration(6-i) * time.Millisecond)
+		return nil
+	}, func(i int, _ error) {
+		order = append(order, i)
+	})
+	for i, got := range order {
+		if got != i {
+			t.Fatalf("done order = %v, want 0..5", order)
+		}
+	}
+	if len(order) != 6 {
+		t.Fatalf("done called %d times, want 6", len(order))
+	}
+}
+
+func TestRunBoundsConcurrency(t *testing.T) {
+	var running, peak atomic.Int32
+	Run(20, 3, func(int) error {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		running.Add(-1)
+		return nil
+	}, nil)
+	if p := peak.Load(); p > 3 {
+		t.Errorf("peak concurrency = %d, want at most 3", p)
+	}
+}
@@ -0,0 +1,118 @@
+// <regolith-diagram>: renders a regular expression as a railroad
+// diagram in the browser, from the WebAssembly build of regolith
+// (cmd/regolith-wasm). The pattern text stays the source of truth:
+//
+//   <script type="module" src="regolith-diagram.js"></script>
+//   <regolith-diagram flavor="pcre" pattern="^(?<year>\d{4})-\d{2}$"></regolith-diagram>
+//
+// Without a pattern attribute the element's text content is used, which
+// spares escaping quotes in attribute values.
+
+import "./wasm_exec.js";
+
+const wasmURL = new URL("./regolith.wasm", import.meta.url);
+
+let loading = null;
+
+// load starts the WebAssembly module once and resolves to the global
+// regolith object it sets (render, flavors, themes).
+export function load() {
+  if (!loading) {
+    loading = new Promise((resolve, reject) => {
+      const go = new Go();
+      globalThis.regolithReady = () => resolve(globalThis.regolith);
+      const instantiate = WebAssembly.instantiateStreaming
+        ? WebAssembly.instantiateStreaming(fetch(wasmURL), go.importObject)
+        : fetch(wasmURL)
+            .then((r) => r.arrayBuffer())
+            .then((b) => WebAssembly.instantiate(b, go.importObject));
+      instantiate.then((result) => go.run(result.instance), reject);
+    });
+  }
+  return loading;
+}
+
+// render draws pattern under flavor and resolves to the SVG markup, or
+// rejects with the parse error.
+export async function render(flavor, pattern, options = {}) {
+  const regolith = await load();
+  const result = regolith.render(flavor, pattern, options);
+  if (result.error) {
+    throw new Error(result.error);
+  }
+  return result.svg;
+}
+
+const numberAttributes = { padding: "padding", "font-size": "fontSize", "line-width": "lineWidth" };
+const booleanAttributes = { compact: "compact", "show-source": "showSource", summary: "summary" };
+
+export class RegolithDiagram extends HTMLElement {
+  static get observedAttributes() {
+    return [
+      "pattern", "flavor", "theme", "background", "lang",
+      ...Object.keys(numberAttributes), ...Object.keys(booleanAttributes),
+    ];
+  }
+
+  constructor() {
+    super();
+    this.attachShadow({ mode: "open" });
+    this.renders = 0;
+  }
+
+  connectedCallback() {
+    this.update();
+  }
+
+  attributeChangedCallback() {
+    if (this.isConnected) {
+      this.update();
+    }
+  }
+
+  get pattern() {
+    return this.getAttribute("pattern") ?? this.textContent.trim();
+  }
+
+  options() {
+    const opts = {
+      theme: this.getAttribute("theme") ?? "",
+      backgroundFill: this.getAttribute("background") ?? "",
+      language: this.getAttribute("lang") ?? "",
+    };
+    for (const [attr, key] of Object.entries(numberAttributes)) {
+      if (this.hasAttribute(attr)) {
+        opts[key] = Number(this.getAttribute(attr));
+      }
+    }
+    for (const [attr, key] of Object.entries(booleanAttributes)) {
+      opts[key] = this.hasAttribute(attr);
+    }
+    return opts;
+  }
+
+  async update() {
+    // Attribute changes can overlap; only the latest render is shown.
+    const seq = ++this.renders;
+    const flavor = this.getAttribute("flavor") ?? "javascript";
+    try {
+      const svg = await render(flavor, this.pattern, this.options());
+      if (seq === this.renders) {
+        this.shadowRoot.innerHTML = svg;
+        this.dispatchEvent(new CustomEvent("regolith-render", { detail: { svg } }));
+      }
+    } catch (err) {
+      if (seq === this.renders) {
+        const pre = document.createElement("pre");
+        pre.setAttribute("part", "error");
+        pre.textContent = err.message;
+        this.shadowRoot.replaceChildren(pre);
+        this.dispatchEvent(new CustomEvent("regolith-error", { detail: { error: err } }));
+      }
+    }
+  }
+}
+
+if (!customElements.get("regolith-diagram")) {
+  customElements.define("regolith-diagram", RegolithDiagram);
+}
@@ -0,0 +1,410 @@
+// Package regolith renders regular expressions as SVG railroad
+// diagrams, for Go programs that want diagrams without shelling out to
+// the regolith CLI.
+//
+// Parse a pattern under one of the registered flavors, then render it:
+//
+//	p, err := regolith.Parse("pcre", `^(?<year>\d{4})-\d{2}$`)
+//	if err != nil {
+//		return err
+//	}
+//	svg, err := regolith.Render(p, regolith.WithTheme("catppuccin-mocha"))
+//
+// Render takes functional options, so callers name only the settings
+// they change and new settings never break existing calls. RenderSVG
+// takes the same settings as an Options struct.
+//
+// Importing this package registers every flavor and theme the CLI
+// supports; RegisterFlavor adds a program's own flavors. The parser,
+// AST, and renderer themselves stay internal; this package is the
+// stable surface over them and follows semantic versioning with the
+// module.
+package regolith
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/0x4d5352/regolith/internal/ast"
+	"github.com/0x4d5352/regolith/internal/flavor"
+	"github.com/0x4d5352/regolith/internal/output"
+	"github.com/0x4d5352/regolith/internal/renderer"
+	"github.com/0x4d5352/regolith/internal/renderer/theme"
+
+	// Register every flavor, as the CLI does.
+	_ "github.com/0x4d5352/regolith/internal/flavor/dotnet"
+	_ "github.com/0x4d5352/regolith/internal/flavor/gnugrep_bre"
+	_ "github.com/0x4d5352/regolith/internal/flavor/gnugrep_ere"
+	_ "github.com/0x4d5352/regolith/internal/flavor/gnused"
+	_ "github.com/0x4d5352/regolith/internal/flavor/golang"
+	_ "github.com/0x4d5352/regolith/internal/flavor/java"
+	_ "github.com/0x4d5352/regolith/internal/flavor/javascript"
+	_ "github.com/0x4d5352/regolith/internal/flavor/pcre"
+	_ "github.com/0x4d5352/regolith/internal/flavor/posix_bre"
+	_ "github.com/0x4d5352/regolith/internal/flavor/posix_ere"
+	_ "github.com/0x4d5352/regolith/internal/flavor/vim"
+)
+
+// ErrUnknownFlavor is returned by Parse, RegisterAlias, and
+// DeregisterFlavor for a flavor name that is not registered,
+// ErrFlavorConflict by RegisterFlavor and RegisterAlias for a name
+// already in use,
+// ErrUnknownTheme by Render and RenderSVG for an unknown theme, and
+// ErrUnknownBackend by Render for a Backend value it does not define.
+var (
+	ErrUnknownFlavor  = errors.New("unknown flavor")
+	ErrFlavorConflict = flavor.ErrConflict
+	ErrUnknownTheme   = errors.New("unknown theme")
+	ErrUnknownBackend = errors.New("unknown backend")
+)
+
+// ParseError is the error Parse returns for a pattern its flavor
+// rejects. Offset, Line and Col locate the problem in the pattern;
+// Expected lists what the flavor's grammar would have accepted there,
+// when it says.
+type ParseError = flavor.ParseError
+
+// Flavor is a regex syntax Parse can read patterns in. RegisterFlavor
+// adds one under its Name; Parse calls its Parse method. The built-in
+// flavors are Flavors too, so a new one can wrap one of them, found
+// with LookupFlavor, and change its name, description or flags.
+type Flavor = flavor.Flavor
+
+// Regexp is the syntax tree a Flavor's Parse method returns. Its
+// fields are not a stable contract; see Pattern.JSON for one.
+type Regexp = ast.Regexp
+
+// FlagInfo describes one of a Flavor's flags, FlagText its label and
+// description in one language, and FeatureSet the constructs a Flavor
+// supports.
+type (
+	FlagInfo   = flavor.FlagInfo
+	FlagText   = flavor.FlagText
+	FeatureSet = flavor.FeatureSet
+)
+
+// Pattern is a parsed regular expression. It is immutable and safe to
+// render from several goroutines at once.
+type Pattern struct {
+	source string
+	flavor flavor.Flavor
+	root   *ast.Regexp
+}
+
+// Source returns the pattern text as given to Parse.
+func (p *Pattern) Source() string { return p.source }
+
+// Flavor returns the name of the flavor the pattern was parsed under.
+func (p *Pattern) Flavor() string { return p.flavor.Name() }
+
+// JSON returns the pattern's syntax tree in the schema of the CLI's
+// --format json output.
+func (p *Pattern) JSON() (string, error) {
+	return output.RenderJSON(p.root, p.source, p.flavor.Name())
+}
+
+// Flavors returns the names of the registered flavors, sorted. Parse
+// also accepts their aliases; see FlavorAliases.
+func Flavors() []string { return flavor.List() }
+
+// FlavorAliases returns the alternative flavor names Parse accepts,
+// such as "js" for "javascript", each mapped to its flavor's name.
+func FlavorAliases() map[string]string { return flavor.Aliases() }
+
+// RegisterFlavor adds f under f.Name(), so Parse accepts that name. It
+// fails with ErrFlavorConflict when the name is already a flavor or an
+// alias; DeregisterFlavor the old one first to replace it. It is safe
+// to call while other goroutines parse.
+func RegisterFlavor(f Flavor) error {
+	return flavor.Register(f)
+}
+
+// LookupFlavor returns the flavor registered as name, or one of its
+// aliases, for a new flavor to build on.
+func LookupFlavor(name string) (Flavor, bool) {
+	return flavor.Get(name)
+}
+
+// RegisterAlias makes alias another name for the flavor registered as
+// name, so a program can accept the names its users already write. It
+// fails with ErrFlavorConflict when alias is already a flavor or an
+// alias, and with ErrUnknownFlavor when name is neither. It is safe to
+// call while other goroutines parse.
+func RegisterAlias(alias, name string) error {
+	err := flavor.RegisterAlias(alias, name)
+	if errors.Is(err, flavor.ErrNotRegistered) {
+		return fmt.Errorf("%w %q", ErrUnknownFlavor, name)
+	}
+	return err
+}
+
+// DeregisterFlavor removes a flavor, and its aliases, or a single
+// alias, so a program can restrict what Parse accepts. Patterns already
+// parsed under the flavor keep working.
+func DeregisterFlavor(name string) error {
+	err := flavor.Deregister(name)
+	if errors.Is(err, flavor.ErrNotRegistered) {
+		return fmt.Errorf("%w %q", ErrUnknownFlavor, name)
+	}
+	return err
+}
+
+// Themes returns the names Options.Theme accepts, sorted.
+func Themes() []string { return theme.List() }
+
+// Parse parses pattern under the named flavor ("javascript", "pcre",
+// "posix-ere", ...; see Flavors) or one of its aliases. Patterns may
+// carry the delimiters and flags their flavor allows, like /abc/i in
+// JavaScript. A pattern the flavor rejects gives a *ParseError.
+func Parse(flavorName, pattern string) (*Pattern, error) {
+	f, ok := flavor.Get(flavorName)
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownFlavor, flavorName)
+	}
+	root, err := f.Parse(pattern)
+	if pe, ok := err.(*ParseError); ok {
+		return nil, pe
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse error: %w", err)
+	}
+	return &Pattern{source: pattern, flavor: f, root: root}, nil
+}
+
+// Backend is the kind of document Render produces. The zero value is
+// BackendSVG. The text backends draw the diagram alone: colors, fonts,
+// dimensions, MaxWidth, MaxHeight, WrapWidth, ShowSource, Summary, and
+// GroupLegend apply to SVG only.
+type Backend int
+
+const (
+	// BackendSVG draws a standalone SVG document.
+	BackendSVG Backend = iota
+	// BackendText draws the diagram in Unicode box-drawing characters,
+	// like the CLI's --format diagram.
+	BackendText
+	// BackendASCII draws the text diagram in plain ASCII (- | +), like
+	// --format diagram --ascii.
+	BackendASCII
+)
+
+// Options controls how a diagram is drawn. The zero value, like a nil
+// *Options, draws the CLI's default diagram, and so does Render with no
+// Option.
+type Options struct {
+	// Theme names a color theme (see Themes); "" keeps the default
+	// palette.
+	Theme string
+	// Compact draws the minimal-footprint layout of the CLI's
+	// --compact, for diagrams set inline in documentation.
+	Compact bool
+	// Padding, FontSize, and LineWidth override the diagram padding,
+	// content font size in pixels, and connector stroke width when
+	// positive.
+	Padding   float64
+	FontSize  float64
+	LineWidth float64
+	// MaxWidth and MaxHeight, when positive, scale an SVG wider or
+	// taller than this many pixels down to fit; the layout itself is
+	// unchanged.
+	MaxWidth  float64
+	MaxHeight float64
+	// WrapWidth, when positive, folds a long top-level sequence onto
+	// rows about this many pixels wide instead of scaling it.
+	WrapWidth float64
+	// BackgroundFill fills the diagram with a solid color; "" leaves
+	// it transparent.
+	BackgroundFill string
+	// TextLength pins content text to its measured width, and Font,
+	// when set, is a WOFF2, WOFF, TrueType or OpenType file embedded as
+	// the content font, so the SVG looks alike in every viewer.
+	TextLength bool
+	Font       []byte
+	// ShowSource draws the pattern beneath the diagram with syntax
+	// coloring.
+	ShowSource bool
+	// Summary draws a one-line overview with each group reduced to a
+	// labeled chip, for thumbnails.
+	Summary bool
+	// GroupLegend lists the capturing groups beside the diagram, each
+	// entry linking to its group's box.
+	GroupLegend bool
+	// Language is a language tag ("de") for the names of the
+	// pattern's flags in the flags panel; "" means English.
+	Language string
+	// Backend picks the kind of document Render produces. RenderSVG
+	// ignores it.
+	Backend Backend
+}
+
+// An Option changes one setting for Render. Options apply in order, so
+// a later one wins.
+type Option func(*Options)
+
+// WithOptions replaces every setting with o, for callers that build an
+// Options value up front. Options after it still apply.
+func WithOptions(o Options) Option {
+	return func(dst *Options) { *dst = o }
+}
+
+// WithTheme selects a color theme by name (see Themes).
+func WithTheme(name string) Option {
+	return func(o *Options) { o.Theme = name }
+}
+
+// WithCompact draws the minimal-footprint layout of the CLI's
+// --compact.
+func WithCompact() Option {
+	return func(o *Options) { o.Compact = true }
+}
+
+// WithPadding sets the padding around the diagram in pixels.
+func WithPadding(px float64) Option {
+	return func(o *Options) { o.Padding = px }
+}
+
+// WithFontSize sets the content font size in pixels.
+func WithFontSize(px float64) Option {
+	return func(o *Options) { o.FontSize = px }
+}
+
+// WithLineWidth sets the stroke width of connectors and loops.
+func WithLineWidth(px float64) Option {
+	return func(o *Options) { o.LineWidth = px }
+}
+
+// WithMaxWidth scales an SVG wider than px pixels down to fit.
+func WithMaxWidth(px float64) Option {
+	return func(o *Options) { o.MaxWidth = px }
+}
+
+// WithMaxHeight scales an SVG taller than px pixels down to fit.
+func WithMaxHeight(px float64) Option {
+	return func(o *Options) { o.MaxHeight = px }
+}
+
+// WithWrapWidth folds a long top-level sequence onto rows about px
+// pixels wide, like the CLI's --layout wrap.
+func WithWrapWidth(px float64) Option {
+	return func(o *Options) { o.WrapWidth = px }
+}
+
+// WithTextLength pins content text to its measured width with the SVG
+// textLength attribute.
+func WithTextLength() Option {
+	return func(o *Options) { o.TextLength = true }
+}
+
+// WithFont embeds font, the contents of a WOFF2, WOFF, TrueType or
+// OpenType file, in the SVG as the content font.
+func WithFont(font []byte) Option {
+	return func(o *Options) { o.Font = font }
+}
+
+// WithBackgroundFill fills the diagram with a solid color.
+func WithBackgroundFill(color string) Option {
+	return func(o *Options) { o.BackgroundFill = color }
+}
+
+// WithSource draws the pattern beneath the diagram with syntax
+// coloring.
+func WithSource() Option {
+	return func(o *Options) { o.ShowSource = true }
+}
+
+// WithSummary draws a one-line overview with each group reduced to a
+// labeled chip.
+func WithSummary() Option {
+	return func(o *Options) { o.Summary = true }
+}
+
+// WithGroupLegend lists the capturing groups beside the diagram.
+func WithGroupLegend() Option {
+	return func(o *Options) { o.GroupLegend = true }
+}
+
+// WithLocale sets the language tag ("de", "fr-CA") used for the names
+// of the pattern's flags in the flags panel.
+func WithLocale(lang string) Option {
+	return func(o *Options) { o.Language = lang }
+}
+
+// WithBackend picks the kind of document Render produces.
+func WithBackend(b Backend) Option {
+	return func(o *Options) { o.Backend = b }
+}
+
+// Render draws p with the given options applied over the defaults:
+// an SVG document unless WithBackend says otherwise.
+func Render(p *Pattern, opts ...Option) (string, error) {
+	var o Options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	switch o.Backend {
+	case BackendSVG:
+		return RenderSVG(p, &o)
+	case BackendText, BackendASCII:
+		cfg, err := o.config()
+		if err != nil {
+			return "", err
+		}
+		return renderer.New(cfg).RenderTextDiagram(p.root, o.Backend == BackendASCII), nil
+	}
+	return "", fmt.Errorf("%w %d", ErrUnknownBackend, o.Backend)
+}
+
+// RenderSVG draws p as a standalone SVG document.
+func RenderSVG(p *Pattern, opts *Options) (string, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	cfg, err := opts.config()
+	if err != nil {
+		return "", err
+	}
+	r := renderer.New(cfg)
+	r.Flavor, r.Language = p.flavor, opts.Language
+	r.Summary = opts.Summary
+	r.GroupLegend, r.Pattern = opts.GroupLegend, p.source
+	if opts.ShowSource {
+		r.Source = flavor.Tokens(p.flavor, p.source)
+	}
+	return r.Render(p.root), nil
+}
+
+// config builds the renderer configuration o describes.
+func (o *Options) config() (*renderer.Config, error) {
+	cfg := renderer.DefaultConfig()
+	if o.Theme != "" {
+		t, ok := theme.Get(o.Theme)
+		if !ok {
+			return nil, fmt.Errorf("%w %q", ErrUnknownTheme, o.Theme)
+		}
+		t.Apply(cfg)
+	}
+	if o.Compact {
+		cfg.Compact()
+	}
+	if o.Padding > 0 {
+		cfg.Padding = o.Padding
+	}
+	if o.FontSize > 0 {
+		cfg.FontSize = o.FontSize
+		cfg.CharWidth = o.FontSize * 0.6
+	}
+	if o.LineWidth > 0 {
+		cfg.Connector.StrokeWidth = o.LineWidth
+	}
+	cfg.MaxWidth = o.MaxWidth
+	cfg.MaxHeight = o.MaxHeight
+	cfg.WrapWidth = o.WrapWidth
+	cfg.BackgroundFill = o.BackgroundFill
+	cfg.TextLength = o.TextLength
+	if o.Font != nil {
+		if err := cfg.EmbedFont(o.Font); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
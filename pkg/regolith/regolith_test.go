@@ -0,0 +1,300 @@
+package regolith_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/0x4d5352/regolith/pkg/regolith"
+)
+
+func TestParseAndRender(t *testing.T) {
+	p, err := regolith.Parse("pcre", `/^(?<year>\d{4})-\d{2}$/i`)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if p.Flavor() != "pcre" || p.Source() != `/^(?<year>\d{4})-\d{2}$/i` {
+		t.Errorf("Pattern = %q under %q", p.Source(), p.Flavor())
+	}
+	svg, err := regolith.RenderSVG(p, nil)
+	if err != nil {
+		t.Fatalf("RenderSVG: %v", err)
+	}
+	for _, want := range []string{"<svg", "year", ">caseless<"} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("SVG missing %q", want)
+		}
+	}
+
+	styled, err := regolith.RenderSVG(p, &regolith.Options{Theme: "gruvbox-dark", Compact: true, ShowSource: true})
+	if err != nil {
+		t.Fatalf("RenderSVG with options: %v", err)
+	}
+	if styled == svg {
+		t.Error("options did not change the diagram")
+	}
+
+	js, err := p.JSON()
+	if err != nil || !strings.Contains(js, `"flavor": "pcre"`) {
+		t.Errorf("JSON() = %q, %v", js, err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	if _, err := regolith.Parse("perl6", "a"); !errors.Is(err, regolith.ErrUnknownFlavor) {
+		t.Errorf("Parse with unknown flavor: got %v, want ErrUnknownFlavor", err)
+	}
+	if _, err := regolith.Parse("javascript", "a("); err == nil || !strings.HasPrefix(err.Error(), "parse error: ") {
+		t.Errorf("Parse of a bad pattern: got %v", err)
+	}
+	var pe *regolith.ParseError
+	if _, err := regolith.Parse("javascript", "a("); !errors.As(err, &pe) || pe.Offset != 1 || pe.Col != 2 {
+		t.Errorf("Parse of a bad pattern: got %v, want a ParseError at column 2", err)
+	}
+	p, err := regolith.Parse("javascript", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := regolith.RenderSVG(p, &regolith.Options{Theme: "nope"}); !errors.Is(err, regolith.ErrUnknownTheme) {
+		t.Errorf("RenderSVG with unknown theme: got %v, want ErrUnknownTheme", err)
+	}
+}
+
+func TestRenderOptions(t *testing.T) {
+	p, err := regolith.Parse("javascript", `/(a|b)+c/i`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := regolith.Render(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if viaStruct, _ := regolith.RenderSVG(p, nil); plain != viaStruct {
+		t.Error("Render with no options differs from RenderSVG(p, nil)")
+	}
+
+	withOpts, err := regolith.Render(p, regolith.WithTheme("gruvbox-dark"), regolith.WithCompact(), regolith.WithSource())
+	if err != nil {
+		t.Fatal(err)
+	}
+	viaStruct, _ := regolith.RenderSVG(p, &regolith.Options{Theme: "gruvbox-dark", Compact: true, ShowSource: true})
+	if withOpts != viaStruct {
+		t.Error("functional options differ from the equivalent Options")
+	}
+
+	later, _ := regolith.Render(p, regolith.WithOptions(regolith.Options{Theme: "nope"}), regolith.WithTheme(""))
+	if later != plain {
+		t.Error("a later option should override WithOptions")
+	}
+
+	narrow, err := regolith.Render(p, regolith.WithMaxWidth(50))
+	if err != nil || !strings.Contains(narrow, `width="50"`) {
+		t.Errorf("WithMaxWidth(50): %v\n%.200s", err, narrow)
+	}
+	short, err := regolith.Render(p, regolith.WithMaxHeight(20))
+	if err != nil || !strings.Contains(short, `height="20"`) {
+		t.Errorf("WithMaxHeight(20): %v\n%.200s", err, short)
+	}
+
+	if fitted, _ := regolith.Render(p, regolith.WithTextLength(), regolith.WithFont([]byte("wOFF font"))); !strings.Contains(fitted, "textLength=") || !strings.Contains(fitted, "data:font/woff;base64,") {
+		t.Errorf("WithTextLength and WithFont: no textLength or font face\n%.300s", fitted)
+	}
+	if _, err := regolith.Render(p, regolith.WithFont([]byte("not a font"))); err == nil {
+		t.Error("WithFont accepted a file that is not a font")
+	}
+
+	if wrapped, _ := regolith.Render(p, regolith.WithWrapWidth(60)); !strings.Contains(wrapped, `class="wrap-path"`) {
+		t.Errorf("WithWrapWidth(60): no rows\n%.200s", wrapped)
+	}
+
+	legend, _ := regolith.Render(p, regolith.WithGroupLegend())
+	if !strings.Contains(legend, `<a href="#group-1">`) || !strings.Contains(legend, ">(a|b)</text>") {
+		t.Errorf("WithGroupLegend: no linked legend entry for (a|b)\n%s", legend)
+	}
+
+	german, _ := regolith.Render(p, regolith.WithLocale("de"))
+	if german == plain {
+		t.Error("WithLocale(\"de\") did not change the flags panel")
+	}
+
+	text, err := regolith.Render(p, regolith.WithBackend(regolith.BackendText))
+	if err != nil || !strings.Contains(text, "─") {
+		t.Errorf("BackendText: %v\n%s", err, text)
+	}
+	ascii, err := regolith.Render(p, regolith.WithBackend(regolith.BackendASCII))
+	if err != nil || strings.Contains(ascii, "─") || !strings.Contains(ascii, "-") {
+		t.Errorf("BackendASCII: %v\n%s", err, ascii)
+	}
+	if _, err := regolith.Render(p, regolith.WithBackend(99)); !errors.Is(err, regolith.ErrUnknownBackend) {
+		t.Errorf("unknown backend: got %v, want ErrUnknownBackend", err)
+	}
+	if _, err := regolith.Render(p, regolith.WithTheme("nope")); !errors.Is(err, regolith.ErrUnknownTheme) {
+		t.Errorf("unknown theme: got %v, want ErrUnknownTheme", err)
+	}
+}
+
+func TestRegistries(t *testing.T) {
+	if got := strings.Join(regolith.Flavors(), ","); !strings.Contains(got, "javascript") || !strings.Contains(got, "posix-ere") {
+		t.Errorf("Flavors() = %s", got)
+	}
+	if got := strings.Join(regolith.Themes(), ","); !strings.Contains(got, "catppuccin-mocha") {
+		t.Errorf("Themes() = %s", got)
+	}
+}
+
+func TestFlavorAliases(t *testing.T) {
+	p, err := regolith.Parse("js", `a+`)
+	if err != nil {
+		t.Fatalf("Parse(js): %v", err)
+	}
+	if p.Flavor() != "javascript" {
+		t.Errorf("Flavor() = %q, want javascript", p.Flavor())
+	}
+	if got := regolith.FlavorAliases()["grep"]; got != "gnugrep" {
+		t.Errorf("FlavorAliases()[grep] = %q, want gnugrep", got)
+	}
+
+	if err := regolith.RegisterAlias("ecma", "js"); err != nil {
+		t.Fatalf("RegisterAlias: %v", err)
+	}
+	if p, err := regolith.Parse("ecma", `a`); err != nil || p.Flavor() != "javascript" {
+		t.Errorf("Parse(ecma) = %v, %v; want a javascript pattern", p, err)
+	}
+	if err := regolith.RegisterAlias("ecma", "pcre"); !errors.Is(err, regolith.ErrFlavorConflict) {
+		t.Errorf("re-registering an alias: got %v, want ErrFlavorConflict", err)
+	}
+	if err := regolith.RegisterAlias("pcre", "javascript"); !errors.Is(err, regolith.ErrFlavorConflict) {
+		t.Errorf("aliasing over a flavor: got %v, want ErrFlavorConflict", err)
+	}
+	if err := regolith.RegisterAlias("x", "cobol"); !errors.Is(err, regolith.ErrUnknownFlavor) {
+		t.Errorf("aliasing an unknown flavor: got %v, want ErrUnknownFlavor", err)
+	}
+	if err := regolith.DeregisterFlavor("ecma"); err != nil {
+		t.Fatalf("DeregisterFlavor: %v", err)
+	}
+	if _, err := regolith.Parse("ecma", `a`); !errors.Is(err, regolith.ErrUnknownFlavor) {
+		t.Errorf("Parse after DeregisterFlavor: got %v, want ErrUnknownFlavor", err)
+	}
+	if err := regolith.DeregisterFlavor("ecma"); !errors.Is(err, regolith.ErrUnknownFlavor) {
+		t.Errorf("DeregisterFlavor twice: got %v, want ErrUnknownFlavor", err)
+	}
+}
+
+// renamed is a flavor that parses as another one does under its own
+// name, as a program adding a dialect of a built-in flavor would write.
+type renamed struct {
+	regolith.Flavor
+	name string
+}
+
+func (f renamed) Name() string { return f.name }
+
+func TestRegisterFlavor(t *testing.T) {
+	pcre, ok := regolith.LookupFlavor("pcre")
+	if !ok {
+		t.Fatal("LookupFlavor(pcre) failed")
+	}
+	if err := regolith.RegisterFlavor(renamed{pcre, "mypcre"}); err != nil {
+		t.Fatalf("RegisterFlavor: %v", err)
+	}
+	defer func() { _ = regolith.DeregisterFlavor("mypcre") }()
+
+	p, err := regolith.Parse("mypcre", `(?<y>\d+)`)
+	if err != nil {
+		t.Fatalf("Parse(mypcre): %v", err)
+	}
+	if p.Flavor() != "mypcre" {
+		t.Errorf("Flavor() = %q, want mypcre", p.Flavor())
+	}
+	if _, err := regolith.RenderSVG(p, nil); err != nil {
+		t.Errorf("RenderSVG: %v", err)
+	}
+	if err := regolith.RegisterFlavor(renamed{pcre, "mypcre"}); !errors.Is(err, regolith.ErrFlavorConflict) {
+		t.Errorf("re-registering a flavor: got %v, want ErrFlavorConflict", err)
+	}
+	if err := regolith.RegisterFlavor(renamed{pcre, "js"}); !errors.Is(err, regolith.ErrFlavorConflict) {
+		t.Errorf("registering over an alias: got %v, want ErrFlavorConflict", err)
+	}
+}
+
+// TestRegisterFlavorConcurrent registers and removes flavors while
+// other goroutines parse and list them; run it with -race.
+func TestRegisterFlavorConcurrent(t *testing.T) {
+	pcre, ok := regolith.LookupFlavor("pcre")
+	if !ok {
+		t.Fatal("LookupFlavor(pcre) failed")
+	}
+	const n = 8
+	var wg sync.WaitGroup
+	for i := range n {
+		name := fmt.Sprintf("concurrent-%d", i)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := regolith.RegisterFlavor(renamed{pcre, name}); err != nil {
+				t.Errorf("RegisterFlavor(%s): %v", name, err)
+				return
+			}
+			if p, err := regolith.Parse(name, `a+b`); err != nil || p.Flavor() != name {
+				t.Errorf("Parse(%s) = %v, %v", name, p, err)
+			}
+			if err := regolith.DeregisterFlavor(name); err != nil {
+				t.Errorf("DeregisterFlavor(%s): %v", name, err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range 20 {
+				if _, err := regolith.Parse("pcre", `a+b`); err != nil {
+					t.Errorf("Parse(pcre): %v", err)
+				}
+				_ = regolith.Flavors()
+				_ = regolith.FlavorAliases()
+			}
+		}()
+	}
+	wg.Wait()
+	for _, name := range regolith.Flavors() {
+		if strings.HasPrefix(name, "concurrent-") {
+			t.Errorf("flavor %s is still registered", name)
+		}
+	}
+}
+
+func Example() {
+	p, err := regolith.Parse("javascript", `/colou?r/i`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	svg, err := regolith.RenderSVG(p, &regolith.Options{Compact: true})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(strings.HasPrefix(svg, "<svg"))
+	// Output: true
+}
+
+func ExampleRender() {
+	p, err := regolith.Parse("javascript", `a|b`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	diagram, err := regolith.Render(p, regolith.WithBackend(regolith.BackendASCII))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(diagram)
+	// Output:
+	//      +---+
+	// o--+-+"a"+-+--o
+	//    | +---+ |
+	//    | +---+ |
+	//    +-+"b"+-+
+	//      +---+
+}